@@ -0,0 +1,264 @@
+// Package aider reads the chat history aider writes in the directory it runs in, giving the model
+// used, the messages and the reasoning behind each commit aider made.
+package aider
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryFileName is the chat history aider writes at the root of the repository.
+const HistoryFileName = ".aider.chat.history.md"
+
+// BusyTimeout is how long a message without a reply counts as being worked on. aider writes
+// nothing to the history while it waits for the model, so a message left unanswered longer
+// than this is assumed to have been interrupted.
+const BusyTimeout = 10 * time.Minute
+
+// MaxReplyLength limits the reply kept for each commit.
+const MaxReplyLength = 2000
+
+// ErrNoHistory is returned when there is no aider chat history in a directory.
+var ErrNoHistory = errors.New("no aider chat history found")
+
+// History is the chat history of the aider sessions started in a directory.
+type History struct {
+	Path string `json:"path"`
+	// Model and WeakModel are the models of the latest session.
+	Model     string          `json:"model,omitempty"`
+	WeakModel string          `json:"weak_model,omitempty"`
+	Messages  []Message       `json:"messages"`
+	Commits   []HistoryCommit `json:"commits"`
+	// Pending is set when the last message of the user has no reply yet.
+	Pending bool      `json:"pending"`
+	Updated time.Time `json:"updated_at"`
+}
+
+// Message is a message of the user or of the model.
+type Message struct {
+	// Role is "user" or "assistant".
+	Role string `json:"role"`
+	Text string `json:"text"`
+}
+
+// HistoryCommit is a commit aider made, with the request and reply that led to it.
+type HistoryCommit struct {
+	// SHA is the abbreviated hash aider prints.
+	SHA     string `json:"sha"`
+	Subject string `json:"subject"`
+	Request string `json:"request,omitempty"`
+	Reply   string `json:"reply,omitempty"`
+}
+
+// Busy reports whether aider is working on a message at now.
+func (h *History) Busy(now time.Time) bool {
+	return h.Pending && now.Sub(h.Updated) < BusyTimeout
+}
+
+// FindCommit returns the commit of the history with the full hash sha.
+func (h *History) FindCommit(sha string) (HistoryCommit, bool) {
+	for i := len(h.Commits) - 1; i >= 0; i-- {
+		if c := h.Commits[i]; c.SHA != "" && strings.HasPrefix(sha, c.SHA) {
+			return c, true
+		}
+	}
+	return HistoryCommit{}, false
+}
+
+// Load returns the chat history in dir. Files are parsed once and only their new lines are read on
+// later calls.
+func Load(dir string) (*History, error) {
+	return LoadFile(filepath.Join(dir, HistoryFileName))
+}
+
+// cachedFile is a history file parsed up to offset.
+type cachedFile struct {
+	offset int64
+	parser *parser
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]*cachedFile)
+)
+
+// LoadFile returns the chat history in the file at path.
+func LoadFile(path string) (*History, error) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoHistory
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open aider history: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open aider history: %w", err)
+	}
+
+	cached, ok := cache[path]
+	if !ok || info.Size() < cached.offset {
+		// New, or rewritten since it was parsed
+		cached = &cachedFile{parser: newParser(path)}
+		cache[path] = cached
+	}
+	if info.Size() > cached.offset {
+		if _, err := f.Seek(cached.offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to read aider history: %w", err)
+		}
+		n, err := cached.parser.parse(f, false)
+		cached.offset += n
+		if err != nil {
+			return nil, err
+		}
+	}
+	cached.parser.history.Updated = info.ModTime()
+	return cached.parser.snapshot(), nil
+}
+
+// Parse reads a whole chat history from r.
+func Parse(r io.Reader) (*History, error) {
+	p := newParser("")
+	if _, err := p.parse(r, true); err != nil {
+		return nil, err
+	}
+	return p.snapshot(), nil
+}
+
+// parser builds a history from the lines of a history file.
+type parser struct {
+	history *History
+	// request is the user message the next commit is made for
+	request string
+	// inRequest is set after a "#### " line, as aider writes each line of a message on its own
+	inRequest bool
+}
+
+func newParser(path string) *parser {
+	return &parser{history: &History{Path: path, Messages: []Message{}, Commits: []HistoryCommit{}}}
+}
+
+// parse reads the complete lines of r and returns the number of bytes they take. Unless whole is
+// set, a last line without a newline is still being written and is left for the next call.
+func (p *parser) parse(r io.Reader, whole bool) (int64, error) {
+	reader := bufio.NewReader(r)
+	var read int64
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && (!whole || len(line) == 0) {
+			return read, nil
+		}
+		if err != nil && err != io.EOF {
+			return read, fmt.Errorf("failed to read aider history: %w", err)
+		}
+		read += int64(len(line))
+		p.add(strings.TrimRight(line, "\r\n"))
+	}
+}
+
+// add reads a line of the history. aider writes the messages of the user as "#### " lines, its own
+// output as "> " lines and the replies of the model as plain markdown.
+func (p *parser) add(line string) {
+	h := p.history
+	continued := p.inRequest
+	p.inRequest = strings.HasPrefix(line, "#### ")
+	switch {
+	case strings.HasPrefix(line, "# aider chat started at"):
+		h.Pending = false
+		p.request = ""
+	case strings.HasPrefix(line, "#### "):
+		text := strings.TrimPrefix(line, "#### ")
+		if last := p.last(); continued && !strings.HasPrefix(last.Text, "/") {
+			last.Text += "\n" + text
+		} else {
+			h.Messages = append(h.Messages, Message{Role: "user", Text: text})
+		}
+		// Commands such as /add get no reply from the model
+		if last := p.last(); !strings.HasPrefix(last.Text, "/") {
+			h.Pending = true
+			p.request = last.Text
+		}
+	case strings.HasPrefix(line, ">"):
+		p.addOutput(strings.TrimSpace(strings.TrimPrefix(line, ">")))
+	case strings.TrimSpace(line) == "":
+		if last := p.last(); last != nil && last.Role == "assistant" {
+			last.Text += "\n"
+		}
+	default:
+		h.Pending = false
+		if last := p.last(); last != nil && last.Role == "assistant" {
+			last.Text += "\n" + line
+		} else {
+			h.Messages = append(h.Messages, Message{Role: "assistant", Text: line})
+		}
+	}
+}
+
+// addOutput reads a line aider printed.
+func (p *parser) addOutput(text string) {
+	h := p.history
+	switch {
+	case strings.HasPrefix(text, "Main model:"), strings.HasPrefix(text, "Model:"):
+		h.Model = modelName(text)
+	case strings.HasPrefix(text, "Weak model:"):
+		h.WeakModel = modelName(text)
+	case strings.HasPrefix(text, "Tokens:"):
+		h.Pending = false
+	case strings.HasPrefix(text, "Commit "):
+		fields := strings.SplitN(strings.TrimPrefix(text, "Commit "), " ", 2)
+		commit := HistoryCommit{SHA: fields[0], Request: p.request}
+		if len(fields) == 2 {
+			commit.Subject = fields[1]
+		}
+		if last := p.last(); last != nil && last.Role == "assistant" {
+			commit.Reply = truncate(strings.TrimSpace(last.Text), MaxReplyLength)
+		}
+		h.Commits = append(h.Commits, commit)
+		h.Pending = false
+	}
+}
+
+// last returns the last message, or nil before the first one.
+func (p *parser) last() *Message {
+	if len(p.history.Messages) == 0 {
+		return nil
+	}
+	return &p.history.Messages[len(p.history.Messages)-1]
+}
+
+// snapshot returns a copy of the history that later lines don't change.
+func (p *parser) snapshot() *History {
+	h := *p.history
+	h.Messages = append([]Message{}, p.history.Messages...)
+	h.Commits = append([]HistoryCommit{}, p.history.Commits...)
+	for i := range h.Messages {
+		h.Messages[i].Text = strings.TrimSpace(h.Messages[i].Text)
+	}
+	return &h
+}
+
+// modelName returns the model of a "Main model: gpt-4o with diff edit format" line.
+func modelName(text string) string {
+	_, name, _ := strings.Cut(text, ":")
+	name, _, _ = strings.Cut(strings.TrimSpace(name), " with ")
+	return strings.TrimSpace(name)
+}
+
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + "…"
+}
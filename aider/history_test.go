@@ -0,0 +1,104 @@
+package aider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const historyLines = `
+# aider chat started at 2025-06-01 12:00:00
+
+> /usr/bin/aider --model gpt-4o
+> Aider v0.80.0
+> Main model: gpt-4o with diff edit format
+> Weak model: gpt-4o-mini
+> Git repo: .git with 12 files
+
+#### /add login.go
+> Added login.go to the chat
+
+#### Fix the login redirect
+#### It should go back to the page the user came from.
+
+The handler drops the next parameter, keep it.
+
+login.go
+` + "```go\nreturn next\n```" + `
+
+> Tokens: 2.3k sent, 150 received. Cost: $0.01 message, $0.01 session.
+> Applied edit to login.go
+> Commit 1a2b3c4 fix: Keep the next parameter on login
+
+#### Add a test for it
+`
+
+func TestParse(t *testing.T) {
+	h, err := Parse(strings.NewReader(historyLines))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if h.Model != "gpt-4o" || h.WeakModel != "gpt-4o-mini" {
+		t.Errorf("models = %q, %q", h.Model, h.WeakModel)
+	}
+	if len(h.Messages) != 4 {
+		t.Fatalf("messages = %+v, want 4", h.Messages)
+	}
+	if m := h.Messages[1]; m.Role != "user" || m.Text != "Fix the login redirect\nIt should go back to the page the user came from." {
+		t.Errorf("request = %+v", m)
+	}
+	if m := h.Messages[2]; m.Role != "assistant" || !strings.HasPrefix(m.Text, "The handler drops") {
+		t.Errorf("reply = %+v", m)
+	}
+	if len(h.Commits) != 1 {
+		t.Fatalf("commits = %+v, want 1", h.Commits)
+	}
+	commit := h.Commits[0]
+	if commit.SHA != "1a2b3c4" || commit.Subject != "fix: Keep the next parameter on login" ||
+		!strings.HasPrefix(commit.Request, "Fix the login redirect") || !strings.HasPrefix(commit.Reply, "The handler drops") {
+		t.Errorf("commit = %+v", commit)
+	}
+	if c, ok := h.FindCommit("1a2b3c4d5e6f"); !ok || c.SHA != "1a2b3c4" {
+		t.Errorf("FindCommit() = %+v, %v", c, ok)
+	}
+	if !h.Pending {
+		t.Error("Pending = false, want the last message waiting for a reply")
+	}
+}
+
+func TestLoadBusy(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Load(dir); err != ErrNoHistory {
+		t.Errorf("Load() without a history error = %v, want ErrNoHistory", err)
+	}
+
+	path := filepath.Join(dir, HistoryFileName)
+	lines := strings.SplitAfter(historyLines, "\n")
+	// Up to the request that gets a reply
+	if err := os.WriteFile(path, []byte(strings.Join(lines[:14], "")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if !h.Busy(time.Now()) {
+		t.Errorf("Busy() = false with the request unanswered, history %+v", h)
+	}
+	if h.Busy(time.Now().Add(BusyTimeout)) {
+		t.Error("Busy() = true after the timeout")
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines[:len(lines)-2], "")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h, err = Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if h.Busy(time.Now()) || len(h.Commits) != 1 {
+		t.Errorf("Busy() = %v, commits %+v; want the reply and commit read", h.Busy(time.Now()), h.Commits)
+	}
+}
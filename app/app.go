@@ -4,15 +4,19 @@ import (
 	"claude-squad/config"
 	"claude-squad/keys"
 	"claude-squad/log"
+	"claude-squad/network"
+	"claude-squad/prompt"
 	"claude-squad/session"
+	"claude-squad/session/git"
 	"claude-squad/ui"
 	"claude-squad/ui/overlay"
 	"claude-squad/web"
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -28,7 +32,9 @@ func Run(ctx context.Context, startOptions StartOptions) error {
 		newHome(ctx, startOptions),
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(), // Mouse scroll
+		tea.WithReportFocus(),     // Suspend polling while unfocused
 	)
+	defer subscribeEdits(p)()
 	_, err := p.Run()
 	return err
 }
@@ -43,13 +49,58 @@ const (
 	statePrompt
 	// stateHelp is the state when a help screen is displayed.
 	stateHelp
+	// stateSwapProgram is the state when the user is entering a program to swap into an instance.
+	stateSwapProgram
+	// stateDetail is the state when the instance detail overlay is displayed.
+	stateDetail
+	// stateRetry is the state when the user is choosing the program to retry an instance with.
+	stateRetry
+	// stateSnapshots is the state when the snapshots of an instance are listed for rollback.
+	stateSnapshots
+	// statePushBlocked is the state when a push blocked by its checks awaits an override.
+	statePushBlocked
+	// stateNotes is the state when the user is editing the notes of an instance.
+	stateNotes
+	// stateTicket is the state when the user is editing the ticket reference of an instance.
+	stateTicket
+	// stateTemplates is the state when the prompt templates are listed to pick one.
+	stateTemplates
+	// stateSessionTemplates is the state when the session templates are listed to pick one for a
+	// new instance.
+	stateSessionTemplates
+	// stateTemplateVars is the state when the user is entering a variable of a prompt template.
+	stateTemplateVars
+	// statePromptWarning is the state when a prompt flagged by its checks awaits an override.
+	statePromptWarning
+	// stateAttachFiles is the state when the user is picking files to attach to a prompt.
+	stateAttachFiles
+	// stateColor is the state when the user is editing the accent color of an instance.
+	stateColor
+	// stateTags is the state when the user is editing the tags of an instance.
+	stateTags
+	// stateRunCommand is the state when the user is entering a command to run in an instance.
+	stateRunCommand
+	// stateRunOutput is the state when the output of a command run in an instance is shown.
+	stateRunOutput
+	// stateAutoYes is the state when the user is entering how long to turn auto-yes on for.
+	stateAutoYes
+	// stateSlashCommands is the state when the slash commands are listed to send one.
+	stateSlashCommands
+	// stateBranch is the state when the user is entering the existing branch of a new instance.
+	stateBranch
+	// stateDiffBase is the state when the user is editing what the diff of an instance compares with.
+	stateDiffBase
+	// stateUndoCommit is the state when the user is choosing to revert or drop a commit.
+	stateUndoCommit
+	// stateSquash is the state when the user is editing the message of a squashed push.
+	stateSquash
 )
 
 type home struct {
 	ctx context.Context
 
-	program string
-	autoYes bool
+	// factory creates new instances with the program and auto-yes setting from the config and flags.
+	factory    *session.Factory
 	simpleMode bool
 
 	// ui components
@@ -57,6 +108,7 @@ type home struct {
 	menu         *ui.Menu
 	tabbedWindow *ui.TabbedWindow
 	errBox       *ui.ErrBox
+	activity     *ui.ActivityFeed
 	// global spinner instance. we plumb this down to where it's needed
 	spinner spinner.Model
 
@@ -75,9 +127,50 @@ type home struct {
 	// newInstanceFinalizer is called when the state is stateNew and then you press enter.
 	// It registers the new instance in the list after the instance has been started.
 	newInstanceFinalizer func()
+	// starting holds the instances starting in the background
+	starting map[*session.Instance]*pendingStart
+	// jobs are the git and tmux jobs running in the background and queued, oldest first
+	jobs      []*job
+	nextJobID int
+	// quitConfirmed is set when q was pressed while jobs were running, so the next q quits
+	quitConfirmed bool
+	// daemonWatching holds the titles of the instances a running daemon watches, as of
+	// daemonCheckedAt
+	daemonWatching  map[string]bool
+	daemonCheckedAt time.Time
 
 	// promptAfterName tracks if we should enter prompt mode after naming
 	promptAfterName bool
+	// retryPrompt prefills the prompt entered after naming a retry, a reviewer or an instance
+	// from a session template
+	retryPrompt string
+	// compareWith is the title of the instance marked for comparison with C
+	compareWith string
+	// snapshots are the snapshots listed in stateSnapshots, numbered from 1
+	snapshots []git.Snapshot
+	// undoCommit is the commit offered for revert or drop in stateUndoCommit
+	undoCommit git.Commit
+	// blockedPush is the push awaiting an override in statePushBlocked
+	blockedPush *blockedPush
+	// queuedPushes are the pushes waiting for the network to come back, oldest first
+	queuedPushes []*blockedPush
+	// templates is the prompt template store, nil if the config directory is unavailable
+	templates *prompt.Store
+	// templateFill is the template being picked and filled in stateTemplates and stateTemplateVars
+	templateFill *templateFill
+	// sessionTemplatePick is the session template being picked in stateSessionTemplates
+	sessionTemplatePick *sessionTemplatePick
+	// flaggedPrompt is the prompt awaiting an override in statePromptWarning
+	flaggedPrompt string
+	// attachDraft is the prompt being written while files are picked in stateAttachFiles
+	attachDraft string
+	// unfocused is true while the terminal window reports it lost focus
+	unfocused bool
+	// previewTickStopped is true when the preview tick wasn't rescheduled because polling is
+	// suspended
+	previewTickStopped bool
+	// detached is set when the user detaches from an instance, to resume polling
+	detached bool
 
 	// textInputOverlay is the component for handling text input with state
 	textInputOverlay *overlay.TextInputOverlay
@@ -85,6 +178,19 @@ type home struct {
 	// textOverlay is the component for displaying text information
 	textOverlay *overlay.TextOverlay
 
+	// commandEditor is the component for editing the program command of an instance
+	commandEditor *overlay.CommandEditorOverlay
+
+	// filePicker is the component for picking files to attach to a prompt
+	filePicker *overlay.FilePickerOverlay
+
+	// runOutput shows the output of shownRun, a command run in an instance, in stateRunOutput
+	runOutput *overlay.RunOutputOverlay
+	shownRun  *session.CommandRun
+
+	// jobsOverlay shows the jobs running in the background
+	jobsOverlay *overlay.JobsOverlay
+
 	// keySent is used to manage underlining menu items
 	keySent bool
 }
@@ -117,21 +223,32 @@ func newHome(ctx context.Context, startOptions StartOptions) *home {
 		appConfig.WebServerPort = startOptions.WebServerPort
 	}
 
+	if startOptions.WebServerSocket != "" {
+		appConfig.WebServerSocket = startOptions.WebServerSocket
+	}
+
 	h := &home{
 		ctx:          ctx,
 		spinner:      spinner.New(spinner.WithSpinner(spinner.MiniDot)),
 		menu:         ui.NewMenu(),
-		tabbedWindow: ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane()),
+		tabbedWindow: ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane(), ui.NewConversationPane(!appConfig.DisableConversations), ui.NewCommitsPane(), ui.NewPromptsPane()),
 		errBox:       ui.NewErrBox(),
+		activity:     ui.NewActivityFeed(),
 		storage:      storage,
 		appConfig:    appConfig,
-		program:      startOptions.Program,
-		autoYes:      startOptions.AutoYes,
+		factory:      startOptions.Factory,
 		simpleMode:   startOptions.SimpleMode,
 		state:        stateDefault,
 		appState:     appState,
+		starting:     make(map[*session.Instance]*pendingStart),
+	}
+	h.list = ui.NewList(&h.spinner, startOptions.Factory.AutoYes)
+	h.jobsOverlay = overlay.NewJobsOverlay(&h.spinner)
+	if templates, err := prompt.NewStore(); err != nil {
+		log.ErrorLog.Printf("prompt templates unavailable: %v", err)
+	} else {
+		h.templates = templates
 	}
-	h.list = ui.NewList(&h.spinner, startOptions.AutoYes)
 
 	// Check if we're in simple mode
 	if startOptions.SimpleMode {
@@ -158,9 +275,7 @@ func newHome(ctx context.Context, startOptions StartOptions) *home {
 						// Add the existing instances to the list
 						for _, existingInstance := range instances {
 							h.list.AddInstance(existingInstance)()
-							if startOptions.AutoYes {
-								existingInstance.AutoYes = true
-							}
+							h.factory.Apply(existingInstance)
 						}
 						
 						return h
@@ -184,12 +299,11 @@ func newHome(ctx context.Context, startOptions StartOptions) *home {
 		instanceName := fmt.Sprintf("simple-%s", time.Now().Format("20060102-150405"))
 		
 		// Create a new instance that runs in-place (no worktree)
-		instance, err := session.NewInstance(session.InstanceOptions{
-			Title:     instanceName,
-			Path:      currentDir,
-			Program:   startOptions.Program,
-			AutoYes:   true,
-			InPlace:   true,
+		instance, err := h.factory.New(session.InstanceOptions{
+			Title:   instanceName,
+			Path:    currentDir,
+			AutoYes: true,
+			InPlace: true,
 		})
 		if err != nil {
 			// Use the proper error handling mechanism
@@ -229,8 +343,9 @@ func newHome(ctx context.Context, startOptions StartOptions) *home {
 			h.textInputOverlay = overlay.NewTextInputOverlay("Enter prompt", "")
 		}
 	} else {
-		// Standard mode - load saved instances
-		instances, err := storage.LoadInstances()
+		// Standard mode - load saved instances. They are shown straight away and reattached to their
+		// tmux sessions in the background by Init.
+		instances, err := storage.LoadInstanceMetadata()
 		if err != nil {
 			// Use the proper error handling mechanism
 			h.errBox.SetError(fmt.Errorf("Failed to load instances: %w", err))
@@ -241,9 +356,7 @@ func newHome(ctx context.Context, startOptions StartOptions) *home {
 		for _, instance := range instances {
 			// Call the finalizer immediately.
 			h.list.AddInstance(instance)()
-			if startOptions.AutoYes {
-				instance.AutoYes = true
-			}
+			h.factory.Apply(instance)
 		}
 	}
 	
@@ -258,18 +371,11 @@ func newHome(ctx context.Context, startOptions StartOptions) *home {
 				h.errBox.SetError(fmt.Errorf("Failed to start React web server: %w", err))
 			} else {
 				// Update menu with web server info with React UI indicator
-				h.menu.SetWebServerInfo(true, appConfig.WebServerHost, appConfig.WebServerPort)
-				log.InfoLog.Printf("React web UI available at http://%s:%d/", 
-					appConfig.WebServerHost, appConfig.WebServerPort)
+				h.menu.SetWebServerInfo(true, appConfig.WebServerAddress())
+				log.InfoLog.Printf("React web UI available at %s", appConfig.WebServerAddress())
 				
 				// Also log to standard error for visibility
-				hostToDisplay := "localhost"
-				if appConfig.WebServerHost != "" {
-					hostToDisplay = appConfig.WebServerHost
-				}
-				fmt.Printf("\nReact web UI available: http://%s:%d/\n", 
-					hostToDisplay, 
-					appConfig.WebServerPort)
+				fmt.Printf("\nReact web UI available: %s\n", appConfig.WebServerAddress())
 			}
 		} else {
 			// Standard web server
@@ -277,7 +383,7 @@ func newHome(ctx context.Context, startOptions StartOptions) *home {
 				h.errBox.SetError(fmt.Errorf("Failed to start web server: %w", err))
 			} else {
 				// Update menu with web server info
-				h.menu.SetWebServerInfo(true, appConfig.WebServerHost, appConfig.WebServerPort)
+				h.menu.SetWebServerInfo(true, appConfig.WebServerAddress())
 			}
 		}
 	}
@@ -305,6 +411,13 @@ func (m *home) updateHandleWindowSizeEvent(msg tea.WindowSizeMsg) {
 	menuHeight := msg.Height - contentHeight - 1     // minus 1 for error box
 	m.errBox.SetSize(int(float32(msg.Width)*0.9), 1) // error box takes 1 row
 
+	// The activity feed, when open, takes a quarter of the list and window's height
+	if m.activity.Visible() {
+		activityHeight := max(contentHeight/4, 5)
+		contentHeight -= activityHeight
+		m.activity.SetSize(msg.Width, activityHeight)
+	}
+
 	m.tabbedWindow.SetSize(tabsWidth, contentHeight)
 	m.list.SetSize(listWidth, contentHeight)
 
@@ -314,6 +427,15 @@ func (m *home) updateHandleWindowSizeEvent(msg tea.WindowSizeMsg) {
 	if m.textOverlay != nil {
 		m.textOverlay.SetWidth(int(float32(msg.Width) * 0.6))
 	}
+	if m.commandEditor != nil {
+		m.commandEditor.SetWidth(int(float32(msg.Width) * 0.6))
+	}
+	if m.filePicker != nil {
+		m.filePicker.SetWidth(int(float32(msg.Width) * 0.6))
+	}
+	if m.runOutput != nil {
+		m.runOutput.SetSize(int(float32(msg.Width)*0.8), int(float32(msg.Height)*0.8))
+	}
 
 	previewWidth, previewHeight := m.tabbedWindow.GetPreviewSize()
 	if err := m.list.SetSessionPreviewSize(previewWidth, previewHeight); err != nil {
@@ -327,6 +449,7 @@ func (m *home) Init() tea.Cmd {
 	// update the spinner, which sends a new spinner.TickMsg. I think this lasts forever lol.
 	return tea.Batch(
 		m.spinner.Tick,
+		m.restoreInstances(),
 		func() tea.Msg {
 			time.Sleep(100 * time.Millisecond) // Initial quick update
 			// Subsequent updates will be slower to reduce load
@@ -341,11 +464,15 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case hideErrMsg:
 		m.errBox.Clear()
 	case previewTickMsg:
+		if m.pollingSuspended() {
+			m.previewTickStopped = true
+			return m, nil
+		}
 		cmd := m.instanceChanged()
 		// Reduce polling frequency after initial fast updates
-		delay := 500 * time.Millisecond // Slower general polling rate
+		delay := m.appConfig.Intervals.PreviewTick()
 		if msg.isInitial {
-			delay = 250 * time.Millisecond // A bit faster for the first few ticks
+			delay /= 2 // A bit faster for the first few ticks
 		}
 		return m, tea.Batch(
 			cmd,
@@ -357,9 +484,38 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case keyupMsg:
 		m.menu.ClearKeydown()
 		return m, nil
+	case runTickMsg:
+		return m, m.handleRunTick(msg)
+	case costReadMsg:
+		return m, m.handleCostRead(msg)
+	case instanceStartedMsg:
+		return m.handleInstanceStarted(msg)
+	case jobDoneMsg:
+		return m, m.handleJobDone(msg)
+	case instanceRestoredMsg:
+		return m, m.instanceChanged()
+	case instanceEditedMsg:
+		return m, m.handleInstanceEdited(msg)
+	case instanceCreatedMsg:
+		return m, m.handleInstanceCreated(msg)
+	case instanceReloadedMsg:
+		return m, m.handleInstanceReloaded(msg)
 	case tickUpdateMetadataMessage:
+		statusChanged := false
 		for _, instance := range m.list.GetInstances() {
-			if !instance.Started() || instance.Paused() {
+			if !instance.Started() || instance.Paused() || instance.Status == session.Loading ||
+				m.jobRunning(instance) {
+				continue
+			}
+			previousStatus := instance.Status
+			if instance.CheckExited() {
+				// A daemon watching the instance restarts the program itself
+				if !m.daemonWatches(instance) {
+					if _, err := instance.AutoRestart(time.Now()); err != nil {
+						log.WarningLog.Printf("could not restart: %v", err)
+					}
+				}
+				statusChanged = statusChanged || instance.Status != previousStatus
 				continue
 			}
 			// Capture content once, then use it for updates
@@ -370,36 +526,57 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				continue
 			}
 			updated, prompt := instance.HasUpdated(currentContent)
+			instance.SetPrompt(prompt)
 			if updated {
 				instance.SetStatus(session.Running)
 			} else if !prompt { // If not updated and not a prompt, it's ready
 				instance.SetStatus(session.Ready)
 			}
-			if prompt && instance.AutoYes { // AutoYes logic for prompts
+			if instance.CheckAutoYesExpired(time.Now()) {
+				m.errBox.SetInfo(fmt.Sprintf("Auto-yes expired for %s, its prompts need approval again", instance.Title))
+				statusChanged = true
+			}
+			if prompt && instance.AutoYes && !m.daemonWatches(instance) { // AutoYes logic for prompts
 				instance.TapEnter()
 			}
+			statusChanged = statusChanged || instance.Status != previousStatus
+			if m.pollingSuspended() {
+				continue
+			}
 			if err := instance.UpdateDiffStats(); err != nil {
 				log.WarningLog.Printf("could not update diff stats: %v", err)
 			}
 		}
-		return m, tickUpdateMetadataCmd
+		// Keep the saved statuses current for readers of the state, such as cs status
+		if statusChanged {
+			if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+				log.WarningLog.Printf("could not save instance statuses: %v", err)
+			}
+		}
+		return m, tea.Batch(m.checkNetwork(), tickUpdateMetadataCmd)
+	case tea.FocusMsg:
+		return m, m.handleFocus(true)
+	case tea.BlurMsg:
+		return m, m.handleFocus(false)
 	case tea.MouseMsg:
-		// Handle mouse wheel scrolling in the diff view
-		if m.tabbedWindow.IsInDiffTab() {
-			if msg.Action == tea.MouseActionPress {
-				switch msg.Button {
-				case tea.MouseButtonWheelUp:
-					m.tabbedWindow.ScrollUp()
-					return m, m.instanceChanged()
-				case tea.MouseButtonWheelDown:
-					m.tabbedWindow.ScrollDown()
-					return m, m.instanceChanged()
-				}
+		// Handle mouse wheel scrolling in the preview and diff views
+		if msg.Action == tea.MouseActionPress {
+			switch msg.Button {
+			case tea.MouseButtonWheelUp:
+				m.tabbedWindow.ScrollUp()
+				return m, m.instanceChanged()
+			case tea.MouseButtonWheelDown:
+				m.tabbedWindow.ScrollDown()
+				return m, m.instanceChanged()
 			}
 		}
 		return m, nil
 	case tea.KeyMsg:
-		return m.handleKeyPress(msg)
+		model, cmd := m.handleKeyPress(msg)
+		if m.detached {
+			cmd = tea.Batch(cmd, m.afterDetach())
+		}
+		return model, cmd
 	case tea.WindowSizeMsg:
 		m.updateHandleWindowSizeEvent(msg)
 		return m, nil
@@ -412,6 +589,13 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *home) handleQuit() (tea.Model, tea.Cmd) {
+	// Quitting would stop the running jobs halfway, such as a push or a pause
+	if running := m.runningJobs(); running > 0 && !m.quitConfirmed {
+		m.quitConfirmed = true
+		m.errBox.SetInfo(fmt.Sprintf("%d jobs are still running, press q again to quit anyway", running))
+		return m, nil
+	}
+
 	// Save instances before quitting
 	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
 		return m, m.handleError(err)
@@ -452,7 +636,14 @@ func (m *home) handleMenuHighlighting(msg tea.KeyMsg) (cmd tea.Cmd, returnEarly
 		m.keySent = false
 		return nil, false
 	}
-	if m.state == statePrompt || m.state == stateHelp {
+	if m.state == stateNew || m.state == statePrompt || m.state == stateHelp || m.state == stateSwapProgram ||
+		m.state == stateDetail || m.state == stateRetry || m.state == stateSnapshots ||
+		m.state == statePushBlocked || m.state == stateNotes || m.state == stateTicket ||
+		m.state == stateTemplates || m.state == stateSessionTemplates || m.state == stateTemplateVars || m.state == statePromptWarning ||
+		m.state == stateAttachFiles || m.state == stateColor || m.state == stateTags || m.state == stateRunCommand ||
+		m.state == stateRunOutput || m.state == stateAutoYes || m.state == stateSlashCommands ||
+		m.state == stateBranch || m.state == stateDiffBase || m.state == stateUndoCommit ||
+		m.state == stateSquash {
 		return nil, false
 	}
 	// If it's in the global keymap, we should try to highlight it.
@@ -468,11 +659,6 @@ func (m *home) handleMenuHighlighting(msg tea.KeyMsg) (cmd tea.Cmd, returnEarly
 		return nil, false
 	}
 
-	// Skip the menu highlighting if the key is not in the map or we are using the shift up and down keys.
-	// TODO: cleanup: when you press enter on stateNew, we use keys.KeySubmitName. We should unify the keymap.
-	if name == keys.KeyEnter && m.state == stateNew {
-		name = keys.KeySubmitName
-	}
 	m.keySent = true
 	return tea.Batch(
 		func() tea.Msg { return msg },
@@ -489,11 +675,93 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		return m.handleHelpState(msg)
 	}
 
+	if m.state == stateDetail {
+		return m.handleDetailState(msg)
+	}
+
+	if m.state == stateSnapshots {
+		return m.handleSnapshotsState(msg)
+	}
+
+	if m.state == statePushBlocked {
+		return m.handlePushBlockedState(msg)
+	}
+
+	if m.state == stateNotes {
+		return m.handleNotesState(msg)
+	}
+
+	if m.state == stateTemplates {
+		return m.handleTemplatesState(msg)
+	}
+
+	if m.state == stateSessionTemplates {
+		return m.handleSessionTemplatesState(msg)
+	}
+
+	if m.state == statePromptWarning {
+		return m.handlePromptWarningState(msg)
+	}
+
+	if m.state == stateAttachFiles {
+		return m.handleAttachFilesState(msg)
+	}
+
+	if m.state == stateTemplateVars {
+		return m.handleTemplateVarsState(msg)
+	}
+
+	if m.state == stateTicket {
+		return m.handleTicketState(msg)
+	}
+
+	if m.state == stateColor {
+		return m.handleColorState(msg)
+	}
+
+	if m.state == stateTags {
+		return m.handleTagsState(msg)
+	}
+
+	if m.state == stateDiffBase {
+		return m.handleDiffBaseState(msg)
+	}
+
+	if m.state == stateUndoCommit {
+		return m.handleUndoCommitState(msg)
+	}
+
+	if m.state == stateSquash {
+		return m.handleSquashState(msg)
+	}
+
+	if m.state == stateRunCommand {
+		return m.handleRunCommandState(msg)
+	}
+
+	if m.state == stateRunOutput {
+		return m.handleRunOutputState(msg)
+	}
+
+	if m.state == stateAutoYes {
+		return m.handleAutoYesState(msg)
+	}
+
+	if m.state == stateSlashCommands {
+		return m.handleSlashCommandsState(msg)
+	}
+
+	if m.state == stateBranch {
+		return m.handleBranchState(msg)
+	}
+
 	if m.state == stateNew {
 		// Handle quit commands first. Don't handle q because the user might want to type that.
 		if msg.String() == "ctrl+c" {
 			m.state = stateDefault
 			m.promptAfterName = false
+			m.retryPrompt = ""
+			m.textInputOverlay = nil
 			m.list.Kill()
 			return m, tea.Sequence(
 				tea.WindowSize(),
@@ -505,63 +773,28 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		}
 
 		instance := m.list.GetInstances()[m.list.NumInstances()-1]
-		switch msg.Type {
-		// Start the instance (enable previews etc) and go back to the main menu state.
-		case tea.KeyEnter:
-			if len(instance.Title) == 0 {
-				return m, m.handleError(fmt.Errorf("title cannot be empty"))
-			}
-
-			if err := instance.Start(true); err != nil {
-				m.list.Kill()
-				m.state = stateDefault
-				return m, m.handleError(err)
-			}
-			// Save after adding new instance
-			if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
-				return m, m.handleError(err)
-			}
-			// Instance added successfully, call the finalizer.
-			m.newInstanceFinalizer()
-			if m.autoYes {
-				instance.AutoYes = true
-			}
+		shouldClose := m.textInputOverlay.HandleKeyPress(msg)
+		if err := instance.SetTitle(m.textInputOverlay.GetValue()); err != nil {
+			return m, m.handleError(err)
+		}
+		if !shouldClose {
+			return m, nil
+		}
+		submitted := m.textInputOverlay.IsSubmitted()
+		m.textInputOverlay = nil
 
-			m.newInstanceFinalizer()
+		switch {
+		// Start the instance (enable previews etc) and go back to the main menu state.
+		case submitted:
+			cmd := m.startInstance(instance)
 			m.state = stateDefault
-			if m.promptAfterName {
-				m.state = statePrompt
-				m.menu.SetState(ui.StatePrompt)
-				// Initialize the text input overlay
-				m.textInputOverlay = overlay.NewTextInputOverlay("Enter prompt", "")
-				m.promptAfterName = false
-			} else {
-				m.menu.SetState(ui.StateDefault)
-				m.showHelpScreen(helpTypeInstanceStart, nil)
-			}
-
-			return m, tea.Batch(tea.WindowSize(), m.instanceChanged())
-		case tea.KeyRunes:
-			if len(instance.Title) >= 32 {
-				return m, m.handleError(fmt.Errorf("title cannot be longer than 32 characters"))
-			}
-			if err := instance.SetTitle(instance.Title + string(msg.Runes)); err != nil {
-				return m, m.handleError(err)
-			}
-		case tea.KeyBackspace:
-			if len(instance.Title) == 0 {
-				return m, nil
-			}
-			if err := instance.SetTitle(instance.Title[:len(instance.Title)-1]); err != nil {
-				return m, m.handleError(err)
-			}
-		case tea.KeySpace:
-			if err := instance.SetTitle(instance.Title + " "); err != nil {
-				return m, m.handleError(err)
-			}
-		case tea.KeyEsc:
+			m.menu.SetState(ui.StateDefault)
+			return m, tea.Batch(cmd, tea.WindowSize(), m.instanceChanged())
+		default:
 			m.list.Kill()
 			m.state = stateDefault
+			m.promptAfterName = false
+			m.retryPrompt = ""
 			m.instanceChanged()
 
 			return m, tea.Sequence(
@@ -571,10 +804,14 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 					return nil
 				},
 			)
-		default:
 		}
-		return m, nil
 	} else if m.state == statePrompt {
+		switch msg.String() {
+		case "ctrl+t":
+			return m.showTemplates(true, m.textInputOverlay.GetValue())
+		case "ctrl+f":
+			return m.openFilePicker(m.textInputOverlay.GetValue())
+		}
 		// Use the new TextInputOverlay component to handle all key events
 		shouldClose := m.textInputOverlay.HandleKeyPress(msg)
 
@@ -586,25 +823,47 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 				if selected == nil {
 					return m, nil
 				}
-				if err := selected.SendPrompt(m.textInputOverlay.GetValue()); err != nil {
+				text := m.textInputOverlay.GetValue()
+				if warnings := m.lintPrompt(text); len(warnings) > 0 {
+					return m.warnPrompt(text, warnings)
+				}
+				if err := selected.SendPrompt(text); err != nil {
 					return m, m.handleError(err)
 				}
 			}
 
-			// Close the overlay and reset state
-			m.textInputOverlay = nil
-			m.state = stateDefault
-			return m, tea.Sequence(
-				tea.WindowSize(),
-				func() tea.Msg {
-					m.menu.SetState(ui.StateDefault)
-					m.showHelpScreen(helpTypeInstanceStart, nil)
-					return nil
-				},
-			)
+			return m, m.closePrompt()
 		}
 
 		return m, nil
+	} else if m.state == stateRetry {
+		return m.handleRetryState(msg)
+	} else if m.state == stateSwapProgram {
+		shouldClose := m.commandEditor.HandleKeyPress(msg)
+		if !shouldClose {
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		if m.commandEditor.IsSubmitted() {
+			if selected := m.list.GetSelectedInstance(); selected != nil {
+				if err := selected.SwapProgram(m.commandEditor.GetValue()); err != nil {
+					cmd = m.handleError(err)
+				} else if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+					cmd = m.handleError(err)
+				}
+			}
+		}
+
+		m.commandEditor = nil
+		m.state = stateDefault
+		return m, tea.Batch(cmd, tea.Sequence(
+			tea.WindowSize(),
+			func() tea.Msg {
+				m.menu.SetState(ui.StateDefault)
+				return nil
+			},
+		))
 	}
 
 	// Handle quit commands first
@@ -612,55 +871,39 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		return m.handleQuit()
 	}
 
+	if msg.Type == tea.KeyEsc {
+		selected := m.list.GetSelectedInstance()
+		if _, starting := m.starting[selected]; starting {
+			return m, m.cancelStart(selected)
+		}
+		cmd, _ := m.cancelJobs(selected)
+		return m, cmd
+	}
+
+	if action, ok := quickActionForKey(msg.String()); ok {
+		return m.runQuickAction(action)
+	}
+
 	name, ok := keys.GlobalKeyStringsMap[msg.String()]
 	if !ok {
 		return m, nil
 	}
+	if selected := m.list.GetSelectedInstance(); selected != nil && selected.Status == session.Loading &&
+		needsStartedInstance[name] {
+		return m, m.handleError(fmt.Errorf("%s is still starting, press esc to cancel", selected.Title))
+	}
+	if selected := m.list.GetSelectedInstance(); selected != nil && m.jobRunning(selected) &&
+		needsStartedInstance[name] && !jobKeys[name] {
+		return m, m.handleError(fmt.Errorf("%s is busy with a job, wait for it or press esc to cancel", selected.Title))
+	}
 
 	switch name {
 	case keys.KeyHelp:
 		return m.showHelpScreen(helpTypeGeneral, nil)
 	case keys.KeyPrompt:
-		if m.list.NumInstances() >= GlobalInstanceLimit {
-			return m, m.handleError(
-				fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
-		}
-		instance, err := session.NewInstance(session.InstanceOptions{
-			Title:   "",
-			Path:    ".",
-			Program: m.program,
-		})
-		if err != nil {
-			return m, m.handleError(err)
-		}
-
-		m.newInstanceFinalizer = m.list.AddInstance(instance)
-		m.list.SetSelectedInstance(m.list.NumInstances() - 1)
-		m.state = stateNew
-		m.menu.SetState(ui.StateNewInstance)
-		m.promptAfterName = true
-
-		return m, nil
+		return m.newInstance(true)
 	case keys.KeyNew:
-		if m.list.NumInstances() >= GlobalInstanceLimit {
-			return m, m.handleError(
-				fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
-		}
-		instance, err := session.NewInstance(session.InstanceOptions{
-			Title:   "",
-			Path:    ".",
-			Program: m.program,
-		})
-		if err != nil {
-			return m, m.handleError(err)
-		}
-
-		m.newInstanceFinalizer = m.list.AddInstance(instance)
-		m.list.SetSelectedInstance(m.list.NumInstances() - 1)
-		m.state = stateNew
-		m.menu.SetState(ui.StateNewInstance)
-
-		return m, nil
+		return m.newInstance(false)
 	case keys.KeyUp:
 		m.list.Up()
 		return m, m.instanceChanged()
@@ -668,135 +911,195 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		m.list.Down()
 		return m, m.instanceChanged()
 	case keys.KeyShiftUp:
-		if m.tabbedWindow.IsInDiffTab() {
-			m.tabbedWindow.ScrollUp()
-		}
+		m.tabbedWindow.ScrollUp()
 		return m, m.instanceChanged()
 	case keys.KeyShiftDown:
-		if m.tabbedWindow.IsInDiffTab() {
-			m.tabbedWindow.ScrollDown()
-		}
+		m.tabbedWindow.ScrollDown()
 		return m, m.instanceChanged()
 	case keys.KeyTab:
 		m.tabbedWindow.Toggle()
 		m.menu.SetInDiffTab(m.tabbedWindow.IsInDiffTab())
+		m.menu.SetInCommitsTab(m.tabbedWindow.IsInCommitsTab())
+		m.menu.SetInPromptsTab(m.tabbedWindow.IsInPromptsTab())
 		return m, m.instanceChanged()
+	case keys.KeyUndoCommit:
+		return m.openUndoCommit()
+	case keys.KeyShowCommit:
+		if err := m.tabbedWindow.ToggleCommitPatch(); err != nil {
+			return m, m.handleError(err)
+		}
+		return m, nil
+	case keys.KeyReplayPrompt:
+		return m.replayPrompt()
 	case keys.KeyKill:
 		selected := m.list.GetSelectedInstance()
 		if selected == nil {
 			return m, nil
 		}
 
-		worktree, err := selected.GetGitWorktree()
-		if err != nil {
-			return m, m.handleError(err)
-		}
-
-		checkedOut, err := worktree.IsBranchCheckedOut()
-		if err != nil {
-			return m, m.handleError(err)
-		}
-
-		if checkedOut {
-			return m, m.handleError(fmt.Errorf("instance %s is currently checked out", selected.Title))
-		}
-
-		// Delete from storage first
-		if err := m.storage.DeleteInstance(selected.Title); err != nil {
-			return m, m.handleError(err)
-		}
+		// Instances in place or in a directory copy have no branch that could be checked out
+		worktree, _ := selected.GetGitWorktree()
+		return m, m.runJob(selected, "Killing", func(ctx context.Context) error {
+			if worktree != nil {
+				checkedOut, err := worktree.IsBranchCheckedOut()
+				if err != nil {
+					return err
+				}
+				if checkedOut {
+					return fmt.Errorf("instance %s is currently checked out", selected.Title)
+				}
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 
-		// Then kill the instance
-		m.list.Kill()
-		return m, m.instanceChanged()
+			// Delete from storage first
+			if err := m.storage.DeleteInstance(selected.Title); err != nil {
+				return err
+			}
+			// Then kill the instance
+			if err := selected.Kill(); err != nil {
+				log.ErrorLog.Printf("could not kill instance: %v", err)
+			}
+			revokeTokens(selected.Title)
+			return nil
+		}, func(err error) tea.Cmd {
+			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					return nil
+				}
+				return m.handleError(err)
+			}
+			// Jobs queued behind the kill have nothing left to act on
+			cmd, _ := m.cancelJobs(selected)
+			m.list.RemoveKilled(selected)
+			return tea.Batch(cmd, m.instanceChanged())
+		})
 	case keys.KeySubmit:
 		selected := m.list.GetSelectedInstance()
 		if selected == nil {
 			return m, nil
 		}
 
-		// Default commit message with timestamp
-		commitMsg := fmt.Sprintf("[claudesquad] update from '%s' on %s", selected.Title, time.Now().Format(time.RFC822))
-		
-		// Handle Simple Mode differently - use direct git commands
-		if selected.InPlace {
-			// Execute git commands directly on the current directory
-			
-			// First check if there are any changes to commit
-			gitStatusCmd := exec.Command("git", "status", "--porcelain")
-			gitStatusCmd.Dir = selected.Path
-			statusOutput, err := gitStatusCmd.Output()
-			if err != nil {
-				return m, m.handleError(fmt.Errorf("failed to get git status: %w", err))
-			}
-			
-			// If no changes, show message and return
-			if len(statusOutput) == 0 {
-				return m, m.handleError(fmt.Errorf("no changes to commit"))
-			}
-			
-			// Add all changes
-			gitAddCmd := exec.Command("git", "add", ".")
-			gitAddCmd.Dir = selected.Path
-			if err := gitAddCmd.Run(); err != nil {
-				return m, m.handleError(fmt.Errorf("failed to stage changes: %w", err))
-			}
-			
-			// Commit changes
-			gitCommitCmd := exec.Command("git", "commit", "-m", commitMsg)
-			gitCommitCmd.Dir = selected.Path
-			if err := gitCommitCmd.Run(); err != nil {
-				return m, m.handleError(fmt.Errorf("failed to commit changes: %w", err))
-			}
-			
-			// Push changes
-			gitPushCmd := exec.Command("git", "push")
-			gitPushCmd.Dir = selected.Path
-			if err := gitPushCmd.Run(); err != nil {
-				return m, m.handleError(fmt.Errorf("failed to push changes: %w", err))
-			}
-			
-			// Show success message
-			m.errBox.SetInfo("Changes committed and pushed successfully")
-			return m, func() tea.Msg {
-				time.Sleep(3 * time.Second)
-				return hideErrMsg{}
-			}
-		} else {
-			// Standard mode - use worktree
-			worktree, err := selected.GetGitWorktree()
-			if err != nil {
+		// Commit message with timestamp, following the ticket template if the instance has one
+		commitMsg := selected.CommitMessage()
+		push := &blockedPush{instance: selected, commitMessage: commitMsg}
+		if !selected.InPlace {
+			if _, err := selected.GetGitWorktree(); err != nil {
 				return m, m.handleError(err)
 			}
-			if err = worktree.PushChanges(commitMsg, true); err != nil {
-				return m, m.handleError(err)
+		}
+		if network.Offline() {
+			// In Simple Mode the changes are committed now and pushed once the network is back
+			if selected.InPlace {
+				return m, m.runJob(selected, "Committing", func(context.Context) error {
+					return commitInPlace(selected.Path, commitMsg)
+				}, func(err error) tea.Cmd {
+					if err != nil {
+						return m.handleError(err)
+					}
+					return m.queuePush(push)
+				})
 			}
+			return m, m.queuePush(push)
 		}
-
-		return m, nil
+		return m, m.runPush(push, false)
+	case keys.KeySquash:
+		return m.openSquashEditor()
 	case keys.KeyCheckout:
 		selected := m.list.GetSelectedInstance()
 		if selected == nil {
 			return m, nil
 		}
 
-		// Show help screen before pausing
-		m.showHelpScreen(helpTypeInstanceCheckout, func() {
-			if err := selected.Pause(); err != nil {
-				m.handleError(err)
-			}
-			m.instanceChanged()
+		// Show help screen before pausing, which starts once it is closed
+		model, cmd := m.showHelpScreen(helpTypeInstanceCheckout, func() {
+			m.queueJob(selected, "Pausing", func(context.Context) error {
+				return selected.Pause()
+			}, func(err error) tea.Cmd {
+				if err != nil && !errors.Is(err, context.Canceled) {
+					return m.handleError(err)
+				}
+				return m.instanceChanged()
+			})
 		})
-		return m, nil
+		return model, tea.Batch(cmd, m.startJobs())
 	case keys.KeyResume:
 		selected := m.list.GetSelectedInstance()
 		if selected == nil {
 			return m, nil
 		}
-		if err := selected.Resume(); err != nil {
-			return m, m.handleError(err)
+		label := "Resuming"
+		if selected.Status == session.Exited {
+			label = "Restarting"
 		}
+		// Whether to restart or resume is decided when the job runs, after the jobs queued before it
+		return m, m.runJob(selected, label, func(context.Context) error {
+			if selected.Status == session.Exited {
+				return selected.Restart()
+			}
+			return selected.Resume()
+		}, func(err error) tea.Cmd {
+			if err != nil && !errors.Is(err, context.Canceled) {
+				return m.handleError(err)
+			}
+			return tea.Batch(tea.WindowSize(), m.instanceChanged())
+		})
+	case keys.KeySwapProgram:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Started() {
+			return m, nil
+		}
+		if selected.Paused() {
+			return m, m.handleError(fmt.Errorf("resume %s before swapping its program", selected.Title))
+		}
+		m.openCommandEditor("Program to run in "+selected.Title, selected.Program)
 		return m, tea.WindowSize()
+	case keys.KeyDetail:
+		return m.showDetail()
+	case keys.KeyFork:
+		return m.forkSelected()
+	case keys.KeyLineage:
+		return m.showLineage()
+	case keys.KeyRetry:
+		return m.retrySelected()
+	case keys.KeyReview:
+		return m.reviewSelected()
+	case keys.KeyCompare:
+		return m.compareSelected()
+	case keys.KeySnapshot:
+		return m.snapshotSelected()
+	case keys.KeyRollback:
+		return m.showSnapshots()
+	case keys.KeyTemplate:
+		return m.showTemplates(false, "")
+	case keys.KeyActivity:
+		m.activity.Toggle()
+		return m, tea.WindowSize()
+	case keys.KeyToggleGroup:
+		m.list.ToggleGroup()
+		return m, m.instanceChanged()
+	case keys.KeyNextWindow:
+		return m.nextWindow()
+	case keys.KeyShellWindow:
+		return m.openShellWindow()
+	case keys.KeyRunCommand:
+		return m.openRunCommand()
+	case keys.KeyAutoYes:
+		return m.toggleAutoYes()
+	case keys.KeySlash:
+		return m.showSlashCommands()
+	case keys.KeyNewOnBranch:
+		return m.openBranchInput()
+	case keys.KeySuspendAll:
+		suspended, err := session.ToggleSuspendAll(m.list.GetInstances())
+		if err != nil {
+			return m, m.handleError(err)
+		}
+		if !suspended {
+			m.errBox.SetInfo("All agents resumed")
+		}
+		return m, nil
 	case keys.KeyEnter:
 		if m.list.NumInstances() == 0 {
 			return m, nil
@@ -814,6 +1117,7 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			}
 			<-ch
 			m.state = stateDefault
+			m.detached = true
 		})
 		return m, nil
 	default:
@@ -828,6 +1132,14 @@ func (m *home) instanceChanged() tea.Cmd {
 	selected := m.list.GetSelectedInstance()
 
 	m.tabbedWindow.UpdateDiff(selected)
+	m.tabbedWindow.UpdateConversation(selected)
+	m.tabbedWindow.UpdateCommits(selected)
+	m.tabbedWindow.UpdatePrompts(selected)
+	if selected != nil {
+		m.tabbedWindow.SetAccentColor(selected.AccentColor())
+	} else {
+		m.tabbedWindow.SetAccentColor("")
+	}
 	// Update menu with current instance
 	m.menu.SetInstance(selected)
 
@@ -885,28 +1197,73 @@ func (m *home) handleError(err error) tea.Cmd {
 	}
 }
 
+var suspendedBannerStyle = lipgloss.NewStyle().
+	Bold(true).
+	Foreground(lipgloss.Color("#000000")).
+	Background(lipgloss.Color("#FFCC00"))
+
+// withSuspendedBanner replaces the padding row at the top of view with a banner saying that all
+// agents are suspended.
+func withSuspendedBanner(view string) string {
+	width := lipgloss.Width(view)
+	banner := suspendedBannerStyle.Width(width).Align(lipgloss.Center).
+		Render("ALL AGENTS SUSPENDED - press z to resume")
+	if idx := strings.Index(view, "\n"); idx >= 0 {
+		return banner + view[idx:]
+	}
+	return banner
+}
+
 func (m *home) View() string {
 	listWithPadding := lipgloss.NewStyle().PaddingTop(1).Render(m.list.String())
 	previewWithPadding := lipgloss.NewStyle().PaddingTop(1).Render(m.tabbedWindow.String())
 	listAndPreview := lipgloss.JoinHorizontal(lipgloss.Top, listWithPadding, previewWithPadding)
+	if session.Suspended() {
+		listAndPreview = withSuspendedBanner(listAndPreview)
+	}
 
+	views := []string{listAndPreview}
+	if m.activity.Visible() {
+		views = append(views, m.activity.String())
+	}
 	mainView := lipgloss.JoinVertical(
 		lipgloss.Center,
-		listAndPreview,
-		m.menu.String(),
-		m.errBox.String(),
+		append(views, m.menu.String(), m.errBox.String())...,
 	)
 
-	if m.state == statePrompt {
+	if m.state == stateNew || m.state == statePrompt || m.state == stateNotes || m.state == stateTicket ||
+		m.state == stateTemplateVars || m.state == stateColor || m.state == stateTags || m.state == stateAutoYes || m.state == stateBranch ||
+		m.state == stateDiffBase || m.state == stateSquash {
 		if m.textInputOverlay == nil {
 			log.ErrorLog.Printf("text input overlay is nil")
 		}
 		return overlay.PlaceOverlay(0, 0, m.textInputOverlay.Render(), mainView, true, true)
-	} else if m.state == stateHelp {
+	} else if m.state == stateSwapProgram || m.state == stateRetry || m.state == stateRunCommand {
+		if m.commandEditor == nil {
+			log.ErrorLog.Printf("command editor is nil")
+		}
+		return overlay.PlaceOverlay(0, 0, m.commandEditor.Render(), mainView, true, true)
+	} else if m.state == stateAttachFiles {
+		if m.filePicker == nil {
+			log.ErrorLog.Printf("file picker is nil")
+		}
+		return overlay.PlaceOverlay(0, 0, m.filePicker.Render(), mainView, true, true)
+	} else if m.state == stateRunOutput {
+		if m.runOutput == nil {
+			log.ErrorLog.Printf("run output overlay is nil")
+		}
+		return overlay.PlaceOverlay(0, 0, m.runOutput.Render(), mainView, true, true)
+	} else if m.state == stateHelp || m.state == stateDetail || m.state == stateSnapshots ||
+		m.state == statePushBlocked || m.state == stateTemplates || m.state == stateSessionTemplates ||
+		m.state == statePromptWarning || m.state == stateSlashCommands || m.state == stateUndoCommit {
 		if m.textOverlay == nil {
 			log.ErrorLog.Printf("text overlay is nil")
 		}
 		return overlay.PlaceOverlay(0, 0, m.textOverlay.Render(), mainView, true, true)
+	} else if m.jobsOverlay.Visible() {
+		// The jobs float in the top right corner, leaving the rest of the view usable
+		jobs := m.jobsOverlay.Render()
+		return overlay.PlaceFloating(lipgloss.Width(mainView)-lipgloss.Width(jobs)-1, 1, jobs, mainView)
 	}
 
 	return mainView
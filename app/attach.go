@@ -0,0 +1,63 @@
+package app
+
+import (
+	"claude-squad/prompt"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openFilePicker opens the picker of files to attach to draft, the prompt entered so far.
+func (m *home) openFilePicker(draft string) (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return m, nil
+	}
+	worktree, err := selected.GetGitWorktree()
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	files, err := worktree.ListFiles()
+	if err != nil {
+		return m, m.handleError(err)
+	}
+
+	m.attachDraft = draft
+	m.textInputOverlay = nil
+	m.filePicker = overlay.NewFilePickerOverlay("Attach files from "+selected.Title, files)
+	m.state = stateAttachFiles
+	return m, tea.WindowSize()
+}
+
+// handleAttachFilesState handles key presses in the file picker. Once files are picked they are
+// appended to the prompt, which is opened again either way.
+func (m *home) handleAttachFilesState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.filePicker.HandleKeyPress(msg) {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	text := m.attachDraft
+	if selected := m.list.GetSelectedInstance(); selected != nil && m.filePicker.IsSubmitted() {
+		worktree, err := selected.GetGitWorktree()
+		var attached string
+		if err == nil {
+			attached, err = prompt.AttachFiles(worktree.GetWorktreePath(), m.filePicker.Selected(), m.filePicker.PathsOnly)
+		}
+		if err != nil {
+			cmd = m.handleError(err)
+		} else if text != "" {
+			text += "\n\n" + attached
+		} else {
+			text = attached
+		}
+	}
+
+	m.attachDraft = ""
+	m.filePicker = nil
+	m.state = statePrompt
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewTextInputOverlay("Enter prompt", text)
+	return m, tea.Batch(cmd, tea.WindowSize())
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultAutoYesDuration prefills how long auto-yes is turned on for.
+const defaultAutoYesDuration = "30m"
+
+// toggleAutoYes turns auto-yes off in the selected instance if it is on, and otherwise asks how
+// long to turn it on for.
+func (m *home) toggleAutoYes() (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return m, nil
+	}
+	if selected.AutoYes {
+		selected.SetAutoYes(false, 0)
+		m.errBox.SetInfo(fmt.Sprintf("Auto-yes off for %s", selected.Title))
+		if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+			return m, m.handleError(err)
+		}
+		return m, nil
+	}
+	m.state = stateAutoYes
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewSingleLineInputOverlay("Auto-yes for "+selected.Title+
+		" for how long? (e.g. 30m or 2h, empty until turned off)", defaultAutoYesDuration, 16, validateAutoYesDuration)
+	return m, tea.WindowSize()
+}
+
+// parseAutoYesDuration parses how long to turn auto-yes on for. Empty means until it is turned off.
+func parseAutoYesDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("enter a duration such as 30m or 2h")
+	}
+	return d, nil
+}
+
+func validateAutoYesDuration(value string) error {
+	_, err := parseAutoYesDuration(value)
+	return err
+}
+
+// handleAutoYesState handles key presses in the auto-yes duration input and turns auto-yes on
+// once submitted.
+func (m *home) handleAutoYesState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	if m.textInputOverlay.IsSubmitted() {
+		if selected := m.list.GetSelectedInstance(); selected != nil {
+			d, _ := parseAutoYesDuration(m.textInputOverlay.GetValue())
+			selected.SetAutoYes(true, d)
+			m.errBox.SetInfo(fmt.Sprintf("%s: %s", selected.Title, selected.AutoYesDescription()))
+			if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+				cmd = m.handleError(err)
+			}
+		}
+	}
+	return m, tea.Batch(cmd, m.closeEditor())
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxBranchLength bounds the branch name typed for a new instance.
+const maxBranchLength = 200
+
+// openBranchInput asks for an existing branch, local or on a remote, to create a new instance on,
+// such as the branch of a pull request whose review comments the agent should address.
+func (m *home) openBranchInput() (tea.Model, tea.Cmd) {
+	if m.list.NumInstances() >= GlobalInstanceLimit {
+		return m, m.handleError(
+			fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
+	}
+	m.state = stateBranch
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewSingleLineInputOverlay("Existing branch to work on", "", maxBranchLength,
+		func(branch string) error {
+			return git.CheckExistingBranch(".", branch)
+		})
+	return m, tea.WindowSize()
+}
+
+// handleBranchState handles key presses in the branch input. Submitting creates an instance that
+// checks the branch out and asks for its name, suggesting one made from the branch.
+func (m *home) handleBranchState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+	branch := m.textInputOverlay.GetValue()
+	if !m.textInputOverlay.IsSubmitted() {
+		return m, m.closeEditor()
+	}
+
+	instance, err := m.factory.New(session.InstanceOptions{
+		Title:  "",
+		Path:   ".",
+		Branch: branch,
+	})
+	if err != nil {
+		return m, tea.Batch(m.closeEditor(), m.handleError(err))
+	}
+
+	m.newInstanceFinalizer = m.list.AddInstance(instance)
+	m.list.SetSelectedInstance(m.list.NumInstances() - 1)
+	m.state = stateNew
+	m.menu.SetState(ui.StateNewInstance)
+	m.openTitleInput(strings.ReplaceAll(branch, "/", " "))
+	return m, tea.WindowSize()
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"claude-squad/ui/overlay"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxCompareEntries caps each section of the comparison overlay, which can't scroll.
+const maxCompareEntries = 10
+
+// comparisonContent renders the comparison of instances a and b.
+func comparisonContent(a, b string, c *git.Comparison) string {
+	lines := []string{titleStyle.Render(fmt.Sprintf("Compare %s ↔ %s", a, b)), ""}
+	if !c.SameBase {
+		lines = append(lines, keyStyle.Render("The instances started from different commits, line numbers may not line up"), "")
+	}
+
+	section := func(header string, entries []string) {
+		lines = append(lines, headerStyle.Render(header))
+		if len(entries) == 0 {
+			lines = append(lines, descStyle.Render("  none"))
+		}
+		for i, entry := range entries {
+			if i == maxCompareEntries {
+				lines = append(lines, descStyle.Render(fmt.Sprintf("  ... and %d more", len(entries)-i)))
+				break
+			}
+			lines = append(lines, entry)
+		}
+		lines = append(lines, "")
+	}
+
+	var both []string
+	for _, file := range c.Both {
+		if len(file.Conflicts) == 0 {
+			both = append(both, descStyle.Render("  "+file.Path+" - no overlapping hunks"))
+			continue
+		}
+		both = append(both, keyStyle.Render("  "+file.Path)+descStyle.Render(fmt.Sprintf(" - %d conflicting hunks", len(file.Conflicts))))
+		for _, conflict := range file.Conflicts {
+			both = append(both, descStyle.Render(fmt.Sprintf("    %s: %s, %s: %s",
+				a, hunkRange(conflict.A), b, hunkRange(conflict.B))))
+		}
+	}
+	section(fmt.Sprintf("Changed by both (%d conflicting hunks):", c.ConflictCount()), both)
+
+	only := func(paths []string) []string {
+		entries := make([]string, 0, len(paths))
+		for _, path := range paths {
+			entries = append(entries, descStyle.Render("  "+path))
+		}
+		return entries
+	}
+	section("Only in "+a+":", only(c.OnlyA))
+	section("Only in "+b+":", only(c.OnlyB))
+
+	lines = append(lines, descStyle.Render("Press any key to close"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// hunkRange describes the base lines a hunk replaces.
+func hunkRange(h git.Hunk) string {
+	switch h.Lines {
+	case 0:
+		return fmt.Sprintf("insert after line %d", h.Start)
+	case 1:
+		return fmt.Sprintf("line %d", h.Start)
+	default:
+		return fmt.Sprintf("lines %d-%d", h.Start, h.Start+h.Lines-1)
+	}
+}
+
+// compareSelected marks the selected instance for comparison. Pressed again on another instance,
+// it shows how the changes of the two differ. Pressed on the marked instance, it clears the mark.
+func (m *home) compareSelected() (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return m, nil
+	}
+	if m.compareWith == "" {
+		m.compareWith = selected.Title
+		m.errBox.SetInfo(fmt.Sprintf("Comparing %s: select another session and press C", selected.Title))
+		return m, nil
+	}
+
+	markedTitle := m.compareWith
+	m.compareWith = ""
+	m.errBox.Clear()
+	if markedTitle == selected.Title {
+		return m, nil
+	}
+	var marked *session.Instance
+	for _, instance := range m.list.GetInstances() {
+		if instance.Title == markedTitle {
+			marked = instance
+		}
+	}
+	if marked == nil {
+		return m, m.handleError(fmt.Errorf("session %s no longer exists", markedTitle))
+	}
+
+	comparison, err := session.CompareInstances(marked, selected)
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	m.textOverlay = overlay.NewTextOverlay(comparisonContent(marked.Title, selected.Title, comparison))
+	m.state = stateHelp
+	return m, nil
+}
@@ -0,0 +1,20 @@
+package app
+
+import (
+	"claude-squad/daemon"
+	"claude-squad/session"
+	"time"
+)
+
+// daemonCheckInterval is how often the TUI looks up the instances a running daemon watches.
+const daemonCheckInterval = 2 * time.Second
+
+// daemonWatches returns true if a running daemon watches instance. The TUI leaves accepting its
+// prompts and restarting its program to the daemon, so neither is done twice.
+func (m *home) daemonWatches(instance *session.Instance) bool {
+	if now := time.Now(); now.Sub(m.daemonCheckedAt) >= daemonCheckInterval {
+		m.daemonWatching = daemon.Watching()
+		m.daemonCheckedAt = now
+	}
+	return m.daemonWatching[instance.Title]
+}
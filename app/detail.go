@@ -0,0 +1,220 @@
+package app
+
+import (
+	"claude-squad/aider"
+	"claude-squad/session"
+	"claude-squad/session/tmux"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// instanceDetailContent renders the detail overlay for an instance, including the program command
+// that actually runs in its tmux session.
+func instanceDetailContent(instance *session.Instance) string {
+	location := instance.Path
+	if worktree, err := instance.GetGitWorktree(); err == nil && !instance.InPlace {
+		location = worktree.GetWorktreePath()
+	}
+
+	lines := []string{
+		titleStyle.Render(instance.Title),
+		"",
+		detailField("Status", instanceStatus(instance)),
+		detailField("Branch", instance.Branch),
+		detailField("Path", location),
+		detailField("Program", instance.Program),
+		detailField("Command", instance.ResolvedProgram()),
+		detailField("Auto-yes", strings.TrimPrefix(instance.AutoYesDescription(), "auto-yes ")),
+	}
+	if ticket, ok := instance.ParsedTicket(); ok {
+		ref := ticket.Key
+		if ticket.URL != "" {
+			ref += " " + ticket.URL
+		}
+		lines = append(lines, detailField("Ticket", ref))
+	}
+	if len(instance.Tags) > 0 {
+		lines = append(lines, detailField("Tags", strings.Join(instance.Tags, ", ")))
+	}
+	if cost := instance.Cost; cost != nil {
+		value := fmt.Sprintf("$%.4f", cost.USD)
+		if cost.WallDuration != "" {
+			value += " over " + cost.WallDuration
+		}
+		lines = append(lines, detailField("Cost", value+", read "+cost.ReadAt.Format("Jan 2 15:04")))
+	}
+	if stats := instance.GetDiffStats(); stats != nil && stats.Base != "" {
+		lines = append(lines, detailField("Base", stats.Base))
+	}
+	if instance.Restarts > 0 {
+		lines = append(lines, detailField("Restarts", fmt.Sprintf("%d in a row, last at %s",
+			instance.Restarts, instance.RestartedAt.Format("Jan 2 15:04:05"))))
+	}
+	accent := lipgloss.NewStyle().Foreground(lipgloss.Color(instance.AccentColor())).Render("██ ")
+	if instance.Color == "" {
+		lines = append(lines, detailField("Color", "")+accent+descStyle.Render(instance.AccentColor()+" (default)"))
+	} else {
+		lines = append(lines, detailField("Color", "")+accent+descStyle.Render(instance.Color))
+	}
+	lines = append(lines, "")
+	if windows, err := instance.Windows(); err == nil && len(windows) > 1 {
+		lines = append(lines, headerStyle.Render("Windows"))
+		for _, window := range windows {
+			line := fmt.Sprintf("%d %s", window.Index, window.Name)
+			if window.Agent {
+				line += " (program)"
+			}
+			if window.Viewed {
+				line += " ◂ shown"
+			}
+			lines = append(lines, descStyle.Render(line))
+		}
+		lines = append(lines, "")
+	}
+	if tmux.IsAiderProgram(instance.Program) {
+		lines = append(lines, aiderDetailLines(instance)...)
+	}
+	if instance.Notes != "" {
+		lines = append(lines, headerStyle.Render("Notes"), descStyle.Render(instance.Notes), "")
+	}
+	if instance.Started() && !instance.Paused() {
+		lines = append(lines, keyStyle.Render("e")+descStyle.Render(" - Edit the command and restart the agent"))
+	}
+	lines = append(lines, keyStyle.Render("n")+descStyle.Render(" - Edit the notes"),
+		keyStyle.Render("t")+descStyle.Render(" - Set the ticket"),
+		keyStyle.Render("g")+descStyle.Render(" - Set the tags"),
+		keyStyle.Render("c")+descStyle.Render(" - Set the color"))
+	if instance.Started() && !instance.InPlace {
+		lines = append(lines, keyStyle.Render("d")+descStyle.Render(" - Set the diff base"))
+	}
+	lines = append(lines, descStyle.Render("Press any other key to close"))
+
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// instanceStatus describes the status of instance, with how its program ended once it exited.
+func instanceStatus(instance *session.Instance) string {
+	if instance.Status == session.Exited {
+		return instance.ExitDescription() + " - press r to restart it"
+	}
+	return instance.Status.String()
+}
+
+// detailField renders a name and value line of the detail overlay.
+func detailField(name, value string) string {
+	return headerStyle.Render(fmt.Sprintf("%-9s", name)) + descStyle.Render(value)
+}
+
+// maxDetailCommits is the number of recent commits listed for aider instances.
+const maxDetailCommits = 5
+
+// aiderDetailLines renders the model and messages of an aider instance's chat history, and its
+// recent commits with the request aider made each one for.
+func aiderDetailLines(instance *session.Instance) []string {
+	history, err := aider.Load(instance.WorkDir())
+	if err != nil {
+		return nil
+	}
+	lines := []string{headerStyle.Render("Aider")}
+	if history.Model != "" {
+		lines = append(lines, detailField("Model", history.Model))
+	}
+	lines = append(lines, detailField("Messages", fmt.Sprintf("%d", len(history.Messages))))
+
+	worktree, err := instance.GetGitWorktree()
+	if err != nil {
+		return append(lines, "")
+	}
+	commits, err := worktree.Commits(maxDetailCommits)
+	if err != nil || len(commits) == 0 {
+		return append(lines, "")
+	}
+	lines = append(lines, "", headerStyle.Render("Commits"))
+	for _, commit := range commits {
+		lines = append(lines, keyStyle.Render(commit.SHA[:7])+descStyle.Render(" "+commit.Subject))
+		if c, ok := history.FindCommit(commit.SHA); ok && c.Request != "" {
+			request, _, _ := strings.Cut(c.Request, "\n")
+			lines = append(lines, descStyle.Render("        for: "+truncateDetail(request, 60)))
+		}
+	}
+	return append(lines, "")
+}
+
+// truncateDetail cuts text to width runes.
+func truncateDetail(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// showDetail opens the detail overlay for the selected instance.
+func (m *home) showDetail() (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return m, nil
+	}
+	m.textOverlay = overlay.NewTextOverlay(instanceDetailContent(selected))
+	m.state = stateDetail
+	return m, tea.WindowSize()
+}
+
+// handleDetailState handles key presses while the detail overlay is shown. "e" switches to the
+// command editor, "n" to the notes editor, "t" to the ticket editor, "g" to the tags editor, "c" to
+// the color editor, "d" to the diff base editor, anything else closes the overlay.
+func (m *home) handleDetailState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if msg.String() == "e" && selected != nil && selected.Started() && !selected.Paused() {
+		m.textOverlay = nil
+		m.openCommandEditor("Edit and restart "+selected.Title, selected.ResolvedProgram())
+		return m, tea.WindowSize()
+	}
+	if msg.String() == "n" && selected != nil {
+		m.textOverlay = nil
+		m.openNotesEditor()
+		return m, tea.WindowSize()
+	}
+	if msg.String() == "t" && selected != nil {
+		m.textOverlay = nil
+		m.openTicketEditor()
+		return m, tea.WindowSize()
+	}
+	if msg.String() == "g" && selected != nil {
+		m.textOverlay = nil
+		m.openTagsEditor()
+		return m, tea.WindowSize()
+	}
+	if msg.String() == "c" && selected != nil {
+		m.textOverlay = nil
+		m.openColorEditor()
+		return m, tea.WindowSize()
+	}
+	if msg.String() == "d" && selected != nil && selected.Started() && !selected.InPlace {
+		m.textOverlay = nil
+		m.openDiffBaseEditor()
+		return m, tea.WindowSize()
+	}
+
+	m.textOverlay = nil
+	m.state = stateDefault
+	return m, tea.Sequence(
+		tea.WindowSize(),
+		func() tea.Msg {
+			m.menu.SetState(ui.StateDefault)
+			return nil
+		},
+	)
+}
+
+// openCommandEditor shows the command editor used to swap the program running in an instance.
+func (m *home) openCommandEditor(title string, command string) {
+	m.state = stateSwapProgram
+	m.menu.SetState(ui.StatePrompt)
+	m.commandEditor = overlay.NewCommandEditorOverlay(title, command)
+}
@@ -0,0 +1,379 @@
+package app
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// instanceEditedMsg carries a notes, ticket, tags or color change, a prompt sent, or a kill, pause or
+// resume, made outside of the TUI, such as through the web API, to the instance with the same title
+// in the list.
+type instanceEditedMsg struct {
+	event session.Event
+}
+
+// subscribeEdits forwards notes, ticket, tags and color changes, prompts sent, new instances, kills, pauses
+// and resumes made in this process to p, so the list doesn't overwrite them the next time it saves. The
+// returned function unsubscribes.
+func subscribeEdits(p *tea.Program) func() {
+	return session.Subscribe(func(e session.Event) {
+		switch e.Type {
+		case session.EventNotesChanged, session.EventTicketChanged, session.EventTagsChanged, session.EventColorChanged,
+			session.EventPromptSent, session.EventCreated, session.EventKilled, session.EventPaused, session.EventResumed:
+		default:
+			return
+		}
+		// Send blocks until the program reads the message, which it can't while it emits the event
+		go p.Send(instanceEditedMsg{event: e})
+	})
+}
+
+// handleInstanceEdited copies a change made outside of the TUI to the listed instance and saves
+// it. Changes made from the TUI come back here too and are already applied.
+func (m *home) handleInstanceEdited(msg instanceEditedMsg) tea.Cmd {
+	switch msg.event.Type {
+	case session.EventCreated:
+		return m.loadCreatedInstance(msg.event.Instance)
+	case session.EventKilled:
+		return m.removeKilledInstance(msg.event.Instance)
+	case session.EventPaused, session.EventResumed:
+		return m.reloadInstance(msg.event.Instance, msg.event.Type == session.EventPaused)
+	}
+	for _, instance := range m.list.GetInstances() {
+		if instance.Title != msg.event.Instance {
+			continue
+		}
+		switch {
+		case msg.event.Type == session.EventNotesChanged && instance.Notes != msg.event.Notes:
+			instance.Notes = msg.event.Notes
+		case msg.event.Type == session.EventTicketChanged && instance.Ticket != msg.event.Ticket:
+			instance.Ticket = msg.event.Ticket
+		case msg.event.Type == session.EventTagsChanged && !slices.Equal(instance.Tags, msg.event.Tags):
+			instance.Tags = msg.event.Tags
+		case msg.event.Type == session.EventPromptSent:
+			if !instance.RecordPrompt(session.PromptRecord{Prompt: msg.event.Prompt, SentAt: msg.event.Time}) {
+				continue
+			}
+		case msg.event.Type == session.EventColorChanged && instance.AccentColor() != msg.event.Color:
+			// The event carries the accent color; the default one means the chosen color was cleared
+			instance.Color = msg.event.Color
+			if msg.event.Color == session.DefaultAccentColor(instance.Title) {
+				instance.Color = ""
+			}
+		default:
+			continue
+		}
+		if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+			return m.handleError(err)
+		}
+	}
+	return nil
+}
+
+// createdInstanceWait bounds how long an instance created outside of the TUI is looked for in
+// storage, which it is saved to after it has started.
+const createdInstanceWait = 5 * time.Second
+
+// instanceCreatedMsg carries an instance created outside of the TUI, loaded from storage.
+type instanceCreatedMsg struct {
+	instance *session.Instance
+}
+
+// instanceReloadedMsg carries an instance paused or resumed outside of the TUI, reloaded from
+// storage.
+type instanceReloadedMsg struct {
+	instance *session.Instance
+}
+
+// loadCreatedInstance looks for the instance titled title, created outside of the TUI such as
+// through the web API, in storage unless it is listed already. Instances created from the TUI
+// are listed before they start.
+func (m *home) loadCreatedInstance(title string) tea.Cmd {
+	if m.listedInstance(title) != nil {
+		return nil
+	}
+	return m.loadStoredInstance(title, "created", func(*session.Instance) bool { return true },
+		func(instance *session.Instance) tea.Msg { return instanceCreatedMsg{instance: instance} })
+}
+
+// reloadInstance reloads the listed instance titled title from storage once it is saved paused,
+// or resumed, outside of the TUI. Instances paused or resumed from the TUI already are.
+func (m *home) reloadInstance(title string, paused bool) tea.Cmd {
+	listed := m.listedInstance(title)
+	if listed == nil || listed.Paused() == paused {
+		return nil
+	}
+	change := "resumed"
+	if paused {
+		change = "paused"
+	}
+	return m.loadStoredInstance(title, change, func(instance *session.Instance) bool { return instance.Paused() == paused },
+		func(instance *session.Instance) tea.Msg { return instanceReloadedMsg{instance: instance} })
+}
+
+// loadStoredInstance waits for the instance titled title, changed outside of the TUI, to be saved
+// in storage as ready reports, and returns the message msg makes of it, once restored.
+func (m *home) loadStoredInstance(title, change string, ready func(*session.Instance) bool, msg func(*session.Instance) tea.Msg) tea.Cmd {
+	storage := m.storage
+	return func() tea.Msg {
+		for deadline := time.Now().Add(createdInstanceWait); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+			instances, err := storage.LoadInstanceMetadata()
+			if err != nil {
+				log.WarningLog.Printf("could not load instance %s: %v", title, err)
+				return nil
+			}
+			for _, instance := range instances {
+				if instance.Title == title && ready(instance) {
+					instance.Restore()
+					return msg(instance)
+				}
+			}
+		}
+		log.WarningLog.Printf("instance %s was %s but never saved", title, change)
+		return nil
+	}
+}
+
+// listedInstance returns the listed instance titled title, or nil.
+func (m *home) listedInstance(title string) *session.Instance {
+	for _, instance := range m.list.GetInstances() {
+		if instance.Title == title {
+			return instance
+		}
+	}
+	return nil
+}
+
+// removeKilledInstance unlists the instance titled title, killed outside of the TUI. Instances
+// killed from the TUI are unlisted already.
+func (m *home) removeKilledInstance(title string) tea.Cmd {
+	instance := m.listedInstance(title)
+	if instance == nil {
+		return nil
+	}
+	m.list.RemoveKilled(instance)
+	return m.instanceChanged()
+}
+
+// handleInstanceReloaded lists an instance paused or resumed outside of the TUI in place of the
+// listed one.
+func (m *home) handleInstanceReloaded(msg instanceReloadedMsg) tea.Cmd {
+	listed := m.listedInstance(msg.instance.Title)
+	if listed == nil {
+		return nil
+	}
+	m.list.Replace(listed, msg.instance)
+	m.factory.Apply(msg.instance)
+	return m.instanceChanged()
+}
+
+// handleInstanceCreated lists an instance created outside of the TUI.
+func (m *home) handleInstanceCreated(msg instanceCreatedMsg) tea.Cmd {
+	if m.listedInstance(msg.instance.Title) != nil {
+		return nil
+	}
+	m.list.AddInstance(msg.instance)()
+	m.factory.Apply(msg.instance)
+	return m.instanceChanged()
+}
+
+// openNotesEditor shows the editor for the notes of the selected instance.
+func (m *home) openNotesEditor() {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return
+	}
+	m.state = stateNotes
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewTextInputOverlay("Notes for "+selected.Title, selected.Notes)
+}
+
+// handleNotesState handles key presses in the notes editor and saves the notes once submitted.
+func (m *home) handleNotesState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	if m.textInputOverlay.IsSubmitted() {
+		if selected := m.list.GetSelectedInstance(); selected != nil {
+			selected.SetNotes(m.textInputOverlay.GetValue())
+			if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+				cmd = m.handleError(err)
+			}
+		}
+	}
+
+	return m, tea.Batch(cmd, m.closeEditor())
+}
+
+// openTicketEditor shows the editor for the ticket reference of the selected instance.
+func (m *home) openTicketEditor() {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return
+	}
+	m.state = stateTicket
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewSingleLineInputOverlay("Ticket for "+selected.Title+" (URL or key, empty to clear)",
+		selected.Ticket, session.MaxTicketLength, func(ref string) error {
+			if strings.TrimSpace(ref) == "" {
+				return nil
+			}
+			_, err := session.ParseTicket(ref)
+			return err
+		})
+}
+
+// handleTicketState handles key presses in the ticket editor and saves the ticket once submitted.
+func (m *home) handleTicketState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	if m.textInputOverlay.IsSubmitted() {
+		if selected := m.list.GetSelectedInstance(); selected != nil {
+			if err := selected.SetTicket(m.textInputOverlay.GetValue()); err != nil {
+				cmd = m.handleError(err)
+			} else if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+				cmd = m.handleError(err)
+			}
+		}
+	}
+	return m, tea.Batch(cmd, m.closeEditor())
+}
+
+// openTagsEditor shows the editor for the tags of the selected instance.
+func (m *home) openTagsEditor() {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return
+	}
+	m.state = stateTags
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewSingleLineInputOverlay("Tags for "+selected.Title+" (separated by commas, empty to clear)",
+		strings.Join(selected.Tags, ", "), session.MaxTags*33, func(tags string) error {
+			_, err := session.ParseTags(tags)
+			return err
+		})
+}
+
+// handleTagsState handles key presses in the tags editor and saves the tags once submitted.
+func (m *home) handleTagsState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	if m.textInputOverlay.IsSubmitted() {
+		if selected := m.list.GetSelectedInstance(); selected != nil {
+			tags, err := session.ParseTags(m.textInputOverlay.GetValue())
+			if err == nil {
+				err = selected.SetTags(tags)
+			}
+			if err != nil {
+				cmd = m.handleError(err)
+			} else if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+				cmd = m.handleError(err)
+			}
+		}
+	}
+	return m, tea.Batch(cmd, m.closeEditor())
+}
+
+// openColorEditor shows the editor for the accent color of the selected instance.
+func (m *home) openColorEditor() {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return
+	}
+	m.state = stateColor
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewSingleLineInputOverlay("Color for "+selected.Title+" (#rrggbb, empty for "+
+		"the default)", selected.Color, len("#rrggbb"), session.ValidateColor)
+}
+
+// handleColorState handles key presses in the color editor and saves the color once submitted.
+func (m *home) handleColorState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	if m.textInputOverlay.IsSubmitted() {
+		if selected := m.list.GetSelectedInstance(); selected != nil {
+			if err := selected.SetColor(m.textInputOverlay.GetValue()); err != nil {
+				cmd = m.handleError(err)
+			} else if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+				cmd = m.handleError(err)
+			}
+			cmd = tea.Batch(cmd, m.instanceChanged())
+		}
+	}
+	return m, tea.Batch(cmd, m.closeEditor())
+}
+
+// maxDiffBaseLength bounds the diff base typed in the diff base editor.
+const maxDiffBaseLength = 200
+
+// openDiffBaseEditor shows the editor for what the diff of the selected instance compares with.
+func (m *home) openDiffBaseEditor() {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return
+	}
+	worktree, err := selected.GetGitWorktree()
+	if err != nil {
+		return
+	}
+	m.state = stateDiffBase
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewSingleLineInputOverlay("Diff base for "+selected.Title+" ("+
+		git.DiffBaseMergeBase+", "+git.DiffBasePush+" or a ref, empty for the creation commit)",
+		selected.DiffBase(), maxDiffBaseLength, func(base string) error {
+			return git.CheckDiffBase(worktree.GetWorktreePath(), strings.TrimSpace(base))
+		})
+}
+
+// handleDiffBaseState handles key presses in the diff base editor and saves the base once
+// submitted.
+func (m *home) handleDiffBaseState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	if m.textInputOverlay.IsSubmitted() {
+		if selected := m.list.GetSelectedInstance(); selected != nil {
+			if err := selected.SetDiffBase(m.textInputOverlay.GetValue()); err != nil {
+				cmd = m.handleError(err)
+			} else if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+				cmd = m.handleError(err)
+			}
+			cmd = tea.Batch(cmd, m.instanceChanged())
+		}
+	}
+	return m, tea.Batch(cmd, m.closeEditor())
+}
+
+// closeEditor closes the notes, ticket, tags, color or diff base editor and returns to the default state.
+func (m *home) closeEditor() tea.Cmd {
+	m.textInputOverlay = nil
+	m.state = stateDefault
+	return tea.Sequence(
+		tea.WindowSize(),
+		func() tea.Msg {
+			m.menu.SetState(ui.StateDefault)
+			return nil
+		},
+	)
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pollingSuspended returns true if preview and diff polling should pause because the terminal
+// window is unfocused. The preview tick then stops and the metadata tick skips diff stats, which
+// run git for every instance. Statuses are still tracked so auto-yes and webhooks keep working.
+// While attached to an instance the update loop blocks on the attached session, so no tick runs
+// at all.
+func (m *home) pollingSuspended() bool {
+	return m.unfocused
+}
+
+// resumePolling restarts the preview tick if it stopped while polling was suspended.
+func (m *home) resumePolling() tea.Cmd {
+	if !m.previewTickStopped {
+		return nil
+	}
+	m.previewTickStopped = false
+	return func() tea.Msg {
+		return previewTickMsg{}
+	}
+}
+
+// handleFocus suspends polling when the terminal window loses focus and resumes it when it regains
+// focus.
+func (m *home) handleFocus(focused bool) tea.Cmd {
+	m.unfocused = !focused
+	if focused {
+		return m.resumePolling()
+	}
+	return nil
+}
+
+// afterDetach resumes polling once the user detaches from an instance. The attached program may
+// have turned off focus reporting, so it is turned back on. The terminal may also have been
+// resized while attached, so the layout and the preview size of every instance are updated.
+func (m *home) afterDetach() tea.Cmd {
+	m.detached = false
+	m.unfocused = false
+	return tea.Batch(tea.EnableReportFocus, tea.WindowSize(), m.resumePolling())
+}
@@ -47,19 +47,45 @@ func (h helpType) ToContent(instance *session.Instance) string {
 			headerStyle.Render("Managing:"),
 			keyStyle.Render("n")+descStyle.Render("         - Create a new session"),
 			keyStyle.Render("N")+descStyle.Render("         - Create a new session with a prompt"),
+			keyStyle.Render("b")+descStyle.Render("         - Create a new session on an existing branch, such as a pull request's"),
+			keyStyle.Render("F")+descStyle.Render("         - Fork the selected session from its branch"),
+			keyStyle.Render("R")+descStyle.Render("         - Retry the session's first prompt on a fresh worktree"),
+			keyStyle.Render("E")+descStyle.Render("         - Start a session reviewing the commits on the session's branch"),
+			keyStyle.Render("g")+descStyle.Render("         - Show which sessions derive from which"),
+			keyStyle.Render("C")+descStyle.Render("         - Mark a session, then press on another to compare their changes"),
+			keyStyle.Render("v")+descStyle.Render("         - Snapshot the session's files, including untracked ones"),
+			keyStyle.Render("V")+descStyle.Render("         - List snapshots and roll back to one"),
+			keyStyle.Render("T")+descStyle.Render("         - Send a prompt from a template (ctrl-t in the prompt input)"),
+			keyStyle.Render("ctrl-f")+descStyle.Render("    - In the prompt input, attach files from the session's worktree"),
 			keyStyle.Render("D")+descStyle.Render("         - Kill (delete) the selected session"),
+			keyStyle.Render("esc")+descStyle.Render("       - Cancel the start, or the push, pause or kill jobs, of the selected session"),
 			keyStyle.Render("↑/j, ↓/k")+descStyle.Render("  - Navigate between sessions"),
+			keyStyle.Render("space")+descStyle.Render("     - Collapse or expand the repo of the selected session"),
 			keyStyle.Render("↵/o")+descStyle.Render("       - Attach to the selected session"),
+			keyStyle.Render("W")+descStyle.Render("         - Open a shell in the session's worktree"),
+			keyStyle.Render("w")+descStyle.Render("         - Show the session's next window in the preview and on attach"),
+			keyStyle.Render("!")+descStyle.Render("         - Run a command, such as tests, in the session's worktree"),
+			keyStyle.Render("/")+descStyle.Render("         - Send a slash command such as /compact or /cost to claude"),
+			keyStyle.Render("1-9")+descStyle.Render("       - Run a quick action from the config in the session"),
+			keyStyle.Render("y")+descStyle.Render("         - Turn auto-yes on for a while, or off, in the session"),
 			keyStyle.Render("ctrl-q")+descStyle.Render("    - Detach from session"),
 			"",
 			headerStyle.Render("Handoff:"),
 			keyStyle.Render("p")+descStyle.Render("         - Commit and push branch to github"),
+			keyStyle.Render("P")+descStyle.Render("         - Squash the branch into one commit and push it"),
 			keyStyle.Render("c")+descStyle.Render("         - Checkout: commit changes and pause session"),
-			keyStyle.Render("r")+descStyle.Render("         - Resume a paused session"),
+			keyStyle.Render("r")+descStyle.Render("         - Resume a paused session, or restart an exited program"),
+			keyStyle.Render("s")+descStyle.Render("         - Swap the program running in the session"),
+			keyStyle.Render("i")+descStyle.Render("         - Show session details and edit its command"),
+			keyStyle.Render("z")+descStyle.Render("         - Interrupt all running agents, press again to resume"),
 			"",
 			headerStyle.Render("Other:"),
-			keyStyle.Render("tab")+descStyle.Render("       - Switch between preview and diff tabs"),
-			keyStyle.Render("shift-↓/↑")+descStyle.Render(" - Scroll in diff view"),
+			keyStyle.Render("tab")+descStyle.Render("       - Switch between the preview, diff, conversation, commits and prompts tabs"),
+			keyStyle.Render("a")+descStyle.Render("         - Open or close the activity feed of every session"),
+			keyStyle.Render("shift-↓/↑")+descStyle.Render(" - Scroll in preview and diff view, select a commit or prompt in their tabs"),
+			keyStyle.Render("x")+descStyle.Render("         - Show or close the patch of the selected commit"),
+			keyStyle.Render("u")+descStyle.Render("         - Revert or drop the selected commit"),
+			keyStyle.Render("e")+descStyle.Render("         - Edit and send again the prompt selected in the prompts tab"),
 			keyStyle.Render("q")+descStyle.Render("         - Quit the application"),
 		)
 		return content
@@ -152,13 +178,14 @@ func (m *home) handleHelpState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	shouldClose := m.textOverlay.HandleKeyPress(msg)
 	if shouldClose {
 		m.state = stateDefault
-		return m, tea.Sequence(
+		// Start the jobs the help screen queued when it was dismissed, such as a checkout
+		return m, tea.Batch(m.startJobs(), tea.Sequence(
 			tea.WindowSize(),
 			func() tea.Msg {
 				m.menu.SetState(ui.StateDefault)
 				return nil
 			},
-		)
+		))
 	}
 
 	return m, nil
@@ -0,0 +1,177 @@
+package app
+
+import (
+	"claude-squad/keys"
+	"claude-squad/session"
+	"claude-squad/ui/overlay"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// jobKeys lists the keys whose git and tmux work runs as a job, queued behind the jobs already
+// running on the selected instance rather than refused.
+var jobKeys = map[keys.KeyName]bool{
+	keys.KeyKill:     true,
+	keys.KeySubmit:   true,
+	keys.KeySquash:   true,
+	keys.KeyCheckout: true,
+	keys.KeyResume:   true,
+}
+
+// job is a slow git or tmux operation on an instance, such as a push or a pause, run off the UI
+// thread. The jobs of an instance run one at a time, in the order they were queued; those of
+// different instances run side by side.
+type job struct {
+	id       int
+	instance *session.Instance
+	// label says what the job does in the jobs overlay
+	label string
+	// run does the work in the background. It should stop between its steps once ctx is
+	// canceled, returning ctx.Err(); a git or tmux command already running is left to finish.
+	run func(ctx context.Context) error
+	// done handles the result of run on the UI thread. It is called with context.Canceled when
+	// the job was canceled before it ran.
+	done func(err error) tea.Cmd
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started time.Time
+}
+
+// jobDoneMsg is sent when a job has run.
+type jobDoneMsg struct {
+	job *job
+	err error
+}
+
+// queueJob queues a job on instance, without starting it: startJobs does. done may be nil.
+func (m *home) queueJob(instance *session.Instance, label string, run func(ctx context.Context) error, done func(err error) tea.Cmd) *job {
+	m.nextJobID++
+	ctx, cancel := context.WithCancel(m.ctx)
+	j := &job{id: m.nextJobID, instance: instance, label: label, run: run, done: done, ctx: ctx, cancel: cancel}
+	m.jobs = append(m.jobs, j)
+	m.updateJobsOverlay()
+	return j
+}
+
+// runJob queues a job on instance and starts it unless another job of instance is running.
+func (m *home) runJob(instance *session.Instance, label string, run func(ctx context.Context) error, done func(err error) tea.Cmd) tea.Cmd {
+	m.queueJob(instance, label, run, done)
+	return m.startJobs()
+}
+
+// startJobs starts the first queued job of each instance that has no job running.
+func (m *home) startJobs() tea.Cmd {
+	var cmds []tea.Cmd
+	busy := make(map[*session.Instance]bool)
+	for _, j := range m.jobs {
+		if busy[j.instance] {
+			continue
+		}
+		busy[j.instance] = true
+		if !j.started.IsZero() {
+			continue
+		}
+		j.started = time.Now()
+		cmds = append(cmds, func() tea.Msg {
+			if err := j.ctx.Err(); err != nil {
+				return jobDoneMsg{job: j, err: err}
+			}
+			return jobDoneMsg{job: j, err: j.run(j.ctx)}
+		})
+	}
+	m.updateJobsOverlay()
+	return tea.Batch(cmds...)
+}
+
+// handleJobDone removes the job that ran, handles its result and starts the next job of its
+// instance.
+func (m *home) handleJobDone(msg jobDoneMsg) tea.Cmd {
+	m.removeJob(msg.job)
+	msg.job.cancel()
+	var cmd tea.Cmd
+	if msg.job.done != nil {
+		cmd = msg.job.done(msg.err)
+	} else if msg.err != nil && !errors.Is(msg.err, context.Canceled) {
+		cmd = m.handleError(msg.err)
+	}
+	return tea.Batch(cmd, m.startJobs())
+}
+
+func (m *home) removeJob(j *job) {
+	for i, other := range m.jobs {
+		if other == j {
+			m.jobs = append(m.jobs[:i], m.jobs[i+1:]...)
+			break
+		}
+	}
+	m.updateJobsOverlay()
+}
+
+// cancelJobs drops the queued jobs of instance and asks its running job to stop. It returns
+// false if instance has no jobs.
+func (m *home) cancelJobs(instance *session.Instance) (tea.Cmd, bool) {
+	var cmds []tea.Cmd
+	canceled := 0
+	for _, j := range append([]*job(nil), m.jobs...) {
+		if j.instance != instance {
+			continue
+		}
+		canceled++
+		j.cancel()
+		if j.started.IsZero() {
+			m.removeJob(j)
+			if j.done != nil {
+				cmds = append(cmds, j.done(context.Canceled))
+			}
+		}
+	}
+	if canceled == 0 {
+		return nil, false
+	}
+	m.updateJobsOverlay()
+	m.errBox.SetInfo(fmt.Sprintf("Canceling %d jobs of %s", canceled, instance.Title))
+	return tea.Batch(append(cmds, func() tea.Msg {
+		time.Sleep(3 * time.Second)
+		return hideErrMsg{}
+	})...), true
+}
+
+// jobRunning returns true while a job of instance runs, during which the metadata tick leaves it
+// alone.
+func (m *home) jobRunning(instance *session.Instance) bool {
+	for _, j := range m.jobs {
+		if j.instance == instance && !j.started.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// runningJobs returns the number of jobs running.
+func (m *home) runningJobs() int {
+	running := 0
+	for _, j := range m.jobs {
+		if !j.started.IsZero() {
+			running++
+		}
+	}
+	return running
+}
+
+func (m *home) updateJobsOverlay() {
+	lines := make([]overlay.JobLine, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		lines = append(lines, overlay.JobLine{
+			ID:       j.id,
+			Label:    j.label + " " + j.instance.Title,
+			Started:  j.started,
+			Canceled: j.ctx.Err() != nil,
+		})
+	}
+	m.jobsOverlay.SetJobs(lines)
+}
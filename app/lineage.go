@@ -0,0 +1,107 @@
+package app
+
+import (
+	"claude-squad/session"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// lineageContent renders instances as a tree, with each derived instance indented under the
+// instance it was forked from, reviews or retries.
+func lineageContent(instances []*session.Instance) string {
+	lines := []string{titleStyle.Render("Instance Lineage"), ""}
+	for _, entry := range session.LineageTree(instances) {
+		line := entry.Instance.Title
+		if entry.Depth > 0 {
+			line = strings.Repeat("  ", entry.Depth-1) + "└ " + line
+			line += descStyle.Render(fmt.Sprintf(" (%s)", entry.Instance.Relation))
+		}
+		lines = append(lines, keyStyle.Render(line)+descStyle.Render(" - "+entry.Instance.Status.String()))
+	}
+	if len(instances) == 0 {
+		lines = append(lines, descStyle.Render("No instances"))
+	}
+	lines = append(lines, "", descStyle.Render("Press any key to close"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// showLineage opens an overlay with the lineage tree of all instances.
+func (m *home) showLineage() (tea.Model, tea.Cmd) {
+	m.textOverlay = overlay.NewTextOverlay(lineageContent(m.list.GetInstances()))
+	m.state = stateHelp
+	return m, nil
+}
+
+// forkSelected creates a new instance branched from the selected instance's branch and asks for
+// its name, recording the fork in the new instance's lineage.
+func (m *home) forkSelected() (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || !selected.Started() {
+		return m, nil
+	}
+	if m.list.NumInstances() >= GlobalInstanceLimit {
+		return m, m.handleError(
+			fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
+	}
+
+	opts := session.InstanceOptions{
+		Title:     "",
+		Path:      selected.Path,
+		Program:   selected.Program,
+		Parent:    selected.Title,
+		Relation:  session.RelationFork,
+		Ticket:    selected.Ticket,
+		GitConfig: selected.GitConfig(),
+	}
+	// In-place instances have no branch of their own, so their forks start from HEAD
+	if !selected.InPlace {
+		opts.BaseBranch = selected.Branch
+	}
+	instance, err := m.factory.New(opts)
+	if err != nil {
+		return m, m.handleError(err)
+	}
+
+	m.newInstanceFinalizer = m.list.AddInstance(instance)
+	m.list.SetSelectedInstance(m.list.NumInstances() - 1)
+	m.state = stateNew
+	m.menu.SetState(ui.StateNewInstance)
+	m.openTitleInput(selected.Prompt)
+	return m, tea.WindowSize()
+}
+
+// reviewSelected creates an instance reviewing the selected instance's branch and asks for its
+// name. Once it starts, the prompt input is prefilled with the review prompt.
+func (m *home) reviewSelected() (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || !selected.Started() {
+		return m, nil
+	}
+	if m.list.NumInstances() >= GlobalInstanceLimit {
+		return m, m.handleError(
+			fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
+	}
+
+	opts, err := session.ReviewOptions(selected, selected.Program)
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	instance, err := m.factory.New(opts)
+	if err != nil {
+		return m, m.handleError(err)
+	}
+
+	m.newInstanceFinalizer = m.list.AddInstance(instance)
+	m.list.SetSelectedInstance(m.list.NumInstances() - 1)
+	m.state = stateNew
+	m.menu.SetState(ui.StateNewInstance)
+	m.openTitleInput(selected.Title + " review")
+	m.promptAfterName = true
+	m.retryPrompt = session.ReviewPrompt(selected)
+	return m, tea.WindowSize()
+}
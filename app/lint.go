@@ -0,0 +1,83 @@
+package app
+
+import (
+	"claude-squad/prompt"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// lintPrompt returns the warnings of the checks of text, none if they are disabled.
+func (m *home) lintPrompt(text string) []prompt.Warning {
+	cfg := m.appConfig.PromptLint
+	if cfg.Disabled {
+		return nil
+	}
+	return prompt.Lint(text, cfg.MaxSize(), m.appConfig.PushScan.SecretPatterns)
+}
+
+// promptWarningContent renders why a prompt was held back.
+func promptWarningContent(title string, warnings []prompt.Warning) string {
+	lines := []string{titleStyle.Render("Prompt for " + title + " not sent"), ""}
+	for i, warning := range warnings {
+		if i == maxBlockedLines {
+			lines = append(lines, descStyle.Render(fmt.Sprintf("  ... and %d more", len(warnings)-i)))
+			break
+		}
+		lines = append(lines, keyStyle.Render("  "+warning.Rule)+descStyle.Render(" - "+warning.Detail))
+	}
+	lines = append(lines, "",
+		descStyle.Render("Press y to send it anyway, any other key to edit it"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// warnPrompt shows the warnings of text and holds it until they are overridden.
+func (m *home) warnPrompt(text string, warnings []prompt.Warning) (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return m, nil
+	}
+	m.flaggedPrompt = text
+	m.textInputOverlay = nil
+	m.textOverlay = overlay.NewTextOverlay(promptWarningContent(selected.Title, warnings))
+	m.state = statePromptWarning
+	return m, tea.WindowSize()
+}
+
+// handlePromptWarningState sends the flagged prompt if y is pressed. Any other key goes back to
+// editing it.
+func (m *home) handlePromptWarningState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	text := m.flaggedPrompt
+	m.flaggedPrompt = ""
+	m.textOverlay = nil
+	if msg.String() != "y" {
+		m.state = statePrompt
+		m.menu.SetState(ui.StatePrompt)
+		m.textInputOverlay = overlay.NewTextInputOverlay("Enter prompt", text)
+		return m, tea.WindowSize()
+	}
+
+	if selected := m.list.GetSelectedInstance(); selected != nil {
+		if err := selected.SendPrompt(text); err != nil {
+			return m, tea.Batch(m.handleError(err), m.closePrompt())
+		}
+	}
+	return m, m.closePrompt()
+}
+
+// closePrompt closes the prompt input and returns to the default state.
+func (m *home) closePrompt() tea.Cmd {
+	m.textInputOverlay = nil
+	m.state = stateDefault
+	return tea.Sequence(
+		tea.WindowSize(),
+		func() tea.Msg {
+			m.menu.SetState(ui.StateDefault)
+			m.showHelpScreen(helpTypeInstanceStart, nil)
+			return nil
+		},
+	)
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// replayPrompt opens the prompt input on the prompt selected in the prompts tab, to edit it and
+// send it again.
+func (m *home) replayPrompt() (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	record, ok := m.tabbedWindow.SelectedPrompt()
+	if selected == nil || !ok {
+		return m, nil
+	}
+	if !selected.Started() || selected.Paused() {
+		return m, m.handleError(fmt.Errorf("%s isn't running, resume it to send the prompt again", selected.Title))
+	}
+	m.state = statePrompt
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewTextInputOverlay("Enter prompt", record.Prompt)
+	return m, tea.WindowSize()
+}
@@ -0,0 +1,312 @@
+package app
+
+import (
+	"claude-squad/command"
+	"claude-squad/network"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxBlockedLines caps the findings and compliance output listed in the blocked push overlay,
+// which can't scroll.
+const maxBlockedLines = 15
+
+// maxSquashedSubjects caps the commit subjects the squash message starts with.
+const maxSquashedSubjects = 50
+
+// blockedPush is a push stopped by its checks, kept so it can be overridden, or by the network,
+// kept until it is back.
+type blockedPush struct {
+	instance      *session.Instance
+	commitMessage string
+	// squash is set when the commits of the branch are squashed into one before the push
+	squash bool
+}
+
+// blockedPushContent renders why the push of title was blocked: the findings of the scan or the
+// output of the failed compliance command.
+func blockedPushContent(title string, scanErr *git.ScanError, complianceErr *git.ComplianceError) string {
+	lines := []string{titleStyle.Render("Push of " + title + " blocked"), ""}
+	capped := func(entries []string) {
+		for i, entry := range entries {
+			if i == maxBlockedLines {
+				lines = append(lines, descStyle.Render(fmt.Sprintf("  ... and %d more", len(entries)-i)))
+				break
+			}
+			lines = append(lines, entry)
+		}
+	}
+
+	if scanErr != nil {
+		lines = append(lines, headerStyle.Render(fmt.Sprintf("The scan found %d large files or secrets:", len(scanErr.Findings))))
+		entries := make([]string, 0, len(scanErr.Findings))
+		for _, finding := range scanErr.Findings {
+			location := finding.Path
+			if finding.Line > 0 {
+				location = fmt.Sprintf("%s:%d", finding.Path, finding.Line)
+			}
+			entries = append(entries, keyStyle.Render("  "+location)+descStyle.Render(" - "+finding.Rule+": "+finding.Detail))
+		}
+		capped(entries)
+	}
+	if complianceErr != nil {
+		lines = append(lines, headerStyle.Render(fmt.Sprintf("Compliance check %s failed (%v):", complianceErr.Command, complianceErr.Err)))
+		var entries []string
+		for _, line := range strings.Split(strings.TrimSpace(complianceErr.Output), "\n") {
+			if line != "" {
+				entries = append(entries, descStyle.Render("  "+line))
+			}
+		}
+		capped(entries)
+	}
+
+	lines = append(lines, "",
+		descStyle.Render("Nothing was committed. Press y to push anyway, any other key to cancel"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// openSquashEditor shows the editor for the message of the single commit the commits and changes
+// of the selected instance are squashed into before they are pushed. It starts with the subjects
+// of the commits, oldest first.
+func (m *home) openSquashEditor() (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return m, nil
+	}
+	worktree, err := selected.GetGitWorktree()
+	if err != nil {
+		return m, m.handleError(fmt.Errorf("only instances with a worktree can be squashed: %w", err))
+	}
+	commits, err := worktree.Commits(maxSquashedSubjects)
+	if err != nil {
+		return m, m.handleError(err)
+	}
+
+	message := selected.CommitMessage()
+	if len(commits) == 1 {
+		message = strings.TrimSpace(commits[0].Subject + "\n\n" + commits[0].Body)
+	} else if len(commits) > 1 {
+		message += "\n"
+		for i := len(commits) - 1; i >= 0; i-- {
+			message += "\n- " + commits[i].Subject
+		}
+	}
+	m.state = stateSquash
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewTextInputOverlay("Squash "+selected.Title+" into one commit and push", message)
+	return m, tea.WindowSize()
+}
+
+// handleSquashState handles key presses in the squash message editor and squashes and pushes
+// once submitted.
+func (m *home) handleSquashState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+	message := strings.TrimSpace(m.textInputOverlay.GetValue())
+	selected := m.list.GetSelectedInstance()
+	if !m.textInputOverlay.IsSubmitted() || selected == nil {
+		return m, m.closeEditor()
+	}
+	if message == "" {
+		return m, tea.Batch(m.closeEditor(), m.handleError(fmt.Errorf("the squashed commit needs a message")))
+	}
+
+	push := &blockedPush{instance: selected, commitMessage: message, squash: true}
+	if network.Offline() {
+		return m, tea.Batch(m.closeEditor(), m.queuePush(push))
+	}
+	return m, tea.Batch(m.closeEditor(), m.runPush(push, false))
+}
+
+// commitInPlace commits all the changes in dir, the directory of a Simple Mode instance, which
+// has no worktree to push them from.
+func commitInPlace(dir, commitMsg string) error {
+	// First check if there are any changes to commit
+	gitStatusCmd := command.New(command.GitTimeout(), "git", "status", "--porcelain")
+	gitStatusCmd.Dir = dir
+	statusOutput, err := gitStatusCmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to get git status: %w", err)
+	}
+	if len(statusOutput) == 0 {
+		return fmt.Errorf("no changes to commit")
+	}
+
+	// Add all changes
+	gitAddCmd := command.New(command.GitTimeout(), "git", "add", ".")
+	gitAddCmd.Dir = dir
+	if err := gitAddCmd.Run(); err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+
+	// Commit changes
+	gitCommitCmd := command.New(command.GitTimeout(), "git", "commit", "-m", commitMsg)
+	gitCommitCmd.Dir = dir
+	if err := gitCommitCmd.Run(); err != nil {
+		return fmt.Errorf("failed to commit changes: %w", err)
+	}
+	return nil
+}
+
+// runPush commits and pushes the changes of push.instance as a job, squashing its commits first
+// if push.squash is set. A push that can't reach origin is queued, one blocked by its checks
+// offers an override. queued is set for a push that was queued while offline: Simple Mode
+// changes were committed then, and no pull request page is opened.
+func (m *home) runPush(push *blockedPush, queued bool) tea.Cmd {
+	instance := push.instance
+	label := "Pushing"
+	if push.squash {
+		label = "Squashing and pushing"
+	}
+	// committed is set once the changes are committed, failures before that aren't push failures
+	committed := queued || !instance.InPlace
+	return m.runJob(instance, label, func(ctx context.Context) error {
+		if instance.InPlace {
+			if !committed {
+				if err := commitInPlace(instance.Path, push.commitMessage); err != nil {
+					return err
+				}
+				committed = true
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return git.Push(instance.Path)
+		}
+		worktree, err := instance.GetGitWorktree()
+		if err != nil {
+			return err
+		}
+		if push.squash {
+			return worktree.PushSquashed(push.commitMessage, !queued)
+		}
+		return worktree.PushChanges(push.commitMessage, !queued)
+	}, func(err error) tea.Cmd {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil
+		case err != nil && !committed:
+			return m.handleError(err)
+		case err != nil:
+			if instance.InPlace && !errors.As(err, new(*git.OfflineError)) {
+				err = fmt.Errorf("failed to push changes: %w", err)
+			}
+			_, cmd := m.handlePushError(instance, push.commitMessage, push.squash, err)
+			return cmd
+		}
+		instance.Emit(session.EventBranchPushed)
+		switch {
+		case queued:
+			m.errBox.SetInfo("Pushed " + instance.Title + ", queued while offline")
+		case push.squash:
+			m.errBox.SetInfo("Squashed and pushed " + instance.Title)
+		case instance.InPlace:
+			m.errBox.SetInfo("Changes committed and pushed successfully")
+		}
+		return m.instanceChanged()
+	})
+}
+
+// handlePushError queues a push that couldn't reach origin, and shows why a push was blocked by
+// its checks, or the error otherwise.
+func (m *home) handlePushError(instance *session.Instance, commitMessage string, squash bool, err error) (tea.Model, tea.Cmd) {
+	if errors.As(err, new(*git.OfflineError)) {
+		return m, m.queuePush(&blockedPush{instance: instance, commitMessage: commitMessage, squash: squash})
+	}
+	instance.EmitError(err)
+	var scanErr *git.ScanError
+	var complianceErr *git.ComplianceError
+	if !errors.As(err, &scanErr) && !errors.As(err, &complianceErr) {
+		return m, m.handleError(err)
+	}
+	m.blockedPush = &blockedPush{instance: instance, commitMessage: commitMessage, squash: squash}
+	m.textOverlay = overlay.NewTextOverlay(blockedPushContent(instance.Title, scanErr, complianceErr))
+	m.state = statePushBlocked
+	return m, tea.WindowSize()
+}
+
+// handlePushBlockedState pushes the blocked changes anyway if y is pressed. Any other key cancels
+// the push.
+func (m *home) handlePushBlockedState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if push := m.blockedPush; push != nil && msg.String() == "y" {
+		cmd = m.runJob(push.instance, "Pushing", func(context.Context) error {
+			worktree, err := push.instance.GetGitWorktree()
+			if err != nil {
+				return err
+			}
+			if push.squash {
+				return worktree.PushSquashedOverridingChecks(push.commitMessage, true)
+			}
+			return worktree.PushChangesOverridingChecks(push.commitMessage, true)
+		}, func(err error) tea.Cmd {
+			switch {
+			case errors.Is(err, context.Canceled):
+				return nil
+			case err != nil:
+				push.instance.EmitError(err)
+				return m.handleError(err)
+			}
+			push.instance.Emit(session.EventBranchPushed)
+			m.errBox.SetInfo("Pushed " + push.instance.Title + " despite the failed checks")
+			return nil
+		})
+	}
+
+	m.blockedPush = nil
+	m.textOverlay = nil
+	m.state = stateDefault
+	return m, tea.Batch(cmd, tea.Sequence(
+		tea.WindowSize(),
+		func() tea.Msg {
+			m.menu.SetState(ui.StateDefault)
+			return nil
+		},
+	))
+}
+
+// queuePush keeps push until the network is back, in place of a push of the same instance
+// already queued, since a single push sends all of its changes.
+func (m *home) queuePush(push *blockedPush) tea.Cmd {
+	queued := false
+	for i, other := range m.queuedPushes {
+		if other.instance == push.instance {
+			m.queuedPushes[i] = push
+			queued = true
+		}
+	}
+	if !queued {
+		m.queuedPushes = append(m.queuedPushes, push)
+	}
+	m.menu.SetOffline(true, len(m.queuedPushes))
+	m.errBox.SetInfo("Offline: " + push.instance.Title + " will be pushed when the network is back")
+	return nil
+}
+
+// checkNetwork shows whether the network is down in the menu and, while it is up, starts the
+// oldest queued push as a job. Pushes of killed instances are dropped, and queued pushes wait
+// while an overlay is open.
+func (m *home) checkNetwork() tea.Cmd {
+	offline := network.Offline()
+	m.menu.SetOffline(offline, len(m.queuedPushes))
+	if offline || len(m.queuedPushes) == 0 || m.state != stateDefault {
+		return nil
+	}
+	push := m.queuedPushes[0]
+	m.queuedPushes = m.queuedPushes[1:]
+	if !slices.Contains(m.list.GetInstances(), push.instance) {
+		return nil
+	}
+	return m.runPush(push, true)
+}
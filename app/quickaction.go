@@ -0,0 +1,33 @@
+package app
+
+import (
+	"claude-squad/config"
+	"claude-squad/session"
+	"fmt"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// quickActionForKey returns the quick action numbered key, if any.
+func quickActionForKey(key string) (config.QuickAction, bool) {
+	n, err := strconv.Atoi(key)
+	actions := session.QuickActions()
+	if err != nil || len(key) != 1 || n < 1 || n > len(actions) {
+		return config.QuickAction{}, false
+	}
+	return actions[n-1], true
+}
+
+// runQuickAction sends the prompt or keys of action to the selected instance.
+func (m *home) runQuickAction(action config.QuickAction) (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return m, nil
+	}
+	if err := selected.RunQuickAction(action); err != nil {
+		return m, m.handleError(err)
+	}
+	m.errBox.SetInfo(fmt.Sprintf("Sent %s to %s", action.Label, selected.Title))
+	return m, m.instanceChanged()
+}
@@ -28,15 +28,13 @@ func (h *home) StartReactWebServer() error {
 		return err
 	}
 
-	log.FileOnlyInfoLog.Printf("Web monitoring server with React UI started on http://%s:%d", 
-		h.appConfig.WebServerHost, h.appConfig.WebServerPort)
+	log.FileOnlyInfoLog.Printf("Web monitoring server with React UI started on %s", h.appConfig.WebServerAddress())
 		
 	// Also log to standard error for visibility
-	fmt.Printf("\nWeb monitoring server with React UI started: http://%s:%d\n", 
-		h.appConfig.WebServerHost, h.appConfig.WebServerPort)
+	fmt.Printf("\nWeb monitoring server with React UI started: %s\n", h.appConfig.WebServerAddress())
 		
 	// Update menu with web server info
-	h.menu.SetWebServerInfo(true, h.appConfig.WebServerHost, h.appConfig.WebServerPort)
+	h.menu.SetWebServerInfo(true, h.appConfig.WebServerAddress())
 	
 	// Create a standard session for web server if no instances exist
 	log.FileOnlyInfoLog.Printf("DEBUG: app/react_web.go: NumInstances() returned %d instances", h.list.NumInstances())
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"claude-squad/session"
+	"claude-squad/ui"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// retrySelected asks for the program to retry the selected instance with, prefilled with the
+// program it runs now so a different model or agent is one edit away.
+func (m *home) retrySelected() (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || !selected.Started() {
+		return m, nil
+	}
+	if m.list.NumInstances() >= GlobalInstanceLimit {
+		return m, m.handleError(
+			fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
+	}
+
+	m.openCommandEditor("Retry "+selected.Title+" with", selected.Program)
+	m.state = stateRetry
+	return m, tea.WindowSize()
+}
+
+// handleRetryState handles key presses in the retry program editor. Submitting creates the retry
+// and asks for its name; once it starts, the prompt input is prefilled with the first prompt of
+// the retried instance.
+func (m *home) handleRetryState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.commandEditor.HandleKeyPress(msg) {
+		return m, nil
+	}
+	program := m.commandEditor.GetValue()
+	submitted := m.commandEditor.IsSubmitted()
+	m.commandEditor = nil
+
+	selected := m.list.GetSelectedInstance()
+	if !submitted || selected == nil {
+		m.state = stateDefault
+		return m, tea.Sequence(
+			tea.WindowSize(),
+			func() tea.Msg {
+				m.menu.SetState(ui.StateDefault)
+				return nil
+			},
+		)
+	}
+
+	instance, err := m.factory.New(session.RetryOptions(selected, program))
+	if err != nil {
+		m.state = stateDefault
+		m.menu.SetState(ui.StateDefault)
+		return m, m.handleError(err)
+	}
+
+	m.newInstanceFinalizer = m.list.AddInstance(instance)
+	m.list.SetSelectedInstance(m.list.NumInstances() - 1)
+	m.state = stateNew
+	m.menu.SetState(ui.StateNewInstance)
+	m.openTitleInput(selected.Prompt)
+	m.promptAfterName = true
+	m.retryPrompt = selected.Prompt
+	return m, tea.WindowSize()
+}
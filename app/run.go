@@ -0,0 +1,138 @@
+package app
+
+import (
+	"claude-squad/session"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runRefreshInterval is how often the output of a running command is refreshed.
+const runRefreshInterval = 250 * time.Millisecond
+
+// runTickMsg refreshes the output of the command shown in stateRunOutput.
+type runTickMsg struct {
+	run *session.CommandRun
+}
+
+// openRunCommand asks for a shell command to run in the selected instance's directory,
+// prefilled with the last one run there.
+func (m *home) openRunCommand() (tea.Model, tea.Cmd) {
+	selected := m.runningSelected()
+	if selected == nil {
+		return m, nil
+	}
+	command := ""
+	if run := selected.LastRun(); run != nil {
+		command = run.Command
+	}
+	m.openCommandEditor("Run in "+selected.Title, command)
+	m.commandEditor.Hint = "enter to run • esc to cancel"
+	m.state = stateRunCommand
+	return m, tea.WindowSize()
+}
+
+// handleRunCommandState handles key presses in the command input, running the command on enter.
+func (m *home) handleRunCommandState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.commandEditor.HandleKeyPress(msg) {
+		return m, nil
+	}
+	command := m.commandEditor.GetValue()
+	submitted := m.commandEditor.IsSubmitted()
+	m.commandEditor = nil
+
+	selected := m.runningSelected()
+	if !submitted || selected == nil {
+		m.state = stateDefault
+		return m, tea.Sequence(
+			tea.WindowSize(),
+			func() tea.Msg {
+				m.menu.SetState(ui.StateDefault)
+				return nil
+			},
+		)
+	}
+	return m.runCommand(selected, command)
+}
+
+// runCommand starts command in instance and shows its output.
+func (m *home) runCommand(instance *session.Instance, command string) (tea.Model, tea.Cmd) {
+	run, err := instance.RunCommand(command)
+	if err != nil {
+		m.state = stateDefault
+		m.menu.SetState(ui.StateDefault)
+		return m, m.handleError(err)
+	}
+	m.runOutput = overlay.NewRunOutputOverlay(fmt.Sprintf("$ %s  (%s)", command, instance.Title))
+	m.shownRun = run
+	m.refreshRunOutput()
+	m.state = stateRunOutput
+	return m, tea.Batch(tea.WindowSize(), runTick(run))
+}
+
+func runTick(run *session.CommandRun) tea.Cmd {
+	return tea.Tick(runRefreshInterval, func(time.Time) tea.Msg {
+		return runTickMsg{run: run}
+	})
+}
+
+// handleRunTick refreshes the output while the command is shown, until it ends.
+func (m *home) handleRunTick(msg runTickMsg) tea.Cmd {
+	if m.state != stateRunOutput || m.shownRun != msg.run {
+		return nil
+	}
+	m.refreshRunOutput()
+	if msg.run.Done() {
+		return nil
+	}
+	return runTick(msg.run)
+}
+
+// refreshRunOutput shows the output and status of the command shown.
+func (m *home) refreshRunOutput() {
+	run := m.shownRun
+	m.runOutput.SetOutput(run.Output())
+	duration := run.Duration().Round(100 * time.Millisecond)
+	switch err := run.Err(); {
+	case !run.Done():
+		m.runOutput.Status = fmt.Sprintf("running for %s", duration)
+	case err != nil:
+		m.runOutput.Status = fmt.Sprintf("✗ %v after %s", err, duration)
+		m.runOutput.Failed = true
+	default:
+		m.runOutput.Status = fmt.Sprintf("✓ succeeded in %s", duration)
+		m.runOutput.Failed = false
+	}
+}
+
+// handleRunOutputState handles key presses while the output of a command is shown: scrolling,
+// stopping the command, running it again and closing the output. Closing leaves the command
+// running; its end shows in the activity feed.
+func (m *home) handleRunOutputState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.runOutput.HandleKeyPress(msg) {
+		return m, nil
+	}
+	switch msg.String() {
+	case "ctrl+c":
+		m.shownRun.Cancel()
+		return m, nil
+	case "r":
+		if !m.shownRun.Done() {
+			return m, nil
+		}
+		if selected := m.runningSelected(); selected != nil {
+			return m.runCommand(selected, m.shownRun.Command)
+		}
+		return m, nil
+	case "esc", "q":
+		m.runOutput = nil
+		m.shownRun = nil
+		m.state = stateDefault
+		m.menu.SetState(ui.StateDefault)
+		return m, tea.WindowSize()
+	}
+	return m, nil
+}
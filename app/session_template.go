@@ -0,0 +1,103 @@
+package app
+
+import (
+	"claude-squad/session"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"maps"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sessionTemplatePick is the session template being picked for a new instance in
+// stateSessionTemplates.
+type sessionTemplatePick struct {
+	// names are the templates listed, numbered from 1
+	names []string
+	// withPrompt is set when the instance was created with N, to be prompted once started
+	withPrompt bool
+}
+
+// sessionTemplatesContent renders the session templates, numbered for picking.
+func sessionTemplatesContent(names []string) string {
+	lines := []string{titleStyle.Render("Session templates"), ""}
+	for i, name := range names {
+		lines = append(lines, keyStyle.Render(fmt.Sprintf("%d", i+1))+descStyle.Render(" - "+name))
+	}
+	lines = append(lines, "", descStyle.Render("Press a number to use that template, enter for none, any other key to cancel"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// newInstance creates an instance to be named, prompted for once started if withPrompt is set. The
+// session templates of the config are offered first, unless one was given with --template.
+func (m *home) newInstance(withPrompt bool) (tea.Model, tea.Cmd) {
+	if m.list.NumInstances() >= GlobalInstanceLimit {
+		return m, m.handleError(
+			fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
+	}
+	if m.factory.Template != "" || len(m.factory.Templates) == 0 {
+		return m, m.createInstance(withPrompt, "")
+	}
+
+	names := slices.Sorted(maps.Keys(m.factory.Templates))
+	if len(names) > maxListedTemplates {
+		names = names[:maxListedTemplates]
+	}
+	m.sessionTemplatePick = &sessionTemplatePick{names: names, withPrompt: withPrompt}
+	m.textOverlay = overlay.NewTextOverlay(sessionTemplatesContent(names))
+	m.state = stateSessionTemplates
+	return m, tea.WindowSize()
+}
+
+// handleSessionTemplatesState creates the instance from the template whose number is pressed, or
+// from none on enter. Any other key cancels.
+func (m *home) handleSessionTemplatesState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	pick := m.sessionTemplatePick
+	m.sessionTemplatePick = nil
+	m.textOverlay = nil
+	key := msg.String()
+	if len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+		if idx := int(key[0] - '1'); idx < len(pick.names) {
+			return m, m.createInstance(pick.withPrompt, pick.names[idx])
+		}
+	}
+	if msg.Type == tea.KeyEnter {
+		return m, m.createInstance(pick.withPrompt, "")
+	}
+	m.state = stateDefault
+	return m, tea.Sequence(
+		tea.WindowSize(),
+		func() tea.Msg {
+			m.menu.SetState(ui.StateDefault)
+			return nil
+		},
+	)
+}
+
+// createInstance adds an instance created from the session template called template, or the
+// factory's, and asks for its name. A template with a prompt prefills the prompt asked for once the
+// instance has started.
+func (m *home) createInstance(withPrompt bool, template string) tea.Cmd {
+	instance, err := m.factory.New(session.InstanceOptions{
+		Title:    "",
+		Path:     ".",
+		Template: template,
+	})
+	if err != nil {
+		m.state = stateDefault
+		return m.handleError(err)
+	}
+
+	m.newInstanceFinalizer = m.list.AddInstance(instance)
+	m.list.SetSelectedInstance(m.list.NumInstances() - 1)
+	m.state = stateNew
+	m.menu.SetState(ui.StateNewInstance)
+	m.openTitleInput("")
+	m.promptAfterName = withPrompt || instance.TemplatePrompt() != ""
+	m.retryPrompt = instance.TemplatePrompt()
+
+	return tea.WindowSize()
+}
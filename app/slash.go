@@ -0,0 +1,93 @@
+package app
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// costReadInterval is how often the pane is read for the output of /cost.
+	costReadInterval = 500 * time.Millisecond
+	// costReadAttempts is how many times the pane is read before giving up on /cost.
+	costReadAttempts = 10
+)
+
+// costReadMsg reads the output of /cost sent to instance.
+type costReadMsg struct {
+	instance *session.Instance
+	attempt  int
+}
+
+// slashCommandsContent renders the slash commands that can be sent, numbered by their keys.
+func slashCommandsContent() string {
+	lines := []string{titleStyle.Render("Slash commands"), ""}
+	for i, command := range session.SlashCommands {
+		lines = append(lines, keyStyle.Render(fmt.Sprintf("%d", i+1))+
+			descStyle.Render(fmt.Sprintf(" - %s: %s", command, command.Description())))
+	}
+	lines = append(lines, "", descStyle.Render("Press a number to send that command, any other key to close"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// showSlashCommands lists the slash commands to send to the selected instance.
+func (m *home) showSlashCommands() (tea.Model, tea.Cmd) {
+	if m.runningSelected() == nil {
+		return m, nil
+	}
+	m.textOverlay = overlay.NewTextOverlay(slashCommandsContent())
+	m.state = stateSlashCommands
+	return m, tea.WindowSize()
+}
+
+// handleSlashCommandsState sends the slash command whose number is pressed. Any other key closes
+// the list.
+func (m *home) handleSlashCommandsState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.textOverlay = nil
+	m.state = stateDefault
+	key := msg.String()
+	selected := m.runningSelected()
+	if selected == nil || len(key) != 1 || key[0] < '1' || int(key[0]-'1') >= len(session.SlashCommands) {
+		return m, tea.WindowSize()
+	}
+	command := session.SlashCommands[key[0]-'1']
+	if err := selected.SendSlashCommand(command); err != nil {
+		return m, tea.Batch(tea.WindowSize(), m.handleError(err))
+	}
+	m.errBox.SetInfo(fmt.Sprintf("Sent %s to %s", command, selected.Title))
+	cmds := []tea.Cmd{tea.WindowSize(), m.instanceChanged()}
+	if command == session.SlashCost {
+		cmds = append(cmds, readCost(selected, 1))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func readCost(instance *session.Instance, attempt int) tea.Cmd {
+	return tea.Tick(costReadInterval, func(time.Time) tea.Msg {
+		return costReadMsg{instance: instance, attempt: attempt}
+	})
+}
+
+// handleCostRead reads the output of /cost into the instance's cost, trying again until it shows.
+func (m *home) handleCostRead(msg costReadMsg) tea.Cmd {
+	ok, err := msg.instance.ReadCost()
+	if err != nil {
+		log.WarningLog.Printf("could not read the cost of %s: %v", msg.instance.Title, err)
+	}
+	if !ok {
+		if msg.attempt < costReadAttempts {
+			return readCost(msg.instance, msg.attempt+1)
+		}
+		return m.handleError(fmt.Errorf("could not read the cost of %s from its output", msg.instance.Title))
+	}
+	m.errBox.SetInfo(fmt.Sprintf("%s has cost $%.2f so far", msg.instance.Title, msg.instance.Cost.USD))
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m.handleError(err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"claude-squad/session/git"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxListedSnapshots is the number of snapshots offered for rollback, one per digit key.
+const maxListedSnapshots = 9
+
+// snapshotSelected snapshots the selected instance's worktree.
+func (m *home) snapshotSelected() (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || !selected.Started() {
+		return m, nil
+	}
+	if _, err := selected.Snapshot("manual snapshot"); err != nil {
+		return m, m.handleError(err)
+	}
+	m.errBox.SetInfo("Snapshot saved for " + selected.Title)
+	return m, nil
+}
+
+// snapshotsContent renders the snapshots of an instance, numbered for rollback.
+func snapshotsContent(title string, snapshots []git.Snapshot) string {
+	lines := []string{titleStyle.Render("Snapshots of " + title), ""}
+	if len(snapshots) == 0 {
+		lines = append(lines, descStyle.Render("No snapshots yet. Press v to take one."))
+	}
+	for i, snapshot := range snapshots {
+		lines = append(lines, keyStyle.Render(fmt.Sprintf("%d", i+1))+
+			descStyle.Render(fmt.Sprintf(" - %s  %s", snapshot.Time.Format("Jan 02 15:04:05"), snapshot.Message)))
+	}
+	lines = append(lines, "")
+	if len(snapshots) > 0 {
+		lines = append(lines, descStyle.Render("Press a number to roll back to that snapshot, any other key to close"))
+	} else {
+		lines = append(lines, descStyle.Render("Press any key to close"))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// showSnapshots opens the list of snapshots of the selected instance.
+func (m *home) showSnapshots() (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || !selected.Started() {
+		return m, nil
+	}
+	snapshots, err := selected.Snapshots()
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	if len(snapshots) > maxListedSnapshots {
+		snapshots = snapshots[:maxListedSnapshots]
+	}
+
+	m.snapshots = snapshots
+	m.textOverlay = overlay.NewTextOverlay(snapshotsContent(selected.Title, snapshots))
+	m.state = stateSnapshots
+	return m, tea.WindowSize()
+}
+
+// handleSnapshotsState rolls back to the snapshot whose number is pressed. Any other key closes
+// the list.
+func (m *home) handleSnapshotsState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	key := msg.String()
+	if selected := m.list.GetSelectedInstance(); selected != nil && len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+		if idx := int(key[0] - '1'); idx < len(m.snapshots) {
+			snapshot := m.snapshots[idx]
+			if err := selected.Rollback(snapshot); err != nil {
+				cmd = m.handleError(err)
+			} else {
+				m.errBox.SetInfo(fmt.Sprintf("Rolled %s back to %s", selected.Title, snapshot.Time.Format("15:04:05")))
+				cmd = m.instanceChanged()
+			}
+		}
+	}
+
+	m.snapshots = nil
+	m.textOverlay = nil
+	m.state = stateDefault
+	return m, tea.Batch(cmd, tea.Sequence(
+		tea.WindowSize(),
+		func() tea.Msg {
+			m.menu.SetState(ui.StateDefault)
+			return nil
+		},
+	))
+}
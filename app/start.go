@@ -0,0 +1,155 @@
+package app
+
+import (
+	"claude-squad/keys"
+	"claude-squad/session"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// needsStartedInstance lists the keys that act on the selected instance's tmux session or
+// worktree, which don't exist until it has started.
+var needsStartedInstance = map[keys.KeyName]bool{
+	keys.KeyEnter:    true,
+	keys.KeyKill:     true,
+	keys.KeySubmit:   true,
+	keys.KeyCheckout: true,
+	keys.KeyResume:   true,
+	keys.KeySnapshot: true,
+	keys.KeyRollback: true,
+	keys.KeyTemplate: true,
+	keys.KeySlash:    true,
+}
+
+// pendingStart is an instance starting in the background.
+type pendingStart struct {
+	cancel context.CancelFunc
+	// finalize registers the instance in the list once it has started
+	finalize func()
+	// promptAfterStart opens the prompt input, prefilled with prompt, once the instance is up
+	promptAfterStart bool
+	prompt           string
+}
+
+// instanceStartedMsg is sent when an instance starting in the background is up, failed or was
+// canceled.
+type instanceStartedMsg struct {
+	instance *session.Instance
+	err      error
+}
+
+// startInstance starts the new instance named in stateNew in the background, so the UI stays
+// responsive while the worktree is created and the program boots. The instance shows as loading
+// in the list with the stage it is at until it is up.
+func (m *home) startInstance(instance *session.Instance) tea.Cmd {
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.starting[instance] = &pendingStart{
+		cancel:           cancel,
+		finalize:         m.newInstanceFinalizer,
+		promptAfterStart: m.promptAfterName,
+		prompt:           m.retryPrompt,
+	}
+	m.promptAfterName = false
+	m.retryPrompt = ""
+	instance.SetStatus(session.Loading)
+
+	return func() tea.Msg {
+		return instanceStartedMsg{instance: instance, err: instance.StartContext(ctx, true)}
+	}
+}
+
+// cancelStart cancels the start of instance if it is starting in the background.
+func (m *home) cancelStart(instance *session.Instance) tea.Cmd {
+	pending, ok := m.starting[instance]
+	if !ok {
+		return nil
+	}
+	pending.cancel()
+	m.errBox.SetInfo(fmt.Sprintf("Canceling the start of %s", instance.Title))
+	return func() tea.Msg {
+		time.Sleep(3 * time.Second)
+		return hideErrMsg{}
+	}
+}
+
+// handleInstanceStarted finishes a background start: a failed or canceled instance is removed
+// from the list, a started one is saved and, if it was created with a prompt, prompted for it.
+func (m *home) handleInstanceStarted(msg instanceStartedMsg) (tea.Model, tea.Cmd) {
+	pending, ok := m.starting[msg.instance]
+	if !ok {
+		return m, nil
+	}
+	delete(m.starting, msg.instance)
+	pending.cancel()
+
+	if msg.err != nil {
+		m.list.Remove(msg.instance)
+		cmd := m.instanceChanged()
+		if errors.Is(msg.err, context.Canceled) {
+			m.errBox.SetInfo(fmt.Sprintf("Canceled the start of %s", msg.instance.Title))
+			return m, tea.Batch(cmd, func() tea.Msg {
+				time.Sleep(3 * time.Second)
+				return hideErrMsg{}
+			})
+		}
+		return m, tea.Batch(cmd, m.handleError(msg.err))
+	}
+
+	// Save after adding new instance
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m, m.handleError(err)
+	}
+	// Instance added successfully, call the finalizer.
+	pending.finalize()
+
+	// Don't pull the user out of whatever they moved on to while the instance started
+	if m.state != stateDefault {
+		return m, m.instanceChanged()
+	}
+	if pending.promptAfterStart {
+		for idx, instance := range m.list.GetInstances() {
+			if instance == msg.instance {
+				m.list.SetSelectedInstance(idx)
+			}
+		}
+		m.state = statePrompt
+		m.menu.SetState(ui.StatePrompt)
+		m.textInputOverlay = overlay.NewTextInputOverlay("Enter prompt", pending.prompt)
+	} else {
+		m.showHelpScreen(helpTypeInstanceStart, nil)
+	}
+	return m, tea.Batch(tea.WindowSize(), m.instanceChanged())
+}
+
+// maxConcurrentRestores bounds the saved instances reattached to tmux at once.
+const maxConcurrentRestores = 4
+
+// instanceRestoredMsg is sent when a saved instance has been reattached to its tmux session.
+type instanceRestoredMsg struct {
+	instance *session.Instance
+}
+
+// restoreInstances reattaches the saved instances loaded at startup to their tmux sessions in the
+// background, a few at a time. Each shows as loading in the list until it is restored.
+func (m *home) restoreInstances() tea.Cmd {
+	slots := make(chan struct{}, maxConcurrentRestores)
+	var cmds []tea.Cmd
+	for _, instance := range m.list.GetInstances() {
+		if !instance.Restoring() {
+			continue
+		}
+		cmds = append(cmds, func() tea.Msg {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			instance.Restore()
+			return instanceRestoredMsg{instance: instance}
+		})
+	}
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"claude-squad/prompt"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxListedTemplates is the number of templates offered, one per digit key.
+const maxListedTemplates = 9
+
+// templateFill is a template being picked and filled in for the selected instance.
+type templateFill struct {
+	// templates are the templates listed in stateTemplates, numbered from 1
+	templates []prompt.Template
+	// fromPrompt is set when the picker was opened from the prompt input, and draft holds the
+	// prompt entered so far
+	fromPrompt bool
+	draft      string
+	template   prompt.Template
+	values     map[string]string
+	// missing are the variables still to ask for in stateTemplateVars
+	missing []string
+}
+
+// templatesContent renders the templates, numbered for picking.
+func templatesContent(templates []prompt.Template) string {
+	lines := []string{titleStyle.Render("Prompt templates"), ""}
+	if len(templates) == 0 {
+		lines = append(lines, descStyle.Render(fmt.Sprintf("No templates yet. Add them to %s or through the web API.",
+			prompt.TemplatesFileName)))
+	}
+	for i, t := range templates {
+		line := keyStyle.Render(fmt.Sprintf("%d", i+1)) + descStyle.Render(" - "+t.Name)
+		if t.Description != "" {
+			line += descStyle.Render(": " + t.Description)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "")
+	if len(templates) > 0 {
+		lines = append(lines, descStyle.Render("Press a number to use that template, any other key to close"))
+	} else {
+		lines = append(lines, descStyle.Render("Press any key to close"))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// showTemplates opens the template picker for the selected instance. When opened from the prompt
+// input, draft is the prompt entered so far; it is kept when the picker is closed and the filled
+// template is appended to it.
+func (m *home) showTemplates(fromPrompt bool, draft string) (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || !selected.Started() {
+		return m, nil
+	}
+	if m.templates == nil {
+		return m, m.handleError(fmt.Errorf("prompt templates are unavailable"))
+	}
+	templates, err := m.templates.List()
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	if len(templates) > maxListedTemplates {
+		templates = templates[:maxListedTemplates]
+	}
+
+	m.templateFill = &templateFill{templates: templates, fromPrompt: fromPrompt, draft: draft}
+	m.textInputOverlay = nil
+	m.textOverlay = overlay.NewTextOverlay(templatesContent(templates))
+	m.state = stateTemplates
+	return m, tea.WindowSize()
+}
+
+// handleTemplatesState picks the template whose number is pressed and asks for the variables the
+// selected instance doesn't fill in. Any other key closes the picker.
+func (m *home) handleTemplatesState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	fill := m.templateFill
+	m.textOverlay = nil
+	key := msg.String()
+	if selected := m.list.GetSelectedInstance(); selected != nil && len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+		if idx := int(key[0] - '1'); idx < len(fill.templates) {
+			fill.template = fill.templates[idx]
+			fill.values = prompt.InstanceValues(selected)
+			for _, name := range fill.template.Variables() {
+				if _, ok := fill.values[name]; !ok {
+					fill.missing = append(fill.missing, name)
+				}
+			}
+			return m, m.askTemplateVariable()
+		}
+	}
+	if fill.fromPrompt {
+		return m, m.openTemplatePrompt(fill.draft)
+	}
+	return m, m.closeTemplates()
+}
+
+// askTemplateVariable asks for the next missing variable, or opens the prompt input with the
+// filled template once all are known.
+func (m *home) askTemplateVariable() tea.Cmd {
+	fill := m.templateFill
+	if len(fill.missing) == 0 {
+		filled := fill.template.Fill(fill.values)
+		if fill.draft != "" {
+			filled = fill.draft + "\n\n" + filled
+		}
+		return m.openTemplatePrompt(filled)
+	}
+	m.state = stateTemplateVars
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewTextInputOverlay(
+		fmt.Sprintf("Value of {%s} in %s", fill.missing[0], fill.template.Name), "")
+	return tea.WindowSize()
+}
+
+// handleTemplateVarsState handles key presses while a variable is entered. Canceling goes back to
+// the prompt input, or closes the template if it wasn't opened from there.
+func (m *home) handleTemplateVarsState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+	fill := m.templateFill
+	if !m.textInputOverlay.IsSubmitted() {
+		if fill.fromPrompt {
+			return m, m.openTemplatePrompt(fill.draft)
+		}
+		return m, m.closeTemplates()
+	}
+	fill.values[fill.missing[0]] = m.textInputOverlay.GetValue()
+	fill.missing = fill.missing[1:]
+	return m, m.askTemplateVariable()
+}
+
+// openTemplatePrompt opens the prompt input with value, to be reviewed and sent.
+func (m *home) openTemplatePrompt(value string) tea.Cmd {
+	m.templateFill = nil
+	m.state = statePrompt
+	m.menu.SetState(ui.StatePrompt)
+	m.textInputOverlay = overlay.NewTextInputOverlay("Enter prompt", value)
+	return tea.WindowSize()
+}
+
+// closeTemplates closes the template picker and returns to the default state.
+func (m *home) closeTemplates() tea.Cmd {
+	m.templateFill = nil
+	m.textInputOverlay = nil
+	m.state = stateDefault
+	return tea.Sequence(
+		tea.WindowSize(),
+		func() tea.Msg {
+			m.menu.SetState(ui.StateDefault)
+			return nil
+		},
+	)
+}
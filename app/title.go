@@ -0,0 +1,56 @@
+package app
+
+import (
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"claude-squad/ui/overlay"
+)
+
+// openTitleInput asks for the title of the new instance, the last one in the list, suggesting one
+// made from prompt. The title is checked against the other instances as it is typed, and the
+// branch and tmux session names derived from it are shown.
+func (m *home) openTitleInput(prompt string) {
+	instances := m.list.GetInstances()
+	others := instances[:len(instances)-1]
+	m.textInputOverlay = overlay.NewSingleLineInputOverlay("Name the instance",
+		session.SuggestTitle(prompt, others), session.MaxTitleLength,
+		func(title string) error {
+			return session.ValidateTitle(title, others)
+		})
+	// Show what the title becomes where other tools see it, and how stale HEAD is when the new
+	// branch starts from it
+	instance := instances[len(instances)-1]
+	existingBranch := instance.ExistingBranch()
+	base := ""
+	if existingBranch == "" && instance.BaseBranch() == "" {
+		base = baseNote(instance.Path)
+	}
+	m.textInputOverlay.SetHint(func(title string) string {
+		branch, tmuxSession := session.TitleNames(title)
+		if prefix := instance.BranchPrefix(); prefix != "" {
+			branch = git.PrefixedBranchName(prefix, title)
+		}
+		if existingBranch != "" {
+			return "checks out " + existingBranch + ", tmux session " + tmuxSession
+		}
+		return "branch " + branch + ", tmux session " + tmuxSession + base
+	})
+	_ = instances[len(instances)-1].SetTitle(m.textInputOverlay.GetValue())
+}
+
+// baseNote tells where a new branch in the repository at path starts from: origin's default
+// branch when it is fetched first, or HEAD along with how far it is behind origin. It is empty
+// when the repository has no origin.
+func baseNote(path string) string {
+	if git.FetchBaseEnabled() {
+		if remote, err := git.RemoteDefaultBranch(path); err == nil {
+			return "; starts from " + remote + ", fetched first"
+		}
+		return ""
+	}
+	freshness, err := git.BaseFreshness(path)
+	if err != nil || freshness.Behind == 0 {
+		return ""
+	}
+	return "; " + freshness.String()
+}
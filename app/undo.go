@@ -0,0 +1,92 @@
+package app
+
+import (
+	"claude-squad/session/git"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// undoCommitContent renders the choice between reverting and dropping commit.
+func undoCommitContent(title string, commit git.Commit) string {
+	lines := []string{
+		titleStyle.Render("Undo a commit of " + title),
+		"",
+		keyStyle.Render(commit.SHA[:7]) + descStyle.Render(" "+commit.Subject),
+		"",
+		keyStyle.Render("r") + descStyle.Render(" - Revert: add a commit undoing it, keeping the history"),
+		keyStyle.Render("d") + descStyle.Render(" - Drop: remove it from the branch, rebasing the commits after it"),
+		"",
+		descStyle.Render("The worktree must have no uncommitted changes. A conflict with later commits aborts"),
+		descStyle.Render("and leaves the branch as it was. Press any other key to cancel"),
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// openUndoCommit offers to revert or drop the commit selected in the commits tab.
+func (m *home) openUndoCommit() (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	commit, ok := m.tabbedWindow.SelectedCommit()
+	if selected == nil || !ok {
+		return m, nil
+	}
+	m.undoCommit = commit
+	m.textOverlay = overlay.NewTextOverlay(undoCommitContent(selected.Title, commit))
+	m.state = stateUndoCommit
+	return m, tea.WindowSize()
+}
+
+// handleUndoCommitState reverts the commit on "r" and drops it on "d". Any other key cancels.
+func (m *home) handleUndoCommitState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	selected := m.list.GetSelectedInstance()
+	key := msg.String()
+	if selected != nil && (key == "r" || key == "d") {
+		commit := m.undoCommit
+		verb, done := "revert", "Reverted"
+		if key == "d" {
+			verb, done = "drop", "Dropped"
+			if git.DryRun() {
+				done = "Dry run, would have dropped"
+			}
+		}
+		worktree, err := selected.GetGitWorktree()
+		if err == nil {
+			if key == "r" {
+				err = worktree.RevertCommit(commit.SHA)
+			} else {
+				err = worktree.DropCommit(commit.SHA)
+			}
+		}
+		var conflict *git.CommitConflictError
+		switch {
+		case errors.As(err, &conflict):
+			cmd = m.handleError(fmt.Errorf("%s of %s aborted, it conflicts with later changes to %s",
+				verb, commit.SHA[:7], strings.Join(conflict.Files, ", ")))
+		case err != nil:
+			cmd = m.handleError(err)
+		default:
+			m.errBox.SetInfo(fmt.Sprintf("%s %s: %s", done, commit.SHA[:7], commit.Subject))
+			if err := selected.UpdateDiffStats(); err != nil {
+				cmd = m.handleError(err)
+			}
+			m.tabbedWindow.ReloadCommits(selected)
+		}
+	}
+
+	m.undoCommit = git.Commit{}
+	m.textOverlay = nil
+	m.state = stateDefault
+	return m, tea.Batch(cmd, tea.Sequence(
+		tea.WindowSize(),
+		func() tea.Msg {
+			m.menu.SetState(ui.StateDefault)
+			return nil
+		},
+	))
+}
@@ -4,6 +4,7 @@ import (
 	"claude-squad/log"
 	"claude-squad/session"
 	"claude-squad/web"
+	"claude-squad/web/tokens"
 	"fmt"
 	"os"
 	"time"
@@ -11,11 +12,13 @@ import (
 
 // StartOptions contains options for starting Claude Squad.
 type StartOptions struct {
-	Program          string
-	AutoYes          bool
+	// Factory creates instances with the program and auto-yes setting from the config and flags.
+	Factory          *session.Factory
 	SimpleMode       bool
 	WebServerEnabled bool
 	WebServerPort    int
+	// WebServerSocket serves the web server on a unix socket instead of TCP.
+	WebServerSocket  string
 	ReactUI          bool
 }
 
@@ -37,15 +40,13 @@ func (h *home) StartWebServer() error {
 		return err
 	}
 
-	log.FileOnlyInfoLog.Printf("Web monitoring server started on http://%s:%d", 
-		h.appConfig.WebServerHost, h.appConfig.WebServerPort)
+	log.FileOnlyInfoLog.Printf("Web monitoring server started on %s", h.appConfig.WebServerAddress())
 		
 	// Also log to standard error for visibility
-	fmt.Printf("\nWeb monitoring server started: http://%s:%d\n", 
-		h.appConfig.WebServerHost, h.appConfig.WebServerPort)
+	fmt.Printf("\nWeb monitoring server started: %s\n", h.appConfig.WebServerAddress())
 		
 	// Update menu with web server info
-	h.menu.SetWebServerInfo(true, h.appConfig.WebServerHost, h.appConfig.WebServerPort)
+	h.menu.SetWebServerInfo(true, h.appConfig.WebServerAddress())
 	
 	// Create a standard session for web server if no instances exist
 	log.FileOnlyInfoLog.Printf("DEBUG: app/web.go: NumInstances() returned %d instances", h.list.NumInstances())
@@ -106,12 +107,11 @@ func (h *home) createWebInstance() error {
 	log.FileOnlyInfoLog.Printf("DEBUG: createWebInstance: Using directory %s", currentDir)
 	
 	// Create a new instance
-	instance, err := session.NewInstance(session.InstanceOptions{
+	instance, err := h.factory.New(session.InstanceOptions{
 		Title:   instanceName,
 		Path:    currentDir,
-		Program: h.program,
 		AutoYes: true, // Auto-confirm any prompts
-		InPlace: true,  // Run in current directory
+		InPlace: true, // Run in current directory
 	})
 	if err != nil {
 		log.FileOnlyErrorLog.Printf("DEBUG: createWebInstance: Failed to create instance: %v", err)
@@ -152,6 +152,17 @@ func (h *home) StopWebServer() {
 		}
 		
 		// Clear web server info from menu
-		h.menu.SetWebServerInfo(false, "", 0)
+		h.menu.SetWebServerInfo(false, "")
 	}
-}
\ No newline at end of file
+}
+// revokeTokens revokes the web tokens scoped to a killed instance, so they don't open a new
+// instance given the same title.
+func revokeTokens(title string) {
+	store, err := tokens.NewStore()
+	if err == nil {
+		err = store.RevokeInstance(title)
+	}
+	if err != nil {
+		log.ErrorLog.Printf("could not revoke the tokens of %s: %v", title, err)
+	}
+}
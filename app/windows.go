@@ -0,0 +1,48 @@
+package app
+
+import (
+	"claude-squad/session"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runningSelected returns the selected instance if its program is running, or nil.
+func (m *home) runningSelected() *session.Instance {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || !selected.Started() || selected.Paused() {
+		return nil
+	}
+	return selected
+}
+
+// nextWindow shows the next tmux window of the selected instance in the preview.
+func (m *home) nextWindow() (tea.Model, tea.Cmd) {
+	selected := m.runningSelected()
+	if selected == nil {
+		return m, nil
+	}
+	window, err := selected.NextWindow()
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	if window.Agent {
+		m.errBox.SetInfo(fmt.Sprintf("Showing %s of %s", selected.Program, selected.Title))
+	} else {
+		m.errBox.SetInfo(fmt.Sprintf("Showing window %s of %s, press w to go back", window.Name, selected.Title))
+	}
+	return m, m.instanceChanged()
+}
+
+// openShellWindow opens a shell in the selected instance's worktree and shows it in the preview.
+func (m *home) openShellWindow() (tea.Model, tea.Cmd) {
+	selected := m.runningSelected()
+	if selected == nil {
+		return m, nil
+	}
+	if err := selected.OpenWindow(session.ShellWindowName, ""); err != nil {
+		return m, m.handleError(err)
+	}
+	m.errBox.SetInfo(fmt.Sprintf("Opened a shell in %s, press enter to attach to it", selected.Title))
+	return m, m.instanceChanged()
+}
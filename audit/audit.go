@@ -0,0 +1,139 @@
+// Package audit keeps a trail of the actions taken on instances, so shared deployments can tell
+// who changed or killed an instance.
+package audit
+
+import (
+	"bytes"
+	"claude-squad/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileName is the file in the config directory holding the trail, one JSON entry per line.
+const FileName = "audit.jsonl"
+
+// MaxFileSize is the size past which the trail is moved to FileName.1, replacing the previous
+// one, and a new file is started.
+const MaxFileSize = 4 << 20
+
+// Entry is an action recorded in the trail.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Actor is who took the action: "token <id>" for a token scoped to an instance, "auth token",
+	// "localhost", "unix socket", or "anonymous" when the server doesn't ask for auth.
+	Actor string `json:"actor"`
+	// Remote is the address the request came from.
+	Remote   string `json:"remote,omitempty"`
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Instance string `json:"instance,omitempty"`
+	// Status is the HTTP status of the response, or 0 for a WebSocket, recorded when it opens.
+	Status int `json:"status,omitempty"`
+	// Summary describes the request body, such as notes="Fix the login flow".
+	Summary string `json:"summary,omitempty"`
+}
+
+// Log appends entries to a file. It is safe for concurrent use.
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLog returns the trail in the config directory.
+func NewLog() (*Log, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewLogAt(filepath.Join(dir, FileName)), nil
+}
+
+// NewLogAt returns a trail kept in the file at path.
+func NewLogAt(path string) *Log {
+	return &Log{path: path}
+}
+
+// Record appends e to the trail, setting its time if it has none.
+func (l *Log) Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if info, err := os.Stat(l.path); err == nil && info.Size()+int64(len(data)) > MaxFileSize {
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate audit trail: %w", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit trail: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Query returns the last limit entries of instance, or of every instance if instance is empty,
+// newest first. A limit of 0 returns them all. The rotated file is read too.
+func (l *Log) Query(instance string, limit int) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []Entry
+	for _, path := range []string{l.path, l.path + ".1"} {
+		file, err := readEntries(path, instance)
+		if err != nil {
+			return nil, err
+		}
+		// Newest first within the file
+		for i := len(file) - 1; i >= 0; i-- {
+			entries = append(entries, file[i])
+			if limit > 0 && len(entries) == limit {
+				return entries, nil
+			}
+		}
+	}
+	if entries == nil {
+		entries = []Entry{}
+	}
+	return entries, nil
+}
+
+// readEntries returns the entries of instance in the file at path, oldest first. Lines that
+// don't parse, such as one cut short by a crash, are skipped.
+func readEntries(path, instance string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit trail: %w", err)
+	}
+	var entries []Entry
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var e Entry
+		if len(line) == 0 || json.Unmarshal(line, &e) != nil {
+			continue
+		}
+		if instance == "" || e.Instance == instance {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	trail := NewLogAt(path)
+
+	if entries, err := trail.Query("", 0); err != nil || len(entries) != 0 {
+		t.Fatalf("Query of an empty trail = %v, %v", entries, err)
+	}
+
+	for _, e := range []Entry{
+		{Actor: "localhost", Method: "PATCH", Path: "/api/instances/a", Instance: "a", Status: 200},
+		{Actor: "token 1234", Method: "PATCH", Path: "/api/instances/b", Instance: "b", Status: 200},
+		{Actor: "auth token", Method: "DELETE", Path: "/api/instances/a", Instance: "a", Status: 204},
+	} {
+		if err := trail.Record(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := trail.Query("a", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Method != "DELETE" || entries[1].Actor != "localhost" {
+		t.Errorf("Query(a) = %+v, want the two actions on a, newest first", entries)
+	}
+	if entries[0].Time.IsZero() {
+		t.Error("Record didn't set the time")
+	}
+	if entries, _ := trail.Query("", 1); len(entries) != 1 || entries[0].Instance != "a" {
+		t.Errorf("Query with a limit = %+v", entries)
+	}
+
+	// A partly written line is skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"actor": "trunc`)
+	f.Close()
+	if entries, err := trail.Query("", 0); err != nil || len(entries) != 3 {
+		t.Errorf("Query with a broken line = %d entries, %v", len(entries), err)
+	}
+}
+
+func TestLogRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	trail := NewLogAt(path)
+
+	// Fill the file past its limit so the next entry starts a new one
+	big := strings.Repeat("x", MaxFileSize/2)
+	for _, instance := range []string{"a", "b", "c"} {
+		if err := trail.Record(Entry{Instance: instance, Summary: big}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Fatalf("trail wasn't rotated: %v", err)
+	}
+	entries, err := trail.Query("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var instances []string
+	for _, e := range entries {
+		instances = append(instances, e.Instance)
+	}
+	if got := strings.Join(instances, ","); got != "c,b" {
+		t.Errorf("instances after rotation = %s, want c,b", got)
+	}
+}
@@ -86,4 +86,13 @@ else
   echo "<!DOCTYPE html><html><head><title>Claude Squad</title></head><body><h1>Claude Squad</h1><p>Web interface fallback.</p></body></html>" > ../web/static/dist/index.html
 fi
 
+# Precompress the assets; the web server serves the .br or .gz copy to browsers accepting it
+print_colored "Compressing assets..."
+find ../web/static/dist \( -name '*.js' -o -name '*.css' -o -name '*.svg' -o -name '*.html' \) -type f | while read -r file; do
+  gzip -9 -k -f "$file"
+  if command -v brotli &> /dev/null; then
+    brotli -q 11 -k -f "$file"
+  fi
+done
+
 print_colored "✅ Frontend build complete!"
\ No newline at end of file
@@ -0,0 +1,139 @@
+// Package command runs the external commands claude-squad depends on, such as git, gh and tmux,
+// with a timeout, so a hung hook, credential prompt or server fails the operation with a
+// *TimeoutError instead of freezing the TUI.
+package command
+
+import (
+	"claude-squad/config"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// waitDelay is how long a killed command's output is waited for: hooks may leave children
+// holding its pipes open.
+const waitDelay = 2 * time.Second
+
+var timeouts atomic.Pointer[config.TimeoutsConfig]
+
+// SetTimeouts sets the timeouts returned by GitTimeout, NetworkTimeout and TmuxTimeout.
+func SetTimeouts(cfg config.TimeoutsConfig) {
+	timeouts.Store(&cfg)
+}
+
+func current() config.TimeoutsConfig {
+	if cfg := timeouts.Load(); cfg != nil {
+		return *cfg
+	}
+	return config.TimeoutsConfig{}
+}
+
+// GitTimeout returns the timeout of local git commands.
+func GitTimeout() time.Duration {
+	return current().Git()
+}
+
+// NetworkTimeout returns the timeout of commands talking to a remote.
+func NetworkTimeout() time.Duration {
+	return current().Network()
+}
+
+// TmuxTimeout returns the timeout of tmux commands.
+func TmuxTimeout() time.Duration {
+	return current().Tmux()
+}
+
+// TimeoutError is returned by commands killed for running longer than their timeout.
+type TimeoutError struct {
+	// Command is the program and its subcommand, such as "git commit".
+	Command string
+	// Dir is the directory the command ran in, if known.
+	Dir     string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	where := ""
+	if e.Dir != "" {
+		where = " in " + e.Dir
+	}
+	return fmt.Sprintf("%s timed out after %s: a hook, a prompt or a server may be stuck. Run it%s "+
+		"to see what it waits for, or raise the timeouts in the config", e.Command, e.Timeout, where)
+}
+
+// Cmd is an exec.Cmd killed once its timeout elapses. Its Run, Output and CombinedOutput return a
+// *TimeoutError when it was killed.
+type Cmd struct {
+	*exec.Cmd
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+}
+
+// New returns a command running name with args, killed if it runs longer than timeout. A zero
+// timeout never kills it.
+func New(timeout time.Duration, name string, args ...string) *Cmd {
+	return NewContext(context.Background(), timeout, name, args...)
+}
+
+// NewContext is New, also killing the command when ctx is done.
+func NewContext(ctx context.Context, timeout time.Duration, name string, args ...string) *Cmd {
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = waitDelay
+	return &Cmd{Cmd: cmd, ctx: ctx, cancel: cancel, timeout: timeout}
+}
+
+func (c *Cmd) Run() error {
+	defer c.cancel()
+	return c.check(c.Cmd.Run())
+}
+
+func (c *Cmd) Output() ([]byte, error) {
+	defer c.cancel()
+	out, err := c.Cmd.Output()
+	return out, c.check(err)
+}
+
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	defer c.cancel()
+	out, err := c.Cmd.CombinedOutput()
+	return out, c.check(err)
+}
+
+// check returns a *TimeoutError in place of err if the command was killed for running too long.
+func (c *Cmd) check(err error) error {
+	if err == nil || !errors.Is(c.ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	name, dir := describe(c.Args)
+	if c.Dir != "" {
+		dir = c.Dir
+	}
+	return &TimeoutError{Command: name, Dir: dir, Timeout: c.timeout}
+}
+
+// describe returns the program and subcommand of args, skipping options such as git's -C dir, and
+// the directory given with -C.
+func describe(args []string) (name, dir string) {
+	name = args[0]
+	for i := 1; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-C" || arg == "-c" || arg == "-L":
+			if arg == "-C" && i+1 < len(args) {
+				dir = args[i+1]
+			}
+			i++
+		case !strings.HasPrefix(arg, "-"):
+			return name + " " + arg, dir
+		}
+	}
+	return name, dir
+}
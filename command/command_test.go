@@ -0,0 +1,45 @@
+package command
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	// The child sleeping in the background keeps the output pipe open after sh is killed
+	start := time.Now()
+	_, err := New(200*time.Millisecond, "sh", "-c", "sleep 10 & sleep 10").Output()
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Output() of a hung command = %v, want a *TimeoutError", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Output() returned after %s, want it killed after its timeout", elapsed)
+	}
+	if timeoutErr.Command != "sh" || timeoutErr.Timeout != 200*time.Millisecond {
+		t.Errorf("TimeoutError = %+v, want sh and 200ms", timeoutErr)
+	}
+
+	if err := New(time.Second, "sh", "-c", "exit 3").Run(); err == nil || errors.As(err, &timeoutErr) {
+		t.Errorf("Run() of a failing command = %v, want its exit error", err)
+	}
+	if err := New(0, "true").Run(); err != nil {
+		t.Errorf("Run() without a timeout = %v", err)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	for _, tt := range []struct {
+		args      []string
+		name, dir string
+	}{
+		{args: []string{"git", "-C", "/repo", "-c", "core.hooksPath=x", "commit", "-m", "msg"}, name: "git commit", dir: "/repo"},
+		{args: []string{"tmux", "-L", "test", "capture-pane", "-p"}, name: "tmux capture-pane"},
+		{args: []string{"gh", "--version"}, name: "gh"},
+	} {
+		if name, dir := describe(tt.args); name != tt.name || dir != tt.dir {
+			t.Errorf("describe(%q) = %q, %q, want %q, %q", tt.args, name, dir, tt.name, tt.dir)
+		}
+	}
+}
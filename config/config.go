@@ -6,6 +6,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 )
 
 const ConfigFileName = "config.json"
@@ -26,6 +30,7 @@ type Config struct {
 	// AutoYes is a flag to automatically accept all prompts.
 	AutoYes bool `json:"auto_yes"`
 	// DaemonPollInterval is the interval (ms) at which the daemon polls sessions for autoyes mode.
+	// Daemon.PollMs replaces it when set.
 	DaemonPollInterval int `json:"daemon_poll_interval"`
 	
 	// Web Server Configuration
@@ -37,7 +42,621 @@ type Config struct {
 	WebServerUseTLS      bool   `json:"web_server_use_tls"`
 	WebServerTLSCert     string `json:"web_server_tls_cert"`
 	WebServerTLSKey      string `json:"web_server_tls_key"`
+	// WebServerCorsOrigin lists, separated by commas, the origins of pages other than the web UI
+	// allowed to call the API, such as a dev server. Pages of other origins can't read its
+	// responses or change anything.
 	WebServerCorsOrigin  string `json:"web_server_cors_origin"`
+	// WebServerBasePath is the path the web server is reached under behind a reverse proxy, such
+	// as /claude-squad. Empty serves it at the root.
+	WebServerBasePath string `json:"web_server_base_path"`
+	// WebServerSocket is the path of a unix socket the web server listens on instead of TCP, for
+	// local integrations such as editors. Access is limited by the permissions of the socket
+	// file, so its connections are not asked for the auth token.
+	WebServerSocket string `json:"web_server_socket"`
+	// WebServerRequireAuth asks web clients for WebServerAuthToken or a token scoped to one
+	// instance. Connections from localhost are exempt when WebServerAllowLocalhost is set, unless
+	// they present a scoped token.
+	WebServerRequireAuth bool `json:"web_server_require_auth"`
+
+	// Tracing Configuration
+	// TracingEnabled exports OpenTelemetry spans for instance operations and web requests.
+	TracingEnabled bool `json:"tracing_enabled"`
+	// TracingEndpoint is the OTLP/HTTP collector address (host:port). If empty, the standard
+	// OTEL_EXPORTER_OTLP_ENDPOINT environment variable is used.
+	TracingEndpoint string `json:"tracing_endpoint"`
+	// TracingInsecure sends spans over plain HTTP instead of HTTPS.
+	TracingInsecure bool `json:"tracing_insecure"`
+
+	// Webhooks receive instance lifecycle events.
+	Webhooks []WebhookConfig `json:"webhooks"`
+
+	// SnapshotPromptPatterns are case-insensitive substrings of prompts that snapshot the worktree
+	// before the prompt is sent, so it can be rolled back. "*" snapshots before every prompt.
+	SnapshotPromptPatterns []string `json:"snapshot_prompt_patterns"`
+
+	// GitAdd decides which new files are staged for the diff stats and when changes are submitted.
+	GitAdd GitAddPolicy `json:"git_add"`
+	// ProgramGitAdd overrides GitAdd for instances running a program, keyed by the program's
+	// command name, e.g. "aider".
+	ProgramGitAdd map[string]GitAddPolicy `json:"program_git_add"`
+
+	// GitConfig holds git settings applied to every worktree alone, such as user.email,
+	// commit.gpgsign, http.proxy or credential.helper, leaving the repository's and the user's
+	// config untouched.
+	GitConfig map[string]string `json:"git_config"`
+	// ProgramGitConfig adds to and overrides GitConfig for instances running a program, keyed by
+	// the program's command name, e.g. "aider".
+	ProgramGitConfig map[string]map[string]string `json:"program_git_config"`
+
+	// PushScan checks the changes of a worktree for large files and secrets before they are pushed.
+	PushScan PushScanConfig `json:"push_scan"`
+	// ComplianceCommand is a shell command, such as a license header check, run in the worktree
+	// before its changes are pushed. The push is blocked if it fails.
+	ComplianceCommand string `json:"compliance_command"`
+	// CheckpointCommits is what a push does with the checkpoint commits made when an instance is
+	// paused: CheckpointsKeep, CheckpointsSquash or CheckpointsFixup. Unless it is CheckpointsKeep,
+	// pausing commits without pushing.
+	CheckpointCommits string `json:"checkpoint_commits"`
+	// VCS is the kind of workspace new instances work in: "git" for a worktree of the repository,
+	// the default, or "dir" for a copy of the directory, which needn't be a git repository.
+	VCS string `json:"vcs"`
+
+	// Intervals tunes how often the TUI and the web server poll and refresh.
+	Intervals IntervalsConfig `json:"intervals"`
+
+	// Tickets configures how ticket references name branches and commits, and whether the
+	// tickets are commented on.
+	Tickets TicketConfig `json:"tickets"`
+
+	// DisableConversations stops reading the session files Claude Code writes under
+	// ~/.claude/projects for the conversation tab and API.
+	DisableConversations bool `json:"disable_conversations"`
+
+	// PromptLint checks prompts entered in the TUI for their size, secrets and being empty before
+	// they are sent.
+	PromptLint PromptLintConfig `json:"prompt_lint"`
+
+	// Windows are companion windows, such as a test watcher, opened next to the program in the
+	// tmux session of every instance.
+	Windows []WindowConfig `json:"windows"`
+
+	// Restart decides whether the program of an instance is started again when it exits.
+	Restart RestartPolicy `json:"restart"`
+	// ProgramRestart overrides Restart for instances running a program, keyed by the program's
+	// command name, e.g. "aider".
+	ProgramRestart map[string]RestartPolicy `json:"program_restart"`
+
+	// QuickActions are shortcuts, numbered from 1 in the menu, that send a prompt or keys to the
+	// selected instance, e.g. "/compact" or Escape.
+	QuickActions []QuickAction `json:"quick_actions"`
+
+	// TitlePolicy decides which instance titles are accepted: TitleDefault, TitleStrict or
+	// TitlePermissive. Empty means TitleDefault.
+	TitlePolicy string `json:"title_policy"`
+
+	// Repositories restricts the repositories instances can be created in, from the TUI, the web
+	// server or any other entry point. The daemon leaves alone instances outside of them.
+	Repositories RepositoryPolicy `json:"repositories"`
+
+	// DryRun makes destructive git operations only log what they would do, like the --dry-run
+	// flag: removing worktrees, deleting branches and snapshots, and rolling worktrees back.
+	DryRun bool `json:"dry_run"`
+
+	// FetchBase makes new instances start from the default branch of origin, fetched before the
+	// worktree is created, instead of the local HEAD, which may be days behind.
+	FetchBase bool `json:"fetch_base"`
+
+	// Submodules configures checking out the submodules of new worktrees.
+	Submodules SubmodulesConfig `json:"submodules"`
+
+	// PushCredentials configures how pushes claude-squad runs authenticate over HTTPS. Pushes
+	// never prompt for credentials: they fail when git has none.
+	PushCredentials PushCredentialsConfig `json:"push_credentials"`
+
+	// Timeouts bounds how long the git, gh and tmux commands claude-squad runs may take, so a
+	// hung hook or server fails the operation instead of freezing the TUI.
+	Timeouts TimeoutsConfig `json:"timeouts"`
+
+	// Daemon tunes the daemon that runs auto-yes and the restart policies while the TUI is closed.
+	Daemon DaemonConfig `json:"daemon"`
+
+	// SessionTemplates are named profiles for launching instances, picked when creating one in the
+	// TUI or with --template, so a team launches its agents the same way in every repository.
+	SessionTemplates map[string]SessionTemplate `json:"session_templates,omitempty"`
+}
+
+// SessionTemplate bundles how an instance is launched.
+type SessionTemplate struct {
+	// Program runs in the instance instead of the default program.
+	Program string `json:"program,omitempty"`
+	// AutoYes turns auto-yes on in the instance.
+	AutoYes bool `json:"auto_yes,omitempty"`
+	// BranchPrefix replaces session/ at the start of the instance's branch, such as alice/.
+	BranchPrefix string `json:"branch_prefix,omitempty"`
+	// WorktreeDir is the directory the instance's worktree is created in, instead of the
+	// worktrees directory of the config. A leading ~/ is the home directory.
+	WorktreeDir string `json:"worktree_dir,omitempty"`
+	// Prompt prefills the prompt asked for once the instance has started.
+	Prompt string `json:"prompt,omitempty"`
+}
+
+var (
+	sessionTemplateName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+	branchPrefixPattern = regexp.MustCompile(`^[A-Za-z0-9._/-]*$`)
+)
+
+// ValidateSessionTemplateName returns an error unless name is up to 64 letters, digits, dots,
+// dashes and underscores.
+func ValidateSessionTemplateName(name string) error {
+	if !sessionTemplateName.MatchString(name) {
+		return fmt.Errorf("invalid session template name %q: use up to 64 letters, digits, dots, dashes and underscores", name)
+	}
+	return nil
+}
+
+// Validate returns an error if the branch prefix isn't fit for a branch name or the worktree
+// directory isn't absolute.
+func (t SessionTemplate) Validate() error {
+	if !branchPrefixPattern.MatchString(t.BranchPrefix) || strings.Contains(t.BranchPrefix, "..") ||
+		strings.HasPrefix(t.BranchPrefix, "/") || strings.HasPrefix(t.BranchPrefix, "-") {
+		return fmt.Errorf("invalid branch prefix %q: use letters, digits, dots, dashes, underscores and slashes", t.BranchPrefix)
+	}
+	if t.WorktreeDir != "" && !strings.HasPrefix(t.WorktreeDir, "~/") && !filepath.IsAbs(t.WorktreeDir) {
+		return fmt.Errorf("worktree directory %q must be absolute or start with ~/", t.WorktreeDir)
+	}
+	return nil
+}
+
+// WorktreePath returns WorktreeDir with a leading ~/ replaced by the home directory.
+func (t SessionTemplate) WorktreePath() (string, error) {
+	if !strings.HasPrefix(t.WorktreeDir, "~/") {
+		return t.WorktreeDir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, t.WorktreeDir[2:]), nil
+}
+
+// SessionTemplateNames returns the names of the session templates, sorted.
+func (c *Config) SessionTemplateNames() []string {
+	names := make([]string, 0, len(c.SessionTemplates))
+	for name := range c.SessionTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DaemonConfig tunes the daemon.
+type DaemonConfig struct {
+	// PollMs is how often the daemon checks the instances it watches, in milliseconds.
+	PollMs int `json:"poll_ms"`
+	// Tags scope the daemon to the instances tagged with one of them. Empty watches every
+	// instance.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// DaemonPoll returns how often the daemon checks instances, between 100ms and 1m: Daemon.PollMs,
+// or DaemonPollInterval when it isn't set, 1s by default.
+func (c *Config) DaemonPoll() time.Duration {
+	ms := c.Daemon.PollMs
+	if ms <= 0 {
+		ms = c.DaemonPollInterval
+	}
+	return clampInterval(ms, time.Second, 100*time.Millisecond, time.Minute)
+}
+
+// RepositoryPolicy restricts the repositories instances can be created in. Entries are paths,
+// such as ~/work/api, which also cover every repository below them, or glob patterns matched
+// against the repository root, such as /srv/*/app.
+type RepositoryPolicy struct {
+	// Allow lists the only repositories allowed. Empty allows every repository not denied.
+	Allow []string `json:"allow,omitempty"`
+	// Deny lists repositories refused even when allowed.
+	Deny []string `json:"deny,omitempty"`
+}
+
+// Restricted reports whether the policy refuses any repository.
+func (p RepositoryPolicy) Restricted() bool {
+	return len(p.Allow) > 0 || len(p.Deny) > 0
+}
+
+// Policies checking instance titles, which name the tmux session and git branch of instances.
+const (
+	// TitleDefault accepts ASCII letters, digits, spaces, '-', '_' and '.'.
+	TitleDefault = "default"
+	// TitleStrict accepts lowercase ASCII slugs such as fix-login, so branch and tmux session
+	// names are the title itself.
+	TitleStrict = "strict"
+	// TitlePermissive accepts any printable characters, such as slashes, colons and non-ASCII
+	// letters. What branch and tmux session names can't hold is dropped or replaced in them.
+	TitlePermissive = "permissive"
+)
+
+// ValidateTitlePolicy returns an error if policy is not a known title policy.
+func ValidateTitlePolicy(policy string) error {
+	switch policy {
+	case "", TitleDefault, TitleStrict, TitlePermissive:
+		return nil
+	}
+	return fmt.Errorf("unknown title policy %q, want %s, %s or %s", policy, TitleDefault, TitleStrict, TitlePermissive)
+}
+
+// QuickAction sends a prompt or a sequence of keys to an instance.
+type QuickAction struct {
+	Label string `json:"label"`
+	// Prompt is sent like a prompt entered in the TUI.
+	Prompt string `json:"prompt,omitempty"`
+	// Keys are sent in order: key names such as "Escape", "Enter", "Tab", "Up" or "C-c", or text
+	// typed as is.
+	Keys []string `json:"keys,omitempty"`
+}
+
+// Validate returns an error if the action has no label, or not exactly one of a prompt and keys.
+func (a QuickAction) Validate() error {
+	if a.Label == "" {
+		return fmt.Errorf("quick action without a label")
+	}
+	if (a.Prompt == "") == (len(a.Keys) == 0) {
+		return fmt.Errorf("quick action %q needs either a prompt or keys", a.Label)
+	}
+	return nil
+}
+
+// WindowConfig is a companion window of the tmux session of instances.
+type WindowConfig struct {
+	Name string `json:"name"`
+	// Command runs in the worktree. Empty opens a shell.
+	Command string `json:"command"`
+}
+
+const (
+	// DefaultTicketBranchTemplate names the branches of instances created with a ticket.
+	DefaultTicketBranchTemplate = "session/{ticket}-{title}"
+	// DefaultTicketCommitTemplate is the message of commits from instances with a ticket.
+	DefaultTicketCommitTemplate = "[{ticket}] update from '{title}' on {time}"
+)
+
+// TicketConfig configures the ticket references of instances, such as a GitHub issue URL or a Jira
+// key.
+type TicketConfig struct {
+	// BranchTemplate names the branch of an instance created with a ticket. {ticket} is replaced
+	// by the ticket key and {title} by the instance title. Empty uses DefaultTicketBranchTemplate.
+	BranchTemplate string `json:"branch_template"`
+	// CommitTemplate is the message of commits from an instance with a ticket. {ticket}, {title}
+	// and {time} are replaced. Empty uses DefaultTicketCommitTemplate.
+	CommitTemplate string `json:"commit_template"`
+	// CommentOnPush comments on the ticket when the branch of its instance is pushed: on GitHub
+	// issues with the gh CLI, on Jira issues through the Jira REST API.
+	CommentOnPush bool `json:"comment_on_push"`
+	// JiraURL is the base URL of the Jira site, e.g. https://example.atlassian.net. Bare issue keys
+	// link to it.
+	JiraURL string `json:"jira_url,omitempty"`
+	// JiraEmail and JiraToken authenticate Jira comments. JiraToken defaults to the
+	// JIRA_API_TOKEN environment variable.
+	JiraEmail string `json:"jira_email,omitempty"`
+	JiraToken string `json:"jira_token,omitempty"`
+}
+
+// Branch returns the branch template, or the default if it is not set.
+func (c TicketConfig) Branch() string {
+	if c.BranchTemplate == "" {
+		return DefaultTicketBranchTemplate
+	}
+	return c.BranchTemplate
+}
+
+// Commit returns the commit message template, or the default if it is not set.
+func (c TicketConfig) Commit() string {
+	if c.CommitTemplate == "" {
+		return DefaultTicketCommitTemplate
+	}
+	return c.CommitTemplate
+}
+
+// DefaultPromptMaxSizeKB is the prompt size above which a prompt is flagged, unless configured.
+const DefaultPromptMaxSizeKB = 64
+
+// PromptLintConfig configures the checks of prompts before they are sent. A flagged prompt is
+// only sent once the warning is overridden. Secrets are matched against the push scan's rules,
+// including its secret_patterns.
+type PromptLintConfig struct {
+	// Disabled sends prompts without checking them.
+	Disabled bool `json:"disabled"`
+	// MaxSizeKB flags prompts larger than this. Zero uses DefaultPromptMaxSizeKB.
+	MaxSizeKB int `json:"max_size_kb"`
+}
+
+// MaxSize returns the prompt size in bytes above which a prompt is flagged.
+func (c PromptLintConfig) MaxSize() int {
+	if c.MaxSizeKB <= 0 {
+		return DefaultPromptMaxSizeKB * 1024
+	}
+	return c.MaxSizeKB * 1024
+}
+
+// IntervalsConfig holds poll and refresh intervals in milliseconds. Zero uses the default and
+// other values are clamped to the bounds of each interval.
+type IntervalsConfig struct {
+	// PreviewTickMs is how often the TUI refreshes the preview and diff of instances.
+	PreviewTickMs int `json:"preview_tick_ms"`
+	// MonitorPollMs is how often the web server checks instances for new output.
+	MonitorPollMs int `json:"monitor_poll_ms"`
+	// InstanceRefreshMs is how often the web server reloads the list of instances.
+	InstanceRefreshMs int `json:"instance_refresh_ms"`
+	// WebSocketPingMs is how often output WebSocket streams are pinged to keep them open.
+	WebSocketPingMs int `json:"websocket_ping_ms"`
+	// TerminalPingMs is how often interactive terminal connections are pinged.
+	TerminalPingMs int `json:"terminal_ping_ms"`
+}
+
+// clampInterval converts ms to a duration within [lo, hi], or def if ms is not set.
+func clampInterval(ms int, def, lo, hi time.Duration) time.Duration {
+	if ms <= 0 {
+		return def
+	}
+	return min(max(time.Duration(ms)*time.Millisecond, lo), hi)
+}
+
+// PreviewTick returns the TUI refresh interval, between 100ms and 5s.
+func (c IntervalsConfig) PreviewTick() time.Duration {
+	return clampInterval(c.PreviewTickMs, 500*time.Millisecond, 100*time.Millisecond, 5*time.Second)
+}
+
+// MonitorPoll returns the web output poll interval, between 100ms and 5s.
+func (c IntervalsConfig) MonitorPoll() time.Duration {
+	return clampInterval(c.MonitorPollMs, 500*time.Millisecond, 100*time.Millisecond, 5*time.Second)
+}
+
+// InstanceRefresh returns the web instance list refresh interval, between 1s and 5m.
+func (c IntervalsConfig) InstanceRefresh() time.Duration {
+	return clampInterval(c.InstanceRefreshMs, 10*time.Second, time.Second, 5*time.Minute)
+}
+
+// WebSocketPing returns the output stream ping interval, between 5s and 5m.
+func (c IntervalsConfig) WebSocketPing() time.Duration {
+	return clampInterval(c.WebSocketPingMs, 30*time.Second, 5*time.Second, 5*time.Minute)
+}
+
+// TerminalPing returns the terminal connection ping interval, between 5s and 5m.
+func (c IntervalsConfig) TerminalPing() time.Duration {
+	return clampInterval(c.TerminalPingMs, 15*time.Second, 5*time.Second, 5*time.Minute)
+}
+
+// TimeoutsConfig holds the timeouts of external commands in seconds. Zero uses the default and a
+// negative value disables the timeout.
+type TimeoutsConfig struct {
+	// GitSeconds bounds local git commands, such as status, add and commit, whose hooks may hang.
+	GitSeconds int `json:"git_seconds"`
+	// NetworkSeconds bounds the commands talking to a remote: git push and fetch, Git LFS
+	// transfers, submodule checkouts and gh.
+	NetworkSeconds int `json:"network_seconds"`
+	// TmuxSeconds bounds tmux commands, other than attaching to a session.
+	TmuxSeconds int `json:"tmux_seconds"`
+}
+
+// timeout converts seconds to a duration, def if seconds is not set, or 0 for no timeout.
+func timeout(seconds int, def time.Duration) time.Duration {
+	switch {
+	case seconds == 0:
+		return def
+	case seconds < 0:
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Git returns the timeout of local git commands, 2 minutes by default.
+func (c TimeoutsConfig) Git() time.Duration {
+	return timeout(c.GitSeconds, 2*time.Minute)
+}
+
+// Network returns the timeout of commands talking to a remote, 10 minutes by default.
+func (c TimeoutsConfig) Network() time.Duration {
+	return timeout(c.NetworkSeconds, 10*time.Minute)
+}
+
+// Tmux returns the timeout of tmux commands, 10 seconds by default.
+func (c TimeoutsConfig) Tmux() time.Duration {
+	return timeout(c.TmuxSeconds, 10*time.Second)
+}
+
+// PushScanConfig configures the scan that blocks pushes of large files and secrets.
+type PushScanConfig struct {
+	// Enabled runs the scan before every push.
+	Enabled bool `json:"enabled"`
+	// MaxFileSizeKB blocks added or modified files larger than this. 0 disables the size check.
+	MaxFileSizeKB int64 `json:"max_file_size_kb"`
+	// SecretPatterns are regular expressions matched against added lines, on top of the built-in
+	// rules for private keys, cloud and API tokens, and .env files.
+	SecretPatterns []string `json:"secret_patterns,omitempty"`
+}
+
+// SubmodulesConfig configures the submodules of new worktrees, which git leaves uninitialized.
+type SubmodulesConfig struct {
+	// Update initializes and checks out the submodules of every new worktree.
+	Update bool `json:"update"`
+	// Depth fetches submodules with that many commits of history. 0 fetches all of it.
+	Depth int `json:"depth"`
+	// Recursive also checks out the submodules of submodules.
+	Recursive bool `json:"recursive"`
+}
+
+// PushCredentialsConfig gives pushes over HTTPS credentials besides git's credential helpers.
+type PushCredentialsConfig struct {
+	// TokenEnv names the environment variable holding a token, such as GITHUB_TOKEN, sent to the
+	// host of origin, and to gh, when pushing.
+	TokenEnv string `json:"token_env,omitempty"`
+	// TokenUser is the user name the token is sent with. Empty means x-access-token, which
+	// GitHub accepts for every kind of token.
+	TokenUser string `json:"token_user,omitempty"`
+	// Askpass is a program git runs to ask for the user name and the password, as GIT_ASKPASS.
+	Askpass string `json:"askpass,omitempty"`
+}
+
+// Modes of a GitAddPolicy.
+const (
+	// GitAddAll stages every new file.
+	GitAddAll = "all"
+	// GitAddExcept stages new files that don't match the policy's Except patterns.
+	GitAddExcept = "except"
+	// GitAddNever stages no new file. Changes to tracked files are still committed.
+	GitAddNever = "never"
+)
+
+// GitAddPolicy decides which new files an agent created are staged automatically.
+type GitAddPolicy struct {
+	// Mode is GitAddAll, GitAddExcept or GitAddNever. Empty means GitAddAll.
+	Mode string `json:"mode"`
+	// Except holds git pathspec patterns, e.g. "*.log" or "scratch/", of new files that are not
+	// staged in GitAddExcept mode.
+	Except []string `json:"except,omitempty"`
+}
+
+// Validate returns an error if the mode of the policy is unknown.
+func (p GitAddPolicy) Validate() error {
+	switch p.Mode {
+	case "", GitAddAll, GitAddExcept, GitAddNever:
+		return nil
+	}
+	return fmt.Errorf("unknown git add mode %q, want %s, %s or %s", p.Mode, GitAddAll, GitAddExcept, GitAddNever)
+}
+
+// Values of Config.CheckpointCommits.
+const (
+	// CheckpointsKeep pushes checkpoint commits as they are, the default.
+	CheckpointsKeep = "keep"
+	// CheckpointsSquash folds checkpoint commits into the commits after them before a push.
+	CheckpointsSquash = "squash"
+	// CheckpointsFixup rewords checkpoint commits as fixups of the commits before them before a
+	// push, for git rebase --autosquash.
+	CheckpointsFixup = "fixup"
+)
+
+// Modes of a RestartPolicy.
+const (
+	// RestartNever leaves exited programs for a restart by hand.
+	RestartNever = "never"
+	// RestartOnCrash restarts programs that exited with a non-zero or unknown status.
+	RestartOnCrash = "on-crash"
+	// RestartAlways restarts programs however they exited.
+	RestartAlways = "always"
+)
+
+const (
+	// DefaultRestartMaxRetries is the number of restarts in a row before giving up, unless
+	// configured.
+	DefaultRestartMaxRetries = 3
+	// DefaultRestartBackoff is the wait before the first restart, unless configured.
+	DefaultRestartBackoff = 5 * time.Second
+	// maxRestartBackoff caps the wait before a restart as it doubles.
+	maxRestartBackoff = 5 * time.Minute
+)
+
+// RestartPolicy decides whether and when an exited program is started again.
+type RestartPolicy struct {
+	// Mode is RestartNever, RestartOnCrash or RestartAlways. Empty means RestartNever.
+	Mode string `json:"mode"`
+	// MaxRetries is the number of restarts in a row before giving up. Zero uses
+	// DefaultRestartMaxRetries.
+	MaxRetries int `json:"max_retries,omitempty"`
+	// BackoffMs is the wait before the first restart, doubled for each restart in a row after it.
+	// Zero uses DefaultRestartBackoff.
+	BackoffMs int `json:"backoff_ms,omitempty"`
+}
+
+// Validate returns an error if the mode of the policy is unknown.
+func (p RestartPolicy) Validate() error {
+	switch p.Mode {
+	case "", RestartNever, RestartOnCrash, RestartAlways:
+		return nil
+	}
+	return fmt.Errorf("unknown restart mode %q, want %s, %s or %s", p.Mode, RestartNever, RestartOnCrash, RestartAlways)
+}
+
+// Enabled reports whether the policy restarts programs at all.
+func (p RestartPolicy) Enabled() bool {
+	return p.Mode == RestartOnCrash || p.Mode == RestartAlways
+}
+
+// Retries returns the number of restarts in a row before giving up.
+func (p RestartPolicy) Retries() int {
+	if p.MaxRetries <= 0 {
+		return DefaultRestartMaxRetries
+	}
+	return p.MaxRetries
+}
+
+// Backoff returns the wait before restart number attempt in a row, counted from 1.
+func (p RestartPolicy) Backoff(attempt int) time.Duration {
+	backoff := DefaultRestartBackoff
+	if p.BackoffMs > 0 {
+		backoff = time.Duration(p.BackoffMs) * time.Millisecond
+	}
+	for n := 1; n < attempt && backoff < maxRestartBackoff; n++ {
+		backoff *= 2
+	}
+	return min(backoff, maxRestartBackoff)
+}
+
+// WebBasePath returns WebServerBasePath with a leading slash and no trailing one, or "" to serve
+// the web server at the root.
+func (c *Config) WebBasePath() string {
+	base := strings.Trim(strings.TrimSpace(c.WebServerBasePath), "/")
+	if base == "" {
+		return ""
+	}
+	return "/" + base
+}
+
+// WebAllowedOrigins returns the origins listed in WebServerCorsOrigin, without trailing slashes.
+func (c *Config) WebAllowedOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(c.WebServerCorsOrigin, ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// WebServerAddress describes where the web server is reached: the URL of its UI, or its unix
+// socket.
+func (c *Config) WebServerAddress() string {
+	if c.WebServerSocket != "" {
+		return "unix:" + c.WebServerSocket
+	}
+	scheme := "http"
+	if c.WebServerUseTLS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d%s/", scheme, c.WebServerHost, c.WebServerPort, c.WebBasePath())
+}
+
+// RestartEnabled reports whether the restart policy, or the one of any program, restarts
+// programs.
+func (c *Config) RestartEnabled() bool {
+	if c.Restart.Enabled() {
+		return true
+	}
+	for _, policy := range c.ProgramRestart {
+		if policy.Enabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookConfig is an outgoing webhook for instance lifecycle events.
+type WebhookConfig struct {
+	// URL receives a POST with a JSON body for each event.
+	URL string `json:"url"`
+	// Secret signs each payload with HMAC-SHA256. Deliveries are unsigned if it is empty.
+	Secret string `json:"secret"`
+	// Events limits the webhook to these event types, e.g. "instance.created". Empty means all.
+	Events []string `json:"events"`
 }
 
 // DefaultConfig returns the default configuration
@@ -61,6 +680,26 @@ func DefaultConfig() *Config {
 		WebServerTLSCert:      "",
 		WebServerTLSKey:       "",
 		WebServerCorsOrigin:   "http://localhost:3000",
+
+		// Tracing defaults
+		TracingEnabled:  false,
+		TracingEndpoint: "",
+		TracingInsecure: false,
+
+		Webhooks: []WebhookConfig{},
+
+		SnapshotPromptPatterns: []string{"delete", "remove", "refactor", "rewrite", "migrate", "rm -rf"},
+
+		GitAdd:        GitAddPolicy{Mode: GitAddAll},
+		ProgramGitAdd: map[string]GitAddPolicy{},
+
+		GitConfig:        map[string]string{},
+		ProgramGitConfig: map[string]map[string]string{},
+
+		PushScan: PushScanConfig{Enabled: true, MaxFileSizeKB: 10 * 1024},
+
+		Restart:        RestartPolicy{Mode: RestartNever},
+		ProgramRestart: map[string]RestartPolicy{},
 	}
 }
 
@@ -97,6 +736,29 @@ func LoadConfig() *Config {
 	return &config
 }
 
+// ReadConfig reads the configuration from disk, or returns the default configuration if there is
+// none yet. Unlike LoadConfig, it fails when the file can't be read or parsed, so a command saving
+// the configuration back doesn't replace a broken file with the defaults.
+func ReadConfig() (*Config, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config directory: %w", err)
+	}
+	configPath := filepath.Join(configDir, ConfigFileName)
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return DefaultConfig(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+	}
+	return &config, nil
+}
+
 // saveConfig saves the configuration to disk
 func saveConfig(config *Config) error {
 	configDir, err := GetConfigDir()
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntervals(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals IntervalsConfig
+		got       func(IntervalsConfig) time.Duration
+		want      time.Duration
+	}{
+		{name: "default", intervals: IntervalsConfig{}, got: IntervalsConfig.PreviewTick, want: 500 * time.Millisecond},
+		{name: "set", intervals: IntervalsConfig{MonitorPollMs: 2000}, got: IntervalsConfig.MonitorPoll, want: 2 * time.Second},
+		{name: "below bound", intervals: IntervalsConfig{PreviewTickMs: 10}, got: IntervalsConfig.PreviewTick, want: 100 * time.Millisecond},
+		{name: "above bound", intervals: IntervalsConfig{InstanceRefreshMs: 3600000}, got: IntervalsConfig.InstanceRefresh, want: 5 * time.Minute},
+		{name: "negative", intervals: IntervalsConfig{WebSocketPingMs: -1}, got: IntervalsConfig.WebSocketPing, want: 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.got(tt.intervals); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaemonPoll(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   time.Duration
+	}{
+		{name: "default", config: Config{}, want: time.Second},
+		{name: "legacy", config: Config{DaemonPollInterval: 2000}, want: 2 * time.Second},
+		{name: "set", config: Config{DaemonPollInterval: 2000, Daemon: DaemonConfig{PollMs: 500}}, want: 500 * time.Millisecond},
+		{name: "below bound", config: Config{Daemon: DaemonConfig{PollMs: 1}}, want: 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.DaemonPoll(); got != tt.want {
+				t.Errorf("DaemonPoll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	timeouts := TimeoutsConfig{NetworkSeconds: 30, TmuxSeconds: -1}
+	if got := timeouts.Git(); got != 2*time.Minute {
+		t.Errorf("Git() by default = %v, want 2m", got)
+	}
+	if got := timeouts.Network(); got != 30*time.Second {
+		t.Errorf("Network() set to 30 = %v, want 30s", got)
+	}
+	if got := timeouts.Tmux(); got != 0 {
+		t.Errorf("Tmux() set to -1 = %v, want no timeout", got)
+	}
+}
+
+func TestRestartPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		policy  RestartPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{policy: RestartPolicy{}, attempt: 1, want: DefaultRestartBackoff},
+		{policy: RestartPolicy{BackoffMs: 1000}, attempt: 3, want: 4 * time.Second},
+		{policy: RestartPolicy{BackoffMs: 60000}, attempt: 10, want: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Backoff(tt.attempt); got != tt.want {
+			t.Errorf("%+v.Backoff(%d) = %v, want %v", tt.policy, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestSessionTemplateValidate(t *testing.T) {
+	tests := []struct {
+		template SessionTemplate
+		valid    bool
+	}{
+		{template: SessionTemplate{}, valid: true},
+		{template: SessionTemplate{BranchPrefix: "alice/", WorktreeDir: "~/worktrees"}, valid: true},
+		{template: SessionTemplate{WorktreeDir: "/srv/worktrees"}, valid: true},
+		{template: SessionTemplate{BranchPrefix: "a b/"}},
+		{template: SessionTemplate{BranchPrefix: "../"}},
+		{template: SessionTemplate{BranchPrefix: "-f"}},
+		{template: SessionTemplate{WorktreeDir: "worktrees"}},
+	}
+	for _, tt := range tests {
+		if err := tt.template.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v.Validate() = %v, want valid %v", tt.template, err, tt.valid)
+		}
+	}
+	for name, valid := range map[string]bool{"backend": true, "Team_A.v2": true, "": false, "two words": false, "-x": false} {
+		if err := ValidateSessionTemplateName(name); (err == nil) != valid {
+			t.Errorf("ValidateSessionTemplateName(%q) = %v, want valid %v", name, err, valid)
+		}
+	}
+}
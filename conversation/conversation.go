@@ -0,0 +1,388 @@
+// Package conversation reads the session files Claude Code writes under ~/.claude/projects, giving
+// a structured view of an instance's turns and tool calls rather than what its pane shows.
+package conversation
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits on the text kept of each tool call, which can be whole files.
+const (
+	MaxToolInputLength  = 2000
+	MaxToolResultLength = 4000
+)
+
+// ErrNoConversation is returned when Claude Code has no session file for a directory.
+var ErrNoConversation = errors.New("no Claude Code conversation found")
+
+// Conversation is a Claude Code session.
+type Conversation struct {
+	SessionID string `json:"session_id"`
+	// Path is the session file.
+	Path string `json:"path"`
+	// Model is the model of the latest assistant turn.
+	Model   string    `json:"model,omitempty"`
+	Summary string    `json:"summary,omitempty"`
+	Turns   []Turn    `json:"turns"`
+	Usage   Usage     `json:"usage"`
+	Updated time.Time `json:"updated_at"`
+}
+
+// Turn is a message of the user or the assistant.
+type Turn struct {
+	// Role is "user" or "assistant".
+	Role      string     `json:"role"`
+	Time      time.Time  `json:"time"`
+	Text      string     `json:"text,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// ToolCall is a tool the assistant used and its result, once the user side has sent it.
+type ToolCall struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Input is the tool input as JSON, truncated to MaxToolInputLength.
+	Input string `json:"input"`
+	// Result is the tool output, truncated to MaxToolResultLength.
+	Result  string `json:"result,omitempty"`
+	IsError bool   `json:"is_error,omitempty"`
+	Done    bool   `json:"done"`
+}
+
+// Usage totals the tokens of the assistant turns.
+type Usage struct {
+	InputTokens     int `json:"input_tokens"`
+	OutputTokens    int `json:"output_tokens"`
+	CacheReadTokens int `json:"cache_read_tokens"`
+}
+
+// ProjectsDir returns the directory Claude Code keeps session files in: projects in
+// $CLAUDE_CONFIG_DIR, or in ~/.claude.
+func ProjectsDir() (string, error) {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "projects"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".claude", "projects"), nil
+}
+
+// ProjectDirName returns the name of the directory of sessions started in dir: its absolute path
+// with every character other than a letter or digit replaced by '-'.
+func ProjectDirName(dir string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, dir)
+}
+
+// FindSession returns the most recently written session file of sessions started in dir.
+func FindSession(dir string) (string, error) {
+	projects, err := ProjectsDir()
+	if err != nil {
+		return "", err
+	}
+	files, err := filepath.Glob(filepath.Join(projects, ProjectDirName(dir), "*.jsonl"))
+	if err != nil {
+		return "", err
+	}
+	var latest string
+	var latestTime time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if latest == "" || info.ModTime().After(latestTime) {
+			latest, latestTime = file, info.ModTime()
+		}
+	}
+	if latest == "" {
+		return "", ErrNoConversation
+	}
+	return latest, nil
+}
+
+// Load returns the latest conversation of sessions started in dir. Files are parsed once and only
+// their new lines are read on later calls.
+func Load(dir string) (*Conversation, error) {
+	path, err := FindSession(dir)
+	if err != nil {
+		return nil, err
+	}
+	return LoadFile(path)
+}
+
+// cachedFile is a session file parsed up to offset.
+type cachedFile struct {
+	offset int64
+	parser *parser
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]*cachedFile)
+)
+
+// LoadFile returns the conversation of the session file at path.
+func LoadFile(path string) (*Conversation, error) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open conversation: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open conversation: %w", err)
+	}
+
+	cached, ok := cache[path]
+	if !ok || info.Size() < cached.offset {
+		// New, or rewritten since it was parsed
+		cached = &cachedFile{parser: newParser(path)}
+		cache[path] = cached
+	}
+	if info.Size() > cached.offset {
+		if _, err := f.Seek(cached.offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to read conversation: %w", err)
+		}
+		n, err := cached.parser.parse(f, false)
+		cached.offset += n
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cached.parser.conv.Updated.IsZero() {
+		cached.parser.conv.Updated = info.ModTime()
+	}
+	return cached.parser.snapshot(), nil
+}
+
+// Parse reads a whole session file from r.
+func Parse(r io.Reader) (*Conversation, error) {
+	p := newParser("")
+	if _, err := p.parse(r, true); err != nil {
+		return nil, err
+	}
+	return p.snapshot(), nil
+}
+
+// entry is a line of a session file. Only the fields used are decoded.
+type entry struct {
+	Type        string    `json:"type"`
+	SessionID   string    `json:"sessionId"`
+	Timestamp   time.Time `json:"timestamp"`
+	IsSidechain bool      `json:"isSidechain"`
+	IsMeta      bool      `json:"isMeta"`
+	Summary     string    `json:"summary"`
+	Message     struct {
+		ID      string          `json:"id"`
+		Role    string          `json:"role"`
+		Model   string          `json:"model"`
+		Content json.RawMessage `json:"content"`
+		Usage   struct {
+			InputTokens     int `json:"input_tokens"`
+			OutputTokens    int `json:"output_tokens"`
+			CacheReadTokens int `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// block is an element of the content of a message.
+type block struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+	IsError   bool            `json:"is_error"`
+}
+
+// parser builds a conversation from the lines of a session file.
+type parser struct {
+	conv *Conversation
+	// messageID is the ID of the assistant message of the last turn. Claude Code writes each
+	// content block of a message on its own line.
+	messageID string
+	// calls locates tool calls by ID, so results sent later fill them in
+	calls map[string]struct{ turn, call int }
+}
+
+func newParser(path string) *parser {
+	return &parser{
+		conv:  &Conversation{Path: path, Turns: []Turn{}},
+		calls: make(map[string]struct{ turn, call int }),
+	}
+}
+
+// parse reads the complete lines of r and returns the number of bytes they take. Unless whole is
+// set, a last line without a newline is still being written and is left for the next call.
+func (p *parser) parse(r io.Reader, whole bool) (int64, error) {
+	reader := bufio.NewReaderSize(r, 64<<10)
+	var read int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF && (!whole || len(line) == 0) {
+			return read, nil
+		}
+		if err != nil && err != io.EOF {
+			return read, fmt.Errorf("failed to read conversation: %w", err)
+		}
+		read += int64(len(line))
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var e entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			// Claude Code adds entry types over time; skip what can't be read
+			continue
+		}
+		p.add(e)
+	}
+}
+
+func (p *parser) add(e entry) {
+	conv := p.conv
+	if e.SessionID != "" {
+		conv.SessionID = e.SessionID
+	}
+	if e.Timestamp.After(conv.Updated) {
+		conv.Updated = e.Timestamp
+	}
+	switch {
+	case e.Type == "summary":
+		conv.Summary = e.Summary
+		return
+	case e.IsSidechain || e.IsMeta:
+		// Subagent and command bookkeeping entries
+		return
+	case e.Type != "user" && e.Type != "assistant":
+		return
+	}
+
+	var text string
+	var blocks []block
+	if err := json.Unmarshal(e.Message.Content, &text); err != nil {
+		if err := json.Unmarshal(e.Message.Content, &blocks); err != nil {
+			return
+		}
+	}
+
+	if e.Type == "assistant" {
+		if e.Message.Model != "" && !strings.HasPrefix(e.Message.Model, "<") {
+			conv.Model = e.Message.Model
+		}
+		if e.Message.ID == "" || e.Message.ID != p.messageID {
+			// Usage is repeated on every line of a message
+			conv.Usage.InputTokens += e.Message.Usage.InputTokens
+			conv.Usage.OutputTokens += e.Message.Usage.OutputTokens
+			conv.Usage.CacheReadTokens += e.Message.Usage.CacheReadTokens
+		}
+		if e.Message.ID == "" || e.Message.ID != p.messageID || len(conv.Turns) == 0 {
+			conv.Turns = append(conv.Turns, Turn{Role: "assistant", Time: e.Timestamp})
+		}
+		p.messageID = e.Message.ID
+		turn := &conv.Turns[len(conv.Turns)-1]
+		for _, b := range blocks {
+			switch b.Type {
+			case "text":
+				turn.Text = joinText(turn.Text, b.Text)
+			case "tool_use":
+				p.calls[b.ID] = struct{ turn, call int }{len(conv.Turns) - 1, len(turn.ToolCalls)}
+				turn.ToolCalls = append(turn.ToolCalls, ToolCall{ID: b.ID, Name: b.Name, Input: truncate(string(b.Input), MaxToolInputLength)})
+			}
+		}
+		return
+	}
+
+	p.messageID = ""
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			text = joinText(text, b.Text)
+		case "tool_result":
+			if at, ok := p.calls[b.ToolUseID]; ok {
+				call := &conv.Turns[at.turn].ToolCalls[at.call]
+				call.Result = truncate(resultText(b.Content), MaxToolResultLength)
+				call.IsError = b.IsError
+				call.Done = true
+			}
+		}
+	}
+	// Lines carrying only tool results aren't turns of the user
+	if text != "" {
+		conv.Turns = append(conv.Turns, Turn{Role: "user", Time: e.Timestamp, Text: text})
+	}
+}
+
+// snapshot returns a copy of the conversation that later lines don't change.
+func (p *parser) snapshot() *Conversation {
+	conv := *p.conv
+	conv.Turns = make([]Turn, len(p.conv.Turns))
+	for i, turn := range p.conv.Turns {
+		turn.ToolCalls = append([]ToolCall(nil), turn.ToolCalls...)
+		conv.Turns[i] = turn
+	}
+	return &conv
+}
+
+// resultText returns the text of a tool result, a string or a list of text blocks.
+func resultText(content json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text
+	}
+	var blocks []block
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return ""
+	}
+	for _, b := range blocks {
+		if b.Type == "text" {
+			text = joinText(text, b.Text)
+		}
+	}
+	return text
+}
+
+func joinText(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return a + "\n\n" + b
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	// Cut on a rune boundary
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
@@ -0,0 +1,87 @@
+package conversation
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sessionLines = `{"type":"summary","summary":"Fix login redirect","leafUuid":"a"}
+{"type":"user","sessionId":"s1","timestamp":"2025-06-01T12:00:00Z","message":{"role":"user","content":"Fix the login redirect"}}
+{"type":"user","sessionId":"s1","isMeta":true,"timestamp":"2025-06-01T12:00:00Z","message":{"role":"user","content":"<local-command-caveat>"}}
+{"type":"assistant","sessionId":"s1","timestamp":"2025-06-01T12:00:01Z","message":{"id":"m1","role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"Looking at the handler."}],"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":100}}}
+{"type":"assistant","sessionId":"s1","timestamp":"2025-06-01T12:00:02Z","message":{"id":"m1","role":"assistant","model":"claude-sonnet-4","content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"login.go"}}],"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":100}}}
+{"type":"assistant","sessionId":"s1","isSidechain":true,"timestamp":"2025-06-01T12:00:02Z","message":{"id":"m9","role":"assistant","content":[{"type":"text","text":"subagent"}]}}
+{"type":"user","sessionId":"s1","timestamp":"2025-06-01T12:00:03Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":[{"type":"text","text":"package login"}]}]}}
+not json
+`
+
+func TestParse(t *testing.T) {
+	conv, err := Parse(strings.NewReader(sessionLines))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if conv.SessionID != "s1" || conv.Summary != "Fix login redirect" || conv.Model != "claude-sonnet-4" {
+		t.Errorf("conversation = %+v", conv)
+	}
+	if conv.Usage != (Usage{InputTokens: 10, OutputTokens: 5, CacheReadTokens: 100}) {
+		t.Errorf("usage = %+v, want one message counted once", conv.Usage)
+	}
+	if len(conv.Turns) != 2 {
+		t.Fatalf("turns = %+v, want the prompt and one assistant turn", conv.Turns)
+	}
+	if turn := conv.Turns[0]; turn.Role != "user" || turn.Text != "Fix the login redirect" {
+		t.Errorf("first turn = %+v", turn)
+	}
+	turn := conv.Turns[1]
+	if turn.Role != "assistant" || turn.Text != "Looking at the handler." || len(turn.ToolCalls) != 1 {
+		t.Fatalf("second turn = %+v", turn)
+	}
+	call := turn.ToolCalls[0]
+	if call.Name != "Read" || call.Input != `{"file_path":"login.go"}` || call.Result != "package login" || !call.Done {
+		t.Errorf("tool call = %+v", call)
+	}
+}
+
+func TestLoadReadsNewLines(t *testing.T) {
+	t.Setenv("CLAUDE_CONFIG_DIR", t.TempDir())
+	worktree := "/tmp/worktrees/fix.login_1"
+	projects, err := ProjectsDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(projects, "-tmp-worktrees-fix-login-1")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(worktree); err != ErrNoConversation {
+		t.Errorf("Load() without a session error = %v, want ErrNoConversation", err)
+	}
+
+	lines := strings.SplitAfter(sessionLines, "\n")
+	path := filepath.Join(dir, "s1.jsonl")
+	// The last line is still being written
+	if err := os.WriteFile(path, []byte(strings.Join(lines[:5], "")+`{"type":"user"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conv, err := Load(worktree)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(conv.Turns) != 2 || conv.Turns[1].ToolCalls[0].Done {
+		t.Fatalf("turns = %+v, want a pending tool call", conv.Turns)
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := Load(worktree)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if !updated.Turns[1].ToolCalls[0].Done || conv.Turns[1].ToolCalls[0].Done {
+		t.Errorf("tool call done = %v, earlier copy %v; want only the new load to see the result",
+			updated.Turns[1].ToolCalls[0].Done, conv.Turns[1].ToolCalls[0].Done)
+	}
+}
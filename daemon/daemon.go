@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"claude-squad/command"
 	"claude-squad/config"
 	"claude-squad/log"
 	"claude-squad/session"
@@ -9,34 +10,52 @@ import (
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"sync"
 	"syscall"
 	"time"
 )
 
-// RunDaemon runs the daemon process which iterates over all sessions and runs AutoYes mode on them.
-// It's expected that the main process kills the daemon when the main process starts.
-func RunDaemon(cfg *config.Config) error {
+// RunDaemon runs the daemon process which iterates over the sessions it watches, runs AutoYes mode
+// on them if autoYes is set and restarts their exited programs according to the restart policies.
+// The daemon.tags of the config scope it to tagged sessions. It reports what it does in
+// daemon.json, see ReadStats.
+// The daemon runs until it is stopped, alongside the TUI, which leaves the sessions the daemon
+// watches to it. It picks up the sessions created, edited, paused or killed elsewhere from the
+// state file, and never writes it. Only one daemon runs at a time.
+func RunDaemon(cfg *config.Config, autoYes bool) error {
 	log.InfoLog.Printf("starting daemon")
-	state := config.LoadState()
-	storage, err := session.NewStorage(state)
-	if err != nil {
-		return fmt.Errorf("failed to initialize storage: %w", err)
+	if err := claimPID(os.Getpid()); err != nil {
+		return err
 	}
+	defer releasePID(os.Getpid())
 
-	instances, err := storage.LoadInstances()
+	configDir, err := config.GetConfigDir()
 	if err != nil {
-		return fmt.Errorf("failed to load instacnes: %w", err)
+		return fmt.Errorf("failed to get config directory: %w", err)
 	}
-	for _, instance := range instances {
-		// Assume AutoYes is true if the daemon is running.
-		instance.AutoYes = true
+	session.SetRestartPolicies(cfg.Restart, cfg.ProgramRestart)
+	command.SetTimeouts(cfg.Timeouts)
+	if err := session.ValidateTags(cfg.Daemon.Tags); err != nil {
+		return fmt.Errorf("invalid daemon tags: %w", err)
 	}
-
-	pollInterval := time.Duration(cfg.DaemonPollInterval) * time.Millisecond
-
-	// If we get an error for a session, it's likely that we'll keep getting the error. Log every 30 seconds.
-	everyN := log.NewEvery(60 * time.Second)
+	pollInterval := cfg.DaemonPoll()
+	w := &watcher{
+		factory:   session.NewFactory(cfg, "", autoYes),
+		tags:      cfg.Daemon.Tags,
+		statePath: filepath.Join(configDir, config.StateFileName),
+		stats: &Stats{
+			StartedAt:      time.Now(),
+			PollIntervalMs: pollInterval.Milliseconds(),
+			Tags:           cfg.Daemon.Tags,
+			Watched:        []string{},
+			Accepted:       make(map[string]int),
+			Restarts:       make(map[string]int),
+		},
+		// If we get an error for a session, it's likely that we'll keep getting the error. Log every 60 seconds.
+		everyN: log.NewEvery(60 * time.Second),
+	}
+	defer removeStats()
 
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
@@ -45,19 +64,7 @@ func RunDaemon(cfg *config.Config) error {
 		defer wg.Done()
 		ticker := time.NewTimer(pollInterval)
 		for {
-			for _, instance := range instances {
-				// We only store started instances, but check anyway.
-				if instance.Started() && !instance.Paused() {
-					if _, hasPrompt := instance.HasUpdated(); hasPrompt {
-						instance.TapEnter()
-						if err := instance.UpdateDiffStats(); err != nil {
-							if everyN.ShouldLog() {
-								log.WarningLog.Printf("could not update diff stats for %s: %v", instance.Title, err)
-							}
-						}
-					}
-				}
-			}
+			w.poll(time.Now())
 
 			// Handle stop before ticker.
 			select {
@@ -71,7 +78,7 @@ func RunDaemon(cfg *config.Config) error {
 		}
 	}()
 
-	// Notify on SIGINT (Ctrl+C) and SIGTERM. Save instances before
+	// Notify on SIGINT (Ctrl+C) and SIGTERM
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-sigChan
@@ -80,22 +87,168 @@ func RunDaemon(cfg *config.Config) error {
 	// Stop the goroutine so we don't race.
 	close(stopCh)
 	wg.Wait()
+	return nil
+}
 
-	if err := storage.SaveInstances(instances); err != nil {
-		log.ErrorLog.Printf("failed to save instances when terminating daemon: %v", err)
+// watches returns true if the daemon scoped to tags watches instance: every instance when tags
+// are empty, or those with one of them.
+func watches(instance *session.Instance, tags []string) bool {
+	return len(tags) == 0 || instance.HasAnyTag(tags)
+}
+
+// watcher runs auto-yes and the restart policies on the instances the daemon watches, counting
+// what it does in stats.
+type watcher struct {
+	factory *session.Factory
+	tags    []string
+	// statePath is the state file the instances are loaded from, or empty to keep instances
+	statePath string
+	// stateModTime is when the state file was last modified, as of the last load
+	stateModTime time.Time
+	instances    []*session.Instance
+	stats        *Stats
+	everyN       *log.Every
+	// written is when stats were last written
+	written time.Time
+}
+
+// sync reloads the instances to watch once the state file changed, as it does whenever the TUI or
+// the web server creates, edits, pauses or kills an instance. Instances still watched are kept
+// with the auto-yes and tags saved; those no longer watched are released. It returns true if the
+// instances watched changed.
+func (w *watcher) sync() bool {
+	if w.statePath == "" {
+		return false
 	}
-	return nil
+	info, err := os.Stat(w.statePath)
+	if err != nil || info.ModTime().Equal(w.stateModTime) {
+		return false
+	}
+	storage, err := session.NewStorage(config.LoadState())
+	if err != nil {
+		log.WarningLog.Printf("could not load the state: %v", err)
+		return false
+	}
+	stored, err := storage.LoadInstanceMetadata()
+	if err != nil {
+		if w.everyN.ShouldLog() {
+			log.WarningLog.Printf("could not load instances: %v", err)
+		}
+		return false
+	}
+	w.stateModTime = info.ModTime()
+
+	current := make(map[string]*session.Instance, len(w.instances))
+	for _, instance := range w.instances {
+		current[instance.Title] = instance
+	}
+	instances := make([]*session.Instance, 0, len(stored))
+	for _, saved := range stored {
+		// Paused instances have no program to watch until they are resumed
+		if saved.Paused() || !watches(saved, w.tags) {
+			continue
+		}
+		if instance, ok := current[saved.Title]; ok {
+			instance.AutoYes, instance.AutoYesUntil, instance.Tags = saved.AutoYes, saved.AutoYesUntil, saved.Tags
+			w.factory.Apply(instance)
+			instances = append(instances, instance)
+			delete(current, saved.Title)
+			continue
+		}
+		// Instances in repositories the config doesn't allow are left alone
+		if err := w.factory.CheckRepository(saved.Path); err != nil {
+			log.WarningLog.Printf("not watching %s: %v", saved.Title, err)
+			continue
+		}
+		saved.Restore()
+		if !saved.Started() {
+			continue
+		}
+		w.factory.Apply(saved)
+		instances = append(instances, saved)
+	}
+	for _, instance := range current {
+		instance.Release()
+	}
+
+	watched := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		watched = append(watched, instance.Title)
+	}
+	changed := !slices.Equal(watched, w.stats.Watched)
+	w.instances = instances
+	w.stats.Watched = watched
+	if changed {
+		log.InfoLog.Printf("daemon watching %d of %d instances", len(instances), len(stored))
+	}
+	return changed
+}
+
+// poll checks every watched instance once, then writes the stats if they changed or are getting
+// stale.
+func (w *watcher) poll(now time.Time) {
+	changed := w.sync()
+	for _, instance := range w.instances {
+		// We only store started instances, but check anyway.
+		if !instance.Started() || instance.Paused() {
+			continue
+		}
+		if instance.CheckExited() {
+			restarted, err := instance.AutoRestart(now)
+			if err != nil && w.everyN.ShouldLog() {
+				log.WarningLog.Printf("could not restart %s: %v", instance.Title, err)
+			}
+			if restarted {
+				w.stats.Restarts[instance.Title]++
+				changed = true
+			}
+			continue
+		}
+		instance.CheckAutoYesExpired(now)
+		if !instance.AutoYes {
+			continue
+		}
+		if _, hasPrompt := instance.HasUpdated(); hasPrompt {
+			instance.PressEnter()
+			w.stats.Accepted[instance.Title]++
+			changed = true
+			if err := instance.UpdateDiffStats(); err != nil {
+				if w.everyN.ShouldLog() {
+					log.WarningLog.Printf("could not update diff stats for %s: %v", instance.Title, err)
+				}
+			}
+		}
+	}
+
+	w.stats.LastPoll = now
+	if !changed && now.Sub(w.written) < statsInterval {
+		return
+	}
+	if err := writeStats(w.stats); err != nil && w.everyN.ShouldLog() {
+		log.WarningLog.Printf("could not write daemon stats: %v", err)
+	}
+	w.written = now
 }
 
-// LaunchDaemon launches the daemon process.
-func LaunchDaemon() error {
+// LaunchDaemon launches the daemon process, running AutoYes mode on all sessions if autoYes is set,
+// unless a daemon runs already.
+func LaunchDaemon(autoYes bool) error {
+	if pid, running := Status(); running {
+		log.InfoLog.Printf("daemon already running with PID %d", pid)
+		return nil
+	}
+
 	// Find the claude squad binary.
 	execPath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("failed to get executable path: %w", err)
 	}
 
-	cmd := exec.Command(execPath, "--daemon")
+	args := []string{"--daemon"}
+	if autoYes {
+		args = append(args, "--autoyes")
+	}
+	cmd := exec.Command(execPath, args...)
 
 	// Detach the process from the parent
 	cmd.Stdin = nil
@@ -109,32 +262,105 @@ func LaunchDaemon() error {
 		return fmt.Errorf("failed to start child process: %w", err)
 	}
 
+	// The daemon writes its PID file itself, once it made sure no other daemon runs
 	log.InfoLog.Printf("started daemon child process with PID: %d", cmd.Process.Pid)
 
-	// Save PID to a file for later management
-	pidDir, err := config.GetConfigDir()
+	// Don't wait for the child to exit, it's detached
+	return nil
+}
+
+func pidPath() (string, error) {
+	dir, err := config.GetConfigDir()
 	if err != nil {
-		return fmt.Errorf("failed to get config directory: %w", err)
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(dir, "daemon.pid"), nil
+}
+
+// claimPID records pid as the running daemon's in the PID file. It fails if another daemon runs;
+// the PID file left by one that died is replaced.
+func claimPID(pid int) error {
+	path, err := pidPath()
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%d", pid)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			return err
+		}
+		if !os.IsExist(err) || attempt > 0 {
+			return fmt.Errorf("failed to write PID file: %w", err)
+		}
+		if other, running := Status(); running && other != pid {
+			return fmt.Errorf("a daemon is already running with PID %d", other)
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale PID file: %w", err)
+		}
 	}
+}
 
-	pidFile := filepath.Join(pidDir, "daemon.pid")
-	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", cmd.Process.Pid)), 0644); err != nil {
-		return fmt.Errorf("failed to write PID file: %w", err)
+// releasePID removes the PID file if it still records pid.
+func releasePID(pid int) {
+	if current, _ := Status(); current != pid {
+		return
 	}
+	if path, err := pidPath(); err == nil {
+		_ = os.Remove(path)
+	}
+}
 
-	// Don't wait for the child to exit, it's detached
-	return nil
+// Status returns the PID of the daemon and whether it is running, according to its PID file and
+// the process table.
+func Status() (pid int, running bool) {
+	path, err := pidPath()
+	if err != nil {
+		return 0, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	if _, err := fmt.Sscanf(string(data), "%d", &pid); err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, processAlive(pid)
+}
+
+// Watching returns the titles of the instances a running daemon watches, whose prompts and
+// restarts the TUI leaves to it so they aren't handled twice. It is empty when no daemon runs, or
+// when the daemon stopped polling.
+func Watching() map[string]bool {
+	if _, running := Status(); !running {
+		return nil
+	}
+	stats, err := ReadStats()
+	if err != nil {
+		return nil
+	}
+	stale := 2*statsInterval + time.Duration(stats.PollIntervalMs)*time.Millisecond
+	if time.Since(stats.LastPoll) > stale {
+		return nil
+	}
+	watching := make(map[string]bool, len(stats.Watched))
+	for _, title := range stats.Watched {
+		watching[title] = true
+	}
+	return watching
 }
 
 // StopDaemon attempts to stop a running daemon process if it exists. Returns no error if the daemon is not found
 // (assumes the daemon does not exist).
 func StopDaemon() error {
-	pidDir, err := config.GetConfigDir()
+	pidFile, err := pidPath()
 	if err != nil {
-		return fmt.Errorf("failed to get config directory: %w", err)
+		return err
 	}
-
-	pidFile := filepath.Join(pidDir, "daemon.pid")
 	data, err := os.ReadFile(pidFile)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -157,10 +383,11 @@ func StopDaemon() error {
 		return fmt.Errorf("failed to stop daemon process: %w", err)
 	}
 
-	// Clean up PID file
+	// Clean up PID file, and the stats the killed daemon couldn't remove
 	if err := os.Remove(pidFile); err != nil {
 		return fmt.Errorf("failed to remove PID file: %w", err)
 	}
+	removeStats()
 
 	log.InfoLog.Printf("daemon process (PID: %d) stopped successfully", pid)
 	return nil
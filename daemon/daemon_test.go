@@ -0,0 +1,171 @@
+package daemon
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatches(t *testing.T) {
+	tagged, err := session.NewInstance(session.InstanceOptions{Title: "tagged", Path: ".", Tags: []string{"nightly"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	untagged, err := session.NewInstance(session.InstanceOptions{Title: "untagged", Path: "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !watches(tagged, nil) || !watches(untagged, nil) {
+		t.Errorf("watches() without tags left an instance out")
+	}
+	if !watches(tagged, []string{"review", "nightly"}) || watches(untagged, []string{"nightly"}) {
+		t.Errorf("watches() scoped to nightly doesn't match the tags")
+	}
+}
+
+func TestStats(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".claude-squad"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	w := &watcher{stats: &Stats{
+		StartedAt:      start,
+		PollIntervalMs: 500,
+		Tags:           []string{"nightly"},
+		Watched:        []string{"a", "b"},
+		Accepted:       map[string]int{"a": 1, "b": 4},
+		Restarts:       map[string]int{},
+	}}
+	w.poll(start)
+	stats, err := ReadStats()
+	if err != nil {
+		t.Fatalf("ReadStats() after the first poll: %v", err)
+	}
+	if !stats.LastPoll.Equal(start) || !reflect.DeepEqual(stats.Accepted, w.stats.Accepted) {
+		t.Errorf("ReadStats() = %+v, want the stats of the first poll", stats)
+	}
+
+	// Unchanged stats are only rewritten once they get stale
+	w.poll(start.Add(time.Second))
+	if stats, _ := ReadStats(); !stats.LastPoll.Equal(start) {
+		t.Errorf("LastPoll = %v, want the stats left alone a second later", stats.LastPoll)
+	}
+	w.poll(start.Add(statsInterval))
+	if stats, _ := ReadStats(); !stats.LastPoll.Equal(start.Add(statsInterval)) {
+		t.Errorf("LastPoll = %v, want the stats rewritten after %s", stats.LastPoll, statsInterval)
+	}
+
+	description := stats.Describe()
+	for _, want := range []string{"every 500ms", "watching 2 instances tagged nightly", "Prompts accepted: b 4, a 1"} {
+		if !strings.Contains(description, want) {
+			t.Errorf("Describe() = %q, want it to contain %q", description, want)
+		}
+	}
+
+	removeStats()
+	if _, err := ReadStats(); !os.IsNotExist(err) {
+		t.Errorf("ReadStats() after removeStats() = %v, want a missing file", err)
+	}
+}
+
+func TestWatcherSync(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".claude-squad"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	kept, err := session.NewInstance(session.InstanceOptions{Title: "kept", Path: ".", Tags: []string{"nightly"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dropped, err := session.NewInstance(session.InstanceOptions{Title: "dropped", Path: ".", Tags: []string{"nightly"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The TUI turned auto-yes on for kept, killed dropped, and created instances the daemon
+	// doesn't watch: one paused, one untagged and one whose tmux session is gone
+	data, err := json.Marshal([]session.InstanceData{
+		{Title: "kept", Path: ".", Status: session.Running, AutoYes: true, Tags: []string{"nightly", "review"}},
+		{Title: "sleeping", Path: ".", Status: session.Paused, Tags: []string{"nightly"}},
+		{Title: "untagged", Path: ".", Status: session.Running},
+		{Title: "gone", Path: ".", Status: session.Running, Tags: []string{"nightly"}, TmuxSession: "claudesquad_daemon_test_gone"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.LoadState().SaveInstances(data); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &watcher{
+		factory:   session.NewFactory(config.DefaultConfig(), "", false),
+		tags:      []string{"nightly"},
+		statePath: filepath.Join(home, ".claude-squad", config.StateFileName),
+		instances: []*session.Instance{kept, dropped},
+		stats:     &Stats{Watched: []string{"kept", "dropped"}},
+		everyN:    log.NewEvery(time.Minute),
+	}
+	if !w.sync() {
+		t.Fatal("sync() = false after the state changed")
+	}
+	if len(w.instances) != 1 || w.instances[0] != kept || !reflect.DeepEqual(w.stats.Watched, []string{"kept"}) {
+		t.Fatalf("watching %v (%d instances), want kept only", w.stats.Watched, len(w.instances))
+	}
+	if !kept.AutoYes || !reflect.DeepEqual(kept.Tags, []string{"nightly", "review"}) {
+		t.Errorf("kept has auto-yes %v and tags %v, want those saved", kept.AutoYes, kept.Tags)
+	}
+	if w.sync() {
+		t.Error("sync() = true with the state unchanged")
+	}
+}
+
+func TestClaimPID(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".claude-squad"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The PID file of a daemon that died is taken over
+	path, err := pidPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("999999999"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := claimPID(os.Getpid()); err != nil {
+		t.Fatalf("claimPID() over a dead daemon: %v", err)
+	}
+	if pid, running := Status(); pid != os.Getpid() || !running {
+		t.Errorf("Status() = %d, %v, want this process running", pid, running)
+	}
+
+	// A second daemon is refused while the first runs
+	if err := claimPID(os.Getpid() + 1); err == nil || !strings.Contains(err.Error(), fmt.Sprint(os.Getpid())) {
+		t.Errorf("claimPID() with a daemon running = %v, want it refused", err)
+	}
+
+	releasePID(os.Getpid() + 1)
+	if _, running := Status(); !running {
+		t.Error("releasePID() of another PID removed the PID file")
+	}
+	releasePID(os.Getpid())
+	if _, running := Status(); running {
+		t.Error("Status() reports a daemon after releasePID()")
+	}
+}
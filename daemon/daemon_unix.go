@@ -12,3 +12,10 @@ func getSysProcAttr() *syscall.SysProcAttr {
 		Setsid: true, // Create a new session
 	}
 }
+
+// processAlive returns true if a process with pid exists. Signal 0 checks without sending one; a
+// permission error means the process exists but belongs to someone else.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,121 @@
+package daemon
+
+import (
+	"claude-squad/config"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// statsInterval is how often the stats file is rewritten when nothing else changed, keeping
+// LastPoll current without writing on every poll.
+const statsInterval = 10 * time.Second
+
+// Stats is what a running daemon reports about itself, in daemon.json next to its PID file.
+type Stats struct {
+	StartedAt time.Time `json:"started_at"`
+	// LastPoll is when the daemon last checked its instances, at most statsInterval ago while
+	// it runs.
+	LastPoll       time.Time `json:"last_poll"`
+	PollIntervalMs int64     `json:"poll_interval_ms"`
+	// Tags are the tags the daemon is scoped to, empty when it watches every instance.
+	Tags []string `json:"tags,omitempty"`
+	// Watched are the titles of the instances the daemon watches.
+	Watched []string `json:"watched"`
+	// Accepted counts the prompts auto-yes accepted since the daemon started, by instance title.
+	Accepted map[string]int `json:"accepted"`
+	// Restarts counts the programs the restart policies restarted since the daemon started, by
+	// instance title.
+	Restarts map[string]int `json:"restarts,omitempty"`
+}
+
+// Describe renders the stats in a few indented lines, for cs status.
+func (s *Stats) Describe() string {
+	scope := ""
+	if len(s.Tags) > 0 {
+		scope = " tagged " + strings.Join(s.Tags, ", ")
+	}
+	lines := []string{fmt.Sprintf("  Polling every %s, watching %d instances%s, last polled %s",
+		time.Duration(s.PollIntervalMs)*time.Millisecond, len(s.Watched), scope, s.LastPoll.Format("15:04:05"))}
+	if accepted := describeCounts(s.Accepted); accepted != "" {
+		lines = append(lines, "  Prompts accepted: "+accepted)
+	}
+	if restarts := describeCounts(s.Restarts); restarts != "" {
+		lines = append(lines, "  Programs restarted: "+restarts)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// describeCounts lists counts by title, highest first.
+func describeCounts(counts map[string]int) string {
+	titles := make([]string, 0, len(counts))
+	for title := range counts {
+		titles = append(titles, title)
+	}
+	sort.Slice(titles, func(i, j int) bool {
+		if counts[titles[i]] != counts[titles[j]] {
+			return counts[titles[i]] > counts[titles[j]]
+		}
+		return titles[i] < titles[j]
+	})
+	parts := make([]string, 0, len(titles))
+	for _, title := range titles {
+		parts = append(parts, fmt.Sprintf("%s %d", title, counts[title]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func statsPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(dir, "daemon.json"), nil
+}
+
+// ReadStats returns the stats the daemon last wrote. Check that it runs with Status: a daemon
+// that was killed leaves its last stats behind.
+func ReadStats() (*Stats, error) {
+	path, err := statsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var stats Stats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// writeStats replaces the stats file with stats, through a rename so readers never see it
+// half-written.
+func writeStats(stats *Stats) error {
+	path, err := statsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write daemon stats: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// removeStats removes the stats file of a stopped daemon.
+func removeStats() {
+	if path, err := statsPath(); err == nil {
+		_ = os.Remove(path)
+	}
+}
@@ -0,0 +1,176 @@
+// Package fakeagent implements a scripted stand-in for Claude Code. It prints output, asks the
+// folder trust question and raises permission prompts on a fixed schedule so the real tmux and
+// instance pipeline can be exercised without an API key.
+package fakeagent
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// The strings below are matched by the tmux package to detect claude screens, so they must stay
+// in sync with session/tmux.
+const (
+	// TrustQuestion is the folder trust screen shown on startup.
+	TrustQuestion = "Do you trust the files in this folder?"
+	// PermissionPrompt is the option line that marks a pending permission prompt.
+	PermissionPrompt = "No, and tell Claude what to do differently"
+	// ReadyBanner is printed once the agent is ready for input.
+	ReadyBanner = "fake agent ready"
+)
+
+// Options configures the fake agent's behaviour.
+type Options struct {
+	// Trust asks the folder trust question before accepting input.
+	Trust bool
+	// PromptEvery raises a permission prompt after every N-th input. Zero disables prompts.
+	PromptEvery int
+	// PromptInterval raises a permission prompt while idle at this interval. Zero disables it.
+	PromptInterval time.Duration
+	// Delay is the pause between lines of scripted output.
+	Delay time.Duration
+	// Lines is the number of output lines printed per input.
+	Lines int
+}
+
+// DefaultOptions returns the options used by the fake-agent subcommand.
+func DefaultOptions() Options {
+	return Options{
+		Trust:       true,
+		PromptEvery: 2,
+		Delay:       100 * time.Millisecond,
+		Lines:       3,
+	}
+}
+
+// Agent is a running fake agent.
+type Agent struct {
+	opts  Options
+	out   io.Writer
+	turns int
+}
+
+// Run runs the agent until in is closed. Every line read from in is treated as a user message.
+// While a prompt is pending, the next line answers it instead.
+func Run(opts Options, in io.Reader, out io.Writer) error {
+	a := &Agent{opts: opts, out: out}
+
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-done:
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	if opts.Trust {
+		a.printf("%s\n", TrustQuestion)
+		a.printf("> 1. Yes, proceed\n  2. No, exit\n")
+		answer, ok := <-lines
+		if !ok {
+			return <-readErr
+		}
+		if strings.TrimSpace(answer) == "2" {
+			a.printf("Exiting.\n")
+			return nil
+		}
+	}
+	a.printf("%s\n", ReadyBanner)
+	a.printf("> ")
+
+	var idle <-chan time.Time
+	var ticker *time.Ticker
+	if opts.PromptInterval > 0 {
+		ticker = time.NewTicker(opts.PromptInterval)
+		defer ticker.Stop()
+		idle = ticker.C
+	}
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return <-readErr
+			}
+			if err := a.handleInput(line, lines); err != nil {
+				return err
+			}
+		case <-idle:
+			a.printf("\n")
+			if !a.ask("Background check: run `git status`?", lines) {
+				return <-readErr
+			}
+			a.printf("> ")
+		}
+	}
+}
+
+// handleInput prints the scripted response to a user message and raises a permission prompt if
+// one is due.
+func (a *Agent) handleInput(line string, lines <-chan string) error {
+	a.turns++
+	message := strings.TrimSpace(line)
+	if message == "" {
+		a.printf("> ")
+		return nil
+	}
+
+	if message == "/cost" {
+		a.printf("  ⎿  Total cost:            $%.4f\n", float64(a.turns)*0.0125)
+		a.printf("     Total duration (API):  %ds\n", a.turns)
+		a.printf("     Total duration (wall): %ds\n", a.turns*2)
+		a.printf("> ")
+		return nil
+	}
+
+	a.printf("● Working on: %s\n", message)
+	for i := 1; i <= a.opts.Lines; i++ {
+		time.Sleep(a.opts.Delay)
+		a.printf("  step %d/%d for turn %d\n", i, a.opts.Lines, a.turns)
+	}
+
+	if a.opts.PromptEvery > 0 && a.turns%a.opts.PromptEvery == 0 {
+		if !a.ask(fmt.Sprintf("Edit file turn_%d.txt?", a.turns), lines) {
+			return nil
+		}
+	}
+
+	a.printf("● Done with turn %d\n", a.turns)
+	a.printf("> ")
+	return nil
+}
+
+// ask shows a permission prompt and waits for the answer. It returns false if input closed.
+func (a *Agent) ask(question string, lines <-chan string) bool {
+	a.printf("%s\n", question)
+	a.printf("Do you want to proceed?\n")
+	a.printf("> 1. Yes\n")
+	a.printf("  2. %s (esc)\n", PermissionPrompt)
+
+	answer, ok := <-lines
+	if !ok {
+		return false
+	}
+	if strings.TrimSpace(answer) == "2" {
+		a.printf("  Declined.\n")
+	} else {
+		a.printf("  Approved.\n")
+	}
+	return true
+}
+
+func (a *Agent) printf(format string, args ...interface{}) {
+	fmt.Fprintf(a.out, format, args...)
+}
@@ -0,0 +1,76 @@
+package fakeagent
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		input   string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:  "trust then prompt on schedule",
+			opts:  Options{Trust: true, PromptEvery: 2, Lines: 1},
+			input: "1\nhello\nworld\n1\n",
+			want: []string{
+				TrustQuestion, ReadyBanner,
+				"Working on: hello", "Working on: world",
+				"Edit file turn_2.txt?", PermissionPrompt, "Approved.",
+				"Done with turn 2",
+			},
+		},
+		{
+			name:    "declined trust exits",
+			opts:    Options{Trust: true},
+			input:   "2\nhello\n",
+			want:    []string{TrustQuestion, "Exiting."},
+			notWant: []string{ReadyBanner, "Working on"},
+		},
+		{
+			name:    "no prompts",
+			opts:    Options{Lines: 2},
+			input:   "a\nb\nc\n",
+			want:    []string{ReadyBanner, "step 2/2 for turn 3"},
+			notWant: []string{TrustQuestion, PermissionPrompt},
+		},
+		{
+			name:  "declined permission",
+			opts:  Options{PromptEvery: 1},
+			input: "a\n2\n",
+			want:  []string{PermissionPrompt, "Declined."},
+		},
+		{
+			name:    "cost",
+			opts:    Options{PromptEvery: 1},
+			input:   "a\n1\n/cost\n",
+			want:    []string{"Total cost:            $0.0250", "Total duration (API):  2s"},
+			notWant: []string{"Working on: /cost"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := Run(tt.opts, strings.NewReader(tt.input), &out); err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			got := out.String()
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("output missing %q:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(got, s) {
+					t.Errorf("output unexpectedly contains %q:\n%s", s, got)
+				}
+			}
+		})
+	}
+}
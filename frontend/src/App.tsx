@@ -3,9 +3,14 @@ import HomePage from './pages/HomePage'
 import TerminalPage from './pages/TerminalPage'
 import InstancesPage from './pages/InstancesPage'
 import IntegratedPage from './pages/IntegratedPage'
+import DashboardPage from './pages/DashboardPage'
 import NotFoundPage from './pages/NotFoundPage'
+import { useAttention } from './utils/attention'
 
 function App() {
+  // Badge and notify for instances waiting for input on every page
+  useAttention()
+
   return (
     <div className="app">
       <Routes>
@@ -16,6 +21,7 @@ function App() {
         <Route path="/home" element={<HomePage />} />
         <Route path="/terminal/:instanceName" element={<TerminalPage />} />
         <Route path="/instances" element={<InstancesPage />} />
+        <Route path="/dashboard/:layoutName" element={<DashboardPage />} />
         <Route path="*" element={<NotFoundPage />} />
       </Routes>
     </div>
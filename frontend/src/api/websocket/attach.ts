@@ -0,0 +1,111 @@
+import { Terminal } from 'xterm'
+import { wsUrl } from '@/utils/basePath'
+
+// ACK_BYTES is how much output is written to the terminal before it is acknowledged. The server
+// stops sending once a window of output, 1 MiB, is unacknowledged, so a slow tab holds tmux back
+// instead of queuing output without bound.
+const ACK_BYTES = 64 * 1024
+
+// AttachControl is a text message of /ws/attach/{name}. The output and the keys typed travel as
+// binary messages.
+interface AttachControl {
+  type: 'attached' | 'detached' | 'error' | 'resize' | 'ack'
+  cols?: number
+  rows?: number
+  bytes?: number
+  window?: number
+  read_only?: boolean
+  message?: string
+}
+
+export interface AttachSocketOptions {
+  instanceName: string
+  terminal: Terminal
+  readOnly?: boolean
+  onAttached?: (readOnly: boolean) => void
+  // onDetached is called once the connection ends, with the reason the server gave, if any
+  onDetached?: (reason: string) => void
+}
+
+// AttachSocket attaches a terminal to an instance through a tmux client of its own, writing the
+// bytes of its PTY to the terminal as they come, like a local tmux attach.
+export class AttachSocket {
+  private socket: WebSocket | null = null
+  private options: AttachSocketOptions
+  private encoder = new TextEncoder()
+  // unacked counts the bytes written to the terminal since the last ack
+  private unacked = 0
+  // pending counts the writes the terminal hasn't processed yet
+  private pending = 0
+  private detachReason = ''
+
+  constructor(options: AttachSocketOptions) {
+    this.options = options
+  }
+
+  connect() {
+    const { instanceName, terminal, readOnly } = this.options
+    const privileges = readOnly ? 'read-only' : 'read-write'
+    const url = wsUrl(
+      `/ws/attach/${encodeURIComponent(instanceName)}?privileges=${privileges}&cols=${terminal.cols}&rows=${terminal.rows}`
+    )
+    const socket = new WebSocket(url)
+    socket.binaryType = 'arraybuffer'
+    socket.onmessage = (event) => this.handleMessage(event)
+    socket.onclose = () => {
+      this.socket = null
+      this.options.onDetached?.(this.detachReason)
+    }
+    this.socket = socket
+  }
+
+  disconnect() {
+    this.socket?.close(1000, 'Detached')
+    this.socket = null
+  }
+
+  // send types data, as given by xterm.js's onData, in the attached terminal
+  send(data: string) {
+    if (this.socket?.readyState === WebSocket.OPEN) {
+      this.socket.send(this.encoder.encode(data))
+    }
+  }
+
+  // resize resizes the tmux client to the terminal's size
+  resize(cols: number, rows: number) {
+    this.sendControl({ type: 'resize', cols, rows })
+  }
+
+  private handleMessage(event: MessageEvent) {
+    if (event.data instanceof ArrayBuffer) {
+      const output = new Uint8Array(event.data)
+      this.pending++
+      // Acknowledge once the terminal has processed the output, not when it arrived
+      this.options.terminal.write(output, () => {
+        this.pending--
+        this.unacked += output.byteLength
+        if (this.unacked >= ACK_BYTES || this.pending === 0) {
+          this.sendControl({ type: 'ack', bytes: this.unacked })
+          this.unacked = 0
+        }
+      })
+      return
+    }
+    const control: AttachControl = JSON.parse(event.data)
+    switch (control.type) {
+      case 'attached':
+        this.options.onAttached?.(control.read_only ?? false)
+        break
+      case 'detached':
+      case 'error':
+        this.detachReason = control.message ?? ''
+        break
+    }
+  }
+
+  private sendControl(control: AttachControl) {
+    if (this.socket?.readyState === WebSocket.OPEN) {
+      this.socket.send(JSON.stringify(control))
+    }
+  }
+}
@@ -1,5 +1,6 @@
 import { Terminal } from 'xterm'
 import { TerminalInput, TerminalResize, TerminalUpdate } from '@/types'
+import { wsUrl } from '@/utils/basePath'
 
 // Constants for binary messages
 const OUTPUT_MESSAGE = 'o'.charCodeAt(0)
@@ -46,13 +47,12 @@ export class TerminalWebSocket {
       return
     }
     
-    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
-    const wsUrl = `${protocol}//${window.location.host}/ws/${this.instanceName}?format=ansi`
+    const url = wsUrl(`/ws/${this.instanceName}?format=ansi`)
     
-    this.terminal.writeln(`\r\nConnecting to ${wsUrl}...`)
+    this.terminal.writeln(`\r\nConnecting to ${url}...`)
     
     try {
-      this.socket = new WebSocket(wsUrl)
+      this.socket = new WebSocket(url)
       
       this.socket.onopen = this.handleOpen.bind(this)
       this.socket.onmessage = this.handleMessage.bind(this)
@@ -0,0 +1,91 @@
+import { useEffect, useRef, useState } from 'react'
+import { Terminal as XTerm } from 'xterm'
+import { FitAddon } from 'xterm-addon-fit'
+import { WebLinksAddon } from 'xterm-addon-web-links'
+import 'xterm/css/xterm.css'
+import { AttachSocket } from '@/api/websocket/attach'
+
+interface AttachTerminalProps {
+  instanceName: string
+  readOnly?: boolean
+  onConnectionChange?: (connected: boolean) => void
+}
+
+// AttachTerminal attaches to an instance like tmux attach does locally: full-screen programs,
+// cursor movement and the tmux status line are drawn as they would be in a local terminal.
+const AttachTerminal = ({ instanceName, readOnly = false, onConnectionChange }: AttachTerminalProps) => {
+  const terminalRef = useRef<HTMLDivElement>(null)
+  const [status, setStatus] = useState('Attaching...')
+
+  useEffect(() => {
+    if (!terminalRef.current) return
+
+    // The PTY sends carriage returns itself, so line feeds are left alone
+    const term = new XTerm({
+      cursorBlink: true,
+      fontFamily: 'Menlo, Monaco, "Courier New", monospace',
+      fontSize: 14,
+      theme: {
+        background: '#1e1e1e',
+        foreground: '#f0f0f0',
+        cursor: '#f0f0f0',
+        selectionBackground: 'rgba(240, 240, 240, 0.3)'
+      },
+      scrollback: 0
+    })
+    const fitAddon = new FitAddon()
+    term.loadAddon(fitAddon)
+    term.loadAddon(new WebLinksAddon())
+    term.open(terminalRef.current)
+    fitAddon.fit()
+
+    const socket = new AttachSocket({
+      instanceName,
+      terminal: term,
+      readOnly,
+      onAttached: (attachedReadOnly) => {
+        setStatus(attachedReadOnly ? 'Attached (read-only)' : 'Attached')
+        onConnectionChange?.(true)
+      },
+      onDetached: (reason) => {
+        setStatus(reason ? `Detached: ${reason}` : 'Detached')
+        onConnectionChange?.(false)
+      }
+    })
+    socket.connect()
+    const dataListener = term.onData((data) => socket.send(data))
+    const resizeListener = term.onResize(({ cols, rows }) => socket.resize(cols, rows))
+
+    let resizeTimeout: number | null = null
+    const observer = new ResizeObserver(() => {
+      if (resizeTimeout) window.clearTimeout(resizeTimeout)
+      resizeTimeout = window.setTimeout(() => fitAddon.fit(), 100)
+    })
+    observer.observe(terminalRef.current)
+    term.focus()
+
+    return () => {
+      observer.disconnect()
+      if (resizeTimeout) window.clearTimeout(resizeTimeout)
+      dataListener.dispose()
+      resizeListener.dispose()
+      socket.disconnect()
+      term.dispose()
+    }
+  }, [instanceName, readOnly, onConnectionChange])
+
+  return (
+    <div className="terminal-wrapper" style={{ width: '100%', height: '100%', display: 'flex', flexDirection: 'column' }}>
+      <div className="terminal-status" style={{ padding: '4px 8px', fontSize: '12px' }}>
+        {status}
+      </div>
+      <div
+        ref={terminalRef}
+        className="xterm-container"
+        style={{ width: '100%', height: 'calc(100% - 30px)', border: '1px solid #444', borderRadius: '4px' }}
+      />
+    </div>
+  )
+}
+
+export default AttachTerminal
@@ -3,6 +3,8 @@ import { Terminal as XTerm } from 'xterm'
 import { FitAddon } from 'xterm-addon-fit'
 import { WebLinksAddon } from 'xterm-addon-web-links'
 import 'xterm/css/xterm.css'
+import { apiUrl, authHeaders, wsUrl } from '@/utils/basePath'
+import { applyDisplay } from '@/utils/terminalThemes'
 
 // Removed binary protocol message type constants - fully using JSON protocol now
 
@@ -314,6 +316,9 @@ const Terminal = ({
   const reconnectAttemptsRef = useRef(0)
   const reconnectTimeoutRef = useRef<number | null>(null)
   const maxReconnectDelay = 30000 // Max 30 seconds between reconnects
+  const openedRef = useRef(false) // Whether a WebSocket was ever established
+  const pollAbortRef = useRef<AbortController | null>(null) // Set while polling over HTTP
+  const maxFailedConnects = 3 // Failed connects before polling over HTTP instead
   const processedContentHashRef = useRef(new Set<string>()) // Track processed content
   
   // Hash function for deduplication
@@ -328,6 +333,45 @@ const Terminal = ({
     return hash.toString(16)
   }, [])
   
+  // Poll output over HTTP when the WebSocket can't be established, such as behind a proxy that
+  // doesn't allow upgrades. Each request waits on the server until the output changes. Polling is
+  // read-only and stops when the component unmounts.
+  const startPolling = useCallback(async () => {
+    if (pollAbortRef.current) return
+    const controller = new AbortController()
+    pollAbortRef.current = controller
+    log('warn', 'WebSocket unavailable, falling back to HTTP polling')
+    updateStatus('WebSocket unavailable. Polling output over HTTP (read-only)', 'warning')
+    if (onConnectionChange) onConnectionChange(true)
+
+    let since = 0
+    while (!controller.signal.aborted) {
+      try {
+        const url = apiUrl(`/api/instances/${encodeURIComponent(instanceName)}/output?format=ansi&since=${since}`)
+        const response = await fetch(url, { headers: authHeaders(), signal: controller.signal })
+        if (response.status === 404) {
+          updateStatus('Instance not found', 'error')
+          break
+        }
+        if (response.status === 200) {
+          const data = await response.json()
+          since = data.seq
+          if (terminal && data.content) {
+            terminal.write(data.content)
+          }
+        } else if (response.status !== 204) {
+          // 204 means no new output yet
+          throw new Error(`HTTP ${response.status}`)
+        }
+      } catch (error) {
+        if (controller.signal.aborted) break
+        log('warn', `Polling output failed: ${error}`)
+        await new Promise(resolve => setTimeout(resolve, 3000))
+      }
+    }
+    if (onConnectionChange) onConnectionChange(false)
+  }, [terminal, instanceName, onConnectionChange, updateStatus, log])
+
   // Connect to WebSocket with proper connection limiting and backoff
   const connectWebSocket = useCallback(() => {
     // Reset content tracking on new connection
@@ -355,15 +399,14 @@ const Terminal = ({
     // Update status to connecting (don't write to terminal)
     updateStatus('Connecting to WebSocket...', 'info')
     
-    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
     // Using the path format expected by the server
     // Make sure to include 'format=ansi' to get raw ANSI codes for xterm.js
-    const wsUrl = `${protocol}//${window.location.host}/ws/${instanceName}?format=ansi&privileges=read-write`
+    const url = wsUrl(`/ws/${instanceName}?format=ansi&privileges=read-write`)
     
-    log('info', `Connecting to WebSocket: ${wsUrl}`)
+    log('info', `Connecting to WebSocket: ${url}`)
     
     try {
-      const ws = new WebSocket(wsUrl)
+      const ws = new WebSocket(url)
       ws.binaryType = 'arraybuffer'
       
       // Set a connection timeout
@@ -380,6 +423,7 @@ const Terminal = ({
         
         // Reset reconnection attempts on successful connection
         reconnectAttemptsRef.current = 0
+        openedRef.current = true
         
         // Update status instead of writing to terminal
         updateStatus('WebSocket connected', 'success')
@@ -425,7 +469,19 @@ const Terminal = ({
               
               if (data.type === 'config') {
                 log('info', 'Received terminal config')
-              } else if (data.type === 'instance_terminated') {
+                // The theme and font follow the display preferences stored by the server
+                if (terminal) {
+                  applyDisplay(terminal, data)
+                  fitAddonRef.current?.fit()
+                }
+              } else if (data.type === 'instance_paused') {
+                // The instance was paused: its terminal is gone until it is resumed
+                log('warn', `Instance "${data.instance_title}" paused`)
+                updateStatus('Instance paused', 'warning')
+                if (terminal) {
+                  terminal.write('\r\n\x1b[33m[PAUSED] Instance paused. Output resumes when it is resumed.\x1b[0m\r\n')
+                }
+              } else if (data.type === 'instance_terminated' || data.type === 'instance_removed') {
                 // Handle instance termination notification from server
                 log('warn', `Instance "${data.instance_title}" terminated: ${data.message}`)
                 updateStatus(`Instance terminated: ${data.message}`, 'error')
@@ -635,6 +691,12 @@ const Terminal = ({
         
         // Exponential backoff for reconnection
         reconnectAttemptsRef.current++
+
+        // A WebSocket that never opened is likely blocked on the way, such as by a corporate proxy
+        if (!openedRef.current && reconnectAttemptsRef.current >= maxFailedConnects) {
+          startPolling()
+          return
+        }
         
         // Calculate delay: 1s, 2s, 4s, 8s, 16s, up to maxReconnectDelay
         const baseDelay = 1000 // Start with 1 second
@@ -675,7 +737,7 @@ const Terminal = ({
         
         // Add additional debugging info about the connection
         console.error('WebSocket error details:', {
-          url: wsUrl,
+          url,
           instance: instanceName,
           readyState: ws.readyState,
           bufferedAmount: ws.bufferedAmount,
@@ -713,7 +775,8 @@ const Terminal = ({
     hashContent,
     updateStatus,
     log,
-    attemptFitAndResize
+    attemptFitAndResize,
+    startPolling
   ])
   
   // Connect to WebSocket
@@ -725,6 +788,10 @@ const Terminal = ({
     
     // Cleanup on unmount
     return () => {
+      // Stop polling over HTTP
+      pollAbortRef.current?.abort()
+      pollAbortRef.current = null
+
       // Clear any pending reconnect timeouts
       if (reconnectTimeoutRef.current !== null) {
         window.clearTimeout(reconnectTimeoutRef.current)
@@ -0,0 +1,135 @@
+import { useEffect, useRef, useState } from 'react'
+import { useParams, Link } from 'react-router-dom'
+import { Terminal as XTerm } from 'xterm'
+import { FitAddon } from 'xterm-addon-fit'
+import 'xterm/css/xterm.css'
+import { wsUrl } from '@/utils/basePath'
+import { applyDisplay, type TerminalDisplay } from '@/utils/terminalThemes'
+
+interface Layout {
+  name: string
+  instances: string[]
+  columns?: number
+}
+
+interface Pane {
+  terminal: XTerm
+  fit: FitAddon
+}
+
+// DashboardPage shows the terminals of a dashboard layout in a grid, all streamed over one
+// WebSocket. Updates name their instance, so each is written to that instance's pane.
+const DashboardPage = () => {
+  const { layoutName } = useParams<{ layoutName: string }>()
+  const [layout, setLayout] = useState<Layout | null>(null)
+  const [removed, setRemoved] = useState<Record<string, string>>({})
+  const [connected, setConnected] = useState(false)
+  const [error, setError] = useState<string | null>(null)
+  const containers = useRef<Record<string, HTMLDivElement | null>>({})
+  const panes = useRef<Record<string, Pane>>({})
+  const display = useRef<TerminalDisplay>({})
+  const pending = useRef<Record<string, string[]>>({})
+
+  useEffect(() => {
+    const ws = new WebSocket(wsUrl(`/ws/layouts/${encodeURIComponent(layoutName ?? '')}`))
+    ws.onopen = () => setConnected(true)
+    ws.onclose = () => setConnected(false)
+    ws.onerror = () => setError('Could not stream this layout')
+    ws.onmessage = (event) => {
+      const data = JSON.parse(event.data)
+      if (data.type === 'config') {
+        display.current = data
+        setLayout(data.layout)
+        return
+      }
+      if (data.type === 'instance_removed') {
+        setRemoved((prev) => ({ ...prev, [data.instance_title]: data.message || 'Instance removed' }))
+        return
+      }
+      if (!data.instance_title || !data.content) {
+        return
+      }
+      const pane = panes.current[data.instance_title]
+      if (pane) {
+        pane.terminal.write(data.content)
+      } else {
+        // The pane is created once the layout has rendered
+        ;(pending.current[data.instance_title] ??= []).push(data.content)
+      }
+    }
+    return () => ws.close()
+  }, [layoutName])
+
+  // Create a terminal in each pane once the layout is known
+  useEffect(() => {
+    if (!layout) return
+    for (const instance of layout.instances) {
+      const container = containers.current[instance]
+      if (!container || panes.current[instance]) continue
+      const terminal = new XTerm({ convertEol: true, scrollback: 2000, disableStdin: true })
+      applyDisplay(terminal, display.current)
+      const fit = new FitAddon()
+      terminal.loadAddon(fit)
+      terminal.open(container)
+      fit.fit()
+      for (const content of pending.current[instance] ?? []) {
+        terminal.write(content)
+      }
+      delete pending.current[instance]
+      panes.current[instance] = { terminal, fit }
+    }
+    const refit = () => Object.values(panes.current).forEach((pane) => pane.fit.fit())
+    window.addEventListener('resize', refit)
+    return () => window.removeEventListener('resize', refit)
+  }, [layout])
+
+  // Dispose of the terminals when leaving the page
+  useEffect(() => () => {
+    Object.values(panes.current).forEach((pane) => pane.terminal.dispose())
+    panes.current = {}
+  }, [])
+
+  if (error) {
+    return (
+      <div className="container">
+        <div className="error">{error}</div>
+        <Link to="/">Back</Link>
+      </div>
+    )
+  }
+  if (!layout) {
+    return <div className="container">Loading layout...</div>
+  }
+
+  return (
+    <div className="container">
+      <div className="terminal-header">
+        <h1>{layout.name}</h1>
+        <div className="status">
+          <span className={`status-indicator ${connected ? 'connected' : 'disconnected'}`}></span>
+          <span>{connected ? 'Connected' : 'Disconnected'}</span>
+        </div>
+      </div>
+      <div
+        style={{
+          display: 'grid',
+          gridTemplateColumns: `repeat(${layout.columns || 2}, minmax(0, 1fr))`,
+          gap: '0.5rem',
+        }}
+      >
+        {layout.instances.map((instance) => (
+          <div key={instance}>
+            <Link to={`/terminal/${encodeURIComponent(instance)}`}>{instance}</Link>
+            {removed[instance] ? (
+              <div className="error">{removed[instance]}</div>
+            ) : (
+              <div ref={(el) => (containers.current[instance] = el)} style={{ height: '40vh' }} />
+            )}
+          </div>
+        ))}
+      </div>
+    </div>
+  )
+}
+
+export default DashboardPage
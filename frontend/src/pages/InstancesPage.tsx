@@ -1,8 +1,9 @@
 import { useEffect, useState, useRef } from 'react'
 import { Link } from 'react-router-dom'
 import { Instance, InstancesResponse } from '@/types/instance'
+import { apiUrl, authHeaders } from '@/utils/basePath'
 
-const API_BASE_URL = '/api'
+const API_BASE_URL = apiUrl('/api')
 
 // API functions with proper error handling - no recursive retry
 const fetchInstancesApi = async (): Promise<InstancesResponse> => {
@@ -13,6 +14,7 @@ const fetchInstancesApi = async (): Promise<InstancesResponse> => {
     const response = await fetch(`${API_BASE_URL}/instances`, {
       signal: controller.signal,
       headers: {
+        ...authHeaders(),
         'Cache-Control': 'no-cache',
       }
     })
@@ -216,6 +218,7 @@ const InstancesPage = () => {
               <div key={instance.title} style={{ 
                 padding: '1.5rem', 
                 border: '1px solid #ddd',
+                borderLeft: `4px solid ${instance.color || '#ddd'}`,
                 borderRadius: '8px',
                 backgroundColor: 'white',
                 boxShadow: '0 2px 4px rgba(0,0,0,0.05)',
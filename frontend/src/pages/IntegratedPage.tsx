@@ -1,8 +1,9 @@
 import { useEffect, useState, useCallback, useRef } from 'react'
 import Terminal from '@/components/terminal/Terminal'
 import { Instance, InstancesResponse } from '@/types/instance'
+import { apiUrl, authHeaders } from '@/utils/basePath'
 
-const API_BASE_URL = '/api'
+const API_BASE_URL = apiUrl('/api')
 
 // API function with proper retry and backoff logic
 const fetchInstancesApi = async (): Promise<InstancesResponse> => {
@@ -13,6 +14,7 @@ const fetchInstancesApi = async (): Promise<InstancesResponse> => {
     const response = await fetch(`${API_BASE_URL}/instances`, {
       signal: controller.signal,
       headers: {
+        ...authHeaders(),
         'Cache-Control': 'no-cache',
       }
     })
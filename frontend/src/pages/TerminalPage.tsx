@@ -1,11 +1,16 @@
 import { useEffect, useState } from 'react'
-import { useParams, Link } from 'react-router-dom'
+import { useParams, useSearchParams, Link } from 'react-router-dom'
 // Terminal component will be implemented later
 import Terminal from '@/components/terminal/Terminal'
+import AttachTerminal from '@/components/terminal/AttachTerminal'
+import { apiUrl, authHeaders } from '@/utils/basePath'
 
 const TerminalPage = () => {
   const { instanceName } = useParams<{ instanceName: string }>()
   const [isConnected, setIsConnected] = useState(false)
+  // ?mode=attach attaches a tmux client of its own instead of streaming captures of the pane
+  const [searchParams, setSearchParams] = useSearchParams()
+  const attached = searchParams.get('mode') === 'attach'
   
   // Placeholder for instance data
   const [instanceData, setInstanceData] = useState<any>(null)
@@ -16,8 +21,9 @@ const TerminalPage = () => {
     // This will be replaced with actual API call later
     const fetchInstanceData = async () => {
       try {
-        // Placeholder - will be implemented with actual API
-        setInstanceData({ title: instanceName })
+        // The accent color marks which instance this terminal talks to
+        const response = await fetch(apiUrl(`/api/instances/${instanceName}`), { headers: authHeaders() })
+        setInstanceData(response.ok ? await response.json() : { title: instanceName })
         setLoading(false)
       } catch (err) {
         setError('Failed to fetch instance data')
@@ -43,7 +49,7 @@ const TerminalPage = () => {
   
   return (
     <div className="container">
-      <div className="terminal-header">
+      <div className="terminal-header" style={{ borderBottom: `4px solid ${instanceData?.color || 'transparent'}` }}>
         <h1>{instanceData?.title || 'Terminal'}</h1>
         <div className="status">
           <span className={`status-indicator ${isConnected ? 'connected' : 'disconnected'}`}></span>
@@ -52,16 +58,26 @@ const TerminalPage = () => {
       </div>
       
       <div className="terminal-container">
-        <Terminal 
-          instanceName={instanceName || ''} 
-          onConnectionChange={setIsConnected}
-        />
+        {attached ? (
+          <AttachTerminal
+            instanceName={instanceName || ''}
+            onConnectionChange={setIsConnected}
+          />
+        ) : (
+          <Terminal 
+            instanceName={instanceName || ''} 
+            onConnectionChange={setIsConnected}
+          />
+        )}
       </div>
       
       <div className="terminal-controls" style={{ marginTop: '1rem' }}>
         <Link to="/instances">
           <button style={{ marginRight: '1rem' }}>Back to Instances</button>
         </Link>
+        <button onClick={() => setSearchParams(attached ? {} : { mode: 'attach' })}>
+          {attached ? 'Stream output' : 'Attach (full terminal)'}
+        </button>
       </div>
     </div>
   )
@@ -7,6 +7,8 @@ export interface Instance {
   updatedAt: string
   program?: string
   inPlace?: boolean
+  // Accent color of the instance, as #rrggbb
+  color?: string
   diffStats?: DiffStats
 }
 
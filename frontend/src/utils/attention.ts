@@ -0,0 +1,118 @@
+import { useEffect } from 'react'
+import { apiUrl, wsUrl } from './basePath'
+
+// The event types that raise a browser notification while the tab is in the background
+const NOTIFY = ['instance_prompt_detected', 'instance_status_changed', 'instance_error'].join(',')
+
+interface EventNotification {
+  title: string
+  body?: string
+  tag: string
+}
+
+interface EventMessage {
+  type: string
+  instance?: string
+  notification?: EventNotification
+  awaiting_prompt?: string[]
+}
+
+const baseTitle = document.title
+
+// setBadge shows the number of instances waiting for input in the page title and on the favicon
+const setBadge = (count: number) => {
+  document.title = count > 0 ? `(${count}) ${baseTitle}` : baseTitle
+
+  const link = document.querySelector<HTMLLinkElement>('link[rel="icon"]')
+  if (!link) {
+    return
+  }
+  link.dataset.original ??= link.href
+  if (count === 0) {
+    link.href = link.dataset.original
+    return
+  }
+  const icon = new Image()
+  icon.onload = () => {
+    const canvas = document.createElement('canvas')
+    canvas.width = canvas.height = 64
+    const ctx = canvas.getContext('2d')
+    if (!ctx) {
+      return
+    }
+    ctx.drawImage(icon, 0, 0, 64, 64)
+    ctx.fillStyle = '#e5484d'
+    ctx.beginPath()
+    ctx.arc(44, 20, 20, 0, 2 * Math.PI)
+    ctx.fill()
+    ctx.fillStyle = '#ffffff'
+    ctx.font = 'bold 28px sans-serif'
+    ctx.textAlign = 'center'
+    ctx.textBaseline = 'middle'
+    ctx.fillText(count > 9 ? '9+' : String(count), 44, 21)
+    link.href = canvas.toDataURL('image/png')
+  }
+  icon.src = link.dataset.original
+}
+
+const notify = (n: EventNotification, instance?: string) => {
+  if (!document.hidden || !('Notification' in window) || window.Notification.permission !== 'granted') {
+    return
+  }
+  const shown = new window.Notification(n.title, { body: n.body, tag: n.tag })
+  shown.onclick = () => {
+    window.focus()
+    if (instance) {
+      window.location.href = apiUrl(`/terminal/${encodeURIComponent(instance)}`)
+    }
+  }
+}
+
+// useAttention keeps the title and favicon badge counting the instances waiting for input, and
+// shows a notification when one needs input while the tab is in the background. Permission to
+// notify is asked on the first click, as browsers only allow asking from a user gesture.
+export const useAttention = () => {
+  useEffect(() => {
+    let ws: WebSocket | null = null
+    let retry: ReturnType<typeof setTimeout> | undefined
+    let delay = 1000
+    let stopped = false
+
+    const askPermission = () => {
+      if ('Notification' in window && window.Notification.permission === 'default') {
+        window.Notification.requestPermission()
+      }
+    }
+    document.addEventListener('click', askPermission, { once: true })
+
+    const connect = () => {
+      ws = new WebSocket(wsUrl(`/ws/events?backlog=false&attention=true&notify=${NOTIFY}`))
+      ws.onopen = () => {
+        delay = 1000
+      }
+      ws.onmessage = (message) => {
+        const event: EventMessage = JSON.parse(message.data)
+        if (event.type === 'attention') {
+          setBadge(event.awaiting_prompt?.length ?? 0)
+        } else if (event.notification) {
+          notify(event.notification, event.instance)
+        }
+      }
+      ws.onclose = () => {
+        if (!stopped) {
+          retry = setTimeout(connect, delay)
+          delay = Math.min(delay * 2, 30000)
+        }
+      }
+    }
+    connect()
+
+    return () => {
+      stopped = true
+      clearTimeout(retry)
+      ws?.close()
+      document.removeEventListener('click', askPermission)
+      setBadge(0)
+    }
+  }, [])
+}
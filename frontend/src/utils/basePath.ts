@@ -0,0 +1,33 @@
+// The path the server is reached under behind a reverse proxy, such as /claude-squad, read from
+// the meta tag the server adds to index.html. It is empty when the app is served at the root.
+export const basePath =
+  document.querySelector('meta[name="claude-squad-base-path"]')?.getAttribute('content') ?? ''
+
+// The access token from the token query parameter of a shared link, such as a token scoped to one
+// instance. It is kept for the rest of the browser session so it survives navigation.
+export const accessToken = (() => {
+  const token = new URLSearchParams(window.location.search).get('token')
+  if (token) {
+    sessionStorage.setItem('claude-squad-token', token)
+    return token
+  }
+  return sessionStorage.getItem('claude-squad-token') ?? ''
+})()
+
+// authHeaders returns the headers sending the access token with a fetch, if there is one
+export const authHeaders = (): Record<string, string> =>
+  accessToken ? { Authorization: `Bearer ${accessToken}` } : {}
+
+// apiUrl prefixes an absolute server path, such as /api/instances, with the base path
+export const apiUrl = (path: string) => `${basePath}${path}`
+
+// wsUrl returns the WebSocket URL of an absolute server path, such as /ws/{name}. Browsers can't
+// send headers with a WebSocket, so the access token goes in the query.
+export const wsUrl = (path: string) => {
+  const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
+  const url = `${protocol}//${window.location.host}${basePath}${path}`
+  if (!accessToken) {
+    return url
+  }
+  return `${url}${path.includes('?') ? '&' : '?'}token=${encodeURIComponent(accessToken)}`
+}
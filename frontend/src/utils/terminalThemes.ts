@@ -0,0 +1,53 @@
+import type { ITheme, Terminal } from 'xterm'
+
+// The terminal color themes named by the server's display preferences
+export const terminalThemes: Record<string, ITheme> = {
+  dark: {
+    background: '#1e1e1e',
+    foreground: '#f0f0f0',
+    cursor: '#f0f0f0',
+    selectionBackground: 'rgba(240, 240, 240, 0.3)',
+  },
+  light: {
+    background: '#ffffff',
+    foreground: '#1e1e1e',
+    cursor: '#1e1e1e',
+    selectionBackground: 'rgba(30, 30, 30, 0.2)',
+  },
+  'high-contrast': {
+    background: '#000000',
+    foreground: '#ffffff',
+    cursor: '#ffff00',
+    selectionBackground: 'rgba(255, 255, 0, 0.4)',
+  },
+  'solarized-dark': {
+    background: '#002b36',
+    foreground: '#839496',
+    cursor: '#93a1a1',
+    selectionBackground: 'rgba(147, 161, 161, 0.3)',
+  },
+  'solarized-light': {
+    background: '#fdf6e3',
+    foreground: '#657b83',
+    cursor: '#586e75',
+    selectionBackground: 'rgba(88, 110, 117, 0.2)',
+  },
+}
+
+// The display preferences sent in the terminal WebSocket's config message
+export interface TerminalDisplay {
+  theme?: string
+  fontFamily?: string
+  fontSize?: number
+}
+
+// applyDisplay shows the terminal with the user's display preferences
+export const applyDisplay = (terminal: Terminal, display: TerminalDisplay) => {
+  terminal.options.theme = terminalThemes[display.theme ?? 'dark'] ?? terminalThemes.dark
+  if (display.fontFamily) {
+    terminal.options.fontFamily = display.fontFamily
+  }
+  if (display.fontSize) {
+    terminal.options.fontSize = display.fontSize
+  }
+}
@@ -22,8 +22,30 @@ const (
 
 	KeyCheckout
 	KeyResume
-	KeyPrompt // New key for entering a prompt
-	KeyHelp   // Key for showing help screen
+	KeyPrompt       // New key for entering a prompt
+	KeyHelp         // Key for showing help screen
+	KeySwapProgram  // Key for replacing the program running in an instance
+	KeyDetail       // Key for showing instance details
+	KeySuspendAll   // Key for suspending and resuming every agent at once
+	KeyFork         // Key for forking the selected instance
+	KeyLineage      // Key for showing the instance lineage tree
+	KeyRetry        // Key for retrying the selected instance's task on a fresh worktree
+	KeyCompare      // Key for comparing the changes of two instances
+	KeySnapshot     // Key for snapshotting the selected instance's worktree
+	KeyRollback     // Key for listing snapshots to roll back to
+	KeyTemplate     // Key for sending a prompt from a template
+	KeyActivity     // Key for opening and closing the activity feed
+	KeyToggleGroup  // Key for collapsing and expanding the repo group of the selected instance
+	KeyNextWindow   // Key for showing the next tmux window of the selected instance
+	KeyShellWindow  // Key for opening a shell window in the selected instance
+	KeyRunCommand   // Key for running a shell command in the selected instance's worktree
+	KeyAutoYes      // Key for turning auto-yes on for a while, or off, in the selected instance
+	KeySlash        // Key for sending a Claude Code slash command to the selected instance
+	KeyNewOnBranch  // Key for creating a new instance on an existing branch
+	KeyShowCommit   // Key for showing the patch of the commit selected in the commits tab
+	KeyUndoCommit   // Key for reverting or dropping the commit selected in the commits tab
+	KeySquash       // Key for squashing the commits of the selected instance and pushing them
+	KeyReplayPrompt // Key for editing and sending again the prompt selected in the prompts tab
 
 	// Diff keybindings
 	KeyShiftUp
@@ -49,6 +71,29 @@ var GlobalKeyStringsMap = map[string]KeyName{
 	"r":          KeyResume,
 	"p":          KeySubmit,
 	"?":          KeyHelp,
+	"s":          KeySwapProgram,
+	"i":          KeyDetail,
+	"z":          KeySuspendAll,
+	"F":          KeyFork,
+	"g":          KeyLineage,
+	"R":          KeyRetry,
+	"E":          KeyReview,
+	"C":          KeyCompare,
+	"v":          KeySnapshot,
+	"V":          KeyRollback,
+	"T":          KeyTemplate,
+	"a":          KeyActivity,
+	" ":          KeyToggleGroup,
+	"w":          KeyNextWindow,
+	"W":          KeyShellWindow,
+	"!":          KeyRunCommand,
+	"y":          KeyAutoYes,
+	"/":          KeySlash,
+	"b":          KeyNewOnBranch,
+	"x":          KeyShowCommit,
+	"u":          KeyUndoCommit,
+	"P":          KeySquash,
+	"e":          KeyReplayPrompt,
 }
 
 // GlobalkeyBindings is a global, immutable map of KeyName tot keybinding.
@@ -109,6 +154,98 @@ var GlobalkeyBindings = map[KeyName]key.Binding{
 		key.WithKeys("r"),
 		key.WithHelp("r", "resume"),
 	),
+	KeySwapProgram: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "swap program"),
+	),
+	KeyDetail: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "details"),
+	),
+	KeySuspendAll: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "suspend all"),
+	),
+	KeyFork: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "fork"),
+	),
+	KeyLineage: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "lineage"),
+	),
+	KeyRetry: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "retry"),
+	),
+	KeyReview: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "review"),
+	),
+	KeyCompare: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "compare"),
+	),
+	KeySnapshot: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "snapshot"),
+	),
+	KeyRollback: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "rollback"),
+	),
+	KeyTemplate: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "templates"),
+	),
+	KeyActivity: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "activity"),
+	),
+	KeyToggleGroup: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "collapse repo"),
+	),
+	KeyNextWindow: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "next window"),
+	),
+	KeyShellWindow: key.NewBinding(
+		key.WithKeys("W"),
+		key.WithHelp("W", "shell"),
+	),
+	KeyRunCommand: key.NewBinding(
+		key.WithKeys("!"),
+		key.WithHelp("!", "run"),
+	),
+	KeyAutoYes: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "auto-yes"),
+	),
+	KeySlash: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "slash command"),
+	),
+	KeyNewOnBranch: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "new on branch"),
+	),
+	KeyShowCommit: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "show commit"),
+	),
+	KeyUndoCommit: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo commit"),
+	),
+	KeySquash: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "squash and push"),
+	),
+	KeyReplayPrompt: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "replay prompt"),
+	),
 
 	// -- Special keybindings --
 
@@ -2,16 +2,34 @@ package main
 
 import (
 	"claude-squad/app"
+	"claude-squad/command"
 	"claude-squad/config"
 	"claude-squad/daemon"
+	"claude-squad/fakeagent"
 	"claude-squad/log"
+	"claude-squad/network"
 	"claude-squad/session"
 	"claude-squad/session/git"
 	"claude-squad/session/tmux"
+	"claude-squad/session/vcs"
+	"claude-squad/ticket"
+	"claude-squad/tracing"
+	"claude-squad/tray"
+	"claude-squad/web/client"
+	"claude-squad/web/demo"
+	"claude-squad/web/handlers"
+	"claude-squad/web/tokens"
+	"claude-squad/webhook"
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -25,7 +43,19 @@ var (
 	fileLoggingFlag       bool
 	webMonitoringFlag     bool
 	webMonitoringPortFlag int
+	webSocketFlag         string
 	reactUIFlag           bool
+	demoFlag              bool
+	fakeAgentOptions      = fakeagent.DefaultOptions()
+	integrateBranchFlag   string
+	statusFormatFlag      string
+	reportFormatFlag      string
+	reportOutputFlag      string
+	dryRunFlag            bool
+	recoverBranchFlag     string
+	recoverInstanceFlag   string
+	templateFlag          string
+	templateAddOptions    config.SessionTemplate
 	rootCmd     = &cobra.Command{
 		Use:   "claude-squad",
 		Short: "Claude Squad - A terminal-based session manager",
@@ -42,52 +72,93 @@ var (
 
 			if daemonFlag {
 				cfg := config.LoadConfig()
-				err := daemon.RunDaemon(cfg)
+				err := daemon.RunDaemon(cfg, autoYesFlag)
 				log.ErrorLog.Printf("failed to start daemon %v", err)
 				return err
 			}
 
-			// Check if we're in a git repository
+			if demoFlag {
+				if !webMonitoringFlag {
+					return fmt.Errorf("--demo requires --web")
+				}
+				cfg := config.LoadConfig()
+				if webMonitoringPortFlag != 0 {
+					cfg.WebServerPort = webMonitoringPortFlag
+				}
+				if webSocketFlag != "" {
+					cfg.WebServerSocket = webSocketFlag
+				}
+				shutdownTracing, err := tracing.Init(ctx, cfg, version)
+				if err != nil {
+					return err
+				}
+				defer shutdownTracing()
+				defer webhook.Start(cfg.Webhooks)()
+				defer ticket.Start(cfg.Tickets)()
+				configureSessions(cfg)
+				ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				return demo.Run(ctx, cfg)
+			}
+
 			currentDir, err := filepath.Abs(".")
 			if err != nil {
 				return fmt.Errorf("failed to get current directory: %w", err)
 			}
 
-			if !git.IsGitRepo(currentDir) {
-				return fmt.Errorf("error: claude-squad must be run from within a git repository")
+			cfg := config.LoadConfig()
+			if err := vcs.Check(cfg.VCS); err != nil {
+				return err
 			}
 
-			cfg := config.LoadConfig()
+			// Check if we're in a git repository, unless instances work in copies of the directory
+			if cfg.VCS != vcs.Directory {
+				state, err := git.InspectRepo(currentDir)
+				if err != nil {
+					return fmt.Errorf("error: %w", err)
+				}
+				if state.Bare && simpleModeFlag {
+					return fmt.Errorf("error: simple mode runs in the current directory, and the bare repository %s has no working tree: run without --simple to work in worktrees", state.Root)
+				}
+			}
 
-			// Program flag overrides config
-			program := cfg.DefaultProgram
-			if programFlag != "" {
-				program = programFlag
+			shutdownTracing, err := tracing.Init(ctx, cfg, version)
+			if err != nil {
+				return err
+			}
+			defer shutdownTracing()
+			defer webhook.Start(cfg.Webhooks)()
+			defer ticket.Start(cfg.Tickets)()
+			if address := git.ProbeAddress(currentDir); address != "" {
+				defer network.Start(address)()
 			}
-			// AutoYes flag overrides config
-			autoYes := cfg.AutoYes
-			if autoYesFlag || simpleModeFlag {
-				autoYes = true
+			configureSessions(cfg)
+
+			// Flags override the config
+			factory := session.NewFactory(cfg, programFlag, autoYesFlag || simpleModeFlag)
+			if templateFlag != "" {
+				if _, err := factory.SessionTemplate(templateFlag); err != nil {
+					return err
+				}
+				factory.Template = templateFlag
 			}
-			if autoYes {
+			// The daemon takes over auto-yes and restarts while the TUI is closed. One that runs
+			// already is left running: the TUI leaves the instances it watches to it.
+			if factory.AutoYes || cfg.RestartEnabled() {
 				defer func() {
-					if err := daemon.LaunchDaemon(); err != nil {
+					if err := daemon.LaunchDaemon(factory.AutoYes); err != nil {
 						log.ErrorLog.Printf("failed to launch daemon: %v", err)
 					}
 				}()
 			}
-			// Kill any daemon that's running.
-			if err := daemon.StopDaemon(); err != nil {
-				log.ErrorLog.Printf("failed to stop daemon: %v", err)
-			}
 
 			// Create start options
 			startOptions := app.StartOptions{
-				Program:          program,
-				AutoYes:          autoYes,
+				Factory:          factory,
 				SimpleMode:       simpleModeFlag,
 				WebServerEnabled: webMonitoringFlag,
 				WebServerPort:    webMonitoringPortFlag,
+				WebServerSocket:  webSocketFlag,
 				ReactUI:          reactUIFlag,
 			}
 			
@@ -112,6 +183,7 @@ var (
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Initialize(false)
 			defer log.Close()
+			git.SetDryRun(dryRunFlag || config.LoadConfig().DryRun)
 
 			state := config.LoadState()
 			storage, err := session.NewStorage(state)
@@ -123,6 +195,15 @@ var (
 			}
 			fmt.Println("Storage has been reset successfully")
 
+			// Tokens scoped to the deleted instances would open new instances of the same titles
+			tokenStore, err := tokens.NewStore()
+			if err == nil {
+				err = tokenStore.RevokeInstance("")
+			}
+			if err != nil {
+				return fmt.Errorf("failed to revoke web tokens: %w", err)
+			}
+
 			if err := tmux.CleanupSessions(); err != nil {
 				return fmt.Errorf("failed to cleanup tmux sessions: %w", err)
 			}
@@ -131,7 +212,11 @@ var (
 			if err := git.CleanupWorktrees(); err != nil {
 				return fmt.Errorf("failed to cleanup worktrees: %w", err)
 			}
-			fmt.Println("Worktrees have been cleaned up")
+			if git.DryRun() {
+				fmt.Println("Dry run: worktrees and branches were left in place, the log lists what would have been removed")
+			} else {
+				fmt.Println("Worktrees have been cleaned up")
+			}
 
 			// Kill any daemon that's running.
 			if err := daemon.StopDaemon(); err != nil {
@@ -161,6 +246,565 @@ var (
 		},
 	}
 
+	fakeAgentCmd = &cobra.Command{
+		Use:    "fake-agent",
+		Short:  "Run a scripted stand-in for claude (for tests and demos)",
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fakeagent.Run(fakeAgentOptions, os.Stdin, os.Stdout)
+		},
+	}
+
+	integrateCmd = &cobra.Command{
+		Use:   "integrate <instance> <instance>...",
+		Short: "Merge the branches of instances into a new integration branch",
+		Long: "Merge the branches of the given instances, in order, into a new branch created from HEAD. " +
+			"Branches that conflict are left out and reported, along with every pair of instances " +
+			"whose branches conflict with each other. Only committed work is merged.",
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			instances, err := storage.LoadInstances()
+			if err != nil {
+				return fmt.Errorf("failed to load instances: %w", err)
+			}
+			byTitle := make(map[string]*session.Instance, len(instances))
+			for _, instance := range instances {
+				byTitle[instance.Title] = instance
+			}
+			selected := make([]*session.Instance, 0, len(args))
+			titles := make(map[string]string, len(args))
+			for _, title := range args {
+				instance, ok := byTitle[title]
+				if !ok {
+					return fmt.Errorf("instance %s not found", title)
+				}
+				selected = append(selected, instance)
+				titles[instance.Branch] = title
+			}
+
+			branch := integrateBranchFlag
+			if branch == "" {
+				branch = "integration/" + time.Now().Format("20060102-150405")
+			}
+			result, uncommitted, err := session.IntegrateInstances(branch, selected)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Created %s from %.7s\n", result.Branch, result.Base)
+			for _, merge := range result.Merges {
+				if merge.Merged {
+					fmt.Printf("  merged   %s\n", titles[merge.Branch])
+				} else {
+					fmt.Printf("  skipped  %s: conflicts in %s\n", titles[merge.Branch], strings.Join(merge.Conflicts, ", "))
+				}
+			}
+			if len(result.PairConflicts) > 0 {
+				fmt.Println("Conflicting pairs:")
+				for _, pair := range result.PairConflicts {
+					fmt.Printf("  %s and %s: %s\n", titles[pair.A], titles[pair.B], strings.Join(pair.Files, ", "))
+				}
+			}
+			if len(uncommitted) > 0 {
+				fmt.Printf("Uncommitted changes were not integrated: %s\n", strings.Join(uncommitted, ", "))
+			}
+			return nil
+		},
+	}
+
+	statusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Print a summary of all instances",
+		Long: "Print instance counts by status, the total diff, the instances awaiting a prompt and " +
+			"whether the daemon runs. --format tmux prints a single line such as \"CS: 3▶ 1❓ 1⏸\" " +
+			"(running, awaiting a prompt, paused) for a tmux status bar or a shell prompt. Only " +
+			"saved state is read, so it is cheap to poll every few seconds.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			data, err := storage.LoadInstanceData()
+			if err != nil {
+				return fmt.Errorf("failed to load instances: %w", err)
+			}
+			summary := session.Summarize(data)
+			pid, daemonRunning := daemon.Status()
+			var daemonStats *daemon.Stats
+			if daemonRunning {
+				daemonStats, _ = daemon.ReadStats()
+			}
+
+			switch statusFormatFlag {
+			case "tmux":
+				fmt.Println(summary.Compact())
+			case "json":
+				// The same shape as GET /api/summary, without the server uptime
+				type daemonStatus struct {
+					Running bool          `json:"running"`
+					PID     int           `json:"pid,omitempty"`
+					Stats   *daemon.Stats `json:"stats,omitempty"`
+				}
+				out, err := json.Marshal(struct {
+					session.Summary
+					Daemon daemonStatus `json:"daemon"`
+				}{summary, daemonStatus{Running: daemonRunning, PID: pid, Stats: daemonStats}})
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(out))
+			case "text":
+				fmt.Printf("%d instances: %d running, %d ready, %d loading, %d paused, %d exited\n", summary.Instances,
+					summary.ByStatus[session.Running.String()], summary.ByStatus[session.Ready.String()],
+					summary.ByStatus[session.Loading.String()], summary.ByStatus[session.Paused.String()],
+					summary.ByStatus[session.Exited.String()])
+				fmt.Printf("Diff: +%d -%d\n", summary.Added, summary.Removed)
+				if len(summary.AwaitingPrompt) > 0 {
+					fmt.Printf("Awaiting prompt: %s\n", strings.Join(summary.AwaitingPrompt, ", "))
+				}
+				if daemonRunning {
+					fmt.Printf("Daemon: running (PID %d)\n", pid)
+					if daemonStats != nil {
+						fmt.Println(daemonStats.Describe())
+					}
+				} else {
+					fmt.Println("Daemon: not running")
+				}
+			default:
+				return fmt.Errorf("unknown format %q, use text, tmux or json", statusFormatFlag)
+			}
+			return nil
+		},
+	}
+
+	trayCmd = &cobra.Command{
+		Use:   "tray",
+		Short: "Print the squad summary as a menu bar plugin for xbar, SwiftBar or Argos",
+		Long: "Print the summary of GET /api/summary as the output of a menu bar plugin: the " +
+			"counts of cs status --format tmux in the menu bar, highlighted while instances wait " +
+			"for a prompt, and the details in its menu, with items opening the web UI, the " +
+			"terminal of an instance waiting for a prompt, or claude-squad in a terminal. Install " +
+			"it as an executable script running cs tray, such as claude-squad.10s.sh, in the " +
+			"plugin folder of xbar or SwiftBar on macOS, or Argos on GNOME. It needs the web " +
+			"server: run claude-squad with --web.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			cfg := config.LoadConfig()
+			c := client.New(cfg)
+			actions := tray.Actions{}
+			if cfg.WebServerSocket == "" {
+				actions.WebURL = c.URL("/")
+			}
+			if executable, err := os.Executable(); err == nil {
+				actions.Executable = executable
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+			defer cancel()
+			var summary handlers.Summary
+			// The plugin shows what is printed, so errors are printed as a menu too
+			if err := c.Get(ctx, "/api/summary", &summary); err != nil {
+				fmt.Print(tray.Unreachable(err, actions))
+				return nil
+			}
+			fmt.Print(tray.Menu(summary, actions))
+			return nil
+		},
+	}
+
+	reportCmd = &cobra.Command{
+		Use:   "report [instance]...",
+		Short: "Write a Markdown or HTML report of the instances",
+		Long: "Write a report summarizing each instance, or only the given ones: its prompt, " +
+			"notes, ticket, duration, diff stats, changed files, branch with a link to it on the " +
+			"web, and status. It is written to standard output unless --output is set, for a " +
+			"standup or a pull request description. Only saved state is read.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			data, err := storage.LoadInstanceData()
+			if err != nil {
+				return fmt.Errorf("failed to load instances: %w", err)
+			}
+			if len(args) > 0 {
+				byTitle := make(map[string]session.InstanceData, len(data))
+				for _, instance := range data {
+					byTitle[instance.Title] = instance
+				}
+				data = data[:0]
+				for _, title := range args {
+					instance, ok := byTitle[title]
+					if !ok {
+						return fmt.Errorf("instance %s not found", title)
+					}
+					data = append(data, instance)
+				}
+			}
+
+			report := session.NewReport(data)
+			var out string
+			switch reportFormatFlag {
+			case "markdown", "md":
+				out = report.Markdown()
+			case "html":
+				if out, err = report.HTML(); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unknown format %q, use markdown or html", reportFormatFlag)
+			}
+			if reportOutputFlag == "" {
+				fmt.Print(out)
+				return nil
+			}
+			if err := os.WriteFile(reportOutputFlag, []byte(out), 0644); err != nil {
+				return fmt.Errorf("failed to write the report: %w", err)
+			}
+			fmt.Printf("Wrote a report of %d instances to %s\n", len(report.Entries), reportOutputFlag)
+			return nil
+		},
+	}
+
+	recoverCmd = &cobra.Command{
+		Use:   "recover [number|commit]",
+		Short: "List work lost with deleted branches and recreate a branch from it",
+		Long: "Without arguments, list the commits of the current repository that are no longer on " +
+			"any branch, newest first: the tips of branches deleted when their instance was killed " +
+			"or reset, and unreachable commits such as snapshots. Given a number from the list or " +
+			"a commit, recreate a branch at it, named after the deleted branch unless --branch is " +
+			"set. With --instance, an instance is created on the recovered branch. git gc prunes " +
+			"unreachable commits after two weeks.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			currentDir, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			lost, err := git.FindLostWork(currentDir)
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				if len(lost) == 0 {
+					fmt.Println("No lost work found")
+					return nil
+				}
+				for i, work := range lost {
+					branch := work.Branch
+					if branch == "" {
+						branch = "(unreachable)"
+					}
+					fmt.Printf("%3d  %.7s  %s  %-30s %s\n", i+1, work.Commit, work.Time.Format("2006-01-02 15:04"), branch, work.Subject)
+				}
+				return nil
+			}
+
+			var work git.LostWork
+			n, numErr := strconv.Atoi(args[0])
+			if numErr == nil && n >= 1 && n <= len(lost) {
+				work = lost[n-1]
+			} else {
+				for _, candidate := range lost {
+					if strings.HasPrefix(candidate.Commit, args[0]) {
+						work = candidate
+						break
+					}
+				}
+			}
+			if work.Commit == "" {
+				if numErr == nil && len(args[0]) < 4 {
+					return fmt.Errorf("no lost work numbered %d, run cs recover to list it", n)
+				}
+				// Any commit still in the repository can be recovered
+				work.Commit = args[0]
+			}
+
+			cfg := config.LoadConfig()
+			branch := recoverBranchFlag
+			if recoverInstanceFlag != "" {
+				if branch != "" {
+					return fmt.Errorf("--branch can't be used with --instance, the branch is named after the instance")
+				}
+				session.SetTitlePolicy(cfg.TitlePolicy)
+				branch, _ = session.TitleNames(recoverInstanceFlag)
+			}
+			if branch == "" {
+				branch = work.Branch
+			}
+			if branch == "" {
+				branch = "recovered/" + work.Commit[:min(7, len(work.Commit))]
+			}
+
+			var storage *session.Storage
+			var instances []*session.Instance
+			if recoverInstanceFlag != "" {
+				if storage, err = session.NewStorage(config.LoadState()); err != nil {
+					return fmt.Errorf("failed to initialize storage: %w", err)
+				}
+				if instances, err = storage.LoadInstances(); err != nil {
+					return fmt.Errorf("failed to load instances: %w", err)
+				}
+				if err := session.ValidateTitle(recoverInstanceFlag, instances); err != nil {
+					return err
+				}
+			}
+
+			if err := git.RecoverBranch(currentDir, branch, work.Commit); err != nil {
+				return err
+			}
+			fmt.Printf("Recreated %s at %.7s\n", branch, work.Commit)
+			if recoverInstanceFlag == "" {
+				return nil
+			}
+
+			instance, err := session.NewFactory(cfg, programFlag, false).New(session.InstanceOptions{
+				Title: recoverInstanceFlag,
+				Path:  currentDir,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create instance: %w", err)
+			}
+			// The worktree is created on the existing branch
+			if err := instance.Start(true); err != nil {
+				return fmt.Errorf("failed to start instance: %w", err)
+			}
+			if err := storage.SaveInstances(append(instances, instance)); err != nil {
+				return fmt.Errorf("failed to save instances: %w", err)
+			}
+			fmt.Printf("Created instance %s on %s\n", recoverInstanceFlag, branch)
+			return nil
+		},
+	}
+
+	batchCmd = &cobra.Command{
+		Use:   "batch <manifest>",
+		Short: "Create and start the instances listed in a YAML or JSON manifest",
+		Long: "Create and start every instance listed in the manifest, one after the other, and " +
+			"send each its prompt. Each instance has a title and may set a path, program, " +
+			"auto_yes, vcs, branch, base_branch, ticket, tags, git_config and prompt; defaults sets them " +
+			"for all. Titles are checked before anything is created, and an instance failing to " +
+			"start doesn't stop the others. A running TUI lists the instances once they have started.",
+		Example: "  claude-squad batch squad.yaml\n\n" +
+			"squad.yaml:\n" +
+			"  defaults:\n" +
+			"    program: claude\n" +
+			"    base_branch: main\n" +
+			"  instances:\n" +
+			"    - title: fix-login\n" +
+			"      prompt: Fix the login timeout in auth/session.go\n" +
+			"    - title: docs\n" +
+			"      program: aider\n" +
+			"      prompt: Document the public API of the client package",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			manifest, err := session.LoadManifest(args[0])
+			if err != nil {
+				return err
+			}
+			cfg := config.LoadConfig()
+			configureSessions(cfg)
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+
+			factory := session.NewFactory(cfg, programFlag, autoYesFlag)
+			if templateFlag != "" {
+				if _, err := factory.SessionTemplate(templateFlag); err != nil {
+					return err
+				}
+				factory.Template = templateFlag
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			created, err := session.BatchCreate(ctx, factory, storage, manifest)
+			for _, instance := range created {
+				if instance.Branch != "" {
+					fmt.Printf("Created instance %s on %s\n", instance.Title, instance.Branch)
+				} else {
+					fmt.Printf("Created instance %s\n", instance.Title)
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("%d of %d instances created: %w", len(created), len(manifest.Instances), err)
+			}
+			return nil
+		},
+	}
+
+	templateCmd = &cobra.Command{
+		Use:   "template",
+		Short: "List, add and remove session templates",
+		Long: "Session templates are named profiles for launching instances, kept in the config: the " +
+			"program, auto-yes, a branch prefix replacing session/, the directory worktrees are created " +
+			"in and a prompt to send once the instance has started. Pick one when creating an " +
+			"instance in the TUI, or pass --template to claude-squad or batch.",
+	}
+
+	templateListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the session templates",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			cfg, err := config.ReadConfig()
+			if err != nil {
+				return err
+			}
+			if len(cfg.SessionTemplates) == 0 {
+				fmt.Println("No session templates. Add one with: claude-squad template add <name>")
+				return nil
+			}
+			for _, name := range cfg.SessionTemplateNames() {
+				fmt.Printf("%s: %s\n", name, describeSessionTemplate(cfg.SessionTemplates[name]))
+			}
+			return nil
+		},
+	}
+
+	templateAddCmd = &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add a session template, or replace the one of that name",
+		Example: "  claude-squad template add backend --program aider --branch-prefix alice/ \\\n" +
+			"    --worktree-dir ~/worktrees --prompt \"Read CONTRIBUTING.md first\"",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			if err := config.ValidateSessionTemplateName(args[0]); err != nil {
+				return err
+			}
+			if err := templateAddOptions.Validate(); err != nil {
+				return err
+			}
+			cfg, err := config.ReadConfig()
+			if err != nil {
+				return err
+			}
+			if cfg.SessionTemplates == nil {
+				cfg.SessionTemplates = make(map[string]config.SessionTemplate)
+			}
+			_, replaced := cfg.SessionTemplates[args[0]]
+			cfg.SessionTemplates[args[0]] = templateAddOptions
+			if err := config.SaveConfig(cfg); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			if replaced {
+				fmt.Printf("Replaced session template %s\n", args[0])
+			} else {
+				fmt.Printf("Added session template %s\n", args[0])
+			}
+			return nil
+		},
+	}
+
+	templateRemoveCmd = &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a session template",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			cfg, err := config.ReadConfig()
+			if err != nil {
+				return err
+			}
+			if _, ok := cfg.SessionTemplates[args[0]]; !ok {
+				return fmt.Errorf("no session template %q", args[0])
+			}
+			delete(cfg.SessionTemplates, args[0])
+			if err := config.SaveConfig(cfg); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			fmt.Printf("Removed session template %s\n", args[0])
+			return nil
+		},
+	}
+
+	daemonCmd = &cobra.Command{
+		Use:   "daemon",
+		Short: "Start or stop the daemon",
+		Long: "The daemon accepts prompts of instances with auto-yes on and restarts exited programs " +
+			"according to the restart policies. claude-squad launches it on quitting when auto-yes or " +
+			"restarts are on; start it to keep it running without the TUI, such as next to a web " +
+			"server. It runs alongside the TUI, which leaves the instances the daemon watches to it.",
+	}
+
+	daemonStartCmd = &cobra.Command{
+		Use:   "start",
+		Short: "Start the daemon unless it runs already",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			if pid, running := daemon.Status(); running {
+				fmt.Printf("Daemon already running (PID %d)\n", pid)
+				return nil
+			}
+			if err := daemon.LaunchDaemon(autoYesFlag); err != nil {
+				return err
+			}
+			for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+				if pid, running := daemon.Status(); running {
+					fmt.Printf("Daemon started (PID %d)\n", pid)
+					return nil
+				}
+			}
+			return fmt.Errorf("the daemon didn't start, see the log")
+		},
+	}
+
+	daemonStopCmd = &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the daemon",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			if _, running := daemon.Status(); !running {
+				fmt.Println("Daemon not running")
+				return nil
+			}
+			if err := daemon.StopDaemon(); err != nil {
+				return err
+			}
+			fmt.Println("Daemon stopped")
+			return nil
+		},
+	}
+
 	versionCmd = &cobra.Command{
 		Use:   "version",
 		Short: "Print the version number of claude-squad",
@@ -171,6 +815,51 @@ var (
 	}
 )
 
+// describeSessionTemplate describes what a session template sets, on one line.
+func describeSessionTemplate(t config.SessionTemplate) string {
+	var parts []string
+	if t.Program != "" {
+		parts = append(parts, "program "+t.Program)
+	}
+	if t.AutoYes {
+		parts = append(parts, "auto-yes")
+	}
+	if t.BranchPrefix != "" {
+		parts = append(parts, "branches "+t.BranchPrefix+"<title>")
+	}
+	if t.WorktreeDir != "" {
+		parts = append(parts, "worktrees in "+t.WorktreeDir)
+	}
+	if t.Prompt != "" {
+		parts = append(parts, fmt.Sprintf("prompt %q", t.Prompt))
+	}
+	if len(parts) == 0 {
+		return "the defaults"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// configureSessions applies the settings of cfg and the command line flags to the instances
+// created and loaded by this process.
+func configureSessions(cfg *config.Config) {
+	session.SetSnapshotPromptPatterns(cfg.SnapshotPromptPatterns)
+	session.SetGitAddPolicies(cfg.GitAdd, cfg.ProgramGitAdd)
+	session.SetGitConfigs(cfg.GitConfig, cfg.ProgramGitConfig)
+	session.SetPushScan(cfg.PushScan)
+	session.SetComplianceCommand(cfg.ComplianceCommand)
+	session.SetCheckpointCommits(cfg.CheckpointCommits)
+	session.SetTicketConfig(cfg.Tickets)
+	session.SetWindows(cfg.Windows)
+	session.SetRestartPolicies(cfg.Restart, cfg.ProgramRestart)
+	session.SetQuickActions(cfg.QuickActions)
+	session.SetTitlePolicy(cfg.TitlePolicy)
+	git.SetDryRun(dryRunFlag || cfg.DryRun)
+	git.SetFetchBase(cfg.FetchBase)
+	git.SetSubmodules(cfg.Submodules)
+	git.SetPushCredentials(cfg.PushCredentials)
+	command.SetTimeouts(cfg.Timeouts)
+}
+
 func init() {
 	rootCmd.Flags().StringVarP(&programFlag, "program", "p", "",
 		"Program to run in new instances (e.g. 'aider --model ollama_chat/gemma3:1b')")
@@ -184,10 +873,18 @@ func init() {
 		"Enable web monitoring server")
 	rootCmd.Flags().IntVar(&webMonitoringPortFlag, "web-port", 0,
 		"Web monitoring server port (default from config)")
+	rootCmd.Flags().StringVar(&webSocketFlag, "web-socket", "",
+		"Serve the web monitoring server on this unix socket instead of TCP")
 	rootCmd.Flags().BoolVar(&reactUIFlag, "react", false,
 		"Enable React frontend for web monitoring (requires --web)")
+	rootCmd.Flags().StringVarP(&templateFlag, "template", "t", "",
+		"Session template to launch new instances with, see the template command")
+	rootCmd.Flags().BoolVar(&demoFlag, "demo", false,
+		"Serve the web UI with simulated fake-agent instances (requires --web)")
+	rootCmd.PersistentFlags().BoolVar(&dryRunFlag, "dry-run", false,
+		"Only log what destructive git operations would do: removing worktrees, deleting branches and snapshots, rollbacks")
 	rootCmd.Flags().BoolVar(&daemonFlag, "daemon", false, "Run a program that loads all sessions"+
-		" and runs autoyes mode on them.")
+		" and runs autoyes mode and restart policies on them.")
 
 	// Hide the daemonFlag as it's only for internal use
 	err := rootCmd.Flags().MarkHidden("daemon")
@@ -195,12 +892,104 @@ func init() {
 		panic(err)
 	}
 
+	fakeAgentCmd.Flags().BoolVar(&fakeAgentOptions.Trust, "trust", fakeAgentOptions.Trust,
+		"Ask the folder trust question on startup")
+	fakeAgentCmd.Flags().IntVar(&fakeAgentOptions.PromptEvery, "prompt-every", fakeAgentOptions.PromptEvery,
+		"Raise a permission prompt after every N inputs (0 disables)")
+	fakeAgentCmd.Flags().DurationVar(&fakeAgentOptions.PromptInterval, "prompt-interval", fakeAgentOptions.PromptInterval,
+		"Raise a permission prompt while idle at this interval (0 disables)")
+	fakeAgentCmd.Flags().DurationVar(&fakeAgentOptions.Delay, "delay", fakeAgentOptions.Delay,
+		"Delay between lines of output")
+	fakeAgentCmd.Flags().IntVar(&fakeAgentOptions.Lines, "lines", fakeAgentOptions.Lines,
+		"Lines of output printed per input")
+
+	integrateCmd.Flags().StringVarP(&integrateBranchFlag, "branch", "b", "",
+		"Name of the integration branch (default integration/<timestamp>)")
+	integrateCmd.ValidArgsFunction = completeInstanceTitles
+
+	statusCmd.Flags().StringVarP(&statusFormatFlag, "format", "f", "text",
+		"Output format: text, tmux (one compact line) or json")
+
+	reportCmd.Flags().StringVarP(&reportFormatFlag, "format", "f", "markdown",
+		"Output format: markdown or html")
+	reportCmd.Flags().StringVarP(&reportOutputFlag, "output", "o", "",
+		"File to write the report to (default standard output)")
+	reportCmd.ValidArgsFunction = completeInstanceTitles
+
+	recoverCmd.Flags().StringVarP(&recoverBranchFlag, "branch", "b", "",
+		"Name of the recovered branch (default the deleted branch's name, or recovered/<commit>)")
+	recoverCmd.Flags().StringVarP(&recoverInstanceFlag, "instance", "i", "",
+		"Create an instance with this title on the recovered branch")
+	recoverCmd.Flags().StringVarP(&programFlag, "program", "p", "",
+		"Program to run in the recovered instance")
+
+	batchCmd.Flags().StringVarP(&programFlag, "program", "p", "",
+		"Program to run in instances the manifest doesn't give one")
+	batchCmd.Flags().BoolVarP(&autoYesFlag, "autoyes", "y", false,
+		"[experimental] Automatically accept prompts in every instance created")
+	batchCmd.Flags().StringVarP(&templateFlag, "template", "t", "",
+		"Session template of instances the manifest doesn't give one")
+
+	templateAddCmd.Flags().StringVarP(&templateAddOptions.Program, "program", "p", "",
+		"Program to run in the instances")
+	templateAddCmd.Flags().BoolVarP(&templateAddOptions.AutoYes, "autoyes", "y", false,
+		"Turn auto-yes on in the instances")
+	templateAddCmd.Flags().StringVar(&templateAddOptions.BranchPrefix, "branch-prefix", "",
+		"Prefix of the instances' branches instead of session/, such as alice/")
+	templateAddCmd.Flags().StringVar(&templateAddOptions.WorktreeDir, "worktree-dir", "",
+		"Directory to create the instances' worktrees in, absolute or starting with ~/")
+	templateAddCmd.Flags().StringVar(&templateAddOptions.Prompt, "prompt", "",
+		"Prompt to send once an instance has started")
+	templateCmd.AddCommand(templateListCmd, templateAddCmd, templateRemoveCmd)
+
+	daemonStartCmd.Flags().BoolVarP(&autoYesFlag, "autoyes", "y", false,
+		"[experimental] Accept the prompts of every instance, not only those with auto-yes on")
+	daemonCmd.AddCommand(daemonStartCmd, daemonStopCmd)
+
 	rootCmd.AddCommand(debugCmd)
+	rootCmd.AddCommand(fakeAgentCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(resetCmd)
+	rootCmd.AddCommand(integrateCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(trayCmd)
+	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(recoverCmd)
+	rootCmd.AddCommand(batchCmd)
+	rootCmd.AddCommand(templateCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
+
+// completeInstanceTitles completes the titles of saved instances not already given as arguments,
+// described by their status. Only the saved state is read, so completing doesn't touch tmux.
+func completeInstanceTitles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	log.Initialize(false)
+	defer log.Close()
+
+	storage, err := session.NewStorage(config.LoadState())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	data, err := storage.LoadInstanceData()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	given := make(map[string]bool, len(args))
+	for _, arg := range args {
+		given[arg] = true
+	}
+	var titles []string
+	for _, instance := range data {
+		if !given[instance.Title] && strings.HasPrefix(instance.Title, toComplete) {
+			titles = append(titles, instance.Title+"\t"+instance.Status.String())
+		}
+	}
+	return titles, cobra.ShellCompDirectiveNoFileComp
 }
 
 func main() {
+	// Name the command as it was installed, usually cs, so shell completions register for it
+	rootCmd.Use = filepath.Base(os.Args[0])
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 	}
@@ -0,0 +1,195 @@
+// Package network tracks whether the host claude-squad pushes to can be reached, so pushes and
+// requests to the forge wait for the network to come back instead of timing out one by one.
+package network
+
+import (
+	"claude-squad/log"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAddress is probed when the repository has no origin to probe, since pushes go
+	// through gh.
+	DefaultAddress = "github.com:443"
+
+	probeTimeout = 3 * time.Second
+	// onlineInterval and offlineInterval are the delays between probes: offline, the network is
+	// probed more often so queued work goes out soon after it is back.
+	onlineInterval  = 30 * time.Second
+	offlineInterval = 5 * time.Second
+)
+
+// unreachable are the messages git, ssh, curl and gh fail with when the network is down.
+var unreachable = []string{
+	"could not resolve host",
+	"couldn't resolve host",
+	"could not resolve hostname",
+	"temporary failure in name resolution",
+	"failed to connect to",
+	"network is unreachable",
+	"no route to host",
+	"connection timed out",
+	"operation timed out",
+	"error connecting to api.github.com",
+}
+
+var state struct {
+	mu      sync.Mutex
+	offline bool
+	// online is closed when the network comes back, and replaced when it goes down.
+	online chan struct{}
+}
+
+func init() {
+	state.online = make(chan struct{})
+	close(state.online)
+}
+
+// Offline reports whether the network was down when last checked.
+func Offline() bool {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.offline
+}
+
+// SetOffline records whether the network is down, as found by the monitor or by a request that
+// failed to reach its host.
+func SetOffline(offline bool) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if offline == state.offline {
+		return
+	}
+	state.offline = offline
+	if offline {
+		log.FileOnlyWarningLog.Print("network: offline, pushes and ticket comments wait for it to come back")
+		state.online = make(chan struct{})
+	} else {
+		log.FileOnlyInfoLog.Print("network: back online")
+		close(state.online)
+	}
+}
+
+// WaitOnline blocks until the network is up, and returns true, or until done is closed, and
+// returns false.
+func WaitOnline(done <-chan struct{}) bool {
+	state.mu.Lock()
+	online := state.online
+	state.mu.Unlock()
+	select {
+	case <-online:
+		return true
+	default:
+	}
+	select {
+	case <-online:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// IsNetworkError reports whether err failed to reach its host, rather than being turned down by
+// it. Errors of commands are recognized by their messages.
+func IsNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	for _, pattern := range unreachable {
+		if strings.Contains(message, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteAddress returns the host and port to probe for a git remote URL, such as
+// https://github.com/org/repo.git or git@github.com:org/repo.git, or "" for a local remote.
+func RemoteAddress(remote string) string {
+	remote = strings.TrimSpace(remote)
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil || u.Host == "" {
+			return ""
+		}
+		port := u.Port()
+		if port == "" {
+			switch u.Scheme {
+			case "https":
+				port = "443"
+			case "http":
+				port = "80"
+			case "ssh", "git+ssh":
+				port = "22"
+			case "git":
+				port = "9418"
+			default:
+				return ""
+			}
+		}
+		return net.JoinHostPort(u.Hostname(), port)
+	}
+	// scp-like syntax, [user@]host:path, but not the <transport>::<address> of remote helpers
+	if strings.Contains(remote, "::") {
+		return ""
+	}
+	if host, _, found := strings.Cut(remote, ":"); found && !strings.Contains(host, "/") {
+		if _, after, ok := strings.Cut(host, "@"); ok {
+			host = after
+		}
+		return net.JoinHostPort(host, "22")
+	}
+	return ""
+}
+
+// Probe reports whether a TCP connection to address can be opened.
+func Probe(address string) bool {
+	conn, err := net.DialTimeout("tcp", address, probeTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// Start probes address in the background, recording whether the network is down, until the
+// returned function is called.
+func Start(address string) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			SetOffline(!Probe(address))
+			interval := onlineInterval
+			if Offline() {
+				interval = offlineInterval
+			}
+			select {
+			case <-time.After(interval):
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
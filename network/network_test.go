@@ -0,0 +1,107 @@
+package network
+
+import (
+	"claude-squad/log"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRemoteAddress(t *testing.T) {
+	for remote, want := range map[string]string{
+		"https://github.com/org/repo.git":       "github.com:443",
+		"http://git.internal:8080/org/repo.git": "git.internal:8080",
+		"ssh://git@gitlab.com/org/repo.git":     "gitlab.com:22",
+		"git@github.com:org/repo.git\n":         "github.com:22",
+		"gitlab.example.com:org/repo.git":       "gitlab.example.com:22",
+		"/srv/git/repo.git":                     "",
+		"../repo":                               "",
+		"file:///srv/git/repo.git":              "",
+		"https://[2001:db8::1]/org/repo.git":    "[2001:db8::1]:443",
+		"git://git.kernel.org/pub/scm/git/git":  "git.kernel.org:9418",
+		"ext::ssh -i key example.com %S 'repo'": "",
+	} {
+		if got := RemoteAddress(remote); got != want {
+			t.Errorf("RemoteAddress(%q) = %q, want %q", remote, got, want)
+		}
+	}
+}
+
+func TestIsNetworkError(t *testing.T) {
+	_, dialErr := net.DialTimeout("tcp", "127.0.0.1:1", time.Second)
+	for err, want := range map[error]bool{
+		dialErr: true,
+		errors.New("fatal: unable to access 'https://github.com/org/repo.git/': Could not resolve host: github.com"): true,
+		errors.New("ssh: connect to host github.com port 22: Network is unreachable"):                                true,
+		errors.New("remote: Permission to org/repo.git denied"):                                                      false,
+		errors.New("! [rejected] main -> main (non-fast-forward)"):                                                   false,
+		nil: false,
+	} {
+		if got := IsNetworkError(err); got != want {
+			t.Errorf("IsNetworkError(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
+
+func TestWaitOnline(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Cleanup(func() { SetOffline(false) })
+
+	if !WaitOnline(nil) {
+		t.Fatal("WaitOnline() online = false, want true")
+	}
+	SetOffline(true)
+	done := make(chan struct{})
+	close(done)
+	if WaitOnline(done) {
+		t.Error("WaitOnline() offline with done closed = true, want false")
+	}
+
+	waited := make(chan bool)
+	go func() { waited <- WaitOnline(make(chan struct{})) }()
+	select {
+	case <-waited:
+		t.Fatal("WaitOnline() returned while offline")
+	case <-time.After(50 * time.Millisecond):
+	}
+	SetOffline(false)
+	select {
+	case online := <-waited:
+		if !online {
+			t.Error("WaitOnline() once back online = false, want true")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitOnline() didn't return once back online")
+	}
+}
+
+func TestStart(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Cleanup(func() { SetOffline(false) })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	// Nothing listens on address any more
+	stop := Start(address)
+	stop()
+	if !Offline() {
+		t.Error("Offline() after probing a closed port = false, want true")
+	}
+	if listener, err = net.Listen("tcp", address); err != nil {
+		t.Skipf("port reused: %v", err)
+	}
+	defer listener.Close()
+	stop = Start(address)
+	stop()
+	if Offline() {
+		t.Error("Offline() after probing a listening port = true, want false")
+	}
+}
@@ -0,0 +1,77 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxAttachedFileBytes is the size above which an attached file is referenced by its path only.
+const MaxAttachedFileBytes = 256 << 10
+
+// AttachFiles returns the text appended to a prompt to point the agent at files: each file's
+// contents in a fenced block after its path, or only the paths in one block if pathsOnly. paths
+// are relative to root. Binary files and files over MaxAttachedFileBytes are listed by path.
+func AttachFiles(root string, paths []string, pathsOnly bool) (string, error) {
+	var b strings.Builder
+	var listed []string
+	for _, path := range paths {
+		if !filepath.IsLocal(path) {
+			return "", fmt.Errorf("file %s is outside of the worktree", path)
+		}
+		if pathsOnly {
+			listed = append(listed, path)
+			continue
+		}
+		info, err := os.Stat(filepath.Join(root, path))
+		if err != nil {
+			return "", fmt.Errorf("failed to attach %s: %w", path, err)
+		}
+		if info.Size() > MaxAttachedFileBytes {
+			listed = append(listed, path)
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			return "", fmt.Errorf("failed to attach %s: %w", path, err)
+		}
+		if bytes.IndexByte(content, 0) >= 0 {
+			listed = append(listed, path)
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		writeFenced(&b, path+":", strings.TrimPrefix(filepath.Ext(path), "."), string(content))
+	}
+	if len(listed) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		header := "Files:"
+		if !pathsOnly {
+			header = "Binary or large files, read them if needed:"
+		}
+		writeFenced(&b, header, "", strings.Join(listed, "\n"))
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// writeFenced writes header and content in a fenced block tagged with lang. The fence is longer
+// than any run of backticks in content so it can't be closed early.
+func writeFenced(b *strings.Builder, header, lang, content string) {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	content = strings.TrimSuffix(content, "\n")
+	fmt.Fprintf(b, "%s\n%s%s\n%s\n%s\n", header, fence, lang, content, fence)
+}
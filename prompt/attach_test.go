@@ -0,0 +1,42 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAttachFiles(t *testing.T) {
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"main.go":    "package main\n",
+		"README.md":  "Run:\n```sh\ngo run .\n```\n",
+		"logo.png":   "\x89PNG\x00\x01",
+		"large.json": strings.Repeat("x", MaxAttachedFileBytes+1),
+	} {
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := AttachFiles(root, []string{"main.go", "README.md", "logo.png", "large.json"}, false)
+	if err != nil {
+		t.Fatalf("AttachFiles() error: %v", err)
+	}
+	want := "main.go:\n```go\npackage main\n```\n\n" +
+		"README.md:\n````md\nRun:\n```sh\ngo run .\n```\n````\n\n" +
+		"Binary or large files, read them if needed:\n```\nlogo.png\nlarge.json\n```"
+	if got != want {
+		t.Errorf("AttachFiles() =\n%s\nwant\n%s", got, want)
+	}
+
+	got, err = AttachFiles(root, []string{"main.go", "README.md"}, true)
+	if err != nil || got != "Files:\n```\nmain.go\nREADME.md\n```" {
+		t.Errorf("AttachFiles() of paths = %q, %v", got, err)
+	}
+
+	if _, err := AttachFiles(root, []string{"../secret"}, false); err == nil {
+		t.Errorf("AttachFiles() of a path outside of root succeeded")
+	}
+}
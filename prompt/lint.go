@@ -0,0 +1,47 @@
+package prompt
+
+import (
+	"claude-squad/session/git"
+	"fmt"
+	"strings"
+)
+
+// bytesPerToken estimates the tokens of a prompt from its size.
+const bytesPerToken = 4
+
+// Warning is a problem found in a prompt before it is sent.
+type Warning struct {
+	// Rule names what was found, e.g. "empty prompt", "large prompt" or "AWS access key".
+	Rule string `json:"rule"`
+	// Detail describes the problem. Secrets are redacted.
+	Detail string `json:"detail"`
+}
+
+func (w Warning) String() string {
+	return w.Rule + ": " + w.Detail
+}
+
+// Lint checks text before it is sent as a prompt: for being empty, for being larger than maxSize
+// bytes, which is likely a paste by mistake that floods the agent's context, and for secrets
+// matching the push scan's built-in rules and secretPatterns. maxSize 0 skips the size check.
+func Lint(text string, maxSize int, secretPatterns []string) []Warning {
+	if strings.TrimSpace(text) == "" {
+		return []Warning{{Rule: "empty prompt", Detail: "the prompt has no text"}}
+	}
+
+	var warnings []Warning
+	if maxSize > 0 && len(text) > maxSize {
+		warnings = append(warnings, Warning{Rule: "large prompt", Detail: fmt.Sprintf(
+			"%d KB, about %d tokens, limit %d KB", len(text)/1024, len(text)/bytesPerToken, maxSize/1024)})
+	}
+	findings, err := git.ScanText("prompt", text, secretPatterns)
+	if err != nil {
+		// Check the built-in rules rather than not at all
+		warnings = append(warnings, Warning{Rule: "secret patterns", Detail: err.Error()})
+		findings, _ = git.ScanText("prompt", text, nil)
+	}
+	for _, finding := range findings {
+		warnings = append(warnings, Warning{Rule: finding.Rule, Detail: fmt.Sprintf("line %d: %s", finding.Line, finding.Detail)})
+	}
+	return warnings
+}
@@ -0,0 +1,212 @@
+// Package prompt stores named prompt templates and fills in their variables.
+package prompt
+
+import (
+	"claude-squad/config"
+	"claude-squad/session"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// TemplatesFileName is the file in the config directory holding the templates.
+const TemplatesFileName = "templates.json"
+
+// MaxTemplateNameLength is the maximum length of a template name.
+const MaxTemplateNameLength = 64
+
+// ErrTemplateNotFound is returned for a template that doesn't exist.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// Template is a named prompt. Its body may contain variables such as {file}, filled in when the
+// prompt is sent.
+type Template struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Body        string `json:"body"`
+}
+
+var (
+	templateName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 ._-]*$`)
+	variable     = regexp.MustCompile(`\{([a-z_][a-z0-9_]*)\}`)
+)
+
+// Validate returns an error if the template has an invalid name or an empty body.
+func (t Template) Validate() error {
+	if len(t.Name) > MaxTemplateNameLength {
+		return fmt.Errorf("template name is longer than %d characters", MaxTemplateNameLength)
+	}
+	if !templateName.MatchString(t.Name) {
+		return fmt.Errorf("template name %q must start with a letter or digit and contain only letters, digits, spaces, '.', '_' and '-'", t.Name)
+	}
+	if t.Body == "" {
+		return fmt.Errorf("template %s has an empty body", t.Name)
+	}
+	return nil
+}
+
+// Variables returns the names of the variables in the template body, in order of first use.
+func (t Template) Variables() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range variable.FindAllStringSubmatch(t.Body, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// Fill returns the template body with each variable replaced by its value. Variables without a
+// value are left as they are.
+func (t Template) Fill(values map[string]string) string {
+	return variable.ReplaceAllStringFunc(t.Body, func(v string) string {
+		if value, ok := values[v[1:len(v)-1]]; ok {
+			return value
+		}
+		return v
+	})
+}
+
+// InstanceValues returns the variables filled in from instance: its title, branch, ticket and the
+// diff of its changes. Those the instance doesn't have, such as the diff of a new instance, are
+// left out so they can be asked for.
+func InstanceValues(instance *session.Instance) map[string]string {
+	values := map[string]string{
+		"title":  instance.Title,
+		"branch": instance.Branch,
+		"ticket": instance.Ticket,
+	}
+	if stats := instance.GetDiffStats(); stats != nil {
+		values["diff"] = stats.Content
+	}
+	for name, value := range values {
+		if value == "" {
+			delete(values, name)
+		}
+	}
+	return values
+}
+
+// Store keeps templates in a JSON file. Every call reads the file, so processes sharing it see each
+// other's changes.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns the store in the config directory.
+func NewStore() (*Store, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreAt(filepath.Join(dir, TemplatesFileName)), nil
+}
+
+// NewStoreAt returns a store kept in the file at path.
+func NewStoreAt(path string) *Store {
+	return &Store{path: path}
+}
+
+// List returns the templates sorted by name.
+func (s *Store) List() ([]Template, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Get returns the template called name, or ErrTemplateNotFound.
+func (s *Store) Get(name string) (Template, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	templates, err := s.load()
+	if err != nil {
+		return Template{}, err
+	}
+	for _, t := range templates {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return Template{}, ErrTemplateNotFound
+}
+
+// Save adds t, replacing the template of the same name.
+func (s *Store) Save(t Template) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	templates, err := s.load()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range templates {
+		if templates[i].Name == t.Name {
+			templates[i] = t
+			replaced = true
+		}
+	}
+	if !replaced {
+		templates = append(templates, t)
+	}
+	return s.write(templates)
+}
+
+// Delete removes the template called name, or returns ErrTemplateNotFound.
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	templates, err := s.load()
+	if err != nil {
+		return err
+	}
+	for i, t := range templates {
+		if t.Name == name {
+			return s.write(append(templates[:i], templates[i+1:]...))
+		}
+	}
+	return ErrTemplateNotFound
+}
+
+func (s *Store) load() ([]Template, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Template{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates: %w", err)
+	}
+	var templates []Template
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+func (s *Store) write(templates []Template) error {
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	data, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal templates: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// Write and rename so a concurrent reader never sees a partial file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write templates: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
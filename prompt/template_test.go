@@ -0,0 +1,56 @@
+package prompt
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTemplateVariables(t *testing.T) {
+	tmpl := Template{Name: "review", Body: "Review {file} for {ticket}.\n{diff}\nFocus on {file}. Keep {\"json\": true} as is."}
+	if got, want := tmpl.Variables(), []string{"file", "ticket", "diff"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Variables() = %v, want %v", got, want)
+	}
+	got := tmpl.Fill(map[string]string{"file": "main.go", "ticket": "ABC-1"})
+	if want := "Review main.go for ABC-1.\n{diff}\nFocus on main.go. Keep {\"json\": true} as is."; got != want {
+		t.Errorf("Fill() = %q, want %q", got, want)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := NewStoreAt(filepath.Join(t.TempDir(), TemplatesFileName))
+	if templates, err := store.List(); err != nil || len(templates) != 0 {
+		t.Fatalf("List() of a new store = %v, %v", templates, err)
+	}
+
+	for _, tmpl := range []Template{
+		{Name: "review", Body: "Review {file}"},
+		{Name: "implement", Body: "Implement {ticket}"},
+		{Name: "review", Body: "Review {file} carefully"},
+	} {
+		if err := store.Save(tmpl); err != nil {
+			t.Fatalf("Save(%s) error: %v", tmpl.Name, err)
+		}
+	}
+	templates, err := store.List()
+	if err != nil || len(templates) != 2 || templates[0].Name != "implement" || templates[1].Body != "Review {file} carefully" {
+		t.Fatalf("List() = %+v, %v, want implement and the replaced review", templates, err)
+	}
+
+	if err := store.Delete("implement"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if _, err := store.Get("implement"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("Get() of a deleted template error = %v, want ErrTemplateNotFound", err)
+	}
+	if err := store.Delete("implement"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("Delete() of a deleted template error = %v, want ErrTemplateNotFound", err)
+	}
+
+	for _, tmpl := range []Template{{Name: "", Body: "x"}, {Name: "../x", Body: "x"}, {Name: "empty"}} {
+		if err := store.Save(tmpl); err == nil {
+			t.Errorf("Save(%+v) succeeded, want an error", tmpl)
+		}
+	}
+}
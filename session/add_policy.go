@@ -0,0 +1,50 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// gitAddPolicies holds the policies deciding which new files worktrees stage.
+var gitAddPolicies struct {
+	mu       sync.RWMutex
+	fallback config.GitAddPolicy
+	programs map[string]config.GitAddPolicy
+}
+
+// SetGitAddPolicies sets the add policy of new worktrees and of worktrees loaded from storage.
+// programs overrides fallback for instances whose program's command name is a key. Policies with
+// an unknown mode are replaced by config.GitAddAll.
+func SetGitAddPolicies(fallback config.GitAddPolicy, programs map[string]config.GitAddPolicy) {
+	gitAddPolicies.mu.Lock()
+	defer gitAddPolicies.mu.Unlock()
+	gitAddPolicies.fallback = validGitAddPolicy(fallback)
+	gitAddPolicies.programs = make(map[string]config.GitAddPolicy, len(programs))
+	for program, policy := range programs {
+		gitAddPolicies.programs[program] = validGitAddPolicy(policy)
+	}
+}
+
+func validGitAddPolicy(policy config.GitAddPolicy) config.GitAddPolicy {
+	if err := policy.Validate(); err != nil {
+		log.WarningLog.Printf("%v, staging all new files", err)
+		return config.GitAddPolicy{Mode: config.GitAddAll}
+	}
+	return policy
+}
+
+// gitAddPolicyFor returns the add policy for an instance running program, such as
+// "aider --model sonnet".
+func gitAddPolicyFor(program string) config.GitAddPolicy {
+	gitAddPolicies.mu.RLock()
+	defer gitAddPolicies.mu.RUnlock()
+	if fields := strings.Fields(program); len(fields) > 0 {
+		if policy, ok := gitAddPolicies.programs[filepath.Base(fields[0])]; ok {
+			return policy
+		}
+	}
+	return gitAddPolicies.fallback
+}
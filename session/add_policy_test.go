@@ -0,0 +1,33 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"reflect"
+	"testing"
+)
+
+func TestGitAddPolicyFor(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	never := config.GitAddPolicy{Mode: config.GitAddNever}
+	SetGitAddPolicies(config.GitAddPolicy{Mode: config.GitAddAll}, map[string]config.GitAddPolicy{
+		"aider": never,
+		"codex": {Mode: "sometimes"},
+	})
+	t.Cleanup(func() { SetGitAddPolicies(config.GitAddPolicy{}, nil) })
+
+	tests := []struct {
+		program string
+		want    config.GitAddPolicy
+	}{
+		{program: "claude", want: config.GitAddPolicy{Mode: config.GitAddAll}},
+		{program: "/usr/local/bin/aider --model sonnet", want: never},
+		{program: "codex", want: config.GitAddPolicy{Mode: config.GitAddAll}},
+	}
+	for _, tt := range tests {
+		if got := gitAddPolicyFor(tt.program); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("gitAddPolicyFor(%q) = %+v, want %+v", tt.program, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetAutoYes turns auto-yes on or off for the instance. A positive d turns it back off after d,
+// so it isn't left on by accident; zero keeps it on until it is turned off.
+func (i *Instance) SetAutoYes(on bool, d time.Duration) {
+	i.AutoYes = on
+	i.AutoYesUntil = time.Time{}
+	if on && d > 0 {
+		i.AutoYesUntil = time.Now().Add(d)
+	}
+}
+
+// CheckAutoYesExpired turns auto-yes off once the time it was turned on for has run out, emitting
+// EventAutoYesExpired. It returns whether it did.
+func (i *Instance) CheckAutoYesExpired(now time.Time) bool {
+	if !i.AutoYes || i.AutoYesUntil.IsZero() || now.Before(i.AutoYesUntil) {
+		return false
+	}
+	i.AutoYes = false
+	i.AutoYesUntil = time.Time{}
+	i.emit(Event{Type: EventAutoYesExpired})
+	return true
+}
+
+// AutoYesDescription describes whether auto-yes is on and for how long, e.g. "auto-yes on for
+// 25m".
+func (i *Instance) AutoYesDescription() string {
+	switch {
+	case !i.AutoYes:
+		return "auto-yes off"
+	case i.AutoYesUntil.IsZero():
+		return "auto-yes on"
+	}
+	left := time.Until(i.AutoYesUntil).Round(time.Minute)
+	if left < time.Minute {
+		return "auto-yes on for less than a minute"
+	}
+	return fmt.Sprintf("auto-yes on for %s", formatMinutes(left))
+}
+
+// formatMinutes formats d, rounded to minutes, without its zero seconds, e.g. "1h30m".
+func formatMinutes(d time.Duration) string {
+	s := d.String()
+	if len(s) > 2 && s[len(s)-2:] == "0s" {
+		s = s[:len(s)-2]
+	}
+	if len(s) > 2 && s[len(s)-2:] == "h0m" {
+		s = s[:len(s)-2]
+	}
+	return s
+}
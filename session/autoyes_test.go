@@ -0,0 +1,36 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAutoYesExpires(t *testing.T) {
+	instance := &Instance{Title: "timed", Program: "claude"}
+	expired := 0
+	unsubscribe := Subscribe(func(e Event) {
+		if e.Type == EventAutoYesExpired && e.Instance == "timed" {
+			expired++
+		}
+	})
+	defer unsubscribe()
+
+	instance.SetAutoYes(true, 30*time.Minute)
+	if got := instance.AutoYesDescription(); got != "auto-yes on for 30m" {
+		t.Errorf("AutoYesDescription() = %q, want auto-yes on for 30m", got)
+	}
+	if instance.CheckAutoYesExpired(time.Now()) || !instance.AutoYes {
+		t.Fatal("auto-yes expired before its time")
+	}
+	if !instance.CheckAutoYesExpired(time.Now().Add(31*time.Minute)) || instance.AutoYes {
+		t.Fatal("auto-yes did not expire after its time")
+	}
+	if instance.CheckAutoYesExpired(time.Now().Add(time.Hour)) || expired != 1 {
+		t.Errorf("got %d expiry events, want 1", expired)
+	}
+
+	instance.SetAutoYes(true, 0)
+	if instance.CheckAutoYesExpired(time.Now().Add(24*time.Hour)) || instance.AutoYesDescription() != "auto-yes on" {
+		t.Error("auto-yes turned on without a duration expired")
+	}
+}
@@ -0,0 +1,156 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest lists instances to create at once, read from a YAML or JSON file by LoadManifest.
+type Manifest struct {
+	// Defaults apply to the instances that don't set a field themselves.
+	Defaults ManifestInstance `yaml:"defaults"`
+	// Instances are created in order.
+	Instances []ManifestInstance `yaml:"instances"`
+}
+
+// ManifestInstance describes an instance of a manifest. Its fields are those of InstanceOptions,
+// and Prompt is sent once the instance has started, or else the prompt of its session template.
+type ManifestInstance struct {
+	Title      string            `yaml:"title"`
+	Path       string            `yaml:"path"`
+	Program    string            `yaml:"program"`
+	AutoYes    bool              `yaml:"auto_yes"`
+	VCS        string            `yaml:"vcs"`
+	Branch     string            `yaml:"branch"`
+	BaseBranch string            `yaml:"base_branch"`
+	Ticket     string            `yaml:"ticket"`
+	Tags       []string          `yaml:"tags"`
+	Template   string            `yaml:"template"`
+	GitConfig  map[string]string `yaml:"git_config"`
+	Prompt     string            `yaml:"prompt"`
+}
+
+// LoadManifest reads the manifest at path, in YAML or JSON. Unknown fields are errors, so typos
+// aren't silently ignored.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	// JSON is YAML, so one decoder reads both
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var manifest Manifest
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	if len(manifest.Instances) == 0 {
+		return nil, fmt.Errorf("manifest %s lists no instances", path)
+	}
+	return &manifest, nil
+}
+
+// options returns the options of the nth instance of the manifest, with the defaults filled in.
+func (m *Manifest) options(n int) (InstanceOptions, string) {
+	instance, defaults := m.Instances[n], m.Defaults
+	pick := func(value, fallback string) string {
+		if value != "" {
+			return value
+		}
+		return fallback
+	}
+	gitConfig := make(map[string]string, len(defaults.GitConfig)+len(instance.GitConfig))
+	for key, value := range defaults.GitConfig {
+		gitConfig[key] = value
+	}
+	for key, value := range instance.GitConfig {
+		gitConfig[key] = value
+	}
+	tags := instance.Tags
+	if len(tags) == 0 {
+		tags = defaults.Tags
+	}
+	opts := InstanceOptions{
+		Title:      instance.Title,
+		Path:       pick(pick(instance.Path, defaults.Path), "."),
+		Program:    pick(instance.Program, defaults.Program),
+		AutoYes:    instance.AutoYes || defaults.AutoYes,
+		VCS:        pick(instance.VCS, defaults.VCS),
+		Branch:     instance.Branch,
+		BaseBranch: pick(instance.BaseBranch, defaults.BaseBranch),
+		Ticket:     pick(instance.Ticket, defaults.Ticket),
+		Tags:       tags,
+		Template:   pick(instance.Template, defaults.Template),
+		GitConfig:  gitConfig,
+	}
+	// An existing branch replaces the base branch
+	if opts.Branch != "" {
+		opts.BaseBranch = ""
+	}
+	return opts, pick(instance.Prompt, defaults.Prompt)
+}
+
+// BatchCreate creates and starts the instances of manifest with factory, one after the other,
+// sending each its prompt, and saves each to storage once it has started. Every title is checked
+// before anything is created. An instance that fails to be created or started doesn't stop the
+// others: BatchCreate returns the instances it started along with the errors of the others.
+func BatchCreate(ctx context.Context, factory *Factory, storage InstanceStore, manifest *Manifest) ([]*Instance, error) {
+	instances, err := storage.LoadInstances()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load instances: %w", err)
+	}
+	titles := make(map[string]bool, len(manifest.Instances))
+	for _, instance := range manifest.Instances {
+		if titles[instance.Title] {
+			return nil, fmt.Errorf("title %q is listed twice", instance.Title)
+		}
+		titles[instance.Title] = true
+		if err := ValidateTitle(instance.Title, instances); err != nil {
+			return nil, fmt.Errorf("instance %q: %w", instance.Title, err)
+		}
+	}
+
+	var created []*Instance
+	var errs []error
+	for n := range manifest.Instances {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		opts, prompt := manifest.options(n)
+		instance, err := factory.New(opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("instance %q: %w", opts.Title, err))
+			continue
+		}
+		if err := instance.StartContext(ctx, true); err != nil {
+			errs = append(errs, fmt.Errorf("instance %q: failed to start: %w", opts.Title, err))
+			continue
+		}
+		instances = append(instances, instance)
+		created = append(created, instance)
+		if err := storage.SaveInstances(instances); err != nil {
+			return created, errors.Join(append(errs, fmt.Errorf("failed to save instances: %w", err))...)
+		}
+		if prompt == "" {
+			prompt = instance.TemplatePrompt()
+		}
+		if prompt != "" {
+			if err := instance.SendPrompt(prompt); err != nil {
+				errs = append(errs, fmt.Errorf("instance %q: failed to send prompt: %w", opts.Title, err))
+			}
+		}
+	}
+	// Save the prompts sent
+	if len(created) > 0 {
+		if err := storage.SaveInstances(instances); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save instances: %w", err))
+		}
+	}
+	return created, errors.Join(errs...)
+}
@@ -0,0 +1,104 @@
+package session
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// memoryStore is an InstanceStore keeping instances in memory.
+type memoryStore struct {
+	instances []*Instance
+	saves     int
+}
+
+func (s *memoryStore) LoadInstances() ([]*Instance, error) { return s.instances, nil }
+
+func (s *memoryStore) SaveInstances(instances []*Instance) error {
+	s.instances = instances
+	s.saves++
+	return nil
+}
+
+func (s *memoryStore) DeleteInstance(title string) error { return nil }
+
+func writeManifest(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadManifest(t *testing.T) {
+	yamlPath := writeManifest(t, "squad.yaml", `
+defaults:
+  program: aider
+  base_branch: main
+  git_config:
+    user.name: Squad
+  tags: [unattended]
+  template: backend
+instances:
+  - title: fix-login
+    prompt: Fix the login timeout
+  - title: review
+    branch: feature/x
+    program: claude
+    tags: [review]
+    git_config:
+      user.name: Reviewer
+`)
+	manifest, err := LoadManifest(yamlPath)
+	if err != nil {
+		t.Fatalf("LoadManifest(): %v", err)
+	}
+	opts, prompt := manifest.options(0)
+	want := InstanceOptions{Title: "fix-login", Path: ".", Program: "aider", BaseBranch: "main",
+		Tags: []string{"unattended"}, Template: "backend", GitConfig: map[string]string{"user.name": "Squad"}}
+	if !reflect.DeepEqual(opts, want) || prompt != "Fix the login timeout" {
+		t.Errorf("options(0) = %+v, %q, want %+v with its prompt", opts, prompt, want)
+	}
+	opts, _ = manifest.options(1)
+	if opts.Program != "claude" || opts.Branch != "feature/x" || opts.BaseBranch != "" || opts.GitConfig["user.name"] != "Reviewer" ||
+		!reflect.DeepEqual(opts.Tags, []string{"review"}) {
+		t.Errorf("options(1) = %+v, want its own program, branch, tags and git settings, without the base branch", opts)
+	}
+
+	jsonPath := writeManifest(t, "squad.json", `{"instances": [{"title": "a", "prompt": "p"}]}`)
+	if manifest, err = LoadManifest(jsonPath); err != nil || manifest.Instances[0].Title != "a" {
+		t.Errorf("LoadManifest() of JSON = %+v, %v", manifest, err)
+	}
+
+	typo := writeManifest(t, "typo.yaml", "instances:\n  - title: a\n    promt: p\n")
+	if _, err := LoadManifest(typo); err == nil || !strings.Contains(err.Error(), "promt") {
+		t.Errorf("LoadManifest() with an unknown field = %v, want an error naming it", err)
+	}
+	empty := writeManifest(t, "empty.yaml", "defaults:\n  program: aider\n")
+	if _, err := LoadManifest(empty); err == nil {
+		t.Error("LoadManifest() without instances succeeded, want an error")
+	}
+}
+
+func TestBatchCreateChecksTitles(t *testing.T) {
+	existing, err := NewInstance(InstanceOptions{Title: "taken", Path: "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	factory := &Factory{Program: "claude"}
+	for name, manifest := range map[string]*Manifest{
+		"duplicate": {Instances: []ManifestInstance{{Title: "a"}, {Title: "a"}}},
+		"existing":  {Instances: []ManifestInstance{{Title: "b"}, {Title: "taken"}}},
+		"empty":     {Instances: []ManifestInstance{{Title: "c"}, {Title: ""}}},
+	} {
+		store := &memoryStore{instances: []*Instance{existing}}
+		created, err := BatchCreate(context.Background(), factory, store, manifest)
+		if err == nil || len(created) != 0 || store.saves != 0 {
+			t.Errorf("%s: BatchCreate() = %v, %v after %d saves, want an error before creating anything", name, created, err, store.saves)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+// AccentColors is the palette instances get their accent color from when none was chosen. The
+// colors read on light and dark terminals alike.
+var AccentColors = []string{
+	"#e06c75", "#e5a84b", "#98c379", "#56b6c2", "#61afef",
+	"#c678dd", "#d19a66", "#2bbac5", "#f472b6", "#a3be8c",
+}
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// AccentColor returns the color marking the instance in the list, its preview, its tmux status
+// line and the web UI: the color set with SetColor, or one picked from AccentColors by a hash of
+// its title, so it stays the same across restarts.
+func (i *Instance) AccentColor() string {
+	if i.Color != "" {
+		return i.Color
+	}
+	return DefaultAccentColor(i.Title)
+}
+
+// DefaultAccentColor returns the accent color of an instance titled title that has none chosen.
+func DefaultAccentColor(title string) string {
+	h := fnv.New32a()
+	h.Write([]byte(title))
+	return AccentColors[h.Sum32()%uint32(len(AccentColors))]
+}
+
+// SetColor sets the accent color of the instance as a #rrggbb hex color, or goes back to the
+// hashed one if color is empty. The tmux status line of a running instance is recolored.
+func (i *Instance) SetColor(color string) error {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if err := ValidateColor(color); err != nil {
+		return err
+	}
+	if color == i.Color {
+		return nil
+	}
+	i.Color = color
+	// Paused instances have no tmux session; resuming colors the new one
+	if i.started && !i.Paused() && i.tmuxSession != nil {
+		i.applyAccentColor()
+	}
+	i.Emit(EventColorChanged)
+	return nil
+}
+
+// ValidateColor returns an error unless color is empty or a #rrggbb hex color.
+func ValidateColor(color string) error {
+	color = strings.TrimSpace(color)
+	if color != "" && !hexColor.MatchString(color) {
+		return fmt.Errorf("invalid color %q: use a #rrggbb hex color", color)
+	}
+	return nil
+}
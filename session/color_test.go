@@ -0,0 +1,24 @@
+package session
+
+import "testing"
+
+func TestAccentColor(t *testing.T) {
+	instance := &Instance{Title: "fix-login"}
+	color := instance.AccentColor()
+	if color != DefaultAccentColor("fix-login") || color != (&Instance{Title: "fix-login"}).AccentColor() {
+		t.Errorf("AccentColor() = %q, want the same color for the same title", color)
+	}
+
+	if err := instance.SetColor(" #FF8800 "); err != nil {
+		t.Fatalf("SetColor() error: %v", err)
+	}
+	if instance.Color != "#ff8800" || instance.AccentColor() != "#ff8800" {
+		t.Errorf("Color = %q, AccentColor() = %q, want the chosen color", instance.Color, instance.AccentColor())
+	}
+	if err := instance.SetColor("orange"); err == nil {
+		t.Error("SetColor(\"orange\") succeeded, want an error")
+	}
+	if err := instance.SetColor(""); err != nil || instance.AccentColor() != color {
+		t.Errorf("AccentColor() = %q (%v) after clearing, want the default %q", instance.AccentColor(), err, color)
+	}
+}
@@ -0,0 +1,20 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"fmt"
+)
+
+// CompareInstances compares the changes of two running instances, each relative to the commit its
+// worktree was created from.
+func CompareInstances(a, b *Instance) (*git.Comparison, error) {
+	for _, instance := range []*Instance{a, b} {
+		if !instance.Started() || instance.Paused() {
+			return nil, fmt.Errorf("instance %s is not running", instance.Title)
+		}
+		if instance.InPlace || instance.gitWorktree == nil {
+			return nil, fmt.Errorf("instance %s has no worktree to compare", instance.Title)
+		}
+	}
+	return git.Compare(a.gitWorktree, b.gitWorktree)
+}
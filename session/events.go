@@ -0,0 +1,186 @@
+package session
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// EventType identifies an instance lifecycle event.
+type EventType string
+
+const (
+	// EventCreated is emitted when a new instance has started for the first time.
+	EventCreated EventType = "instance.created"
+	// EventStatusChanged is emitted when a started instance changes status.
+	EventStatusChanged EventType = "instance.status_changed"
+	// EventPromptDetected is emitted when an instance starts waiting on a prompt.
+	EventPromptDetected EventType = "instance.prompt_detected"
+	// EventBranchPushed is emitted when an instance's branch has been pushed.
+	EventBranchPushed EventType = "instance.branch_pushed"
+	// EventPaused is emitted when an instance has been paused.
+	EventPaused EventType = "instance.paused"
+	// EventResumed is emitted when a paused instance has been resumed.
+	EventResumed EventType = "instance.resumed"
+	// EventKilled is emitted when an instance has been killed.
+	EventKilled EventType = "instance.killed"
+	// EventNotesChanged is emitted when the notes of an instance have been changed.
+	EventNotesChanged EventType = "instance.notes_changed"
+	// EventTicketChanged is emitted when the ticket reference of an instance has been changed.
+	EventTicketChanged EventType = "instance.ticket_changed"
+	// EventColorChanged is emitted when the accent color of an instance has been changed.
+	EventColorChanged EventType = "instance.color_changed"
+	// EventError is emitted when starting or pushing an instance has failed.
+	EventError EventType = "instance.error"
+	// EventCommandFinished is emitted when a command run in an instance's directory has ended.
+	EventCommandFinished EventType = "instance.command_finished"
+	// EventRestarted is emitted when the program of an instance has been restarted by its restart
+	// policy after it exited.
+	EventRestarted EventType = "instance.restarted"
+	// EventAutoYesExpired is emitted when auto-yes, turned on for a limited time, has turned off.
+	EventAutoYesExpired EventType = "instance.auto_yes_expired"
+	// EventPromptSent is emitted when a prompt has been sent to an instance with SendPrompt.
+	EventPromptSent EventType = "instance.prompt_sent"
+	// EventTagsChanged is emitted when the tags of an instance have been changed.
+	EventTagsChanged EventType = "instance.tags_changed"
+)
+
+// MaxRecentEvents is the number of events kept for RecentEvents.
+const MaxRecentEvents = 200
+
+// EventTypes lists every event type, in the order above.
+var EventTypes = []EventType{
+	EventCreated, EventStatusChanged, EventPromptDetected, EventBranchPushed, EventPaused, EventResumed,
+	EventKilled, EventNotesChanged, EventTicketChanged, EventColorChanged, EventError, EventCommandFinished,
+	EventRestarted, EventAutoYesExpired, EventPromptSent, EventTagsChanged,
+}
+
+// Event describes something that happened to an instance.
+type Event struct {
+	Type     EventType `json:"type"`
+	Instance string    `json:"instance"`
+	Program  string    `json:"program"`
+	Branch   string    `json:"branch,omitempty"`
+	Ticket   string    `json:"ticket,omitempty"`
+	Tags     []string  `json:"tags,omitempty"`
+	// Color is the accent color of the instance.
+	Color  string `json:"color"`
+	Status string `json:"status"`
+	// PreviousStatus is only set for EventStatusChanged.
+	PreviousStatus string `json:"previous_status,omitempty"`
+	// Notes is only set for EventNotesChanged.
+	Notes string `json:"notes,omitempty"`
+	// Prompt is only set for EventPromptSent.
+	Prompt string `json:"prompt,omitempty"`
+	// Command is only set for EventCommandFinished.
+	Command string `json:"command,omitempty"`
+	// Attempt is only set for EventRestarted: the number of restarts in a row, counting this one.
+	Attempt int `json:"attempt,omitempty"`
+	// Error is set for EventError, and for EventCommandFinished if the command failed.
+	Error string    `json:"error,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+var eventListeners struct {
+	mu        sync.RWMutex
+	listeners map[int]func(Event)
+	next      int
+	// recent holds the last MaxRecentEvents events, oldest first. recentMu guards it, as emitters
+	// only hold mu for reading.
+	recentMu sync.Mutex
+	recent   []Event
+}
+
+// Subscribe registers fn to be called for every event. fn is called synchronously from the code
+// that triggered the event, so it must not block. The returned function unsubscribes.
+func Subscribe(fn func(Event)) func() {
+	eventListeners.mu.Lock()
+	defer eventListeners.mu.Unlock()
+	return subscribe(fn)
+}
+
+// SubscribeRecent is Subscribe, also returning the recent events fn won't be called for. No event
+// is missed or seen twice between the two.
+func SubscribeRecent(fn func(Event)) (recent []Event, unsubscribe func()) {
+	eventListeners.mu.Lock()
+	defer eventListeners.mu.Unlock()
+	return recentEvents(), subscribe(fn)
+}
+
+// subscribe adds fn to the listeners. mu must be held.
+func subscribe(fn func(Event)) func() {
+	if eventListeners.listeners == nil {
+		eventListeners.listeners = make(map[int]func(Event))
+	}
+	id := eventListeners.next
+	eventListeners.next++
+	eventListeners.listeners[id] = fn
+	return func() {
+		eventListeners.mu.Lock()
+		defer eventListeners.mu.Unlock()
+		delete(eventListeners.listeners, id)
+	}
+}
+
+// RecentEvents returns the last MaxRecentEvents events emitted, oldest first.
+func RecentEvents() []Event {
+	return recentEvents()
+}
+
+func recentEvents() []Event {
+	eventListeners.recentMu.Lock()
+	defer eventListeners.recentMu.Unlock()
+	return append([]Event(nil), eventListeners.recent...)
+}
+
+// SetPrompt records whether the instance's program is showing a prompt and emits
+// EventPromptDetected when it starts showing one.
+func (i *Instance) SetPrompt(hasPrompt bool) {
+	if hasPrompt && !i.waitingOnPrompt {
+		i.Emit(EventPromptDetected)
+	}
+	i.waitingOnPrompt = hasPrompt
+}
+
+// SetNotes replaces the notes of the instance and emits EventNotesChanged if they changed.
+func (i *Instance) SetNotes(notes string) {
+	if notes == i.Notes {
+		return
+	}
+	i.Notes = notes
+	i.emit(Event{Type: EventNotesChanged, Notes: notes})
+}
+
+// Emit sends an event of type t about instance to every subscriber.
+func (i *Instance) Emit(t EventType) {
+	i.emit(Event{Type: t})
+}
+
+// EmitError sends an EventError about instance with the message of err to every subscriber.
+func (i *Instance) EmitError(err error) {
+	i.emit(Event{Type: EventError, Error: err.Error()})
+}
+
+func (i *Instance) emit(e Event) Event {
+	e.Instance = i.Title
+	e.Program = i.Program
+	e.Branch = i.Branch
+	e.Ticket = i.Ticket
+	e.Tags = slices.Clone(i.Tags)
+	e.Color = i.AccentColor()
+	e.Status = i.Status.String()
+	e.Time = time.Now()
+
+	eventListeners.mu.RLock()
+	defer eventListeners.mu.RUnlock()
+	eventListeners.recentMu.Lock()
+	eventListeners.recent = append(eventListeners.recent, e)
+	if len(eventListeners.recent) > MaxRecentEvents {
+		eventListeners.recent = eventListeners.recent[len(eventListeners.recent)-MaxRecentEvents:]
+	}
+	eventListeners.recentMu.Unlock()
+	for _, fn := range eventListeners.listeners {
+		fn(e)
+	}
+	return e
+}
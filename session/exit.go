@@ -0,0 +1,69 @@
+package session
+
+import (
+	"claude-squad/log"
+	"fmt"
+	"time"
+)
+
+// CheckExited checks whether the program of a running instance has exited, recording its exit
+// status and setting the status to Exited if so. An exited program found running again, such as
+// after a respawn from tmux, sets the status back to Running. It returns whether the program has
+// exited.
+func (i *Instance) CheckExited() bool {
+	if !i.started || i.Paused() || i.Status == Loading {
+		return false
+	}
+	exited, code, err := i.tmuxSession.ProgramExited()
+	if err != nil {
+		log.FileOnlyWarningLog.Printf("could not check whether the program of %s exited: %v", i.Title, err)
+		return i.Status == Exited
+	}
+	switch {
+	case exited && i.Status != Exited:
+		i.ExitCode = code
+		i.exitedAt = time.Now()
+		i.restartGaveUp = false
+		i.SetStatus(Exited)
+	case !exited && i.Status == Exited:
+		i.ExitCode = 0
+		i.SetStatus(Running)
+	}
+	return exited
+}
+
+// ExitDescription describes how the program of an exited instance ended.
+func (i *Instance) ExitDescription() string {
+	switch i.ExitCode {
+	case -1:
+		return fmt.Sprintf("%s exited", i.Program)
+	case 0:
+		return fmt.Sprintf("%s exited normally", i.Program)
+	default:
+		return fmt.Sprintf("%s exited with status %d", i.Program, i.ExitCode)
+	}
+}
+
+// Restart starts the program of an exited instance again, in the same pane and worktree. It
+// resets the count of restarts in a row of the restart policy.
+func (i *Instance) Restart() error {
+	if err := i.respawn(); err != nil {
+		return err
+	}
+	i.Restarts = 0
+	return nil
+}
+
+// respawn starts the program of an exited instance again.
+func (i *Instance) respawn() error {
+	if i.Status != Exited {
+		return fmt.Errorf("can only restart instances whose program has exited")
+	}
+	if err := i.tmuxSession.RespawnProgram(); err != nil {
+		return err
+	}
+	i.ExitCode = 0
+	i.UpdatedAt = time.Now()
+	i.SetStatus(Running)
+	return nil
+}
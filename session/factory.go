@@ -0,0 +1,176 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/session/git"
+	"claude-squad/session/tmux"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrRepositoryNotAllowed is returned when creating an instance in a repository the repository
+// policy refuses.
+var ErrRepositoryNotAllowed = errors.New("repository not allowed")
+
+// Factory creates instances with the program and auto-yes setting resolved from the config and
+// command line flags, so instances get the same defaults however they are created: from the TUI,
+// the web server, the daemon or the CLI.
+type Factory struct {
+	// Program runs in instances whose options don't name one.
+	Program string
+	// AutoYes is set on every instance the factory creates or is applied to.
+	AutoYes bool
+	// Repositories restricts the repositories instances are created in.
+	Repositories config.RepositoryPolicy
+	// VCS is the kind of workspace of instances whose options don't name one.
+	VCS string
+	// Templates are the session templates instances can be created from, by name.
+	Templates map[string]config.SessionTemplate
+	// Template is the name of the session template of instances whose options don't name one.
+	Template string
+}
+
+// NewFactory returns a factory using the default program and auto-yes setting of cfg. A program
+// and autoYes given on the command line override them.
+func NewFactory(cfg *config.Config, program string, autoYes bool) *Factory {
+	f := &Factory{Program: cfg.DefaultProgram, AutoYes: cfg.AutoYes || autoYes, Repositories: cfg.Repositories, VCS: cfg.VCS,
+		Templates: cfg.SessionTemplates}
+	if program != "" {
+		f.Program = program
+	}
+	if f.Program == "" {
+		f.Program = tmux.ProgramClaude
+	}
+	return f
+}
+
+// New creates an instance from opts with the factory's program and kind of workspace, unless opts
+// names them, and its auto-yes setting. The session template of opts, or else the factory's, fills
+// in what opts leaves out, and its prompt is the instance's TemplatePrompt. It fails with ErrRepositoryNotAllowed if the repository of opts.Path is
+// refused by the factory's repository policy.
+func (f *Factory) New(opts InstanceOptions) (*Instance, error) {
+	if err := f.CheckRepository(opts.Path); err != nil {
+		return nil, err
+	}
+	if opts.Template == "" {
+		opts.Template = f.Template
+	}
+	var template config.SessionTemplate
+	if opts.Template != "" {
+		var err error
+		if template, err = f.SessionTemplate(opts.Template); err != nil {
+			return nil, err
+		}
+		if err != nil {
+			return nil, err
+		}
+		if opts.Program == "" {
+			opts.Program = template.Program
+		}
+		opts.AutoYes = opts.AutoYes || template.AutoYes
+		if opts.BranchPrefix == "" {
+			opts.BranchPrefix = template.BranchPrefix
+		}
+		if opts.WorktreeDir == "" {
+			if opts.WorktreeDir, err = template.WorktreePath(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if opts.Program == "" {
+		opts.Program = f.Program
+	}
+	if opts.VCS == "" {
+		opts.VCS = f.VCS
+	}
+	opts.AutoYes = opts.AutoYes || f.AutoYes
+	instance, err := NewInstance(opts)
+	if err != nil {
+		return nil, err
+	}
+	instance.templatePrompt = template.Prompt
+	return instance, nil
+}
+
+// SessionTemplate returns the session template called name, validated.
+func (f *Factory) SessionTemplate(name string) (config.SessionTemplate, error) {
+	template, ok := f.Templates[name]
+	if !ok {
+		return config.SessionTemplate{}, fmt.Errorf("unknown session template %q", name)
+	}
+	if err := template.Validate(); err != nil {
+		return config.SessionTemplate{}, fmt.Errorf("session template %s: %w", name, err)
+	}
+	return template, nil
+}
+
+// Apply gives an instance loaded from storage the factory's auto-yes setting.
+func (f *Factory) Apply(instance *Instance) {
+	if f.AutoYes {
+		instance.AutoYes = true
+	}
+}
+
+// CheckRepository returns an error wrapping ErrRepositoryNotAllowed if the repository containing
+// path is refused by the factory's repository policy. Outside of a git repository, path itself is
+// checked.
+func (f *Factory) CheckRepository(path string) error {
+	if !f.Repositories.Restricted() {
+		return nil
+	}
+	root, err := git.RepoRoot(path)
+	if err != nil {
+		if root, err = filepath.Abs(path); err != nil {
+			return err
+		}
+	}
+	root = canonicalPath(root)
+
+	for _, pattern := range f.Repositories.Deny {
+		if matchRepository(pattern, root) {
+			return fmt.Errorf("%w: %s is denied by %s", ErrRepositoryNotAllowed, root, pattern)
+		}
+	}
+	if len(f.Repositories.Allow) == 0 {
+		return nil
+	}
+	for _, pattern := range f.Repositories.Allow {
+		if matchRepository(pattern, root) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not in the allowed repositories", ErrRepositoryNotAllowed, root)
+}
+
+// matchRepository reports whether root is the repository named by pattern, below it, or matched by
+// it as a glob.
+func matchRepository(pattern, root string) bool {
+	if strings.HasPrefix(pattern, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		pattern = filepath.Join(home, pattern[2:])
+	}
+	if strings.ContainsAny(pattern, "*?[") {
+		matched, err := filepath.Match(filepath.Clean(pattern), root)
+		return err == nil && matched
+	}
+	pattern = canonicalPath(pattern)
+	return root == pattern || strings.HasPrefix(root, pattern+string(filepath.Separator))
+}
+
+// canonicalPath returns the absolute path with symbolic links resolved, so a repository is matched
+// however it is reached. A path that can't be resolved is only cleaned.
+func canonicalPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,133 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/session/vcs"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewFactory(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.Config
+		program     string
+		autoYes     bool
+		wantProgram string
+		wantAutoYes bool
+	}{
+		{name: "config", cfg: config.Config{DefaultProgram: "aider", AutoYes: true}, wantProgram: "aider", wantAutoYes: true},
+		{name: "flags override", cfg: config.Config{DefaultProgram: "aider"}, program: "codex", autoYes: true, wantProgram: "codex", wantAutoYes: true},
+		{name: "no program", wantProgram: "claude"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFactory(&tt.cfg, tt.program, tt.autoYes)
+			if f.Program != tt.wantProgram || f.AutoYes != tt.wantAutoYes {
+				t.Errorf("NewFactory() = %+v, want program %q and auto-yes %v", *f, tt.wantProgram, tt.wantAutoYes)
+			}
+		})
+	}
+}
+
+func TestFactoryNew(t *testing.T) {
+	f := &Factory{Program: "aider", AutoYes: true}
+
+	instance, err := f.New(InstanceOptions{Title: "default", Path: "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.Program != "aider" || !instance.AutoYes {
+		t.Errorf("instance program = %q, auto-yes = %v, want the factory's", instance.Program, instance.AutoYes)
+	}
+
+	// A fork or retry keeps the program it was given
+	instance, err = f.New(InstanceOptions{Title: "fork", Path: ".", Program: "claude"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.Program != "claude" {
+		t.Errorf("instance program = %q, want the one in its options", instance.Program)
+	}
+
+	f.VCS = vcs.Directory
+	instance, err = f.New(InstanceOptions{Title: "copy", Path: "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.VCS != vcs.Directory {
+		t.Errorf("instance vcs = %q, want the factory's", instance.VCS)
+	}
+	if _, err := f.New(InstanceOptions{Title: "branch", Path: ".", Branch: "main"}); err == nil {
+		t.Error("New() with a branch in a directory copy succeeded, want an error")
+	}
+	if _, err := f.New(InstanceOptions{Title: "svn", Path: ".", VCS: "svn"}); err == nil {
+		t.Error("New() with an unknown vcs succeeded, want an error")
+	}
+}
+
+func TestFactoryNewFromTemplate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	f := &Factory{Program: "claude", Templates: map[string]config.SessionTemplate{
+		"backend": {Program: "aider", AutoYes: true, BranchPrefix: "alice/", WorktreeDir: "~/worktrees"},
+		"broken":  {BranchPrefix: "a b"},
+	}}
+
+	instance, err := f.New(InstanceOptions{Title: "api", Path: ".", Template: "backend"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.Program != "aider" || !instance.AutoYes || instance.BranchPrefix() != "alice/" ||
+		instance.worktreeDir != filepath.Join(home, "worktrees") {
+		t.Errorf("instance program = %q, auto-yes = %v, branch prefix = %q, worktree dir = %q, want the template's",
+			instance.Program, instance.AutoYes, instance.BranchPrefix(), instance.worktreeDir)
+	}
+
+	// The factory's template applies to instances that don't name one, and options win over it
+	f.Template = "backend"
+	instance, err = f.New(InstanceOptions{Title: "fork", Path: ".", Program: "codex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.Program != "codex" || instance.BranchPrefix() != "alice/" {
+		t.Errorf("instance program = %q, branch prefix = %q, want codex from its options and the template's prefix",
+			instance.Program, instance.BranchPrefix())
+	}
+
+	for _, name := range []string{"missing", "broken"} {
+		if _, err := f.New(InstanceOptions{Title: "x", Path: ".", Template: name}); err == nil {
+			t.Errorf("New() with template %s succeeded, want an error", name)
+		}
+	}
+}
+
+func TestFactoryCheckRepository(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"api", "api/vendor", "web", "scratch"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f := &Factory{Repositories: config.RepositoryPolicy{
+		Allow: []string{filepath.Join(dir, "api"), filepath.Join(dir, "w*")},
+		Deny:  []string{filepath.Join(dir, "api", "vendor")},
+	}}
+
+	tests := map[string]bool{"api": true, "web": true, "api/vendor": false, "scratch": false}
+	for name, want := range tests {
+		err := f.CheckRepository(filepath.Join(dir, name))
+		if (err == nil) != want {
+			t.Errorf("CheckRepository(%s) = %v, want allowed %v", name, err, want)
+		}
+		if err != nil && !errors.Is(err, ErrRepositoryNotAllowed) {
+			t.Errorf("CheckRepository(%s) = %v, want ErrRepositoryNotAllowed", name, err)
+		}
+	}
+
+	if _, err := f.New(InstanceOptions{Title: "scratch", Path: filepath.Join(dir, "scratch")}); !errors.Is(err, ErrRepositoryNotAllowed) {
+		t.Errorf("New() in a refused repository = %v, want ErrRepositoryNotAllowed", err)
+	}
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"claude-squad/config"
+	"fmt"
+)
+
+// SetAddPolicy sets which new files the worktree stages for its diff and when changes are pushed.
+func (g *GitWorktree) SetAddPolicy(policy config.GitAddPolicy) {
+	g.addPolicy = policy
+}
+
+// newFilePathspecs returns the pathspecs of the new files the add policy stages, or nil if it
+// stages none.
+func (g *GitWorktree) newFilePathspecs() []string {
+	switch g.addPolicy.Mode {
+	case config.GitAddNever:
+		return nil
+	case config.GitAddExcept:
+		pathspecs := []string{"."}
+		for _, pattern := range g.addPolicy.Except {
+			pathspecs = append(pathspecs, ":(exclude)"+pattern)
+		}
+		return pathspecs
+	default:
+		return []string{"."}
+	}
+}
+
+// addIntentToAdd stages the new files the add policy allows with intent to add, which includes
+// them in diffs without staging their content.
+func (g *GitWorktree) addIntentToAdd() error {
+	pathspecs := g.newFilePathspecs()
+	if pathspecs == nil {
+		return nil
+	}
+	_, err := g.runGitCommand(g.worktreePath, append([]string{"add", "-N", "--"}, pathspecs...)...)
+	return err
+}
+
+// stageChanges stages changes to tracked files and the new files the add policy allows. It
+// returns false if nothing ended up staged.
+func (g *GitWorktree) stageChanges() (bool, error) {
+	if _, err := g.runGitCommand(g.worktreePath, "add", "-u"); err != nil {
+		return false, err
+	}
+	if pathspecs := g.newFilePathspecs(); pathspecs != nil {
+		if _, err := g.runGitCommand(g.worktreePath, append([]string{"add", "--"}, pathspecs...)...); err != nil {
+			return false, err
+		}
+	}
+	staged, err := runGit(g.worktreePath, "diff", "--cached", "--name-only")
+	if err != nil {
+		return false, fmt.Errorf("failed to list staged changes: %w", err)
+	}
+	return len(splitLines(staged)) > 0, nil
+}
@@ -0,0 +1,73 @@
+package git
+
+import (
+	"claude-squad/config"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAddPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy config.GitAddPolicy
+		want   []string
+	}{
+		{name: "default stages all", policy: config.GitAddPolicy{}, want: []string{"main.go", "new.go", "scratch.txt"}},
+		{
+			name:   "except",
+			policy: config.GitAddPolicy{Mode: config.GitAddExcept, Except: []string{"*.txt"}},
+			want:   []string{"main.go", "new.go"},
+		},
+		{name: "never", policy: config.GitAddPolicy{Mode: config.GitAddNever}, want: []string{"main.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, base := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+			w := newTestWorktree(t, repo, base, "policy")
+			w.SetAddPolicy(tt.policy)
+			writeTestFile(t, filepath.Join(w.worktreePath, "main.go"), "package main\n\nfunc main() {}\n")
+			writeTestFile(t, filepath.Join(w.worktreePath, "new.go"), "package main\n")
+			writeTestFile(t, filepath.Join(w.worktreePath, "scratch.txt"), "notes\n")
+
+			stats := w.Diff()
+			if stats.Error != nil {
+				t.Fatalf("Diff() error: %v", stats.Error)
+			}
+			var diffed []string
+			for _, line := range strings.Split(stats.Content, "\n") {
+				if strings.HasPrefix(line, "+++ b/") {
+					diffed = append(diffed, strings.TrimPrefix(line, "+++ b/"))
+				}
+			}
+			if !reflect.DeepEqual(diffed, tt.want) {
+				t.Errorf("Diff() files = %v, want %v", diffed, tt.want)
+			}
+
+			staged, err := w.stageChanges()
+			if err != nil || !staged {
+				t.Fatalf("stageChanges() = %v, %v, want staged changes", staged, err)
+			}
+			if got := splitLines(testGit(t, w.worktreePath, "diff", "--cached", "--name-only")); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("staged files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStageChangesNothingAllowed(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	w := newTestWorktree(t, repo, base, "never")
+	w.SetAddPolicy(config.GitAddPolicy{Mode: config.GitAddNever})
+	writeTestFile(t, filepath.Join(w.worktreePath, "new.go"), "package main\n")
+
+	staged, err := w.stageChanges()
+	if err != nil {
+		t.Fatalf("stageChanges() error: %v", err)
+	}
+	if staged {
+		t.Errorf("stageChanges() = true, want nothing staged when the only change is a new file")
+	}
+}
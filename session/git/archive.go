@@ -0,0 +1,97 @@
+package git
+
+import (
+	"archive/zip"
+	"claude-squad/command"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteArchive writes a zip of the worktree's files to w, as they are on disk, with uncommitted
+// changes and new files that aren't ignored. Every file is under a directory named after the
+// branch. A worktree removed on pause is archived from the last commit of its branch. Writing
+// stops when ctx is done, as when the client downloading the archive goes away, or when it takes
+// longer than the network timeout, as w is usually a client on the network.
+func (g *GitWorktree) WriteArchive(ctx context.Context, w io.Writer) error {
+	prefix := strings.ReplaceAll(g.branchName, "/", "-") + "/"
+	if _, err := os.Stat(g.worktreePath); err != nil {
+		cmd := command.NewContext(ctx, command.NetworkTimeout(), "git", "-C", g.repoPath, "archive",
+			"--format=zip", "--prefix="+prefix, g.branchName)
+		cmd.Stdout = w
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to archive %s: %s (%w)", g.branchName, strings.TrimSpace(stderr.String()), err)
+		}
+		return nil
+	}
+
+	out, err := runGit(g.worktreePath, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return fmt.Errorf("failed to list files: %w", err)
+	}
+	if timeout := command.NetworkTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	archive := zip.NewWriter(w)
+	for _, name := range strings.Split(out, "\x00") {
+		if name == "" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := addToArchive(archive, filepath.Join(g.worktreePath, name), prefix+name); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// addToArchive adds the file at path to archive as name. Files deleted since they were committed,
+// submodules and other directories are left out, and symlinks are stored as links.
+func addToArchive(archive *zip.Writer, path, name string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() && info.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	entry, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(entry, target)
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(entry, f); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package git
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"fmt"
+	"strings"
+)
+
+// CheckpointTrailer marks the commits CommitCheckpoint makes, so a push can rewrite them.
+const CheckpointTrailer = "Claude-Squad-Checkpoint: true"
+
+// SetCheckpointCommits sets what a push does with the checkpoint commits of the branch that
+// weren't pushed yet: config.CheckpointsKeep, config.CheckpointsSquash or config.CheckpointsFixup.
+func (g *GitWorktree) SetCheckpointCommits(mode string) {
+	g.checkpoints = mode
+}
+
+// RewritesCheckpoints reports whether pushes rewrite checkpoint commits, which then shouldn't be
+// pushed when they are made.
+func (g *GitWorktree) RewritesCheckpoints() bool {
+	return g.checkpoints == config.CheckpointsSquash || g.checkpoints == config.CheckpointsFixup
+}
+
+// CommitCheckpoint commits the changes in the worktree with message and CheckpointTrailer, such as
+// before it is removed on pause, without pushing them. The next push rewrites the commit according
+// to SetCheckpointCommits.
+func (g *GitWorktree) CommitCheckpoint(message string) error {
+	return g.CommitChanges(message + "\n\n" + CheckpointTrailer)
+}
+
+// branchCommit is a commit being rebuilt by rewriteCheckpoints.
+type branchCommit struct {
+	sha     string
+	parents []string
+	// tree is the commit whose tree the rebuilt commit gets
+	tree       string
+	author     []string
+	message    string
+	checkpoint bool
+}
+
+// rewriteCheckpoints folds the checkpoint commits that weren't pushed yet into the commits after
+// them in config.CheckpointsSquash mode, or rewords them as fixups of the commit before them, for
+// git rebase --autosquash, in config.CheckpointsFixup mode. Commits already pushed are left as they
+// are, so the push stays a fast-forward. The branch keeps its tree, and is left as it was if it
+// has merge commits or moved while it was rewritten.
+func (g *GitWorktree) rewriteCheckpoints() error {
+	if !g.RewritesCheckpoints() {
+		return nil
+	}
+	start := g.GetBaseCommitSHA()
+	remote := "refs/remotes/" + baseRemote + "/" + g.branchName
+	if out, err := runGit(g.worktreePath, "rev-parse", "--verify", "--quiet", remote); err == nil {
+		if _, err := runGit(g.worktreePath, "merge-base", "--is-ancestor", remote, "HEAD"); err != nil {
+			log.WarningLog.Printf("%s diverged from %s, pushing its checkpoint commits as they are", g.branchName, remote)
+			return nil
+		}
+		start = strings.TrimSpace(out)
+	}
+	commits, err := g.unpushedCommits(start)
+	if err != nil {
+		return err
+	}
+	checkpoints := 0
+	for _, commit := range commits {
+		if len(commit.parents) != 1 {
+			log.WarningLog.Printf("%s has merge commits, pushing its checkpoint commits as they are", g.branchName)
+			return nil
+		}
+		if commit.checkpoint {
+			checkpoints++
+		}
+	}
+	if checkpoints == 0 {
+		return nil
+	}
+	head := commits[len(commits)-1].sha
+	if g.checkpoints == config.CheckpointsSquash {
+		if len(commits) == 1 {
+			// A lone checkpoint has nothing to be folded into
+			return nil
+		}
+		commits = foldCheckpoints(commits)
+	} else if marked, err := g.markFixups(commits, start); err != nil || marked == 0 {
+		return err
+	}
+
+	if skipInDryRun("rewrite %d checkpoint commits of %s", checkpoints, g.branchName) {
+		return nil
+	}
+	tip := start
+	for _, commit := range commits {
+		out, err := runGitEnv(g.worktreePath, commit.author, "commit-tree", commit.tree+"^{tree}", "-p", tip, "-m", commit.message)
+		if err != nil {
+			return fmt.Errorf("failed to rewrite checkpoint commits: %w", err)
+		}
+		tip = strings.TrimSpace(out)
+	}
+	if _, err := runGit(g.worktreePath, "diff", "--quiet", head, tip); err != nil {
+		return fmt.Errorf("rewriting the checkpoint commits of %s changed its files, left it as it was", g.branchName)
+	}
+	// Only move the branch if it is still where it was when the commits were read
+	if _, err := runGit(g.worktreePath, "update-ref", "-m", "claude-squad: rewrite checkpoint commits",
+		"refs/heads/"+g.branchName, tip, head); err != nil {
+		return fmt.Errorf("failed to rewrite checkpoint commits: %w", err)
+	}
+	return nil
+}
+
+// unpushedCommits returns the commits of the branch after start, oldest first.
+func (g *GitWorktree) unpushedCommits(start string) ([]branchCommit, error) {
+	out, err := runGit(g.worktreePath, "log", "--reverse", "-z",
+		"--format=%H%x1f%P%x1f%an%x1f%ae%x1f%aI%x1f%B", start+"..HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+	var commits []branchCommit
+	for _, record := range strings.Split(out, "\x00") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 6)
+		if len(fields) != 6 {
+			continue
+		}
+		message := strings.TrimSpace(fields[5])
+		commits = append(commits, branchCommit{
+			sha:     fields[0],
+			parents: strings.Fields(fields[1]),
+			tree:    fields[0],
+			author: []string{"GIT_AUTHOR_NAME=" + fields[2], "GIT_AUTHOR_EMAIL=" + fields[3],
+				"GIT_AUTHOR_DATE=" + fields[4]},
+			message:    message,
+			checkpoint: strings.HasSuffix(message, CheckpointTrailer),
+		})
+	}
+	return commits, nil
+}
+
+// foldCheckpoints drops the checkpoint commits, whose changes end up in the commit after them
+// since it keeps its tree. Checkpoints at the tip are folded into the commit before them, or into
+// a single checkpoint commit if there is none.
+func foldCheckpoints(commits []branchCommit) []branchCommit {
+	var folded []branchCommit
+	for _, commit := range commits {
+		if !commit.checkpoint {
+			folded = append(folded, commit)
+		}
+	}
+	last := commits[len(commits)-1]
+	if !last.checkpoint {
+		return folded
+	}
+	if len(folded) == 0 {
+		return []branchCommit{last}
+	}
+	folded[len(folded)-1].tree = last.sha
+	return folded
+}
+
+// markFixups rewords every checkpoint commit as a fixup of the commit before it that isn't one,
+// and returns how many it reworded. Checkpoints right after start are fixups of start, unless it
+// is the base commit and so not on the branch.
+func (g *GitWorktree) markFixups(commits []branchCommit, start string) (int, error) {
+	target := ""
+	if start != g.GetBaseCommitSHA() {
+		subject, err := runGit(g.worktreePath, "log", "-1", "--format=%s", start)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read commit %s: %w", shortSHA(start), err)
+		}
+		// A pushed checkpoint is a fixup of the same commit as the checkpoint itself
+		target = strings.TrimPrefix(strings.TrimSpace(subject), "fixup! ")
+	}
+	marked := 0
+	for i, commit := range commits {
+		subject, _, _ := strings.Cut(commit.message, "\n")
+		if !commit.checkpoint {
+			target = subject
+			continue
+		}
+		if target != "" {
+			commits[i].message = "fixup! " + target + "\n\n" + commit.message
+			marked++
+		}
+	}
+	return marked, nil
+}
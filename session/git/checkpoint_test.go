@@ -0,0 +1,91 @@
+package git
+
+import (
+	"claude-squad/config"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRewriteCheckpoints(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	// newBranch returns a worktree with a checkpoint, a commit, and another checkpoint
+	newBranch := func(name, mode string) *GitWorktree {
+		repo, base := newTestRepo(t, map[string]string{"a.txt": "a\n"})
+		w := newTestWorktree(t, repo, base, name)
+		w.SetCheckpointCommits(mode)
+		writeTestFile(t, filepath.Join(w.worktreePath, "a.txt"), "b\n")
+		if err := w.CommitCheckpoint("update (paused)"); err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, filepath.Join(w.worktreePath, "b.txt"), "b\n")
+		testGit(t, w.worktreePath, "add", "b.txt")
+		testGit(t, w.worktreePath, "commit", "-q", "-m", "Add b")
+		writeTestFile(t, filepath.Join(w.worktreePath, "c.txt"), "c\n")
+		if err := w.CommitCheckpoint("update (paused)"); err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+	subjects := func(w *GitWorktree) []string {
+		commits, err := w.Commits(10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var subjects []string
+		for _, commit := range commits {
+			subjects = append(subjects, commit.Subject)
+		}
+		return subjects
+	}
+
+	w := newBranch("squash", config.CheckpointsSquash)
+	tree := testGit(t, w.worktreePath, "rev-parse", "HEAD^{tree}")
+	if err := w.rewriteCheckpoints(); err != nil {
+		t.Fatalf("rewriteCheckpoints(): %v", err)
+	}
+	if got := subjects(w); strings.Join(got, ",") != "Add b" {
+		t.Errorf("commits after squashing checkpoints = %q, want only Add b", got)
+	}
+	if after := testGit(t, w.worktreePath, "rev-parse", "HEAD^{tree}"); after != tree {
+		t.Errorf("tree = %s after squashing checkpoints, want %s", after, tree)
+	}
+	if status := testGit(t, w.worktreePath, "status", "--porcelain"); status != "" {
+		t.Errorf("worktree not clean after squashing checkpoints:\n%s", status)
+	}
+
+	w = newBranch("fixup", config.CheckpointsFixup)
+	if err := w.rewriteCheckpoints(); err != nil {
+		t.Fatalf("rewriteCheckpoints(): %v", err)
+	}
+	// The first checkpoint has no commit of the branch before it to be a fixup of
+	want := "fixup! Add b,Add b,update (paused)"
+	if got := subjects(w); strings.Join(got, ",") != want {
+		t.Errorf("commits after marking checkpoints = %q, want %q", got, want)
+	}
+
+	// Checkpoints that were pushed are left alone
+	w = newBranch("pushed", config.CheckpointsSquash)
+	testGit(t, w.worktreePath, "update-ref", "refs/remotes/origin/"+w.branchName, "HEAD")
+	head := testGit(t, w.worktreePath, "rev-parse", "HEAD")
+	if err := w.rewriteCheckpoints(); err != nil {
+		t.Fatalf("rewriteCheckpoints(): %v", err)
+	}
+	if after := testGit(t, w.worktreePath, "rev-parse", "HEAD"); after != head {
+		t.Errorf("HEAD = %s after rewriting pushed checkpoints, want %s", after, head)
+	}
+
+	// Keeping checkpoints leaves the branch as it is
+	w = newBranch("keep", config.CheckpointsKeep)
+	head = testGit(t, w.worktreePath, "rev-parse", "HEAD")
+	if err := w.rewriteCheckpoints(); err != nil {
+		t.Fatalf("rewriteCheckpoints(): %v", err)
+	}
+	if after := testGit(t, w.worktreePath, "rev-parse", "HEAD"); after != head {
+		t.Errorf("HEAD = %s with checkpoints kept, want %s", after, head)
+	}
+}
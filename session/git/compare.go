@@ -0,0 +1,207 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Hunk is a change to a file, located by the lines it replaces in the base commit.
+type Hunk struct {
+	// Start is the first base line replaced. For a pure insertion it is the line the new lines
+	// follow.
+	Start int `json:"start"`
+	// Lines is the number of base lines replaced, 0 for a pure insertion.
+	Lines int `json:"lines"`
+	// Content is the hunk as printed by git diff, header included.
+	Content string `json:"content"`
+}
+
+// overlaps returns true if h and o touch the same base lines. A pure insertion touches the line it
+// follows, so two agents inserting at the same place conflict like they would in a merge.
+func (h Hunk) overlaps(o Hunk) bool {
+	hEnd := h.Start + max(h.Lines, 1)
+	oEnd := o.Start + max(o.Lines, 1)
+	return h.Start < oEnd && o.Start < hEnd
+}
+
+// body returns the changed lines of the hunk without its header.
+func (h Hunk) body() string {
+	if i := strings.IndexByte(h.Content, '\n'); i >= 0 {
+		return h.Content[i+1:]
+	}
+	return ""
+}
+
+// HunkConflict is a pair of differing hunks from two worktrees that touch the same base lines.
+type HunkConflict struct {
+	A Hunk `json:"a"`
+	B Hunk `json:"b"`
+}
+
+// FileComparison compares the changes two worktrees made to the same file.
+type FileComparison struct {
+	Path      string         `json:"path"`
+	HunksA    []Hunk         `json:"hunks_a"`
+	HunksB    []Hunk         `json:"hunks_b"`
+	Conflicts []HunkConflict `json:"conflicts"`
+}
+
+// Comparison is the difference between the changes of two worktrees, each relative to its base
+// commit.
+type Comparison struct {
+	BaseA string `json:"base_a"`
+	BaseB string `json:"base_b"`
+	// SameBase is false when the worktrees were created from different commits, in which case
+	// line numbers of their hunks refer to different versions of the files.
+	SameBase bool `json:"same_base"`
+	// Both lists the files changed by both worktrees.
+	Both []FileComparison `json:"both"`
+	// OnlyA and OnlyB list the files changed by a single worktree.
+	OnlyA []string `json:"only_a"`
+	OnlyB []string `json:"only_b"`
+}
+
+// ConflictCount returns the number of conflicting hunks across all files.
+func (c *Comparison) ConflictCount() int {
+	n := 0
+	for _, file := range c.Both {
+		n += len(file.Conflicts)
+	}
+	return n
+}
+
+// Compare compares the changes of worktrees a and b, including uncommitted and untracked files.
+func Compare(a, b *GitWorktree) (*Comparison, error) {
+	hunksA, err := a.changedHunks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s: %w", a.sessionName, err)
+	}
+	hunksB, err := b.changedHunks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s: %w", b.sessionName, err)
+	}
+
+	c := &Comparison{
+		BaseA:    a.GetBaseCommitSHA(),
+		BaseB:    b.GetBaseCommitSHA(),
+		SameBase: a.GetBaseCommitSHA() == b.GetBaseCommitSHA(),
+		Both:     []FileComparison{},
+		OnlyA:    []string{},
+		OnlyB:    []string{},
+	}
+	for _, path := range sortedKeys(hunksA) {
+		theirs, ok := hunksB[path]
+		if !ok {
+			c.OnlyA = append(c.OnlyA, path)
+			continue
+		}
+		file := FileComparison{Path: path, HunksA: hunksA[path], HunksB: theirs, Conflicts: []HunkConflict{}}
+		for _, ha := range file.HunksA {
+			for _, hb := range file.HunksB {
+				// Identical changes merge cleanly
+				if ha.overlaps(hb) && (ha.Start != hb.Start || ha.Lines != hb.Lines || ha.body() != hb.body()) {
+					file.Conflicts = append(file.Conflicts, HunkConflict{A: ha, B: hb})
+				}
+			}
+		}
+		c.Both = append(c.Both, file)
+	}
+	for _, path := range sortedKeys(hunksB) {
+		if _, ok := hunksA[path]; !ok {
+			c.OnlyB = append(c.OnlyB, path)
+		}
+	}
+	return c, nil
+}
+
+// changedHunks returns the hunks of the worktree's diff against its base commit by file path.
+func (g *GitWorktree) changedHunks() (map[string][]Hunk, error) {
+	// -N stages untracked files (intent to add), including them in the diff
+	if err := g.addIntentToAdd(); err != nil {
+		return nil, err
+	}
+	content, err := g.runGitCommand(g.worktreePath, "--no-pager", "diff", "--no-color", "--no-renames",
+		"-U0", g.GetBaseCommitSHA())
+	if err != nil {
+		return nil, err
+	}
+	return parseHunks(content)
+}
+
+// parseHunks splits a zero-context diff into hunks by file path. Deleted files are listed under
+// their old path.
+func parseHunks(diff string) (map[string][]Hunk, error) {
+	files := make(map[string][]Hunk)
+	var oldPath, path string
+	var current *Hunk
+	var content strings.Builder
+	flush := func() {
+		if current != nil {
+			current.Content = content.String()
+			files[path] = append(files[path], *current)
+			current = nil
+		}
+		content.Reset()
+	}
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+		case strings.HasPrefix(line, "--- ") && current == nil:
+			oldPath = strings.TrimPrefix(strings.TrimPrefix(line, "--- "), "a/")
+		case strings.HasPrefix(line, "+++ ") && current == nil:
+			path = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+			if path == "/dev/null" {
+				path = oldPath
+			}
+			if _, ok := files[path]; !ok {
+				files[path] = []Hunk{}
+			}
+		case strings.HasPrefix(line, "@@ "):
+			flush()
+			start, lines, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			current = &Hunk{Start: start, Lines: lines}
+			content.WriteString(line + "\n")
+		case current != nil && (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") ||
+			strings.HasPrefix(line, "\\")):
+			content.WriteString(line + "\n")
+		}
+	}
+	flush()
+	return files, nil
+}
+
+// parseHunkHeader returns the base line range of a hunk header such as "@@ -12,3 +12,4 @@".
+func parseHunkHeader(header string) (start, lines int, err error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") {
+		return 0, 0, fmt.Errorf("invalid hunk header %q", header)
+	}
+	rangeSpec := strings.TrimPrefix(fields[1], "-")
+	lines = 1
+	if i := strings.IndexByte(rangeSpec, ','); i >= 0 {
+		if lines, err = strconv.Atoi(rangeSpec[i+1:]); err != nil {
+			return 0, 0, fmt.Errorf("invalid hunk header %q: %w", header, err)
+		}
+		rangeSpec = rangeSpec[:i]
+	}
+	if start, err = strconv.Atoi(rangeSpec); err != nil {
+		return 0, 0, fmt.Errorf("invalid hunk header %q: %w", header, err)
+	}
+	return start, lines, nil
+}
+
+func sortedKeys(m map[string][]Hunk) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,134 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHunks(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -3 +3 @@ package main
+-var x = 1
++var x = 2
+@@ -10,0 +11,2 @@ func main() {
++	// --- not a header
++	x++
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1,2 +0,0 @@
+-a
+-b
+`
+	files, err := parseHunks(diff)
+	if err != nil {
+		t.Fatalf("parseHunks() error: %v", err)
+	}
+
+	var got []string
+	for _, path := range sortedKeys(files) {
+		for _, h := range files[path] {
+			got = append(got, fmt.Sprintf("%s:%d,%d", path, h.Start, h.Lines))
+		}
+	}
+	want := []string{"main.go:3,1", "main.go:10,0", "old.txt:1,2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("hunks = %v, want %v", got, want)
+	}
+	if content := files["main.go"][1].Content; !strings.Contains(content, "// --- not a header") {
+		t.Errorf("hunk content lost a changed line: %q", content)
+	}
+}
+
+// testGit runs git in dir with a fixed identity, failing the test on error.
+func testGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// newTestRepo creates a repository with a base commit of files and returns its path and the
+// base commit.
+func newTestRepo(t *testing.T, files map[string]string) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skipf("git not found in PATH: %v", err)
+	}
+
+	repo := filepath.Join(t.TempDir(), "repo")
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, repo, "init", "-q")
+	for name, content := range files {
+		writeTestFile(t, filepath.Join(repo, name), content)
+	}
+	testGit(t, repo, "add", ".")
+	testGit(t, repo, "commit", "-q", "-m", "base")
+	return repo, testGit(t, repo, "rev-parse", "HEAD")
+}
+
+// newTestWorktree adds a worktree on a new branch name from base.
+func newTestWorktree(t *testing.T, repo, base, name string) *GitWorktree {
+	t.Helper()
+	path := filepath.Join(filepath.Dir(repo), name)
+	testGit(t, repo, "worktree", "add", "-q", "-b", name, path, base)
+	return NewGitWorktreeFromStorage(repo, path, name, name, base)
+}
+
+func TestCompare(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{
+		"shared.txt": "one\ntwo\nthree\nfour\nfive\n",
+		"same.txt":   "same\n",
+	})
+	a, b := newTestWorktree(t, repo, base, "a"), newTestWorktree(t, repo, base, "b")
+
+	// Both edit line 2 differently and line 5 identically; each adds its own file
+	writeTestFile(t, filepath.Join(a.worktreePath, "shared.txt"), "one\nTWO\nthree\nfour\nFIVE\n")
+	writeTestFile(t, filepath.Join(b.worktreePath, "shared.txt"), "one\n2\nthree\nfour\nFIVE\n")
+	writeTestFile(t, filepath.Join(a.worktreePath, "a.txt"), "a\n")
+	writeTestFile(t, filepath.Join(b.worktreePath, "b.txt"), "b\n")
+
+	c, err := Compare(a, b)
+	if err != nil {
+		t.Fatalf("Compare() error: %v", err)
+	}
+	if !c.SameBase {
+		t.Errorf("SameBase = false for worktrees from the same commit")
+	}
+	if !reflect.DeepEqual(c.OnlyA, []string{"a.txt"}) || !reflect.DeepEqual(c.OnlyB, []string{"b.txt"}) {
+		t.Errorf("only a = %v, only b = %v, want [a.txt] and [b.txt]", c.OnlyA, c.OnlyB)
+	}
+	if len(c.Both) != 1 || c.Both[0].Path != "shared.txt" {
+		t.Fatalf("both = %+v, want shared.txt", c.Both)
+	}
+	conflicts := c.Both[0].Conflicts
+	if len(conflicts) != 1 || conflicts[0].A.Start != 2 || conflicts[0].B.Start != 2 {
+		t.Errorf("conflicts = %+v, want the differing edits of line 2 only", conflicts)
+	}
+	if c.ConflictCount() != 1 {
+		t.Errorf("ConflictCount() = %d, want 1", c.ConflictCount())
+	}
+}
@@ -0,0 +1,61 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// complianceTimeout bounds the compliance command so a hung script can't block a push forever.
+const complianceTimeout = 2 * time.Minute
+
+// ComplianceError is returned by PushChanges when the compliance command fails. The changes are
+// staged but not committed. PushChangesOverridingChecks pushes them anyway.
+type ComplianceError struct {
+	Command string
+	// Output is the combined output of the command, which lists the violations.
+	Output string
+	Err    error
+}
+
+func (e *ComplianceError) Error() string {
+	return fmt.Sprintf("compliance check %q failed: %v\n%s", e.Command, e.Err, strings.TrimSpace(e.Output))
+}
+
+func (e *ComplianceError) Unwrap() error {
+	return e.Err
+}
+
+// SetComplianceCommand sets the shell command that must succeed in the worktree before its changes
+// are pushed. An empty command disables the check.
+func (g *GitWorktree) SetComplianceCommand(command string) {
+	g.complianceCommand = command
+}
+
+// checkCompliance runs the compliance command in the worktree, with the base commit and branch in
+// CLAUDE_SQUAD_BASE and CLAUDE_SQUAD_BRANCH so it can check only the changed files.
+func (g *GitWorktree) checkCompliance() error {
+	if strings.TrimSpace(g.complianceCommand) == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), complianceTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", g.complianceCommand)
+	cmd.Dir = g.worktreePath
+	cmd.Env = append(os.Environ(),
+		"CLAUDE_SQUAD_BASE="+g.GetBaseCommitSHA(),
+		"CLAUDE_SQUAD_BRANCH="+g.branchName)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", complianceTimeout)
+	}
+	return &ComplianceError{Command: g.complianceCommand, Output: string(output), Err: err}
+}
@@ -0,0 +1,47 @@
+package git
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckCompliance(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{"main.go": "// Copyright\npackage main\n"})
+	w := newTestWorktree(t, repo, base, "compliance")
+	writeTestFile(t, filepath.Join(w.worktreePath, "new.go"), "package main\n")
+	// Lists changed Go files without a copyright header, like addlicense -check
+	check := `git add -N . && for f in $(git diff --name-only "$CLAUDE_SQUAD_BASE" -- '*.go'); do
+		head -1 "$f" | grep -q Copyright || { echo "$CLAUDE_SQUAD_BRANCH: $f: missing license header"; failed=1; }
+	done; exit ${failed:-0}`
+
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{name: "disabled", command: ""},
+		{name: "passes", command: "true"},
+		{name: "violations", command: check, want: "compliance: new.go: missing license header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w.SetComplianceCommand(tt.command)
+			err := w.checkCompliance()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("checkCompliance() = %v, want nil", err)
+				}
+				return
+			}
+			var complianceErr *ComplianceError
+			if !errors.As(err, &complianceErr) {
+				t.Fatalf("checkCompliance() = %v, want a *ComplianceError", err)
+			}
+			if strings.TrimSpace(complianceErr.Output) != tt.want {
+				t.Errorf("output = %q, want %q", complianceErr.Output, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package git
+
+import (
+	"claude-squad/command"
+	"claude-squad/config"
+	"claude-squad/network"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// pushCredentials configures how pushes authenticate, see SetPushCredentials.
+var pushCredentials atomic.Pointer[config.PushCredentialsConfig]
+
+// SetPushCredentials sets the token or askpass helper pushes authenticate with over HTTPS, in
+// addition to git's credential helpers.
+func SetPushCredentials(cfg config.PushCredentialsConfig) {
+	pushCredentials.Store(&cfg)
+}
+
+// noPrompts keeps git, Git Credential Manager and gh from asking for credentials on the terminal,
+// where nobody answers them and the push would hang.
+var noPrompts = []string{"GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never", "GH_PROMPT_DISABLED=1"}
+
+// stallLimits abort HTTP transfers stalled for 20 seconds, as they are when the network drops
+// halfway through a push.
+var stallLimits = []string{"GIT_HTTP_LOW_SPEED_LIMIT=1", "GIT_HTTP_LOW_SPEED_TIME=20"}
+
+// credentialPrompts are the messages git and Git Credential Manager fail with when they needed to
+// ask for credentials but couldn't.
+var credentialPrompts = []string{
+	"terminal prompts disabled",
+	"could not read Username",
+	"could not read Password",
+	"user interactivity has been disabled",
+}
+
+// CredentialsError is returned by pushes that needed credentials git had none for.
+type CredentialsError struct {
+	// Output is what git printed.
+	Output string
+}
+
+func (e *CredentialsError) Error() string {
+	return fmt.Sprintf("the push needs credentials for origin: set up a git credential helper, or set "+
+		"push_credentials in the config to a token or an askpass helper (%s)", e.Output)
+}
+
+// OfflineError is returned by pushes that couldn't reach origin. They can be tried again once
+// the network is back, see network.Offline.
+type OfflineError struct {
+	// Output is what git printed.
+	Output string
+}
+
+func (e *OfflineError) Error() string {
+	return fmt.Sprintf("origin can't be reached, the network may be down (%s)", e.Output)
+}
+
+// pushEnv returns the environment of a push from dir: no credential prompts, and the credentials
+// of SetPushCredentials.
+func pushEnv(dir string) ([]string, error) {
+	env := append(append(os.Environ(), noPrompts...), stallLimits...)
+	cfg := pushCredentials.Load()
+	if cfg == nil {
+		return env, nil
+	}
+	if cfg.Askpass != "" {
+		env = append(env, "GIT_ASKPASS="+cfg.Askpass)
+	}
+	if cfg.TokenEnv == "" {
+		return env, nil
+	}
+	token := os.Getenv(cfg.TokenEnv)
+	if token == "" {
+		return nil, fmt.Errorf("push_credentials.token_env is %s, which isn't set", cfg.TokenEnv)
+	}
+	if os.Getenv("GH_TOKEN") == "" {
+		env = append(env, "GH_TOKEN="+token)
+	}
+	remote, err := runGit(dir, "remote", "get-url", "--push", baseRemote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the URL of %s: %w", baseRemote, err)
+	}
+	u, err := url.Parse(strings.TrimSpace(remote))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		// The token is for HTTPS; SSH remotes authenticate with keys
+		return env, nil
+	}
+	user := cfg.TokenUser
+	if user == "" {
+		user = "x-access-token"
+	}
+	// The header is passed in the environment, out of the process list, and only sent to the
+	// host of origin
+	header := "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+token))
+	return appendGitConfigEnv(env, "http."+u.Scheme+"://"+u.Host+"/.extraHeader", header), nil
+}
+
+// appendGitConfigEnv adds a git setting to env, after the settings GIT_CONFIG_COUNT already gives.
+func appendGitConfigEnv(env []string, key, value string) []string {
+	count := 0
+	for _, entry := range env {
+		if n, ok := strings.CutPrefix(entry, "GIT_CONFIG_COUNT="); ok {
+			count, _ = strconv.Atoi(n)
+		}
+	}
+	n := strconv.Itoa(count)
+	return append(env, "GIT_CONFIG_COUNT="+strconv.Itoa(count+1), "GIT_CONFIG_KEY_"+n+"="+key, "GIT_CONFIG_VALUE_"+n+"="+value)
+}
+
+// runPush runs name, git or gh, with args in dir, authenticating as SetPushCredentials set and
+// failing with a *CredentialsError rather than prompting for credentials, or with an
+// *OfflineError, recorded by the network package, when origin can't be reached.
+func runPush(dir, name string, args ...string) (string, error) {
+	env, err := pushEnv(dir)
+	if err != nil {
+		return "", err
+	}
+	cmd := command.New(command.NetworkTimeout(), name, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		output := strings.TrimSpace(string(out))
+		for _, prompt := range credentialPrompts {
+			if strings.Contains(output, prompt) {
+				return string(out), &CredentialsError{Output: output}
+			}
+		}
+		if network.IsNetworkError(errors.New(output)) {
+			network.SetOffline(true)
+			return string(out), &OfflineError{Output: output}
+		}
+		return string(out), fmt.Errorf("%s %s: %s (%w)", name, args[0], output, err)
+	}
+	return string(out), nil
+}
+
+// Push runs git push with args in dir like the pushes of worktrees: authenticating as
+// SetPushCredentials set and failing with a *CredentialsError rather than prompting, or with an
+// *OfflineError when origin can't be reached.
+func Push(dir string, args ...string) error {
+	_, err := runPush(dir, "git", append([]string{"push"}, args...)...)
+	return err
+}
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/network"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestPushCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_ASKPASS", "")
+	t.Setenv("SSH_ASKPASS", "")
+	t.Cleanup(func() { SetPushCredentials(config.PushCredentialsConfig{}) })
+
+	// The server asks for credentials, and turns down those it gets
+	var mu sync.Mutex
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if authorization = r.Header.Get("Authorization"); authorization == "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	repo, _ := newTestRepo(t, map[string]string{"a.txt": "a\n"})
+	testGit(t, repo, "remote", "add", "origin", server.URL+"/repo.git")
+	basic := func(user, password string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+	}
+
+	var credentialsErr *CredentialsError
+	if err := Push(repo, "origin", "HEAD:refs/heads/feature"); !errors.As(err, &credentialsErr) {
+		t.Fatalf("Push() without credentials = %v, want a *CredentialsError", err)
+	}
+
+	t.Setenv("SQUAD_TEST_TOKEN", "s3cret")
+	SetPushCredentials(config.PushCredentialsConfig{TokenEnv: "SQUAD_TEST_TOKEN"})
+	if err := Push(repo, "origin", "HEAD:refs/heads/feature"); err == nil || errors.As(err, &credentialsErr) {
+		t.Errorf("Push() with a token = %v, want the server's refusal", err)
+	}
+	if want := basic("x-access-token", "s3cret"); authorization != want {
+		t.Errorf("Push() with a token sent %q, want %q", authorization, want)
+	}
+
+	askpass := filepath.Join(t.TempDir(), "askpass.sh")
+	if err := os.WriteFile(askpass, []byte("#!/bin/sh\necho helper\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	SetPushCredentials(config.PushCredentialsConfig{Askpass: askpass})
+	if err := Push(repo, "origin", "HEAD:refs/heads/feature"); err == nil || errors.As(err, &credentialsErr) {
+		t.Errorf("Push() with an askpass helper = %v, want the server's refusal", err)
+	}
+	if want := basic("helper", "helper"); authorization != want {
+		t.Errorf("Push() with an askpass helper sent %q, want %q", authorization, want)
+	}
+
+	SetPushCredentials(config.PushCredentialsConfig{TokenEnv: "SQUAD_TEST_UNSET"})
+	if err := Push(repo, "origin", "HEAD:refs/heads/feature"); err == nil {
+		t.Error("Push() with an unset token variable succeeded, want an error")
+	}
+}
+
+func TestPushOffline(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Cleanup(func() { network.SetOffline(false) })
+
+	// Nothing listens on the port of origin
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	repo, _ := newTestRepo(t, map[string]string{"a.txt": "a\n"})
+	testGit(t, repo, "remote", "add", "origin", "http://"+address+"/repo.git")
+	var offlineErr *OfflineError
+	if err := Push(repo, "origin", "HEAD:refs/heads/feature"); !errors.As(err, &offlineErr) {
+		t.Fatalf("Push() to an unreachable origin = %v, want an *OfflineError", err)
+	}
+	if !network.Offline() {
+		t.Error("network.Offline() after a push failed to connect = false, want true")
+	}
+}
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -12,6 +13,8 @@ type DiffStats struct {
 	Added int
 	// Removed is the number of removed lines
 	Removed int
+	// Base describes the commit the changes are diffed against, such as "creation commit 1a2b3c4"
+	Base string
 	// Error holds any error that occurred during diff computation
 	// This allows propagating setup errors (like missing base commit) without breaking the flow
 	Error error
@@ -21,18 +24,22 @@ func (d *DiffStats) IsEmpty() bool {
 	return d.Added == 0 && d.Removed == 0 && d.Content == ""
 }
 
-// Diff returns the git diff between the worktree and the base branch along with statistics
+// Diff returns the git diff between the worktree and its diff base, the commit it was created from
+// unless changed with SetDiffBase, along with statistics
 func (g *GitWorktree) Diff() *DiffStats {
 	stats := &DiffStats{}
 
 	// -N stages untracked files (intent to add), including them in the diff
-	_, err := g.runGitCommand(g.worktreePath, "add", "-N", ".")
-	if err != nil {
+	if err := g.addIntentToAdd(); err != nil {
 		stats.Error = err
 		return stats
 	}
 
-	content, err := g.runGitCommand(g.worktreePath, "--no-pager", "diff", g.GetBaseCommitSHA())
+	base, label := g.resolveDiffBase()
+	stats.Base = label
+	// Changes inside submodules are shown as changes of their files, rather than of the commit
+	// they point to
+	content, err := g.runGitCommand(g.worktreePath, "--no-pager", "diff", "--submodule=diff", base)
 	if err != nil {
 		stats.Error = err
 		return stats
@@ -49,3 +56,18 @@ func (g *GitWorktree) Diff() *DiffStats {
 
 	return stats
 }
+
+// Patch returns the changes of the worktree since its diff base as a patch git apply can apply to
+// the base, with the contents of new and binary files, whatever the user's diff configuration.
+func (g *GitWorktree) Patch() (string, error) {
+	if err := g.addIntentToAdd(); err != nil {
+		return "", err
+	}
+	base, _ := g.resolveDiffBase()
+	patch, err := runGit(g.worktreePath, "diff", "--binary", "--full-index", "--no-color", "--no-ext-diff",
+		"--no-relative", "--src-prefix=a/", "--dst-prefix=b/", base)
+	if err != nil {
+		return "", fmt.Errorf("failed to create patch: %w", err)
+	}
+	return patch, nil
+}
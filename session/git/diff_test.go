@@ -0,0 +1,41 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPatchApplies(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{"a.txt": "a\n", "deleted.txt": "d\n"})
+	w := newTestWorktree(t, repo, base, "patch")
+	writeTestFile(t, filepath.Join(w.worktreePath, "a.txt"), "changed\n")
+	writeTestFile(t, filepath.Join(w.worktreePath, "new.txt"), "new\n")
+	binary := "\x00\x01\x02binary\xff"
+	writeTestFile(t, filepath.Join(w.worktreePath, "image.bin"), binary)
+	if err := os.Remove(filepath.Join(w.worktreePath, "deleted.txt")); err != nil {
+		t.Fatal(err)
+	}
+	// Settings that would make a plain diff unappliable
+	testGit(t, repo, "config", "diff.noprefix", "true")
+	testGit(t, repo, "config", "color.diff", "always")
+
+	patch, err := w.Patch()
+	if err != nil {
+		t.Fatalf("Patch(): %v", err)
+	}
+	patchFile := filepath.Join(t.TempDir(), "changes.patch")
+	writeTestFile(t, patchFile, patch)
+
+	target := newTestWorktree(t, repo, base, "target")
+	testGit(t, target.worktreePath, "apply", patchFile)
+	for name, want := range map[string]string{"a.txt": "changed\n", "new.txt": "new\n", "image.bin": binary} {
+		got, err := os.ReadFile(filepath.Join(target.worktreePath, name))
+		if err != nil || string(got) != want {
+			t.Errorf("%s after applying the patch = %q (%v), want %q", name, got, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(target.worktreePath, "deleted.txt")); !os.IsNotExist(err) {
+		t.Errorf("deleted.txt still exists after applying the patch: %v", err)
+	}
+}
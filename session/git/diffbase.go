@@ -0,0 +1,88 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The diff bases a worktree's changes can be diffed against, besides a ref.
+const (
+	// DiffBaseCreation is the commit the worktree was created from, the default.
+	DiffBaseCreation = ""
+	// DiffBaseMergeBase is the merge base of the branch with origin's default branch, so commits
+	// that reached it after the worktree was created, or through a rebase, aren't counted.
+	DiffBaseMergeBase = "merge-base"
+	// DiffBasePush is the branch as last pushed to origin, so only unpushed changes are counted.
+	DiffBasePush = "push"
+)
+
+// CheckDiffBase returns an error if base can't be a diff base in the repository containing path:
+// DiffBaseCreation, DiffBaseMergeBase, DiffBasePush or an existing revision, such as a branch or a
+// commit.
+func CheckDiffBase(path, base string) error {
+	switch base {
+	case DiffBaseCreation, DiffBaseMergeBase, DiffBasePush:
+		return nil
+	}
+	if _, err := runGit(path, "rev-parse", "--verify", "--quiet", base+"^{commit}"); err != nil {
+		return fmt.Errorf("%s is not %s, %s or a commit, branch or tag", base, DiffBaseMergeBase, DiffBasePush)
+	}
+	return nil
+}
+
+// SetDiffBase sets what Diff compares the worktree with, a base checked by CheckDiffBase. A base
+// that stops resolving, such as a deleted branch, falls back to the creation commit.
+func (g *GitWorktree) SetDiffBase(base string) {
+	g.diffBase = base
+}
+
+// DiffBase returns what Diff compares the worktree with, as set by SetDiffBase.
+func (g *GitWorktree) DiffBase() string {
+	return g.diffBase
+}
+
+// resolveDiffBase returns the commit Diff compares the worktree with and a description of it for
+// the diff header. A base that can't be resolved, such as the last push of a branch that was never
+// pushed, falls back to the creation commit and says why.
+func (g *GitWorktree) resolveDiffBase() (string, string) {
+	creation := g.GetBaseCommitSHA()
+	creationLabel := "creation commit " + shortSHA(creation)
+	switch g.diffBase {
+	case DiffBaseCreation:
+		return creation, creationLabel
+	case DiffBaseMergeBase:
+		remote, err := RemoteDefaultBranch(g.worktreePath)
+		if err != nil {
+			return creation, creationLabel + " (no origin default branch)"
+		}
+		commit, err := runGit(g.worktreePath, "merge-base", "HEAD", remote)
+		if err != nil {
+			return creation, creationLabel + " (no merge base with " + remote + ")"
+		}
+		commit = strings.TrimSpace(commit)
+		return commit, "merge base with " + remote + " " + shortSHA(commit)
+	case DiffBasePush:
+		remote := baseRemote + "/" + g.branchName
+		commit, err := runGit(g.worktreePath, "rev-parse", "--verify", "--quiet", "refs/remotes/"+remote)
+		if err != nil {
+			return creation, creationLabel + " (" + g.branchName + " not pushed yet)"
+		}
+		commit = strings.TrimSpace(commit)
+		return commit, "last push " + remote + " " + shortSHA(commit)
+	default:
+		commit, err := runGit(g.worktreePath, "rev-parse", "--verify", "--quiet", g.diffBase+"^{commit}")
+		if err != nil {
+			return creation, creationLabel + " (" + g.diffBase + " not found)"
+		}
+		commit = strings.TrimSpace(commit)
+		return commit, g.diffBase + " " + shortSHA(commit)
+	}
+}
+
+// shortSHA abbreviates a commit hash for display.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,45 @@
+package git
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffBase(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{"a.txt": "a\n"})
+	w := newTestWorktree(t, repo, base, "feature")
+	writeTestFile(t, filepath.Join(w.worktreePath, "a.txt"), "a\nb\n")
+	testGit(t, w.worktreePath, "commit", "-q", "-am", "pushed")
+	testGit(t, repo, "update-ref", "refs/remotes/origin/feature", "feature")
+	writeTestFile(t, filepath.Join(w.worktreePath, "a.txt"), "a\nb\nc\n")
+
+	for _, tc := range []struct {
+		base  string
+		added int
+		label string
+	}{
+		{DiffBaseCreation, 2, "creation commit " + base[:7]},
+		{DiffBasePush, 1, "last push origin/feature"},
+		{"feature", 1, "feature"},
+		// No origin default branch to find a merge base with
+		{DiffBaseMergeBase, 2, "(no origin default branch)"},
+	} {
+		if err := CheckDiffBase(repo, tc.base); err != nil {
+			t.Fatalf("CheckDiffBase(%q): %v", tc.base, err)
+		}
+		w.SetDiffBase(tc.base)
+		stats := w.Diff()
+		if stats.Error != nil {
+			t.Fatalf("Diff against %q: %v", tc.base, stats.Error)
+		}
+		if stats.Added != tc.added || !strings.Contains(stats.Base, tc.label) {
+			t.Errorf("Diff against %q = %d added since %q, want %d since %q", tc.base, stats.Added, stats.Base,
+				tc.added, tc.label)
+		}
+	}
+
+	if err := CheckDiffBase(repo, "no-such-branch"); err == nil {
+		t.Error("CheckDiffBase accepted a missing branch")
+	}
+}
@@ -0,0 +1,31 @@
+package git
+
+import (
+	"claude-squad/log"
+	"sync/atomic"
+)
+
+// dryRun is set when destructive git operations only log what they would do.
+var dryRun atomic.Bool
+
+// SetDryRun sets whether destructive git operations only log what they would do instead: removing
+// worktrees, deleting branches and snapshots, and rolling worktrees back. Instances are still
+// killed, leaving their worktree and branch in place.
+func SetDryRun(enabled bool) {
+	dryRun.Store(enabled)
+}
+
+// DryRun reports whether destructive git operations only log what they would do.
+func DryRun() bool {
+	return dryRun.Load()
+}
+
+// skipInDryRun logs what would be done, described by format and args, and reports true in dry run
+// mode, in which case the caller must not do it.
+func skipInDryRun(format string, args ...any) bool {
+	if !dryRun.Load() {
+		return false
+	}
+	log.InfoLog.Printf("dry run: would "+format, args...)
+	return true
+}
@@ -0,0 +1,41 @@
+package git
+
+import (
+	"claude-squad/log"
+	"os"
+	"testing"
+)
+
+func TestCleanupDryRun(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	repo, base := newTestRepo(t, map[string]string{"file.txt": "content\n"})
+	w := newTestWorktree(t, repo, base, "dry")
+	if _, err := w.Snapshot("before cleanup"); err != nil {
+		t.Fatal(err)
+	}
+
+	SetDryRun(true)
+	err := w.Cleanup()
+	SetDryRun(false)
+	if err != nil {
+		t.Fatalf("Cleanup() in dry run: %v", err)
+	}
+	if _, err := os.Stat(w.worktreePath); err != nil {
+		t.Errorf("dry run removed the worktree: %v", err)
+	}
+	if branch := testGit(t, repo, "branch", "--list", "dry"); branch == "" {
+		t.Error("dry run deleted the branch")
+	}
+	if snapshots, err := w.Snapshots(); err != nil || len(snapshots) != 1 {
+		t.Errorf("snapshots after a dry run = %v, %v, want the one taken", snapshots, err)
+	}
+
+	if err := w.Cleanup(); err != nil {
+		t.Fatalf("Cleanup() error: %v", err)
+	}
+	if _, err := os.Stat(w.worktreePath); !os.IsNotExist(err) {
+		t.Errorf("worktree left after cleanup: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// baseRemote is the remote whose default branch new worktrees start from when fetching is on.
+const baseRemote = "origin"
+
+// fetchBase is set when new worktrees start from the freshly fetched default branch of the remote.
+var fetchBase atomic.Bool
+
+// SetFetchBase sets whether new worktrees without a base of their own start from the default
+// branch of origin, fetched first, instead of the local HEAD.
+func SetFetchBase(enabled bool) {
+	fetchBase.Store(enabled)
+}
+
+// FetchBaseEnabled reports whether new worktrees start from the fetched default branch of origin.
+func FetchBaseEnabled() bool {
+	return fetchBase.Load()
+}
+
+// RemoteDefaultBranch returns the remote-tracking branch of origin's default branch in the
+// repository containing path, such as origin/main.
+func RemoteDefaultBranch(path string) (string, error) {
+	repoPath, err := findGitRepoRoot(path)
+	if err != nil {
+		return "", err
+	}
+	if out, err := runGit(repoPath, "symbolic-ref", "--quiet", "--short", "refs/remotes/"+baseRemote+"/HEAD"); err == nil {
+		return strings.TrimSpace(out), nil
+	}
+	// Repositories not cloned from the remote don't know its default branch
+	for _, name := range []string{"main", "master"} {
+		if _, err := runGit(repoPath, "rev-parse", "--verify", "--quiet", "refs/remotes/"+baseRemote+"/"+name); err == nil {
+			return baseRemote + "/" + name, nil
+		}
+	}
+	return "", fmt.Errorf("%s has no default branch, set it with git remote set-head %s --auto", baseRemote, baseRemote)
+}
+
+// FetchBase fetches origin's default branch into the repository containing path and returns its
+// remote-tracking branch, such as origin/main, to start a worktree from.
+func FetchBase(path string) (string, error) {
+	ref, err := RemoteDefaultBranch(path)
+	if err != nil {
+		return "", err
+	}
+	repoPath, err := findGitRepoRoot(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := runGit(repoPath, "fetch", "--quiet", baseRemote, strings.TrimPrefix(ref, baseRemote+"/")); err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+	return ref, nil
+}
+
+// Freshness tells how far the local HEAD is behind origin's default branch, as of the last fetch.
+type Freshness struct {
+	// Head is the branch checked out, or "detached HEAD".
+	Head string
+	// Remote is the remote-tracking branch compared with, such as origin/main.
+	Remote string
+	// Behind is the number of commits on Remote that Head lacks.
+	Behind int
+	// FetchedAt is when the repository was last fetched, zero if it wasn't since it was cloned.
+	FetchedAt time.Time
+}
+
+// BaseFreshness compares the HEAD of the repository containing path with origin's default
+// branch, without fetching.
+func BaseFreshness(path string) (*Freshness, error) {
+	remote, err := RemoteDefaultBranch(path)
+	if err != nil {
+		return nil, err
+	}
+	repoPath, err := findGitRepoRoot(path)
+	if err != nil {
+		return nil, err
+	}
+	head, err := runGit(repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	count, err := runGit(repoPath, "rev-list", "--count", "HEAD.."+remote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare HEAD with %s: %w", remote, err)
+	}
+	behind, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare HEAD with %s: %w", remote, err)
+	}
+
+	freshness := &Freshness{Head: strings.TrimSpace(head), Remote: remote, Behind: behind}
+	if freshness.Head == "HEAD" {
+		freshness.Head = "detached HEAD"
+	}
+	if gitDir, err := runGit(repoPath, "rev-parse", "--path-format=absolute", "--git-common-dir"); err == nil {
+		if info, err := os.Stat(filepath.Join(strings.TrimSpace(gitDir), "FETCH_HEAD")); err == nil {
+			freshness.FetchedAt = info.ModTime()
+		}
+	}
+	return freshness, nil
+}
+
+// String describes the freshness, such as "main is 12 commits behind origin/main, fetched 3d ago".
+func (f *Freshness) String() string {
+	s := fmt.Sprintf("%s is %d commits behind %s", f.Head, f.Behind, f.Remote)
+	if f.Behind == 0 {
+		s = fmt.Sprintf("%s is up to date with %s", f.Head, f.Remote)
+	} else if f.Behind == 1 {
+		s = fmt.Sprintf("%s is 1 commit behind %s", f.Head, f.Remote)
+	}
+	if f.FetchedAt.IsZero() {
+		return s
+	}
+	age := time.Since(f.FetchedAt)
+	switch {
+	case age < time.Hour:
+		return s + fmt.Sprintf(", fetched %dm ago", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return s + fmt.Sprintf(", fetched %dh ago", int(age.Hours()))
+	default:
+		return s + fmt.Sprintf(", fetched %dd ago", int(age.Hours()/24))
+	}
+}
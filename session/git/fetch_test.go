@@ -0,0 +1,48 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFetchBase(t *testing.T) {
+	origin, _ := newTestRepo(t, map[string]string{"a.txt": "a\n"})
+	branch := testGit(t, origin, "rev-parse", "--abbrev-ref", "HEAD")
+	clone := filepath.Join(t.TempDir(), "clone")
+	testGit(t, origin, "clone", "-q", origin, clone)
+
+	remote, err := RemoteDefaultBranch(clone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote != "origin/"+branch {
+		t.Fatalf("RemoteDefaultBranch = %q, want origin/%s", remote, branch)
+	}
+
+	writeTestFile(t, filepath.Join(origin, "b.txt"), "b\n")
+	testGit(t, origin, "add", ".")
+	testGit(t, origin, "commit", "-q", "-m", "upstream")
+
+	// The clone doesn't know about the new commit until it fetches
+	freshness, err := BaseFreshness(clone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if freshness.Behind != 0 || freshness.Head != branch || !freshness.FetchedAt.IsZero() {
+		t.Errorf("freshness before fetching = %+v, want 0 behind and never fetched", freshness)
+	}
+	if _, err := FetchBase(clone); err != nil {
+		t.Fatal(err)
+	}
+	if freshness, err = BaseFreshness(clone); err != nil {
+		t.Fatal(err)
+	}
+	if freshness.Behind != 1 || freshness.FetchedAt.IsZero() {
+		t.Errorf("freshness after fetching = %+v, want 1 behind and fetched", freshness)
+	}
+
+	// A repository without origin has nothing to fetch
+	if _, err := FetchBase(origin); err == nil {
+		t.Error("FetchBase without origin succeeded")
+	}
+}
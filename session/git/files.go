@@ -0,0 +1,120 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FileReport lists the files of a worktree that the diff stats don't make obvious.
+type FileReport struct {
+	// Untracked lists new files that were never staged. Diff stats count them, but they are easy
+	// to miss among the changes of tracked files.
+	Untracked []string `json:"untracked"`
+	// Ignored lists files matched by .gitignore, which are never committed. A directory that is
+	// ignored as a whole is listed once, with a trailing slash.
+	Ignored []string `json:"ignored"`
+	// IgnoredModified lists tracked files that match .gitignore and were changed since the base
+	// commit. Their changes are committed, which is rarely intended.
+	IgnoredModified []string `json:"ignored_modified"`
+	// Submodules lists the submodules that aren't checked out, or that changed.
+	Submodules []Submodule `json:"submodules"`
+	// NotInLFS lists changed files .gitattributes tracks with Git LFS that are stored in git
+	// instead, see GitWorktree.NotInLFS.
+	NotInLFS []string `json:"not_in_lfs"`
+}
+
+// IsEmpty returns true if the report lists no file.
+func (r *FileReport) IsEmpty() bool {
+	return len(r.Untracked) == 0 && len(r.Ignored) == 0 && len(r.IgnoredModified) == 0 && len(r.Submodules) == 0 && len(r.NotInLFS) == 0
+}
+
+// FileReport returns the untracked and ignored files of the worktree, the files Git LFS should
+// track and its submodules that need attention.
+func (g *GitWorktree) FileReport() (*FileReport, error) {
+	report := &FileReport{Untracked: []string{}, Ignored: []string{}, IgnoredModified: []string{}, Submodules: []Submodule{}, NotInLFS: []string{}}
+
+	status, err := runGit(g.worktreePath, "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree status: %w", err)
+	}
+	entries := strings.Split(status, "\x00")
+	for n := 0; n < len(entries); n++ {
+		entry := entries[n]
+		if len(entry) < 4 {
+			continue
+		}
+		code, path := entry[:2], entry[3:]
+		switch {
+		case code == "??", code == " A":
+			// " A" is a file added with intent to add, as the diff stats do with untracked files
+			report.Untracked = append(report.Untracked, path)
+		case code[0] == 'R' || code[0] == 'C':
+			// Renames and copies are followed by their source path
+			n++
+		}
+	}
+
+	ignored, err := runGit(g.worktreePath, "ls-files", "-z", "--others", "--ignored", "--exclude-standard", "--directory")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ignored files: %w", err)
+	}
+	if ignored = strings.Trim(ignored, "\x00"); ignored != "" {
+		report.Ignored = strings.Split(ignored, "\x00")
+	}
+
+	tracked, err := runGit(g.worktreePath, "ls-files", "-z", "--cached", "--ignored", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ignored tracked files: %w", err)
+	}
+	if tracked = strings.Trim(tracked, "\x00"); tracked != "" {
+		trackedIgnored := make(map[string]bool)
+		for _, path := range strings.Split(tracked, "\x00") {
+			trackedIgnored[path] = true
+		}
+		changed, err := runGit(g.worktreePath, "diff", "-z", "--name-only", g.GetBaseCommitSHA())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list changed files: %w", err)
+		}
+		for _, path := range strings.Split(strings.Trim(changed, "\x00"), "\x00") {
+			if trackedIgnored[path] {
+				report.IgnoredModified = append(report.IgnoredModified, path)
+			}
+		}
+	}
+
+	changedSubmodules, err := g.Submodules()
+	if err != nil {
+		return nil, err
+	}
+	report.Submodules = append(report.Submodules, changedSubmodules...)
+	notInLFS, err := g.NotInLFS()
+	if err != nil {
+		return nil, err
+	}
+	report.NotInLFS = append(report.NotInLFS, notInLFS...)
+
+	sort.Strings(report.Untracked)
+	sort.Strings(report.Ignored)
+	sort.Strings(report.IgnoredModified)
+	return report, nil
+}
+
+// ListFiles returns the files of the worktree that aren't ignored, tracked or not, sorted by path.
+func (g *GitWorktree) ListFiles() ([]string, error) {
+	out, err := runGit(g.worktreePath, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	files := []string{}
+	seen := make(map[string]bool)
+	for _, path := range strings.Split(strings.Trim(out, "\x00"), "\x00") {
+		// Unmerged files are listed once per stage
+		if path != "" && !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,70 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileReport(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{
+		".gitignore": "*.log\nbuild/\n",
+		"main.go":    "package main\n",
+	})
+	// A file tracked before it was ignored
+	writeTestFile(t, filepath.Join(repo, "debug.log"), "old\n")
+	testGit(t, repo, "add", "-f", "debug.log")
+	testGit(t, repo, "commit", "-q", "-m", "track log")
+	base = testGit(t, repo, "rev-parse", "HEAD")
+	w := newTestWorktree(t, repo, base, "report")
+
+	writeTestFile(t, filepath.Join(w.worktreePath, "main.go"), "package main\n\nfunc main() {}\n")
+	writeTestFile(t, filepath.Join(w.worktreePath, "debug.log"), "new\n")
+	writeTestFile(t, filepath.Join(w.worktreePath, "new.go"), "package main\n")
+	writeTestFile(t, filepath.Join(w.worktreePath, "staged.go"), "package main\n")
+	testGit(t, w.worktreePath, "add", "staged.go")
+	writeTestFile(t, filepath.Join(w.worktreePath, "trace.log"), "trace\n")
+	if err := os.MkdirAll(filepath.Join(w.worktreePath, "build", "out"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(w.worktreePath, "build", "out", "app"), "binary\n")
+	// The diff stats stage untracked files with intent to add; they must still be reported
+	testGit(t, w.worktreePath, "add", "-N", ".")
+
+	report, err := w.FileReport()
+	if err != nil {
+		t.Fatalf("FileReport() error: %v", err)
+	}
+	want := &FileReport{
+		Untracked:       []string{"new.go"},
+		Ignored:         []string{"build/", "trace.log"},
+		IgnoredModified: []string{"debug.log"},
+		Submodules:      []Submodule{},
+		NotInLFS:        []string{},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("FileReport() = %+v, want %+v", report, want)
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{
+		".gitignore": "*.log\n",
+		"main.go":    "package main\n",
+	})
+	w := newTestWorktree(t, repo, base, "list")
+	if err := os.MkdirAll(filepath.Join(w.worktreePath, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(w.worktreePath, "pkg", "new.go"), "package pkg\n")
+	writeTestFile(t, filepath.Join(w.worktreePath, "trace.log"), "trace\n")
+
+	files, err := w.ListFiles()
+	if err != nil {
+		t.Fatalf("ListFiles() error: %v", err)
+	}
+	if want := []string{".gitignore", "main.go", "pkg/new.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ListFiles() = %v, want %v", files, want)
+	}
+}
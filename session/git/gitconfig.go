@@ -0,0 +1,105 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// gitConfigPrefixes are the git settings a worktree can override: the identity and signing of
+// commits, and how the remote is reached. Others, such as core.fsmonitor or core.sshCommand, run
+// commands and aren't allowed.
+var gitConfigPrefixes = []string{
+	"user.", "author.", "committer.", "commit.gpgsign", "tag.gpgsign", "gpg.", "http.", "https.", "credential.",
+}
+
+// CheckGitConfig returns an error if config sets a git setting worktrees can't override.
+func CheckGitConfig(config map[string]string) error {
+	for key := range config {
+		if !gitConfigAllowed(key) {
+			return fmt.Errorf("git setting %q can't be overridden: only %s settings can", key, strings.Join(gitConfigPrefixes, ", "))
+		}
+	}
+	return nil
+}
+
+func gitConfigAllowed(key string) bool {
+	key = strings.ToLower(key)
+	for _, prefix := range gitConfigPrefixes {
+		// Prefixes ending with a dot are sections, the others settings
+		if strings.HasSuffix(prefix, ".") {
+			if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+				return true
+			}
+		} else if key == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// SetGitConfig sets the git settings the worktree overrides, applied to it alone when it is set
+// up. They have no effect on a worktree already set up until it is set up again.
+func (g *GitWorktree) SetGitConfig(config map[string]string) {
+	g.gitConfig = config
+}
+
+// GitConfig returns the git settings the worktree overrides.
+func (g *GitWorktree) GitConfig() map[string]string {
+	return g.gitConfig
+}
+
+// applyGitConfig writes the settings of the worktree to its own config file, which the
+// repository and its other worktrees don't read.
+func (g *GitWorktree) applyGitConfig() error {
+	if len(g.gitConfig) == 0 {
+		return nil
+	}
+	if err := enableWorktreeConfig(g.repoPath); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(g.gitConfig))
+	for key := range g.gitConfig {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, err := runGit(g.worktreePath, "config", "--worktree", key, g.gitConfig[key]); err != nil {
+			return fmt.Errorf("failed to set %s in the worktree: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// enableWorktreeConfig lets the worktrees of the repository containing path have settings of their
+// own. As the git documentation asks, core.bare and core.worktree move to the config of the main
+// worktree first, or every worktree would read them, which breaks the worktrees of a bare
+// repository.
+func enableWorktreeConfig(path string) error {
+	if enabled, err := runGit(path, "config", "--type=bool", "extensions.worktreeConfig"); err == nil && strings.TrimSpace(enabled) == "true" {
+		return nil
+	}
+	commonDir, err := runGit(path, "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return fmt.Errorf("failed to find the git directory of %s: %w", path, err)
+	}
+	commonDir = strings.TrimSpace(commonDir)
+	shared, main := filepath.Join(commonDir, "config"), filepath.Join(commonDir, "config.worktree")
+	for _, key := range []string{"core.bare", "core.worktree"} {
+		value, err := runGit(path, "config", "--file", shared, key)
+		if err != nil || (key == "core.bare" && strings.TrimSpace(value) != "true") {
+			continue
+		}
+		if _, err := runGit(path, "config", "--file", main, key, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("failed to move %s to the config of the main worktree: %w", key, err)
+		}
+		if _, err := runGit(path, "config", "--file", shared, "--unset", key); err != nil {
+			return fmt.Errorf("failed to move %s to the config of the main worktree: %w", key, err)
+		}
+	}
+	if _, err := runGit(path, "config", "--file", shared, "extensions.worktreeConfig", "true"); err != nil {
+		return fmt.Errorf("failed to enable worktree settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package git
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckGitConfig(t *testing.T) {
+	for key, allowed := range map[string]bool{
+		"user.email":                      true,
+		"commit.gpgSign":                  true,
+		"gpg.format":                      true,
+		"http.proxy":                      true,
+		"http.https://example.com/.proxy": true,
+		"credential.helper":               true,
+		"core.fsmonitor":                  false,
+		"core.sshCommand":                 false,
+		"commit.template":                 false,
+		"user":                            false,
+		"alias.co":                        false,
+	} {
+		err := CheckGitConfig(map[string]string{key: "value"})
+		if (err == nil) != allowed {
+			t.Errorf("CheckGitConfig(%q) = %v, want allowed %v", key, err, allowed)
+		}
+	}
+}
+
+func TestApplyGitConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo, _ := newTestRepo(t, map[string]string{"a.txt": "a\n"})
+	bare := filepath.Join(filepath.Dir(repo), "bare.git")
+	testGit(t, filepath.Dir(repo), "clone", "-q", "--bare", repo, bare)
+
+	w, _, err := NewGitWorktree(bare, "bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetGitConfig(map[string]string{"user.name": "Review Bot", "http.proxy": "http://proxy:3128"})
+	if err := w.Setup(); err != nil {
+		t.Fatalf("Setup(): %v", err)
+	}
+	defer w.Cleanup()
+
+	if name := testGit(t, w.worktreePath, "config", "user.name"); name != "Review Bot" {
+		t.Errorf("user.name in the worktree = %q, want the worktree's", name)
+	}
+	if out, err := runGit(bare, "config", "http.proxy"); err == nil {
+		t.Errorf("http.proxy leaked to the repository: %q", out)
+	}
+	// The worktree of a bare repository must still have a working tree
+	if out := testGit(t, w.worktreePath, "rev-parse", "--is-inside-work-tree"); strings.TrimSpace(out) != "true" {
+		t.Errorf("worktree isn't a working tree once worktree settings are enabled: %s", out)
+	}
+	if out := testGit(t, bare, "rev-parse", "--is-bare-repository"); out != "true" {
+		t.Errorf("repository is no longer bare: %s", out)
+	}
+}
@@ -0,0 +1,181 @@
+package git
+
+import (
+	"claude-squad/command"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// MergeResult is the outcome of merging one branch into an integration branch.
+type MergeResult struct {
+	Branch string `json:"branch"`
+	Merged bool   `json:"merged"`
+	// Conflicts lists the files that conflicted. The merge is aborted and the branch left out.
+	Conflicts []string `json:"conflicts"`
+}
+
+// PairConflict lists the files that conflict when two branches are merged with each other.
+type PairConflict struct {
+	A     string   `json:"a"`
+	B     string   `json:"b"`
+	Files []string `json:"files"`
+}
+
+// Integration is the result of merging several branches into a new integration branch.
+type Integration struct {
+	// Branch is the integration branch.
+	Branch string `json:"branch"`
+	// Base is the commit the integration branch was created from.
+	Base string `json:"base"`
+	// Merges holds the result of each merge, in order.
+	Merges []MergeResult `json:"merges"`
+	// PairConflicts holds every pair of branches that conflict with each other, whatever the
+	// order of the merges.
+	PairConflicts []PairConflict `json:"pair_conflicts"`
+}
+
+// Integrate creates branch from the HEAD of the repository at repoPath and merges branches into
+// it one after the other, in a temporary worktree that is removed afterwards. A branch that
+// conflicts is left out and the next one merged. Conflicts between every pair of branches are
+// checked separately, so the report doesn't depend on the merge order.
+func Integrate(repoPath string, branch string, branches []string) (*Integration, error) {
+	repoPath, err := findGitRepoRoot(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := runGit(repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		return nil, fmt.Errorf("branch %s already exists", branch)
+	}
+	base, err := runGit(repoPath, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD commit hash: %w", err)
+	}
+
+	result := &Integration{
+		Branch:        branch,
+		Base:          strings.TrimSpace(base),
+		Merges:        []MergeResult{},
+		PairConflicts: []PairConflict{},
+	}
+	for i := range branches {
+		for _, other := range branches[i+1:] {
+			files, err := mergeTreeConflicts(repoPath, branches[i], other)
+			if err != nil {
+				return nil, err
+			}
+			if len(files) > 0 {
+				result.PairConflicts = append(result.PairConflicts, PairConflict{A: branches[i], B: other, Files: files})
+			}
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "claude-squad-integrate-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create integration worktree directory: %w", err)
+	}
+	// git worktree add wants to create the directory itself
+	if err := os.Remove(dir); err != nil {
+		return nil, fmt.Errorf("failed to prepare integration worktree directory: %w", err)
+	}
+	if _, err := runGit(repoPath, "worktree", "add", "-b", branch, dir, result.Base); err != nil {
+		return nil, fmt.Errorf("failed to create integration worktree: %w", err)
+	}
+	defer func() {
+		_, _ = runGit(repoPath, "worktree", "remove", "-f", dir)
+	}()
+
+	for _, b := range branches {
+		merge := MergeResult{Branch: b, Conflicts: []string{}}
+		if _, err := runGit(dir, "merge", "--no-ff", "--no-edit", "-m", fmt.Sprintf("Merge %s into %s", b, branch), b); err != nil {
+			conflicts, diffErr := runGit(dir, "diff", "--name-only", "--diff-filter=U")
+			if diffErr != nil || strings.TrimSpace(conflicts) == "" {
+				// Not a conflict: the branch is missing or git failed otherwise
+				return nil, fmt.Errorf("failed to merge %s: %w", b, err)
+			}
+			merge.Conflicts = splitLines(conflicts)
+			if _, err := runGit(dir, "merge", "--abort"); err != nil {
+				return nil, fmt.Errorf("failed to abort merge of %s: %w", b, err)
+			}
+		} else {
+			merge.Merged = true
+		}
+		result.Merges = append(result.Merges, merge)
+	}
+	return result, nil
+}
+
+// mergeTreeConflicts returns the files that conflict when a and b are merged, without touching any
+// worktree.
+func mergeTreeConflicts(repoPath, a, b string) ([]string, error) {
+	out, err := runGit(repoPath, "merge-tree", "--write-tree", "--name-only", "--no-messages", a, b)
+	if err == nil {
+		return nil, nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		return nil, fmt.Errorf("failed to check %s against %s: %w", a, b, err)
+	}
+	// The first line is the tree that would be written, the rest the conflicted files
+	lines := splitLines(out)
+	if len(lines) > 0 {
+		lines = lines[1:]
+	}
+	return lines, nil
+}
+
+// runGit runs git in dir and returns its standard output. The error wraps *exec.ExitError and
+// carries git's standard error.
+func runGit(dir string, args ...string) (string, error) {
+	return runGitEnv(dir, nil, args...)
+}
+
+// runGitEnv is runGit with env added to the environment of git.
+func runGitEnv(dir string, env []string, args ...string) (string, error) {
+	cmd := command.New(gitTimeout(args), "git", append([]string{"-C", dir}, args...)...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return string(out), fmt.Errorf("git %s: %s (%w)", args[0], strings.TrimSpace(stderr.String()), err)
+	}
+	return string(out), nil
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// networkCommands are the git subcommands talking to a remote, bounded by the network timeout
+// rather than that of local commands.
+var networkCommands = map[string]bool{
+	"push": true, "fetch": true, "pull": true, "clone": true, "ls-remote": true, "lfs": true, "submodule": true,
+}
+
+// gitTimeout returns the timeout of git run with args.
+func gitTimeout(args []string) time.Duration {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-c" || args[i] == "-C":
+			i++
+		case !strings.HasPrefix(args[i], "-"):
+			if networkCommands[args[i]] {
+				return command.NetworkTimeout()
+			}
+			return command.GitTimeout()
+		}
+	}
+	return command.GitTimeout()
+}
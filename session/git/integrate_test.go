@@ -0,0 +1,97 @@
+package git
+
+import (
+	"claude-squad/command"
+	"claude-squad/config"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIntegrate(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{"shared.txt": "one\ntwo\nthree\n"})
+	a := newTestWorktree(t, repo, base, "a")
+	b := newTestWorktree(t, repo, base, "b")
+	c := newTestWorktree(t, repo, base, "c")
+
+	// a and b add separate files, c edits the line that b also edits
+	writeTestFile(t, filepath.Join(a.worktreePath, "a.txt"), "a\n")
+	writeTestFile(t, filepath.Join(b.worktreePath, "shared.txt"), "one\nb\nthree\n")
+	writeTestFile(t, filepath.Join(c.worktreePath, "shared.txt"), "one\nc\nthree\n")
+	for _, w := range []*GitWorktree{a, b, c} {
+		testGit(t, w.worktreePath, "add", ".")
+		testGit(t, w.worktreePath, "commit", "-q", "-m", w.branchName)
+	}
+
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	result, err := Integrate(repo, "integration", []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("Integrate() error: %v", err)
+	}
+
+	want := []MergeResult{
+		{Branch: "a", Merged: true, Conflicts: []string{}},
+		{Branch: "b", Merged: true, Conflicts: []string{}},
+		{Branch: "c", Merged: false, Conflicts: []string{"shared.txt"}},
+	}
+	if !reflect.DeepEqual(result.Merges, want) {
+		t.Errorf("merges = %+v, want %+v", result.Merges, want)
+	}
+	wantPairs := []PairConflict{{A: "b", B: "c", Files: []string{"shared.txt"}}}
+	if !reflect.DeepEqual(result.PairConflicts, wantPairs) {
+		t.Errorf("pair conflicts = %+v, want %+v", result.PairConflicts, wantPairs)
+	}
+
+	// The integration branch has the merged work and its temporary worktree is gone
+	if got := testGit(t, repo, "show", "integration:shared.txt"); got != "one\nb\nthree" {
+		t.Errorf("integration shared.txt = %q, want b's version", got)
+	}
+	testGit(t, repo, "cat-file", "-e", "integration:a.txt")
+	if worktrees := testGit(t, repo, "worktree", "list", "--porcelain"); strings.Contains(worktrees, "claude-squad-integrate-") {
+		t.Errorf("integration worktree was not removed:\n%s", worktrees)
+	}
+
+	if _, err := Integrate(repo, "integration", []string{"a", "b"}); err == nil {
+		t.Errorf("Integrate() into an existing branch succeeded")
+	}
+}
+
+func TestRunGitTimeout(t *testing.T) {
+	repo, _ := newTestRepo(t, map[string]string{"a.txt": "a\n"})
+	command.SetTimeouts(config.TimeoutsConfig{GitSeconds: 1, NetworkSeconds: 5})
+	t.Cleanup(func() { command.SetTimeouts(config.TimeoutsConfig{}) })
+
+	if got := gitTimeout([]string{"-c", "core.askPass=", "push", "origin"}); got != 5*time.Second {
+		t.Errorf("gitTimeout(push) = %v, want the network timeout", got)
+	}
+	if got := gitTimeout([]string{"commit", "-m", "push"}); got != time.Second {
+		t.Errorf("gitTimeout(commit) = %v, want the git timeout", got)
+	}
+
+	// A pre-commit hook that never returns fails the commit instead of hanging it
+	writeTestFile(t, filepath.Join(repo, ".git", "hooks", "pre-commit"), "#!/bin/sh\nsleep 30\n")
+	if err := os.Chmod(filepath.Join(repo, ".git", "hooks", "pre-commit"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(repo, "a.txt"), "b\n")
+	start := time.Now()
+	_, err := runGitEnv(repo, []string{"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com"}, "commit", "-q", "-am", "hung")
+	var timeoutErr *command.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("runGit(commit) with a hung hook = %v, want a *command.TimeoutError", err)
+	}
+	if timeoutErr.Command != "git commit" || timeoutErr.Dir != repo {
+		t.Errorf("TimeoutError = %+v, want git commit in %s", timeoutErr, repo)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("runGit(commit) returned after %s, want it killed after 1s", elapsed)
+	}
+}
@@ -0,0 +1,126 @@
+package git
+
+import (
+	"claude-squad/command"
+	"claude-squad/log"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// lfsPointerPrefix starts the pointer files Git LFS stores in place of the files it tracks.
+const lfsPointerPrefix = "version https://git-lfs.github.com/spec/v1"
+
+// lfsSkipSmudge keeps Git LFS from downloading files while a worktree is checked out, so a
+// download failing doesn't fail the worktree. setupLFS pulls them afterwards.
+var lfsSkipSmudge = []string{"GIT_LFS_SKIP_SMUDGE=1"}
+
+// usesLFS reports whether the .gitattributes of the worktree route files through Git LFS.
+func (g *GitWorktree) usesLFS() bool {
+	attributes, err := os.ReadFile(filepath.Join(g.worktreePath, ".gitattributes"))
+	return err == nil && strings.Contains(string(attributes), "filter=lfs")
+}
+
+// lfsInstalled reports whether the git-lfs extension is installed.
+func lfsInstalled() bool {
+	return command.New(command.GitTimeout(), "git", "lfs", "version").Run() == nil
+}
+
+// lfsFilterConfigured reports whether git runs files tracked by Git LFS through it when they are
+// staged, which git lfs install sets up.
+func lfsFilterConfigured(dir string) bool {
+	out, err := runGit(dir, "config", "--get", "filter.lfs.clean")
+	return err == nil && strings.TrimSpace(out) != ""
+}
+
+// setupLFS downloads the files Git LFS tracks into a new worktree of a repository using it, after
+// setting up its filters and hooks if git lfs install never did. Failures are logged, leaving
+// pointer files in place of the files.
+func (g *GitWorktree) setupLFS() {
+	if !g.usesLFS() {
+		return
+	}
+	if !lfsInstalled() {
+		log.WarningLog.Printf("%s uses Git LFS, which isn't installed: the files it tracks are left as pointers", g.repoPath)
+		return
+	}
+	if !lfsFilterConfigured(g.worktreePath) {
+		if _, err := runGit(g.worktreePath, "lfs", "install", "--local"); err != nil {
+			log.WarningLog.Printf("failed to set up Git LFS in %s: %v", g.repoPath, err)
+		}
+	}
+	if _, err := runGit(g.worktreePath, "lfs", "pull"); err != nil {
+		log.WarningLog.Printf("failed to download the Git LFS files of %s: %v", g.worktreePath, err)
+	}
+}
+
+// pushLFS uploads the Git LFS files of the branch to origin, which a push by gh or without the
+// hooks of Git LFS would leave out.
+func (g *GitWorktree) pushLFS() error {
+	if !g.usesLFS() || !lfsInstalled() {
+		return nil
+	}
+	if _, err := runPush(g.worktreePath, "git", "lfs", "push", "origin", g.branchName); err != nil {
+		return fmt.Errorf("failed to upload Git LFS files: %w", err)
+	}
+	return nil
+}
+
+// NotInLFS returns the files changed since the base commit that .gitattributes routes through Git
+// LFS, but that would be committed, or were staged, as regular files: because Git LFS isn't set up,
+// or they were staged before it was.
+func (g *GitWorktree) NotInLFS() ([]string, error) {
+	if !g.usesLFS() {
+		return nil, nil
+	}
+	changed, err := runGit(g.worktreePath, "diff", "-z", "--name-only", "--diff-filter=AMT", g.GetBaseCommitSHA())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	paths := strings.Split(strings.Trim(changed, "\x00"), "\x00")
+	if len(paths) == 0 || paths[0] == "" {
+		return nil, nil
+	}
+	attrs, err := runGit(g.worktreePath, append([]string{"check-attr", "-z", "filter", "--"}, paths...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check the attributes of changed files: %w", err)
+	}
+
+	filtered := lfsFilterConfigured(g.worktreePath)
+	var files []string
+	// Attributes come as path, attribute and value triples
+	fields := strings.Split(attrs, "\x00")
+	for n := 0; n+2 < len(fields); n += 3 {
+		path, value := fields[n], fields[n+2]
+		if value != "lfs" {
+			continue
+		}
+		if !filtered || !g.stagedAsPointer(path) {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// stagedAsPointer reports whether path is staged as a Git LFS pointer, or not staged yet. New files
+// are staged empty, with intent to add, until they are committed.
+func (g *GitWorktree) stagedAsPointer(path string) bool {
+	size, err := runGit(g.worktreePath, "cat-file", "-s", ":"+path)
+	if err != nil {
+		return true
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(size))
+	if err != nil || n == 0 {
+		return true
+	}
+	// Pointers are a few lines long
+	if n > 1024 {
+		return false
+	}
+	blob, err := runGit(g.worktreePath, "cat-file", "blob", ":"+path)
+	return err == nil && strings.HasPrefix(blob, lfsPointerPrefix)
+}
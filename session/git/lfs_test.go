@@ -0,0 +1,47 @@
+package git
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNotInLFS(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{
+		".gitattributes": "*.bin filter=lfs diff=lfs merge=lfs -text\n",
+		"main.go":        "package main\n",
+	})
+	w := newTestWorktree(t, repo, base, "lfs")
+	writeTestFile(t, filepath.Join(w.worktreePath, "model.bin"), "weights\n")
+	writeTestFile(t, filepath.Join(w.worktreePath, "main.go"), "package main\n\nfunc main() {}\n")
+	testGit(t, w.worktreePath, "add", "-N", ".")
+
+	// Without Git LFS set up, the file would be committed as it is
+	files, err := w.NotInLFS()
+	if err != nil {
+		t.Fatalf("NotInLFS(): %v", err)
+	}
+	if !reflect.DeepEqual(files, []string{"model.bin"}) {
+		t.Errorf("NotInLFS() = %v, want model.bin", files)
+	}
+
+	// Committed before the filter was set up, the file stays in git; staged after, it is a pointer
+	testGit(t, w.worktreePath, "add", "model.bin")
+	testGit(t, w.worktreePath, "commit", "-q", "-m", "model")
+	testGit(t, repo, "config", "filter.lfs.clean",
+		"cat >/dev/null; printf 'version https://git-lfs.github.com/spec/v1\\noid sha256:0\\nsize 8\\n'")
+	writeTestFile(t, filepath.Join(w.worktreePath, "data.bin"), "data\n")
+	testGit(t, w.worktreePath, "add", "data.bin")
+	if files, err = w.NotInLFS(); err != nil || !reflect.DeepEqual(files, []string{"model.bin"}) {
+		t.Errorf("NotInLFS() = %v, %v, want model.bin only", files, err)
+	}
+}
+
+func TestNotInLFSWithoutLFS(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	w := newTestWorktree(t, repo, base, "plain")
+	writeTestFile(t, filepath.Join(w.worktreePath, "model.bin"), "weights\n")
+	if files, err := w.NotInLFS(); err != nil || files != nil {
+		t.Errorf("NotInLFS() = %v, %v, want nothing for a repository without Git LFS", files, err)
+	}
+}
@@ -0,0 +1,120 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is a commit made in a worktree since its base commit.
+type Commit struct {
+	SHA     string    `json:"sha"`
+	Author  string    `json:"author"`
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body,omitempty"`
+	// Insertions and Deletions count the lines the commit changed, binary files left out.
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// Commits returns up to limit commits made in the worktree since its base commit, newest first,
+// with the number of lines each changed.
+func (g *GitWorktree) Commits(limit int) ([]Commit, error) {
+	out, err := runGit(g.worktreePath, "log", "-z", fmt.Sprintf("--max-count=%d", limit),
+		"--format=%H%x1f%an%x1f%at%x1f%s%x1f%b", g.GetBaseCommitSHA()+"..HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+	commits := []Commit{}
+	for _, record := range strings.Split(strings.Trim(out, "\x00"), "\x00") {
+		fields := strings.SplitN(strings.TrimPrefix(record, "\n"), "\x1f", 5)
+		if len(fields) != 5 {
+			continue
+		}
+		seconds, _ := strconv.ParseInt(fields[2], 10, 64)
+		commits = append(commits, Commit{
+			SHA:     fields[0],
+			Author:  fields[1],
+			Time:    time.Unix(seconds, 0),
+			Subject: fields[3],
+			Body:    strings.TrimSpace(fields[4]),
+		})
+	}
+	if len(commits) == 0 {
+		return commits, nil
+	}
+
+	stats, err := g.commitLineStats(limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range commits {
+		counts := stats[commits[i].SHA]
+		commits[i].Insertions, commits[i].Deletions = counts[0], counts[1]
+	}
+	return commits, nil
+}
+
+// commitLineStats returns the lines inserted and deleted by the commits Commits lists, by hash.
+func (g *GitWorktree) commitLineStats(limit int) (map[string][2]int, error) {
+	out, err := runGit(g.worktreePath, "log", fmt.Sprintf("--max-count=%d", limit), "--numstat",
+		"--format=%x00%H", g.GetBaseCommitSHA()+"..HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count the changes of commits: %w", err)
+	}
+	stats := make(map[string][2]int)
+	for _, record := range strings.Split(out, "\x00") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		if lines[0] == "" {
+			continue
+		}
+		var counts [2]int
+		for _, line := range lines[1:] {
+			// Binary files are counted as "-"
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			added, _ := strconv.Atoi(fields[0])
+			deleted, _ := strconv.Atoi(fields[1])
+			counts[0] += added
+			counts[1] += deleted
+		}
+		stats[lines[0]] = counts
+	}
+	return stats, nil
+}
+
+// CommitPatch returns the message, changed files and patch of sha, one of the commits made in the
+// worktree since its base commit.
+func (g *GitWorktree) CommitPatch(sha string) (string, error) {
+	commit, err := g.branchCommit(sha)
+	if err != nil {
+		return "", err
+	}
+	patch, err := runGit(g.worktreePath, "--no-pager", "show", "--no-color", "--stat", "--patch", commit)
+	if err != nil {
+		return "", fmt.Errorf("failed to show commit %s: %w", sha, err)
+	}
+	return patch, nil
+}
+
+// branchCommit returns the full hash of sha if it is one of the commits made in the worktree since
+// its base commit, which Commits lists.
+func (g *GitWorktree) branchCommit(sha string) (string, error) {
+	commit, err := runGit(g.worktreePath, "rev-parse", "--verify", "--quiet", sha+"^{commit}")
+	if err != nil || strings.HasPrefix(sha, "-") {
+		return "", fmt.Errorf("commit %s not found", sha)
+	}
+	commit = strings.TrimSpace(commit)
+	// Only commits of the instance, not of the branch it started from
+	if _, err := runGit(g.worktreePath, "merge-base", "--is-ancestor", commit, g.GetBaseCommitSHA()); err == nil {
+		return "", fmt.Errorf("commit %s was not made in this worktree", sha)
+	}
+	if _, err := runGit(g.worktreePath, "merge-base", "--is-ancestor", commit, "HEAD"); err != nil {
+		return "", fmt.Errorf("commit %s is not on the branch", sha)
+	}
+	return commit, nil
+}
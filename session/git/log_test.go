@@ -0,0 +1,52 @@
+package git
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommits(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	w := newTestWorktree(t, repo, base, "log")
+	if commits, err := w.Commits(10); err != nil || len(commits) != 0 {
+		t.Fatalf("Commits() of a new worktree = %v, %v", commits, err)
+	}
+
+	writeTestFile(t, filepath.Join(w.worktreePath, "a.go"), "package main\n")
+	testGit(t, w.worktreePath, "add", ".")
+	testGit(t, w.worktreePath, "commit", "-q", "--author=Dev (aider) <dev@example.com>", "-m", "feat: Add a\n\nBecause a was missing.")
+	writeTestFile(t, filepath.Join(w.worktreePath, "b.go"), "package main\n")
+	testGit(t, w.worktreePath, "add", ".")
+	testGit(t, w.worktreePath, "commit", "-q", "-m", "Add b")
+
+	commits, err := w.Commits(10)
+	if err != nil {
+		t.Fatalf("Commits() error: %v", err)
+	}
+	if len(commits) != 2 || commits[0].Subject != "Add b" || commits[1].Author != "Dev (aider)" ||
+		commits[1].Body != "Because a was missing." || len(commits[1].SHA) != 40 {
+		t.Errorf("Commits() = %+v", commits)
+	}
+	if commits, _ := w.Commits(1); len(commits) != 1 {
+		t.Errorf("Commits(1) returned %d commits", len(commits))
+	}
+
+	writeTestFile(t, filepath.Join(w.worktreePath, "b.go"), "package b\n\nfunc B() {}\n")
+	testGit(t, w.worktreePath, "commit", "-q", "-am", "Fill b")
+	commits, err = w.Commits(10)
+	if err != nil {
+		t.Fatalf("Commits() error: %v", err)
+	}
+	if commits[0].Insertions != 3 || commits[0].Deletions != 1 || commits[1].Insertions != 1 {
+		t.Errorf("Commits() line stats = %+v", commits)
+	}
+
+	patch, err := w.CommitPatch(commits[0].SHA[:7])
+	if err != nil || !strings.Contains(patch, "Fill b") || !strings.Contains(patch, "+func B() {}") {
+		t.Errorf("CommitPatch() = %q, %v", patch, err)
+	}
+	if _, err := w.CommitPatch(base); err == nil {
+		t.Error("CommitPatch() showed the base commit")
+	}
+}
@@ -0,0 +1,207 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LostWork is a commit that is no longer on any branch, such as the tip of a branch deleted
+// when its instance was killed.
+type LostWork struct {
+	// Branch is the deleted branch the commit was the tip of, or "" for an unreachable commit
+	// that no reflog names.
+	Branch  string
+	Commit  string
+	Subject string
+	// Time is when the branch last moved, or when the commit was made.
+	Time time.Time
+}
+
+// FindLostWork lists work lost in the repository at repoPath, newest first: the last commit of
+// every deleted branch whose reflog git kept, as when claude-squad removes a branch, or that HEAD
+// last moved away from, followed by the unreachable commits no other unreachable commit builds
+// on, such as snapshots and branches deleted with git branch -D. Commits still on a branch are
+// left out. Unreachable commits are pruned by git gc after two weeks.
+func FindLostWork(repoPath string) ([]LostWork, error) {
+	repoPath, err := findGitRepoRoot(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	gitDir, err := runGit(repoPath, "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the git directory: %w", err)
+	}
+
+	lost, err := deletedBranches(repoPath, strings.TrimSpace(gitDir))
+	if err != nil {
+		return nil, err
+	}
+	dangling, err := unreachableTips(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	lost = append(lost, dangling...)
+	sort.SliceStable(lost, func(i, j int) bool { return lost[i].Time.After(lost[j].Time) })
+	return lost, nil
+}
+
+// reflogEntry is a line of a reflog: "<old> <new> <committer> <unix time> <zone>\t<message>".
+type reflogEntry struct {
+	old, new string
+	time     time.Time
+	message  string
+}
+
+// readReflog returns the entries of the reflog at path, oldest first.
+func readReflog(path string) []reflogEntry {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var entries []reflogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, message, _ := strings.Cut(scanner.Text(), "\t")
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, reflogEntry{old: fields[0], new: fields[1], time: time.Unix(seconds, 0), message: message})
+	}
+	return entries
+}
+
+// deletedBranches returns the last commit of each branch that no longer exists but has a reflog
+// or was checked out, unless the commit is on another branch.
+func deletedBranches(repoPath, gitDir string) ([]LostWork, error) {
+	tips := make(map[string]reflogEntry)
+	logsDir := filepath.Join(gitDir, "logs", "refs", "heads")
+	err := filepath.WalkDir(logsDir, func(path string, d os.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(logsDir, path)
+		if err != nil {
+			return err
+		}
+		if entries := readReflog(path); len(entries) > 0 {
+			last := entries[len(entries)-1]
+			tips[filepath.ToSlash(rel)] = reflogEntry{new: last.new, time: last.time}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read branch reflogs: %w", err)
+	}
+	// Checking out another branch records the commit left behind
+	for _, entry := range readReflog(filepath.Join(gitDir, "logs", "HEAD")) {
+		rest, ok := strings.CutPrefix(entry.message, "checkout: moving from ")
+		if !ok {
+			continue
+		}
+		branch, _, _ := strings.Cut(rest, " to ")
+		if tip, ok := tips[branch]; !ok || !tip.time.After(entry.time) {
+			tips[branch] = reflogEntry{new: entry.old, time: entry.time}
+		}
+	}
+
+	lost := []LostWork{}
+	for branch, tip := range tips {
+		if strings.Trim(tip.new, "0") == "" {
+			continue
+		}
+		if _, err := runGit(repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			continue
+		}
+		// Detached HEADs name a commit rather than a branch
+		if strings.HasPrefix(tip.new, branch) {
+			continue
+		}
+		onBranch, err := runGit(repoPath, "for-each-ref", "--count=1", "--contains", tip.new, "refs/heads")
+		if err != nil {
+			// The commit was pruned since
+			continue
+		}
+		if strings.TrimSpace(onBranch) != "" {
+			continue
+		}
+		subject, err := runGit(repoPath, "log", "-1", "--format=%s", tip.new)
+		if err != nil {
+			continue
+		}
+		lost = append(lost, LostWork{Branch: branch, Commit: tip.new, Subject: strings.TrimSpace(subject), Time: tip.time})
+	}
+	return lost, nil
+}
+
+// unreachableTips returns the unreachable commits that aren't the parent of another one.
+func unreachableTips(repoPath string) ([]LostWork, error) {
+	out, err := runGit(repoPath, "fsck", "--unreachable", "--no-progress")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find unreachable commits: %w", err)
+	}
+	var commits []string
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) == 3 && fields[0] == "unreachable" && fields[1] == "commit" {
+			commits = append(commits, fields[2])
+		}
+	}
+	if len(commits) == 0 {
+		return []LostWork{}, nil
+	}
+
+	out, err = runGit(repoPath, append([]string{"show", "-s", "--format=%H%x1f%P%x1f%ct%x1f%s"}, commits...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read unreachable commits: %w", err)
+	}
+	var found []LostWork
+	parents := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(line, "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		for _, parent := range strings.Fields(fields[1]) {
+			parents[parent] = true
+		}
+		seconds, _ := strconv.ParseInt(fields[2], 10, 64)
+		found = append(found, LostWork{Commit: fields[0], Subject: fields[3], Time: time.Unix(seconds, 0)})
+	}
+	tips := []LostWork{}
+	for _, work := range found {
+		if !parents[work.Commit] {
+			tips = append(tips, work)
+		}
+	}
+	return tips, nil
+}
+
+// RecoverBranch creates branch at commit in the repository at repoPath. It fails if the branch
+// already exists.
+func RecoverBranch(repoPath, branch, commit string) error {
+	repoPath, err := findGitRepoRoot(repoPath)
+	if err != nil {
+		return err
+	}
+	if _, err := runGit(repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		return fmt.Errorf("branch %s already exists", branch)
+	}
+	if _, err := runGit(repoPath, "branch", branch, commit); err != nil {
+		return fmt.Errorf("failed to recreate branch %s: %w", branch, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFindLostWork(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+
+	// Removed the way killing an instance removes it, which keeps the branch's reflog
+	killed := newTestWorktree(t, repo, base, "session/killed")
+	writeTestFile(t, filepath.Join(killed.worktreePath, "a.go"), "package main\n")
+	testGit(t, killed.worktreePath, "add", ".")
+	testGit(t, killed.worktreePath, "commit", "-q", "-m", "Add a")
+	killedTip := testGit(t, killed.worktreePath, "rev-parse", "HEAD")
+	if err := killed.Cleanup(); err != nil {
+		t.Fatalf("Cleanup() error: %v", err)
+	}
+
+	// Deleted with git branch -D, which drops the reflog too
+	reset := newTestWorktree(t, repo, base, "session/reset")
+	for _, name := range []string{"b.go", "c.go"} {
+		writeTestFile(t, filepath.Join(reset.worktreePath, name), "package main\n")
+		testGit(t, reset.worktreePath, "add", ".")
+		testGit(t, reset.worktreePath, "commit", "-q", "-m", "Add "+name)
+	}
+	resetTip := testGit(t, reset.worktreePath, "rev-parse", "HEAD")
+	testGit(t, repo, "worktree", "remove", "-f", reset.worktreePath)
+	testGit(t, repo, "branch", "-D", "session/reset")
+
+	// Checked out in the repository itself, where HEAD's reflog still reaches it
+	testGit(t, repo, "checkout", "-q", "-b", "in-place")
+	testGit(t, repo, "commit", "-q", "--allow-empty", "-m", "Work in place")
+	inPlaceTip := testGit(t, repo, "rev-parse", "HEAD")
+	testGit(t, repo, "checkout", "-q", "-")
+	testGit(t, repo, "branch", "-q", "-D", "in-place")
+
+	lost, err := FindLostWork(repo)
+	if err != nil {
+		t.Fatalf("FindLostWork() error: %v", err)
+	}
+	byCommit := make(map[string]LostWork)
+	for _, work := range lost {
+		byCommit[work.Commit] = work
+	}
+	if work, ok := byCommit[killedTip]; !ok || work.Branch != "session/killed" || work.Subject != "Add a" {
+		t.Errorf("killed branch: %+v, found %v", work, ok)
+	}
+	if work, ok := byCommit[resetTip]; !ok || work.Branch != "" || work.Subject != "Add c.go" {
+		t.Errorf("reset branch: %+v, found %v", work, ok)
+	}
+	if work, ok := byCommit[inPlaceTip]; !ok || work.Branch != "in-place" {
+		t.Errorf("branch checked out in place: %+v, found %v", work, ok)
+	}
+	if len(lost) != 3 {
+		t.Errorf("FindLostWork() = %+v, want the three branch tips", lost)
+	}
+
+	if err := RecoverBranch(repo, "session/killed", killedTip); err != nil {
+		t.Fatalf("RecoverBranch() error: %v", err)
+	}
+	if got := testGit(t, repo, "rev-parse", "session/killed"); got != killedTip {
+		t.Errorf("recovered branch at %s, want %s", got, killedTip)
+	}
+	if err := RecoverBranch(repo, "session/killed", resetTip); err == nil {
+		t.Error("RecoverBranch() over an existing branch succeeded")
+	}
+	if lost, _ := FindLostWork(repo); len(lost) != 2 {
+		t.Errorf("FindLostWork() after recovering = %+v, want the other two branch tips", lost)
+	}
+}
@@ -0,0 +1,54 @@
+package git
+
+import (
+	"claude-squad/command"
+	"claude-squad/network"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// webURL returns the web address of the repository at remote, a clone URL such as
+// git@github.com:owner/repo.git or https://github.com/owner/repo. It returns false for remotes
+// without one, such as local paths.
+func webURL(remote string) (string, bool) {
+	remote = strings.TrimSuffix(strings.TrimSpace(remote), ".git")
+	if host, path, ok := strings.Cut(strings.TrimPrefix(remote, "git@"), ":"); ok && strings.HasPrefix(remote, "git@") {
+		return "https://" + host + "/" + path, true
+	}
+	u, err := url.Parse(remote)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	switch u.Scheme {
+	case "https", "http", "ssh", "git":
+	default:
+		return "", false
+	}
+	return "https://" + u.Hostname() + u.Path, true
+}
+
+// BranchWebURL returns the web address of branch on the origin remote of the repository at
+// repoPath, such as https://github.com/owner/repo/tree/branch.
+func BranchWebURL(repoPath, branch string) (string, error) {
+	output, err := command.New(command.GitTimeout(), "git", "-C", repoPath, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the origin remote of %s: %w", repoPath, err)
+	}
+	base, ok := webURL(string(output))
+	if !ok {
+		return "", fmt.Errorf("origin remote of %s has no web address", repoPath)
+	}
+	return base + "/tree/" + branch, nil
+}
+
+// ProbeAddress returns the address the network monitor probes to know whether pushes from the
+// repository at repoPath can reach origin: the host of origin, or network.DefaultAddress when
+// there is no origin. It returns "" for an origin on a local path, which is never offline.
+func ProbeAddress(repoPath string) string {
+	output, err := runGit(repoPath, "remote", "get-url", "--push", baseRemote)
+	if err != nil {
+		return network.DefaultAddress
+	}
+	return network.RemoteAddress(output)
+}
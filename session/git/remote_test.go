@@ -0,0 +1,23 @@
+package git
+
+import "testing"
+
+func TestWebURL(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   string
+		ok     bool
+	}{
+		{remote: "git@github.com:owner/repo.git\n", want: "https://github.com/owner/repo", ok: true},
+		{remote: "https://github.com/owner/repo.git", want: "https://github.com/owner/repo", ok: true},
+		{remote: "ssh://git@gitlab.example.com:2222/group/repo.git", want: "https://gitlab.example.com/group/repo", ok: true},
+		{remote: "/srv/git/repo.git", ok: false},
+		{remote: "file:///srv/git/repo.git", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := webURL(tt.remote)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("webURL(%q) = %q, %v; want %q, %v", tt.remote, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RepoState describes the repository containing a path, for the states worktrees are created
+// differently in, or not at all.
+type RepoState struct {
+	// Root is the top level of the working tree, or the git directory of a bare repository.
+	Root string
+	// Bare is set for a repository without a working tree, such as the mirrors of build servers.
+	// Worktrees are created from it like from any other repository.
+	Bare bool
+	// Head is the branch HEAD points to, "" when it is detached.
+	Head string
+	// Unborn is set when the branch HEAD points to has no commits, as in a new repository.
+	Unborn bool
+	// Superproject is the working tree of the repository this one is a submodule of, if any.
+	Superproject string
+	// Operation is the rebase, merge, cherry-pick, revert or bisect in progress, if any.
+	Operation string
+}
+
+// operationFiles are the files of the git directory telling an operation is in progress.
+var operationFiles = []struct{ file, operation string }{
+	{"rebase-merge", "rebase"},
+	{"rebase-apply", "rebase"},
+	{"MERGE_HEAD", "merge"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+	{"BISECT_LOG", "bisect"},
+}
+
+// InspectRepo returns the state of the repository containing path. Its error, when path isn't in a
+// repository, tells what is.
+func InspectRepo(path string) (*RepoState, error) {
+	out, err := runGit(path, "rev-parse", "--is-bare-repository", "--absolute-git-dir")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not in a git repository: claude-squad runs in a clone, a bare "+
+			"repository or one of their worktrees, or in any directory with \"vcs\": \"dir\" in the config", path)
+	}
+	lines := splitLines(out)
+	if len(lines) != 2 {
+		return nil, fmt.Errorf("failed to inspect the repository of %s: unexpected output %q", path, out)
+	}
+	state := &RepoState{Bare: lines[0] == "true", Root: lines[1]}
+	gitDir := lines[1]
+
+	if !state.Bare {
+		root, err := runGit(path, "rev-parse", "--show-toplevel")
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the working tree of %s: %w", path, err)
+		}
+		state.Root = strings.TrimSpace(root)
+		if super, err := runGit(path, "rev-parse", "--show-superproject-working-tree"); err == nil {
+			state.Superproject = strings.TrimSpace(super)
+		}
+	}
+	// symbolic-ref fails when HEAD is detached
+	if head, err := runGit(path, "symbolic-ref", "--quiet", "HEAD"); err == nil {
+		state.Head = strings.TrimPrefix(strings.TrimSpace(head), "refs/heads/")
+	}
+	if _, err := runGit(path, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		state.Unborn = true
+	}
+	for _, f := range operationFiles {
+		if _, err := os.Stat(filepath.Join(gitDir, f.file)); err == nil {
+			state.Operation = f.operation
+			break
+		}
+	}
+	return state, nil
+}
+
+// Detached reports whether HEAD points to a commit rather than a branch.
+func (s *RepoState) Detached() bool {
+	return s.Head == ""
+}
+
+// CheckHead returns an error telling what to do if a worktree can't branch from HEAD, because the
+// branch it points to has no commits.
+func (s *RepoState) CheckHead() error {
+	if !s.Unborn {
+		return nil
+	}
+	if !s.Bare {
+		return fmt.Errorf("this appears to be a brand new repository: please create an initial commit before creating an instance")
+	}
+	branches, _ := runGit(s.Root, "for-each-ref", "--count=1", "--format=%(refname:short)", "refs/heads")
+	if branch := strings.TrimSpace(branches); branch != "" {
+		return fmt.Errorf("HEAD of the bare repository %s points to %s, which has no commits: point it to a "+
+			"branch with 'git --git-dir=%s symbolic-ref HEAD refs/heads/%s', or create the instance from a base branch",
+			s.Root, s.Head, s.Root, branch)
+	}
+	return fmt.Errorf("the bare repository %s has no commits: push a branch to it before creating an instance", s.Root)
+}
+
+// Describe returns what is unusual about the repository for a worktree created from HEAD, or ""
+// if nothing is.
+func (s *RepoState) Describe() string {
+	var notes []string
+	if s.Operation != "" {
+		notes = append(notes, fmt.Sprintf("%s is in the middle of a %s, so HEAD may not be a commit you want to branch from", s.Root, s.Operation))
+	} else if s.Detached() {
+		notes = append(notes, fmt.Sprintf("HEAD of %s is detached, so new worktrees branch from the commit it points to", s.Root))
+	}
+	if s.Superproject != "" {
+		notes = append(notes, fmt.Sprintf("%s is a submodule of %s, and worktrees are created from the submodule", s.Root, s.Superproject))
+	}
+	return strings.Join(notes, "; ")
+}
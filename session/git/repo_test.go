@@ -0,0 +1,76 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInspectRepo(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{"a.txt": "a\n"})
+
+	state, err := InspectRepo(repo)
+	if err != nil {
+		t.Fatalf("InspectRepo(): %v", err)
+	}
+	if state.Bare || state.Detached() || state.Unborn || state.Describe() != "" {
+		t.Errorf("state = %+v, want a plain repository on a branch", state)
+	}
+
+	testGit(t, repo, "checkout", "-q", "--detach", base)
+	if state, err = InspectRepo(repo); err != nil || !state.Detached() {
+		t.Errorf("state = %+v, %v, want detached", state, err)
+	}
+
+	bare := filepath.Join(filepath.Dir(repo), "bare.git")
+	testGit(t, filepath.Dir(repo), "clone", "-q", "--bare", repo, bare)
+	state, err = InspectRepo(filepath.Join(bare, "refs"))
+	if err != nil {
+		t.Fatalf("InspectRepo() in a bare repository: %v", err)
+	}
+	if !state.Bare || state.Root != bare {
+		t.Errorf("state = %+v, want the bare repository at %s", state, bare)
+	}
+
+	if _, err := InspectRepo(t.TempDir()); err == nil || !strings.Contains(err.Error(), "not in a git repository") {
+		t.Errorf("InspectRepo() outside of a repository = %v, want an error", err)
+	}
+}
+
+func TestCheckHeadBare(t *testing.T) {
+	repo, _ := newTestRepo(t, map[string]string{"a.txt": "a\n"})
+	bare := filepath.Join(filepath.Dir(repo), "bare.git")
+	testGit(t, filepath.Dir(repo), "clone", "-q", "--bare", repo, bare)
+	testGit(t, bare, "symbolic-ref", "HEAD", "refs/heads/missing")
+
+	state, err := InspectRepo(bare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := state.CheckHead(); err == nil || !strings.Contains(err.Error(), "symbolic-ref HEAD") {
+		t.Errorf("CheckHead() = %v, want an error telling how to fix HEAD", err)
+	}
+}
+
+func TestSetupFromBareRepo(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo, base := newTestRepo(t, map[string]string{"a.txt": "a\n"})
+	bare := filepath.Join(filepath.Dir(repo), "bare.git")
+	testGit(t, filepath.Dir(repo), "clone", "-q", "--bare", repo, bare)
+
+	w, _, err := NewGitWorktree(bare, "build")
+	if err != nil {
+		t.Fatalf("NewGitWorktree(): %v", err)
+	}
+	if err := w.Setup(); err != nil {
+		t.Fatalf("Setup(): %v", err)
+	}
+	defer w.Cleanup()
+	if w.GetBaseCommitSHA() != base {
+		t.Errorf("base = %s, want HEAD of the bare repository %s", w.GetBaseCommitSHA(), base)
+	}
+	if _, err := os.Stat(filepath.Join(w.GetWorktreePath(), "a.txt")); err != nil {
+		t.Errorf("worktree lacks the files of the bare repository: %v", err)
+	}
+}
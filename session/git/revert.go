@@ -0,0 +1,80 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CommitConflictError is returned when undoing a commit conflicts with the changes made after it.
+// The revert or rebase is aborted, leaving the worktree as it was.
+type CommitConflictError struct {
+	Commit string
+	// Files lists the files that conflicted.
+	Files []string
+}
+
+func (e *CommitConflictError) Error() string {
+	return fmt.Sprintf("undoing %s conflicts with later changes to %s", shortSHA(e.Commit), strings.Join(e.Files, ", "))
+}
+
+// RevertCommit adds a commit to the branch undoing sha, one of the commits Commits lists. The
+// worktree must have no uncommitted changes to tracked files. A conflict returns a
+// *CommitConflictError.
+func (g *GitWorktree) RevertCommit(sha string) error {
+	commit, err := g.undoableCommit(sha)
+	if err != nil {
+		return err
+	}
+	if _, err := runGit(g.worktreePath, "revert", "--no-edit", commit); err != nil {
+		return g.abortOnConflict(commit, err, "revert")
+	}
+	return nil
+}
+
+// DropCommit removes sha, one of the commits Commits lists, from the branch, rebasing the commits
+// made after it onto its parent. The worktree must have no uncommitted changes to tracked files. A
+// conflict returns a *CommitConflictError. The dropped commit stays in the reflog of the branch.
+func (g *GitWorktree) DropCommit(sha string) error {
+	commit, err := g.undoableCommit(sha)
+	if err != nil {
+		return err
+	}
+	if skipInDryRun("drop commit %s from %s", commit, g.branchName) {
+		return nil
+	}
+	if _, err := runGit(g.worktreePath, "rebase", "--quiet", "--onto", commit+"^", commit); err != nil {
+		return g.abortOnConflict(commit, err, "rebase")
+	}
+	return nil
+}
+
+// undoableCommit checks that sha is a commit of the branch and that the worktree has no changes
+// the revert or rebase could mix with its own, and returns the full hash of sha.
+func (g *GitWorktree) undoableCommit(sha string) (string, error) {
+	commit, err := g.branchCommit(sha)
+	if err != nil {
+		return "", err
+	}
+	// Untracked files are left alone by both
+	status, err := runGit(g.worktreePath, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return "", fmt.Errorf("failed to check worktree status: %w", err)
+	}
+	if strings.TrimSpace(status) != "" {
+		return "", fmt.Errorf("the worktree has uncommitted changes, commit or roll them back first")
+	}
+	return commit, nil
+}
+
+// abortOnConflict aborts the revert or rebase, command, that failed with err and returns a
+// *CommitConflictError listing the conflicted files, or err if nothing conflicted.
+func (g *GitWorktree) abortOnConflict(commit string, err error, command string) error {
+	conflicts, diffErr := runGit(g.worktreePath, "diff", "--name-only", "--diff-filter=U")
+	if _, abortErr := runGit(g.worktreePath, command, "--abort"); abortErr != nil && diffErr == nil && strings.TrimSpace(conflicts) != "" {
+		return fmt.Errorf("failed to abort %s after conflicts: %w", command, abortErr)
+	}
+	if diffErr != nil || strings.TrimSpace(conflicts) == "" {
+		return fmt.Errorf("failed to %s %s: %w", command, shortSHA(commit), err)
+	}
+	return &CommitConflictError{Commit: commit, Files: splitLines(conflicts)}
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUndoCommits(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	repo, base := newTestRepo(t, map[string]string{"a.txt": "1\n2\n3\n"})
+	w := newTestWorktree(t, repo, base, "undo")
+	commit := func(name, content, message string) string {
+		writeTestFile(t, filepath.Join(w.worktreePath, name), content)
+		testGit(t, w.worktreePath, "add", ".")
+		testGit(t, w.worktreePath, "commit", "-q", "-m", message)
+		return testGit(t, w.worktreePath, "rev-parse", "HEAD")
+	}
+	first := commit("a.txt", "one\n2\n3\n", "first")
+	second := commit("b.txt", "b\n", "second")
+	commit("a.txt", "uno\n2\n3\n", "third")
+
+	if err := w.RevertCommit(base); err == nil {
+		t.Error("RevertCommit() reverted the base commit")
+	}
+
+	// The third commit changed the same line as the first
+	head := testGit(t, w.worktreePath, "rev-parse", "HEAD")
+	var conflict *CommitConflictError
+	if err := w.DropCommit(first); !errors.As(err, &conflict) || !reflect.DeepEqual(conflict.Files, []string{"a.txt"}) {
+		t.Fatalf("DropCommit(first) = %v, want a conflict in a.txt", err)
+	}
+	if after := testGit(t, w.worktreePath, "rev-parse", "HEAD"); after != head {
+		t.Errorf("HEAD moved from %s to %s after an aborted drop", head, after)
+	}
+	if status := testGit(t, w.worktreePath, "status", "--porcelain"); status != "" {
+		t.Errorf("worktree not clean after an aborted drop:\n%s", status)
+	}
+
+	writeTestFile(t, filepath.Join(w.worktreePath, "a.txt"), "dirty\n")
+	if err := w.RevertCommit(second); err == nil {
+		t.Error("RevertCommit() ran with uncommitted changes")
+	}
+	testGit(t, w.worktreePath, "checkout", "--", "a.txt")
+
+	if err := w.DropCommit(second); err != nil {
+		t.Fatalf("DropCommit(second): %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(w.worktreePath, "b.txt")); !os.IsNotExist(err) {
+		t.Errorf("b.txt still exists after dropping the commit adding it: %v", err)
+	}
+	// The third commit was rebased, so it is HEAD rather than its old hash
+	if err := w.RevertCommit("HEAD"); err != nil {
+		t.Fatalf("RevertCommit(third): %v", err)
+	}
+	commits, err := w.Commits(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var subjects []string
+	for _, c := range commits {
+		subjects = append(subjects, c.Subject)
+	}
+	if want := []string{`Revert "third"`, "third", "first"}; !reflect.DeepEqual(subjects, want) {
+		t.Errorf("commits after revert and drop = %q, want %q", subjects, want)
+	}
+}
@@ -0,0 +1,222 @@
+package git
+
+import (
+	"claude-squad/config"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// secretRule is a regular expression of a secret in added lines.
+type secretRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// secretRules are the built-in rules of the push scan.
+var secretRules = []secretRule{
+	{"private key", regexp.MustCompile(`-----BEGIN ([A-Z]+ )*PRIVATE KEY( BLOCK)?-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"Anthropic API key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{"OpenAI API key", regexp.MustCompile(`\bsk-(proj-)?[A-Za-z0-9_-]{32,}`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"hardcoded credential", regexp.MustCompile(
+		`(?i)(api[_-]?key|secret|passw(or)?d|access[_-]?token|auth[_-]?token)["']?\s*[:=]\s*["'][^"'\s]{12,}["']`)},
+}
+
+// secretFileNames are base names of files that hold secrets, matched with path.Match.
+var secretFileNames = []string{".env", ".env.*", "*.pem", "*.key", "*.p12", "*.pfx", "id_rsa", "id_dsa",
+	"id_ecdsa", "id_ed25519", ".netrc", ".npmrc", ".pypirc", "credentials.json"}
+
+// secretFileExamples are .env style files that hold placeholders rather than secrets.
+var secretFileExamples = []string{".example", ".sample", ".template", ".dist"}
+
+// Finding is a file or line the push scan blocks.
+type Finding struct {
+	Path string `json:"path"`
+	// Line is the line of the finding in the new version of the file, 0 for the whole file.
+	Line int `json:"line,omitempty"`
+	// Rule names what was found, e.g. "AWS access key" or "large file".
+	Rule string `json:"rule"`
+	// Detail describes the finding. Secrets are redacted.
+	Detail string `json:"detail"`
+}
+
+func (f Finding) String() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s (%s)", f.Path, f.Line, f.Rule, f.Detail)
+	}
+	return fmt.Sprintf("%s: %s (%s)", f.Path, f.Rule, f.Detail)
+}
+
+// ScanError is returned by PushChanges when the scan finds large files or secrets in the changes.
+// Nothing is committed. PushChangesOverridingChecks pushes them anyway.
+type ScanError struct {
+	Findings []Finding
+}
+
+func (e *ScanError) Error() string {
+	lines := make([]string, 0, len(e.Findings))
+	for _, finding := range e.Findings {
+		lines = append(lines, finding.String())
+	}
+	return fmt.Sprintf("push blocked, %d findings: %s", len(e.Findings), strings.Join(lines, "; "))
+}
+
+// SetPushScan configures the scan run before the worktree's changes are pushed.
+func (g *GitWorktree) SetPushScan(scan config.PushScanConfig) {
+	g.pushScan = scan
+}
+
+// checkScan returns a *ScanError if the scan of the staged changes finds anything.
+func (g *GitWorktree) checkScan() error {
+	findings, err := g.scanStaged()
+	if err != nil {
+		return fmt.Errorf("failed to scan changes before push: %w", err)
+	}
+	if len(findings) > 0 {
+		return &ScanError{Findings: findings}
+	}
+	return nil
+}
+
+// scanStaged scans the changes between the base commit and the index, which is what a commit of
+// the staged changes would push.
+func (g *GitWorktree) scanStaged() ([]Finding, error) {
+	if !g.pushScan.Enabled {
+		return nil, nil
+	}
+	rules, err := compileSecretRules(g.pushScan.SecretPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	files, err := runGit(g.worktreePath, "diff", "--cached", "-z", "--name-only", "--diff-filter=ACMR", g.GetBaseCommitSHA())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	for _, file := range strings.Split(strings.Trim(files, "\x00"), "\x00") {
+		if file == "" {
+			continue
+		}
+		if isSecretFile(file) {
+			findings = append(findings, Finding{Path: file, Rule: "secret file", Detail: "files like this usually hold credentials"})
+		}
+		if g.pushScan.MaxFileSizeKB <= 0 {
+			continue
+		}
+		size, err := runGit(g.worktreePath, "cat-file", "-s", ":"+file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get size of %s: %w", file, err)
+		}
+		if bytes, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64); err == nil && bytes > g.pushScan.MaxFileSizeKB*1024 {
+			findings = append(findings, Finding{Path: file, Rule: "large file",
+				Detail: fmt.Sprintf("%d KB, limit %d KB", bytes/1024, g.pushScan.MaxFileSizeKB)})
+		}
+	}
+
+	diff, err := runGit(g.worktreePath, "diff", "--cached", "--no-color", "--no-ext-diff", "-U0", g.GetBaseCommitSHA())
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff staged changes: %w", err)
+	}
+	return append(findings, scanAddedLines(diff, rules)...), nil
+}
+
+// compileSecretRules returns the built-in rules followed by the rules of patterns.
+func compileSecretRules(patterns []string) ([]secretRule, error) {
+	rules := append([]secretRule{}, secretRules...)
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret pattern %q: %w", pattern, err)
+		}
+		rules = append(rules, secretRule{name: "secret pattern " + pattern, pattern: re})
+	}
+	return rules, nil
+}
+
+// ScanText matches each line of text, such as a prompt, against the built-in secret rules and
+// patterns. The findings have name as their path.
+func ScanText(name, text string, patterns []string) ([]Finding, error) {
+	rules, err := compileSecretRules(patterns)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for i, line := range strings.Split(text, "\n") {
+		for _, rule := range rules {
+			if match := rule.pattern.FindString(line); match != "" {
+				findings = append(findings, Finding{Path: name, Line: i + 1, Rule: rule.name, Detail: redact(match)})
+			}
+		}
+	}
+	return findings, nil
+}
+
+// isSecretFile returns true if the base name of file is one that holds secrets.
+func isSecretFile(file string) bool {
+	name := path.Base(file)
+	for _, suffix := range secretFileExamples {
+		if strings.HasSuffix(name, suffix) {
+			return false
+		}
+	}
+	for _, pattern := range secretFileNames {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// scanAddedLines matches the lines a zero-context diff adds against rules.
+func scanAddedLines(diff string, rules []secretRule) []Finding {
+	var findings []Finding
+	var file string
+	line, inHeader := 0, false
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			inHeader = true
+		case inHeader && strings.HasPrefix(text, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+		case strings.HasPrefix(text, "@@ "):
+			inHeader = false
+			line = newStartLine(text)
+		case !inHeader && strings.HasPrefix(text, "+") && file != "":
+			for _, rule := range rules {
+				if match := rule.pattern.FindString(text[1:]); match != "" {
+					findings = append(findings, Finding{Path: file, Line: line, Rule: rule.name, Detail: redact(match)})
+				}
+			}
+			line++
+		}
+	}
+	return findings
+}
+
+// newStartLine returns the first new line of a hunk header such as "@@ -12,3 +12,4 @@".
+func newStartLine(header string) int {
+	for _, field := range strings.Fields(header) {
+		if strings.HasPrefix(field, "+") {
+			start, _, _ := strings.Cut(field[1:], ",")
+			n, _ := strconv.Atoi(start)
+			return n
+		}
+	}
+	return 0
+}
+
+// redact keeps the first characters of a secret so it can be recognised without being shown.
+func redact(secret string) string {
+	runes := []rune(secret)
+	if len(runes) <= 8 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:6]) + strings.Repeat("*", min(len(runes)-6, 12))
+}
@@ -0,0 +1,156 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// snapshotRefPrefix namespaces snapshot refs so they show up neither as branches nor tags.
+	snapshotRefPrefix = "refs/claude-squad/snapshots/"
+	// maxSnapshots is the number of snapshots kept per worktree. Older ones are deleted.
+	maxSnapshots = 20
+)
+
+// snapshotIdentity is the author of snapshot commits, so snapshots work without a git identity.
+var snapshotIdentity = []string{
+	"GIT_AUTHOR_NAME=claude-squad", "GIT_AUTHOR_EMAIL=claude-squad@localhost",
+	"GIT_COMMITTER_NAME=claude-squad", "GIT_COMMITTER_EMAIL=claude-squad@localhost",
+}
+
+// Snapshot is a saved state of a worktree's files, including untracked ones.
+type Snapshot struct {
+	// Ref is the git ref that keeps the snapshot commit.
+	Ref string
+	// Commit is the snapshot commit. Its parent is the HEAD of the worktree when it was taken.
+	Commit  string
+	Message string
+	Time    time.Time
+}
+
+// snapshotRefs returns the ref prefix of this worktree's snapshots.
+func (g *GitWorktree) snapshotRefs() string {
+	return snapshotRefPrefix + sanitizeBranchName(g.sessionName) + "/"
+}
+
+// Snapshot saves the files of the worktree, tracked or not, without touching the worktree, the
+// index or the branch. Ignored files are left out.
+func (g *GitWorktree) Snapshot(message string) (*Snapshot, error) {
+	// Stage everything into a throwaway index so the real one is untouched
+	tmpDir, err := os.MkdirTemp("", "claude-squad-snapshot-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot index: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	env := append([]string{"GIT_INDEX_FILE=" + filepath.Join(tmpDir, "index")}, snapshotIdentity...)
+
+	if _, err := runGitEnv(g.worktreePath, env, "read-tree", "HEAD"); err != nil {
+		return nil, fmt.Errorf("failed to read HEAD into snapshot index: %w", err)
+	}
+	if _, err := runGitEnv(g.worktreePath, env, "add", "-A"); err != nil {
+		return nil, fmt.Errorf("failed to stage snapshot: %w", err)
+	}
+	tree, err := runGitEnv(g.worktreePath, env, "write-tree")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write snapshot tree: %w", err)
+	}
+	commit, err := runGitEnv(g.worktreePath, env, "commit-tree", strings.TrimSpace(tree), "-p", "HEAD", "-m", message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write snapshot commit: %w", err)
+	}
+
+	now := time.Now()
+	snapshot := &Snapshot{
+		Ref:     g.snapshotRefs() + strconv.FormatInt(now.UnixNano(), 10),
+		Commit:  strings.TrimSpace(commit),
+		Message: message,
+		Time:    now,
+	}
+	if _, err := runGit(g.worktreePath, "update-ref", snapshot.Ref, snapshot.Commit); err != nil {
+		return nil, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+
+	snapshots, err := g.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+	for _, old := range snapshots[min(len(snapshots), maxSnapshots):] {
+		if skipInDryRun("delete old snapshot %s", old.Ref) {
+			continue
+		}
+		if _, err := runGit(g.worktreePath, "update-ref", "-d", old.Ref); err != nil {
+			return nil, fmt.Errorf("failed to delete old snapshot: %w", err)
+		}
+	}
+	return snapshot, nil
+}
+
+// Snapshots returns the snapshots of the worktree, newest first.
+func (g *GitWorktree) Snapshots() ([]Snapshot, error) {
+	out, err := runGit(g.repoPath, "for-each-ref", "--sort=-refname",
+		"--format=%(refname)%00%(objectname)%00%(subject)", g.snapshotRefs())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, line := range splitLines(out) {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		nanos, err := strconv.ParseInt(strings.TrimPrefix(fields[0], g.snapshotRefs()), 10, 64)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{
+			Ref:     fields[0],
+			Commit:  fields[1],
+			Message: fields[2],
+			Time:    time.Unix(0, nanos),
+		})
+	}
+	return snapshots, nil
+}
+
+// Rollback restores the files of the worktree to snapshot: files are reverted, files created
+// since are deleted and files deleted since come back. Ignored files, the branch and its commits
+// are left alone, and files untracked in the snapshot are untracked again.
+func (g *GitWorktree) Rollback(snapshot Snapshot) error {
+	if skipInDryRun("roll %s back to snapshot %s", g.worktreePath, snapshot.Commit) {
+		return nil
+	}
+	// Track everything so read-tree knows which files to delete
+	if _, err := runGit(g.worktreePath, "add", "-A"); err != nil {
+		return fmt.Errorf("failed to stage worktree for rollback: %w", err)
+	}
+	if _, err := runGit(g.worktreePath, "read-tree", "--reset", "-u", snapshot.Commit); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+	// Back to an index matching HEAD, leaving the restored files as changes
+	if _, err := runGit(g.worktreePath, "reset", "-q"); err != nil {
+		return fmt.Errorf("failed to reset index after rollback: %w", err)
+	}
+	return nil
+}
+
+// DeleteSnapshots deletes all snapshots of the worktree.
+func (g *GitWorktree) DeleteSnapshots() error {
+	snapshots, err := g.Snapshots()
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		if skipInDryRun("delete snapshot %s", snapshot.Ref) {
+			continue
+		}
+		if _, err := runGit(g.repoPath, "update-ref", "-d", snapshot.Ref); err != nil {
+			return fmt.Errorf("failed to delete snapshot: %w", err)
+		}
+	}
+	return nil
+}
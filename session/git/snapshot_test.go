@@ -0,0 +1,94 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotRollback(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{
+		"keep.txt":    "original\n",
+		".gitignore":  "ignored.txt\n",
+		"deleted.txt": "deleted later\n",
+	})
+	w := newTestWorktree(t, repo, base, "snap")
+	path := func(name string) string { return filepath.Join(w.worktreePath, name) }
+
+	writeTestFile(t, path("keep.txt"), "edited\n")
+	writeTestFile(t, path("stray.txt"), "untracked\n")
+	snapshot, err := w.Snapshot("before risky prompt")
+	if err != nil {
+		t.Fatalf("Snapshot() error: %v", err)
+	}
+	if status := testGit(t, w.worktreePath, "status", "--porcelain"); status != "M keep.txt\n?? stray.txt" {
+		t.Errorf("Snapshot() changed the worktree status:\n%s", status)
+	}
+
+	// The agent goes off the rails
+	writeTestFile(t, path("keep.txt"), "mangled\n")
+	writeTestFile(t, path("new.txt"), "created after the snapshot\n")
+	writeTestFile(t, path("ignored.txt"), "ignored\n")
+	if err := os.Remove(path("deleted.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path("stray.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Rollback(*snapshot); err != nil {
+		t.Fatalf("Rollback() error: %v", err)
+	}
+	for name, want := range map[string]string{
+		"keep.txt":    "edited\n",
+		"stray.txt":   "untracked\n",
+		"deleted.txt": "deleted later\n",
+		"ignored.txt": "ignored\n",
+	} {
+		got, err := os.ReadFile(path(name))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := os.Stat(path("new.txt")); !os.IsNotExist(err) {
+		t.Errorf("file created after the snapshot survived the rollback: %v", err)
+	}
+	if status := testGit(t, w.worktreePath, "status", "--porcelain"); status != "M keep.txt\n?? stray.txt" {
+		t.Errorf("status after rollback:\n%s", status)
+	}
+	if head := testGit(t, w.worktreePath, "rev-parse", "HEAD"); head != base {
+		t.Errorf("Rollback() moved HEAD to %s", head)
+	}
+
+	snapshots, err := w.Snapshots()
+	if err != nil || len(snapshots) != 1 || snapshots[0].Message != "before risky prompt" {
+		t.Errorf("Snapshots() = %+v, %v", snapshots, err)
+	}
+	if err := w.DeleteSnapshots(); err != nil {
+		t.Fatalf("DeleteSnapshots() error: %v", err)
+	}
+	if snapshots, _ := w.Snapshots(); len(snapshots) != 0 {
+		t.Errorf("snapshots left after DeleteSnapshots(): %+v", snapshots)
+	}
+}
+
+func TestSnapshotPrunesOldest(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{"file.txt": "base\n"})
+	w := newTestWorktree(t, repo, base, "prune")
+
+	for i := 0; i < maxSnapshots+2; i++ {
+		if _, err := w.Snapshot("snapshot"); err != nil {
+			t.Fatalf("Snapshot() error: %v", err)
+		}
+	}
+	snapshots, err := w.Snapshots()
+	if err != nil {
+		t.Fatalf("Snapshots() error: %v", err)
+	}
+	if len(snapshots) != maxSnapshots {
+		t.Errorf("kept %d snapshots, want %d", len(snapshots), maxSnapshots)
+	}
+	if !snapshots[0].Time.After(snapshots[len(snapshots)-1].Time) {
+		t.Errorf("snapshots are not sorted newest first")
+	}
+}
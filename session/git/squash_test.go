@@ -0,0 +1,45 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSquash(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	repo, base := newTestRepo(t, map[string]string{"a.txt": "a\n"})
+	w := newTestWorktree(t, repo, base, "squash")
+
+	// Changes that cancel out leave nothing to squash, and the branch as it was
+	writeTestFile(t, filepath.Join(w.worktreePath, "a.txt"), "b\n")
+	testGit(t, w.worktreePath, "commit", "-q", "-am", "change")
+	writeTestFile(t, filepath.Join(w.worktreePath, "a.txt"), "a\n")
+	testGit(t, w.worktreePath, "commit", "-q", "-am", "change back")
+	head := testGit(t, w.worktreePath, "rev-parse", "HEAD")
+	if err := w.squash("nothing"); err == nil {
+		t.Error("squash() of no changes succeeded")
+	}
+	if after := testGit(t, w.worktreePath, "rev-parse", "HEAD"); after != head {
+		t.Errorf("HEAD = %s after a failed squash, want %s", after, head)
+	}
+
+	writeTestFile(t, filepath.Join(w.worktreePath, "b.txt"), "b\n")
+	testGit(t, w.worktreePath, "add", ".")
+	testGit(t, w.worktreePath, "commit", "-q", "-m", "add b")
+	writeTestFile(t, filepath.Join(w.worktreePath, "c.txt"), "c\n")
+	testGit(t, w.worktreePath, "add", "c.txt")
+	if err := w.squash("Add b and c\n\nIn one commit."); err != nil {
+		t.Fatalf("squash(): %v", err)
+	}
+	commits, err := w.Commits(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 || commits[0].Subject != "Add b and c" || commits[0].Body != "In one commit." ||
+		commits[0].Insertions != 2 {
+		t.Errorf("commits after squash = %+v, want one adding b and c", commits)
+	}
+}
@@ -0,0 +1,220 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxStatsCommits is the number of most recent commits ChangeStats follows.
+const maxStatsCommits = 200
+
+// Sources of a ChurnPoint.
+const (
+	ChurnCommit   = "commit"
+	ChurnSnapshot = "snapshot"
+	ChurnWorktree = "worktree"
+)
+
+// ChangeStats describes how the changes of a worktree grew since its base commit, step by step:
+// its commits, its snapshots and the uncommitted changes.
+type ChangeStats struct {
+	// Timeline has a point per step, oldest first.
+	Timeline []ChurnPoint `json:"timeline"`
+	// Files are the files changed by the steps, the most often changed first.
+	Files []FileChurn `json:"files"`
+	// FilesChanged counts Files, and TestFiles those that look like tests.
+	FilesChanged int `json:"files_changed"`
+	TestFiles    int `json:"test_files"`
+	// TestFileRatio is TestFiles over FilesChanged, 0 without changes.
+	TestFileRatio float64 `json:"test_file_ratio"`
+}
+
+// ChurnPoint is a step in the changes of a worktree.
+type ChurnPoint struct {
+	Time time.Time `json:"time"`
+	// Source is ChurnCommit, ChurnSnapshot or ChurnWorktree for the uncommitted changes now.
+	Source string `json:"source"`
+	// Commit is the commit or snapshot commit, empty for the uncommitted changes.
+	Commit string `json:"commit,omitempty"`
+	// Insertions and Deletions count the lines the step changed since the one before it.
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+	// Added and Removed count the lines changed since the base commit as of this step.
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// FileChurn is how often a file was changed by the steps of a worktree's changes.
+type FileChurn struct {
+	Path string `json:"path"`
+	// Changes is the number of steps that changed the file.
+	Changes    int  `json:"changes"`
+	Insertions int  `json:"insertions"`
+	Deletions  int  `json:"deletions"`
+	Test       bool `json:"test"`
+}
+
+// ChangeStats computes the statistics of the changes of the worktree since its base commit, from
+// its last maxStatsCommits commits, the snapshots taken on top of them and the uncommitted changes.
+// Binary files count as changed without lines.
+func (g *GitWorktree) ChangeStats() (*ChangeStats, error) {
+	base := g.GetBaseCommitSHA()
+	points, previous, err := g.churnSteps(base)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ChangeStats{Timeline: []ChurnPoint{}, Files: []FileChurn{}}
+	files := make(map[string]*FileChurn)
+	for _, point := range points {
+		step, err := g.numstat(previous, point.Commit)
+		if err != nil {
+			return nil, err
+		}
+		if len(step) == 0 && point.Source != ChurnCommit {
+			// Snapshots of unchanged files and a clean worktree add nothing
+			continue
+		}
+		total, err := g.numstat(base, point.Commit)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range step {
+			point.Insertions += change.Insertions
+			point.Deletions += change.Deletions
+			file, ok := files[change.Path]
+			if !ok {
+				file = &FileChurn{Path: change.Path, Test: isTestFile(change.Path)}
+				files[change.Path] = file
+			}
+			file.Changes++
+			file.Insertions += change.Insertions
+			file.Deletions += change.Deletions
+		}
+		for _, change := range total {
+			point.Added += change.Insertions
+			point.Removed += change.Deletions
+		}
+		stats.Timeline = append(stats.Timeline, point)
+		if point.Commit != "" {
+			previous = point.Commit
+		}
+	}
+
+	for _, file := range files {
+		stats.Files = append(stats.Files, *file)
+		if file.Test {
+			stats.TestFiles++
+		}
+	}
+	sort.Slice(stats.Files, func(i, j int) bool {
+		a, b := stats.Files[i], stats.Files[j]
+		if a.Changes != b.Changes {
+			return a.Changes > b.Changes
+		}
+		if a.Insertions+a.Deletions != b.Insertions+b.Deletions {
+			return a.Insertions+a.Deletions > b.Insertions+b.Deletions
+		}
+		return a.Path < b.Path
+	})
+	stats.FilesChanged = len(stats.Files)
+	if stats.FilesChanged > 0 {
+		stats.TestFileRatio = float64(stats.TestFiles) / float64(stats.FilesChanged)
+	}
+	return stats, nil
+}
+
+// churnSteps returns the last commits since base and the snapshots taken on top of them, oldest
+// first, followed by the uncommitted changes, and the commit the first step starts from.
+func (g *GitWorktree) churnSteps(base string) ([]ChurnPoint, string, error) {
+	out, err := runGit(g.worktreePath, "log", "--reverse", fmt.Sprintf("--max-count=%d", maxStatsCommits),
+		"--first-parent", "--format=%H %ct", base+"..HEAD")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list commits: %w", err)
+	}
+	var points []ChurnPoint
+	for _, line := range splitLines(out) {
+		sha, seconds, _ := strings.Cut(line, " ")
+		unix, _ := strconv.ParseInt(seconds, 10, 64)
+		points = append(points, ChurnPoint{Time: time.Unix(unix, 0), Source: ChurnCommit, Commit: sha})
+	}
+	// Steps start after the commits made before the ones followed
+	start := base
+	if len(points) == maxStatsCommits {
+		start = points[0].Commit + "^"
+	}
+
+	snapshots, err := g.Snapshots()
+	if err != nil {
+		return nil, "", err
+	}
+	for _, snapshot := range snapshots {
+		// Snapshots taken before the first commit followed, or on another branch, aren't steps
+		if _, err := runGit(g.worktreePath, "merge-base", "--is-ancestor", start, snapshot.Commit+"^"); err != nil {
+			continue
+		}
+		if _, err := runGit(g.worktreePath, "merge-base", "--is-ancestor", snapshot.Commit+"^", "HEAD"); err != nil {
+			continue
+		}
+		points = append(points, ChurnPoint{Time: snapshot.Time, Source: ChurnSnapshot, Commit: snapshot.Commit})
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+
+	// -N includes the new files of the uncommitted changes, as in Diff
+	if err := g.addIntentToAdd(); err != nil {
+		return nil, "", err
+	}
+	return append(points, ChurnPoint{Time: time.Now(), Source: ChurnWorktree}), start, nil
+}
+
+// numstat returns the lines changed by each file from commit from to commit to, or to the files
+// in the worktree if to is empty.
+func (g *GitWorktree) numstat(from, to string) ([]FileChurn, error) {
+	args := []string{"diff", "--numstat", "-z", "--no-renames", "--no-ext-diff", "--no-relative", from}
+	if to != "" {
+		args = append(args, to)
+	}
+	out, err := runGit(g.worktreePath, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count changes: %w", err)
+	}
+	var changes []FileChurn
+	for _, record := range strings.Split(out, "\x00") {
+		fields := strings.SplitN(record, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		// Binary files are counted as "-"
+		insertions, _ := strconv.Atoi(fields[0])
+		deletions, _ := strconv.Atoi(fields[1])
+		changes = append(changes, FileChurn{Path: fields[2], Insertions: insertions, Deletions: deletions})
+	}
+	return changes, nil
+}
+
+// isTestFile reports whether the file at p looks like a test, by the naming conventions of
+// common languages: foo_test.go, test_foo.py, foo.test.ts, foo.spec.js, FooTest.java, or a file
+// in a test directory.
+func isTestFile(p string) bool {
+	name := path.Base(p)
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	if strings.HasSuffix(stem, "Test") || strings.HasSuffix(stem, "Tests") {
+		return true
+	}
+	stem = strings.ToLower(stem)
+	if strings.HasSuffix(stem, "_test") || strings.HasSuffix(stem, ".test") || strings.HasSuffix(stem, ".spec") ||
+		strings.HasPrefix(stem, "test_") {
+		return true
+	}
+	for _, dir := range strings.Split(strings.ToLower(path.Dir(p)), "/") {
+		switch dir {
+		case "test", "tests", "__tests__", "spec", "testdata":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestChangeStats(t *testing.T) {
+	repo, base := newTestRepo(t, map[string]string{"a.go": "a\n"})
+	w := newTestWorktree(t, repo, base, "stats")
+	writeTestFile(t, filepath.Join(w.worktreePath, "a.go"), "a\nb\n")
+	testGit(t, w.worktreePath, "commit", "-q", "-am", "one")
+	writeTestFile(t, filepath.Join(w.worktreePath, "a.go"), "a\nb\nc\n")
+	writeTestFile(t, filepath.Join(w.worktreePath, "a_test.go"), "t\n")
+	testGit(t, w.worktreePath, "add", ".")
+	testGit(t, w.worktreePath, "commit", "-q", "-m", "two")
+	// Uncommitted, replacing a line
+	writeTestFile(t, filepath.Join(w.worktreePath, "a.go"), "a\nB\nc\n")
+
+	stats, err := w.ChangeStats()
+	if err != nil {
+		t.Fatalf("ChangeStats(): %v", err)
+	}
+	if len(stats.Timeline) != 3 {
+		t.Fatalf("timeline = %+v, want two commits and the worktree", stats.Timeline)
+	}
+	last := stats.Timeline[2]
+	if last.Source != ChurnWorktree || last.Insertions != 1 || last.Deletions != 1 || last.Added != 3 || last.Removed != 0 {
+		t.Errorf("worktree point = %+v, want +1 -1 since the last commit and +3 since the base", last)
+	}
+	if stats.Timeline[0].Source != ChurnCommit || stats.Timeline[0].Insertions != 1 {
+		t.Errorf("first point = %+v, want the first commit adding a line", stats.Timeline[0])
+	}
+	if len(stats.Files) != 2 || stats.Files[0].Path != "a.go" || stats.Files[0].Changes != 3 {
+		t.Errorf("files = %+v, want a.go changed 3 times first", stats.Files)
+	}
+	if stats.TestFiles != 1 || stats.TestFileRatio != 0.5 {
+		t.Errorf("test files = %d, ratio %v, want 1 and 0.5", stats.TestFiles, stats.TestFileRatio)
+	}
+}
+
+func TestIsTestFile(t *testing.T) {
+	for p, want := range map[string]bool{
+		"session/git/stats_test.go": true,
+		"tests/helpers.py":          true,
+		"src/app.spec.ts":           true,
+		"src/FooTest.java":          true,
+		"test_views.py":             true,
+		"contest.go":                false,
+		"latest.txt":                false,
+		"session/git/stats.go":      false,
+	} {
+		if got := isTestFile(p); got != want {
+			t.Errorf("isTestFile(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package git
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// submodules configures the submodules of new worktrees, see SetSubmodules.
+var submodules atomic.Pointer[config.SubmodulesConfig]
+
+// SetSubmodules sets whether new worktrees check out their submodules, and how.
+func SetSubmodules(cfg config.SubmodulesConfig) {
+	submodules.Store(&cfg)
+}
+
+// Submodule is the state of a submodule of a worktree that needs attention.
+type Submodule struct {
+	Path string `json:"path"`
+	// Commit is the commit checked out, or the one the worktree records if it isn't checked out.
+	Commit string `json:"commit"`
+	// Initialized is false for a submodule that isn't checked out, which builds may need.
+	Initialized bool `json:"initialized"`
+	// Moved is set when the commit checked out isn't the one the worktree records.
+	Moved bool `json:"moved"`
+	// Modified is set when the submodule has uncommitted changes.
+	Modified bool `json:"modified"`
+}
+
+// updateSubmodules checks out the submodules of the worktree if SetSubmodules asked for it. A
+// failure is logged rather than returned, since the worktree works without them.
+func (g *GitWorktree) updateSubmodules() {
+	cfg := submodules.Load()
+	if cfg == nil || !cfg.Update {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(g.worktreePath, ".gitmodules")); err != nil {
+		return
+	}
+	args := []string{"submodule", "update", "--init"}
+	if cfg.Recursive {
+		args = append(args, "--recursive")
+	}
+	if cfg.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(cfg.Depth))
+	}
+	if _, err := runGit(g.worktreePath, args...); err != nil {
+		log.WarningLog.Printf("failed to check out the submodules of %s: %v", g.worktreePath, err)
+	}
+}
+
+// Submodules returns the submodules of the worktree that aren't checked out, are on another commit
+// than the one the worktree records, or have uncommitted changes.
+func (g *GitWorktree) Submodules() ([]Submodule, error) {
+	if _, err := os.Stat(filepath.Join(g.worktreePath, ".gitmodules")); err != nil {
+		return nil, nil
+	}
+	out, err := runGit(g.worktreePath, "submodule", "status")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submodule status: %w", err)
+	}
+	var changed []Submodule
+	// Lines are a state character, the commit, the path and, for checked out submodules, the
+	// description of the commit in parentheses. The state is a space for most, so lines aren't
+	// trimmed.
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		fields := strings.Fields(line[1:])
+		if len(fields) < 2 {
+			continue
+		}
+		s := Submodule{Path: fields[1], Commit: fields[0], Initialized: line[0] != '-', Moved: line[0] == '+'}
+		if s.Initialized {
+			status, err := runGit(filepath.Join(g.worktreePath, s.Path), "status", "--porcelain")
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the status of submodule %s: %w", s.Path, err)
+			}
+			s.Modified = strings.TrimSpace(status) != ""
+		}
+		if !s.Initialized || s.Moved || s.Modified {
+			changed = append(changed, s)
+		}
+	}
+	return changed, nil
+}
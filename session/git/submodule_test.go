@@ -0,0 +1,76 @@
+package git
+
+import (
+	"claude-squad/config"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupUpdatesSubmodules(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	// Submodules of local paths are cloned with the file protocol, which git refuses by default
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+	lib, _ := newTestRepo(t, map[string]string{"lib.go": "package lib\n"})
+	repo, _ := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	testGit(t, repo, "submodule", "add", "-q", lib, "lib")
+	testGit(t, repo, "commit", "-q", "-m", "add lib")
+
+	SetSubmodules(config.SubmodulesConfig{Update: true, Depth: 1})
+	defer SetSubmodules(config.SubmodulesConfig{})
+
+	w, _, err := NewGitWorktree(repo, "submodules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Setup(); err != nil {
+		t.Fatalf("Setup(): %v", err)
+	}
+	defer w.Cleanup()
+
+	changed, err := w.Submodules()
+	if err != nil {
+		t.Fatalf("Submodules(): %v", err)
+	}
+	if len(changed) != 0 {
+		t.Fatalf("submodules = %+v, want lib checked out and unchanged", changed)
+	}
+
+	writeTestFile(t, filepath.Join(w.GetWorktreePath(), "lib", "lib.go"), "package lib\n\nvar x = 1\n")
+	if changed, err = w.Submodules(); err != nil || len(changed) != 1 || !changed[0].Modified || changed[0].Path != "lib" {
+		t.Errorf("submodules = %+v, %v, want lib modified", changed, err)
+	}
+	if diff := w.Diff(); diff.Error != nil || diff.Added != 2 || !strings.Contains(diff.Content, "lib/lib.go") {
+		t.Errorf("diff = %+v, want the changes inside lib", diff)
+	}
+}
+
+func TestSubmodulesNotCheckedOut(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+	lib, _ := newTestRepo(t, map[string]string{"lib.go": "package lib\n"})
+	repo, _ := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	testGit(t, repo, "submodule", "add", "-q", lib, "lib")
+	testGit(t, repo, "commit", "-q", "-m", "add lib")
+
+	w, _, err := NewGitWorktree(repo, "plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Setup(); err != nil {
+		t.Fatalf("Setup(): %v", err)
+	}
+	defer w.Cleanup()
+
+	report, err := w.FileReport()
+	if err != nil {
+		t.Fatalf("FileReport(): %v", err)
+	}
+	if len(report.Submodules) != 1 || report.Submodules[0].Initialized {
+		t.Errorf("submodules = %+v, want lib not checked out", report.Submodules)
+	}
+}
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"claude-squad/command"
 	"fmt"
 	"os/exec"
 	"path/filepath"
@@ -29,12 +30,43 @@ func sanitizeBranchName(s string) string {
 	reDash := regexp.MustCompile(`-+`)
 	s = reDash.ReplaceAllString(s, "-")
 
-	// Trim leading and trailing dashes or slashes to avoid issues
-	s = strings.Trim(s, "-/")
+	// Drop what git refuses in ref names: empty path components, "..", components starting with a
+	// dot and components ending with ".lock"
+	s = regexp.MustCompile(`/+`).ReplaceAllString(s, "/")
+	s = regexp.MustCompile(`\.\.+`).ReplaceAllString(s, ".")
+	s = regexp.MustCompile(`(^|/)\.+`).ReplaceAllString(s, "$1")
+	s = regexp.MustCompile(`\.lock(/|$)`).ReplaceAllString(strings.TrimRight(s, "."), "$1")
+
+	// Trim leading and trailing dashes, slashes or dots to avoid issues
+	s = strings.Trim(s, "-/.")
 
 	return s
 }
 
+// BranchName returns the name of the branch created for the worktree of session sessionName.
+func BranchName(sessionName string) string {
+	return fmt.Sprintf("session/%s", sanitizeBranchName(sessionName))
+}
+
+// PrefixedBranchName returns the branch name for a session whose branches start with prefix
+// instead of session/, or BranchName when prefix is empty.
+func PrefixedBranchName(prefix, sessionName string) string {
+	if prefix == "" {
+		return BranchName(sessionName)
+	}
+	return prefix + sanitizeBranchName(sessionName)
+}
+
+// BranchNameFromTemplate returns the branch name made from template by replacing each {name} with
+// values[name], cut down to the characters sanitizeBranchName allows.
+func BranchNameFromTemplate(template string, values map[string]string) string {
+	pairs := make([]string, 0, 2*len(values))
+	for name, value := range values {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return sanitizeBranchName(strings.NewReplacer(pairs...).Replace(template))
+}
+
 // checkGHCLI checks if GitHub CLI is installed and configured
 func checkGHCLI() error {
 	// Check if gh is installed
@@ -43,7 +75,7 @@ func checkGHCLI() error {
 	}
 
 	// Check if gh is authenticated
-	cmd := exec.Command("gh", "auth", "status")
+	cmd := command.New(command.NetworkTimeout(), "gh", "auth", "status")
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("GitHub CLI is not configured. Please run 'gh auth login' first")
 	}
@@ -51,20 +83,20 @@ func checkGHCLI() error {
 	return nil
 }
 
-// IsGitRepo checks if the given path is within a git repository
+// IsGitRepo checks if the given path is within a git repository, a bare repository or one of their
+// worktrees. InspectRepo tells why it isn't.
 func IsGitRepo(path string) bool {
-	for {
-		_, err := git.PlainOpen(path)
-		if err == nil {
-			return true
-		}
+	_, err := InspectRepo(path)
+	return err == nil
+}
 
-		parent := filepath.Dir(path)
-		if parent == path {
-			return false
-		}
-		path = parent
+// RepoRoot returns the root of the git repository containing path, which may be relative.
+func RepoRoot(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
 	}
+	return findGitRepoRoot(absPath)
 }
 
 func findGitRepoRoot(path string) (string, error) {
@@ -55,6 +55,16 @@ func TestSanitizeBranchName(t *testing.T) {
 			input:    "",
 			expected: "",
 		},
+		{
+			name:     "string git refuses as a ref",
+			input:    "v1..2//.hidden/x.lock.",
+			expected: "v1.2/hidden/x",
+		},
+		{
+			name:     "string with colons and unicode",
+			input:    "修复: login",
+			expected: "login",
+		},
 		{
 			name:     "complex mixed case with special chars",
 			input:    "USER/Feature Branch!@#$%^&*()/v1.0",
@@ -71,3 +81,23 @@ func TestSanitizeBranchName(t *testing.T) {
 		})
 	}
 }
+
+func TestBranchNameFromTemplate(t *testing.T) {
+	got := BranchNameFromTemplate("session/{ticket}-{title}", map[string]string{"ticket": "ABC-123", "title": "Fix login"})
+	if want := "session/abc-123-fix-login"; got != want {
+		t.Errorf("BranchNameFromTemplate() = %q, want %q", got, want)
+	}
+	got = BranchNameFromTemplate("{ticket}/{title}", map[string]string{"ticket": "#42", "title": "docs"})
+	if want := "42/docs"; got != want {
+		t.Errorf("BranchNameFromTemplate() = %q, want %q", got, want)
+	}
+}
+
+func TestPrefixedBranchName(t *testing.T) {
+	if got, want := PrefixedBranchName("alice/", "Fix login"), "alice/fix-login"; got != want {
+		t.Errorf("PrefixedBranchName() = %q, want %q", got, want)
+	}
+	if got, want := PrefixedBranchName("", "Fix login"), BranchName("Fix login"); got != want {
+		t.Errorf("PrefixedBranchName() without a prefix = %q, want %q", got, want)
+	}
+}
@@ -29,6 +29,23 @@ type GitWorktree struct {
 	branchName string
 	// Base commit hash for the worktree
 	baseCommitSHA string
+	// baseRef is the revision a new worktree branches from. Empty means HEAD.
+	baseRef string
+	// existingBranch is set when the worktree checks out a branch it didn't create, which is
+	// kept when the worktree is cleaned up
+	existingBranch bool
+	// diffBase is what Diff compares the worktree with, see SetDiffBase
+	diffBase string
+	// addPolicy decides which new files are staged
+	addPolicy config.GitAddPolicy
+	// pushScan configures the scan for large files and secrets before a push
+	pushScan config.PushScanConfig
+	// complianceCommand must succeed in the worktree before a push
+	complianceCommand string
+	// checkpoints is what a push does with checkpoint commits, see SetCheckpointCommits
+	checkpoints string
+	// gitConfig holds the git settings of the worktree alone, see SetGitConfig
+	gitConfig map[string]string
 }
 
 func NewGitWorktreeFromStorage(repoPath string, worktreePath string, sessionName string, branchName string, baseCommitSHA string) *GitWorktree {
@@ -44,7 +61,7 @@ func NewGitWorktreeFromStorage(repoPath string, worktreePath string, sessionName
 // NewGitWorktree creates a new GitWorktree instance
 func NewGitWorktree(repoPath string, sessionName string) (tree *GitWorktree, branchname string, err error) {
 	sanitizedName := sanitizeBranchName(sessionName)
-	branchName := fmt.Sprintf("session/%s", sanitizedName)
+	branchName := BranchName(sessionName)
 
 	// Convert repoPath to absolute path
 	absPath, err := filepath.Abs(repoPath)
@@ -75,6 +92,37 @@ func NewGitWorktree(repoPath string, sessionName string) (tree *GitWorktree, bra
 	}, branchName, nil
 }
 
+// SetBaseRef sets the revision a new worktree branches from, such as the branch of the instance it
+// was forked from. It has no effect once the worktree has been set up.
+func (g *GitWorktree) SetBaseRef(ref string) {
+	g.baseRef = ref
+}
+
+// SetWorktreeDir creates the worktree in dir instead of the worktrees directory of the config. It
+// has no effect once the worktree has been set up.
+func (g *GitWorktree) SetWorktreeDir(dir string) {
+	g.worktreePath = filepath.Join(dir, filepath.Base(g.worktreePath))
+}
+
+// SetBranchName replaces the branch a new worktree is created on, such as one named after the
+// instance's ticket. It has no effect once the worktree has been set up.
+func (g *GitWorktree) SetBranchName(name string) {
+	g.branchName = name
+}
+
+// SetExistingBranch makes the worktree check out name, an existing branch of the repository or of
+// one of its remotes, instead of creating a branch. The branch isn't deleted by Cleanup. It has no
+// effect once the worktree has been set up.
+func (g *GitWorktree) SetExistingBranch(name string) {
+	g.branchName = name
+	g.existingBranch = true
+}
+
+// ExistingBranch returns true if the worktree checks out a branch it didn't create.
+func (g *GitWorktree) ExistingBranch() bool {
+	return g.existingBranch
+}
+
 // GetWorktreePath returns the path to the worktree
 func (g *GitWorktree) GetWorktreePath() string {
 	return g.worktreePath
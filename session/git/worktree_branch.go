@@ -3,6 +3,7 @@ package git
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
@@ -10,6 +11,9 @@ import (
 
 // cleanupExistingBranch performs a thorough cleanup of any existing branch or reference
 func (g *GitWorktree) cleanupExistingBranch(repo *git.Repository) error {
+	if skipInDryRun("delete stale references of branch %s", g.branchName) {
+		return nil
+	}
 	branchRef := plumbing.NewBranchReferenceName(g.branchName)
 
 	// Try to remove the branch reference
@@ -58,3 +62,41 @@ func (g *GitWorktree) combineErrors(errs []error) error {
 	}
 	return errors.New(errMsg)
 }
+
+// findRemoteBranch returns the remote-tracking branch, such as origin/fix-login, of the one
+// remote that has branch. Fetch the remote first to find branches pushed since.
+func findRemoteBranch(repoPath, branch string) (string, error) {
+	out, err := runGit(repoPath, "for-each-ref", "--format=%(refname:short)", "refs/remotes/*/"+branch)
+	if err != nil {
+		return "", fmt.Errorf("failed to look for branch %s on remotes: %w", branch, err)
+	}
+	remoteBranches := strings.Fields(out)
+	switch len(remoteBranches) {
+	case 0:
+		return "", fmt.Errorf("branch %s not found locally or on a remote, fetch it first", branch)
+	case 1:
+		return remoteBranches[0], nil
+	default:
+		return "", fmt.Errorf("branch %s is on several remotes: %s", branch, strings.Join(remoteBranches, ", "))
+	}
+}
+
+// CheckExistingBranch returns an error unless branch is a branch of the repository containing
+// path, or of exactly one of its remotes, so a worktree can check it out.
+func CheckExistingBranch(path, branch string) error {
+	if branch == "" {
+		return fmt.Errorf("branch name cannot be empty")
+	}
+	repoPath, err := findGitRepoRoot(path)
+	if err != nil {
+		return err
+	}
+	if _, err := runGit(repoPath, "check-ref-format", "--branch", branch); err != nil || strings.HasPrefix(branch, "-") {
+		return fmt.Errorf("invalid branch name %q", branch)
+	}
+	if _, err := runGit(repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		return nil
+	}
+	_, err = findRemoteBranch(repoPath, branch)
+	return err
+}
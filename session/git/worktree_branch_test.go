@@ -0,0 +1,48 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSetupExistingBranch(t *testing.T) {
+	origin, base := newTestRepo(t, map[string]string{"main.go": "package main\n"})
+	testGit(t, origin, "checkout", "-q", "-b", "pr/review-me")
+	testGit(t, origin, "commit", "-q", "--allow-empty", "-m", "Colleague's work")
+	prTip := testGit(t, origin, "rev-parse", "HEAD")
+	testGit(t, origin, "checkout", "-q", "-")
+
+	repo := filepath.Join(filepath.Dir(origin), "clone")
+	testGit(t, filepath.Dir(origin), "clone", "-q", origin, repo)
+	testGit(t, repo, "branch", "-q", "local", base)
+
+	if err := CheckExistingBranch(repo, "missing"); err == nil {
+		t.Error("CheckExistingBranch() of a missing branch succeeded")
+	}
+	if err := CheckExistingBranch(repo, "bad..name"); err == nil {
+		t.Error("CheckExistingBranch() of an invalid name succeeded")
+	}
+
+	for _, tc := range []struct{ branch, tip string }{{"local", base}, {"pr/review-me", prTip}} {
+		if err := CheckExistingBranch(repo, tc.branch); err != nil {
+			t.Fatalf("CheckExistingBranch(%s) error: %v", tc.branch, err)
+		}
+		w := NewGitWorktreeFromStorage(repo, filepath.Join(filepath.Dir(repo), "wt-"+filepath.Base(tc.branch)), tc.branch, "", "")
+		w.SetExistingBranch(tc.branch)
+		if err := w.Setup(); err != nil {
+			t.Fatalf("Setup() on %s error: %v", tc.branch, err)
+		}
+		if head := testGit(t, w.worktreePath, "rev-parse", "HEAD"); head != tc.tip || w.GetBaseCommitSHA() != tc.tip {
+			t.Errorf("%s: worktree at %s with base %s, want both at %s", tc.branch, head, w.GetBaseCommitSHA(), tc.tip)
+		}
+		if got := testGit(t, w.worktreePath, "rev-parse", "--abbrev-ref", "HEAD"); got != tc.branch {
+			t.Errorf("worktree on %s, want %s", got, tc.branch)
+		}
+		if err := w.Cleanup(); err != nil {
+			t.Fatalf("Cleanup() error: %v", err)
+		}
+		if got := testGit(t, repo, "rev-parse", "refs/heads/"+tc.branch); got != tc.tip {
+			t.Errorf("branch %s at %s after Cleanup(), want it kept at %s", tc.branch, got, tc.tip)
+		}
+	}
+}
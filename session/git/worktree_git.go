@@ -1,16 +1,20 @@
 package git
 
 import (
+	"claude-squad/command"
 	"claude-squad/log"
+	"claude-squad/tracing"
+	"context"
 	"fmt"
-	"os/exec"
 	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // runGitCommand executes a git command and returns any error
 func (g *GitWorktree) runGitCommand(path string, args ...string) (string, error) {
 	baseArgs := []string{"-C", path}
-	cmd := exec.Command("git", append(baseArgs, args...)...)
+	cmd := command.New(gitTimeout(args), "git", append(baseArgs, args...)...)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -20,8 +24,41 @@ func (g *GitWorktree) runGitCommand(path string, args ...string) (string, error)
 	return string(output), nil
 }
 
-// PushChanges commits and pushes changes in the worktree to the remote branch
+// PushChanges commits and pushes changes in the worktree to the remote branch, rewriting the
+// checkpoint commits not pushed yet as set by SetCheckpointCommits. It returns a *ScanError
+// without committing if the push scan finds large files or secrets, and a *ComplianceError if the
+// compliance command fails.
 func (g *GitWorktree) PushChanges(commitMessage string, open bool) error {
+	_, span := tracing.Start(context.Background(), "git.push", attribute.String("git.branch", g.branchName))
+	return tracing.End(span, g.pushChanges(commitMessage, open, true, false))
+}
+
+// PushChangesOverridingChecks is PushChanges without the scan for large files and secrets and
+// without the compliance check.
+func (g *GitWorktree) PushChangesOverridingChecks(commitMessage string, open bool) error {
+	_, span := tracing.Start(context.Background(), "git.push",
+		attribute.String("git.branch", g.branchName), attribute.Bool("git.checks_overridden", true))
+	return tracing.End(span, g.pushChanges(commitMessage, open, false, false))
+}
+
+// PushSquashed is PushChanges squashing the commits of the branch since its base commit and the
+// uncommitted changes into a single commit with commitMessage. The branch is force pushed, in
+// case its commits were pushed before. In dry run mode, it only logs what it would do.
+func (g *GitWorktree) PushSquashed(commitMessage string, open bool) error {
+	_, span := tracing.Start(context.Background(), "git.push",
+		attribute.String("git.branch", g.branchName), attribute.Bool("git.squash", true))
+	return tracing.End(span, g.pushChanges(commitMessage, open, true, true))
+}
+
+// PushSquashedOverridingChecks is PushSquashed without the scan for large files and secrets and
+// without the compliance check.
+func (g *GitWorktree) PushSquashedOverridingChecks(commitMessage string, open bool) error {
+	_, span := tracing.Start(context.Background(), "git.push", attribute.String("git.branch", g.branchName),
+		attribute.Bool("git.squash", true), attribute.Bool("git.checks_overridden", true))
+	return tracing.End(span, g.pushChanges(commitMessage, open, false, true))
+}
+
+func (g *GitWorktree) pushChanges(commitMessage string, open bool, check bool, squash bool) error {
 	if err := checkGHCLI(); err != nil {
 		return err
 	}
@@ -32,39 +69,68 @@ func (g *GitWorktree) PushChanges(commitMessage string, open bool) error {
 		return fmt.Errorf("failed to check for changes: %w", err)
 	}
 
+	staged := false
 	if isDirty {
-		// Stage all changes
-		if _, err := g.runGitCommand(g.worktreePath, "add", "."); err != nil {
+		// Stage changes, leaving out new files the add policy excludes
+		if staged, err = g.stageChanges(); err != nil {
 			log.ErrorLog.Print(err)
 			return fmt.Errorf("failed to stage changes: %w", err)
 		}
+	}
+
+	if files, err := g.NotInLFS(); err != nil {
+		log.WarningLog.Print(err)
+	} else if len(files) > 0 {
+		log.WarningLog.Printf("pushing %s with files .gitattributes tracks with Git LFS stored in git: %s", g.branchName, strings.Join(files, ", "))
+	}
+
+	// Check everything the push publishes: earlier commits and the staged changes
+	if check {
+		if err := g.checkScan(); err != nil {
+			return err
+		}
+		if err := g.checkCompliance(); err != nil {
+			return err
+		}
+	}
 
-		// Create commit
+	if squash {
+		if skipInDryRun("squash the commits of %s into one and force push it", g.branchName) {
+			return nil
+		}
+		if err := g.squash(commitMessage); err != nil {
+			return err
+		}
+		return g.forcePush(open)
+	}
+
+	// Create commit, unless the only changes were excluded new files
+	if staged {
 		if _, err := g.runGitCommand(g.worktreePath, "commit", "-m", commitMessage, "--no-verify"); err != nil {
 			log.ErrorLog.Print(err)
 			return fmt.Errorf("failed to commit changes: %w", err)
 		}
 	}
+	if err := g.rewriteCheckpoints(); err != nil {
+		return err
+	}
+	if err := g.pushLFS(); err != nil {
+		return err
+	}
 
 	// First push the branch to remote to ensure it exists
-	pushCmd := exec.Command("gh", "repo", "sync", "--source", "-b", g.branchName)
-	pushCmd.Dir = g.worktreePath
-	if err := pushCmd.Run(); err != nil {
+	if _, err := runPush(g.worktreePath, "gh", "repo", "sync", "--source", "-b", g.branchName); err != nil {
 		// If sync fails, try creating the branch on remote first
-		gitPushCmd := exec.Command("git", "push", "-u", "origin", g.branchName)
-		gitPushCmd.Dir = g.worktreePath
-		if pushOutput, pushErr := gitPushCmd.CombinedOutput(); pushErr != nil {
+		if pushErr := Push(g.worktreePath, "-u", "origin", g.branchName); pushErr != nil {
 			log.ErrorLog.Print(pushErr)
-			return fmt.Errorf("failed to push branch: %s (%w)", pushOutput, pushErr)
+			return fmt.Errorf("failed to push branch: %w", pushErr)
 		}
 	}
 
 	// Now sync with remote
-	syncCmd := exec.Command("gh", "repo", "sync", "-b", g.branchName)
-	syncCmd.Dir = g.worktreePath
-	if output, err := syncCmd.CombinedOutput(); err != nil {
+	if _, err := runPush(g.worktreePath, "gh", "repo", "sync", "-b", g.branchName); err != nil {
 		log.ErrorLog.Print(err)
-		return fmt.Errorf("failed to sync changes: %s (%w)", output, err)
+		return fmt.Errorf("failed to sync changes: %w", err)
 	}
 
 	// Open the branch in the browser
@@ -76,6 +142,68 @@ func (g *GitWorktree) PushChanges(commitMessage string, open bool) error {
 	return nil
 }
 
+// CommitChanges commits the changes in the worktree with message, leaving out the new files the
+// add policy excludes, without pushing them. It does nothing if there is nothing to commit.
+func (g *GitWorktree) CommitChanges(message string) error {
+	staged, err := g.stageChanges()
+	if err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	if !staged {
+		return nil
+	}
+	if _, err := g.runGitCommand(g.worktreePath, "commit", "-m", message, "--no-verify"); err != nil {
+		log.ErrorLog.Print(err)
+		return fmt.Errorf("failed to commit changes: %w", err)
+	}
+	return nil
+}
+
+// squash replaces the commits of the branch since its base commit, and the staged changes, with a
+// single commit with message. The branch is left as it was if that fails.
+func (g *GitWorktree) squash(message string) error {
+	head, err := runGit(g.worktreePath, "rev-parse", "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if _, err := g.runGitCommand(g.worktreePath, "reset", "--soft", g.GetBaseCommitSHA()); err != nil {
+		return fmt.Errorf("failed to squash commits: %w", err)
+	}
+	restore := func(err error) error {
+		if _, resetErr := g.runGitCommand(g.worktreePath, "reset", "--soft", strings.TrimSpace(head)); resetErr != nil {
+			log.ErrorLog.Printf("failed to restore %s after a failed squash: %v", g.branchName, resetErr)
+		}
+		return err
+	}
+	if _, err := runGit(g.worktreePath, "diff", "--cached", "--quiet"); err == nil {
+		return restore(fmt.Errorf("no changes to squash since the base commit"))
+	}
+	if _, err := g.runGitCommand(g.worktreePath, "commit", "-m", message, "--no-verify"); err != nil {
+		log.ErrorLog.Print(err)
+		return restore(fmt.Errorf("failed to commit squashed changes: %w", err))
+	}
+	return nil
+}
+
+// forcePush pushes the branch to origin, replacing the commits pushed before if it was rewritten,
+// unless someone else pushed to it since it was last fetched.
+func (g *GitWorktree) forcePush(open bool) error {
+	if err := g.pushLFS(); err != nil {
+		return err
+	}
+	if err := Push(g.worktreePath, "--force-with-lease", "-u", "origin", g.branchName); err != nil {
+		log.ErrorLog.Print(err)
+		return fmt.Errorf("failed to push branch: %w", err)
+	}
+	if !open {
+		return nil
+	}
+	if err := g.OpenBranchURL(); err != nil {
+		log.ErrorLog.Printf("failed to open branch URL: %v", err)
+	}
+	return nil
+}
+
 // IsDirty checks if the worktree has uncommitted changes
 func (g *GitWorktree) IsDirty() (bool, error) {
 	output, err := g.runGitCommand(g.worktreePath, "status", "--porcelain")
@@ -101,7 +229,7 @@ func (g *GitWorktree) OpenBranchURL() error {
 		return err
 	}
 
-	cmd := exec.Command("gh", "browse", "--branch", g.branchName)
+	cmd := command.New(command.NetworkTimeout(), "gh", "browse", "--branch", g.branchName)
 	cmd.Dir = g.worktreePath
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to open branch URL: %w", err)
@@ -1,10 +1,10 @@
 package git
 
 import (
+	"claude-squad/command"
 	"claude-squad/log"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -21,7 +21,7 @@ func (g *GitWorktree) Setup() error {
 	}
 
 	branchRef := plumbing.NewBranchReferenceName(g.branchName)
-	if _, err := repo.Reference(branchRef, false); err == nil {
+	if _, err := repo.Reference(branchRef, false); err == nil || g.existingBranch {
 		// Branch exists, use SetupFromExistingBranch
 		return g.SetupFromExistingBranch()
 	}
@@ -41,11 +41,33 @@ func (g *GitWorktree) SetupFromExistingBranch() error {
 	// Clean up any existing worktree first
 	_, _ = g.runGitCommand(g.repoPath, "worktree", "remove", "-f", g.worktreePath) // Ignore error if worktree doesn't exist
 
-	// Create a new worktree from the existing branch
-	if _, err := g.runGitCommand(g.repoPath, "worktree", "add", g.worktreePath, g.branchName); err != nil {
+	// Create a new worktree from the existing branch, or a local branch tracking the remote one
+	args := []string{"worktree", "add", g.worktreePath, g.branchName}
+	if _, err := runGit(g.repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+g.branchName); err != nil {
+		remoteBranch, err := findRemoteBranch(g.repoPath, g.branchName)
+		if err != nil {
+			return err
+		}
+		args = []string{"worktree", "add", "--track", "-b", g.branchName, g.worktreePath, remoteBranch}
+	}
+	if _, err := runGitEnv(g.repoPath, lfsSkipSmudge, args...); err != nil {
 		return fmt.Errorf("failed to create worktree from branch %s: %w", g.branchName, err)
 	}
 
+	// Changes are shown from where the branch was when the worktree was created
+	if g.baseCommitSHA == "" {
+		head, err := runGit(g.worktreePath, "rev-parse", "HEAD")
+		if err != nil {
+			return fmt.Errorf("failed to get HEAD commit hash: %w", err)
+		}
+		g.baseCommitSHA = strings.TrimSpace(head)
+	}
+
+	if err := g.applyGitConfig(); err != nil {
+		return err
+	}
+	g.setupLFS()
+	g.updateSubmodules()
 	return nil
 }
 
@@ -71,7 +93,21 @@ func (g *GitWorktree) SetupNewWorktree() error {
 		return fmt.Errorf("failed to cleanup existing branch: %w", err)
 	}
 
-	output, err := g.runGitCommand(g.repoPath, "rev-parse", "HEAD")
+	baseRef := "HEAD"
+	if g.baseRef != "" {
+		baseRef = g.baseRef
+	} else if state, err := InspectRepo(g.repoPath); err == nil {
+		if err := state.CheckHead(); err != nil {
+			return err
+		}
+		if note := state.Describe(); note != "" {
+			log.WarningLog.Printf("creating worktree for %s: %s", g.sessionName, note)
+		}
+	}
+	output, err := g.runGitCommand(g.repoPath, "rev-parse", baseRef)
+	if err != nil && g.baseRef != "" {
+		return fmt.Errorf("failed to resolve base %s: %w", g.baseRef, err)
+	}
 	if err != nil {
 		if strings.Contains(err.Error(), "fatal: ambiguous argument 'HEAD'") ||
 			strings.Contains(err.Error(), "fatal: not a valid object name") ||
@@ -87,15 +123,24 @@ func (g *GitWorktree) SetupNewWorktree() error {
 	// Otherwise, we'll inherit uncommitted changes from the previous worktree.
 	// This way, we can start the worktree with a clean slate.
 	// TODO: we might want to give an option to use main/master instead of the current branch.
-	if _, err := g.runGitCommand(g.repoPath, "worktree", "add", "-b", g.branchName, g.worktreePath, headCommit); err != nil {
+	if _, err := runGitEnv(g.repoPath, lfsSkipSmudge, "worktree", "add", "-b", g.branchName, g.worktreePath, headCommit); err != nil {
 		return fmt.Errorf("failed to create worktree from commit %s: %w", headCommit, err)
 	}
 
+	if err := g.applyGitConfig(); err != nil {
+		return err
+	}
+	g.setupLFS()
+	g.updateSubmodules()
 	return nil
 }
 
 // Cleanup removes the worktree and associated branch
 func (g *GitWorktree) Cleanup() error {
+	if skipInDryRun("remove worktree %s and delete branch %s and its snapshots", g.worktreePath, g.branchName) {
+		return nil
+	}
+
 	var errs []error
 
 	// Check if worktree path exists before attempting removal
@@ -118,13 +163,19 @@ func (g *GitWorktree) Cleanup() error {
 
 	branchRef := plumbing.NewBranchReferenceName(g.branchName)
 
-	// Check if branch exists before attempting removal
-	if _, err := repo.Reference(branchRef, false); err == nil {
-		if err := repo.Storer.RemoveReference(branchRef); err != nil {
-			errs = append(errs, fmt.Errorf("failed to remove branch %s: %w", g.branchName, err))
+	// Check if branch exists before attempting removal. A branch the worktree didn't create is kept.
+	if !g.existingBranch {
+		if _, err := repo.Reference(branchRef, false); err == nil {
+			if err := repo.Storer.RemoveReference(branchRef); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove branch %s: %w", g.branchName, err))
+			}
+		} else if err != plumbing.ErrReferenceNotFound {
+			errs = append(errs, fmt.Errorf("error checking branch %s existence: %w", g.branchName, err))
 		}
-	} else if err != plumbing.ErrReferenceNotFound {
-		errs = append(errs, fmt.Errorf("error checking branch %s existence: %w", g.branchName, err))
+	}
+
+	if err := g.DeleteSnapshots(); err != nil {
+		errs = append(errs, err)
 	}
 
 	// Prune the worktree to clean up any remaining references
@@ -170,7 +221,7 @@ func CleanupWorktrees() error {
 	}
 
 	// Get a list of all branches associated with worktrees
-	cmd := exec.Command("git", "worktree", "list", "--porcelain")
+	cmd := command.New(command.GitTimeout(), "git", "worktree", "list", "--porcelain")
 	output, err := cmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to list worktrees: %w", err)
@@ -200,8 +251,11 @@ func CleanupWorktrees() error {
 			// Delete the branch associated with this worktree if found
 			for path, branch := range worktreeBranches {
 				if strings.Contains(path, entry.Name()) {
+					if skipInDryRun("delete branch %s", branch) {
+						break
+					}
 					// Delete the branch
-					deleteCmd := exec.Command("git", "branch", "-D", branch)
+					deleteCmd := command.New(command.GitTimeout(), "git", "branch", "-D", branch)
 					if err := deleteCmd.Run(); err != nil {
 						// Log the error but continue with other worktrees
 						log.ErrorLog.Printf("failed to delete branch %s: %v", branch, err)
@@ -211,12 +265,17 @@ func CleanupWorktrees() error {
 			}
 
 			// Remove the worktree directory
-			os.RemoveAll(worktreePath)
+			if !skipInDryRun("remove worktree %s", worktreePath) {
+				os.RemoveAll(worktreePath)
+			}
 		}
 	}
 
 	// You have to prune the cleaned up worktrees.
-	cmd = exec.Command("git", "worktree", "prune")
+	if skipInDryRun("prune worktrees") {
+		return nil
+	}
+	cmd = command.New(command.GitTimeout(), "git", "worktree", "prune")
 	_, err = cmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to prune worktrees: %w", err)
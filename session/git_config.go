@@ -0,0 +1,61 @@
+package session
+
+import (
+	"claude-squad/log"
+	"claude-squad/session/git"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// gitConfigs holds the git settings worktrees override.
+var gitConfigs struct {
+	mu       sync.RWMutex
+	fallback map[string]string
+	programs map[string]map[string]string
+}
+
+// SetGitConfigs sets the git settings of new worktrees and of worktrees loaded from storage.
+// programs adds to and overrides fallback for instances whose program's command name is a key.
+// Settings worktrees can't override, see git.CheckGitConfig, are left out.
+func SetGitConfigs(fallback map[string]string, programs map[string]map[string]string) {
+	gitConfigs.mu.Lock()
+	defer gitConfigs.mu.Unlock()
+	gitConfigs.fallback = validGitConfig(fallback)
+	gitConfigs.programs = make(map[string]map[string]string, len(programs))
+	for program, settings := range programs {
+		gitConfigs.programs[program] = validGitConfig(settings)
+	}
+}
+
+func validGitConfig(settings map[string]string) map[string]string {
+	valid := make(map[string]string, len(settings))
+	for key, value := range settings {
+		if err := git.CheckGitConfig(map[string]string{key: value}); err != nil {
+			log.WarningLog.Printf("%v, leaving it out", err)
+			continue
+		}
+		valid[key] = value
+	}
+	return valid
+}
+
+// gitConfigFor returns the git settings of the worktree of an instance running program, such as
+// "aider --model sonnet", with the settings of the instance itself taking precedence.
+func gitConfigFor(program string, instance map[string]string) map[string]string {
+	gitConfigs.mu.RLock()
+	defer gitConfigs.mu.RUnlock()
+	settings := make(map[string]string)
+	for key, value := range gitConfigs.fallback {
+		settings[key] = value
+	}
+	if fields := strings.Fields(program); len(fields) > 0 {
+		for key, value := range gitConfigs.programs[filepath.Base(fields[0])] {
+			settings[key] = value
+		}
+	}
+	for key, value := range instance {
+		settings[key] = value
+	}
+	return settings
+}
@@ -0,0 +1,31 @@
+package session
+
+import (
+	"claude-squad/log"
+	"reflect"
+	"testing"
+)
+
+func TestGitConfigFor(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	SetGitConfigs(map[string]string{"user.name": "Squad", "core.fsmonitor": "rm -rf ~"}, map[string]map[string]string{
+		"aider": {"user.name": "Aider", "commit.gpgsign": "false"},
+	})
+	t.Cleanup(func() { SetGitConfigs(nil, nil) })
+
+	tests := []struct {
+		program  string
+		instance map[string]string
+		want     map[string]string
+	}{
+		{program: "claude", want: map[string]string{"user.name": "Squad"}},
+		{program: "/usr/local/bin/aider --model sonnet", want: map[string]string{"user.name": "Aider", "commit.gpgsign": "false"}},
+		{program: "aider", instance: map[string]string{"user.name": "Me"}, want: map[string]string{"user.name": "Me", "commit.gpgsign": "false"}},
+	}
+	for _, tt := range tests {
+		if got := gitConfigFor(tt.program, tt.instance); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("gitConfigFor(%q, %v) = %v, want %v", tt.program, tt.instance, got, tt.want)
+		}
+	}
+}
@@ -1,17 +1,25 @@
 package session
 
 import (
+	"claude-squad/aider"
 	"claude-squad/log"
 	"claude-squad/session/git"
 	"claude-squad/session/tmux"
+	"claude-squad/session/vcs"
+	"claude-squad/tracing"
+	"context"
+	"errors"
 	"path/filepath"
 
 	"fmt"
 	"os"
+	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/atotto/clipboard"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Status int
@@ -25,8 +33,28 @@ const (
 	Loading
 	// Paused is if the instance is paused (worktree removed but branch preserved).
 	Paused
+	// Exited is if the program has exited, crashing or quit, and its pane shows its last output.
+	Exited
 )
 
+// String returns the lowercase name of the status as used by the web API.
+func (s Status) String() string {
+	switch s {
+	case Running:
+		return "running"
+	case Ready:
+		return "ready"
+	case Loading:
+		return "loading"
+	case Paused:
+		return "paused"
+	case Exited:
+		return "exited"
+	default:
+		return "unknown"
+	}
+}
+
 // Instance is a running instance of claude code.
 type Instance struct {
 	// Title is the title of the instance.
@@ -51,51 +79,148 @@ type Instance struct {
 	UpdatedAt time.Time
 	// AutoYes is true if the instance should automatically press enter when prompted.
 	AutoYes bool
-	// Prompt is the initial prompt to pass to the instance on startup
+	// AutoYesUntil is when AutoYes turns back off, or zero to keep it on. Set both with SetAutoYes.
+	AutoYesUntil time.Time
+	// Prompt is the first prompt sent to the instance. Retries start from it.
 	Prompt string
 	// InPlace is true if the instance should run in the current directory without creating a worktree
 	InPlace bool
+	// VCS is the kind of workspace the instance works in: vcs.Git, or vcs.Directory for a copy
+	// of Path in a project without git. Empty means vcs.Git.
+	VCS string
+	// Parent is the title of the instance this one derives from, if any.
+	Parent string
+	// Relation is how the instance relates to Parent.
+	Relation Relation
+	// Notes is free-form text about the instance, such as its intent, linked tickets or review
+	// notes. Change it with SetNotes so listeners learn about it.
+	Notes string
+	// Ticket references the ticket the instance works on, such as a GitHub issue URL or a Jira
+	// key. Change it with SetTicket.
+	Ticket string
+	// Color is the accent color chosen for the instance, or empty for one derived from its
+	// title. Change it with SetColor; AccentColor returns the color to use.
+	Color string
+	// Tags label the instance, such as to scope the daemon to some instances. Change them with
+	// SetTags.
+	Tags []string
+	// ExitCode is the exit status of the program once it has Exited, -1 if it is unknown.
+	ExitCode int
+	// Restarts is the number of times in a row a restart policy restarted the program after it
+	// exited, and RestartedAt when it last did.
+	Restarts    int
+	RestartedAt time.Time
+	// Cost is the cost of the Claude Code session last read from /cost, or nil.
+	Cost *CostReport
+
+	// exitedAt is when the program was found to have exited
+	exitedAt time.Time
+	// restartGaveUp is set once the restart policy ran out of retries for the current exit
+	restartGaveUp bool
+
+	// baseBranch is the branch a new worktree is created from instead of HEAD
+	baseBranch string
+	// existingBranch is the existing branch a new worktree checks out instead of creating one
+	existingBranch string
+	// branchPrefix replaces session/ at the start of a new branch
+	branchPrefix string
+	// worktreeDir is the directory a new worktree is created in instead of the config's
+	worktreeDir string
+	// templatePrompt is the prompt of the session template the instance was created from
+	templatePrompt string
+	// gitConfig holds the git settings the instance's worktree overrides, on top of those of the
+	// config
+	gitConfig map[string]string
 
 	// DiffStats stores the current git diff statistics
 	diffStats *git.DiffStats
+	// fileReport stores the untracked and ignored files found with the diff statistics
+	fileReport *git.FileReport
 
 	// lastPreviewContent stores the most recently captured preview content
 	lastPreviewContent string
 
+	// waitingOnPrompt is true while the program shows a prompt, as last reported to SetPrompt
+	waitingOnPrompt bool
+
+	// restoring is true from FromInstanceMetadata until Restore returns, while savedStatus is the
+	// stored status to restore and persist in place of Loading
+	restoring   bool
+	savedStatus Status
+
+	// startStage describes what Start or Restore is doing while the instance is Loading
+	startStage   string
+	startStageMu sync.Mutex
+
+	// lastRun is the last command run in the instance's directory with RunCommand
+	lastRun *CommandRun
+	runMu   sync.Mutex
+
+	// prompts is the prompt history, oldest first, see PromptHistory
+	prompts   []PromptRecord
+	promptsMu sync.Mutex
+
 	// The below fields are initialized upon calling Start().
 
 	started bool
 	// tmuxSession is the tmux session for the instance.
 	tmuxSession *tmux.TmuxSession
-	// gitWorktree is the git worktree for the instance.
+	// gitWorktree is the git worktree for the instance, nil for a vcs.Directory workspace.
 	gitWorktree *git.GitWorktree
+	// workspace is where the program runs: gitWorktree, or the copy of a vcs.Directory instance.
+	workspace vcs.Workspace
 }
 
 // ToInstanceData converts an Instance to its serializable form
 func (i *Instance) ToInstanceData() InstanceData {
 	data := InstanceData{
-		Title:     i.Title,
-		Path:      i.Path,
-		Branch:    i.Branch,
-		Status:    i.Status,
-		Height:    i.Height,
-		Width:     i.Width,
-		CreatedAt: i.CreatedAt,
-		UpdatedAt: time.Now(),
-		Program:   i.Program,
-		AutoYes:   i.AutoYes,
-		InPlace:   i.InPlace,
+		Title:        i.Title,
+		Path:         i.Path,
+		Branch:       i.Branch,
+		Status:       i.Status,
+		Height:       i.Height,
+		Width:        i.Width,
+		CreatedAt:    i.CreatedAt,
+		UpdatedAt:    time.Now(),
+		Program:      i.Program,
+		AutoYes:      i.AutoYes,
+		AutoYesUntil: i.AutoYesUntil,
+		InPlace:      i.InPlace,
+		VCS:          i.VCS,
+		Parent:       i.Parent,
+		Relation:     i.Relation,
+		Prompt:       i.Prompt,
+		Notes:        i.Notes,
+		Ticket:       i.Ticket,
+		Tags:         i.Tags,
+		Color:        i.Color,
+		ExitCode:     i.ExitCode,
+		Restarts:     i.Restarts,
+		RestartedAt:  i.RestartedAt,
+		Cost:         i.Cost,
+		GitConfig:    i.gitConfig,
+		Prompts:      i.PromptHistory(),
+	}
+	if i.restoring {
+		data.Status = i.savedStatus
+	}
+	if i.tmuxSession != nil {
+		data.TmuxSession = i.tmuxSession.SanitizedName()
 	}
 
 	// Only include worktree data if gitWorktree is initialized
 	if i.gitWorktree != nil {
 		data.Worktree = GitWorktreeData{
-			RepoPath:      i.gitWorktree.GetRepoPath(),
-			WorktreePath:  i.gitWorktree.GetWorktreePath(),
-			SessionName:   i.Title,
-			BranchName:    i.gitWorktree.GetBranchName(),
-			BaseCommitSHA: i.gitWorktree.GetBaseCommitSHA(),
+			RepoPath:       i.gitWorktree.GetRepoPath(),
+			WorktreePath:   i.gitWorktree.GetWorktreePath(),
+			SessionName:    i.Title,
+			BranchName:     i.gitWorktree.GetBranchName(),
+			BaseCommitSHA:  i.gitWorktree.GetBaseCommitSHA(),
+			ExistingBranch: i.gitWorktree.ExistingBranch(),
+			DiffBase:       i.gitWorktree.DiffBase(),
 		}
+	} else if copy, ok := i.workspace.(*vcs.DirWorkspace); ok {
+		data.Worktree = GitWorktreeData{RepoPath: copy.Source(), WorktreePath: copy.Path(), SessionName: i.Title}
 	}
 
 	// Only include diff stats if they exist
@@ -112,67 +237,138 @@ func (i *Instance) ToInstanceData() InstanceData {
 
 // FromInstanceData creates a new Instance from serialized data
 func FromInstanceData(data InstanceData) (*Instance, error) {
+	instance := fromInstanceMetadata(data)
+	instance.restore()
+	return instance, nil
+}
+
+// FromInstanceMetadata creates a new Instance from serialized data without touching its tmux
+// session, so it can be shown straight away. Unless it is paused, the instance is Loading until
+// Restore reattaches it to its tmux session.
+func FromInstanceMetadata(data InstanceData) *Instance {
+	instance := fromInstanceMetadata(data)
+	if !instance.Paused() {
+		instance.restoring = true
+		instance.Status = Loading
+		instance.started = true
+		instance.setStartStage("restoring session")
+	}
+	return instance
+}
+
+func fromInstanceMetadata(data InstanceData) *Instance {
 	instance := &Instance{
-		Title:     data.Title,
-		Path:      data.Path,
-		Branch:    data.Branch,
-		Status:    data.Status,
-		Height:    data.Height,
-		Width:     data.Width,
-		CreatedAt: data.CreatedAt,
-		UpdatedAt: data.UpdatedAt,
-		Program:   data.Program,
-		AutoYes:   data.AutoYes,
-		InPlace:   data.InPlace,
-		gitWorktree: git.NewGitWorktreeFromStorage(
-			data.Worktree.RepoPath,
-			data.Worktree.WorktreePath,
-			data.Worktree.SessionName,
-			data.Worktree.BranchName,
-			data.Worktree.BaseCommitSHA,
-		),
+		Title:        data.Title,
+		Path:         data.Path,
+		Branch:       data.Branch,
+		Status:       data.Status,
+		Height:       data.Height,
+		Width:        data.Width,
+		CreatedAt:    data.CreatedAt,
+		UpdatedAt:    data.UpdatedAt,
+		Program:      data.Program,
+		AutoYes:      data.AutoYes,
+		AutoYesUntil: data.AutoYesUntil,
+		InPlace:      data.InPlace,
+		VCS:          data.VCS,
+		gitConfig:    data.GitConfig,
+		Parent:       data.Parent,
+		Relation:     data.Relation,
+		Prompt:       data.Prompt,
+		Notes:        data.Notes,
+		Ticket:       data.Ticket,
+		Tags:         data.Tags,
+		Color:        data.Color,
+		ExitCode:     data.ExitCode,
+		Restarts:     data.Restarts,
+		RestartedAt:  data.RestartedAt,
+		Cost:         data.Cost,
+		prompts:      data.Prompts,
 		diffStats: &git.DiffStats{
 			Added:   data.DiffStats.Added,
 			Removed: data.DiffStats.Removed,
 			Content: data.DiffStats.Content,
 		},
+		savedStatus: data.Status,
+	}
+	if data.TmuxSession != "" {
+		instance.tmuxSession = tmux.NewTmuxSessionNamed(data.Title, data.TmuxSession, data.Program)
+	} else {
+		instance.tmuxSession = tmux.NewTmuxSession(data.Title, data.Program)
 	}
 
-	if instance.Paused() {
-		log.FileOnlyInfoLog.Printf("FromInstanceData: Instance %s is PAUSED, not starting tmux", instance.Title)
-		instance.started = true
-		instance.tmuxSession = tmux.NewTmuxSession(instance.Title, instance.Program)
+	if data.VCS == vcs.Directory {
+		instance.workspace = vcs.NewDirWorkspaceFromStorage(data.Worktree.RepoPath, data.Worktree.WorktreePath)
+		return instance
+	}
+	instance.gitWorktree = git.NewGitWorktreeFromStorage(
+		data.Worktree.RepoPath,
+		data.Worktree.WorktreePath,
+		data.Worktree.SessionName,
+		data.Worktree.BranchName,
+		data.Worktree.BaseCommitSHA,
+	)
+	instance.workspace = vcs.NewGitWorkspace(instance.gitWorktree)
+	if data.Worktree.ExistingBranch {
+		instance.gitWorktree.SetExistingBranch(data.Worktree.BranchName)
+	}
+	instance.gitWorktree.SetDiffBase(data.Worktree.DiffBase)
+	instance.gitWorktree.SetAddPolicy(gitAddPolicyFor(instance.Program))
+	instance.gitWorktree.SetGitConfig(gitConfigFor(instance.Program, instance.gitConfig))
+	instance.configurePushChecks()
+	return instance
+}
+
+// Restore reattaches an instance created by FromInstanceMetadata to its tmux session and gives it
+// back its saved status. An instance whose tmux session is gone is left not started, as
+// FromInstanceData would have. Restore is meant to run in its own goroutine, one per instance.
+func (i *Instance) Restore() {
+	if !i.restoring {
+		return
+	}
+	i.restore()
+	i.Status = i.savedStatus
+	i.setStartStage("")
+	i.restoring = false
+}
+
+func (i *Instance) restore() {
+	if i.Paused() {
+		log.FileOnlyInfoLog.Printf("FromInstanceData: Instance %s is PAUSED, not starting tmux", i.Title)
+		i.started = true
+		return
+	}
+
+	// Check if a tmux session already exists with this name
+	tmuxSessionName := i.tmuxSession.SanitizedName()
+	sessionExists := tmux.DoesSessionExist(tmuxSessionName)
+	log.FileOnlyInfoLog.Printf("FromInstanceData: Tmux session %s exists: %v", tmuxSessionName, sessionExists)
+
+	if !sessionExists {
+		// If session does not exist, it means it's not running.
+		// We don't automatically start it here. Instance.Start() is for explicit starting.
+		log.FileOnlyInfoLog.Printf("FromInstanceData: Tmux session for %s does not exist. Will be created if Instance.Start() is called.", i.Title)
+		i.started = false // Explicitly mark as not started if tmux session isn't found
+		return
+	}
+
+	// If session already exists, just restore it instead of creating a new one
+	log.FileOnlyInfoLog.Printf("FromInstanceData: Using existing tmux session for %s", i.Title)
+	i.started = true
+
+	// Don't try to start a new session, just set up our tracking of the existing one
+	if err := i.tmuxSession.Restore(); err != nil {
+		log.FileOnlyWarningLog.Printf("FromInstanceData: Non-fatal error restoring existing tmux session %s: %v",
+			i.Title, err)
 	} else {
-		// Check if a tmux session already exists with this name
-		tmuxSessionName := tmux.ToClaudeSquadTmuxName(instance.Title)
-		sessionExists := tmux.DoesSessionExist(tmuxSessionName)
-		log.FileOnlyInfoLog.Printf("FromInstanceData: Tmux session %s exists: %v", tmuxSessionName, sessionExists)
-		
-		if sessionExists {
-			// If session already exists, just restore it instead of creating a new one
-			log.FileOnlyInfoLog.Printf("FromInstanceData: Using existing tmux session for %s", instance.Title)
-			instance.started = true
-			instance.tmuxSession = tmux.NewTmuxSession(instance.Title, instance.Program)
-			
-			// Don't try to start a new session, just set up our tracking of the existing one
-			if err := instance.tmuxSession.Restore(); err != nil {
-				log.FileOnlyWarningLog.Printf("FromInstanceData: Non-fatal error restoring existing tmux session %s: %v", 
-					instance.Title, err)
-			} else {
-				log.FileOnlyInfoLog.Printf("FromInstanceData: Successfully restored existing tmux session for %s", 
-					instance.Title)
-			}
-		} else {
-			// If session does not exist, it means it's not running.
-			// We don't automatically start it here. Instance.Start() is for explicit starting.
-			// We just initialize the tmuxSession object for potential future use.
-			log.FileOnlyInfoLog.Printf("FromInstanceData: Tmux session for %s does not exist. Will be created if Instance.Start() is called.", instance.Title)
-			instance.tmuxSession = tmux.NewTmuxSession(instance.Title, instance.Program)
-			instance.started = false // Explicitly mark as not started if tmux session isn't found
-		}
+		log.FileOnlyInfoLog.Printf("FromInstanceData: Successfully restored existing tmux session for %s",
+			i.Title)
 	}
+}
 
-	return instance, nil
+// Restoring returns true while an instance created by FromInstanceMetadata waits for Restore.
+func (i *Instance) Restoring() bool {
+	return i.restoring
 }
 
 // Options for creating a new instance
@@ -187,6 +383,32 @@ type InstanceOptions struct {
 	AutoYes bool
 	// If InPlace is true, the instance will run in the current directory without creating a worktree
 	InPlace bool
+	// VCS is the kind of workspace the instance works in, vcs.Git unless set.
+	VCS string
+	// Parent is the title of the instance the new one derives from, with Relation describing how.
+	Parent   string
+	Relation Relation
+	// BaseBranch is the branch the worktree is created from. Defaults to HEAD.
+	BaseBranch string
+	// Branch is an existing branch, of the repository or one of its remotes, the worktree checks
+	// out instead of creating a branch, such as the branch of a pull request to work on. It is
+	// kept when the instance is killed. BaseBranch is ignored and Ticket doesn't name the branch.
+	Branch string
+	// Ticket references the ticket the instance works on. Its branch is named after it.
+	Ticket string
+	// Tags label the instance, see ValidateTags.
+	Tags []string
+	// BranchPrefix replaces session/ at the start of the instance's new branch. A ticket still
+	// names the branch.
+	BranchPrefix string
+	// WorktreeDir is the directory the worktree is created in instead of the worktrees directory
+	// of the config.
+	WorktreeDir string
+	// Template names the session template Factory.New fills in the other options from.
+	Template string
+	// GitConfig holds git settings, such as user.email or http.proxy, for the instance's worktree
+	// alone, on top of those of the config. See git.CheckGitConfig for the settings allowed.
+	GitConfig map[string]string
 }
 
 func NewInstance(opts InstanceOptions) (*Instance, error) {
@@ -197,6 +419,34 @@ func NewInstance(opts InstanceOptions) (*Instance, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get absolute path: %w", err)
 	}
+	if opts.Ticket != "" {
+		if _, err := ParseTicket(opts.Ticket); err != nil {
+			return nil, err
+		}
+	}
+	if err := ValidateTags(opts.Tags); err != nil {
+		return nil, err
+	}
+	if err := vcs.Check(opts.VCS); err != nil {
+		return nil, err
+	}
+	if opts.VCS == vcs.Directory && (opts.Branch != "" || opts.BaseBranch != "") {
+		return nil, fmt.Errorf("an instance working in a directory copy has no branch")
+	}
+	if err := git.CheckGitConfig(opts.GitConfig); err != nil {
+		return nil, err
+	}
+	if len(opts.GitConfig) > 0 && (opts.InPlace || opts.VCS == vcs.Directory) {
+		return nil, fmt.Errorf("git settings need an instance working in a worktree")
+	}
+	if opts.Branch != "" {
+		if opts.InPlace {
+			return nil, fmt.Errorf("an instance running in place can't check out a branch")
+		}
+		if err := git.CheckExistingBranch(absPath, opts.Branch); err != nil {
+			return nil, err
+		}
+	}
 
 	return &Instance{
 		Title:     opts.Title,
@@ -209,6 +459,17 @@ func NewInstance(opts InstanceOptions) (*Instance, error) {
 		UpdatedAt: t,
 		AutoYes:   opts.AutoYes,
 		InPlace:   opts.InPlace,
+		VCS:       opts.VCS,
+		Parent:    opts.Parent,
+		Relation:  opts.Relation,
+		Ticket:    strings.TrimSpace(opts.Ticket),
+		Tags:      opts.Tags,
+
+		baseBranch:     opts.BaseBranch,
+		existingBranch: opts.Branch,
+		branchPrefix:   opts.BranchPrefix,
+		worktreeDir:    opts.WorktreeDir,
+		gitConfig:      opts.GitConfig,
 	}, nil
 }
 
@@ -217,8 +478,8 @@ func (i *Instance) RepoName() (string, error) {
 		return "", fmt.Errorf("cannot get repo name for instance that has not been started")
 	}
 	
-	// Handle Simple Mode (in-place) instances differently
-	if i.InPlace {
+	// Handle Simple Mode (in-place) instances and directory copies differently
+	if i.InPlace || i.gitWorktree == nil {
 		// For Simple Mode, use the directory name as the repo name
 		return filepath.Base(i.Path), nil
 	}
@@ -228,16 +489,49 @@ func (i *Instance) RepoName() (string, error) {
 }
 
 func (i *Instance) SetStatus(status Status) {
+	previous := i.Status
 	i.Status = status
+	// Statuses set while starting up or restoring from storage aren't changes anyone needs to hear about
+	if i.started && previous != status {
+		i.emit(Event{Type: EventStatusChanged, PreviousStatus: previous.String()})
+	}
 }
 
 // firstTimeSetup is true if this is a new instance. Otherwise, it's one loaded from storage.
 func (i *Instance) Start(firstTimeSetup bool) error {
+	return i.StartContext(context.Background(), firstTimeSetup)
+}
+
+// StartContext is Start, canceled with ctx. Cancelling stops the start between its stages and
+// removes whatever was set up, returning ctx.Err(). StartStage reports the current stage.
+func (i *Instance) StartContext(ctx context.Context, firstTimeSetup bool) error {
+	ctx, span := tracing.Start(ctx, "instance.start", tracing.Instance(i.Title, i.Program)...)
+	span.SetAttributes(attribute.Bool("instance.first_time_setup", firstTimeSetup))
+	defer i.setStartStage("")
+	if err := tracing.End(span, i.start(ctx, firstTimeSetup)); err != nil {
+		if ctx.Err() == nil {
+			i.EmitError(err)
+		}
+		return err
+	}
+	if firstTimeSetup {
+		i.Emit(EventCreated)
+	}
+	return nil
+}
+
+func (i *Instance) start(ctx context.Context, firstTimeSetup bool) error {
 	if i.Title == "" {
 		return fmt.Errorf("instance title cannot be empty")
 	}
 
-	tmuxSession := tmux.NewTmuxSession(i.Title, i.Program)
+	// A new instance gets a session name no running session uses. A loaded one keeps its own.
+	var tmuxSession *tmux.TmuxSession
+	if firstTimeSetup || i.tmuxSession == nil {
+		tmuxSession = tmux.NewTmuxSessionNamed(i.Title, tmux.UniqueSessionName(i.Title), i.Program)
+	} else {
+		tmuxSession = tmux.NewTmuxSessionNamed(i.Title, i.tmuxSession.SanitizedName(), i.Program)
+	}
 	i.tmuxSession = tmuxSession
 
 	// Setup error handler to cleanup resources on any error
@@ -255,7 +549,7 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 	if i.InPlace {
 		// Simple mode - run directly in current directory without worktree
 		// Create new session directly in the current path
-		if err := i.tmuxSession.Start(i.Program, i.Path); err != nil {
+		if err := i.startTmux(ctx, i.Path); err != nil {
 			setupErr = fmt.Errorf("failed to start new session: %w", err)
 			return setupErr
 		}
@@ -266,23 +560,90 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 			setupErr = fmt.Errorf("failed to restore existing session: %w", err)
 			return setupErr
 		}
+	} else if i.VCS == vcs.Directory {
+		// No git - work in a copy of the directory
+		i.setStartStage("copying the directory")
+		workspace, err := vcs.NewDirWorkspace(i.Path, i.Title)
+		if err != nil {
+			return fmt.Errorf("failed to create workspace: %w", err)
+		}
+		i.workspace = workspace
+		if err := i.setupWorkspace(ctx); err != nil {
+			setupErr = fmt.Errorf("failed to copy %s: %w", i.Path, err)
+			return setupErr
+		}
+		if err := ctx.Err(); err != nil {
+			setupErr = i.abortStart(err)
+			return setupErr
+		}
+		i.setStartStage("starting " + i.Program)
+		if err := i.startTmux(ctx, workspace.Path()); err != nil {
+			if cleanupErr := workspace.Cleanup(); cleanupErr != nil {
+				err = fmt.Errorf("%v (cleanup error: %v)", err, cleanupErr)
+			}
+			setupErr = fmt.Errorf("failed to start new session: %w", err)
+			return setupErr
+		}
+		if err := ctx.Err(); err != nil {
+			setupErr = i.abortStart(err)
+			return setupErr
+		}
 	} else {
 		// Regular mode - create new instance with worktree
+		i.setStartStage("creating worktree")
 		gitWorktree, branchName, err := git.NewGitWorktree(i.Path, i.Title)
 		if err != nil {
 			return fmt.Errorf("failed to create git worktree: %w", err)
 		}
+		if i.worktreeDir != "" {
+			gitWorktree.SetWorktreeDir(i.worktreeDir)
+		}
+		if i.branchPrefix != "" {
+			branchName = git.PrefixedBranchName(i.branchPrefix, i.Title)
+			gitWorktree.SetBranchName(branchName)
+		}
+		if ticketBranch := i.ticketBranchName(); ticketBranch != "" {
+			gitWorktree.SetBranchName(ticketBranch)
+			branchName = ticketBranch
+		}
+		if i.existingBranch != "" {
+			gitWorktree.SetExistingBranch(i.existingBranch)
+			branchName = i.existingBranch
+		}
 		i.gitWorktree = gitWorktree
+		i.workspace = vcs.NewGitWorkspace(gitWorktree)
 		i.Branch = branchName
+		if i.baseBranch != "" {
+			i.gitWorktree.SetBaseRef(i.baseBranch)
+		} else if i.existingBranch == "" && git.FetchBaseEnabled() {
+			// Start from the remote's latest code rather than a stale local HEAD, or from HEAD
+			// when the remote can't be reached
+			i.setStartStage("fetching the base branch")
+			if ref, err := git.FetchBase(i.Path); err != nil {
+				log.WarningLog.Printf("starting %s from HEAD: %v", i.Title, err)
+			} else {
+				i.gitWorktree.SetBaseRef(ref)
+			}
+			i.setStartStage("creating worktree")
+		}
+		i.gitWorktree.SetAddPolicy(gitAddPolicyFor(i.Program))
+		i.gitWorktree.SetGitConfig(gitConfigFor(i.Program, i.gitConfig))
+		i.configurePushChecks()
 
 		// Setup git worktree
-		if err := i.gitWorktree.Setup(); err != nil {
+		if err := i.setupWorktree(ctx); err != nil {
 			setupErr = fmt.Errorf("failed to setup git worktree: %w", err)
 			return setupErr
 		}
 
+		if err := ctx.Err(); err != nil {
+			setupErr = i.abortStart(err)
+			return setupErr
+		}
+
 		// Create new session
-		if err := i.tmuxSession.Start(i.Program, i.gitWorktree.GetWorktreePath()); err != nil {
+		i.setStartStage("starting " + i.Program)
+		if err := i.startTmux(ctx, i.gitWorktree.GetWorktreePath()); err != nil {
 			// Cleanup git worktree if tmux session creation fails
 			if cleanupErr := i.gitWorktree.Cleanup(); cleanupErr != nil {
 				err = fmt.Errorf("%v (cleanup error: %v)", err, cleanupErr)
@@ -290,6 +651,10 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 			setupErr = fmt.Errorf("failed to start new session: %w", err)
 			return setupErr
 		}
+		if err := ctx.Err(); err != nil {
+			setupErr = i.abortStart(err)
+			return setupErr
+		}
 	}
 
 	i.SetStatus(Running)
@@ -297,6 +662,72 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 	return nil
 }
 
+// abortStart removes the tmux session and workspace of a start canceled with err, which it returns
+// with any cleanup error. Kill can't be used since the instance hasn't started.
+func (i *Instance) abortStart(err error) error {
+	var errs []error
+	if i.tmuxSession.DoesSessionExist() {
+		if closeErr := i.tmuxSession.Close(); closeErr != nil {
+			errs = append(errs, closeErr)
+		}
+	}
+	if cleanupErr := i.workspace.Cleanup(); cleanupErr != nil {
+		errs = append(errs, cleanupErr)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w (cleanup error: %v)", err, errors.Join(errs...))
+	}
+	return err
+}
+
+// setStartStage records what Start is doing.
+func (i *Instance) setStartStage(stage string) {
+	i.startStageMu.Lock()
+	defer i.startStageMu.Unlock()
+	i.startStage = stage
+}
+
+// StartStage returns what Start is doing, such as creating the worktree, or "" when it isn't
+// running. It is safe to call while Start runs in another goroutine.
+func (i *Instance) StartStage() string {
+	i.startStageMu.Lock()
+	defer i.startStageMu.Unlock()
+	return i.startStage
+}
+
+// setupWorktree sets up the instance's git worktree in a child span of ctx.
+func (i *Instance) setupWorktree(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "git.worktree.setup", tracing.Instance(i.Title, i.Program)...)
+	return tracing.End(span, i.gitWorktree.Setup())
+}
+
+// setupWorkspace sets up the instance's workspace, other than a git worktree, in a child span of
+// ctx.
+func (i *Instance) setupWorkspace(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "workspace.setup", tracing.Instance(i.Title, i.Program)...)
+	span.SetAttributes(attribute.String("workspace.vcs", i.VCS))
+	return tracing.End(span, i.workspace.Setup())
+}
+
+// startTmux starts the instance's program in its tmux session in a child span of ctx.
+func (i *Instance) startTmux(ctx context.Context, workDir string) error {
+	_, span := tracing.Start(ctx, "tmux.start", tracing.Instance(i.Title, i.Program)...)
+	if err := tracing.End(span, i.tmuxSession.Start(i.Program, workDir)); err != nil {
+		return err
+	}
+	i.applyAccentColor()
+	i.openConfiguredWindows(workDir)
+	return nil
+}
+
+// applyAccentColor colors the tmux status line of the instance with its accent color. tmux keeps
+// it for the life of the session, so restored sessions already have it.
+func (i *Instance) applyAccentColor() {
+	if err := i.tmuxSession.SetAccentColor(i.AccentColor()); err != nil {
+		log.FileOnlyWarningLog.Printf("could not set the accent color of %s: %v", i.Title, err)
+	}
+}
+
 // Kill terminates the instance and cleans up all resources
 func (i *Instance) Kill() error {
 	if !i.started {
@@ -314,13 +745,14 @@ func (i *Instance) Kill() error {
 		}
 	}
 
-	// Then clean up git worktree
-	if i.gitWorktree != nil {
-		if err := i.gitWorktree.Cleanup(); err != nil {
-			errs = append(errs, fmt.Errorf("failed to cleanup git worktree: %w", err))
+	// Then clean up the worktree or directory copy
+	if i.workspace != nil {
+		if err := i.workspace.Cleanup(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to cleanup workspace: %w", err))
 		}
 	}
 
+	i.Emit(EventKilled)
 	return i.combineErrors(errs)
 }
 
@@ -373,9 +805,18 @@ func (i *Instance) Preview() (string, error) {
 	return content, nil
 }
 
+// PreviewHistory returns the pane content including up to lines of scrollback above it.
+func (i *Instance) PreviewHistory(lines int) (string, error) {
+	if !i.started || i.Status == Paused {
+		return "", nil
+	}
+	return i.tmuxSession.CapturePaneContentWithOptions(fmt.Sprintf("-%d", lines), "-")
+}
+
 // HasUpdated checks if the tmux pane content has changed since the last tick.
 // It can optionally use provided content to avoid re-fetching.
-// It also returns true if the tmux pane has a prompt for aider or claude code.
+// It also returns true if the tmux pane has a prompt for aider or claude code. aider instances
+// whose chat history has a message waiting for a reply count as updated while the pane is still.
 func (i *Instance) HasUpdated(optionalCurrentContent ...string) (updated bool, hasPrompt bool) {
 	var currentContent string
 	var err error
@@ -387,16 +828,26 @@ func (i *Instance) HasUpdated(optionalCurrentContent ...string) (updated bool, h
 	if !i.started {
 		return false, false
 	}
+	if err == nil && !i.Paused() && i.tmuxSession.ViewedWindow() != "" {
+		// The preview shows a companion window, the status comes from the program's
+		currentContent, err = i.tmuxSession.CaptureAgentContent()
+	}
 	if err != nil { // if Preview itself failed
 		log.FileOnlyErrorLog.Printf("error getting content for HasUpdated check for %s: %v", i.Title, err)
 		return false, false
 	}
-	return i.tmuxSession.HasUpdated(currentContent) // Pass content to avoid re-capture
+	updated, hasPrompt = i.tmuxSession.HasUpdated(currentContent) // Pass content to avoid re-capture
+	if !updated && !hasPrompt && tmux.IsAiderProgram(i.Program) {
+		if history, err := aider.Load(i.WorkDir()); err == nil && history.Busy(time.Now()) {
+			updated = true
+		}
+	}
+	return updated, hasPrompt
 }
 
-// TapEnter sends an enter key press to the tmux session if AutoYes is enabled.
+// TapEnter sends an enter key press to the tmux session if AutoYes is enabled and hasn't expired.
 func (i *Instance) TapEnter() {
-	if !i.started || !i.AutoYes {
+	if !i.started || i.CheckAutoYesExpired(time.Now()) || !i.AutoYes {
 		return
 	}
 	if err := i.tmuxSession.TapEnter(); err != nil {
@@ -404,13 +855,63 @@ func (i *Instance) TapEnter() {
 	}
 }
 
+// PressEnter is TapEnter through tmux send-keys, for a process watching the instance while
+// another may show it, such as the daemon while the TUI runs: the window keeps the size of the
+// clients in use.
+func (i *Instance) PressEnter() {
+	if !i.started || i.CheckAutoYesExpired(time.Now()) || !i.AutoYes {
+		return
+	}
+	if err := i.tmuxSession.PressEnter(); err != nil {
+		log.ErrorLog.Printf("error pressing enter: %v", err)
+	}
+}
+
+// Release detaches this process from the instance's tmux session, leaving it running, once the
+// instance is no longer watched.
+func (i *Instance) Release() {
+	if i.tmuxSession == nil {
+		return
+	}
+	if err := i.tmuxSession.Release(); err != nil {
+		log.FileOnlyWarningLog.Printf("could not release the tmux session of %s: %v", i.Title, err)
+	}
+}
+
 func (i *Instance) Attach() (chan struct{}, error) {
 	if !i.started {
 		return nil, fmt.Errorf("cannot attach instance that has not been started")
 	}
+	i.showBanner()
 	return i.tmuxSession.Attach()
 }
 
+// attachBannerDuration is how long the banner is shown over the status line on attach.
+const attachBannerDuration = 3 * time.Second
+
+// Banner returns the line describing the instance while attached: its title, branch, whether
+// auto-yes is on and how to detach.
+func (i *Instance) Banner() string {
+	parts := []string{i.Title}
+	if i.Branch != "" {
+		parts = append(parts, i.Branch)
+	}
+	return strings.Join(append(parts, i.AutoYesDescription(), "ctrl-q to detach"), " · ")
+}
+
+// showBanner sets the banner as the pane title and status line of the instance's tmux session and
+// briefly displays it. It is refreshed on every attach as auto-yes can change in between.
+func (i *Instance) showBanner() {
+	banner := i.Banner()
+	if err := i.tmuxSession.SetBanner(banner); err != nil {
+		log.FileOnlyWarningLog.Printf("could not set the banner of %s: %v", i.Title, err)
+		return
+	}
+	if err := i.tmuxSession.DisplayMessage(banner, attachBannerDuration); err != nil {
+		log.FileOnlyWarningLog.Printf("could not display the banner of %s: %v", i.Title, err)
+	}
+}
+
 // Detach detaches from the tmux session
 func (i *Instance) Detach() {
 	if !i.started {
@@ -435,6 +936,15 @@ func (i *Instance) GetTmuxSessionName() string {
 	return i.tmuxSession.SanitizedName()
 }
 
+// tmuxSessionName returns the name of the instance's tmux session, or the name its title maps to
+// if it has none yet.
+func (i *Instance) tmuxSessionName() string {
+	if i.tmuxSession != nil {
+		return i.tmuxSession.SanitizedName()
+	}
+	return tmux.ToClaudeSquadTmuxName(i.Title)
+}
+
 // GetGitWorktree returns the git worktree for the instance
 func (i *Instance) GetGitWorktree() (*git.GitWorktree, error) {
 	if !i.started {
@@ -443,9 +953,81 @@ func (i *Instance) GetGitWorktree() (*git.GitWorktree, error) {
 	if i.InPlace {
 		return nil, fmt.Errorf("no git worktree available for in-place instance")
 	}
+	if i.gitWorktree == nil {
+		return nil, fmt.Errorf("no git worktree available for instance working in a directory copy")
+	}
 	return i.gitWorktree, nil
 }
 
+// WorkDir returns the directory the instance's program runs in: its worktree or directory copy, or
+// its path when it runs in place. It is empty until the instance has started.
+func (i *Instance) WorkDir() string {
+	if !i.started {
+		return ""
+	}
+	if i.InPlace {
+		return i.Path
+	}
+	if i.workspace == nil {
+		return ""
+	}
+	return i.workspace.Path()
+}
+
+// ExistingBranch returns the existing branch the instance's worktree checks out, which is kept when
+// the instance is killed, or "" if the instance has a branch of its own.
+func (i *Instance) ExistingBranch() string {
+	if i.gitWorktree != nil && i.gitWorktree.ExistingBranch() {
+		return i.gitWorktree.GetBranchName()
+	}
+	return i.existingBranch
+}
+
+// SetDiffBase sets what the diff of the instance compares its worktree with, one of the bases
+// accepted by git.CheckDiffBase, and refreshes the diff stats.
+func (i *Instance) SetDiffBase(base string) error {
+	if !i.started || i.InPlace || i.gitWorktree == nil {
+		return fmt.Errorf("instance %s has no worktree", i.Title)
+	}
+	base = strings.TrimSpace(base)
+	if err := git.CheckDiffBase(i.gitWorktree.GetWorktreePath(), base); err != nil {
+		return err
+	}
+	i.gitWorktree.SetDiffBase(base)
+	return i.UpdateDiffStats()
+}
+
+// DiffBase returns what the diff of the instance compares its worktree with, "" for the commit it
+// was created from.
+func (i *Instance) DiffBase() string {
+	if i.gitWorktree == nil {
+		return ""
+	}
+	return i.gitWorktree.DiffBase()
+}
+
+// GitConfig returns the git settings the instance's worktree overrides, on top of those of the
+// config.
+func (i *Instance) GitConfig() map[string]string {
+	return i.gitConfig
+}
+
+// BaseBranch returns the branch the instance's new branch is created from, or "" for HEAD.
+func (i *Instance) BaseBranch() string {
+	return i.baseBranch
+}
+
+// BranchPrefix returns what the instance's new branch starts with instead of session/, or "".
+func (i *Instance) BranchPrefix() string {
+	return i.branchPrefix
+}
+
+// TemplatePrompt returns the prompt of the session template the instance was created from, to
+// send once it has started, or "".
+func (i *Instance) TemplatePrompt() string {
+	return i.templatePrompt
+}
+
 func (i *Instance) Started() bool {
 	return i.started
 }
@@ -471,6 +1053,11 @@ func (i *Instance) TmuxAlive() bool {
 
 // Pause stops the tmux session and removes the worktree, preserving the branch
 func (i *Instance) Pause() error {
+	_, span := tracing.Start(context.Background(), "instance.pause", tracing.Instance(i.Title, i.Program)...)
+	return tracing.End(span, i.pause())
+}
+
+func (i *Instance) pause() error {
 	if !i.started {
 		return fmt.Errorf("cannot pause instance that has not been started")
 	}
@@ -480,6 +1067,9 @@ func (i *Instance) Pause() error {
 	if i.InPlace {
 		return fmt.Errorf("cannot pause in-place instances (simple mode)")
 	}
+	if i.gitWorktree == nil {
+		return fmt.Errorf("cannot pause instances working in a directory copy")
+	}
 
 	var errs []error
 
@@ -488,9 +1078,13 @@ func (i *Instance) Pause() error {
 		errs = append(errs, fmt.Errorf("failed to check if worktree is dirty: %w", err))
 		log.ErrorLog.Print(err)
 	} else if dirty {
-		// Commit changes with timestamp
-		commitMsg := fmt.Sprintf("[claudesquad] update from '%s' on %s (paused)", i.Title, time.Now().Format(time.RFC822))
-		if err := i.gitWorktree.PushChanges(commitMsg, false); err != nil {
+		// Commit changes with timestamp, leaving checkpoints for the next push to rewrite
+		commitMsg := i.CommitMessage() + " (paused)"
+		commit := i.gitWorktree.PushChanges
+		if i.gitWorktree.RewritesCheckpoints() {
+			commit = func(message string, _ bool) error { return i.gitWorktree.CommitCheckpoint(message) }
+		}
+		if err := commit(commitMsg, false); err != nil {
 			errs = append(errs, fmt.Errorf("failed to commit changes: %w", err))
 			log.ErrorLog.Print(err)
 			// Return early if we can't commit changes to avoid corrupted state
@@ -529,12 +1123,18 @@ func (i *Instance) Pause() error {
 	}
 
 	i.SetStatus(Paused)
+	i.Emit(EventPaused)
 	_ = clipboard.WriteAll(i.gitWorktree.GetBranchName())
 	return nil
 }
 
 // Resume recreates the worktree and restarts the tmux session
 func (i *Instance) Resume() error {
+	ctx, span := tracing.Start(context.Background(), "instance.resume", tracing.Instance(i.Title, i.Program)...)
+	return tracing.End(span, i.resume(ctx))
+}
+
+func (i *Instance) resume(ctx context.Context) error {
 	if !i.started {
 		return fmt.Errorf("cannot resume instance that has not been started")
 	}
@@ -551,13 +1151,18 @@ func (i *Instance) Resume() error {
 	}
 
 	// Setup git worktree
-	if err := i.gitWorktree.Setup(); err != nil {
+	if err := i.setupWorktree(ctx); err != nil {
 		log.ErrorLog.Print(err)
 		return fmt.Errorf("failed to setup git worktree: %w", err)
 	}
 
+	// Another instance may have taken the session name while this one was paused
+	if i.tmuxSession.DoesSessionExist() {
+		i.tmuxSession = tmux.NewTmuxSessionNamed(i.Title, tmux.UniqueSessionName(i.Title), i.Program)
+	}
+
 	// Create new tmux session
-	if err := i.tmuxSession.Start(i.Program, i.gitWorktree.GetWorktreePath()); err != nil {
+	if err := i.startTmux(ctx, i.gitWorktree.GetWorktreePath()); err != nil {
 		log.ErrorLog.Print(err)
 		// Cleanup git worktree if tmux session creation fails
 		if cleanupErr := i.gitWorktree.Cleanup(); cleanupErr != nil {
@@ -567,6 +1172,61 @@ func (i *Instance) Resume() error {
 		return fmt.Errorf("failed to start new session: %w", err)
 	}
 
+	i.SetStatus(Running)
+	i.Emit(EventResumed)
+	return nil
+}
+
+// ResolvedProgram returns the instance's program command with the executable resolved against
+// PATH, which is what actually runs in the tmux session. If it can't be resolved the command is
+// returned unchanged.
+func (i *Instance) ResolvedProgram() string {
+	fields := strings.Fields(i.Program)
+	if len(fields) == 0 {
+		return i.Program
+	}
+	path, err := exec.LookPath(fields[0])
+	if err != nil {
+		return i.Program
+	}
+	return strings.Join(append([]string{path}, fields[1:]...), " ")
+}
+
+// SwapProgram stops the program running in the instance's tmux session and starts program in
+// its place. The worktree, branch and diff are left untouched, so the instance keeps its identity.
+func (i *Instance) SwapProgram(program string) error {
+	if !i.started {
+		return fmt.Errorf("cannot swap program of instance that has not been started")
+	}
+	if i.Status == Paused {
+		return fmt.Errorf("cannot swap program of a paused instance")
+	}
+	if strings.TrimSpace(program) == "" {
+		return fmt.Errorf("program cannot be empty")
+	}
+
+	workDir := i.WorkDir()
+
+	if err := i.tmuxSession.Close(); err != nil {
+		return fmt.Errorf("failed to stop %s: %w", i.Program, err)
+	}
+
+	tmuxSession := tmux.NewTmuxSession(i.Title, program)
+	if err := tmuxSession.Start(program, workDir); err != nil {
+		// Try to bring the previous program back so the instance isn't left without a session
+		previous := tmux.NewTmuxSession(i.Title, i.Program)
+		if restoreErr := previous.Start(i.Program, workDir); restoreErr != nil {
+			return fmt.Errorf("failed to start %s: %w (restoring %s also failed: %v)", program, err, i.Program, restoreErr)
+		}
+		i.tmuxSession = previous
+		return fmt.Errorf("failed to start %s: %w", program, err)
+	}
+
+	i.tmuxSession = tmuxSession
+	i.Program = program
+	i.applyAccentColor()
+	i.openConfiguredWindows(workDir)
+	i.UpdatedAt = time.Now()
 	i.SetStatus(Running)
 	return nil
 }
@@ -575,6 +1235,7 @@ func (i *Instance) Resume() error {
 func (i *Instance) UpdateDiffStats() error {
 	if !i.started {
 		i.diffStats = nil
+		i.fileReport = nil
 		return nil
 	}
 
@@ -586,20 +1247,38 @@ func (i *Instance) UpdateDiffStats() error {
 	if i.InPlace {
 		// Simple mode doesn't use worktrees, so no diff stats
 		i.diffStats = nil
+		i.fileReport = nil
+		return nil
+	}
+
+	if i.workspace == nil {
+		i.diffStats = nil
+		i.fileReport = nil
 		return nil
 	}
 
-	stats := i.gitWorktree.Diff()
+	stats := i.workspace.Diff()
 	if stats.Error != nil {
 		if strings.Contains(stats.Error.Error(), "base commit SHA not set") {
 			// Worktree is not fully set up yet, not an error
 			i.diffStats = nil
+			i.fileReport = nil
 			return nil
 		}
 		return fmt.Errorf("failed to get diff stats: %w", stats.Error)
 	}
 
+	var report *git.FileReport
+	if i.gitWorktree != nil {
+		var err error
+		report, err = i.gitWorktree.FileReport()
+		if err != nil {
+			return fmt.Errorf("failed to get file report: %w", err)
+		}
+	}
+
 	i.diffStats = stats
+	i.fileReport = report
 	return nil
 }
 
@@ -608,8 +1287,35 @@ func (i *Instance) GetDiffStats() *git.DiffStats {
 	return i.diffStats
 }
 
+// GetFileReport returns the untracked and ignored files found by the last UpdateDiffStats.
+func (i *Instance) GetFileReport() *git.FileReport {
+	return i.fileReport
+}
+
+// FileReport lists the untracked and ignored files of the instance's worktree.
+func (i *Instance) FileReport() (*git.FileReport, error) {
+	if !i.started || i.Paused() || i.InPlace || i.gitWorktree == nil {
+		return nil, fmt.Errorf("instance %s is not running in a worktree", i.Title)
+	}
+	return i.gitWorktree.FileReport()
+}
+
 // SendPrompt sends a prompt to the tmux session
 func (i *Instance) SendPrompt(prompt string) error {
+	_, span := tracing.Start(context.Background(), "instance.send_prompt", tracing.Instance(i.Title, i.Program)...)
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	i.snapshotBeforePrompt(prompt)
+	err := i.sendPrompt(prompt)
+	if err == nil {
+		if i.Prompt == "" {
+			i.Prompt = prompt
+		}
+		i.recordPrompt(prompt)
+	}
+	return tracing.End(span, err)
+}
+
+func (i *Instance) sendPrompt(prompt string) error {
 	if !i.started {
 		return fmt.Errorf("instance not started")
 	}
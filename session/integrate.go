@@ -0,0 +1,40 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"fmt"
+)
+
+// IntegrateInstances merges the branches of instances, in order, into a new branch created from
+// the HEAD of their repository. Only committed work is merged; the titles of instances with
+// uncommitted changes are returned so callers can warn about them.
+func IntegrateInstances(branch string, instances []*Instance) (*git.Integration, []string, error) {
+	if len(instances) < 2 {
+		return nil, nil, fmt.Errorf("at least two instances are needed to integrate")
+	}
+
+	var repoPath string
+	var branches, uncommitted []string
+	for _, instance := range instances {
+		worktree := instance.gitWorktree
+		if instance.InPlace || worktree == nil || worktree.GetBranchName() == "" {
+			return nil, nil, fmt.Errorf("instance %s has no branch to integrate", instance.Title)
+		}
+		if repoPath == "" {
+			repoPath = worktree.GetRepoPath()
+		} else if worktree.GetRepoPath() != repoPath {
+			return nil, nil, fmt.Errorf("instance %s is in %s, not %s", instance.Title, worktree.GetRepoPath(), repoPath)
+		}
+		branches = append(branches, worktree.GetBranchName())
+
+		// Paused instances have committed everything and removed their worktree
+		if !instance.Paused() {
+			if dirty, err := worktree.IsDirty(); err == nil && dirty {
+				uncommitted = append(uncommitted, instance.Title)
+			}
+		}
+	}
+
+	integration, err := git.Integrate(repoPath, branch, branches)
+	return integration, uncommitted, err
+}
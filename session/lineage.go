@@ -0,0 +1,172 @@
+package session
+
+import (
+	"claude-squad/session/vcs"
+	"fmt"
+)
+
+// Relation describes how an instance derives from its parent instance.
+type Relation string
+
+const (
+	// RelationFork is an instance branched from its parent's branch to try another direction.
+	RelationFork Relation = "fork"
+	// RelationReviewerOf is an instance reviewing its parent's changes.
+	RelationReviewerOf Relation = "reviewer-of"
+	// RelationRetryOf is an instance redoing its parent's task from scratch.
+	RelationRetryOf Relation = "retry-of"
+)
+
+// Relations lists every relation.
+var Relations = []Relation{RelationFork, RelationReviewerOf, RelationRetryOf}
+
+// RetryOptions returns the options for an instance that redoes parent's task on a fresh
+// worktree from HEAD, running program. The caller sets the title and sends parent.Prompt once the
+// new instance has started.
+func RetryOptions(parent *Instance, program string) InstanceOptions {
+	return InstanceOptions{
+		Path:      parent.Path,
+		Program:   program,
+		AutoYes:   parent.AutoYes,
+		Parent:    parent.Title,
+		Relation:  RelationRetryOf,
+		Ticket:    parent.Ticket,
+		GitConfig: parent.GitConfig(),
+	}
+}
+
+// ReviewOptions returns the options for an instance that reviews parent's changes on a worktree
+// branched from parent's branch, running program. The caller sets the title and sends
+// ReviewPrompt(parent) once the new instance has started. The reviewer only sees the changes
+// parent committed.
+func ReviewOptions(parent *Instance, program string) (InstanceOptions, error) {
+	if parent.InPlace || parent.VCS == vcs.Directory || parent.Branch == "" {
+		return InstanceOptions{}, fmt.Errorf("%s has no branch to review", parent.Title)
+	}
+	return InstanceOptions{
+		Path:       parent.Path,
+		Program:    program,
+		BaseBranch: parent.Branch,
+		Parent:     parent.Title,
+		Relation:   RelationReviewerOf,
+		Ticket:     parent.Ticket,
+		GitConfig:  parent.GitConfig(),
+	}, nil
+}
+
+// ReviewPrompt returns the prompt asking a reviewer of parent to review the commits parent made
+// on its branch.
+func ReviewPrompt(parent *Instance) string {
+	since := "it was created"
+	if worktree, err := parent.GetGitWorktree(); err == nil && worktree.GetBaseCommitSHA() != "" {
+		since = "commit " + worktree.GetBaseCommitSHA()
+	}
+	prompt := fmt.Sprintf("Review the changes made on this branch, %s, since %s.", parent.Branch, since)
+	if parent.Prompt != "" {
+		prompt += " They were made for this task:\n\n" + parent.Prompt + "\n\n"
+	} else {
+		prompt += " "
+	}
+	return prompt + "Report bugs, missing tests and risky changes, pointing to the files and " +
+		"lines, without changing any files."
+}
+
+// GraphNode is an instance in the lineage graph.
+type GraphNode struct {
+	Title   string `json:"title"`
+	Status  string `json:"status"`
+	Program string `json:"program"`
+	Branch  string `json:"branch"`
+}
+
+// GraphEdge links a parent instance to an instance derived from it.
+type GraphEdge struct {
+	Parent   string   `json:"parent"`
+	Child    string   `json:"child"`
+	Relation Relation `json:"relation"`
+}
+
+// Graph is the lineage of a set of instances.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// BuildGraph returns the lineage graph of instances. Edges to parents that are no longer in
+// instances, such as killed ones, are left out.
+func BuildGraph(instances []*Instance) Graph {
+	graph := Graph{
+		Nodes: make([]GraphNode, 0, len(instances)),
+		Edges: make([]GraphEdge, 0),
+	}
+	titles := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		titles[instance.Title] = true
+	}
+
+	for _, instance := range instances {
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			Title:   instance.Title,
+			Status:  instance.Status.String(),
+			Program: instance.Program,
+			Branch:  instance.Branch,
+		})
+		if instance.Parent != "" && instance.Parent != instance.Title && titles[instance.Parent] {
+			graph.Edges = append(graph.Edges, GraphEdge{
+				Parent:   instance.Parent,
+				Child:    instance.Title,
+				Relation: instance.Relation,
+			})
+		}
+	}
+	return graph
+}
+
+// LineageEntry is an instance positioned in the lineage tree.
+type LineageEntry struct {
+	Instance *Instance
+	// Depth is the number of ancestors of the instance present in the tree.
+	Depth int
+}
+
+// LineageTree orders instances depth first so every instance directly follows its parent or an
+// earlier sibling. Instances without a parent in instances are roots. The relative order of roots
+// and of siblings is kept.
+func LineageTree(instances []*Instance) []LineageEntry {
+	titles := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		titles[instance.Title] = true
+	}
+	children := make(map[string][]*Instance)
+	var roots []*Instance
+	for _, instance := range instances {
+		if instance.Parent == "" || instance.Parent == instance.Title || !titles[instance.Parent] {
+			roots = append(roots, instance)
+			continue
+		}
+		children[instance.Parent] = append(children[instance.Parent], instance)
+	}
+
+	entries := make([]LineageEntry, 0, len(instances))
+	visited := make(map[*Instance]bool, len(instances))
+	var walk func(instance *Instance, depth int)
+	walk = func(instance *Instance, depth int) {
+		if visited[instance] {
+			return
+		}
+		visited[instance] = true
+		entries = append(entries, LineageEntry{Instance: instance, Depth: depth})
+		for _, child := range children[instance.Title] {
+			walk(child, depth+1)
+		}
+	}
+	for _, root := range roots {
+		walk(root, 0)
+	}
+	// Parents that point at each other form a cycle with no root. Show them at the top level
+	// rather than dropping them.
+	for _, instance := range instances {
+		walk(instance, 0)
+	}
+	return entries
+}
@@ -0,0 +1,96 @@
+package session
+
+import (
+	"claude-squad/session/vcs"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func lineageInstances() []*Instance {
+	return []*Instance{
+		{Title: "api", Program: "claude"},
+		{Title: "api-review", Parent: "api", Relation: RelationReviewerOf},
+		{Title: "ui", Program: "aider"},
+		{Title: "api-fork", Parent: "api", Relation: RelationFork},
+		{Title: "api-fork-retry", Parent: "api-fork", Relation: RelationRetryOf},
+		{Title: "orphan", Parent: "killed", Relation: RelationFork},
+	}
+}
+
+func TestBuildGraph(t *testing.T) {
+	graph := BuildGraph(lineageInstances())
+
+	if len(graph.Nodes) != 6 {
+		t.Errorf("got %d nodes, want 6", len(graph.Nodes))
+	}
+	want := []GraphEdge{
+		{Parent: "api", Child: "api-review", Relation: RelationReviewerOf},
+		{Parent: "api", Child: "api-fork", Relation: RelationFork},
+		{Parent: "api-fork", Child: "api-fork-retry", Relation: RelationRetryOf},
+	}
+	if !reflect.DeepEqual(graph.Edges, want) {
+		t.Errorf("edges = %+v, want %+v", graph.Edges, want)
+	}
+}
+
+func TestLineageTree(t *testing.T) {
+	tests := []struct {
+		name      string
+		instances []*Instance
+		want      []string
+	}{
+		{
+			name:      "children follow parents",
+			instances: lineageInstances(),
+			want: []string{
+				"0:api", "1:api-review", "1:api-fork", "2:api-fork-retry", "0:ui", "0:orphan",
+			},
+		},
+		{
+			name: "cycle is kept",
+			instances: []*Instance{
+				{Title: "a", Parent: "b"},
+				{Title: "b", Parent: "a"},
+			},
+			want: []string{"0:a", "1:b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, entry := range LineageTree(tt.instances) {
+				got = append(got, fmt.Sprintf("%d:%s", entry.Depth, entry.Instance.Title))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LineageTree() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReviewOptions(t *testing.T) {
+	parent := &Instance{Title: "api", Path: "/repo", Program: "claude", Branch: "session/api", Prompt: "Add rate limits"}
+	opts, err := ReviewOptions(parent, "aider")
+	if err != nil {
+		t.Fatalf("ReviewOptions() error: %v", err)
+	}
+	if opts.BaseBranch != "session/api" || opts.Parent != "api" || opts.Relation != RelationReviewerOf || opts.Program != "aider" {
+		t.Errorf("ReviewOptions() = %+v", opts)
+	}
+	prompt := ReviewPrompt(parent)
+	if !strings.Contains(prompt, "session/api") || !strings.Contains(prompt, "Add rate limits") {
+		t.Errorf("ReviewPrompt() = %q", prompt)
+	}
+
+	for _, parent := range []*Instance{
+		{Title: "here", InPlace: true},
+		{Title: "copy", VCS: vcs.Directory, Branch: "session/copy"},
+	} {
+		if _, err := ReviewOptions(parent, "claude"); err == nil {
+			t.Errorf("ReviewOptions(%s) succeeded, want error", parent.Title)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxPromptHistory is the number of prompts kept in the history of an instance. Older prompts are
+// dropped as new ones are sent.
+const MaxPromptHistory = 100
+
+// PromptRecord is a prompt sent to an instance with SendPrompt.
+type PromptRecord struct {
+	Prompt string    `json:"prompt"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// PromptHistory returns the prompts sent to the instance, oldest first.
+func (i *Instance) PromptHistory() []PromptRecord {
+	i.promptsMu.Lock()
+	defer i.promptsMu.Unlock()
+	return append([]PromptRecord(nil), i.prompts...)
+}
+
+// RecordPrompt adds record to the prompt history and returns true, unless it is already there. It
+// copies a prompt sent to another copy of the instance, such as one the web server loaded, see
+// EventPromptSent.
+func (i *Instance) RecordPrompt(record PromptRecord) bool {
+	i.promptsMu.Lock()
+	defer i.promptsMu.Unlock()
+	for _, recorded := range i.prompts {
+		if recorded.SentAt.Equal(record.SentAt) && recorded.Prompt == record.Prompt {
+			return false
+		}
+	}
+	i.prompts = append(i.prompts, record)
+	if len(i.prompts) > MaxPromptHistory {
+		i.prompts = i.prompts[len(i.prompts)-MaxPromptHistory:]
+	}
+	return true
+}
+
+// recordPrompt adds prompt, just sent, to the history and emits EventPromptSent. Blank prompts,
+// such as the empty one confirming a trust prompt, aren't recorded.
+func (i *Instance) recordPrompt(prompt string) {
+	if strings.TrimSpace(prompt) == "" {
+		return
+	}
+	e := i.emit(Event{Type: EventPromptSent, Prompt: prompt})
+	i.RecordPrompt(PromptRecord{Prompt: prompt, SentAt: e.Time})
+}
+
+// ReplayPrompt sends the prompt at index in PromptHistory again, and returns it.
+func (i *Instance) ReplayPrompt(index int) (PromptRecord, error) {
+	history := i.PromptHistory()
+	if index < 0 || index >= len(history) {
+		return PromptRecord{}, fmt.Errorf("instance %s has no prompt %d in its history", i.Title, index)
+	}
+	return history[index], i.SendPrompt(history[index].Prompt)
+}
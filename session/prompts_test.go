@@ -0,0 +1,37 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPromptHistory(t *testing.T) {
+	instance := &Instance{Title: "task", Status: Paused}
+	sentAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for n := 0; n < MaxPromptHistory+2; n++ {
+		instance.RecordPrompt(PromptRecord{Prompt: fmt.Sprintf("prompt %d", n), SentAt: sentAt.Add(time.Duration(n) * time.Minute)})
+	}
+	// A prompt copied from another copy of the instance is only recorded once
+	instance.RecordPrompt(PromptRecord{Prompt: "prompt 5", SentAt: sentAt.Add(5 * time.Minute)})
+
+	history := instance.PromptHistory()
+	if len(history) != MaxPromptHistory {
+		t.Fatalf("len(PromptHistory()) = %d, want %d", len(history), MaxPromptHistory)
+	}
+	if history[0].Prompt != "prompt 2" || history[len(history)-1].Prompt != fmt.Sprintf("prompt %d", MaxPromptHistory+1) {
+		t.Errorf("history runs from %q to %q, want the oldest prompts dropped", history[0].Prompt, history[len(history)-1].Prompt)
+	}
+
+	restored := FromInstanceMetadata(instance.ToInstanceData())
+	if got := restored.PromptHistory(); len(got) != len(history) || !got[0].SentAt.Equal(history[0].SentAt) {
+		t.Errorf("restored history = %+v, want %+v", got, history)
+	}
+
+	if _, err := instance.ReplayPrompt(len(history)); err == nil {
+		t.Error("ReplayPrompt() past the end of the history succeeded, want an error")
+	}
+	if _, err := instance.ReplayPrompt(0); err == nil {
+		t.Error("ReplayPrompt() of a paused instance succeeded, want an error")
+	}
+}
@@ -0,0 +1,55 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"sync"
+)
+
+// pushChecks holds the configuration of the checks run before a push.
+var pushChecks struct {
+	mu         sync.RWMutex
+	scan       config.PushScanConfig
+	compliance string
+	// checkpoints is what pushes do with checkpoint commits
+	checkpoints string
+}
+
+// SetPushScan configures the push scan of new worktrees and of worktrees loaded from storage.
+func SetPushScan(scan config.PushScanConfig) {
+	pushChecks.mu.Lock()
+	defer pushChecks.mu.Unlock()
+	pushChecks.scan = scan
+}
+
+// SetComplianceCommand sets the command that must succeed before new worktrees and worktrees
+// loaded from storage push their changes.
+func SetComplianceCommand(command string) {
+	pushChecks.mu.Lock()
+	defer pushChecks.mu.Unlock()
+	pushChecks.compliance = command
+}
+
+// SetCheckpointCommits sets what pushes of new worktrees and of worktrees loaded from storage do
+// with checkpoint commits, a config.CheckpointCommits value. Unknown values keep them.
+func SetCheckpointCommits(mode string) {
+	switch mode {
+	case "", config.CheckpointsKeep, config.CheckpointsSquash, config.CheckpointsFixup:
+	default:
+		log.WarningLog.Printf("unknown checkpoint_commits %q, want %s, %s or %s, keeping checkpoint commits",
+			mode, config.CheckpointsKeep, config.CheckpointsSquash, config.CheckpointsFixup)
+		mode = config.CheckpointsKeep
+	}
+	pushChecks.mu.Lock()
+	defer pushChecks.mu.Unlock()
+	pushChecks.checkpoints = mode
+}
+
+// configurePushChecks applies the configured push checks to the instance's worktree.
+func (i *Instance) configurePushChecks() {
+	pushChecks.mu.RLock()
+	defer pushChecks.mu.RUnlock()
+	i.gitWorktree.SetPushScan(pushChecks.scan)
+	i.gitWorktree.SetComplianceCommand(pushChecks.compliance)
+	i.gitWorktree.SetCheckpointCommits(pushChecks.checkpoints)
+}
@@ -0,0 +1,89 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MaxQuickActions is the number of quick actions kept, one per digit key.
+const MaxQuickActions = 9
+
+var quickActions struct {
+	mu      sync.RWMutex
+	actions []config.QuickAction
+}
+
+// SetQuickActions sets the quick actions offered for instances. Invalid actions and those past
+// MaxQuickActions are dropped with a warning.
+func SetQuickActions(actions []config.QuickAction) {
+	valid := make([]config.QuickAction, 0, len(actions))
+	for _, action := range actions {
+		if err := action.Validate(); err != nil {
+			log.WarningLog.Printf("%v, ignoring it", err)
+			continue
+		}
+		if len(valid) == MaxQuickActions {
+			log.WarningLog.Printf("only the first %d quick actions are used, ignoring %q", MaxQuickActions, action.Label)
+			continue
+		}
+		valid = append(valid, action)
+	}
+	quickActions.mu.Lock()
+	defer quickActions.mu.Unlock()
+	quickActions.actions = valid
+}
+
+// QuickActions returns the quick actions offered for instances, in the order they are numbered.
+func QuickActions() []config.QuickAction {
+	quickActions.mu.RLock()
+	defer quickActions.mu.RUnlock()
+	return quickActions.actions
+}
+
+// namedKeys are the bytes sent for key names of quick actions.
+var namedKeys = map[string]string{
+	"escape":    "\x1b",
+	"esc":       "\x1b",
+	"enter":     "\r",
+	"tab":       "\t",
+	"backspace": "\x7f",
+	"space":     " ",
+	"up":        "\x1b[A",
+	"down":      "\x1b[B",
+	"right":     "\x1b[C",
+	"left":      "\x1b[D",
+}
+
+// keySequence returns the bytes to send for the keys of a quick action. Key names are matched
+// regardless of case; C-x is control and the letter x. Anything else is sent as typed.
+func keySequence(keys []string) string {
+	var b strings.Builder
+	for _, key := range keys {
+		if seq, ok := namedKeys[strings.ToLower(key)]; ok {
+			b.WriteString(seq)
+			continue
+		}
+		if len(key) == 3 && (key[:2] == "C-" || key[:2] == "c-") {
+			if c := key[2] | 0x20; c >= 'a' && c <= 'z' {
+				b.WriteByte(c - 'a' + 1)
+				continue
+			}
+		}
+		b.WriteString(key)
+	}
+	return b.String()
+}
+
+// RunQuickAction sends the prompt or keys of action to the instance's program.
+func (i *Instance) RunQuickAction(action config.QuickAction) error {
+	if !i.started || i.Paused() || i.Status == Exited {
+		return fmt.Errorf("cannot run %q in %s: its program is not running", action.Label, i.Title)
+	}
+	if action.Prompt != "" {
+		return i.SendPrompt(action.Prompt)
+	}
+	return i.tmuxSession.SendKeys(keySequence(action.Keys))
+}
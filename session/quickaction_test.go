@@ -0,0 +1,44 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"testing"
+)
+
+func TestKeySequence(t *testing.T) {
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{keys: []string{"Escape"}, want: "\x1b"},
+		{keys: []string{"C-c", "up", "Enter"}, want: "\x03\x1b[A\r"},
+		{keys: []string{"/clear", "enter"}, want: "/clear\r"},
+		{keys: []string{"C-1"}, want: "C-1"},
+	}
+	for _, tt := range tests {
+		if got := keySequence(tt.keys); got != tt.want {
+			t.Errorf("keySequence(%q) = %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+}
+
+func TestSetQuickActions(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Cleanup(func() { SetQuickActions(nil) })
+
+	actions := []config.QuickAction{
+		{Label: "compact", Prompt: "/compact"},
+		{Label: "both", Prompt: "/test", Keys: []string{"Enter"}},
+		{Prompt: "no label"},
+	}
+	for range MaxQuickActions {
+		actions = append(actions, config.QuickAction{Label: "escape", Keys: []string{"Escape"}})
+	}
+	SetQuickActions(actions)
+	got := QuickActions()
+	if len(got) != MaxQuickActions || got[0].Label != "compact" || got[1].Label != "escape" {
+		t.Errorf("QuickActions() = %+v, want compact then escapes, %d in all", got, MaxQuickActions)
+	}
+}
@@ -0,0 +1,190 @@
+package session
+
+import (
+	"bytes"
+	"claude-squad/session/git"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// Report summarizes the work of instances, for a standup or a pull request description.
+type Report struct {
+	Generated time.Time
+	Entries   []ReportEntry
+	// Added and Removed total the diff stats of the entries.
+	Added   int
+	Removed int
+}
+
+// ReportEntry is the summary of one instance in a Report.
+type ReportEntry struct {
+	Title   string
+	Status  string
+	Program string
+	Branch  string
+	// BranchURL links to the branch on the web, if the repository's origin has a web address.
+	BranchURL string
+	Ticket    string
+	TicketURL string
+	Prompt    string
+	Notes     string
+	Created   time.Time
+	// Duration is the time from the instance's creation to its last saved update.
+	Duration time.Duration
+	Added    int
+	Removed  int
+	// Files are the paths changed in the instance's last diff.
+	Files []string
+}
+
+// NewReport builds a report of the saved data of instances, reading only the origin remote of
+// their repositories to link branches.
+func NewReport(instances []InstanceData) Report {
+	report := Report{Generated: time.Now()}
+	for _, data := range instances {
+		entry := ReportEntry{
+			Title:    data.Title,
+			Status:   data.Status.String(),
+			Program:  data.Program,
+			Branch:   data.Branch,
+			Prompt:   data.Prompt,
+			Notes:    data.Notes,
+			Created:  data.CreatedAt,
+			Duration: data.UpdatedAt.Sub(data.CreatedAt).Round(time.Minute),
+			Added:    data.DiffStats.Added,
+			Removed:  data.DiffStats.Removed,
+			Files:    diffFiles(data.DiffStats.Content),
+		}
+		if entry.Duration < 0 {
+			entry.Duration = 0
+		}
+		if data.Ticket != "" {
+			entry.Ticket = data.Ticket
+			if ticket, err := ParseTicket(data.Ticket); err == nil {
+				entry.Ticket = ticket.Key
+				entry.TicketURL = ticket.URL
+			}
+		}
+		if data.Branch != "" && data.Worktree.RepoPath != "" && !data.InPlace {
+			if url, err := git.BranchWebURL(data.Worktree.RepoPath, data.Branch); err == nil {
+				entry.BranchURL = url
+			}
+		}
+		report.Added += entry.Added
+		report.Removed += entry.Removed
+		report.Entries = append(report.Entries, entry)
+	}
+	return report
+}
+
+// RanFor returns the duration of the instance in minutes, e.g. "2h30m".
+func (e ReportEntry) RanFor() string {
+	return formatMinutes(e.Duration)
+}
+
+// diffFiles returns the paths changed in a unified diff produced by git.
+func diffFiles(diff string) []string {
+	var files []string
+	for _, line := range strings.Split(diff, "\n") {
+		if !strings.HasPrefix(line, "diff --git a/") {
+			continue
+		}
+		if idx := strings.LastIndex(line, " b/"); idx >= 0 {
+			files = append(files, line[idx+len(" b/"):])
+		}
+	}
+	return files
+}
+
+// Markdown renders the report as Markdown.
+func (r Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Squad report\n\nGenerated %s · %d instances · +%d -%d\n",
+		r.Generated.Format("2006-01-02 15:04"), len(r.Entries), r.Added, r.Removed)
+	for _, e := range r.Entries {
+		fmt.Fprintf(&b, "\n## %s\n\n", e.Title)
+		fmt.Fprintf(&b, "- **Status:** %s\n", e.Status)
+		fmt.Fprintf(&b, "- **Program:** `%s`\n", e.Program)
+		if e.Branch != "" {
+			branch := "`" + e.Branch + "`"
+			if e.BranchURL != "" {
+				branch = fmt.Sprintf("[%s](%s)", branch, e.BranchURL)
+			}
+			fmt.Fprintf(&b, "- **Branch:** %s\n", branch)
+		}
+		if e.Ticket != "" {
+			ticket := e.Ticket
+			if e.TicketURL != "" {
+				ticket = fmt.Sprintf("[%s](%s)", ticket, e.TicketURL)
+			}
+			fmt.Fprintf(&b, "- **Ticket:** %s\n", ticket)
+		}
+		fmt.Fprintf(&b, "- **Started:** %s, ran for %s\n", e.Created.Format("2006-01-02 15:04"), e.RanFor())
+		fmt.Fprintf(&b, "- **Diff:** +%d -%d in %d files\n", e.Added, e.Removed, len(e.Files))
+		if e.Prompt != "" {
+			b.WriteString("\n**Prompt**\n\n")
+			for _, line := range strings.Split(strings.TrimSpace(e.Prompt), "\n") {
+				b.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+			}
+		}
+		if e.Notes != "" {
+			fmt.Fprintf(&b, "\n**Notes**\n\n%s\n", strings.TrimSpace(e.Notes))
+		}
+		if len(e.Files) > 0 {
+			b.WriteString("\n**Files changed**\n\n")
+			for _, file := range e.Files {
+				fmt.Fprintf(&b, "- `%s`\n", file)
+			}
+		}
+	}
+	return b.String()
+}
+
+var reportHTML = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Squad report</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+section { border-top: 1px solid #ddd; padding: 0.5rem 0 1rem; }
+dt { font-weight: bold; float: left; width: 6rem; }
+dd { margin: 0 0 0.25rem 6rem; }
+blockquote { border-left: 3px solid #ccc; margin: 0.5rem 0; padding-left: 1rem; white-space: pre-wrap; }
+.added { color: #1a7f37; } .removed { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>Squad report</h1>
+<p>Generated {{.Generated.Format "2006-01-02 15:04"}} · {{len .Entries}} instances · <span class="added">+{{.Added}}</span> <span class="removed">-{{.Removed}}</span></p>
+{{range .Entries}}<section>
+<h2>{{.Title}}</h2>
+<dl>
+<dt>Status</dt><dd>{{.Status}}</dd>
+<dt>Program</dt><dd><code>{{.Program}}</code></dd>
+{{if .Branch}}<dt>Branch</dt><dd>{{if .BranchURL}}<a href="{{.BranchURL}}"><code>{{.Branch}}</code></a>{{else}}<code>{{.Branch}}</code>{{end}}</dd>
+{{end}}{{if .Ticket}}<dt>Ticket</dt><dd>{{if .TicketURL}}<a href="{{.TicketURL}}">{{.Ticket}}</a>{{else}}{{.Ticket}}{{end}}</dd>
+{{end}}<dt>Started</dt><dd>{{.Created.Format "2006-01-02 15:04"}}, ran for {{.RanFor}}</dd>
+<dt>Diff</dt><dd><span class="added">+{{.Added}}</span> <span class="removed">-{{.Removed}}</span> in {{len .Files}} files</dd>
+</dl>
+{{if .Prompt}}<h3>Prompt</h3>
+<blockquote>{{.Prompt}}</blockquote>
+{{end}}{{if .Notes}}<h3>Notes</h3>
+<p>{{.Notes}}</p>
+{{end}}{{if .Files}}<h3>Files changed</h3>
+<ul>{{range .Files}}<li><code>{{.}}</code></li>{{end}}</ul>
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
+
+// HTML renders the report as a standalone HTML page.
+func (r Report) HTML() (string, error) {
+	var b bytes.Buffer
+	if err := reportHTML.Execute(&b, r); err != nil {
+		return "", fmt.Errorf("failed to render the report: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,44 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReport(t *testing.T) {
+	created := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	report := NewReport([]InstanceData{
+		{
+			Title: "auth", Status: Ready, Program: "claude", Branch: "session/auth",
+			CreatedAt: created, UpdatedAt: created.Add(150 * time.Minute),
+			Prompt: "add login\nwith tests", Notes: "needs review", Ticket: "ABC-12",
+			DiffStats: DiffStatsData{Added: 10, Removed: 2, Content: "diff --git a/auth.go b/auth.go\n+x\ndiff --git a/auth_test.go b/auth_test.go\n"},
+		},
+		{Title: "docs <draft>", Status: Paused, Program: "aider", CreatedAt: created, UpdatedAt: created},
+	})
+	if report.Added != 10 || report.Removed != 2 || len(report.Entries) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	markdown := report.Markdown()
+	for _, want := range []string{
+		"2 instances · +10 -2", "## auth", "- **Status:** ready", "- **Branch:** `session/auth`",
+		"- **Ticket:** ABC-12", "ran for 2h30m", "+10 -2 in 2 files", "> add login\n> with tests",
+		"needs review", "- `auth_test.go`", "## docs <draft>",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Markdown() lacks %q:\n%s", want, markdown)
+		}
+	}
+
+	html, err := report.HTML()
+	if err != nil {
+		t.Fatalf("HTML() error: %v", err)
+	}
+	for _, want := range []string{"<h2>auth</h2>", "<code>auth_test.go</code>", "<h2>docs &lt;draft&gt;</h2>", "ran for 2h30m"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML() lacks %q:\n%s", want, html)
+		}
+	}
+}
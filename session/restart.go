@@ -0,0 +1,97 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stableRunTime is how long a restarted program has to run before exiting again for the restart
+// to no longer count towards the retries of its policy.
+const stableRunTime = 10 * time.Minute
+
+// restartPolicies holds the policies deciding whether exited programs are restarted.
+var restartPolicies struct {
+	mu       sync.RWMutex
+	fallback config.RestartPolicy
+	programs map[string]config.RestartPolicy
+}
+
+// SetRestartPolicies sets the restart policy of instances. programs overrides fallback for
+// instances whose program's command name is a key. Policies with an unknown mode are replaced by
+// config.RestartNever.
+func SetRestartPolicies(fallback config.RestartPolicy, programs map[string]config.RestartPolicy) {
+	restartPolicies.mu.Lock()
+	defer restartPolicies.mu.Unlock()
+	restartPolicies.fallback = validRestartPolicy(fallback)
+	restartPolicies.programs = make(map[string]config.RestartPolicy, len(programs))
+	for program, policy := range programs {
+		restartPolicies.programs[program] = validRestartPolicy(policy)
+	}
+}
+
+func validRestartPolicy(policy config.RestartPolicy) config.RestartPolicy {
+	if err := policy.Validate(); err != nil {
+		log.WarningLog.Printf("%v, not restarting programs", err)
+		return config.RestartPolicy{Mode: config.RestartNever}
+	}
+	return policy
+}
+
+// restartPolicyFor returns the restart policy for an instance running program, such as
+// "aider --model sonnet".
+func restartPolicyFor(program string) config.RestartPolicy {
+	restartPolicies.mu.RLock()
+	defer restartPolicies.mu.RUnlock()
+	if fields := strings.Fields(program); len(fields) > 0 {
+		if policy, ok := restartPolicies.programs[filepath.Base(fields[0])]; ok {
+			return policy
+		}
+	}
+	return restartPolicies.fallback
+}
+
+// AutoRestart restarts the program of an exited instance if the restart policy of its program
+// says so and its backoff has passed since the program exited. A program whose exit status is
+// unknown counts as crashed. Once the policy's retries are used up, an EventError is emitted and
+// the instance is left exited. It returns whether the program was restarted.
+func (i *Instance) AutoRestart(now time.Time) (bool, error) {
+	if i.Status != Exited {
+		return false, nil
+	}
+	policy := restartPolicyFor(i.Program)
+	if !policy.Enabled() || (policy.Mode == config.RestartOnCrash && i.ExitCode == 0) {
+		return false, nil
+	}
+	if i.exitedAt.IsZero() {
+		// Exited before it was loaded from storage
+		i.exitedAt = now
+	}
+	if !i.RestartedAt.IsZero() && i.exitedAt.Sub(i.RestartedAt) > stableRunTime {
+		i.Restarts = 0
+	}
+	if i.Restarts >= policy.Retries() {
+		if !i.restartGaveUp {
+			i.restartGaveUp = true
+			i.EmitError(fmt.Errorf("gave up restarting %s after %d restarts in a row", i.Program, i.Restarts))
+		}
+		return false, nil
+	}
+	if now.Before(i.exitedAt.Add(policy.Backoff(i.Restarts + 1))) {
+		return false, nil
+	}
+
+	description := i.ExitDescription()
+	if err := i.respawn(); err != nil {
+		return false, fmt.Errorf("failed to restart %s: %w", i.Title, err)
+	}
+	i.Restarts++
+	i.RestartedAt = now
+	log.InfoLog.Printf("%s, restarted it in %s (attempt %d)", description, i.Title, i.Restarts)
+	i.emit(Event{Type: EventRestarted, Attempt: i.Restarts})
+	return true, nil
+}
@@ -0,0 +1,55 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"testing"
+	"time"
+)
+
+func TestAutoRestartWaitsAndGivesUp(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	SetRestartPolicies(config.RestartPolicy{Mode: config.RestartOnCrash, MaxRetries: 2}, map[string]config.RestartPolicy{
+		"aider": {Mode: config.RestartNever},
+	})
+	t.Cleanup(func() { SetRestartPolicies(config.RestartPolicy{}, nil) })
+
+	var failures []Event
+	unsubscribe := Subscribe(func(e Event) {
+		if e.Type == EventError && e.Instance == "crashy" {
+			failures = append(failures, e)
+		}
+	})
+	defer unsubscribe()
+
+	now := time.Now()
+	tests := []struct {
+		name     string
+		instance *Instance
+	}{
+		{name: "never for the program", instance: &Instance{Title: "crashy", Program: "aider --model sonnet", Status: Exited, ExitCode: 1}},
+		{name: "exited normally", instance: &Instance{Title: "crashy", Program: "claude", Status: Exited, ExitCode: 0}},
+		{name: "still running", instance: &Instance{Title: "crashy", Program: "claude", Status: Running}},
+		{name: "backoff not passed", instance: &Instance{Title: "crashy", Program: "claude", Status: Exited, ExitCode: -1, exitedAt: now}},
+	}
+	for _, tt := range tests {
+		if restarted, err := tt.instance.AutoRestart(now); restarted || err != nil {
+			t.Errorf("%s: AutoRestart() = %v, %v, want no restart", tt.name, restarted, err)
+		}
+	}
+	if len(failures) != 0 {
+		t.Fatalf("failures = %+v, want none", failures)
+	}
+
+	instance := &Instance{Title: "crashy", Program: "claude", Status: Exited, ExitCode: 1, Restarts: 2,
+		RestartedAt: now.Add(-time.Minute), exitedAt: now.Add(-time.Second)}
+	for range 2 {
+		if restarted, err := instance.AutoRestart(now.Add(time.Hour)); restarted || err != nil {
+			t.Errorf("AutoRestart() after the retries = %v, %v, want no restart", restarted, err)
+		}
+	}
+	if len(failures) != 1 {
+		t.Errorf("got %d failures after running out of retries, want 1", len(failures))
+	}
+}
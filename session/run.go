@@ -0,0 +1,159 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// MaxRunOutput is the number of bytes of output a command run keeps. Older output is dropped.
+const MaxRunOutput = 256 * 1024
+
+// CommandRun is a shell command run in the directory of an instance, in its own PTY apart from the
+// program's pane, such as a quick go test ./... while the agent works.
+type CommandRun struct {
+	Command string
+	Dir     string
+	Started time.Time
+
+	cancel context.CancelFunc
+	ptmx   *os.File
+	done   chan struct{}
+
+	mu     sync.Mutex
+	output []byte
+	ended  time.Time
+	err    error
+}
+
+// RunCommand starts command with sh in the instance's directory. The run is kept as the
+// instance's LastRun, and EventCommandFinished is emitted when it ends.
+func (i *Instance) RunCommand(command string) (*CommandRun, error) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return nil, fmt.Errorf("no command to run")
+	}
+	dir := i.WorkDir()
+	if dir == "" || i.Paused() {
+		return nil, fmt.Errorf("cannot run a command in %s: it is not running", i.Title)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: 120, Rows: 40})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to run %q: %w", command, err)
+	}
+
+	run := &CommandRun{Command: command, Dir: dir, Started: time.Now(), cancel: cancel, ptmx: ptmx, done: make(chan struct{})}
+	go func() {
+		defer close(run.done)
+		// Reading fails with EIO once the command and its children have exited
+		_, _ = io.Copy(run, ptmx)
+		err := cmd.Wait()
+		ptmx.Close()
+		if ctx.Err() != nil {
+			err = errors.New("canceled")
+		}
+		cancel()
+		run.mu.Lock()
+		run.ended = time.Now()
+		run.err = err
+		run.mu.Unlock()
+
+		e := Event{Type: EventCommandFinished, Command: command}
+		if err != nil {
+			e.Error = err.Error()
+		}
+		i.emit(e)
+	}()
+
+	i.runMu.Lock()
+	i.lastRun = run
+	i.runMu.Unlock()
+	return run, nil
+}
+
+// LastRun returns the last command run in the instance, or nil.
+func (i *Instance) LastRun() *CommandRun {
+	i.runMu.Lock()
+	defer i.runMu.Unlock()
+	return i.lastRun
+}
+
+// Write appends output of the command.
+func (r *CommandRun) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.output = append(r.output, p...)
+	if len(r.output) > MaxRunOutput {
+		r.output = r.output[len(r.output)-MaxRunOutput:]
+	}
+	return len(p), nil
+}
+
+// Output returns the output of the command so far, with the line endings of the PTY turned into
+// newlines.
+func (r *CommandRun) Output() string {
+	r.mu.Lock()
+	output := string(r.output)
+	r.mu.Unlock()
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+	// Progress bars redraw their line after a carriage return; keep what was drawn last
+	lines := strings.Split(output, "\n")
+	for n, line := range lines {
+		if idx := strings.LastIndex(strings.TrimRight(line, "\r"), "\r"); idx >= 0 {
+			lines[n] = line[idx+1:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Done reports whether the command has ended.
+func (r *CommandRun) Done() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Wait waits for the command to end and returns its error, nil if it succeeded.
+func (r *CommandRun) Wait() error {
+	<-r.done
+	return r.Err()
+}
+
+// Err returns the error the command ended with, nil while it runs or if it succeeded.
+func (r *CommandRun) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Duration returns how long the command ran, or has been running.
+func (r *CommandRun) Duration() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ended.IsZero() {
+		return time.Since(r.Started)
+	}
+	return r.ended.Sub(r.Started)
+}
+
+// Cancel kills the command. Closing its PTY hangs up the processes it started.
+func (r *CommandRun) Cancel() {
+	r.cancel()
+	_ = r.ptmx.Close()
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCommand(t *testing.T) {
+	dir := t.TempDir()
+	instance := &Instance{Title: "runner", Path: dir, InPlace: true, started: true}
+
+	finished := make(chan Event, 2)
+	unsubscribe := Subscribe(func(e Event) {
+		if e.Type == EventCommandFinished && e.Instance == "runner" {
+			finished <- e
+		}
+	})
+	defer unsubscribe()
+
+	run, err := instance.RunCommand("pwd; echo 'half\rdone'; exit 3")
+	if err != nil {
+		t.Fatalf("RunCommand() error: %v", err)
+	}
+	if err := run.Wait(); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("Wait() = %v, want exit status 3", err)
+	}
+	if output := run.Output(); !strings.Contains(output, dir) || !strings.Contains(output, "\ndone\n") {
+		t.Errorf("Output() = %q, want the directory and the last redraw of the line", output)
+	}
+	if instance.LastRun() != run {
+		t.Error("LastRun() is not the run")
+	}
+	select {
+	case e := <-finished:
+		if e.Command != run.Command || !strings.Contains(e.Error, "exit status 3") {
+			t.Errorf("event = %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no EventCommandFinished")
+	}
+
+	run, err = instance.RunCommand("sleep 30")
+	if err != nil {
+		t.Fatalf("RunCommand() error: %v", err)
+	}
+	run.Cancel()
+	done := make(chan error)
+	go func() { done <- run.Wait() }()
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "canceled" {
+			t.Errorf("Wait() after Cancel() = %v, want canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the canceled command is still running")
+	}
+
+	if _, err := instance.RunCommand("  "); err == nil {
+		t.Error("RunCommand() without a command succeeded")
+	}
+}
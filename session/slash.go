@@ -0,0 +1,110 @@
+package session
+
+import (
+	"claude-squad/session/tmux"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SlashCommand is a Claude Code slash command sent to an instance's program.
+type SlashCommand string
+
+const (
+	// SlashCompact summarizes the conversation to free up context.
+	SlashCompact SlashCommand = "/compact"
+	// SlashClear starts the conversation over.
+	SlashClear SlashCommand = "/clear"
+	// SlashCost shows the cost and duration of the session, read into the instance's Cost.
+	SlashCost SlashCommand = "/cost"
+	// SlashReview asks for a review of the changes.
+	SlashReview SlashCommand = "/review"
+)
+
+// SlashCommands lists the slash commands offered for Claude Code instances.
+var SlashCommands = []SlashCommand{SlashCompact, SlashClear, SlashCost, SlashReview}
+
+// Description describes what the slash command does.
+func (c SlashCommand) Description() string {
+	switch c {
+	case SlashCompact:
+		return "summarize the conversation to free up context"
+	case SlashClear:
+		return "clear the conversation"
+	case SlashCost:
+		return "show the session's cost and record it"
+	case SlashReview:
+		return "review the changes"
+	}
+	return ""
+}
+
+// CostReport is the cost of a Claude Code session, as shown by /cost.
+type CostReport struct {
+	// USD is the total cost in US dollars.
+	USD float64 `json:"usd"`
+	// APIDuration and WallDuration are the durations shown, such as "1m 2.3s".
+	APIDuration  string    `json:"api_duration,omitempty"`
+	WallDuration string    `json:"wall_duration,omitempty"`
+	ReadAt       time.Time `json:"read_at"`
+}
+
+var (
+	costPattern         = regexp.MustCompile(`Total cost:\s+\$([0-9]+(?:\.[0-9]+)?)`)
+	apiDurationPattern  = regexp.MustCompile(`Total duration \(API\):\s+(.+)`)
+	wallDurationPattern = regexp.MustCompile(`Total duration \(wall\):\s+(.+)`)
+)
+
+// parseCostReport returns the last cost report shown in content.
+func parseCostReport(content string) (*CostReport, bool) {
+	idx := strings.LastIndex(content, "Total cost:")
+	if idx < 0 {
+		return nil, false
+	}
+	report := content[idx:]
+	match := costPattern.FindStringSubmatch(report)
+	if match == nil {
+		return nil, false
+	}
+	usd, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return nil, false
+	}
+	cost := &CostReport{USD: usd, ReadAt: time.Now()}
+	if m := apiDurationPattern.FindStringSubmatch(report); m != nil {
+		cost.APIDuration = strings.TrimSpace(m[1])
+	}
+	if m := wallDurationPattern.FindStringSubmatch(report); m != nil {
+		cost.WallDuration = strings.TrimSpace(m[1])
+	}
+	return cost, true
+}
+
+// SendSlashCommand sends a slash command to the instance's program, which must be Claude Code.
+// The output of SlashCost is read with ReadCost.
+func (i *Instance) SendSlashCommand(command SlashCommand) error {
+	if !tmux.IsClaudeProgram(i.Program) {
+		return fmt.Errorf("%s only works in claude, not %s", command, i.Program)
+	}
+	if !i.started || i.Paused() || i.Status == Exited {
+		return fmt.Errorf("cannot send %s to %s: its program is not running", command, i.Title)
+	}
+	return i.sendPrompt(string(command))
+}
+
+// ReadCost reads the cost last shown by /cost in the instance's pane into Cost. It returns false
+// if no cost is shown.
+func (i *Instance) ReadCost() (bool, error) {
+	content, err := i.Preview()
+	if err != nil {
+		return false, err
+	}
+	cost, ok := parseCostReport(content)
+	if !ok {
+		return false, nil
+	}
+	i.Cost = cost
+	return true, nil
+}
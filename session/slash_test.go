@@ -0,0 +1,25 @@
+package session
+
+import "testing"
+
+func TestParseCostReport(t *testing.T) {
+	content := `> /cost
+  ⎿  Total cost:            $0.0100
+     Total duration (API):  10s
+> /cost
+  ⎿  Total cost:            $1.2345
+     Total duration (API):  1m 2.3s
+     Total duration (wall): 5m 3.4s
+     Total code changes:    12 lines added, 3 lines removed
+>`
+	cost, ok := parseCostReport(content)
+	if !ok {
+		t.Fatal("parseCostReport() found no cost")
+	}
+	if cost.USD != 1.2345 || cost.APIDuration != "1m 2.3s" || cost.WallDuration != "5m 3.4s" {
+		t.Errorf("parseCostReport() = %+v, want the last report", cost)
+	}
+	if _, ok := parseCostReport("> hello"); ok {
+		t.Error("parseCostReport() found a cost in output without one")
+	}
+}
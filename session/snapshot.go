@@ -0,0 +1,100 @@
+package session
+
+import (
+	"claude-squad/log"
+	"claude-squad/session/git"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// snapshotPromptPatterns holds the case-insensitive substrings that make a prompt risky enough to
+// snapshot the worktree before sending it. "*" matches every prompt.
+var snapshotPromptPatterns struct {
+	mu       sync.RWMutex
+	patterns []string
+}
+
+// SetSnapshotPromptPatterns sets the substrings of prompts that trigger a snapshot before they are
+// sent. An empty list disables automatic snapshots.
+func SetSnapshotPromptPatterns(patterns []string) {
+	snapshotPromptPatterns.mu.Lock()
+	defer snapshotPromptPatterns.mu.Unlock()
+	snapshotPromptPatterns.patterns = make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern = strings.ToLower(strings.TrimSpace(pattern)); pattern != "" {
+			snapshotPromptPatterns.patterns = append(snapshotPromptPatterns.patterns, pattern)
+		}
+	}
+}
+
+// isRiskyPrompt returns true if prompt matches one of the snapshot prompt patterns.
+func isRiskyPrompt(prompt string) bool {
+	snapshotPromptPatterns.mu.RLock()
+	defer snapshotPromptPatterns.mu.RUnlock()
+	prompt = strings.ToLower(prompt)
+	for _, pattern := range snapshotPromptPatterns.patterns {
+		if pattern == "*" || strings.Contains(prompt, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshotWorktree returns the worktree of an instance that can be snapshotted.
+func (i *Instance) snapshotWorktree() (*git.GitWorktree, error) {
+	if !i.started || i.Paused() {
+		return nil, fmt.Errorf("instance %s is not running", i.Title)
+	}
+	if i.InPlace || i.gitWorktree == nil {
+		return nil, fmt.Errorf("instance %s has no worktree to snapshot", i.Title)
+	}
+	return i.gitWorktree, nil
+}
+
+// Snapshot saves the files of the instance's worktree, including untracked files that commits
+// miss, so they can be restored with Rollback.
+func (i *Instance) Snapshot(message string) (*git.Snapshot, error) {
+	worktree, err := i.snapshotWorktree()
+	if err != nil {
+		return nil, err
+	}
+	return worktree.Snapshot(message)
+}
+
+// Snapshots returns the snapshots of the instance, newest first.
+func (i *Instance) Snapshots() ([]git.Snapshot, error) {
+	worktree, err := i.snapshotWorktree()
+	if err != nil {
+		return nil, err
+	}
+	return worktree.Snapshots()
+}
+
+// Rollback restores the instance's worktree to snapshot. The current state is snapshotted first,
+// so a rollback can itself be rolled back.
+func (i *Instance) Rollback(snapshot git.Snapshot) error {
+	worktree, err := i.snapshotWorktree()
+	if err != nil {
+		return err
+	}
+	if _, err := worktree.Snapshot("before rollback to " + snapshot.Message); err != nil {
+		return fmt.Errorf("failed to snapshot before rollback: %w", err)
+	}
+	return worktree.Rollback(snapshot)
+}
+
+// snapshotBeforePrompt snapshots the worktree if prompt is risky. Failures are logged rather than
+// blocking the prompt.
+func (i *Instance) snapshotBeforePrompt(prompt string) {
+	if i.InPlace || !isRiskyPrompt(prompt) {
+		return
+	}
+	summary := prompt
+	if runes := []rune(summary); len(runes) > 50 {
+		summary = string(runes[:50]) + "..."
+	}
+	if _, err := i.Snapshot("before prompt: " + summary); err != nil {
+		log.WarningLog.Printf("could not snapshot %s before prompt: %v", i.Title, err)
+	}
+}
@@ -19,8 +19,32 @@ type InstanceData struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	AutoYes   bool      `json:"auto_yes"`
+	// AutoYesUntil is when AutoYes turns back off, zero to keep it on.
+	AutoYesUntil time.Time `json:"auto_yes_until"`
 	NoTTY     bool      `json:"no_tty"`
 	InPlace   bool      `json:"in_place"`
+	// VCS is the kind of workspace, empty for older data working in git worktrees.
+	VCS       string    `json:"vcs,omitempty"`
+	Parent    string    `json:"parent,omitempty"`
+	Relation  Relation  `json:"relation,omitempty"`
+	Prompt    string    `json:"prompt,omitempty"`
+	Notes     string    `json:"notes,omitempty"`
+	Ticket    string    `json:"ticket,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	Color     string    `json:"color,omitempty"`
+	ExitCode  int       `json:"exit_code,omitempty"`
+	Restarts  int       `json:"restarts,omitempty"`
+	// RestartedAt is when a restart policy last restarted the program.
+	RestartedAt time.Time `json:"restarted_at,omitempty"`
+	// Cost is the cost of the Claude Code session last read from /cost.
+	Cost *CostReport `json:"cost,omitempty"`
+	// TmuxSession is the name of the instance's tmux session. Older data lacks it, in which case
+	// the name is derived from the title.
+	TmuxSession string `json:"tmux_session,omitempty"`
+	// GitConfig holds the git settings the instance's worktree overrides.
+	GitConfig map[string]string `json:"git_config,omitempty"`
+	// Prompts is the prompt history of the instance, oldest first.
+	Prompts []PromptRecord `json:"prompts,omitempty"`
 
 	Program   string          `json:"program"`
 	Worktree  GitWorktreeData `json:"worktree"`
@@ -34,6 +58,11 @@ type GitWorktreeData struct {
 	SessionName   string `json:"session_name"`
 	BranchName    string `json:"branch_name"`
 	BaseCommitSHA string `json:"base_commit_sha"`
+	// ExistingBranch is set when the worktree checks out a branch it didn't create, which is kept
+	// when the instance is killed.
+	ExistingBranch bool `json:"existing_branch,omitempty"`
+	// DiffBase is what the diff compares the worktree with, see git.GitWorktree.SetDiffBase.
+	DiffBase string `json:"diff_base,omitempty"`
 }
 
 // DiffStatsData represents the serializable data of a DiffStats
@@ -43,6 +72,17 @@ type DiffStatsData struct {
 	Content string `json:"content"`
 }
 
+// InstanceStore is the subset of Storage used by consumers that only read and
+// persist instances, such as the web server. It lets tests substitute mocks.
+type InstanceStore interface {
+	LoadInstances() ([]*Instance, error)
+	SaveInstances(instances []*Instance) error
+	DeleteInstance(title string) error
+}
+
+// Ensure Storage implements InstanceStore
+var _ InstanceStore = (*Storage)(nil)
+
 // Storage handles saving and loading instances using the state interface
 type Storage struct {
 	state config.InstanceStorage
@@ -108,6 +148,32 @@ func (s *Storage) LoadInstances() ([]*Instance, error) {
 	return instances, nil
 }
 
+// LoadInstanceMetadata loads the list of instances from disk without reattaching them to their
+// tmux sessions, which is left to Instance.Restore. It is fast enough to show the instances
+// before any of them is restored.
+func (s *Storage) LoadInstanceMetadata() ([]*Instance, error) {
+	instancesData, err := s.LoadInstanceData()
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]*Instance, len(instancesData))
+	for i, data := range instancesData {
+		instances[i] = FromInstanceMetadata(data)
+	}
+	return instances, nil
+}
+
+// LoadInstanceData loads the saved data of the instances without creating them, for callers
+// that only read it, such as the status command.
+func (s *Storage) LoadInstanceData() ([]InstanceData, error) {
+	var instancesData []InstanceData
+	if err := json.Unmarshal(s.state.GetInstances(), &instancesData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal instances: %w", err)
+	}
+	return instancesData, nil
+}
+
 // PreloadSimpleMode ensures that an empty instance list can be loaded even if storage is corrupt
 func (s *Storage) PreloadSimpleMode() {
 	// Check if we can load instances
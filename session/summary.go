@@ -0,0 +1,64 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Summary is an overview of a set of instances, cheap enough to poll from a status bar.
+type Summary struct {
+	Instances int `json:"instances"`
+	// ByStatus counts instances by status, with every status present.
+	ByStatus map[string]int `json:"by_status"`
+	// Added and Removed total the last diff stats of the instances.
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	// AwaitingPrompt lists the instances whose program waits for input.
+	AwaitingPrompt []string `json:"awaiting_prompt"`
+}
+
+// Summarize summarizes the saved data of instances. It works on data rather than instances so
+// callers don't have to reattach instances to their tmux sessions.
+func Summarize(instances []InstanceData) Summary {
+	summary := Summary{
+		Instances:      len(instances),
+		ByStatus:       make(map[string]int),
+		AwaitingPrompt: []string{},
+	}
+	for _, status := range []Status{Running, Ready, Loading, Paused, Exited} {
+		summary.ByStatus[status.String()] = 0
+	}
+	for _, data := range instances {
+		summary.ByStatus[data.Status.String()]++
+		summary.Added += data.DiffStats.Added
+		summary.Removed += data.DiffStats.Removed
+		if data.Status == Ready {
+			summary.AwaitingPrompt = append(summary.AwaitingPrompt, data.Title)
+		}
+	}
+	return summary
+}
+
+// Compact returns the summary on one short line for a tmux status bar or a shell prompt, such as
+// "CS: 3▶ 1❓ 1⏸ 1✗": running, awaiting a prompt, paused and exited instances, leaving out zero
+// counts.
+func (s Summary) Compact() string {
+	var parts []string
+	for _, part := range []struct {
+		count  int
+		symbol string
+	}{
+		{s.ByStatus[Running.String()] + s.ByStatus[Loading.String()], "▶"},
+		{len(s.AwaitingPrompt), "❓"},
+		{s.ByStatus[Paused.String()], "⏸"},
+		{s.ByStatus[Exited.String()], "✗"},
+	} {
+		if part.count > 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", part.count, part.symbol))
+		}
+	}
+	if len(parts) == 0 {
+		return "CS: -"
+	}
+	return "CS: " + strings.Join(parts, " ")
+}
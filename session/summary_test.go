@@ -0,0 +1,33 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	summary := Summarize([]InstanceData{
+		{Title: "a", Status: Running, DiffStats: DiffStatsData{Added: 3, Removed: 1}},
+		{Title: "b", Status: Running},
+		{Title: "c", Status: Ready, DiffStats: DiffStatsData{Added: 2}},
+		{Title: "d", Status: Paused, DiffStats: DiffStatsData{Removed: 4}},
+		{Title: "e", Status: Exited, ExitCode: 1},
+	})
+
+	wantByStatus := map[string]int{"running": 2, "ready": 1, "loading": 0, "paused": 1, "exited": 1}
+	if summary.Instances != 5 || !reflect.DeepEqual(summary.ByStatus, wantByStatus) {
+		t.Errorf("instances = %d, by status = %v, want 5 and %v", summary.Instances, summary.ByStatus, wantByStatus)
+	}
+	if summary.Added != 5 || summary.Removed != 5 {
+		t.Errorf("diff = +%d -%d, want +5 -5", summary.Added, summary.Removed)
+	}
+	if !reflect.DeepEqual(summary.AwaitingPrompt, []string{"c"}) {
+		t.Errorf("awaiting prompt = %v, want [c]", summary.AwaitingPrompt)
+	}
+	if got := summary.Compact(); got != "CS: 2▶ 1❓ 1⏸ 1✗" {
+		t.Errorf("Compact() = %q", got)
+	}
+	if got := Summarize(nil).Compact(); got != "CS: -" {
+		t.Errorf("Compact() without instances = %q", got)
+	}
+}
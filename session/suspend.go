@@ -0,0 +1,73 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// continuePrompt is sent to interrupted agents when the global suspend is lifted.
+const continuePrompt = "continue"
+
+// suspendState tracks the global suspend toggle. The TUI and the web server run in the same
+// process, so a press of the suspend key and a call to the API see the same state.
+var suspendState struct {
+	mu        sync.Mutex
+	suspended bool
+	// interrupted holds the titles of the instances interrupted by the suspend, so only those
+	// are told to continue.
+	interrupted map[string]bool
+}
+
+// Suspended returns true if all instances have been suspended with ToggleSuspendAll.
+func Suspended() bool {
+	suspendState.mu.Lock()
+	defer suspendState.mu.Unlock()
+	return suspendState.suspended
+}
+
+// ToggleSuspendAll interrupts every running instance, or tells the interrupted instances to
+// continue if they are already suspended. It returns the new state. The state flips even if some
+// instances fail so one dead session can't block the panic button; the errors are returned
+// together.
+func ToggleSuspendAll(instances []*Instance) (bool, error) {
+	suspendState.mu.Lock()
+	defer suspendState.mu.Unlock()
+
+	var errs []error
+	if !suspendState.suspended {
+		suspendState.interrupted = make(map[string]bool)
+		for _, instance := range instances {
+			if !instance.Started() || instance.Paused() {
+				continue
+			}
+			if err := instance.Interrupt(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", instance.Title, err))
+				continue
+			}
+			suspendState.interrupted[instance.Title] = true
+		}
+	} else {
+		for _, instance := range instances {
+			if !suspendState.interrupted[instance.Title] || instance.Paused() {
+				continue
+			}
+			// Not SendPrompt, so "continue" is never recorded as the instance's task
+			if err := instance.sendPrompt(continuePrompt); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", instance.Title, err))
+			}
+		}
+		suspendState.interrupted = nil
+	}
+
+	suspendState.suspended = !suspendState.suspended
+	return suspendState.suspended, errors.Join(errs...)
+}
+
+// Interrupt stops what the agent running in the instance is doing, without closing its session.
+func (i *Instance) Interrupt() error {
+	if !i.started {
+		return fmt.Errorf("cannot interrupt instance that has not been started")
+	}
+	return i.tmuxSession.Interrupt()
+}
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MaxTags is the number of tags an instance can have.
+const MaxTags = 10
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,31}$`)
+
+// ParseTags splits s, a list of tags separated by commas or spaces, into tags. Tags are lowercased
+// and listed once.
+func ParseTags(s string) ([]string, error) {
+	return normalizeTags(strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }))
+}
+
+// normalizeTags lowercases and trims tags, drops empty and repeated ones, and validates the rest.
+func normalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, ValidateTags(normalized)
+}
+
+// ValidateTags returns an error unless tags are at most MaxTags lowercase words of up to 32
+// letters, digits, dots, dashes and underscores.
+func ValidateTags(tags []string) error {
+	if len(tags) > MaxTags {
+		return fmt.Errorf("an instance can have at most %d tags", MaxTags)
+	}
+	for _, tag := range tags {
+		if !tagPattern.MatchString(tag) {
+			return fmt.Errorf("invalid tag %q: use up to 32 lowercase letters, digits, dots, dashes and underscores", tag)
+		}
+	}
+	return nil
+}
+
+// SetTags replaces the tags of the instance and emits EventTagsChanged if they changed. Tags
+// scope what the daemon watches, see config.DaemonConfig.
+func (i *Instance) SetTags(tags []string) error {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	if slices.Equal(tags, i.Tags) {
+		return nil
+	}
+	i.Tags = tags
+	i.Emit(EventTagsChanged)
+	return nil
+}
+
+// HasAnyTag returns true if the instance has one of tags.
+func (i *Instance) HasAnyTag(tags []string) bool {
+	for _, tag := range tags {
+		if slices.Contains(i.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
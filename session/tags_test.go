@@ -0,0 +1,59 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	for _, tt := range []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{in: "Nightly, review  nightly", want: []string{"nightly", "review"}},
+		{in: "ci-1,team.web,x_y", want: []string{"ci-1", "team.web", "x_y"}},
+		{in: "-leading", wantErr: true},
+		{in: "no/slash", wantErr: true},
+		{in: "a,b,c,d,e,f,g,h,i,j,k", wantErr: true},
+	} {
+		got, err := ParseTags(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTags(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTags(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSetTags(t *testing.T) {
+	instance, err := NewInstance(InstanceOptions{Title: "tagged", Path: "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []Event
+	defer Subscribe(func(e Event) {
+		if e.Instance == "tagged" {
+			events = append(events, e)
+		}
+	})()
+
+	if err := instance.SetTags([]string{"Nightly", "review"}); err != nil {
+		t.Fatalf("SetTags(): %v", err)
+	}
+	if err := instance.SetTags([]string{"nightly", "review"}); err != nil {
+		t.Fatalf("SetTags() again: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != EventTagsChanged || !reflect.DeepEqual(events[0].Tags, []string{"nightly", "review"}) {
+		t.Errorf("events = %+v, want a single EventTagsChanged with the tags", events)
+	}
+	if !instance.HasAnyTag([]string{"other", "review"}) || instance.HasAnyTag([]string{"other"}) {
+		t.Errorf("HasAnyTag() doesn't match the tags %q", instance.Tags)
+	}
+	if err := instance.SetTags([]string{"bad tag!"}); err == nil {
+		t.Errorf("SetTags() with an invalid tag succeeded")
+	}
+}
@@ -0,0 +1,176 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/session/git"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxTicketLength is the maximum length of a ticket reference.
+const MaxTicketLength = 200
+
+// TicketKind is the tracker a ticket reference points to.
+type TicketKind string
+
+const (
+	// TicketGitHub is a GitHub issue, referenced by URL, #123 or owner/repo#123.
+	TicketGitHub TicketKind = "github"
+	// TicketJira is a Jira issue, referenced by URL or key such as ABC-123.
+	TicketJira TicketKind = "jira"
+	// TicketOther is a reference to an unknown tracker. It names branches and commits, but is not
+	// commented on.
+	TicketOther TicketKind = "other"
+)
+
+// Ticket is a parsed ticket reference.
+type Ticket struct {
+	// Ref is the reference as given.
+	Ref  string
+	Kind TicketKind
+	// Key is the short form used in branch names and commit messages, e.g. ABC-123 or #42.
+	Key string
+	// Repo is the owner/name of the repository of a GitHub issue, if the reference names it.
+	Repo string
+	// URL links to the ticket, if it is known.
+	URL string
+}
+
+var (
+	githubIssueURL = regexp.MustCompile(`^https?://github\.com/([\w.-]+/[\w.-]+)/issues/(\d+)/?$`)
+	githubIssueRef = regexp.MustCompile(`^(?:([\w.-]+/[\w.-]+))?#(\d+)$`)
+	jiraIssueURL   = regexp.MustCompile(`^(https?://[^/]+)/browse/([A-Z][A-Z0-9_]+-\d+)/?$`)
+	jiraIssueKey   = regexp.MustCompile(`^[A-Z][A-Z0-9_]+-\d+$`)
+)
+
+// ParseTicket parses a ticket reference: a GitHub issue URL or #123, a Jira issue URL or key, or
+// any other single word or URL.
+func ParseTicket(ref string) (Ticket, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return Ticket{}, fmt.Errorf("ticket reference is empty")
+	}
+	if len(ref) > MaxTicketLength {
+		return Ticket{}, fmt.Errorf("ticket reference is longer than %d characters", MaxTicketLength)
+	}
+	if strings.ContainsAny(ref, " \t\n") {
+		return Ticket{}, fmt.Errorf("ticket reference %q contains spaces", ref)
+	}
+
+	ticket := Ticket{Ref: ref, Kind: TicketOther, Key: ref}
+	switch {
+	case githubIssueURL.MatchString(ref):
+		m := githubIssueURL.FindStringSubmatch(ref)
+		ticket.Kind, ticket.Repo, ticket.Key, ticket.URL = TicketGitHub, m[1], "#"+m[2], ref
+	case githubIssueRef.MatchString(ref):
+		m := githubIssueRef.FindStringSubmatch(ref)
+		ticket.Kind, ticket.Repo, ticket.Key = TicketGitHub, m[1], "#"+m[2]
+		if ticket.Repo != "" {
+			ticket.URL = "https://github.com/" + ticket.Repo + "/issues/" + m[2]
+		}
+	case jiraIssueURL.MatchString(ref):
+		m := jiraIssueURL.FindStringSubmatch(ref)
+		ticket.Kind, ticket.Key, ticket.URL = TicketJira, m[2], ref
+	case jiraIssueKey.MatchString(ref):
+		ticket.Kind = TicketJira
+		if base := ticketConfig().JiraURL; base != "" {
+			ticket.URL = strings.TrimSuffix(base, "/") + "/browse/" + ref
+		}
+	default:
+		// Other trackers' URLs are keyed by their last path element
+		if u, err := url.Parse(ref); err == nil && u.Scheme != "" && u.Host != "" {
+			ticket.URL = ref
+			if base := path.Base(u.Path); base != "/" && base != "." {
+				ticket.Key = base
+			} else {
+				ticket.Key = u.Host
+			}
+		}
+	}
+	return ticket, nil
+}
+
+// JiraBaseURL returns the base URL of the Jira site of a Jira ticket: the site of its URL, or the
+// configured site for a bare key.
+func (t Ticket) JiraBaseURL() string {
+	if m := jiraIssueURL.FindStringSubmatch(t.URL); m != nil {
+		return m[1]
+	}
+	return strings.TrimSuffix(ticketConfig().JiraURL, "/")
+}
+
+// tickets holds the configuration of ticket references.
+var tickets struct {
+	mu  sync.RWMutex
+	cfg config.TicketConfig
+}
+
+// SetTicketConfig sets the branch and commit templates of instances with a ticket and the Jira
+// site bare keys link to.
+func SetTicketConfig(cfg config.TicketConfig) {
+	tickets.mu.Lock()
+	defer tickets.mu.Unlock()
+	tickets.cfg = cfg
+}
+
+func ticketConfig() config.TicketConfig {
+	tickets.mu.RLock()
+	defer tickets.mu.RUnlock()
+	return tickets.cfg
+}
+
+// SetTicket sets the ticket reference of the instance, or clears it if ref is empty. The branch
+// name only follows the ticket if it is set before the instance starts.
+func (i *Instance) SetTicket(ref string) error {
+	ref = strings.TrimSpace(ref)
+	if ref != "" {
+		if _, err := ParseTicket(ref); err != nil {
+			return err
+		}
+	}
+	if ref == i.Ticket {
+		return nil
+	}
+	i.Ticket = ref
+	i.Emit(EventTicketChanged)
+	return nil
+}
+
+// ParsedTicket returns the instance's ticket, and false if it has none.
+func (i *Instance) ParsedTicket() (Ticket, bool) {
+	if i.Ticket == "" {
+		return Ticket{}, false
+	}
+	ticket, err := ParseTicket(i.Ticket)
+	return ticket, err == nil
+}
+
+// ticketBranchName returns the branch name for the instance from the configured template, or ""
+// if it has no ticket.
+func (i *Instance) ticketBranchName() string {
+	ticket, ok := i.ParsedTicket()
+	if !ok {
+		return ""
+	}
+	return git.BranchNameFromTemplate(ticketConfig().Branch(), map[string]string{
+		"ticket": ticket.Key,
+		"title":  i.Title,
+	})
+}
+
+// CommitMessage returns the message for committing the instance's changes: from the configured
+// template if it has a ticket, the default message otherwise.
+func (i *Instance) CommitMessage() string {
+	now := time.Now().Format(time.RFC822)
+	ticket, ok := i.ParsedTicket()
+	if !ok {
+		return fmt.Sprintf("[claudesquad] update from '%s' on %s", i.Title, now)
+	}
+	return strings.NewReplacer("{ticket}", ticket.Key, "{title}", i.Title, "{time}", now).
+		Replace(ticketConfig().Commit())
+}
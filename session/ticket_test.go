@@ -0,0 +1,59 @@
+package session
+
+import (
+	"claude-squad/config"
+	"strings"
+	"testing"
+)
+
+func TestParseTicket(t *testing.T) {
+	SetTicketConfig(config.TicketConfig{JiraURL: "https://acme.atlassian.net/"})
+	defer SetTicketConfig(config.TicketConfig{})
+
+	tests := []struct {
+		ref  string
+		want Ticket
+	}{
+		{"https://github.com/acme/app/issues/42", Ticket{Kind: TicketGitHub, Key: "#42", Repo: "acme/app", URL: "https://github.com/acme/app/issues/42"}},
+		{"acme/app#7", Ticket{Kind: TicketGitHub, Key: "#7", Repo: "acme/app", URL: "https://github.com/acme/app/issues/7"}},
+		{"#7", Ticket{Kind: TicketGitHub, Key: "#7"}},
+		{"https://jira.example.com/browse/ABC-123", Ticket{Kind: TicketJira, Key: "ABC-123", URL: "https://jira.example.com/browse/ABC-123"}},
+		{"ABC-123", Ticket{Kind: TicketJira, Key: "ABC-123", URL: "https://acme.atlassian.net/browse/ABC-123"}},
+		{"https://linear.app/acme/issue/ENG-9", Ticket{Kind: TicketOther, Key: "ENG-9", URL: "https://linear.app/acme/issue/ENG-9"}},
+		{"spike", Ticket{Kind: TicketOther, Key: "spike"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseTicket(tt.ref)
+		tt.want.Ref = tt.ref
+		if err != nil || got != tt.want {
+			t.Errorf("ParseTicket(%q) = %+v, %v, want %+v", tt.ref, got, err, tt.want)
+		}
+	}
+
+	for _, ref := range []string{"", "two words", strings.Repeat("x", MaxTicketLength+1)} {
+		if _, err := ParseTicket(ref); err == nil {
+			t.Errorf("ParseTicket(%q) succeeded, want an error", ref)
+		}
+	}
+}
+
+func TestTicketTemplates(t *testing.T) {
+	SetTicketConfig(config.TicketConfig{CommitTemplate: "{ticket}: {title}"})
+	defer SetTicketConfig(config.TicketConfig{})
+
+	instance := &Instance{Title: "Fix login", Ticket: "ABC-123"}
+	if got, want := instance.ticketBranchName(), "session/abc-123-fix-login"; got != want {
+		t.Errorf("ticketBranchName() = %q, want %q", got, want)
+	}
+	if got, want := instance.CommitMessage(), "ABC-123: Fix login"; got != want {
+		t.Errorf("CommitMessage() = %q, want %q", got, want)
+	}
+
+	instance.Ticket = ""
+	if got := instance.ticketBranchName(); got != "" {
+		t.Errorf("ticketBranchName() without a ticket = %q, want none", got)
+	}
+	if got := instance.CommitMessage(); !strings.HasPrefix(got, "[claudesquad] update from 'Fix login' on ") {
+		t.Errorf("CommitMessage() without a ticket = %q", got)
+	}
+}
@@ -0,0 +1,171 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session/git"
+	"claude-squad/session/tmux"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTitleLength is the maximum number of characters in an instance title.
+const MaxTitleLength = 32
+
+// titleSeparators are the characters allowed in a title besides ASCII letters and digits. Titles
+// name tmux sessions and git branches, which either drop or reject anything else.
+const titleSeparators = " -_."
+
+var titlePolicy struct {
+	mu     sync.RWMutex
+	policy string
+}
+
+// SetTitlePolicy sets the policy ValidateTitle checks new titles against. An unknown policy is
+// replaced by config.TitleDefault.
+func SetTitlePolicy(policy string) {
+	if err := config.ValidateTitlePolicy(policy); err != nil {
+		log.WarningLog.Printf("%v, using %s", err, config.TitleDefault)
+		policy = ""
+	}
+	if policy == "" {
+		policy = config.TitleDefault
+	}
+	titlePolicy.mu.Lock()
+	defer titlePolicy.mu.Unlock()
+	titlePolicy.policy = policy
+}
+
+func currentTitlePolicy() string {
+	titlePolicy.mu.RLock()
+	defer titlePolicy.mu.RUnlock()
+	if titlePolicy.policy == "" {
+		return config.TitleDefault
+	}
+	return titlePolicy.policy
+}
+
+func isTitleChar(r rune) bool {
+	return r < utf8.RuneSelf && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		strings.ContainsRune(titleSeparators, r))
+}
+
+// TitleNames returns the git branch and tmux session names a new instance titled title gets.
+// Either may already be taken, in which case the instance gets a variant of it.
+func TitleNames(title string) (branch, tmuxSession string) {
+	return git.BranchName(title), tmux.ToClaudeSquadTmuxName(title)
+}
+
+// checkTitleChars returns an error if title has characters policy doesn't accept.
+func checkTitleChars(title, policy string) error {
+	switch policy {
+	case config.TitleStrict:
+		for i, r := range title {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return fmt.Errorf("title cannot contain %q, use lowercase letters, digits and '-', such as fix-login", r)
+			}
+			if r == '-' && (i == len(title)-1 || title[i+1] == '-') {
+				return fmt.Errorf("title cannot end with '-' or repeat it")
+			}
+		}
+	case config.TitlePermissive:
+		for _, r := range title {
+			if !unicode.IsPrint(r) {
+				return fmt.Errorf("title cannot contain %q", r)
+			}
+		}
+		if branch, _ := TitleNames(title); branch == git.BranchName("") {
+			return fmt.Errorf("title needs ASCII letters or digits to name its branch")
+		}
+	default:
+		for _, r := range title {
+			if !isTitleChar(r) {
+				return fmt.Errorf("title cannot contain %q, use letters, digits, spaces, '-', '_' or '.'", r)
+			}
+		}
+	}
+	first, _ := utf8.DecodeRuneInString(title)
+	if !unicode.IsLetter(first) && !unicode.IsDigit(first) {
+		return fmt.Errorf("title must start with a letter or digit")
+	}
+	return nil
+}
+
+// ValidateTitle returns an error if title can't name a new instance alongside existing: it must be
+// non-empty, at most MaxTitleLength characters accepted by the title policy (for the default one
+// letters, digits and " -_."), start with a letter or digit, and not map to the tmux session or
+// branch of another instance.
+func ValidateTitle(title string, existing []*Instance) error {
+	if title == "" {
+		return fmt.Errorf("title cannot be empty")
+	}
+	if utf8.RuneCountInString(title) > MaxTitleLength {
+		return fmt.Errorf("title cannot be longer than %d characters", MaxTitleLength)
+	}
+	if err := checkTitleChars(title, currentTitlePolicy()); err != nil {
+		return err
+	}
+
+	branch, tmuxName := TitleNames(title)
+	for _, instance := range existing {
+		switch {
+		case instance.Title == title:
+			return fmt.Errorf("an instance named %s already exists", title)
+		case instance.tmuxSessionName() == tmuxName:
+			return fmt.Errorf("%s would share the tmux session of %s", title, instance.Title)
+		case git.BranchName(instance.Title) == branch:
+			return fmt.Errorf("%s would share the branch of %s", title, instance.Title)
+		}
+	}
+	return nil
+}
+
+// SuggestTitle returns a valid title for a new instance made of the first words of prompt, or ""
+// if prompt has no usable words. A number is appended if the title is taken by existing.
+func SuggestTitle(prompt string, existing []*Instance) string {
+	var words []string
+	length := 0
+	for _, field := range strings.Fields(strings.ToLower(prompt)) {
+		word := strings.Map(func(r rune) rune {
+			if isTitleChar(r) && !strings.ContainsRune(titleSeparators, r) {
+				return r
+			}
+			if r == '-' || r == '_' {
+				return '-'
+			}
+			return -1
+		}, field)
+		// Single dashes between letters and digits, which every title policy accepts
+		word = strings.Join(strings.FieldsFunc(word, func(r rune) bool { return r == '-' }), "-")
+		if word == "" {
+			continue
+		}
+		// Leave room for a separator and a number to make the title unique
+		if length+len(word)+1 > MaxTitleLength-3 {
+			if len(words) == 0 {
+				words = append(words, word[:MaxTitleLength-3])
+			}
+			break
+		}
+		words = append(words, word)
+		length += len(word) + 1
+		if len(words) == 5 {
+			break
+		}
+	}
+	if len(words) == 0 {
+		return ""
+	}
+
+	title := strings.Join(words, "-")
+	for n := 2; ValidateTitle(title, existing) != nil && n < 100; n++ {
+		title = fmt.Sprintf("%s-%d", strings.Join(words, "-"), n)
+	}
+	if ValidateTitle(title, existing) != nil {
+		return ""
+	}
+	return title
+}
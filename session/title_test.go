@@ -0,0 +1,114 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"strings"
+	"testing"
+)
+
+func TestValidateTitle(t *testing.T) {
+	existing := []*Instance{{Title: "fix login"}, {Title: "api.v2"}}
+	tests := []struct {
+		title   string
+		wantErr string
+	}{
+		{title: "new-feature"},
+		{title: "Fix_Login 2"},
+		{title: "", wantErr: "empty"},
+		{title: strings.Repeat("a", MaxTitleLength+1), wantErr: "longer"},
+		{title: "feat:x", wantErr: "cannot contain"},
+		{title: "修复", wantErr: "cannot contain"},
+		{title: "-flag", wantErr: "start with"},
+		{title: "fix login", wantErr: "already exists"},
+		{title: "fixlogin", wantErr: "tmux session"},
+		{title: "api_v2", wantErr: "tmux session"},
+		{title: "Fix-Login", wantErr: "branch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			err := ValidateTitle(tt.title, existing)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateTitle(%q) error: %v", tt.title, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateTitle(%q) = %v, want an error about %q", tt.title, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTitlePolicies(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	defer SetTitlePolicy("")
+	tests := []struct {
+		policy  string
+		title   string
+		wantErr string
+	}{
+		{policy: config.TitleStrict, title: "fix-login-2"},
+		{policy: config.TitleStrict, title: "Fix-login", wantErr: "cannot contain"},
+		{policy: config.TitleStrict, title: "fix login", wantErr: "cannot contain"},
+		{policy: config.TitleStrict, title: "fix--login", wantErr: "repeat"},
+		{policy: config.TitleStrict, title: "fix-", wantErr: "end with"},
+		{policy: config.TitlePermissive, title: "feat: login/oauth"},
+		{policy: config.TitlePermissive, title: "修复 login"},
+		{policy: config.TitlePermissive, title: "修复", wantErr: "ASCII"},
+		{policy: config.TitlePermissive, title: "fix\tlogin", wantErr: "cannot contain"},
+		{policy: config.TitlePermissive, title: "/login", wantErr: "start with"},
+		{policy: "bogus", title: "feat:x", wantErr: "cannot contain"},
+	}
+
+	for _, tt := range tests {
+		SetTitlePolicy(tt.policy)
+		err := ValidateTitle(tt.title, nil)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: ValidateTitle(%q) error: %v", tt.policy, tt.title, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: ValidateTitle(%q) = %v, want an error about %q", tt.policy, tt.title, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTitleNames(t *testing.T) {
+	branch, tmuxSession := TitleNames("feat: Login/OAuth")
+	if branch != "session/feat-login/oauth" || tmuxSession != "claudesquad_feat_Login_OAuth" {
+		t.Errorf("TitleNames() = %q, %q", branch, tmuxSession)
+	}
+}
+
+func TestSuggestTitle(t *testing.T) {
+	existing := []*Instance{{Title: "fix-the-login-page"}}
+	tests := []struct {
+		prompt string
+		want   string
+	}{
+		{prompt: "Add dark mode to the settings screen, please!", want: "add-dark-mode-to-the"},
+		{prompt: "Fix the login page", want: "fix-the-login-page-2"},
+		{prompt: "refactor internationalization_and_localization_support", want: "refactor"},
+		{prompt: "supercalifragilisticexpialidocious-extra", want: "supercalifragilisticexpialido"},
+		{prompt: "修复 登录", want: ""},
+		{prompt: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		got := SuggestTitle(tt.prompt, existing)
+		if got != tt.want {
+			t.Errorf("SuggestTitle(%q) = %q, want %q", tt.prompt, got, tt.want)
+		}
+		if got != "" {
+			if err := ValidateTitle(got, existing); err != nil {
+				t.Errorf("SuggestTitle(%q) = %q is invalid: %v", tt.prompt, got, err)
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package tmux
 
 import (
 	"bytes"
+	"claude-squad/command"
 	"claude-squad/log"
 	"context"
 	"crypto/sha256"
@@ -10,18 +11,44 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/creack/pty"
+	"golang.org/x/term"
 )
 
 const ProgramClaude = "claude"
 
 const ProgramAider = "aider"
 
+// ProgramFakeAgent is the hidden subcommand that imitates claude for tests and demos.
+const ProgramFakeAgent = "fake-agent"
+
+// IsClaudeProgram returns true if the program shows claude's trust and permission screens.
+func IsClaudeProgram(program string) bool {
+	return programName(program) == ProgramClaude || strings.Contains(program, ProgramFakeAgent)
+}
+
+// IsAiderProgram returns true if the program is aider.
+func IsAiderProgram(program string) bool {
+	return strings.HasPrefix(programName(program), ProgramAider)
+}
+
+// programName returns the executable name of a program command, without its path or flags, so
+// "/usr/local/bin/claude --model opus" is still recognized as claude.
+func programName(program string) string {
+	fields := strings.Fields(program)
+	if len(fields) == 0 {
+		return ""
+	}
+	return filepath.Base(fields[0])
+}
+
 // TmuxSession represents a managed tmux session
 type TmuxSession struct {
 	// Initialized by NewTmuxSession
@@ -40,6 +67,18 @@ type TmuxSession struct {
 	// monitor monitors the tmux pane content and sends signals to the UI when it's status changes
 	monitor *statusMonitor
 
+	// viewWindow is the id of the companion window shown in the preview and on attach, empty
+	// for the program's window.
+	windowMu   sync.Mutex
+	viewWindow string
+
+	// previewWidth and previewHeight are the size of the pane while detached, set by
+	// SetDetachedSize. While attached the pane follows the terminal instead, and goes back to
+	// this size on detach.
+	sizeMu                      sync.Mutex
+	previewWidth, previewHeight int
+	attached                    bool
+
 	// Initialized by Attach
 	// Deinitilaized by Detach
 	//
@@ -54,12 +93,75 @@ type TmuxSession struct {
 
 const TmuxPrefix = "claudesquad_"
 
+// socketName selects the tmux server socket (tmux -L). Empty uses the default server.
+var socketName string
+
+// SetSocketName makes all tmux commands talk to the server on the named socket instead of the
+// default one. It is used by tests to run against an isolated tmux server.
+func SetSocketName(name string) {
+	socketName = name
+}
+
+// tmuxCommand builds a tmux command targeting the configured server socket, killed if the server
+// doesn't answer within the tmux timeout.
+func tmuxCommand(args ...string) *command.Cmd {
+	return command.New(command.TmuxTimeout(), "tmux", tmuxArgs(args)...)
+}
+
+// ptyCommand builds a tmux command targeting the configured server socket to start in a PTY, such
+// as attach-session, which runs as long as the PTY is open and has no timeout.
+func ptyCommand(args ...string) *exec.Cmd {
+	return exec.Command("tmux", tmuxArgs(args)...)
+}
+
+// AttachCommand builds a tmux client attaching to the session name on the configured server
+// socket, to start in a PTY of its own. A read-only client ignores the keys typed in it.
+func AttachCommand(name string, readOnly bool) *exec.Cmd {
+	args := []string{"attach-session", "-t", name}
+	if readOnly {
+		args = append(args, "-r")
+	}
+	return ptyCommand(args...)
+}
+
+// tmuxArgs prefixes args with the configured server socket.
+func tmuxArgs(args []string) []string {
+	if socketName != "" {
+		args = append([]string{"-L", socketName}, args...)
+	}
+	return args
+}
+
+// Ping checks that tmux runs and its server answers within timeout. No server running, as before
+// any instance is started, is not an error.
+func Ping(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "tmux", tmuxArgs([]string{"list-sessions"})...).CombinedOutput()
+	if ctx.Err() != nil {
+		return fmt.Errorf("tmux did not answer within %s", timeout)
+	}
+	var exitErr *exec.ExitError
+	if err == nil || errors.As(err, &exitErr) && (bytes.Contains(output, []byte("no server running")) ||
+		bytes.Contains(output, []byte("error connecting"))) {
+		return nil
+	}
+	return fmt.Errorf("tmux list-sessions failed: %v: %s", err, strings.TrimSpace(string(output)))
+}
+
 var whiteSpaceRegex = regexp.MustCompile(`\s+`)
 
 // ToClaudeSquadTmuxName converts a string to a valid tmux session name with the claude squad prefix
 func ToClaudeSquadTmuxName(str string) string {
 	str = whiteSpaceRegex.ReplaceAllString(str, "")
 	str = strings.ReplaceAll(str, ".", "_") // tmux replaces all . with _
+	// Colons separate the parts of tmux targets, and other tools expect plain ASCII names
+	str = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, str)
 	return fmt.Sprintf("%s%s", TmuxPrefix, str)
 }
 
@@ -69,13 +171,40 @@ func toClaudeSquadTmuxName(str string) string {
 }
 
 func NewTmuxSession(name string, program string) *TmuxSession {
+	return NewTmuxSessionNamed(name, toClaudeSquadTmuxName(name), program)
+}
+
+// NewTmuxSessionNamed is NewTmuxSession with the tmux session name given, such as one from
+// UniqueSessionName or saved by an earlier run.
+func NewTmuxSessionNamed(name, sanitizedName, program string) *TmuxSession {
 	return &TmuxSession{
 		Name:          name,
-		sanitizedName: toClaudeSquadTmuxName(name),
+		sanitizedName: sanitizedName,
 		program:       program,
 	}
 }
 
+// UniqueSessionName returns a tmux session name for name that no running session uses. Distinct
+// names can sanitize to the same session name, like "fix bug" and "fixbug", so when it is taken
+// a short hash of name is appended, then a counter if that is taken too.
+func UniqueSessionName(name string) string {
+	return uniqueSessionName(name, DoesSessionExist)
+}
+
+func uniqueSessionName(name string, exists func(string) bool) string {
+	sanitized := toClaudeSquadTmuxName(name)
+	if !exists(sanitized) {
+		return sanitized
+	}
+	sum := sha256.Sum256([]byte(name))
+	hashed := fmt.Sprintf("%s_%x", sanitized, sum[:3])
+	candidate := hashed
+	for n := 2; exists(candidate); n++ {
+		candidate = fmt.Sprintf("%s_%d", hashed, n)
+	}
+	return candidate
+}
+
 // SanitizedName returns the sanitized tmux session name
 func (t *TmuxSession) SanitizedName() string {
 	return t.sanitizedName
@@ -89,15 +218,23 @@ func (t *TmuxSession) Start(program string, workDir string) error {
 		return fmt.Errorf("tmux session already exists: %s", t.sanitizedName)
 	}
 
-	// Create a new detached tmux session and start claude in it
-	cmd := exec.Command("tmux", "new-session", "-d", "-s", t.sanitizedName, "-c", workDir, program)
+	// Windows of a previous session, such as before a pause, are gone
+	t.windowMu.Lock()
+	t.viewWindow = ""
+	t.windowMu.Unlock()
+
+	// Create a new detached tmux session and start claude in it. The pane is kept when the program
+	// exits, to tell its exit status and show its last output. Setting it in the same command
+	// leaves no time for the program to exit before.
+	cmd := ptyCommand("new-session", "-d", "-s", t.sanitizedName, "-c", workDir, program,
+		";", "set-option", "-w", "remain-on-exit", "on")
 
 	// Start with standard PTY
 	ptmx, err := pty.Start(cmd)
 	if err != nil {
 		// Cleanup any partially created session if any exists.
 		if DoesSessionExist(t.sanitizedName) {
-			cleanupCmd := exec.Command("tmux", "kill-session", "-t", t.sanitizedName)
+			cleanupCmd := tmuxCommand("kill-session", "-t", t.sanitizedName)
 			if cleanupErr := cleanupCmd.Run(); cleanupErr != nil {
 				err = fmt.Errorf("%v (cleanup error: %v)", err, cleanupErr)
 			}
@@ -133,11 +270,11 @@ func (t *TmuxSession) Start(program string, workDir string) error {
 		return fmt.Errorf("error restoring tmux session: %w", err)
 	}
 
-	if program == ProgramClaude || strings.HasPrefix(program, ProgramAider) {
+	if IsClaudeProgram(program) || IsAiderProgram(program) {
 		searchString := "Do you trust the files in this folder?"
 		tapFunc := t.TapEnter
 		iterations := 5
-		if program != ProgramClaude {
+		if !IsClaudeProgram(program) {
 			searchString = "Open documentation url for more info"
 			tapFunc = t.TapDAndEnter
 			iterations = 10 // Aider takes longer to start :/
@@ -169,7 +306,7 @@ func (t *TmuxSession) Restore() error {
 	}
 	
 	// Normal PTY mode
-	ptmx, err := pty.Start(exec.Command("tmux", "attach-session", "-t", t.sanitizedName))
+	ptmx, err := pty.Start(ptyCommand("attach-session", "-t", t.sanitizedName))
 	if err != nil {
 		return fmt.Errorf("error opening PTY: %w", err)
 	}
@@ -214,6 +351,27 @@ func (t *TmuxSession) TapEnter() error {
 	return nil
 }
 
+// PressEnter presses enter in the program's pane with tmux send-keys rather than through the PTY,
+// so this process's client doesn't become the most recently active one, whose size tmux gives the
+// window. It works without a PTY, and whichever window is being viewed.
+func (t *TmuxSession) PressEnter() error {
+	if err := tmuxCommand("send-keys", "-t", t.sanitizedName+agentWindow, "Enter").Run(); err != nil {
+		return fmt.Errorf("error pressing enter in %s: %w", t.sanitizedName, err)
+	}
+	return nil
+}
+
+// Release closes the PTY attached to the session, detaching its tmux client and leaving the
+// session running.
+func (t *TmuxSession) Release() error {
+	if t.ptmx == nil {
+		return nil
+	}
+	err := t.ptmx.Close()
+	t.ptmx = nil
+	return err
+}
+
 // TapDAndEnter sends 'D' followed by an enter keystroke to the tmux pane.
 func (t *TmuxSession) TapDAndEnter() error {
 	if t.ptmx == nil {
@@ -246,6 +404,92 @@ func (t *TmuxSession) SendKeys(keys string) error {
 	return err
 }
 
+// ProgramExited reports whether the program has exited, and its exit status if so. The status is
+// -1 if the program was killed by a signal.
+func (t *TmuxSession) ProgramExited() (bool, int, error) {
+	out, err := tmuxCommand("display-message", "-p", "-t", t.sanitizedName+agentWindow,
+		"#{pane_dead} #{pane_dead_status}").Output()
+	if err != nil {
+		return false, 0, fmt.Errorf("error checking the program of %s: %w", t.sanitizedName, err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 || fields[0] != "1" {
+		return false, 0, nil
+	}
+	status := -1
+	if len(fields) > 1 {
+		if code, err := strconv.Atoi(fields[1]); err == nil {
+			status = code
+		}
+	}
+	return true, status, nil
+}
+
+// RespawnProgram starts the program again in its pane after it has exited.
+func (t *TmuxSession) RespawnProgram() error {
+	if err := tmuxCommand("respawn-pane", "-t", t.sanitizedName+agentWindow).Run(); err != nil {
+		return fmt.Errorf("error restarting the program of %s: %w", t.sanitizedName, err)
+	}
+	t.monitor = newStatusMonitor()
+	return nil
+}
+
+// Interrupt stops whatever the program is doing without exiting it. Claude is interrupted with
+// escape because a second ctrl-c would quit it; other programs get ctrl-c.
+func (t *TmuxSession) Interrupt() error {
+	key := "C-c"
+	if IsClaudeProgram(t.program) {
+		key = "Escape"
+	}
+	if err := tmuxCommand("send-keys", "-t", t.sanitizedName, key).Run(); err != nil {
+		return fmt.Errorf("error sending %s to %s: %w", key, t.sanitizedName, err)
+	}
+	return nil
+}
+
+// SetAccentColor colors the session's status line and active pane border with color, a #rrggbb
+// hex color, so the session is recognizable while attached.
+func (t *TmuxSession) SetAccentColor(color string) error {
+	for _, option := range [][2]string{
+		{"status-style", "bg=" + color + ",fg=#1a1a1a"},
+		{"pane-active-border-style", "fg=" + color},
+	} {
+		if err := tmuxCommand("set-option", "-t", t.sanitizedName, option[0], option[1]).Run(); err != nil {
+			return fmt.Errorf("error setting %s of %s: %w", option[0], t.sanitizedName, err)
+		}
+	}
+	return nil
+}
+
+// SetBanner sets the title of the session's pane to banner and shows it on the left of the status
+// line. Programs can change the pane title, so the status line reads it from a session option.
+func (t *TmuxSession) SetBanner(banner string) error {
+	if err := tmuxCommand("select-pane", "-t", t.sanitizedName, "-T", banner).Run(); err != nil {
+		return fmt.Errorf("error setting the pane title of %s: %w", t.sanitizedName, err)
+	}
+	for _, option := range [][2]string{
+		{"@banner", banner},
+		{"status-left", " #{@banner} "},
+		{"status-left-length", "200"},
+	} {
+		if err := tmuxCommand("set-option", "-t", t.sanitizedName, option[0], option[1]).Run(); err != nil {
+			return fmt.Errorf("error setting %s of %s: %w", option[0], t.sanitizedName, err)
+		}
+	}
+	return nil
+}
+
+// DisplayMessage shows message over the status line of the session's clients for d.
+func (t *TmuxSession) DisplayMessage(message string, d time.Duration) error {
+	// tmux expands formats in the message
+	message = strings.ReplaceAll(message, "#", "##")
+	cmd := tmuxCommand("display-message", "-t", t.sanitizedName, "-d", strconv.FormatInt(d.Milliseconds(), 10), message)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error displaying a message in %s: %w", t.sanitizedName, err)
+	}
+	return nil
+}
+
 // HasUpdated checks if the tmux pane content has changed since the last check.
 // It uses the provided content string.
 // It also returns true if the tmux pane has a prompt for aider or claude code.
@@ -257,9 +501,9 @@ func (t *TmuxSession) HasUpdated(content string) (updated bool, hasPrompt bool)
 	}
 
 	// Only set hasPrompt for claude and aider. Use these strings to check for a prompt.
-	if t.program == ProgramClaude {
+	if IsClaudeProgram(t.program) {
 		hasPrompt = strings.Contains(content, "No, and tell Claude what to do differently")
-	} else if strings.HasPrefix(t.program, ProgramAider) {
+	} else if IsAiderProgram(t.program) {
 		hasPrompt = strings.Contains(content, "(Y)es/(N)o/(D)on't ask again")
 	}
 
@@ -271,7 +515,11 @@ func (t *TmuxSession) HasUpdated(content string) (updated bool, hasPrompt bool)
 }
 
 func (t *TmuxSession) Attach() (chan struct{}, error) {
+	if err := t.selectWindow(t.previewTarget()); err != nil {
+		return nil, err
+	}
 	t.attachCh = make(chan struct{})
+	t.setAttached(true)
 
 	t.wg = &sync.WaitGroup{}
 	t.wg.Add(1)
@@ -354,6 +602,7 @@ func (t *TmuxSession) Detach() {
 			close(t.attachCh)
 			t.attachCh = nil
 		}
+		t.setAttached(false)
 		return
 	}
 	
@@ -402,6 +651,15 @@ func (t *TmuxSession) Detach() {
 	// Cancel goroutines created by Attach.
 	t.cancel()
 	t.wg.Wait()
+
+	// Only now, as a resize of the terminal could still be handled until the goroutines stop
+	t.setAttached(false)
+	t.restorePreviewSize()
+
+	// Input sent while detached goes to the program
+	if err := t.selectWindow(t.sanitizedName + agentWindow); err != nil {
+		log.FileOnlyErrorLog.Printf("failed to select the program's window of %s: %v", t.sanitizedName, err)
+	}
 }
 
 // Close terminates the tmux session and cleans up resources
@@ -415,7 +673,7 @@ func (t *TmuxSession) Close() error {
 		t.ptmx = nil
 	}
 
-	cmd := exec.Command("tmux", "kill-session", "-t", t.sanitizedName)
+	cmd := tmuxCommand("kill-session", "-t", t.sanitizedName)
 	if err := cmd.Run(); err != nil {
 		errs = append(errs, fmt.Errorf("error killing tmux session: %w", err))
 	}
@@ -435,11 +693,51 @@ func (t *TmuxSession) Close() error {
 }
 
 // SetDetachedSize set the width and height of the session while detached. This makes the
-// tmux output conform to the specified shape.
+// tmux output conform to the specified shape. While attached the size is kept for the detach
+// and the pane keeps the size of the terminal.
 func (t *TmuxSession) SetDetachedSize(width, height int) error {
+	t.sizeMu.Lock()
+	t.previewWidth, t.previewHeight = width, height
+	attached := t.attached
+	t.sizeMu.Unlock()
+	if attached {
+		return nil
+	}
 	return t.updateWindowSize(width, height)
 }
 
+func (t *TmuxSession) setAttached(attached bool) {
+	t.sizeMu.Lock()
+	defer t.sizeMu.Unlock()
+	t.attached = attached
+}
+
+// restorePreviewSize sizes a new PTY to the last size set with SetDetachedSize, so the pane isn't
+// squashed to the PTY's default size until the next resize.
+func (t *TmuxSession) restorePreviewSize() {
+	t.sizeMu.Lock()
+	width, height := t.previewWidth, t.previewHeight
+	t.sizeMu.Unlock()
+	if width <= 0 || height <= 0 {
+		return
+	}
+	if err := t.updateWindowSize(width, height); err != nil {
+		log.FileOnlyErrorLog.Printf("failed to restore the preview size of %s: %v", t.sanitizedName, err)
+	}
+}
+
+// resizeToTerminal sizes the pane to the terminal while attached.
+func (t *TmuxSession) resizeToTerminal() error {
+	cols, rows, err := term.GetSize(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to get the terminal size: %w", err)
+	}
+	if err := t.updateWindowSize(cols, rows); err != nil {
+		return fmt.Errorf("failed to update window size: %w", err)
+	}
+	return nil
+}
+
 // updateWindowSize updates the window size of the PTY.
 func (t *TmuxSession) updateWindowSize(cols, rows int) error {
 	if t.ptmx == nil {
@@ -463,7 +761,7 @@ func (t *TmuxSession) updateWindowSize(cols, rows int) error {
 // DoesSessionExist checks if a tmux session exists
 func DoesSessionExist(name string) bool {
 	// Using "-t name" does a prefix match, which is wrong. `-t=` does an exact match.
-	existsCmd := exec.Command("tmux", "has-session", fmt.Sprintf("-t=%s", name))
+	existsCmd := tmuxCommand("has-session", fmt.Sprintf("-t=%s", name))
 	return existsCmd.Run() == nil
 }
 
@@ -471,25 +769,31 @@ func (t *TmuxSession) DoesSessionExist() bool {
 	return DoesSessionExist(t.sanitizedName)
 }
 
-// CapturePaneContent captures the content of the tmux pane
+// CapturePaneContent captures the content of the tmux pane of the viewed window
 func (t *TmuxSession) CapturePaneContent() (string, error) {
-	// Add -e flag to preserve escape sequences (ANSI color codes)
-	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-t", t.sanitizedName)
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("error capturing pane content: %v", err)
-	}
-	return string(output), nil
+	return t.capture(t.previewTarget())
 }
 
 // CapturePaneContentWithOptions captures the pane content with additional options
 // start and end specify the starting and ending line numbers (use "-" for the start/end of history)
 func (t *TmuxSession) CapturePaneContentWithOptions(start, end string) (string, error) {
+	return t.capture(t.previewTarget(), "-S", start, "-E", end)
+}
+
+// capture captures the content of the pane with the target. A viewed window that was closed is
+// replaced with the program's.
+func (t *TmuxSession) capture(target string, options ...string) (string, error) {
 	// Add -e flag to preserve escape sequences (ANSI color codes)
-	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-S", start, "-E", end, "-t", t.sanitizedName)
-	output, err := cmd.Output()
+	args := append([]string{"capture-pane", "-p", "-e", "-J"}, options...)
+	output, err := tmuxCommand(append(args, "-t", target)...).Output()
+	if err != nil && target != t.sanitizedName+agentWindow && t.DoesSessionExist() {
+		t.windowMu.Lock()
+		t.viewWindow = ""
+		t.windowMu.Unlock()
+		return t.capture(t.sanitizedName+agentWindow, options...)
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to capture tmux pane content with options: %v", err)
+		return "", fmt.Errorf("error capturing pane content: %v", err)
 	}
 	return string(output), nil
 }
@@ -497,7 +801,7 @@ func (t *TmuxSession) CapturePaneContentWithOptions(start, end string) (string,
 // CleanupSessions kills all tmux sessions that start with "session-"
 func CleanupSessions() error {
 	// First try to list sessions
-	cmd := exec.Command("tmux", "ls")
+	cmd := tmuxCommand("ls")
 	output, err := cmd.Output()
 
 	// If there's an error and it's because no server is running, that's fine
@@ -517,7 +821,7 @@ func CleanupSessions() error {
 
 	for _, match := range matches {
 		log.FileOnlyInfoLog.Printf("cleaning up session: %s", match)
-		cmd := exec.Command("tmux", "kill-session", "-t", match)
+		cmd := tmuxCommand("kill-session", "-t", match)
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("failed to kill tmux session %s: %v", match, err)
 		}
@@ -0,0 +1,42 @@
+package tmux
+
+import "testing"
+
+func TestUniqueSessionName(t *testing.T) {
+	running := map[string]bool{}
+	exists := func(name string) bool { return running[name] }
+
+	first := uniqueSessionName("fixbug", exists)
+	if first != "claudesquad_fixbug" {
+		t.Fatalf("uniqueSessionName() = %q for a free name", first)
+	}
+	running[first] = true
+
+	// "fix bug" sanitizes to the same name, so it gets a suffix derived from the title
+	second := uniqueSessionName("fix bug", exists)
+	if second == first || len(second) != len(first)+7 {
+		t.Fatalf("uniqueSessionName() = %q, want %q with a hash suffix", second, first)
+	}
+	if again := uniqueSessionName("fix bug", exists); again != second {
+		t.Errorf("suffix is not stable: %q then %q", second, again)
+	}
+	running[second] = true
+
+	if third := uniqueSessionName("fix bug", exists); third != second+"_2" {
+		t.Errorf("uniqueSessionName() = %q, want %q once the hashed name is taken", third, second+"_2")
+	}
+}
+
+func TestToClaudeSquadTmuxName(t *testing.T) {
+	tests := map[string]string{
+		"fix bug":      "claudesquad_fixbug",
+		"api.v2":       "claudesquad_api_v2",
+		"feat:login/x": "claudesquad_feat_login_x",
+		"修复":           "claudesquad___",
+	}
+	for title, want := range tests {
+		if got := ToClaudeSquadTmuxName(title); got != want {
+			t.Errorf("ToClaudeSquadTmuxName(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
@@ -8,8 +8,6 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
-
-	"golang.org/x/term"
 )
 
 // monitorWindowSize monitors and handles window resize events while attached.
@@ -26,17 +24,8 @@ func (t *TmuxSession) monitorWindowSize() {
 
 	doUpdate := func() {
 		// Use the current terminal height and width.
-		cols, rows, err := term.GetSize(int(os.Stdin.Fd()))
-		if err != nil {
-			if everyN.ShouldLog() {
-				log.FileOnlyErrorLog.Printf("failed to update window size: %v", err)
-			}
-		} else {
-			if err := t.updateWindowSize(cols, rows); err != nil {
-				if everyN.ShouldLog() {
-					log.FileOnlyErrorLog.Printf("failed to update window size: %v", err)
-				}
-			}
+		if err := t.resizeToTerminal(); err != nil && everyN.ShouldLog() {
+			log.FileOnlyErrorLog.Print(err)
 		}
 	}
 	// Do one at the end of the function to set the initial size.
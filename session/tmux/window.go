@@ -0,0 +1,113 @@
+package tmux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// agentWindow targets the first window of a session, the one the program was started in.
+const agentWindow = ":^"
+
+// Window is a window of the session: the program's own, or a companion window such as a shell
+// in the worktree.
+type Window struct {
+	// ID is tmux's window id, such as @3. It stays the same when windows are renumbered.
+	ID    string `json:"id"`
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	// Agent is set for the window the program runs in.
+	Agent bool `json:"agent"`
+	// Viewed is set for the window shown in the preview and on attach.
+	Viewed bool `json:"viewed"`
+}
+
+// Windows returns the windows of the session in order.
+func (t *TmuxSession) Windows() ([]Window, error) {
+	out, err := tmuxCommand("list-windows", "-t", t.sanitizedName, "-F", "#{window_id}\t#{window_index}\t#{window_name}").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing the windows of %s: %w", t.sanitizedName, err)
+	}
+	viewed := t.ViewedWindow()
+	var windows []Window
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		index, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		windows = append(windows, Window{ID: fields[0], Index: index, Name: fields[2]})
+	}
+	for i := range windows {
+		windows[i].Agent = i == 0
+		windows[i].Viewed = windows[i].ID == viewed || (viewed == "" && i == 0)
+	}
+	return windows, nil
+}
+
+// NewWindow opens a companion window named name running command in dir, or the default shell if
+// command is empty, and returns its id. The program's window stays the one the session shows.
+func (t *TmuxSession) NewWindow(name, dir, command string) (string, error) {
+	args := []string{"new-window", "-d", "-P", "-F", "#{window_id}", "-t", t.sanitizedName + ":", "-n", name, "-c", dir}
+	if command != "" {
+		args = append(args, command)
+	}
+	out, err := tmuxCommand(args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("error opening window %s in %s: %w", name, t.sanitizedName, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ViewWindow shows the window with id in the preview and on attach. Input sent to the session
+// while detached, such as prompts and auto-yes, still goes to the program's window.
+func (t *TmuxSession) ViewWindow(id string) error {
+	windows, err := t.Windows()
+	if err != nil {
+		return err
+	}
+	for _, window := range windows {
+		if window.ID != id {
+			continue
+		}
+		if window.Agent {
+			id = ""
+		}
+		t.windowMu.Lock()
+		t.viewWindow = id
+		t.windowMu.Unlock()
+		return nil
+	}
+	return fmt.Errorf("%s has no window %s", t.sanitizedName, id)
+}
+
+// ViewedWindow returns the id of the companion window being viewed, or "" for the program's.
+func (t *TmuxSession) ViewedWindow() string {
+	t.windowMu.Lock()
+	defer t.windowMu.Unlock()
+	return t.viewWindow
+}
+
+// previewTarget returns the tmux target of the pane shown in the preview.
+func (t *TmuxSession) previewTarget() string {
+	if id := t.ViewedWindow(); id != "" {
+		return id
+	}
+	return t.sanitizedName + agentWindow
+}
+
+// selectWindow makes the window with the target the session's current one.
+func (t *TmuxSession) selectWindow(target string) error {
+	if err := tmuxCommand("select-window", "-t", target).Run(); err != nil {
+		return fmt.Errorf("error selecting window %s: %w", target, err)
+	}
+	return nil
+}
+
+// CaptureAgentContent captures the pane of the program's window, whichever window is viewed.
+func (t *TmuxSession) CaptureAgentContent() (string, error) {
+	return t.capture(t.sanitizedName + agentWindow)
+}
@@ -0,0 +1,307 @@
+// Package tmuxtest provides a harness for exercising instances against a real tmux server.
+//
+// Each Harness runs its own tmux server on a private socket (tmux -L), a throwaway HOME so
+// worktrees and config land in a temp dir, a scratch git repository, and a bin directory on
+// PATH where fake agent scripts can be installed. Tests using it are expected to be behind the
+// "integration" build tag and run with `go test -tags=integration ./...`.
+package tmuxtest
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/tmux"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// FakeClaudeScript is a shell script that behaves enough like claude for the prompt detection
+// and input paths to be exercised. It echoes every line it reads followed by a permission prompt.
+const FakeClaudeScript = `#!/bin/sh
+echo "fake agent ready"
+while IFS= read -r line; do
+  echo "received: $line"
+  echo "Do you want to proceed?"
+  echo "> 1. Yes"
+  echo "  2. No, and tell Claude what to do differently (esc)"
+done
+`
+
+// FakeAiderScript mimics aider's confirmation prompt.
+const FakeAiderScript = `#!/bin/sh
+echo "fake aider ready"
+while IFS= read -r line; do
+  echo "received: $line"
+  echo "Apply edits? (Y)es/(N)o/(D)on't ask again [Yes]:"
+done
+`
+
+// ghStubScript stands in for the GitHub CLI, which Pause requires even when nothing is pushed.
+const ghStubScript = `#!/bin/sh
+exit 0
+`
+
+// Harness is an isolated tmux server plus the filesystem state instances need.
+type Harness struct {
+	t testing.TB
+
+	// Socket is the tmux socket name passed to tmux -L.
+	Socket string
+	// Home is the temporary HOME directory. The claude-squad config dir lives inside it.
+	Home string
+	// RepoDir is a git repository with a single commit that instances are created in.
+	RepoDir string
+	// BinDir is prepended to PATH. Fake agents are installed here.
+	BinDir string
+
+	// origHome is HOME before the harness replaced it, used for go build caches.
+	origHome string
+
+	mu        sync.Mutex
+	instances []*session.Instance
+}
+
+// New creates a harness and registers cleanup with t. The test is skipped if tmux or git is
+// not installed.
+func New(t testing.TB) *Harness {
+	t.Helper()
+
+	for _, bin := range []string{"tmux", "git"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s not found in PATH: %v", bin, err)
+		}
+	}
+
+	log.Initialize(false)
+
+	h := &Harness{
+		t:        t,
+		Socket:   fmt.Sprintf("claudesquad-test-%d-%d", os.Getpid(), time.Now().UnixNano()),
+		Home:     t.TempDir(),
+		BinDir:   t.TempDir(),
+		origHome: os.Getenv("HOME"),
+	}
+
+	t.Setenv("HOME", h.Home)
+	t.Setenv("PATH", h.BinDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	// Keep git from reading the developer's global or system config
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "tmuxtest")
+	t.Setenv("GIT_AUTHOR_EMAIL", "tmuxtest@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "tmuxtest")
+	t.Setenv("GIT_COMMITTER_EMAIL", "tmuxtest@example.com")
+	// Make sure we never attach to the tmux server of the shell running the tests
+	t.Setenv("TMUX", "")
+
+	tmux.SetSocketName(h.Socket)
+	t.Cleanup(h.cleanup)
+
+	h.InstallAgent("gh", ghStubScript)
+	h.RepoDir = h.initRepo()
+	return h
+}
+
+// initRepo creates a git repository with one commit.
+func (h *Harness) initRepo() string {
+	h.t.Helper()
+
+	dir := filepath.Join(h.Home, "repo")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		h.t.Fatalf("failed to create repo dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# tmuxtest\n"), 0644); err != nil {
+		h.t.Fatalf("failed to write README: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "."},
+		{"commit", "-q", "-m", "initial commit"},
+	} {
+		h.Git(dir, args...)
+	}
+	return dir
+}
+
+// Git runs a git command in dir and fails the test on error. It returns trimmed stdout.
+func (h *Harness) Git(dir string, args ...string) string {
+	h.t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		h.t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// InstallAgent writes an executable script named name into BinDir and returns the name, so it
+// can be used directly as an instance program.
+func (h *Harness) InstallAgent(name, script string) string {
+	h.t.Helper()
+
+	path := filepath.Join(h.BinDir, name)
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		h.t.Fatalf("failed to install fake agent %s: %v", name, err)
+	}
+	return name
+}
+
+// BuildFakeAgent compiles claude-squad into BinDir and returns a program string that runs its
+// hidden fake-agent subcommand with the given extra flags.
+func (h *Harness) BuildFakeAgent(flags ...string) string {
+	h.t.Helper()
+
+	if _, err := exec.LookPath("go"); err != nil {
+		h.t.Skipf("go not found in PATH: %v", err)
+	}
+
+	bin := filepath.Join(h.BinDir, "claude-squad")
+	cmd := exec.Command("go", "build", "-o", bin, "claude-squad")
+	// Build with the real HOME so the module and build caches are reused
+	cmd.Env = append(os.Environ(), "HOME="+h.origHome)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		h.t.Fatalf("failed to build claude-squad: %v\n%s", err, out)
+	}
+	return strings.Join(append([]string{"claude-squad", tmux.ProgramFakeAgent}, flags...), " ")
+}
+
+// NewInstance creates an unstarted instance in RepoDir running program. The instance is killed
+// when the test finishes.
+func (h *Harness) NewInstance(title, program string) *session.Instance {
+	h.t.Helper()
+
+	return h.NewInstanceWithOptions(session.InstanceOptions{
+		Title:   title,
+		Program: program,
+	})
+}
+
+// NewInstanceWithOptions creates an unstarted instance from opts, in RepoDir unless opts sets a
+// path. The instance is killed when the test finishes.
+func (h *Harness) NewInstanceWithOptions(opts session.InstanceOptions) *session.Instance {
+	h.t.Helper()
+
+	if opts.Path == "" {
+		opts.Path = h.RepoDir
+	}
+	instance, err := session.NewInstance(opts)
+	if err != nil {
+		h.t.Fatalf("failed to create instance %s: %v", opts.Title, err)
+	}
+
+	h.mu.Lock()
+	h.instances = append(h.instances, instance)
+	h.mu.Unlock()
+	return instance
+}
+
+// StartInstance creates and starts an instance, failing the test on error.
+func (h *Harness) StartInstance(title, program string) *session.Instance {
+	h.t.Helper()
+
+	instance := h.NewInstance(title, program)
+	if err := instance.Start(true); err != nil {
+		h.t.Fatalf("failed to start instance %s: %v", title, err)
+	}
+	return instance
+}
+
+// Storage returns an in-memory store over the instances created by this harness, suitable for
+// passing to web.NewServer.
+func (h *Harness) Storage() session.InstanceStore {
+	return &harnessStore{h: h}
+}
+
+// WaitForContent polls the instance's pane until it contains substr or the timeout elapses.
+// It returns the last captured content.
+func (h *Harness) WaitForContent(instance *session.Instance, substr string, timeout time.Duration) string {
+	h.t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	var content string
+	for {
+		var err error
+		content, err = instance.Preview()
+		if err == nil && strings.Contains(content, substr) {
+			return content
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("timed out after %v waiting for %q in %s, last content:\n%s", timeout, substr, instance.Title, content)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// SessionExists reports whether the isolated tmux server has a session for the instance title.
+func (h *Harness) SessionExists(title string) bool {
+	return tmux.DoesSessionExist(tmux.ToClaudeSquadTmuxName(title))
+}
+
+// WindowSize returns the size of the tmux window of instance.
+func (h *Harness) WindowSize(instance *session.Instance) (width, height int) {
+	h.t.Helper()
+	out, err := exec.Command("tmux", "-L", h.Socket, "display-message", "-p", "-t", instance.GetTmuxSessionName(),
+		"#{window_width} #{window_height}").Output()
+	if err != nil {
+		h.t.Fatalf("failed to get the window size of %s: %v", instance.Title, err)
+	}
+	if _, err := fmt.Sscan(string(out), &width, &height); err != nil {
+		h.t.Fatalf("failed to parse the window size %q: %v", out, err)
+	}
+	return width, height
+}
+
+func (h *Harness) cleanup() {
+	h.mu.Lock()
+	instances := h.instances
+	h.instances = nil
+	h.mu.Unlock()
+
+	for _, instance := range instances {
+		if err := instance.Kill(); err != nil {
+			h.t.Logf("failed to kill instance %s: %v", instance.Title, err)
+		}
+	}
+
+	// Killing the server takes any leftover sessions with it
+	_ = exec.Command("tmux", "-L", h.Socket, "kill-server").Run()
+	tmux.SetSocketName("")
+}
+
+// harnessStore implements session.InstanceStore over the harness instances.
+type harnessStore struct {
+	h *Harness
+}
+
+func (s *harnessStore) LoadInstances() ([]*session.Instance, error) {
+	s.h.mu.Lock()
+	defer s.h.mu.Unlock()
+	instances := make([]*session.Instance, len(s.h.instances))
+	copy(instances, s.h.instances)
+	return instances, nil
+}
+
+func (s *harnessStore) SaveInstances(instances []*session.Instance) error {
+	s.h.mu.Lock()
+	defer s.h.mu.Unlock()
+	s.h.instances = instances
+	return nil
+}
+
+func (s *harnessStore) DeleteInstance(title string) error {
+	s.h.mu.Lock()
+	defer s.h.mu.Unlock()
+	for i, instance := range s.h.instances {
+		if instance.Title == title {
+			s.h.instances = append(s.h.instances[:i], s.h.instances[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("instance not found: %s", title)
+}
@@ -0,0 +1,714 @@
+//go:build integration
+
+package tmuxtest_test
+
+import (
+	"claude-squad/config"
+	"claude-squad/fakeagent"
+	"claude-squad/session"
+	"claude-squad/session/tmuxtest"
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstanceLifecycle(t *testing.T) {
+	h := tmuxtest.New(t)
+	program := h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+
+	instance := h.StartInstance("lifecycle", program)
+	if !h.SessionExists("lifecycle") {
+		t.Fatalf("tmux session was not created")
+	}
+	if instance.Status != session.Running {
+		t.Errorf("status after start = %v, want running", instance.Status)
+	}
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+
+	worktree, err := instance.GetGitWorktree()
+	if err != nil {
+		t.Fatalf("GetGitWorktree() error: %v", err)
+	}
+	worktreePath := worktree.GetWorktreePath()
+	if !strings.HasPrefix(worktreePath, h.Home) {
+		t.Errorf("worktree %s is outside the harness HOME %s", worktreePath, h.Home)
+	}
+
+	// Leave a change behind so Pause has to commit it
+	if err := os.WriteFile(worktreePath+"/change.txt", []byte("change\n"), 0644); err != nil {
+		t.Fatalf("failed to write change: %v", err)
+	}
+
+	if err := instance.Pause(); err != nil {
+		t.Fatalf("Pause() error: %v", err)
+	}
+	if !instance.Paused() {
+		t.Errorf("instance not marked paused")
+	}
+	if h.SessionExists("lifecycle") {
+		t.Errorf("tmux session still exists after pause")
+	}
+	if _, err := os.Stat(worktreePath); !os.IsNotExist(err) {
+		t.Errorf("worktree still exists after pause: %v", err)
+	}
+	if log := h.Git(h.RepoDir, "log", "--oneline", worktree.GetBranchName()); !strings.Contains(log, "paused") {
+		t.Errorf("pause did not commit changes to the branch, log:\n%s", log)
+	}
+
+	if err := instance.Resume(); err != nil {
+		t.Fatalf("Resume() error: %v", err)
+	}
+	if instance.Status != session.Running {
+		t.Errorf("status after resume = %v, want running", instance.Status)
+	}
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+	if _, err := os.Stat(worktreePath + "/change.txt"); err != nil {
+		t.Errorf("committed change missing after resume: %v", err)
+	}
+
+	if err := instance.Kill(); err != nil {
+		t.Fatalf("Kill() error: %v", err)
+	}
+	if h.SessionExists("lifecycle") {
+		t.Errorf("tmux session still exists after kill")
+	}
+	if _, err := os.Stat(worktreePath); !os.IsNotExist(err) {
+		t.Errorf("worktree still exists after kill: %v", err)
+	}
+}
+
+func TestInstancePromptDetection(t *testing.T) {
+	tests := []struct {
+		name    string
+		agent   string
+		script  string
+		program string
+	}{
+		{name: "claude", agent: "claude", script: tmuxtest.FakeClaudeScript, program: "claude"},
+		{name: "aider", agent: "aider", script: tmuxtest.FakeAiderScript, program: "aider"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tmuxtest.New(t)
+			h.InstallAgent(tt.agent, tt.script)
+
+			instance := h.StartInstance("prompt-"+tt.name, tt.program)
+			h.WaitForContent(instance, "ready", 5*time.Second)
+			if _, hasPrompt := instance.HasUpdated(); hasPrompt {
+				t.Fatalf("prompt detected before any input")
+			}
+
+			if err := instance.SendPrompt("hello"); err != nil {
+				t.Fatalf("SendPrompt() error: %v", err)
+			}
+			h.WaitForContent(instance, "received: hello", 5*time.Second)
+			if _, hasPrompt := instance.HasUpdated(); !hasPrompt {
+				t.Errorf("prompt not detected after agent asked for confirmation")
+			}
+		})
+	}
+}
+
+func TestFakeAgentSubcommand(t *testing.T) {
+	h := tmuxtest.New(t)
+	program := h.BuildFakeAgent("--delay=0", "--prompt-every=1")
+
+	// Start answers the trust question the same way it does for claude
+	instance := h.StartInstance("fake-agent", program)
+	h.WaitForContent(instance, fakeagent.ReadyBanner, 10*time.Second)
+
+	if err := instance.SendPrompt("hello"); err != nil {
+		t.Fatalf("SendPrompt() error: %v", err)
+	}
+	h.WaitForContent(instance, fakeagent.PermissionPrompt, 5*time.Second)
+	if _, hasPrompt := instance.HasUpdated(); !hasPrompt {
+		t.Errorf("prompt not detected for fake-agent program")
+	}
+
+	instance.AutoYes = true
+	instance.TapEnter()
+	h.WaitForContent(instance, "Done with turn 1", 5*time.Second)
+}
+
+func TestInstanceSwapProgram(t *testing.T) {
+	h := tmuxtest.New(t)
+	h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+	h.InstallAgent("aider", tmuxtest.FakeAiderScript)
+
+	instance := h.StartInstance("swap", "claude")
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+	worktree, err := instance.GetGitWorktree()
+	if err != nil {
+		t.Fatalf("GetGitWorktree() error: %v", err)
+	}
+	if err := os.WriteFile(worktree.GetWorktreePath()+"/wip.txt", []byte("wip\n"), 0644); err != nil {
+		t.Fatalf("failed to write change: %v", err)
+	}
+
+	if err := instance.SwapProgram("aider"); err != nil {
+		t.Fatalf("SwapProgram() error: %v", err)
+	}
+	if instance.Program != "aider" {
+		t.Errorf("program = %q, want aider", instance.Program)
+	}
+	h.WaitForContent(instance, "fake aider ready", 5*time.Second)
+	if got, want := instance.ResolvedProgram(), h.BinDir+"/aider"; got != want {
+		t.Errorf("ResolvedProgram() = %q, want %q", got, want)
+	}
+
+	// Restarting with extra flags on the resolved path is still detected as the same agent
+	if err := instance.SwapProgram(h.BinDir + "/claude --model test"); err != nil {
+		t.Fatalf("SwapProgram() with flags error: %v", err)
+	}
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+	if err := instance.SendPrompt("hello"); err != nil {
+		t.Fatalf("SendPrompt() error: %v", err)
+	}
+	h.WaitForContent(instance, "received: hello", 5*time.Second)
+	if _, hasPrompt := instance.HasUpdated(); !hasPrompt {
+		t.Errorf("prompt not detected for claude started by path with flags")
+	}
+
+	after, err := instance.GetGitWorktree()
+	if err != nil {
+		t.Fatalf("GetGitWorktree() error: %v", err)
+	}
+	if after.GetWorktreePath() != worktree.GetWorktreePath() || after.GetBranchName() != worktree.GetBranchName() {
+		t.Errorf("swap changed the worktree: %s/%s -> %s/%s", worktree.GetWorktreePath(), worktree.GetBranchName(),
+			after.GetWorktreePath(), after.GetBranchName())
+	}
+	if _, err := os.Stat(after.GetWorktreePath() + "/wip.txt"); err != nil {
+		t.Errorf("uncommitted work lost across swap: %v", err)
+	}
+}
+
+func TestSuspendAll(t *testing.T) {
+	h := tmuxtest.New(t)
+	h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+
+	instance := h.StartInstance("suspend", "claude")
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+
+	suspended, err := session.ToggleSuspendAll([]*session.Instance{instance})
+	if err != nil || !suspended {
+		t.Fatalf("ToggleSuspendAll() = %v, %v, want true, nil", suspended, err)
+	}
+	if !session.Suspended() {
+		t.Errorf("Suspended() = false after suspending")
+	}
+
+	suspended, err = session.ToggleSuspendAll([]*session.Instance{instance})
+	if err != nil || suspended {
+		t.Fatalf("ToggleSuspendAll() = %v, %v, want false, nil", suspended, err)
+	}
+	// The interrupted agent is told to pick up where it left off. The fake agent echoes the escape
+	// it was interrupted with, which garbles the echoed line, so wait for its reply instead.
+	h.WaitForContent(instance, "Do you want to proceed?", 5*time.Second)
+}
+
+func TestInstanceEvents(t *testing.T) {
+	h := tmuxtest.New(t)
+	h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+
+	var events []session.Event
+	unsubscribe := session.Subscribe(func(e session.Event) {
+		if e.Instance == "events" {
+			events = append(events, e)
+		}
+	})
+	defer unsubscribe()
+
+	instance := h.StartInstance("events", "claude")
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+	instance.SetStatus(session.Ready)
+	instance.SetStatus(session.Ready)
+	instance.SetPrompt(true)
+	instance.SetPrompt(true)
+	instance.SetPrompt(false)
+	if err := instance.Kill(); err != nil {
+		t.Fatalf("Kill() error: %v", err)
+	}
+
+	want := []session.EventType{
+		session.EventCreated,
+		session.EventStatusChanged,
+		session.EventPromptDetected,
+		session.EventKilled,
+	}
+	var got []session.EventType
+	for _, e := range events {
+		got = append(got, e.Type)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	if events[1].PreviousStatus != "running" || events[1].Status != "ready" {
+		t.Errorf("status change = %s -> %s, want running -> ready", events[1].PreviousStatus, events[1].Status)
+	}
+}
+
+func TestForkInstance(t *testing.T) {
+	h := tmuxtest.New(t)
+	h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+
+	parent := h.StartInstance("parent", "claude")
+	h.WaitForContent(parent, "fake agent ready", 5*time.Second)
+	worktree, err := parent.GetGitWorktree()
+	if err != nil {
+		t.Fatalf("GetGitWorktree() error: %v", err)
+	}
+	if err := os.WriteFile(worktree.GetWorktreePath()+"/parent.txt", []byte("parent\n"), 0644); err != nil {
+		t.Fatalf("failed to write change: %v", err)
+	}
+	h.Git(worktree.GetWorktreePath(), "add", "parent.txt")
+	h.Git(worktree.GetWorktreePath(), "commit", "-m", "parent work")
+
+	fork := h.NewInstanceWithOptions(session.InstanceOptions{
+		Title:      "fork",
+		Program:    "claude",
+		Parent:     parent.Title,
+		Relation:   session.RelationFork,
+		BaseBranch: parent.Branch,
+	})
+	if err := fork.Start(true); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	forkWorktree, err := fork.GetGitWorktree()
+	if err != nil {
+		t.Fatalf("GetGitWorktree() error: %v", err)
+	}
+	if _, err := os.Stat(forkWorktree.GetWorktreePath() + "/parent.txt"); err != nil {
+		t.Errorf("fork does not start from the parent's branch: %v", err)
+	}
+	if forkWorktree.GetBranchName() == worktree.GetBranchName() {
+		t.Errorf("fork shares the parent's branch %s", worktree.GetBranchName())
+	}
+
+	// The relationship survives a save and load
+	restored, err := session.FromInstanceData(fork.ToInstanceData())
+	if err != nil {
+		t.Fatalf("FromInstanceData() error: %v", err)
+	}
+	graph := session.BuildGraph([]*session.Instance{parent, restored})
+	want := []session.GraphEdge{{Parent: "parent", Child: "fork", Relation: session.RelationFork}}
+	if !reflect.DeepEqual(graph.Edges, want) {
+		t.Errorf("edges = %+v, want %+v", graph.Edges, want)
+	}
+}
+
+func TestRetryInstance(t *testing.T) {
+	h := tmuxtest.New(t)
+	h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+	h.InstallAgent("aider", tmuxtest.FakeAiderScript)
+
+	original := h.StartInstance("original", "claude")
+	h.WaitForContent(original, "fake agent ready", 5*time.Second)
+	for _, prompt := range []string{"hello", "again"} {
+		if err := original.SendPrompt(prompt); err != nil {
+			t.Fatalf("SendPrompt() error: %v", err)
+		}
+	}
+	if original.Prompt != "hello" {
+		t.Errorf("Prompt = %q, want the first prompt hello", original.Prompt)
+	}
+	worktree, err := original.GetGitWorktree()
+	if err != nil {
+		t.Fatalf("GetGitWorktree() error: %v", err)
+	}
+	if err := os.WriteFile(worktree.GetWorktreePath()+"/off-the-rails.txt", []byte("oops\n"), 0644); err != nil {
+		t.Fatalf("failed to write change: %v", err)
+	}
+	h.Git(worktree.GetWorktreePath(), "add", "off-the-rails.txt")
+	h.Git(worktree.GetWorktreePath(), "commit", "-m", "off the rails")
+
+	opts := session.RetryOptions(original, "aider")
+	opts.Title = "retry"
+	retry := h.NewInstanceWithOptions(opts)
+	if err := retry.Start(true); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	h.WaitForContent(retry, "fake aider ready", 5*time.Second)
+	retryWorktree, err := retry.GetGitWorktree()
+	if err != nil {
+		t.Fatalf("GetGitWorktree() error: %v", err)
+	}
+	if _, err := os.Stat(retryWorktree.GetWorktreePath() + "/off-the-rails.txt"); !os.IsNotExist(err) {
+		t.Errorf("retry did not start from a fresh worktree: %v", err)
+	}
+	if retry.Parent != "original" || retry.Relation != session.RelationRetryOf {
+		t.Errorf("retry lineage = %q %q, want original retry-of", retry.Parent, retry.Relation)
+	}
+
+	if err := retry.SendPrompt(original.Prompt); err != nil {
+		t.Fatalf("SendPrompt() error: %v", err)
+	}
+	h.WaitForContent(retry, "received: hello", 5*time.Second)
+}
+
+func TestIntegrateInstances(t *testing.T) {
+	h := tmuxtest.New(t)
+	h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+
+	var instances []*session.Instance
+	for _, title := range []string{"part-a", "part-b"} {
+		instance := h.StartInstance(title, "claude")
+		worktree, err := instance.GetGitWorktree()
+		if err != nil {
+			t.Fatalf("GetGitWorktree() error: %v", err)
+		}
+		if err := os.WriteFile(worktree.GetWorktreePath()+"/"+title+".txt", []byte(title+"\n"), 0644); err != nil {
+			t.Fatalf("failed to write change: %v", err)
+		}
+		h.Git(worktree.GetWorktreePath(), "add", ".")
+		h.Git(worktree.GetWorktreePath(), "commit", "-m", title)
+		instances = append(instances, instance)
+	}
+	// Left uncommitted, so it is reported rather than merged
+	worktree, _ := instances[1].GetGitWorktree()
+	if err := os.WriteFile(worktree.GetWorktreePath()+"/wip.txt", []byte("wip\n"), 0644); err != nil {
+		t.Fatalf("failed to write change: %v", err)
+	}
+
+	result, uncommitted, err := session.IntegrateInstances("integration/parts", instances)
+	if err != nil {
+		t.Fatalf("IntegrateInstances() error: %v", err)
+	}
+	for _, merge := range result.Merges {
+		if !merge.Merged {
+			t.Errorf("%s was not merged: %v", merge.Branch, merge.Conflicts)
+		}
+	}
+	if !reflect.DeepEqual(uncommitted, []string{"part-b"}) {
+		t.Errorf("uncommitted = %v, want [part-b]", uncommitted)
+	}
+	files := h.Git(h.RepoDir, "ls-tree", "--name-only", "integration/parts")
+	if !strings.Contains(files, "part-a.txt") || !strings.Contains(files, "part-b.txt") || strings.Contains(files, "wip.txt") {
+		t.Errorf("integration branch files:\n%s", files)
+	}
+}
+
+func TestSnapshotBeforeRiskyPrompt(t *testing.T) {
+	h := tmuxtest.New(t)
+	h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+	session.SetSnapshotPromptPatterns([]string{"delete"})
+	t.Cleanup(func() { session.SetSnapshotPromptPatterns(nil) })
+
+	instance := h.StartInstance("risky", "claude")
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+	worktree, err := instance.GetGitWorktree()
+	if err != nil {
+		t.Fatalf("GetGitWorktree() error: %v", err)
+	}
+	notes := worktree.GetWorktreePath() + "/notes.txt"
+	if err := os.WriteFile(notes, []byte("keep me\n"), 0644); err != nil {
+		t.Fatalf("failed to write notes: %v", err)
+	}
+
+	if err := instance.SendPrompt("list the files"); err != nil {
+		t.Fatalf("SendPrompt() error: %v", err)
+	}
+	if err := instance.SendPrompt("Delete the notes"); err != nil {
+		t.Fatalf("SendPrompt() error: %v", err)
+	}
+	snapshots, err := instance.Snapshots()
+	if err != nil {
+		t.Fatalf("Snapshots() error: %v", err)
+	}
+	if len(snapshots) != 1 || !strings.Contains(snapshots[0].Message, "Delete the notes") {
+		t.Fatalf("snapshots = %+v, want one taken before the risky prompt", snapshots)
+	}
+
+	if err := os.Remove(notes); err != nil {
+		t.Fatalf("failed to remove notes: %v", err)
+	}
+	if err := instance.Rollback(snapshots[0]); err != nil {
+		t.Fatalf("Rollback() error: %v", err)
+	}
+	if content, err := os.ReadFile(notes); err != nil || string(content) != "keep me\n" {
+		t.Errorf("notes after rollback = %q, %v, want the untracked file restored", content, err)
+	}
+	if snapshots, _ := instance.Snapshots(); len(snapshots) != 2 {
+		t.Errorf("got %d snapshots after rollback, want the rollback to snapshot first", len(snapshots))
+	}
+}
+
+func TestStartCanceled(t *testing.T) {
+	h := tmuxtest.New(t)
+	program := h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	instance := h.NewInstance("canceled", program)
+	if err := instance.StartContext(ctx, true); !errors.Is(err, context.Canceled) {
+		t.Fatalf("StartContext() error = %v, want context.Canceled", err)
+	}
+	if instance.Started() {
+		t.Errorf("canceled instance is marked started")
+	}
+	if stage := instance.StartStage(); stage != "" {
+		t.Errorf("StartStage() = %q after the start returned", stage)
+	}
+	if h.SessionExists("canceled") {
+		t.Errorf("tmux session exists after the start was canceled")
+	}
+	if worktrees := h.Git(h.RepoDir, "worktree", "list"); strings.Count(worktrees, "\n") > 0 {
+		t.Errorf("worktree left behind after the start was canceled:\n%s", worktrees)
+	}
+}
+
+func TestRestoreFromMetadata(t *testing.T) {
+	h := tmuxtest.New(t)
+	program := h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+
+	original := h.StartInstance("restore", program)
+	h.WaitForContent(original, "fake agent ready", 5*time.Second)
+	original.SetStatus(session.Ready)
+	data := original.ToInstanceData()
+
+	instance := session.FromInstanceMetadata(data)
+	if instance.Status != session.Loading || !instance.Restoring() {
+		t.Fatalf("status before restore = %v, restoring = %v, want loading", instance.Status, instance.Restoring())
+	}
+	if saved := instance.ToInstanceData().Status; saved != session.Ready {
+		t.Errorf("status saved while restoring = %v, want the stored ready", saved)
+	}
+
+	instance.Restore()
+	if instance.Restoring() || instance.Status != session.Ready || !instance.Started() {
+		t.Errorf("after restore: restoring = %v, status = %v, started = %v", instance.Restoring(), instance.Status, instance.Started())
+	}
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+	// The status monitor is set up by the restore
+	instance.HasUpdated()
+}
+
+func TestTmuxNameCollision(t *testing.T) {
+	h := tmuxtest.New(t)
+	program := h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+
+	// Both titles sanitize to the same tmux session name
+	first := h.StartInstance("fixbug", program)
+	second := h.StartInstance("fix bug", program)
+	if first.GetTmuxSessionName() == second.GetTmuxSessionName() {
+		t.Fatalf("both instances use tmux session %s", first.GetTmuxSessionName())
+	}
+	h.WaitForContent(first, "fake agent ready", 5*time.Second)
+	h.WaitForContent(second, "fake agent ready", 5*time.Second)
+
+	// The actual name is saved and used again when the instance is loaded
+	data := second.ToInstanceData()
+	if data.TmuxSession != second.GetTmuxSessionName() {
+		t.Errorf("saved tmux session = %q, want %q", data.TmuxSession, second.GetTmuxSessionName())
+	}
+	loaded, err := session.FromInstanceData(data)
+	if err != nil {
+		t.Fatalf("FromInstanceData() error: %v", err)
+	}
+	if !loaded.Started() || loaded.GetTmuxSessionName() != data.TmuxSession {
+		t.Errorf("loaded instance started = %v with tmux session %q, want %q", loaded.Started(),
+			loaded.GetTmuxSessionName(), data.TmuxSession)
+	}
+}
+
+func TestPreviewSizeAfterDetach(t *testing.T) {
+	h := tmuxtest.New(t)
+	program := h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+	instance := h.StartInstance("resize", program)
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+
+	// The status line takes a row of the size given
+	waitForSize := func(width, height int) {
+		t.Helper()
+		height--
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			w, hh := h.WindowSize(instance)
+			if w == width && hh == height {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("window size = %dx%d, want %dx%d", w, hh, width, height)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	if err := instance.SetPreviewSize(100, 30); err != nil {
+		t.Fatalf("SetPreviewSize() error: %v", err)
+	}
+	waitForSize(100, 30)
+
+	// Attach copies the pane to stdout and reads stdin, which the test stands in for
+	stdin, stdinWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	origStdin, origStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = stdin, devNull
+	defer func() {
+		os.Stdin, os.Stdout = origStdin, origStdout
+		stdinWriter.Close()
+		devNull.Close()
+	}()
+
+	ch, err := instance.Attach()
+	if err != nil {
+		t.Fatalf("Attach() error: %v", err)
+	}
+	// The TUI is resized while attached: the attached pane keeps its size until the detach
+	if err := instance.SetPreviewSize(60, 20); err != nil {
+		t.Fatalf("SetPreviewSize() while attached error: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if w, hh := h.WindowSize(instance); w != 100 || hh != 29 {
+		t.Errorf("window size while attached = %dx%d, want 100x29", w, hh)
+	}
+
+	instance.Detach()
+	<-ch
+	waitForSize(60, 20)
+}
+
+func TestCompanionWindows(t *testing.T) {
+	h := tmuxtest.New(t)
+	h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+	session.SetWindows([]config.WindowConfig{{Name: "tests", Command: "sh -c 'echo tests watching; sleep 60'"}})
+	defer session.SetWindows(nil)
+
+	instance := h.StartInstance("windows", "claude")
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+	if err := instance.OpenWindow(session.ShellWindowName, ""); err != nil {
+		t.Fatalf("OpenWindow() error: %v", err)
+	}
+	windows, err := instance.Windows()
+	if err != nil {
+		t.Fatalf("Windows() error: %v", err)
+	}
+	var names []string
+	for _, window := range windows {
+		names = append(names, window.Name)
+	}
+	if len(windows) != 3 || !windows[0].Agent || names[1] != "tests" || names[2] != "shell" || !windows[2].Viewed {
+		t.Fatalf("windows = %+v, want the program's, tests and the shell shown", windows)
+	}
+
+	// Prompts go to the program while the shell is shown, and the status comes from its window
+	if err := instance.SendPrompt("hello"); err != nil {
+		t.Fatalf("SendPrompt() error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, hasPrompt := instance.HasUpdated(); hasPrompt {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("prompt of the program not detected while the shell is shown")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if content, _ := instance.Preview(); strings.Contains(content, "received: hello") {
+		t.Errorf("preview shows the program, want the shell:\n%s", content)
+	}
+
+	// Cycling goes back to the program after the last window
+	if window, err := instance.NextWindow(); err != nil || !window.Agent {
+		t.Fatalf("NextWindow() = %+v, %v; want the program's window", window, err)
+	}
+	if window, err := instance.NextWindow(); err != nil || window.Name != "tests" {
+		t.Fatalf("NextWindow() = %+v, %v; want the tests window", window, err)
+	}
+	h.WaitForContent(instance, "tests watching", 5*time.Second)
+}
+
+func TestProgramExit(t *testing.T) {
+	h := tmuxtest.New(t)
+	program := h.InstallAgent("claude", "#!/bin/sh\necho \"fake agent ready\"\nread -r line\necho \"bye\"\nexit 3\n")
+	instance := h.StartInstance("exit", program)
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+	if instance.CheckExited() {
+		t.Fatal("CheckExited() = true while the program runs")
+	}
+
+	if err := instance.SendPrompt("quit"); err != nil {
+		t.Fatalf("SendPrompt() error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !instance.CheckExited() {
+		if time.Now().After(deadline) {
+			t.Fatal("the program's exit was not detected")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if instance.Status != session.Exited {
+		t.Errorf("status = %v, want exited", instance.Status)
+	}
+	// Some sandboxes keep tmux from reaping its children, leaving the status unknown
+	if instance.ExitCode != 3 && instance.ExitCode != -1 {
+		t.Errorf("exit code = %d, want 3", instance.ExitCode)
+	}
+	// The pane is kept with the last output
+	h.WaitForContent(instance, "bye", time.Second)
+
+	if err := instance.Restart(); err != nil {
+		t.Fatalf("Restart() error: %v", err)
+	}
+	if instance.Status != session.Running || instance.CheckExited() {
+		t.Errorf("status after restart = %v, want running", instance.Status)
+	}
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+}
+
+func TestAutoRestart(t *testing.T) {
+	session.SetRestartPolicies(config.RestartPolicy{Mode: config.RestartAlways, BackoffMs: 1, MaxRetries: 1}, nil)
+	t.Cleanup(func() { session.SetRestartPolicies(config.RestartPolicy{}, nil) })
+
+	h := tmuxtest.New(t)
+	program := h.InstallAgent("claude", "#!/bin/sh\necho \"fake agent ready\"\nread -r line\nexit 0\n")
+	instance := h.StartInstance("restart", program)
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+
+	if err := instance.SendPrompt("quit"); err != nil {
+		t.Fatalf("SendPrompt() error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !instance.CheckExited() {
+		if time.Now().After(deadline) {
+			t.Fatal("the program's exit was not detected")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	restarted, err := instance.AutoRestart(time.Now().Add(time.Second))
+	if err != nil || !restarted {
+		t.Fatalf("AutoRestart() = %v, %v; want a restart", restarted, err)
+	}
+	if instance.Status != session.Running || instance.Restarts != 1 {
+		t.Errorf("status = %v with %d restarts, want running with 1", instance.Status, instance.Restarts)
+	}
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+}
+
+func TestSlashCost(t *testing.T) {
+	h := tmuxtest.New(t)
+	program := h.BuildFakeAgent("--delay=0", "--prompt-every=0")
+	instance := h.StartInstance("cost", program)
+	h.WaitForContent(instance, fakeagent.ReadyBanner, 10*time.Second)
+
+	if err := instance.SendSlashCommand(session.SlashCost); err != nil {
+		t.Fatalf("SendSlashCommand() error: %v", err)
+	}
+	h.WaitForContent(instance, "Total duration (wall)", 5*time.Second)
+	if ok, err := instance.ReadCost(); !ok || err != nil {
+		t.Fatalf("ReadCost() = %v, %v; want the cost", ok, err)
+	}
+	if instance.Cost.USD != 0.0125 || instance.Cost.APIDuration != "1s" {
+		t.Errorf("cost = %+v, want $0.0125 over 1s", instance.Cost)
+	}
+}
@@ -0,0 +1,372 @@
+//go:build integration
+
+package tmuxtest_test
+
+import (
+	"claude-squad/config"
+	"claude-squad/session"
+	"claude-squad/session/tmuxtest"
+	"claude-squad/web"
+	"claude-squad/web/handlers"
+	"claude-squad/web/terminal"
+	"claude-squad/web/types"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebEndpoints(t *testing.T) {
+	h := tmuxtest.New(t)
+	program := h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+	instance := h.StartInstance("web", program)
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+
+	cfg := config.DefaultConfig()
+	cfg.WebServerHost = "127.0.0.1"
+	cfg.WebServerPort = 0 // The real listener is unused; requests go through httptest
+
+	server := web.NewServer(h.Storage(), cfg)
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer server.Stop()
+
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	t.Run("ListInstances", func(t *testing.T) {
+		var resp struct {
+			Instances []map[string]interface{} `json:"instances"`
+		}
+		getJSON(t, ts.URL+"/api/instances", &resp)
+		if len(resp.Instances) != 1 || resp.Instances[0]["title"] != "web" {
+			t.Fatalf("unexpected instances: %v", resp.Instances)
+		}
+		if resp.Instances[0]["status"] != "running" {
+			t.Errorf("status = %v, want running", resp.Instances[0]["status"])
+		}
+	})
+
+	t.Run("InstanceOutput", func(t *testing.T) {
+		var output map[string]interface{}
+		getJSON(t, ts.URL+"/api/instances/web/output?format=text", &output)
+		content, _ := output["content"].(string)
+		if !strings.Contains(content, "fake agent ready") {
+			t.Errorf("output does not contain agent banner: %q", content)
+		}
+	})
+
+	t.Run("InstanceDiff", func(t *testing.T) {
+		// Diff stats are refreshed by the caller (the TUI tick in the app)
+		if err := instance.UpdateDiffStats(); err != nil {
+			t.Fatalf("UpdateDiffStats() error: %v", err)
+		}
+		var diff map[string]interface{}
+		getJSON(t, ts.URL+"/api/instances/web/diff?format=stats", &diff)
+		if _, ok := diff["added"]; !ok {
+			t.Errorf("diff stats missing added count: %v", diff)
+		}
+	})
+
+	t.Run("WebSocketInput", func(t *testing.T) {
+		wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws/web?privileges=read-write&format=text"
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("failed to dial websocket: %v", err)
+		}
+		defer conn.Close()
+
+		if err := conn.WriteJSON(types.TerminalInput{InstanceTitle: "web", Content: "ping\n"}); err != nil {
+			t.Fatalf("failed to send input: %v", err)
+		}
+
+		deadline := time.Now().Add(10 * time.Second)
+		_ = conn.SetReadDeadline(deadline)
+		for time.Now().Before(deadline) {
+			var update types.TerminalUpdate
+			if err := conn.ReadJSON(&update); err != nil {
+				t.Fatalf("failed to read update: %v", err)
+			}
+			if strings.Contains(update.Content, "received: ping") {
+				if !update.HasPrompt {
+					t.Errorf("update did not report the agent prompt")
+				}
+				return
+			}
+		}
+		t.Fatalf("never saw the agent echo the input")
+	})
+}
+
+func TestWebLifecycle(t *testing.T) {
+	h := tmuxtest.New(t)
+	h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+	instance := h.StartInstance("lifecycle", "claude")
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+
+	server := web.NewServer(h.Storage(), config.DefaultConfig())
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	if code := request(t, http.MethodPost, ts.URL+"/api/instances/lifecycle/resume"); code != http.StatusConflict {
+		t.Errorf("resume of a running instance = %d, want 409", code)
+	}
+	if code := request(t, http.MethodPost, ts.URL+"/api/instances/lifecycle/pause"); code != http.StatusOK {
+		t.Fatalf("pause = %d, want 200", code)
+	}
+	if !instance.Paused() || h.SessionExists("lifecycle") {
+		t.Errorf("after pause, paused = %v and tmux session exists = %v", instance.Paused(), h.SessionExists("lifecycle"))
+	}
+	if code := request(t, http.MethodPost, ts.URL+"/api/instances/lifecycle/resume"); code != http.StatusOK {
+		t.Fatalf("resume = %d, want 200", code)
+	}
+	if instance.Paused() || !h.SessionExists("lifecycle") {
+		t.Errorf("after resume, paused = %v and tmux session exists = %v", instance.Paused(), h.SessionExists("lifecycle"))
+	}
+	if code := request(t, http.MethodDelete, ts.URL+"/api/instances/lifecycle"); code != http.StatusNoContent {
+		t.Fatalf("kill = %d, want 204", code)
+	}
+	if h.SessionExists("lifecycle") {
+		t.Error("tmux session still exists after kill")
+	}
+	if instances, _ := h.Storage().LoadInstances(); len(instances) != 0 {
+		t.Errorf("instances after kill = %d, want 0", len(instances))
+	}
+}
+
+func TestWebPromptReplay(t *testing.T) {
+	h := tmuxtest.New(t)
+	h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+	instance := h.StartInstance("prompts", "claude")
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+	if err := instance.SendPrompt("ping"); err != nil {
+		t.Fatalf("SendPrompt() error: %v", err)
+	}
+	h.WaitForContent(instance, "received: ping", 5*time.Second)
+
+	server := web.NewServer(h.Storage(), config.DefaultConfig())
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	var prompts handlers.InstancePrompts
+	getJSON(t, ts.URL+"/api/instances/prompts/prompts", &prompts)
+	if len(prompts.Prompts) != 1 || prompts.Prompts[0].Prompt != "ping" {
+		t.Fatalf("prompts = %+v, want ping", prompts.Prompts)
+	}
+	if code := request(t, http.MethodPost, ts.URL+"/api/instances/prompts/prompts/0/replay"); code != http.StatusOK {
+		t.Fatalf("replay = %d, want 200", code)
+	}
+	if history := instance.PromptHistory(); len(history) != 2 || history[1].Prompt != "ping" {
+		t.Errorf("history after replay = %+v, want ping twice", history)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		content, err := instance.Preview()
+		if err == nil && strings.Count(content, "received: ping") >= 2 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the agent didn't receive the prompt again: %q", content)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestWebAttach(t *testing.T) {
+	h := tmuxtest.New(t)
+	h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+	instance := h.StartInstance("attach", "claude")
+	h.WaitForContent(instance, "fake agent ready", 5*time.Second)
+
+	server := web.NewServer(h.Storage(), config.DefaultConfig())
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws/attach/attach?privileges=read-write&cols=100&rows=30"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("failed to dial websocket: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	var attached terminal.AttachControl
+	if err := conn.ReadJSON(&attached); err != nil {
+		t.Fatalf("failed to read the attached message: %v", err)
+	}
+	if attached.Type != "attached" || attached.Cols != 100 || attached.Rows != 30 || attached.ReadOnly {
+		t.Fatalf("attached = %+v, want a read-write 100x30 terminal", attached)
+	}
+
+	// readUntil reads output, acknowledging it, until it contains want
+	var output strings.Builder
+	readUntil := func(want string) {
+		t.Helper()
+		for !strings.Contains(output.String(), want) {
+			kind, data, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("never saw %q in the output: %v\n%q", want, err, output.String())
+			}
+			if kind != websocket.BinaryMessage {
+				continue
+			}
+			output.Write(data)
+			if err := conn.WriteJSON(terminal.AttachControl{Type: "ack", Bytes: len(data)}); err != nil {
+				t.Fatalf("failed to send ack: %v", err)
+			}
+		}
+	}
+	readUntil("fake agent ready")
+
+	if err := conn.WriteMessage(websocket.BinaryMessage, []byte("ping\r")); err != nil {
+		t.Fatalf("failed to send input: %v", err)
+	}
+	readUntil("received: ping")
+
+	if err := conn.WriteJSON(terminal.AttachControl{Type: "resize", Cols: 90, Rows: 20}); err != nil {
+		t.Fatalf("failed to send resize: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		size, err := exec.Command("tmux", "-L", h.Socket, "display-message", "-p", "-t", instance.GetTmuxSessionName(), "#{window_width}x#{window_height}").Output()
+		if err == nil && strings.TrimSpace(string(size)) == "90x19" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("window size after resize = %q (%v), want 90x19 under the status line", size, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if err := instance.Kill(); err != nil {
+		t.Fatalf("Kill() error: %v", err)
+	}
+	for {
+		kind, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("connection closed without a detached message: %v", err)
+		}
+		var control terminal.AttachControl
+		if kind == websocket.TextMessage && json.Unmarshal(data, &control) == nil && control.Type == "detached" {
+			break
+		}
+	}
+}
+
+// request sends a request without a body to url and returns the status code of the response.
+func request(t *testing.T, method, url string) int {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func getJSON(t *testing.T, url string, v interface{}) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s returned %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("failed to decode %s: %v", url, err)
+	}
+}
+
+func TestWebCompare(t *testing.T) {
+	h := tmuxtest.New(t)
+	h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+	a := h.StartInstance("attempt-a", "claude")
+	b := h.StartInstance("attempt-b", "claude")
+	for _, instance := range []*session.Instance{a, b} {
+		worktree, err := instance.GetGitWorktree()
+		if err != nil {
+			t.Fatalf("GetGitWorktree() error: %v", err)
+		}
+		content := []byte("changed by " + instance.Title + "\n")
+		if err := os.WriteFile(worktree.GetWorktreePath()+"/README.md", content, 0644); err != nil {
+			t.Fatalf("failed to write change: %v", err)
+		}
+		if err := os.WriteFile(worktree.GetWorktreePath()+"/"+instance.Title+".txt", content, 0644); err != nil {
+			t.Fatalf("failed to write change: %v", err)
+		}
+	}
+
+	server := web.NewServer(h.Storage(), config.DefaultConfig())
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	var comparison handlers.InstanceComparison
+	getJSON(t, ts.URL+"/api/compare?a=attempt-a&b=attempt-b", &comparison)
+	if len(comparison.Both) != 1 || comparison.Both[0].Path != "README.md" || len(comparison.Both[0].Conflicts) != 1 {
+		t.Errorf("both = %+v, want one conflict in README.md", comparison.Both)
+	}
+	if !reflect.DeepEqual(comparison.OnlyA, []string{"attempt-a.txt"}) ||
+		!reflect.DeepEqual(comparison.OnlyB, []string{"attempt-b.txt"}) {
+		t.Errorf("only a = %v, only b = %v", comparison.OnlyA, comparison.OnlyB)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/compare?a=attempt-a")
+	if err != nil {
+		t.Fatalf("GET /api/compare error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("compare without b = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestWebFiles(t *testing.T) {
+	h := tmuxtest.New(t)
+	h.InstallAgent("claude", tmuxtest.FakeClaudeScript)
+	instance := h.StartInstance("files", "claude")
+	worktree, err := instance.GetGitWorktree()
+	if err != nil {
+		t.Fatalf("GetGitWorktree() error: %v", err)
+	}
+	for name, content := range map[string]string{".gitignore": "*.log\n", "new.go": "package main\n", "run.log": "log\n"} {
+		if err := os.WriteFile(worktree.GetWorktreePath()+"/"+name, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if err := instance.UpdateDiffStats(); err != nil {
+		t.Fatalf("UpdateDiffStats() error: %v", err)
+	}
+	if report := instance.GetFileReport(); report == nil || !reflect.DeepEqual(report.Ignored, []string{"run.log"}) {
+		t.Errorf("file report after UpdateDiffStats = %+v, want run.log ignored", report)
+	}
+
+	server := web.NewServer(h.Storage(), config.DefaultConfig())
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	var files handlers.InstanceFiles
+	getJSON(t, ts.URL+"/api/instances/files/files", &files)
+	if !reflect.DeepEqual(files.Untracked, []string{".gitignore", "new.go"}) {
+		t.Errorf("untracked = %v, want .gitignore and new.go", files.Untracked)
+	}
+	if !reflect.DeepEqual(files.Ignored, []string{"run.log"}) {
+		t.Errorf("ignored = %v, want run.log", files.Ignored)
+	}
+}
@@ -0,0 +1,286 @@
+package vcs
+
+import (
+	"bytes"
+	"claude-squad/command"
+	"claude-squad/config"
+	"claude-squad/session/git"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// metadataDirs are the directories of version control systems, which copies leave out: a copy is
+// a plain directory, and its changes are those of the project's files.
+var metadataDirs = map[string]bool{".git": true, ".jj": true, ".sl": true, ".hg": true, ".svn": true}
+
+// DirWorkspace is a Workspace that is a copy of a directory. Its changes are diffed against the
+// directory it was copied from, and can't be committed or pushed.
+type DirWorkspace struct {
+	source string
+	path   string
+}
+
+// NewDirWorkspace returns a workspace copying source into a new directory named after title
+// under the claude-squad config directory. Setup makes the copy.
+func NewDirWorkspace(source, title string) (*DirWorkspace, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	name := regexp.MustCompile(`[^a-z0-9\-_.]+`).ReplaceAllString(strings.ToLower(title), "-")
+	path := filepath.Join(configDir, "workspaces", fmt.Sprintf("%s_%x", name, time.Now().UnixNano()))
+	return NewDirWorkspaceFromStorage(source, path), nil
+}
+
+// NewDirWorkspaceFromStorage returns the workspace copying source at path, as saved.
+func NewDirWorkspaceFromStorage(source, path string) *DirWorkspace {
+	return &DirWorkspace{source: source, path: path}
+}
+
+// Source returns the directory the workspace is a copy of.
+func (w *DirWorkspace) Source() string {
+	return w.source
+}
+
+// Path returns the directory of the copy.
+func (w *DirWorkspace) Path() string {
+	return w.path
+}
+
+// Setup copies the files of the source directory, with their modes and modification times,
+// leaving out version control metadata. Symlinks are copied as links.
+func (w *DirWorkspace) Setup() error {
+	if rel, err := filepath.Rel(w.source, w.path); err == nil && !strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("can't copy %s into %s, inside of it", w.source, w.path)
+	}
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return fmt.Errorf("failed to create workspaces directory: %w", err)
+	}
+	err := filepath.WalkDir(w.source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(w.source, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(w.path, rel)
+		if d.IsDir() {
+			if metadataDirs[d.Name()] && rel != "." {
+				return filepath.SkipDir
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		}
+		return copyFile(path, target, d)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %w", w.source, err)
+	}
+	return nil
+}
+
+// copyFile copies the regular file or symlink at path to target. Other files, such as sockets,
+// are left out.
+func copyFile(path, target string, d fs.DirEntry) error {
+	if d.Type()&fs.ModeSymlink != 0 {
+		link, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, target)
+	}
+	if !d.Type().IsRegular() {
+		return nil
+	}
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// Unchanged files keep the time of the source, so diffs can skip them without reading them
+	return os.Chtimes(target, info.ModTime(), info.ModTime())
+}
+
+// Diff returns the differences between the source directory and the copy as a git diff, with
+// paths relative to them.
+func (w *DirWorkspace) Diff() *git.DiffStats {
+	stats := &git.DiffStats{Base: "directory " + w.source}
+	sourceFiles, err := listFiles(w.source)
+	if err != nil {
+		stats.Error = err
+		return stats
+	}
+	copyFiles, err := listFiles(w.path)
+	if err != nil {
+		stats.Error = err
+		return stats
+	}
+	names := make([]string, 0, len(copyFiles))
+	for name := range copyFiles {
+		names = append(names, name)
+	}
+	for name := range sourceFiles {
+		if _, ok := copyFiles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var content strings.Builder
+	for _, name := range names {
+		before, inSource := sourceFiles[name]
+		after, inCopy := copyFiles[name]
+		if inSource && inCopy && sameFile(before, after, filepath.Join(w.source, name), filepath.Join(w.path, name)) {
+			continue
+		}
+		old, new := os.DevNull, os.DevNull
+		if inSource {
+			old = filepath.Join(w.source, name)
+		}
+		if inCopy {
+			new = filepath.Join(w.path, name)
+		}
+		diff, err := fileDiff(old, new, name)
+		if err != nil {
+			stats.Error = err
+			return stats
+		}
+		content.WriteString(diff)
+	}
+
+	stats.Content = content.String()
+	for _, line := range strings.Split(stats.Content, "\n") {
+		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+			stats.Added++
+		} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
+			stats.Removed++
+		}
+	}
+	return stats
+}
+
+// listFiles returns the regular files and symlinks under dir by their slash-separated path
+// relative to it, leaving out version control metadata.
+func listFiles(dir string) (map[string]fs.FileInfo, error) {
+	files := make(map[string]fs.FileInfo)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if metadataDirs[d.Name()] && path != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() && d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = info
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the files of %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// sameFile reports whether the files at a and b, described by infoA and infoB, have the same
+// content, taking files of the same size and modification time as unchanged.
+func sameFile(infoA, infoB fs.FileInfo, a, b string) bool {
+	if infoA.Mode() != infoB.Mode() || infoA.Size() != infoB.Size() {
+		return false
+	}
+	if infoA.ModTime().Equal(infoB.ModTime()) {
+		return true
+	}
+	if infoA.Mode()&fs.ModeSymlink != 0 {
+		linkA, errA := os.Readlink(a)
+		linkB, errB := os.Readlink(b)
+		return errA == nil && errB == nil && linkA == linkB
+	}
+	contentA, errA := os.ReadFile(a)
+	contentB, errB := os.ReadFile(b)
+	return errA == nil && errB == nil && bytes.Equal(contentA, contentB)
+}
+
+// fileDiff returns the git diff of the file name from old to new, either of which may be
+// os.DevNull, with the paths in its headers replaced by name.
+func fileDiff(old, new, name string) (string, error) {
+	cmd := command.New(command.GitTimeout(), "git", "diff", "--no-index", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", "--", old, new)
+	out, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		// Exit status 1 means the files differ
+		err = nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to diff %s: %w", name, err)
+	}
+	replacer := strings.NewReplacer(
+		"a/"+strings.TrimPrefix(filepath.ToSlash(old), "/"), "a/"+name,
+		"b/"+strings.TrimPrefix(filepath.ToSlash(new), "/"), "b/"+name,
+		"a/"+strings.TrimPrefix(filepath.ToSlash(new), "/"), "a/"+name,
+		"b/"+strings.TrimPrefix(filepath.ToSlash(old), "/"), "b/"+name,
+	)
+	lines := strings.SplitAfter(string(out), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "@@") {
+			break
+		}
+		lines[i] = replacer.Replace(line)
+	}
+	return strings.Join(lines, ""), nil
+}
+
+// Commit isn't supported: a copy has no history.
+func (w *DirWorkspace) Commit(message string) error {
+	return fmt.Errorf("can't commit in a copy of %s: %w", w.source, ErrUnsupported)
+}
+
+// Push isn't supported: a copy has nowhere to be pushed to.
+func (w *DirWorkspace) Push(message string, open bool) error {
+	return fmt.Errorf("can't push a copy of %s: %w", w.source, ErrUnsupported)
+}
+
+// Cleanup removes the copy. The source directory is left alone.
+func (w *DirWorkspace) Cleanup() error {
+	if err := os.RemoveAll(w.path); err != nil {
+		return fmt.Errorf("failed to remove workspace %s: %w", w.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package vcs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirWorkspace(t *testing.T) {
+	source := t.TempDir()
+	for name, content := range map[string]string{"a.txt": "a\n", "gone.txt": "g\n", "sub/b.txt": "b\n", ".git/HEAD": "ref\n"} {
+		path := filepath.Join(source, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := NewDirWorkspaceFromStorage(source, filepath.Join(t.TempDir(), "copy"))
+	if err := w.Setup(); err != nil {
+		t.Fatalf("Setup(): %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(w.Path(), ".git")); !os.IsNotExist(err) {
+		t.Errorf("the copy has the .git directory: %v", err)
+	}
+	if stats := w.Diff(); stats.Error != nil || !stats.IsEmpty() {
+		t.Fatalf("Diff() of a fresh copy = %+v, want no changes", stats)
+	}
+
+	if err := os.WriteFile(filepath.Join(w.Path(), "a.txt"), []byte("a\nchanged\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(w.Path(), "sub", "new.txt"), []byte("n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(w.Path(), "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+	stats := w.Diff()
+	if stats.Error != nil {
+		t.Fatalf("Diff(): %v", stats.Error)
+	}
+	if stats.Added != 2 || stats.Removed != 1 {
+		t.Errorf("Diff() = +%d -%d, want +2 -1", stats.Added, stats.Removed)
+	}
+	for _, header := range []string{"diff --git a/a.txt b/a.txt", "+++ b/sub/new.txt", "--- a/gone.txt"} {
+		if !strings.Contains(stats.Content, header) {
+			t.Errorf("diff lacks %q:\n%s", header, stats.Content)
+		}
+	}
+	if strings.Contains(stats.Content, source) {
+		t.Errorf("diff names the directories:\n%s", stats.Content)
+	}
+
+	if err := w.Push("message", false); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Push() = %v, want ErrUnsupported", err)
+	}
+	if err := w.Cleanup(); err != nil {
+		t.Fatalf("Cleanup(): %v", err)
+	}
+	if _, err := os.Stat(w.Path()); !os.IsNotExist(err) {
+		t.Errorf("the copy still exists after Cleanup: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(source, "a.txt")); err != nil {
+		t.Errorf("Cleanup touched the source: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package vcs
+
+import "claude-squad/session/git"
+
+// GitWorkspace is a Workspace backed by a git worktree.
+type GitWorkspace struct {
+	*git.GitWorktree
+}
+
+// NewGitWorkspace returns the workspace of worktree.
+func NewGitWorkspace(worktree *git.GitWorktree) *GitWorkspace {
+	return &GitWorkspace{GitWorktree: worktree}
+}
+
+// Path returns the path of the worktree.
+func (w *GitWorkspace) Path() string {
+	return w.GetWorktreePath()
+}
+
+// Commit commits the changes of the worktree on its branch, without pushing them.
+func (w *GitWorkspace) Commit(message string) error {
+	return w.CommitChanges(message)
+}
+
+// Push commits the changes of the worktree and pushes its branch, after the push checks.
+func (w *GitWorkspace) Push(message string, open bool) error {
+	return w.PushChanges(message, open)
+}
@@ -0,0 +1,49 @@
+// Package vcs abstracts the workspaces instances work in, so they can be git worktrees or, for
+// projects git doesn't manage, plain copies of a directory. Other version control systems, such
+// as jj or Sapling, plug in by implementing Workspace.
+package vcs
+
+import (
+	"claude-squad/session/git"
+	"errors"
+	"fmt"
+)
+
+// Kinds of workspace, the values of the vcs setting.
+const (
+	// Git works in a git worktree on a branch of its own, the default.
+	Git = "git"
+	// Directory works in a copy of the directory, for projects without version control.
+	Directory = "dir"
+)
+
+// ErrUnsupported is returned by the operations a kind of workspace can't do, such as pushing a
+// directory copy.
+var ErrUnsupported = errors.New("not supported by this kind of workspace")
+
+// Workspace is where an instance's program makes its changes, away from the directory the
+// instance was created in.
+type Workspace interface {
+	// Setup creates the workspace.
+	Setup() error
+	// Path returns the directory the program runs in.
+	Path() string
+	// Diff returns the changes made in the workspace.
+	Diff() *git.DiffStats
+	// Commit records the changes in the workspace with message.
+	Commit(message string) error
+	// Push commits the changes with message and publishes them, opening them in the browser if
+	// open is set.
+	Push(message string, open bool) error
+	// Cleanup removes the workspace and whatever it was recorded in.
+	Cleanup() error
+}
+
+// Check returns an error if kind isn't a known kind of workspace. Empty means Git.
+func Check(kind string) error {
+	switch kind {
+	case "", Git, Directory:
+		return nil
+	}
+	return fmt.Errorf("unknown vcs %q, want %s or %s", kind, Git, Directory)
+}
@@ -0,0 +1,86 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session/tmux"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ShellWindowName is the name of the companion shell windows opened with OpenWindow.
+const ShellWindowName = "shell"
+
+// companionWindows holds the windows opened next to the program of every new instance.
+var companionWindows struct {
+	mu      sync.RWMutex
+	windows []config.WindowConfig
+}
+
+// SetWindows sets the companion windows opened in the tmux session of instances when they start.
+func SetWindows(windows []config.WindowConfig) {
+	companionWindows.mu.Lock()
+	defer companionWindows.mu.Unlock()
+	companionWindows.windows = append([]config.WindowConfig(nil), windows...)
+}
+
+// openConfiguredWindows opens the configured companion windows. A window failing to open doesn't
+// fail the start.
+func (i *Instance) openConfiguredWindows(workDir string) {
+	companionWindows.mu.RLock()
+	windows := companionWindows.windows
+	companionWindows.mu.RUnlock()
+	for _, window := range windows {
+		name := strings.TrimSpace(window.Name)
+		if name == "" {
+			name = ShellWindowName
+		}
+		if _, err := i.tmuxSession.NewWindow(name, workDir, window.Command); err != nil {
+			log.FileOnlyWarningLog.Printf("could not open window %s of %s: %v", name, i.Title, err)
+		}
+	}
+}
+
+// Windows returns the windows of the instance's tmux session, the program's first.
+func (i *Instance) Windows() ([]tmux.Window, error) {
+	if !i.started || i.Paused() {
+		return nil, nil
+	}
+	return i.tmuxSession.Windows()
+}
+
+// OpenWindow opens a companion window named name running command in the instance's directory,
+// or a shell if command is empty, and shows it in the preview.
+func (i *Instance) OpenWindow(name, command string) error {
+	if !i.started || i.Paused() {
+		return fmt.Errorf("cannot open a window in %s: it is not running", i.Title)
+	}
+	id, err := i.tmuxSession.NewWindow(name, i.WorkDir(), command)
+	if err != nil {
+		return err
+	}
+	return i.tmuxSession.ViewWindow(id)
+}
+
+// NextWindow shows the window after the one in the preview, going back to the program's after the
+// last. The preview and attach show the window, while prompts still go to the program.
+func (i *Instance) NextWindow() (tmux.Window, error) {
+	windows, err := i.Windows()
+	if err != nil {
+		return tmux.Window{}, err
+	}
+	if len(windows) == 0 {
+		return tmux.Window{}, fmt.Errorf("%s has no windows", i.Title)
+	}
+	next := windows[0]
+	for n, window := range windows {
+		if window.Viewed && n+1 < len(windows) {
+			next = windows[n+1]
+		}
+	}
+	if err := i.tmuxSession.ViewWindow(next.ID); err != nil {
+		return tmux.Window{}, err
+	}
+	return next, nil
+}
@@ -0,0 +1,214 @@
+// Package ticket comments on the ticket of an instance when its branch is pushed: on GitHub issues
+// with the gh CLI, on Jira issues through the Jira REST API.
+package ticket
+
+import (
+	"bytes"
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/network"
+	"claude-squad/session"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	queueSize      = 64
+	maxAttempts    = 3
+	requestTimeout = 30 * time.Second
+)
+
+// jiraTokenEnv is the environment variable holding the Jira API token if the config has none.
+const jiraTokenEnv = "JIRA_API_TOKEN"
+
+// Commenter posts a comment on the ticket of every pushed instance from a background goroutine so
+// the code emitting events never waits on the network.
+type Commenter struct {
+	cfg    config.TicketConfig
+	client *http.Client
+	queue  chan session.Event
+	done   chan struct{}
+	wg     sync.WaitGroup
+	// github posts comment on a GitHub issue. It runs gh unless replaced by tests.
+	github func(ctx context.Context, ticket session.Ticket, comment string) error
+}
+
+// Start comments on tickets until the returned function is called. It does nothing unless
+// commenting on push is enabled.
+func Start(cfg config.TicketConfig) func() {
+	if !cfg.CommentOnPush {
+		return func() {}
+	}
+	return NewCommenter(cfg).Start()
+}
+
+// NewCommenter creates a commenter for cfg. Call Start to begin commenting.
+func NewCommenter(cfg config.TicketConfig) *Commenter {
+	return &Commenter{
+		cfg:    cfg,
+		client: &http.Client{Timeout: requestTimeout},
+		queue:  make(chan session.Event, queueSize),
+		done:   make(chan struct{}),
+		github: ghComment,
+	}
+}
+
+// Start subscribes to instance events and starts commenting. It returns a function that
+// unsubscribes, posts the comments already queued and stops the commenter.
+func (c *Commenter) Start() func() {
+	c.wg.Add(1)
+	go c.run()
+	unsubscribe := session.Subscribe(c.Handle)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			unsubscribe()
+			close(c.done)
+			c.wg.Wait()
+		})
+	}
+}
+
+// Handle queues a comment for branch pushes of instances with a ticket. Comments are dropped if the
+// queue is full.
+func (c *Commenter) Handle(event session.Event) {
+	if event.Type != session.EventBranchPushed || event.Ticket == "" {
+		return
+	}
+	select {
+	case c.queue <- event:
+	default:
+		log.FileOnlyWarningLog.Printf("ticket: queue full, dropping comment on %s", event.Ticket)
+	}
+}
+
+func (c *Commenter) run() {
+	defer c.wg.Done()
+	for {
+		select {
+		case event := <-c.queue:
+			c.comment(event)
+		case <-c.done:
+			for {
+				select {
+				case event := <-c.queue:
+					c.comment(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// comment posts the comment of event, once the network is up. A comment that fails to reach the
+// tracker is posted again when the network is back, up to maxAttempts times.
+func (c *Commenter) comment(event session.Event) {
+	for attempt := 1; network.WaitOnline(c.done); attempt++ {
+		err := c.post(event)
+		if err == nil {
+			return
+		}
+		if !network.IsNetworkError(err) || attempt == maxAttempts {
+			log.FileOnlyErrorLog.Printf("ticket: %v", err)
+			return
+		}
+		network.SetOffline(true)
+	}
+	log.FileOnlyWarningLog.Printf("ticket: offline on shutdown, dropping comment on %s", event.Ticket)
+}
+
+// post comments on the ticket of event.
+func (c *Commenter) post(event session.Event) error {
+	ticket, err := session.ParseTicket(event.Ticket)
+	if err != nil {
+		return err
+	}
+	comment := Comment(event)
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	switch ticket.Kind {
+	case session.TicketGitHub:
+		if err := c.github(ctx, ticket, comment); err != nil {
+			return fmt.Errorf("failed to comment on %s: %w", ticket.Ref, err)
+		}
+	case session.TicketJira:
+		if err := c.jiraComment(ctx, ticket, comment); err != nil {
+			return fmt.Errorf("failed to comment on %s: %w", ticket.Ref, err)
+		}
+	default:
+		log.FileOnlyInfoLog.Printf("ticket: no tracker known for %s, not commenting", ticket.Ref)
+	}
+	return nil
+}
+
+// Comment returns the comment posted when the branch of the instance of event is pushed.
+func Comment(event session.Event) string {
+	return fmt.Sprintf("Branch `%s` was pushed from claude-squad instance '%s' (%s).",
+		event.Branch, event.Instance, event.Program)
+}
+
+// ghComment comments on a GitHub issue with gh. Issues without a repository are looked up in the
+// repository of the working directory.
+func ghComment(ctx context.Context, ticket session.Ticket, comment string) error {
+	issue := strings.TrimPrefix(ticket.Key, "#")
+	args := []string{"issue", "comment", issue, "--body", comment}
+	if ticket.Repo != "" {
+		args = append(args, "--repo", ticket.Repo)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gh", args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("gh issue comment: %s (%w)", strings.TrimSpace(stderr.String()), err)
+	}
+	return nil
+}
+
+// jiraComment comments on a Jira issue through the REST API, authenticated with the configured
+// email and API token.
+func (c *Commenter) jiraComment(ctx context.Context, ticket session.Ticket, comment string) error {
+	base := ticket.JiraBaseURL()
+	if base == "" {
+		return fmt.Errorf("no Jira site configured for %s, set tickets.jira_url", ticket.Key)
+	}
+	token := c.cfg.JiraToken
+	if token == "" {
+		token = os.Getenv(jiraTokenEnv)
+	}
+	if c.cfg.JiraEmail == "" || token == "" {
+		return fmt.Errorf("set tickets.jira_email and tickets.jira_token or %s to comment on Jira issues", jiraTokenEnv)
+	}
+
+	body, err := json.Marshal(map[string]string{"body": comment})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		base+"/rest/api/2/issue/"+ticket.Key+"/comment", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.cfg.JiraEmail, token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "claude-squad")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("jira returned %s", resp.Status)
+	}
+	return nil
+}
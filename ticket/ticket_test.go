@@ -0,0 +1,74 @@
+package ticket
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCommenterPostsToJira(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	var gotPath, gotUser, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotUser, _, _ = r.BasicAuth()
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		gotBody = body["body"]
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	c := NewCommenter(config.TicketConfig{CommentOnPush: true, JiraEmail: "dev@example.com", JiraToken: "secret"})
+	event := session.Event{
+		Type:     session.EventBranchPushed,
+		Instance: "login",
+		Program:  "claude",
+		Branch:   "session/abc-12-login",
+		Ticket:   srv.URL + "/browse/ABC-12",
+	}
+	if err := c.post(event); err != nil {
+		t.Fatalf("post() error: %v", err)
+	}
+	if gotPath != "/rest/api/2/issue/ABC-12/comment" || gotUser != "dev@example.com" {
+		t.Errorf("request to %s as %q, want the comment endpoint of ABC-12 as dev@example.com", gotPath, gotUser)
+	}
+	if gotBody != Comment(event) {
+		t.Errorf("comment = %q, want %q", gotBody, Comment(event))
+	}
+}
+
+func TestCommenterPostsToGitHub(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	c := NewCommenter(config.TicketConfig{CommentOnPush: true})
+	var got session.Ticket
+	c.github = func(ctx context.Context, ticket session.Ticket, comment string) error {
+		got = ticket
+		return nil
+	}
+	if err := c.post(session.Event{Type: session.EventBranchPushed, Ticket: "https://github.com/acme/app/issues/42"}); err != nil {
+		t.Fatalf("post() error: %v", err)
+	}
+	if got.Repo != "acme/app" || got.Key != "#42" {
+		t.Errorf("commented on %+v, want acme/app#42", got)
+	}
+}
+
+func TestCommenterOnlyQueuesPushesWithTickets(t *testing.T) {
+	c := NewCommenter(config.TicketConfig{CommentOnPush: true})
+	c.Handle(session.Event{Type: session.EventBranchPushed})
+	c.Handle(session.Event{Type: session.EventPaused, Ticket: "ABC-1"})
+	c.Handle(session.Event{Type: session.EventBranchPushed, Ticket: "ABC-1"})
+	if len(c.queue) != 1 {
+		t.Errorf("queued %d comments, want 1", len(c.queue))
+	}
+}
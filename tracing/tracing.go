@@ -0,0 +1,109 @@
+// Package tracing sets up OpenTelemetry tracing for instance operations and the web server.
+// Until Init is called with tracing enabled, spans go to the global no-op provider and cost
+// nothing.
+package tracing
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName is reported as the service.name resource attribute.
+const ServiceName = "claude-squad"
+
+// shutdownTimeout bounds how long Shutdown waits to flush pending spans.
+const shutdownTimeout = 5 * time.Second
+
+// Init installs an OTLP/HTTP exporter as the global tracer provider if tracing is enabled in cfg.
+// The returned function flushes and stops the exporter and must be called before exit. When
+// tracing is disabled it does nothing.
+func Init(ctx context.Context, cfg *config.Config, version string) (func(), error) {
+	if !cfg.TracingEnabled {
+		return func() {}, nil
+	}
+
+	// Without an endpoint the exporter falls back to the standard OTEL_EXPORTER_OTLP_* variables
+	var opts []otlptracehttp.Option
+	if cfg.TracingEndpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(cfg.TracingEndpoint))
+	}
+	if cfg.TracingInsecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(
+			semconv.ServiceName(ServiceName),
+			semconv.ServiceVersion(version),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	log.FileOnlyInfoLog.Printf("tracing enabled, exporting to %s", endpointForLog(cfg.TracingEndpoint))
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			log.ErrorLog.Printf("failed to shut down tracing: %v", err)
+		}
+	}, nil
+}
+
+func endpointForLog(endpoint string) string {
+	if endpoint == "" {
+		return "the endpoint from the OTEL_EXPORTER_OTLP environment"
+	}
+	return endpoint
+}
+
+// Start starts a span from the global tracer provider.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(ServiceName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on span, if any, and ends it. It returns err so it can wrap a return statement.
+func End(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	return err
+}
+
+// Instance returns the attributes that identify an instance on a span.
+func Instance(title, program string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("instance.title", title),
+		attribute.String("instance.program", program),
+	}
+}
@@ -0,0 +1,89 @@
+// Package tray renders the squad summary as a menu bar plugin: xbar and SwiftBar on macOS, and
+// Argos on GNOME, run a program every few seconds and show the first line it prints in the menu
+// bar, and the lines after "---" in the menu under it. Items take parameters after a "|", such
+// as href=URL to open a URL when clicked.
+package tray
+
+import (
+	"claude-squad/session"
+	"claude-squad/web/handlers"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// alertColor colors the menu bar title while instances wait for a prompt.
+const alertColor = "#e5a50a"
+
+// Actions are what the menu items do when clicked.
+type Actions struct {
+	// WebURL is the URL of the web UI, ending with a slash, or "" when it can't be opened in a
+	// browser, as when the server only listens on a unix socket.
+	WebURL string
+	// Executable is the claude-squad program, run in a terminal to open the TUI.
+	Executable string
+}
+
+// Menu renders summary as the output of a menu bar plugin: the counts of Summary.Compact in the
+// menu bar, highlighted while instances wait for a prompt, and the details in the menu. Clicking
+// an instance waiting for a prompt opens its terminal in the web UI.
+func Menu(summary handlers.Summary, actions Actions) string {
+	var b strings.Builder
+	title := summary.Compact()
+	if len(summary.AwaitingPrompt) > 0 {
+		title += " | color=" + alertColor
+	}
+	b.WriteString(title + "\n---\n")
+
+	fmt.Fprintf(&b, "%d instances: %d running, %d ready, %d loading, %d paused, %d exited\n", summary.Instances,
+		summary.ByStatus[session.Running.String()], summary.ByStatus[session.Ready.String()],
+		summary.ByStatus[session.Loading.String()], summary.ByStatus[session.Paused.String()],
+		summary.ByStatus[session.Exited.String()])
+	fmt.Fprintf(&b, "Diff: +%d -%d\n", summary.Added, summary.Removed)
+	if summary.Daemon.Running {
+		fmt.Fprintf(&b, "Daemon: running (PID %d)\n", summary.Daemon.PID)
+	} else {
+		b.WriteString("Daemon: not running\n")
+	}
+
+	if len(summary.AwaitingPrompt) > 0 {
+		b.WriteString("---\nAwaiting a prompt\n")
+		for _, title := range summary.AwaitingPrompt {
+			line := item(title)
+			if actions.WebURL != "" {
+				line += " | href=" + actions.WebURL + "terminal/" + url.PathEscape(title)
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	writeActions(&b, actions)
+	return b.String()
+}
+
+// Unreachable renders the menu shown when the web server can't be reached, as when claude-squad
+// runs without --web.
+func Unreachable(err error, actions Actions) string {
+	var b strings.Builder
+	b.WriteString("CS: ? | color=gray\n---\n")
+	b.WriteString("Web server not reachable\n")
+	b.WriteString(item(err.Error()) + " | size=11\n")
+	writeActions(&b, actions)
+	return b.String()
+}
+
+func writeActions(b *strings.Builder, actions Actions) {
+	b.WriteString("---\n")
+	if actions.WebURL != "" {
+		b.WriteString("Open web UI | href=" + actions.WebURL + "\n")
+	}
+	if actions.Executable != "" {
+		b.WriteString("Open claude-squad | bash=" + strconv.Quote(actions.Executable) + " terminal=true\n")
+	}
+	b.WriteString("Refresh | refresh=true\n")
+}
+
+// item makes text fit on one menu item: line breaks end the item and "|" starts its parameters.
+func item(text string) string {
+	return strings.NewReplacer("\n", " ", "\r", " ", "|", "/").Replace(text)
+}
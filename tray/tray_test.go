@@ -0,0 +1,53 @@
+package tray
+
+import (
+	"claude-squad/session"
+	"claude-squad/web/handlers"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMenu(t *testing.T) {
+	summary := handlers.Summary{
+		Summary: session.Summary{
+			Instances:      3,
+			ByStatus:       map[string]int{"running": 2, "paused": 1},
+			Added:          12,
+			Removed:        4,
+			AwaitingPrompt: []string{"api fix"},
+		},
+		Daemon: handlers.DaemonStatus{Running: true, PID: 42},
+	}
+	menu := Menu(summary, Actions{WebURL: "http://127.0.0.1:8080/", Executable: "/usr/local/bin/cs"})
+	lines := strings.Split(menu, "\n")
+
+	if lines[0] != "CS: 2▶ 1❓ 1⏸ | color="+alertColor {
+		t.Errorf("title = %q", lines[0])
+	}
+	for _, want := range []string{
+		"3 instances: 2 running, 0 ready, 0 loading, 1 paused, 0 exited",
+		"Diff: +12 -4",
+		"Daemon: running (PID 42)",
+		"api fix | href=http://127.0.0.1:8080/terminal/api%20fix",
+		"Open web UI | href=http://127.0.0.1:8080/",
+		`Open claude-squad | bash="/usr/local/bin/cs" terminal=true`,
+	} {
+		if !strings.Contains(menu, want+"\n") {
+			t.Errorf("menu has no line %q:\n%s", want, menu)
+		}
+	}
+
+	// Without a web UI to open, nothing links to it
+	menu = Menu(handlers.Summary{Summary: session.Summary{AwaitingPrompt: []string{"a|b"}}}, Actions{})
+	if strings.Contains(menu, "href=") || !strings.Contains(menu, "\na/b\n") {
+		t.Errorf("menu without web UI:\n%s", menu)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	menu := Unreachable(errors.New("dial unix: no such file\nor directory"), Actions{})
+	if !strings.HasPrefix(menu, "CS: ? | color=gray\n---\n") || !strings.Contains(menu, "dial unix: no such file or directory | size=11\n") {
+		t.Errorf("menu:\n%s", menu)
+	}
+}
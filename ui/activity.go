@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"claude-squad/session"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	activityTitleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7D56F4"))
+	activityTimeStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	activityInstanceStyle = lipgloss.NewStyle().Bold(true)
+	activityPromptStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#f59e0b"))
+	activityPushStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#22c55e"))
+	activityBorderStyle   = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderTop(true).
+				BorderForeground(lipgloss.AdaptiveColor{Light: "#B38EF3", Dark: "#7D56F4"})
+)
+
+// ActivityFeed is a drawer below the list and preview showing the latest events of every instance:
+// status changes, prompts, pushes and errors.
+type ActivityFeed struct {
+	visible       bool
+	width, height int
+}
+
+func NewActivityFeed() *ActivityFeed {
+	return &ActivityFeed{}
+}
+
+// Toggle opens or closes the drawer.
+func (a *ActivityFeed) Toggle() {
+	a.visible = !a.visible
+}
+
+func (a *ActivityFeed) Visible() bool {
+	return a.visible
+}
+
+// SetSize sets the size of the drawer, including its border and title.
+func (a *ActivityFeed) SetSize(width, height int) {
+	a.width = width
+	a.height = height
+}
+
+func (a *ActivityFeed) String() string {
+	if !a.visible {
+		return ""
+	}
+	// The border and the title take two lines
+	rows := max(a.height-2, 1)
+	events := session.RecentEvents()
+	line := lipgloss.NewStyle().MaxWidth(a.width)
+	lines := make([]string, 0, rows)
+	for _, e := range events[max(len(events)-rows, 0):] {
+		lines = append(lines, line.Render(activityTimeStyle.Render(e.Time.Local().Format("15:04:05"))+" "+
+			activityInstanceStyle.Foreground(lipgloss.Color(e.Color)).Render(e.Instance)+" "+describeEvent(e)))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, activityTimeStyle.Render("Nothing has happened yet"))
+	}
+
+	title := activityTitleStyle.Render("Activity") + activityTimeStyle.Render(fmt.Sprintf(" · %d events", len(events)))
+	content := lipgloss.JoinVertical(lipgloss.Left, append([]string{title}, lines...)...)
+	return activityBorderStyle.Width(a.width).Height(a.height - 1).Render(content)
+}
+
+// describeEvent returns what happened in e, styled by how much it needs attention.
+func describeEvent(e session.Event) string {
+	switch e.Type {
+	case session.EventCreated:
+		return descStyle.Render("started " + e.Program)
+	case session.EventStatusChanged:
+		return descStyle.Render(e.PreviousStatus + " → " + e.Status)
+	case session.EventPromptDetected:
+		return activityPromptStyle.Render("is waiting on a prompt")
+	case session.EventBranchPushed:
+		return activityPushStyle.Render("pushed " + e.Branch)
+	case session.EventPaused:
+		return descStyle.Render("paused")
+	case session.EventKilled:
+		return descStyle.Render("killed")
+	case session.EventNotesChanged:
+		return descStyle.Render("notes changed")
+	case session.EventTicketChanged:
+		if e.Ticket == "" {
+			return descStyle.Render("ticket cleared")
+		}
+		return descStyle.Render("ticket set to " + e.Ticket)
+	case session.EventTagsChanged:
+		if len(e.Tags) == 0 {
+			return descStyle.Render("tags cleared")
+		}
+		return descStyle.Render("tagged " + strings.Join(e.Tags, ", "))
+	case session.EventColorChanged:
+		return descStyle.Render("color changed")
+	case session.EventCommandFinished:
+		if e.Error != "" {
+			return errStyle.Render("ran " + e.Command + ": " + e.Error)
+		}
+		return activityPushStyle.Render("ran " + e.Command)
+	case session.EventRestarted:
+		return activityPromptStyle.Render(fmt.Sprintf("restarted %s (attempt %d)", e.Program, e.Attempt))
+	case session.EventPromptSent:
+		return descStyle.Render("was sent: " + strings.ReplaceAll(e.Prompt, "\n", " "))
+	case session.EventAutoYesExpired:
+		return activityPromptStyle.Render("auto-yes expired, prompts need approval again")
+	case session.EventError:
+		return errStyle.Render("error: " + strings.ReplaceAll(e.Error, "\n", " "))
+	}
+	return descStyle.Render(string(e.Type))
+}
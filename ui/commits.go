@@ -0,0 +1,215 @@
+package ui
+
+import (
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// maxListedCommits is the number of commits listed in the commits tab.
+	maxListedCommits = 200
+	// commitsRefresh is how often the commits tab lists the commits of the shown instance again.
+	commitsRefresh = 2 * time.Second
+)
+
+var (
+	commitHashStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#f59e0b"))
+	commitTimeStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	selectedCommitStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color("#dde4f0")).
+				Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#1a1a1a"})
+)
+
+// CommitsPane lists the commits made on the branch of an instance since its worktree was created,
+// and shows the patch of the selected one.
+type CommitsPane struct {
+	viewport viewport.Model
+	width    int
+	height   int
+
+	// title is the instance whose commits are listed
+	title    string
+	worktree *git.GitWorktree
+	commits  []git.Commit
+	loadedAt time.Time
+	selected int
+	// patch is the patch of the selected commit while it is shown instead of the list
+	patch string
+}
+
+// NewCommitsPane creates an empty commits pane.
+func NewCommitsPane() *CommitsPane {
+	return &CommitsPane{viewport: viewport.New(0, 0)}
+}
+
+func (c *CommitsPane) SetSize(width, height int) {
+	c.width = width
+	c.height = height
+	c.viewport.Width = width
+	c.viewport.Height = height
+	c.render()
+}
+
+// SetCommits lists the commits of instance, read again every commitsRefresh or when another
+// instance is selected. A shown patch stays until it is closed.
+func (c *CommitsPane) SetCommits(instance *session.Instance) {
+	if instance == nil || !instance.Started() || instance.InPlace {
+		c.reset("")
+		c.message("No commits")
+		return
+	}
+	if instance.Title != c.title {
+		c.reset(instance.Title)
+	} else if c.patch != "" || time.Since(c.loadedAt) < commitsRefresh {
+		return
+	}
+
+	worktree, err := instance.GetGitWorktree()
+	if err != nil {
+		c.message(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	commits, err := worktree.Commits(maxListedCommits)
+	if err != nil {
+		c.message(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	c.worktree = worktree
+	c.loadedAt = time.Now()
+	// Keep the same commit selected as new ones are added on top
+	if c.selected < len(c.commits) {
+		sha := c.commits[c.selected].SHA
+		c.selected = 0
+		for i, commit := range commits {
+			if commit.SHA == sha {
+				c.selected = i
+			}
+		}
+	}
+	c.commits = commits
+	c.render()
+}
+
+// reset forgets the commits listed for another instance.
+func (c *CommitsPane) reset(title string) {
+	c.title = title
+	c.worktree = nil
+	c.commits = nil
+	c.loadedAt = time.Time{}
+	c.selected = 0
+	c.patch = ""
+	c.viewport.GotoTop()
+}
+
+func (c *CommitsPane) message(text string) {
+	c.viewport.SetContent(lipgloss.Place(c.width, c.height, lipgloss.Center, lipgloss.Center, text))
+}
+
+// render shows the patch if one is open, the list of commits otherwise.
+func (c *CommitsPane) render() {
+	if c.patch != "" {
+		c.viewport.SetContent(colorizeDiff(c.patch))
+		return
+	}
+	if c.title == "" {
+		return
+	}
+	if len(c.commits) == 0 {
+		c.message("No commits on this branch yet")
+		return
+	}
+
+	lines := []string{commitTimeStyle.Render(fmt.Sprintf("%d commits since the worktree was created", len(c.commits))), ""}
+	for i, commit := range c.commits {
+		stats := fmt.Sprintf("+%d -%d", commit.Insertions, commit.Deletions)
+		when := commit.Time.Format("Jan 2 15:04")
+		subject := truncateLine(commit.Subject, max(c.width-len(stats)-len(when)-12, 16))
+		if i == c.selected {
+			lines = append(lines, selectedCommitStyle.Render(fmt.Sprintf("%s %s %s %s",
+				commit.SHA[:7], subject, stats, when)))
+			continue
+		}
+		lines = append(lines, commitHashStyle.Render(commit.SHA[:7])+" "+subject+" "+
+			AdditionStyle.Render(fmt.Sprintf("+%d", commit.Insertions))+" "+
+			DeletionStyle.Render(fmt.Sprintf("-%d", commit.Deletions))+" "+commitTimeStyle.Render(when))
+	}
+	c.viewport.SetContent(strings.Join(lines, "\n"))
+
+	// Keep the selected commit in view, below the two header lines
+	line := c.selected + 2
+	if line < c.viewport.YOffset {
+		c.viewport.SetYOffset(line)
+	} else if line >= c.viewport.YOffset+c.viewport.Height {
+		c.viewport.SetYOffset(line - c.viewport.Height + 1)
+	}
+}
+
+// TogglePatch shows the patch of the selected commit, or goes back to the list if it is shown.
+func (c *CommitsPane) TogglePatch() error {
+	if c.patch != "" {
+		c.patch = ""
+		c.loadedAt = time.Time{}
+		c.render()
+		return nil
+	}
+	if c.worktree == nil || c.selected >= len(c.commits) {
+		return nil
+	}
+	patch, err := c.worktree.CommitPatch(c.commits[c.selected].SHA)
+	if err != nil {
+		return err
+	}
+	c.patch = patch
+	c.render()
+	c.viewport.GotoTop()
+	return nil
+}
+
+// SelectedCommit returns the commit selected in the list.
+func (c *CommitsPane) SelectedCommit() (git.Commit, bool) {
+	if c.selected >= len(c.commits) {
+		return git.Commit{}, false
+	}
+	return c.commits[c.selected], true
+}
+
+// Reload closes the patch and lists the commits again on the next SetCommits, as after the branch
+// was rewritten.
+func (c *CommitsPane) Reload() {
+	c.patch = ""
+	c.loadedAt = time.Time{}
+}
+
+// ScrollUp selects the previous commit, or scrolls the patch up if it is shown.
+func (c *CommitsPane) ScrollUp() {
+	if c.patch != "" {
+		c.viewport.LineUp(1)
+		return
+	}
+	if c.selected > 0 {
+		c.selected--
+		c.render()
+	}
+}
+
+// ScrollDown selects the next commit, or scrolls the patch down if it is shown.
+func (c *CommitsPane) ScrollDown() {
+	if c.patch != "" {
+		c.viewport.LineDown(1)
+		return
+	}
+	if c.selected < len(c.commits)-1 {
+		c.selected++
+		c.render()
+	}
+}
+
+func (c *CommitsPane) String() string {
+	return c.viewport.View()
+}
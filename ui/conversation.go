@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"claude-squad/conversation"
+	"claude-squad/session"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxToolResultLines is the number of lines of a tool result shown under the call.
+const maxToolResultLines = 3
+
+var (
+	userTurnStyle      = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#0ea5e9"))
+	assistantTurnStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7D56F4"))
+	toolCallStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("#f59e0b"))
+	toolResultStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// ConversationPane shows the Claude Code conversation of an instance, parsed from the session file
+// Claude Code writes for the instance's directory.
+type ConversationPane struct {
+	viewport viewport.Model
+	enabled  bool
+	width    int
+	height   int
+	// content is the rendered conversation, kept to render it again on resize
+	content string
+}
+
+// NewConversationPane creates a conversation pane. A disabled pane doesn't read session files.
+func NewConversationPane(enabled bool) *ConversationPane {
+	return &ConversationPane{
+		viewport: viewport.New(0, 0),
+		enabled:  enabled,
+	}
+}
+
+func (c *ConversationPane) SetSize(width, height int) {
+	c.width = width
+	c.height = height
+	c.viewport.Width = width
+	c.viewport.Height = height
+	if c.content != "" {
+		c.viewport.SetContent(c.content)
+	}
+}
+
+// SetConversation loads and renders the conversation of instance. The view follows new turns
+// unless it was scrolled up.
+func (c *ConversationPane) SetConversation(instance *session.Instance) {
+	message := func(text string) {
+		c.content = ""
+		c.viewport.SetContent(lipgloss.Place(c.width, c.height, lipgloss.Center, lipgloss.Center, text))
+	}
+	if !c.enabled {
+		message("The conversation view is disabled (disable_conversations)")
+		return
+	}
+	if instance == nil || instance.WorkDir() == "" {
+		message("No conversation")
+		return
+	}
+
+	conv, err := conversation.Load(instance.WorkDir())
+	if errors.Is(err, conversation.ErrNoConversation) {
+		message("No Claude Code conversation found for this session")
+		return
+	}
+	if err != nil {
+		message(fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	following := c.content == "" || c.viewport.AtBottom()
+	c.content = renderConversation(conv, c.width)
+	c.viewport.SetContent(c.content)
+	if following {
+		c.viewport.GotoBottom()
+	}
+}
+
+// renderConversation renders the turns of conv wrapped to width.
+func renderConversation(conv *conversation.Conversation, width int) string {
+	wrap := lipgloss.NewStyle().Width(max(width, 20))
+	var lines []string
+	header := fmt.Sprintf("%d turns, %d input and %d output tokens", len(conv.Turns), conv.Usage.InputTokens, conv.Usage.OutputTokens)
+	if conv.Model != "" {
+		header = conv.Model + " · " + header
+	}
+	if conv.Summary != "" {
+		header = conv.Summary + "\n" + header
+	}
+	lines = append(lines, toolResultStyle.Render(header), "")
+
+	for _, turn := range conv.Turns {
+		name, style := "Claude", assistantTurnStyle
+		if turn.Role == "user" {
+			name, style = "You", userTurnStyle
+		}
+		lines = append(lines, style.Render(name)+toolResultStyle.Render(" "+turn.Time.Local().Format("15:04:05")))
+		if turn.Text != "" {
+			lines = append(lines, wrap.Render(turn.Text))
+		}
+		for _, call := range turn.ToolCalls {
+			mark := "…"
+			if call.Done && call.IsError {
+				mark = "✗"
+			} else if call.Done {
+				mark = "✓"
+			}
+			lines = append(lines, toolCallStyle.Render(mark+" "+call.Name+" ")+
+				truncateLine(call.Input, max(width-len(call.Name)-3, 16)))
+			result := strings.Split(strings.TrimSpace(call.Result), "\n")
+			for i, line := range result {
+				if line == "" {
+					continue
+				}
+				if i == maxToolResultLines {
+					lines = append(lines, toolResultStyle.Render(fmt.Sprintf("    ... %d more lines", len(result)-i)))
+					break
+				}
+				lines = append(lines, toolResultStyle.Render("    "+truncateLine(line, max(width-4, 16))))
+			}
+		}
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// truncateLine cuts line to width runes.
+func truncateLine(line string, width int) string {
+	runes := []rune(line)
+	if len(runes) <= width {
+		return line
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func (c *ConversationPane) String() string {
+	return c.viewport.View()
+}
+
+// ScrollUp scrolls the viewport up
+func (c *ConversationPane) ScrollUp() {
+	c.viewport.LineUp(1)
+}
+
+// ScrollDown scrolls the viewport down
+func (c *ConversationPane) ScrollDown() {
+	c.viewport.LineDown(1)
+}
@@ -1,9 +1,10 @@
 package ui
 
 import (
+	"claude-squad/command"
 	"claude-squad/session"
+	"claude-squad/session/git"
 	"fmt"
-	"os/exec"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/viewport"
@@ -14,6 +15,7 @@ var (
 	AdditionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#22c55e"))
 	DeletionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ef4444"))
 	HunkStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#0ea5e9"))
+	FileFlagStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#f59e0b"))
 )
 
 type DiffPane struct {
@@ -73,7 +75,7 @@ func (d *DiffPane) SetDiff(instance *session.Instance) {
 			
 		// Get git status for current directory
 		gitStatus := "Git status unavailable"
-		gitCmd := exec.Command("git", "status", "-s")
+		gitCmd := command.New(command.GitTimeout(), "git", "status", "-s")
 		gitCmd.Dir = instance.Path
 		gitStatusOutput, err := gitCmd.Output()
 		if err == nil {
@@ -84,7 +86,7 @@ func (d *DiffPane) SetDiff(instance *session.Instance) {
 			}
 			
 			// Also get branch info
-			gitBranchCmd := exec.Command("git", "branch", "--show-current")
+			gitBranchCmd := command.New(command.GitTimeout(), "git", "branch", "--show-current")
 			gitBranchCmd.Dir = instance.Path
 			gitBranchOutput, err := gitBranchCmd.Output()
 			if err == nil {
@@ -156,19 +158,64 @@ func (d *DiffPane) SetDiff(instance *session.Instance) {
 		return
 	}
 
-	if stats.IsEmpty() {
+	report := instance.GetFileReport()
+	if stats.IsEmpty() && (report == nil || report.IsEmpty()) {
 		d.stats = ""
 		d.diff = ""
+		if stats.Base != "" {
+			centeredFallbackMessage = lipgloss.Place(d.width, d.height, lipgloss.Center, lipgloss.Center,
+				"No changes since "+stats.Base)
+		}
 		d.viewport.SetContent(centeredFallbackMessage)
 	} else {
 		additions := AdditionStyle.Render(fmt.Sprintf("%d additions(+)", stats.Added))
 		deletions := DeletionStyle.Render(fmt.Sprintf("%d deletions(-)", stats.Removed))
 		d.stats = lipgloss.JoinHorizontal(lipgloss.Center, additions, " ", deletions)
+		if stats.Base != "" {
+			// The base changes with the diff base setting of the instance, so say which is in use
+			d.stats = lipgloss.JoinHorizontal(lipgloss.Center, d.stats, " since "+stats.Base)
+		}
+		if flags := fileReportFlags(report); flags != "" {
+			d.stats = lipgloss.JoinVertical(lipgloss.Left, d.stats, flags)
+		}
 		d.diff = colorizeDiff(stats.Content)
 		d.viewport.SetContent(lipgloss.JoinVertical(lipgloss.Left, d.stats, d.diff))
 	}
 }
 
+// fileReportFlags lists the untracked and ignored files and the submodules of report under the diff
+// stats, so new files, files that won't be committed and submodules builds lack stand out.
+func fileReportFlags(report *git.FileReport) string {
+	if report == nil || report.IsEmpty() {
+		return ""
+	}
+	var lines []string
+	flag := func(label string, paths []string) {
+		if len(paths) == 0 {
+			return
+		}
+		lines = append(lines, FileFlagStyle.Render(fmt.Sprintf("⚠ %d %s:", len(paths), label)))
+		for _, path := range paths {
+			lines = append(lines, FileFlagStyle.Render("    "+path))
+		}
+	}
+	flag("untracked (never staged)", report.Untracked)
+	flag("ignored (won't be committed)", report.Ignored)
+	flag("ignored but tracked and modified", report.IgnoredModified)
+	var uninitialized, changed []string
+	for _, submodule := range report.Submodules {
+		if submodule.Initialized {
+			changed = append(changed, submodule.Path)
+		} else {
+			uninitialized = append(uninitialized, submodule.Path)
+		}
+	}
+	flag("submodules not checked out", uninitialized)
+	flag("submodules changed", changed)
+	flag("for Git LFS but stored in git", report.NotInLFS)
+	return strings.Join(lines, "\n")
+}
+
 func (d *DiffPane) String() string {
 	return d.viewport.View()
 }
@@ -5,14 +5,20 @@ import (
 	"claude-squad/session"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 const readyIcon = "● "
 const pausedIcon = "⏸ "
+const exitedIcon = "✗ "
+
+// accentBar marks each instance with its accent color, in place of the space before its number.
+const accentBar = "▌"
 
 var readyStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.AdaptiveColor{Light: "#51bd73", Dark: "#51bd73"})
@@ -26,6 +32,9 @@ var removedLinesStyle = lipgloss.NewStyle().
 var pausedStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.AdaptiveColor{Light: "#888888", Dark: "#888888"})
 
+var exitedStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#de613e"))
+
 var titleStyle = lipgloss.NewStyle().
 	Padding(1, 1, 0, 1).
 	Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"})
@@ -62,6 +71,21 @@ var simpleLabelStyle = lipgloss.NewStyle().
 	Bold(true).
 	Padding(0, 1)
 
+var ticketLabelStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.AdaptiveColor{Light: "#5a56e0", Dark: "#7d79f6"})
+
+var repoHeaderStyle = lipgloss.NewStyle().
+	Padding(1, 1, 0, 1).
+	Bold(true).
+	Foreground(lipgloss.AdaptiveColor{Light: "#5a56e0", Dark: "#7d79f6"})
+
+var selectedRepoHeaderStyle = repoHeaderStyle.
+	Background(lipgloss.Color("#dde4f0")).
+	Foreground(lipgloss.Color("#1a1a1a"))
+
+const expandedIcon = "▾"
+const collapsedIcon = "▸"
+
 type List struct {
 	items         []*session.Instance
 	selectedIdx   int
@@ -69,17 +93,20 @@ type List struct {
 	renderer      *InstanceRenderer
 	autoyes       bool
 
-	// map of repo name to number of instances using it. Used to display the repo name only if there are
-	// multiple repos in play.
+	// map of repo name to number of instances using it. Used to group the instances by repo only if
+	// there are multiple repos in play.
 	repos map[string]int
+	// collapsed holds the repos whose group only shows its heading
+	collapsed map[string]bool
 }
 
 func NewList(spinner *spinner.Model, autoYes bool) *List {
 	return &List{
-		items:    []*session.Instance{},
-		renderer: &InstanceRenderer{spinner: spinner},
-		repos:    make(map[string]int),
-		autoyes:  autoYes,
+		items:     []*session.Instance{},
+		renderer:  &InstanceRenderer{spinner: spinner},
+		repos:     make(map[string]int),
+		collapsed: make(map[string]bool),
+		autoyes:   autoYes,
 	}
 }
 
@@ -123,7 +150,23 @@ func (r *InstanceRenderer) setWidth(width int) {
 // ɹ and ɻ are other options.
 const branchIcon = "Ꮧ"
 
-func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, hasMultipleRepos bool) string {
+// truncateWidth cuts s to at most width terminal cells, ending it with an ellipsis if it was cut.
+// Widths are measured in cells rather than bytes, so wide characters such as CJK and emoji are
+// never split and styled text keeps its escape sequences.
+func truncateWidth(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	if width <= 3 {
+		return ansi.Truncate(s, width, "")
+	}
+	return ansi.Truncate(s, width, "...")
+}
+
+func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool) string {
 	prefix := fmt.Sprintf(" %d. ", idx)
 	if idx >= 10 {
 		prefix = prefix[:len(prefix)-1]
@@ -138,12 +181,14 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 	// add spinner next to title if it's running
 	var join string
 	switch i.Status {
-	case session.Running:
+	case session.Running, session.Loading:
 		join = fmt.Sprintf("%s ", r.spinner.View())
 	case session.Ready:
 		join = readyStyle.Render(readyIcon)
 	case session.Paused:
 		join = pausedStyle.Render(pausedIcon)
+	case session.Exited:
+		join = exitedStyle.Render(exitedIcon)
 	default:
 	}
 
@@ -155,14 +200,19 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 		simpleLabel := simpleLabelStyle.Render("SIMPLE")
 		titleText = lipgloss.JoinHorizontal(lipgloss.Left, simpleLabel, " ", titleText)
 	}
+	if ticket, ok := i.ParsedTicket(); ok {
+		titleText = lipgloss.JoinHorizontal(lipgloss.Left, titleText, " ", ticketLabelStyle.Render(ticket.Key))
+	}
 	
-	widthAvail := r.width - 3 - len(prefix) - 1
-	if widthAvail > 0 && widthAvail < len(titleText) && len(titleText) >= widthAvail-3 {
-		titleText = titleText[:widthAvail-3] + "..."
+	widthAvail := r.width - 3 - lipgloss.Width(prefix) - 1
+	if widthAvail > 0 {
+		titleText = truncateWidth(titleText, widthAvail)
 	}
+	accent := lipgloss.NewStyle().Foreground(lipgloss.Color(i.AccentColor())).
+		Background(titleS.GetBackground()).Render(accentBar)
 	title := titleS.Render(lipgloss.JoinHorizontal(
 		lipgloss.Left,
-		lipgloss.Place(r.width-3, 1, lipgloss.Left, lipgloss.Center, fmt.Sprintf("%s %s", prefix, titleText)),
+		lipgloss.Place(r.width-3, 1, lipgloss.Left, lipgloss.Center, fmt.Sprintf("%s%s %s", accent, prefix[1:], titleText)),
 		" ",
 		join,
 	))
@@ -188,8 +238,8 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 	}
 
 	remainingWidth := r.width
-	remainingWidth -= len(prefix)
-	remainingWidth -= len(branchIcon)
+	remainingWidth -= lipgloss.Width(prefix)
+	remainingWidth -= lipgloss.Width(branchIcon)
 
 	diffWidth := len(addedDiff) + len(removedDiff)
 	if diffWidth > 0 {
@@ -200,26 +250,18 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 	remainingWidth -= diffWidth
 
 	branch := i.Branch
-	if i.Started() && hasMultipleRepos {
-		repoName, err := i.RepoName()
-		if err != nil {
-			log.ErrorLog.Printf("could not get repo name in instance renderer: %v", err)
-		} else {
-			branch += fmt.Sprintf(" (%s)", repoName)
-		}
-	}
 	// Don't show branch if there's no space for it. Or show ellipsis if it's too long.
 	if remainingWidth < 0 {
 		branch = ""
-	} else if remainingWidth < len(branch) {
+	} else if remainingWidth < lipgloss.Width(branch) {
 		if remainingWidth < 3 {
 			branch = ""
 		} else {
-			// We know the remainingWidth is at least 4 and branch is longer than that, so this is safe.
-			branch = branch[:remainingWidth-3] + "..."
+			branch = truncateWidth(branch, remainingWidth)
 		}
 	}
-	remainingWidth -= len(branch)
+	// A wide character that didn't fit leaves a cell to fill
+	remainingWidth -= lipgloss.Width(branch)
 
 	// Add spaces to fill the remaining width.
 	spaces := ""
@@ -228,6 +270,13 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 	}
 
 	branchLine := fmt.Sprintf("%s %s-%s%s%s", strings.Repeat(" ", len(prefix)), branchIcon, branch, spaces, diff)
+	// Show what a starting instance is doing in place of its branch, in the same width
+	if stage := i.StartStage(); i.Status == session.Loading && stage != "" {
+		stageWidth := lipgloss.Width(branchLine) - len(prefix) - 1
+		stage = truncateWidth(stage+"...", stageWidth)
+		branchLine = fmt.Sprintf("%s %s%s", strings.Repeat(" ", len(prefix)), stage,
+			strings.Repeat(" ", max(stageWidth-lipgloss.Width(stage), 0)))
+	}
 
 	// join title and subtitle
 	text := lipgloss.JoinVertical(
@@ -291,25 +340,181 @@ func (l *List) String() string {
 	b.WriteString("\n")
 
 	// Render the list.
-	for i, item := range l.items {
-		b.WriteString(l.renderer.Render(item, i+1, i == l.selectedIdx, len(l.repos) > 1))
-		if i != len(l.items)-1 {
-			b.WriteString("\n\n")
+	if !l.grouped() {
+		for i, item := range l.items {
+			b.WriteString(l.renderer.Render(item, i+1, i == l.selectedIdx))
+			if i != len(l.items)-1 {
+				b.WriteString("\n\n")
+			}
+		}
+		return lipgloss.Place(l.width, l.height, lipgloss.Left, lipgloss.Top, b.String())
+	}
+
+	number := 1
+	for g, group := range l.groups() {
+		if g > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(l.renderRepoHeader(group))
+		if l.collapsed[group.repo] {
+			number += len(group.items)
+			continue
+		}
+		for _, idx := range group.items {
+			b.WriteString("\n")
+			b.WriteString(l.renderer.Render(l.items[idx], number, idx == l.selectedIdx))
+			number++
 		}
 	}
 	return lipgloss.Place(l.width, l.height, lipgloss.Left, lipgloss.Top, b.String())
 }
 
-// Down selects the next item in the list.
-func (l *List) Down() {
-	if len(l.items) == 0 {
+// renderRepoHeader renders the heading of a repo group: its name, the number of instances and
+// their total diff stats. The heading of a collapsed group is highlighted when it's selected.
+func (l *List) renderRepoHeader(group repoGroup) string {
+	icon := expandedIcon
+	style := repoHeaderStyle
+	if l.collapsed[group.repo] {
+		icon = collapsedIcon
+		if group.contains(l.selectedIdx) {
+			style = selectedRepoHeaderStyle
+		}
+	}
+
+	var added, removed int
+	for _, idx := range group.items {
+		if stat := l.items[idx].GetDiffStats(); stat != nil && stat.Error == nil {
+			added += stat.Added
+			removed += stat.Removed
+		}
+	}
+	header := fmt.Sprintf("%s %s (%d)", icon, group.repo, len(group.items))
+	var diff string
+	if added > 0 || removed > 0 {
+		diff = addedLinesStyle.Background(style.GetBackground()).Render(fmt.Sprintf(" +%d", added)) +
+			style.UnsetPadding().Render(",") +
+			removedLinesStyle.Background(style.GetBackground()).Render(fmt.Sprintf("-%d", removed))
+	}
+	width := AdjustPreviewWidth(l.width)
+	header = truncateWidth(header, width-2-lipgloss.Width(diff))
+	padding := strings.Repeat(" ", max(width-2-lipgloss.Width(header)-lipgloss.Width(diff), 0))
+	return style.Render(header + padding + diff)
+}
+
+// repoGroup is the instances of one repo, as indices into the list in list order.
+type repoGroup struct {
+	repo  string
+	items []int
+}
+
+func (g repoGroup) contains(idx int) bool {
+	for _, item := range g.items {
+		if item == idx {
+			return true
+		}
+	}
+	return false
+}
+
+// grouped reports whether the instances are shown grouped by repo, which they are when they span
+// several repos.
+func (l *List) grouped() bool {
+	return len(l.repos) > 1
+}
+
+// instanceRepo returns the name of the repo of an instance, using the name of its directory until
+// it has started.
+func instanceRepo(instance *session.Instance) string {
+	if name, err := instance.RepoName(); err == nil {
+		return name
+	}
+	return filepath.Base(instance.Path)
+}
+
+// groups returns the instances grouped by repo, in the order their repos first appear in the list.
+func (l *List) groups() []repoGroup {
+	var groups []repoGroup
+	index := make(map[string]int)
+	for idx, item := range l.items {
+		repo := instanceRepo(item)
+		g, ok := index[repo]
+		if !ok {
+			g = len(groups)
+			index[repo] = g
+			groups = append(groups, repoGroup{repo: repo})
+		}
+		groups[g].items = append(groups[g].items, idx)
+	}
+	return groups
+}
+
+// rows returns the instances that can be selected, in the order they are shown: every instance, or
+// when grouped, those of expanded groups and the first instance of each collapsed group, which
+// stands for its heading.
+func (l *List) rows() []int {
+	if !l.grouped() {
+		rows := make([]int, len(l.items))
+		for i := range rows {
+			rows[i] = i
+		}
+		return rows
+	}
+	var rows []int
+	for _, group := range l.groups() {
+		if l.collapsed[group.repo] {
+			rows = append(rows, group.items[0])
+			continue
+		}
+		rows = append(rows, group.items...)
+	}
+	return rows
+}
+
+// move selects the row delta rows away from the selected one.
+func (l *List) move(delta int) {
+	rows := l.rows()
+	if len(rows) == 0 {
+		return
+	}
+	selectedRepo := instanceRepo(l.items[l.selectedIdx])
+	pos := 0
+	for i, idx := range rows {
+		if idx == l.selectedIdx {
+			pos = i
+			break
+		}
+		// An instance hidden in a collapsed group is at the row of its heading
+		if l.grouped() && l.collapsed[selectedRepo] && instanceRepo(l.items[idx]) == selectedRepo {
+			pos = i
+		}
+	}
+	pos = min(max(pos+delta, 0), len(rows)-1)
+	l.selectedIdx = rows[pos]
+}
+
+// ToggleGroup collapses the repo group of the selected instance, or expands it if it's collapsed.
+// A collapsed group selects its first instance. It does nothing unless the list is grouped.
+func (l *List) ToggleGroup() {
+	if !l.grouped() || len(l.items) == 0 {
 		return
 	}
-	if l.selectedIdx < len(l.items)-1 {
-		l.selectedIdx++
+	repo := instanceRepo(l.items[l.selectedIdx])
+	l.collapsed[repo] = !l.collapsed[repo]
+	if !l.collapsed[repo] {
+		return
+	}
+	for _, group := range l.groups() {
+		if group.repo == repo {
+			l.selectedIdx = group.items[0]
+		}
 	}
 }
 
+// Down selects the next item in the list.
+func (l *List) Down() {
+	l.move(1)
+}
+
 // Kill selects the next item in the list.
 func (l *List) Kill() {
 	if len(l.items) == 0 {
@@ -322,10 +527,6 @@ func (l *List) Kill() {
 		log.ErrorLog.Printf("could not kill instance: %v", err)
 	}
 
-	// If you delete the last one in the list, select the previous one.
-	if l.selectedIdx == len(l.items)-1 {
-		defer l.Up()
-	}
 
 	// Unregister the reponame.
 	repoName, err := targetInstance.RepoName()
@@ -335,8 +536,12 @@ func (l *List) Kill() {
 		l.rmRepo(repoName)
 	}
 
-	// Since there's items after this, the selectedIdx can stay the same.
+	// Since there's items after this, the selectedIdx can stay the same. If you delete the last one in
+	// the list, select the previous one.
 	l.items = append(l.items[:l.selectedIdx], l.items[l.selectedIdx+1:]...)
+	if l.selectedIdx == len(l.items) && l.selectedIdx > 0 {
+		l.selectedIdx--
+	}
 }
 
 func (l *List) Attach() (chan struct{}, error) {
@@ -346,12 +551,7 @@ func (l *List) Attach() (chan struct{}, error) {
 
 // Up selects the prev item in the list.
 func (l *List) Up() {
-	if len(l.items) == 0 {
-		return
-	}
-	if l.selectedIdx > 0 {
-		l.selectedIdx--
-	}
+	l.move(-1)
 }
 
 func (l *List) addRepo(repo string) {
@@ -389,6 +589,40 @@ func (l *List) AddInstance(instance *session.Instance) (finalize func()) {
 	}
 }
 
+// Remove removes instance from the list without killing it, such as one that failed to start.
+func (l *List) Remove(instance *session.Instance) {
+	for idx, item := range l.items {
+		if item != instance {
+			continue
+		}
+		l.items = append(l.items[:idx], l.items[idx+1:]...)
+		if l.selectedIdx > idx || l.selectedIdx >= len(l.items) {
+			l.selectedIdx = max(l.selectedIdx-1, 0)
+		}
+		return
+	}
+}
+
+// RemoveKilled removes instance, killed outside of the list such as through the web API, and
+// unregisters its repo.
+func (l *List) RemoveKilled(instance *session.Instance) {
+	if repoName, err := instance.RepoName(); err == nil {
+		l.rmRepo(repoName)
+	}
+	l.Remove(instance)
+}
+
+// Replace lists instance in place of old, such as old reloaded from storage after it was paused
+// or resumed outside of the list.
+func (l *List) Replace(old, instance *session.Instance) {
+	for idx, item := range l.items {
+		if item == old {
+			l.items[idx] = instance
+			return
+		}
+	}
+}
+
 // GetSelectedInstance returns the currently selected instance
 func (l *List) GetSelectedInstance() *session.Instance {
 	if len(l.items) == 0 {
@@ -397,12 +631,14 @@ func (l *List) GetSelectedInstance() *session.Instance {
 	return l.items[l.selectedIdx]
 }
 
-// SetSelectedInstance sets the selected index. Noop if the index is out of bounds.
+// SetSelectedInstance sets the selected index, expanding the instance's repo group. Noop if the
+// index is out of bounds.
 func (l *List) SetSelectedInstance(idx int) {
 	if idx >= len(l.items) {
 		return
 	}
 	l.selectedIdx = idx
+	delete(l.collapsed, instanceRepo(l.items[idx]))
 }
 
 // GetInstances returns all instances in the list
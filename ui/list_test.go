@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"claude-squad/session"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		width int
+		want  string
+	}{
+		{name: "fits", s: "fix-bug", width: 10, want: "fix-bug"},
+		{name: "ascii", s: "refactor-everything", width: 10, want: "refacto..."},
+		{name: "cjk", s: "修复登录页面的错误", width: 10, want: "修复登..."},
+		{name: "cjk does not split a wide character", s: "修复登录页面的错误", width: 9, want: "修复登..."},
+		{name: "emoji", s: "🚀🚀🚀🚀🚀🚀", width: 8, want: "🚀🚀..."},
+		{name: "too narrow for an ellipsis", s: "修复登录", width: 3, want: "修"},
+		{name: "no width", s: "title", width: 0, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateWidth(tt.s, tt.width)
+			if got != tt.want {
+				t.Errorf("truncateWidth(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+			}
+			if lipgloss.Width(got) > tt.width {
+				t.Errorf("truncateWidth(%q, %d) is %d cells wide", tt.s, tt.width, lipgloss.Width(got))
+			}
+		})
+	}
+}
+
+func TestRenderWideTitles(t *testing.T) {
+	titles := []string{
+		"修复登录页面的错误并且重构整个认证模块",
+		"🚀 ship the 🎉 release with all the 🐛 fixes 🚀🚀🚀",
+		"ログイン画面のバグを修正してテストを追加する",
+	}
+	r := &InstanceRenderer{}
+	r.setWidth(40)
+	render := func(title string) []string {
+		instance := &session.Instance{Title: title, Branch: "agent/" + title, Status: session.Ready}
+		return strings.Split(ansi.Strip(r.Render(instance, 1, false)), "\n")
+	}
+	// A long ASCII title is laid out correctly, so wide titles must take the same cells
+	want := render(strings.Repeat("x", 80))
+
+	for _, title := range titles {
+		lines := render(title)
+		if len(lines) != len(want) {
+			t.Fatalf("rendered %d lines for %q, want %d", len(lines), title, len(want))
+		}
+		for i, line := range lines {
+			if !utf8.ValidString(line) {
+				t.Errorf("rendered invalid UTF-8 for %q: %q", title, line)
+			}
+			if lipgloss.Width(line) != lipgloss.Width(want[i]) {
+				t.Errorf("line %d of %q is %d cells wide, want %d", i, title, lipgloss.Width(line), lipgloss.Width(want[i]))
+			}
+			if strings.TrimSpace(want[i]) != "" && !strings.HasSuffix(strings.TrimRight(line, " ●"), "...") {
+				t.Errorf("line %d of %q is not truncated: %q", i, title, line)
+			}
+		}
+	}
+}
+
+func TestListGroupsByRepo(t *testing.T) {
+	l := NewList(&spinner.Model{}, false)
+	l.SetSize(60, 40)
+	for _, item := range []struct{ title, repo string }{
+		{"api-fix", "api"}, {"web-fix", "web"}, {"api-docs", "api"},
+	} {
+		l.AddInstance(&session.Instance{Title: item.title, Path: "/src/" + item.repo, Status: session.Ready})
+		l.addRepo(item.repo)
+	}
+
+	out := ansi.Strip(l.String())
+	api, web := strings.Index(out, "▾ api (2)"), strings.Index(out, "▾ web (1)")
+	if api < 0 || web < 0 || !(api < strings.Index(out, "api-docs") && strings.Index(out, "api-docs") < web) {
+		t.Fatalf("list is not grouped by repo:\n%s", out)
+	}
+
+	titles := func() []string {
+		var got []string
+		l.SetSelectedInstance(0)
+		for {
+			got = append(got, l.GetSelectedInstance().Title)
+			before := l.GetSelectedInstance()
+			l.Down()
+			if l.GetSelectedInstance() == before {
+				return got
+			}
+		}
+	}
+	if got := strings.Join(titles(), ","); got != "api-fix,api-docs,web-fix" {
+		t.Errorf("Down() visits %s, want the display order", got)
+	}
+
+	l.SetSelectedInstance(2) // api-docs
+	l.ToggleGroup()
+	if out := ansi.Strip(l.String()); !strings.Contains(out, "▸ api (2)") || strings.Contains(out, "api-docs") {
+		t.Errorf("collapsed group still shows its instances:\n%s", out)
+	}
+	if got := l.GetSelectedInstance().Title; got != "api-fix" {
+		t.Errorf("collapsing selects %s, want the group's first instance", got)
+	}
+	l.Down()
+	if got := l.GetSelectedInstance().Title; got != "web-fix" {
+		t.Errorf("Down() from a collapsed group selects %s, want web-fix", got)
+	}
+	l.Up()
+	if got := l.GetSelectedInstance().Title; got != "api-fix" {
+		t.Errorf("Up() onto a collapsed group selects %s, want its first instance", got)
+	}
+}
@@ -27,6 +27,8 @@ var sepStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{
 
 var actionGroupStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
 
+var offlineStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#D7263D", Dark: "#FF5F5F"})
+
 var separator = " • "
 var verticalSeparator = " │ "
 
@@ -49,11 +51,17 @@ type Menu struct {
 	state         MenuState
 	instance      *session.Instance
 	isInDiffTab   bool
+	// isInCommitsTab is set while the commits tab is shown
+	isInCommitsTab bool
+	// isInPromptsTab is set while the prompts tab is shown
+	isInPromptsTab bool
 	
-	// webServerEnabled and webServerPort indicate if the web server is active
+	// webServerEnabled and webServerAddress indicate if the web server is active and where
 	webServerEnabled bool
-	webServerPort    int
-	webServerHost    string
+	webServerAddress string
+	// offline is set while the network is down, with queuedPushes pushes waiting for it
+	offline      bool
+	queuedPushes int
 
 	// keyDown is the key which is pressed. The default is -1.
 	keyDown keys.KeyName
@@ -69,8 +77,7 @@ func NewMenu() *Menu {
 		state:            StateEmpty,
 		isInDiffTab:      false,
 		webServerEnabled: false,
-		webServerPort:    0,
-		webServerHost:    "",
+		webServerAddress: "",
 		keyDown:          -1,
 	}
 }
@@ -109,11 +116,28 @@ func (m *Menu) SetInDiffTab(inDiffTab bool) {
 	m.updateOptions()
 }
 
-// SetWebServerInfo updates the web server status information
-func (m *Menu) SetWebServerInfo(enabled bool, host string, port int) {
+// SetInCommitsTab updates whether we're currently in the commits tab
+func (m *Menu) SetInCommitsTab(inCommitsTab bool) {
+	m.isInCommitsTab = inCommitsTab
+	m.updateOptions()
+}
+
+// SetInPromptsTab updates whether we're currently in the prompts tab
+func (m *Menu) SetInPromptsTab(inPromptsTab bool) {
+	m.isInPromptsTab = inPromptsTab
+	m.updateOptions()
+}
+
+// SetWebServerInfo updates the web server status information. address is its URL or unix socket.
+func (m *Menu) SetWebServerInfo(enabled bool, address string) {
 	m.webServerEnabled = enabled
-	m.webServerHost = host
-	m.webServerPort = port
+	m.webServerAddress = address
+}
+
+// SetOffline shows whether the network is down, and how many pushes wait for it to come back.
+func (m *Menu) SetOffline(offline bool, queuedPushes int) {
+	m.offline = offline
+	m.queuedPushes = queuedPushes
 }
 
 // updateOptions updates the menu options based on current state and instance
@@ -157,11 +181,20 @@ func (m *Menu) addInstanceOptions() {
 			actionGroup = append(actionGroup, keys.KeyCheckout)
 		}
 	}
+	if m.instance.Status == session.Exited {
+		actionGroup = append(actionGroup, keys.KeyResume)
+	}
 
 	// Navigation group (when in diff tab)
 	if m.isInDiffTab {
 		actionGroup = append(actionGroup, keys.KeyShiftUp)
 	}
+	if m.isInCommitsTab {
+		actionGroup = append(actionGroup, keys.KeyShiftUp, keys.KeyShowCommit, keys.KeyUndoCommit)
+	}
+	if m.isInPromptsTab {
+		actionGroup = append(actionGroup, keys.KeyShiftUp, keys.KeyReplayPrompt)
+	}
 
 	// System group
 	systemGroup := []keys.KeyName{keys.KeyTab, keys.KeyHelp, keys.KeyQuit}
@@ -244,12 +277,26 @@ func (m *Menu) String() string {
 
 	// Get the menu text
 	menuText := s.String()
+	if actions := m.quickActionsLine(); actions != "" {
+		menuText = lipgloss.JoinVertical(lipgloss.Center, menuText, actions)
+	}
 	
-	// Add web server info if enabled
-	if m.webServerEnabled && m.webServerPort > 0 {
-		webInfo := lipgloss.NewStyle().
+	// Add the offline indicator and web server info if enabled
+	var info []string
+	if m.offline {
+		text := " Offline"
+		if m.queuedPushes > 0 {
+			text += fmt.Sprintf(", %d pushes queued", m.queuedPushes)
+		}
+		info = append(info, offlineStyle.Render(text))
+	}
+	if m.webServerEnabled && m.webServerAddress != "" {
+		info = append(info, lipgloss.NewStyle().
 			Foreground(lipgloss.AdaptiveColor{Light: "#007BFF", Dark: "#00AFFF"}). // Blue color
-			Render(fmt.Sprintf(" Web: http://%s:%d", m.webServerHost, m.webServerPort))
+			Render(" Web: "+m.webServerAddress))
+	}
+	if len(info) > 0 {
+		webInfo := strings.Join(info, " ")
 		
 		// Calculate available width for menuText to avoid overlap
 		menuTextWidth := lipgloss.Width(menuText)
@@ -271,3 +318,22 @@ func (m *Menu) String() string {
 
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, menuText)
 }
+
+// quickActionsLine renders the quick actions numbered by their keys, while an instance is
+// selected.
+func (m *Menu) quickActionsLine() string {
+	actions := session.QuickActions()
+	if m.state != StateDefault || m.instance == nil || len(actions) == 0 {
+		return ""
+	}
+	var s strings.Builder
+	for i, action := range actions {
+		if i > 0 {
+			s.WriteString(sepStyle.Render(separator))
+		}
+		s.WriteString(keyStyle.Render(fmt.Sprint(i + 1)))
+		s.WriteString(" ")
+		s.WriteString(descStyle.Render(action.Label))
+	}
+	return s.String()
+}
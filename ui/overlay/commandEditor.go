@@ -0,0 +1,91 @@
+package overlay
+
+import (
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// CommandEditorOverlay is a single-line editor for a program command. Enter submits and Esc
+// cancels, so flags can be tweaked without tabbing to a button.
+type CommandEditorOverlay struct {
+	input     textinput.Model
+	Title     string
+	Hint      string
+	Submitted bool
+	Canceled  bool
+	width     int
+}
+
+// NewCommandEditorOverlay creates a command editor with the given title and initial command.
+func NewCommandEditorOverlay(title string, command string) *CommandEditorOverlay {
+	ti := textinput.New()
+	ti.SetValue(command)
+	ti.CursorEnd()
+	ti.Focus()
+	ti.Prompt = "$ "
+	ti.CharLimit = 0
+
+	return &CommandEditorOverlay{
+		input: ti,
+		Title: title,
+		Hint:  "enter to apply • esc to cancel",
+	}
+}
+
+// SetWidth sets the rendered width of the overlay.
+func (c *CommandEditorOverlay) SetWidth(width int) {
+	c.width = width
+}
+
+// HandleKeyPress processes a key press and returns true if the overlay should be closed.
+func (c *CommandEditorOverlay) HandleKeyPress(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyEsc:
+		c.Canceled = true
+		return true
+	case tea.KeyEnter:
+		c.Submitted = true
+		return true
+	default:
+		c.input, _ = c.input.Update(msg)
+		return false
+	}
+}
+
+// GetValue returns the edited command.
+func (c *CommandEditorOverlay) GetValue() string {
+	return c.input.Value()
+}
+
+// IsSubmitted returns whether the command was submitted.
+func (c *CommandEditorOverlay) IsSubmitted() bool {
+	return c.Submitted
+}
+
+// Render renders the command editor overlay.
+func (c *CommandEditorOverlay) Render() string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(1, 2)
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("62")).
+		Bold(true).
+		MarginBottom(1)
+
+	hintStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241"))
+
+	if c.width > 0 {
+		style = style.Width(c.width)
+		c.input.Width = c.width - 8 // Account for padding, borders and prompt
+	}
+
+	content := titleStyle.Render(c.Title) + "\n"
+	content += c.input.View() + "\n\n"
+	content += hintStyle.Render(c.Hint)
+
+	return style.Render(content)
+}
@@ -0,0 +1,238 @@
+package overlay
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxPickerMatches is the number of matching files shown at once.
+const maxPickerMatches = 10
+
+// FilePickerOverlay picks files by fuzzy matching their paths against a query. Tab selects the
+// highlighted file, enter submits the selection, or the highlighted file if none is selected.
+type FilePickerOverlay struct {
+	input   textinput.Model
+	Title   string
+	files   []string
+	matches []string
+	// offset is the index of the first match shown, cursor the index of the highlighted match
+	offset, cursor int
+	selected       map[string]bool
+	// order keeps the selected files in the order they were picked
+	order     []string
+	Submitted bool
+	Canceled  bool
+	// PathsOnly is set when the selection is submitted with ctrl+p, to attach paths rather than
+	// contents
+	PathsOnly bool
+	width     int
+}
+
+// NewFilePickerOverlay creates a file picker over files.
+func NewFilePickerOverlay(title string, files []string) *FilePickerOverlay {
+	ti := textinput.New()
+	ti.Focus()
+	ti.Prompt = "> "
+	ti.Placeholder = "type to filter"
+	ti.CharLimit = 0
+
+	f := &FilePickerOverlay{
+		input:    ti,
+		Title:    title,
+		files:    files,
+		selected: make(map[string]bool),
+	}
+	f.filter()
+	return f
+}
+
+// SetWidth sets the rendered width of the overlay.
+func (f *FilePickerOverlay) SetWidth(width int) {
+	f.width = width
+}
+
+// HandleKeyPress processes a key press and returns true if the overlay should be closed.
+func (f *FilePickerOverlay) HandleKeyPress(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "esc":
+		f.Canceled = true
+		return true
+	case "enter", "ctrl+p":
+		if len(f.order) == 0 && len(f.matches) > 0 {
+			f.toggle(f.matches[f.cursor])
+		}
+		if len(f.order) == 0 {
+			return false
+		}
+		f.Submitted = true
+		f.PathsOnly = msg.String() == "ctrl+p"
+		return true
+	case "tab":
+		if len(f.matches) > 0 {
+			f.toggle(f.matches[f.cursor])
+			f.move(1)
+		}
+	case "up", "ctrl+k":
+		f.move(-1)
+	case "down", "ctrl+j":
+		f.move(1)
+	default:
+		query := f.input.Value()
+		f.input, _ = f.input.Update(msg)
+		if f.input.Value() != query {
+			f.filter()
+		}
+	}
+	return false
+}
+
+// Selected returns the selected files in the order they were picked.
+func (f *FilePickerOverlay) Selected() []string {
+	return f.order
+}
+
+// IsSubmitted returns whether the selection was submitted.
+func (f *FilePickerOverlay) IsSubmitted() bool {
+	return f.Submitted
+}
+
+func (f *FilePickerOverlay) toggle(file string) {
+	if f.selected[file] {
+		delete(f.selected, file)
+		for i, picked := range f.order {
+			if picked == file {
+				f.order = append(f.order[:i], f.order[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+	f.selected[file] = true
+	f.order = append(f.order, file)
+}
+
+func (f *FilePickerOverlay) move(delta int) {
+	if len(f.matches) == 0 {
+		return
+	}
+	f.cursor = min(max(f.cursor+delta, 0), len(f.matches)-1)
+	if f.cursor < f.offset {
+		f.offset = f.cursor
+	} else if f.cursor >= f.offset+maxPickerMatches {
+		f.offset = f.cursor - maxPickerMatches + 1
+	}
+}
+
+// filter matches the files against the query, best matches first.
+func (f *FilePickerOverlay) filter() {
+	f.matches = FuzzyFilter(f.input.Value(), f.files)
+	f.cursor, f.offset = 0, 0
+}
+
+// FuzzyFilter returns the paths containing the characters of query in order, ignoring case. The
+// paths matching in fewer, longer runs and in their base name come first. An empty query returns
+// paths as they are.
+func FuzzyFilter(query string, paths []string) []string {
+	if query == "" {
+		return paths
+	}
+	type match struct {
+		path  string
+		score int
+	}
+	var matches []match
+	for _, p := range paths {
+		if score, ok := fuzzyScore(strings.ToLower(query), p); ok {
+			matches = append(matches, match{p, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return len(matches[i].path) < len(matches[j].path)
+	})
+	result := make([]string, 0, len(matches))
+	for _, m := range matches {
+		result = append(result, m.path)
+	}
+	return result
+}
+
+// fuzzyScore returns how well the lowercase query matches p, and false if p doesn't contain its
+// characters in order.
+func fuzzyScore(query, p string) (int, bool) {
+	lower := strings.ToLower(p)
+	base := len(p) - len(path.Base(p))
+	score, qi, prev := 0, 0, -2
+	for i := 0; i < len(lower) && qi < len(query); i++ {
+		if lower[i] != query[qi] {
+			continue
+		}
+		score++
+		if i == prev+1 {
+			// Consecutive characters
+			score += 3
+		}
+		if i == base || i > 0 && strings.ContainsRune("/_-. ", rune(p[i-1])) {
+			// Start of the base name or of a word
+			score += 2
+		}
+		if i >= base {
+			score++
+		}
+		prev = i
+		qi++
+	}
+	return score, qi == len(query)
+}
+
+// Render renders the file picker overlay.
+func (f *FilePickerOverlay) Render() string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(1, 2)
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("62")).
+		Bold(true).
+		MarginBottom(1)
+
+	cursorStyle := lipgloss.NewStyle().
+		Background(lipgloss.Color("62")).
+		Foreground(lipgloss.Color("0"))
+
+	hintStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241"))
+
+	if f.width > 0 {
+		style = style.Width(f.width)
+		f.input.Width = f.width - 8 // Account for padding, borders and prompt
+	}
+
+	content := titleStyle.Render(f.Title) + "\n"
+	content += f.input.View() + "\n\n"
+	end := min(f.offset+maxPickerMatches, len(f.matches))
+	for i := f.offset; i < end; i++ {
+		mark := "[ ] "
+		if f.selected[f.matches[i]] {
+			mark = "[x] "
+		}
+		line := mark + f.matches[i]
+		if i == f.cursor {
+			line = cursorStyle.Render(line)
+		}
+		content += line + "\n"
+	}
+	content += "\n" + hintStyle.Render(fmt.Sprintf("%d of %d files, %d selected", len(f.matches), len(f.files), len(f.order))) + "\n"
+	content += hintStyle.Render("↑/↓ move • tab select • enter attach contents • ctrl+p attach paths • esc cancel")
+
+	return style.Render(content)
+}
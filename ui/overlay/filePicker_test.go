@@ -0,0 +1,49 @@
+package overlay
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFuzzyFilter(t *testing.T) {
+	paths := []string{"app/app.go", "session/git/worktree.go", "ui/overlay/textInput.go", "web/handlers/templates.go"}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", paths},
+		{"wt", []string{"session/git/worktree.go", "web/handlers/templates.go"}},
+		{"APP", []string{"app/app.go"}},
+		{"tgo", []string{"ui/overlay/textInput.go", "web/handlers/templates.go", "session/git/worktree.go"}},
+		{"xyz", []string{}},
+	}
+	for _, tt := range tests {
+		if got := FuzzyFilter(tt.query, paths); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FuzzyFilter(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFilePickerSelection(t *testing.T) {
+	picker := NewFilePickerOverlay("Attach", []string{"a.go", "b.go", "c.go"})
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyDown}, {Type: tea.KeyTab}, {Type: tea.KeyUp}, {Type: tea.KeyUp}, {Type: tea.KeyTab}} {
+		if picker.HandleKeyPress(key) {
+			t.Fatalf("%s closed the picker", key)
+		}
+	}
+	if !picker.HandleKeyPress(tea.KeyMsg{Type: tea.KeyCtrlP}) || !picker.IsSubmitted() || !picker.PathsOnly {
+		t.Fatalf("ctrl+p didn't submit the paths")
+	}
+	if got, want := picker.Selected(), []string{"b.go", "a.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Selected() = %v, want %v", got, want)
+	}
+
+	// Enter without a selection picks the highlighted file
+	picker = NewFilePickerOverlay("Attach", []string{"a.go", "b.go"})
+	picker.HandleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	if !picker.HandleKeyPress(tea.KeyMsg{Type: tea.KeyEnter}) || !reflect.DeepEqual(picker.Selected(), []string{"b.go"}) {
+		t.Errorf("Selected() after enter = %v, want b.go", picker.Selected())
+	}
+}
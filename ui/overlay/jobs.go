@@ -0,0 +1,78 @@
+package overlay
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxJobLines caps the jobs listed, the overlay sitting over the list and preview.
+const maxJobLines = 6
+
+// JobLine is a job shown in the JobsOverlay.
+type JobLine struct {
+	ID int
+	// Label says what the job does, such as "Pushing feature".
+	Label string
+	// Started is when the job started running, zero while it is queued.
+	Started time.Time
+	// Canceled is set once a running job was asked to stop.
+	Canceled bool
+}
+
+// JobsOverlay shows the git and tmux jobs running in the background, with a spinner, and those
+// queued behind them. It floats in a corner without taking the input.
+type JobsOverlay struct {
+	spinner *spinner.Model
+	jobs    []JobLine
+}
+
+// NewJobsOverlay creates an overlay animated with spinner.
+func NewJobsOverlay(spinner *spinner.Model) *JobsOverlay {
+	return &JobsOverlay{spinner: spinner}
+}
+
+// SetJobs sets the jobs shown, running and queued, in the order they were started.
+func (j *JobsOverlay) SetJobs(jobs []JobLine) {
+	j.jobs = jobs
+}
+
+// Visible returns true while there are jobs to show.
+func (j *JobsOverlay) Visible() bool {
+	return len(j.jobs) > 0
+}
+
+var (
+	jobStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	jobQueuedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+)
+
+// Render renders the overlay.
+func (j *JobsOverlay) Render() string {
+	lines := make([]string, 0, len(j.jobs)+1)
+	for i, job := range j.jobs {
+		if i == maxJobLines {
+			lines = append(lines, jobQueuedStyle.Render(fmt.Sprintf("  ... and %d more", len(j.jobs)-i)))
+			break
+		}
+		switch {
+		case job.Started.IsZero():
+			lines = append(lines, jobQueuedStyle.Render(fmt.Sprintf("  #%d %s, queued", job.ID, job.Label)))
+		case job.Canceled:
+			lines = append(lines, jobQueuedStyle.Render(fmt.Sprintf("%s #%d %s, canceling", j.spinner.View(), job.ID, job.Label)))
+		default:
+			elapsed := time.Since(job.Started).Truncate(time.Second)
+			lines = append(lines, jobStyle.Render(fmt.Sprintf("%s #%d %s %s", j.spinner.View(), job.ID, job.Label, elapsed)))
+		}
+	}
+	lines = append(lines, jobQueuedStyle.Render("esc cancels the jobs of the selected session"))
+
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(0, 1)
+	return style.Render(strings.Join(lines, "\n"))
+}
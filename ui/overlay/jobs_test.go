@@ -0,0 +1,38 @@
+package overlay
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+)
+
+func TestJobsOverlay(t *testing.T) {
+	s := spinner.New()
+	jobs := NewJobsOverlay(&s)
+	if jobs.Visible() {
+		t.Fatalf("Visible() without jobs = true")
+	}
+	jobs.SetJobs([]JobLine{
+		{ID: 1, Label: "Pushing a", Started: time.Now().Add(-3 * time.Second)},
+		{ID: 2, Label: "Pausing a"},
+	})
+	view := jobs.Render()
+	for _, want := range []string{"#1 Pushing a 3s", "#2 Pausing a, queued"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Render() = %q, want it to contain %q", view, want)
+		}
+	}
+}
+
+func TestPlaceFloating(t *testing.T) {
+	bg := "aaaaaa\nbbbbbb\ncccccc"
+	if got, want := PlaceFloating(3, 1, "XY", bg), "aaaaaa\nbbbXYb\ncccccc"; got != want {
+		t.Errorf("PlaceFloating() = %q, want %q", got, want)
+	}
+	// Placed past the edge, it is moved back inside the background
+	if got, want := PlaceFloating(10, 5, "XY", bg), "aaaaaa\nbbbbbb\nccccXY"; got != want {
+		t.Errorf("PlaceFloating() past the edge = %q, want %q", got, want)
+	}
+}
@@ -53,7 +53,6 @@ func PlaceOverlay(
 ) string {
 	fgLines, fgWidth := getLines(fg)
 	bgLines, bgWidth := getLines(bg)
-	bgHeight := len(bgLines)
 	fgHeight := len(fgLines)
 
 	// Apply a fade effect to the background by directly modifying each line
@@ -117,6 +116,27 @@ func PlaceOverlay(
 		_ = PlaceOverlay(placeX+shadowOffsetX, placeY+shadowOffsetY, shadowStr, bg, false, false, opts...)
 	}
 
+	// Apply whitespace options
+	ws := &whitespace{}
+	for _, opt := range opts {
+		opt(ws)
+	}
+	return place(placeX, placeY, fg, fgLines, fgWidth, bgLines, bgWidth, ws)
+}
+
+// PlaceFloating places fg on top of bg at x and y, leaving the rest of bg as it is, for overlays
+// such as progress that don't take the input.
+func PlaceFloating(x, y int, fg, bg string) string {
+	fgLines, fgWidth := getLines(fg)
+	bgLines, bgWidth := getLines(bg)
+	return place(x, y, fg, fgLines, fgWidth, bgLines, bgWidth, &whitespace{})
+}
+
+// place writes the lines of fg over those of bg from placeX and placeY.
+func place(placeX, placeY int, fg string, fgLines []string, fgWidth int, bgLines []string, bgWidth int, ws *whitespace) string {
+	bgHeight := len(bgLines)
+	fgHeight := len(fgLines)
+
 	// Check if foreground exceeds background size
 	if fgWidth >= bgWidth && fgHeight >= bgHeight {
 		return fg // Return foreground if it's larger than background
@@ -126,12 +146,6 @@ func PlaceOverlay(
 	placeX = clamp(placeX, 0, bgWidth-fgWidth)
 	placeY = clamp(placeY, 0, bgHeight-fgHeight)
 
-	// Apply whitespace options
-	ws := &whitespace{}
-	for _, opt := range opts {
-		opt(ws)
-	}
-
 	// Build the output string
 	var b strings.Builder
 	for i, bgLine := range bgLines {
@@ -0,0 +1,93 @@
+package overlay
+
+import (
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// RunOutputOverlay shows the output of a command as it runs. The view follows new output unless
+// it was scrolled up.
+type RunOutputOverlay struct {
+	viewport viewport.Model
+	Title    string
+	// Status describes whether the command runs, succeeded or failed.
+	Status string
+	Hint   string
+	// Failed colors the status as an error.
+	Failed bool
+	output string
+	width  int
+}
+
+// NewRunOutputOverlay creates an overlay for the output of a command titled title.
+func NewRunOutputOverlay(title string) *RunOutputOverlay {
+	return &RunOutputOverlay{
+		viewport: viewport.New(0, 0),
+		Title:    title,
+		Hint:     "↑/↓ scroll • ctrl-c stop • r run again • esc close",
+	}
+}
+
+// SetSize sets the size of the overlay, including its border.
+func (r *RunOutputOverlay) SetSize(width, height int) {
+	r.width = width
+	// Border, padding, title, status and hint
+	r.viewport.Width = max(width-6, 10)
+	r.viewport.Height = max(height-10, 3)
+	r.SetOutput(r.output)
+}
+
+// SetOutput replaces the output shown.
+func (r *RunOutputOverlay) SetOutput(output string) {
+	following := r.output == "" || r.viewport.AtBottom()
+	r.output = output
+	r.viewport.SetContent(lipgloss.NewStyle().Width(r.viewport.Width).Render(output))
+	if following {
+		r.viewport.GotoBottom()
+	}
+}
+
+// HandleKeyPress scrolls the output. It returns false for the keys it doesn't handle.
+func (r *RunOutputOverlay) HandleKeyPress(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "up", "k":
+		r.viewport.LineUp(1)
+	case "down", "j":
+		r.viewport.LineDown(1)
+	case "pgup":
+		r.viewport.HalfViewUp()
+	case "pgdown":
+		r.viewport.HalfViewDown()
+	case "home", "g":
+		r.viewport.GotoTop()
+	case "end", "G":
+		r.viewport.GotoBottom()
+	default:
+		return false
+	}
+	return true
+}
+
+// Render renders the overlay.
+func (r *RunOutputOverlay) Render() string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(1, 2)
+	if r.width > 0 {
+		style = style.Width(r.width)
+	}
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#22c55e"))
+	if r.Failed {
+		statusStyle = statusStyle.Foreground(lipgloss.Color("#ef4444"))
+	}
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	content := titleStyle.Render(r.Title) + "\n" +
+		statusStyle.Render(r.Status) + "\n\n" +
+		r.viewport.View() + "\n\n" +
+		hintStyle.Render(r.Hint)
+	return style.Render(content)
+}
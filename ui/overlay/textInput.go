@@ -1,6 +1,8 @@
 package overlay
 
 import (
+	"strings"
+
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -15,6 +17,14 @@ type TextInputOverlay struct {
 	Canceled      bool
 	OnSubmit      func()
 	width, height int
+
+	// singleLine makes enter submit and turns pasted newlines into spaces
+	singleLine bool
+	// validate checks the value as it is typed. Submitting is refused while it returns an error.
+	validate func(string) error
+	err      error
+	// hint describes a valid value, shown under it in place of the error
+	hint func(string) string
 }
 
 // NewTextInputOverlay creates a new text input overlay with the given title and initial value.
@@ -40,7 +50,43 @@ func NewTextInputOverlay(title string, initialValue string) *TextInputOverlay {
 	}
 }
 
+// NewSingleLineInputOverlay creates a text input overlay for a one line value of at most limit
+// characters, checked by validate as it is typed. Enter submits the value unless it is invalid.
+func NewSingleLineInputOverlay(title string, initialValue string, limit int, validate func(string) error) *TextInputOverlay {
+	t := NewTextInputOverlay(title, "")
+	t.singleLine = true
+	t.validate = validate
+	t.textarea.CharLimit = limit
+	t.textarea.SetHeight(1)
+	t.textarea.SetValue(initialValue)
+	t.check()
+	return t
+}
+
+// check validates the value, keeping a single line value on one line.
+func (t *TextInputOverlay) check() {
+	if t.singleLine && strings.ContainsAny(t.textarea.Value(), "\r\n") {
+		t.textarea.SetValue(strings.Join(strings.Fields(t.textarea.Value()), " "))
+	}
+	if t.validate != nil {
+		t.err = t.validate(t.textarea.Value())
+	}
+}
+
+// SetHint sets what is shown under a valid value, such as the names derived from it.
+func (t *TextInputOverlay) SetHint(hint func(string) string) {
+	t.hint = hint
+}
+
+// Err returns why the current value is invalid, or nil.
+func (t *TextInputOverlay) Err() error {
+	return t.err
+}
+
 func (t *TextInputOverlay) SetSize(width, height int) {
+	if t.singleLine {
+		height = 1
+	}
 	t.textarea.SetHeight(height) // Set textarea height to 10 lines
 	t.width = width
 	t.height = height
@@ -82,8 +128,11 @@ func (t *TextInputOverlay) HandleKeyPress(msg tea.KeyMsg) bool {
 		t.Canceled = true
 		return true
 	case tea.KeyEnter:
-		if t.FocusIndex == 1 {
-			// Enter button is focused, so submit.
+		if t.FocusIndex == 1 || t.singleLine {
+			// Enter button is focused or the value is a single line, so submit.
+			if t.err != nil {
+				return false
+			}
 			t.Submitted = true
 			if t.OnSubmit != nil {
 				t.OnSubmit()
@@ -94,6 +143,7 @@ func (t *TextInputOverlay) HandleKeyPress(msg tea.KeyMsg) bool {
 	default:
 		if t.FocusIndex == 0 {
 			t.textarea, _ = t.textarea.Update(msg)
+			t.check()
 		}
 		return false
 	}
@@ -135,6 +185,12 @@ func (t *TextInputOverlay) Render() string {
 	buttonStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("7"))
 
+	errorStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("1"))
+
+	hintStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8"))
+
 	focusedButtonStyle := buttonStyle
 	focusedButtonStyle = focusedButtonStyle.
 		Background(lipgloss.Color("62")).
@@ -146,6 +202,11 @@ func (t *TextInputOverlay) Render() string {
 	// Build the view
 	content := titleStyle.Render(t.Title) + "\n"
 	content += t.textarea.View() + "\n\n"
+	if t.err != nil {
+		content += errorStyle.Render(t.err.Error()) + "\n\n"
+	} else if t.hint != nil {
+		content += hintStyle.Render(t.hint(t.textarea.Value())) + "\n\n"
+	}
 
 	// Render enter button with appropriate style
 	enterButton := " Enter "
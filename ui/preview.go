@@ -11,11 +11,29 @@ import (
 var previewPaneStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"})
 
+var scrollIndicatorStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.AdaptiveColor{Light: "#874BFD", Dark: "#7D56F4"})
+
+// previewHistoryLines is how much scrollback the preview can scroll through.
+const previewHistoryLines = 2000
+
 type PreviewPane struct {
 	width  int
 	height int
 
 	previewState previewState
+
+	// title is the instance shown, so scrolling resets when another one is selected
+	title string
+	// scrollOffset is how many lines the preview is scrolled up from the bottom. At 0 the preview
+	// follows new output, otherwise it holds the scrollback captured when scrolling started.
+	scrollOffset int
+	// history is true once the scrollback has been captured for the current scroll
+	history bool
+	// visible is the pane content when scrolling started, to notice new output
+	visible string
+	// newOutput is true if output arrived while scrolled up
+	newOutput bool
 }
 
 type previewState struct {
@@ -34,8 +52,35 @@ func (p *PreviewPane) SetSize(width, maxHeight int) {
 	p.height = maxHeight
 }
 
+// ScrollUp scrolls the preview one line back into the scrollback, holding the position while new
+// output arrives.
+func (p *PreviewPane) ScrollUp() {
+	if !p.previewState.fallback {
+		p.scrollOffset++
+	}
+}
+
+// ScrollDown scrolls the preview one line forward. Reaching the bottom follows new output again.
+func (p *PreviewPane) ScrollDown() {
+	if p.scrollOffset > 0 {
+		p.scrollOffset--
+	}
+	if p.scrollOffset == 0 {
+		p.resetScroll()
+	}
+}
+
+// resetScroll returns to following new output.
+func (p *PreviewPane) resetScroll() {
+	p.scrollOffset = 0
+	p.history = false
+	p.visible = ""
+	p.newOutput = false
+}
+
 // setFallbackState sets the preview state with fallback text and a message
 func (p *PreviewPane) setFallbackState(message string) {
+	p.resetScroll()
 	p.previewState = previewState{
 		fallback: true,
 		text:     lipgloss.JoinVertical(lipgloss.Center, FallBackText, "", message),
@@ -44,10 +89,24 @@ func (p *PreviewPane) setFallbackState(message string) {
 
 // Updates the preview pane content with the tmux pane content
 func (p *PreviewPane) UpdateContent(instance *session.Instance) error {
+	if instance == nil || instance.Title != p.title {
+		p.resetScroll()
+	}
+	if instance != nil {
+		p.title = instance.Title
+	}
+
 	switch {
 	case instance == nil:
 		p.setFallbackState("No agents running yet. Spin up a new instance with 'n' to get started!")
 		return nil
+	case instance.Status == session.Loading:
+		stage := instance.StartStage()
+		if stage == "" {
+			stage = "starting"
+		}
+		p.setFallbackState(fmt.Sprintf("Starting %s: %s... Press esc to cancel.", instance.Title, stage))
+		return nil
 	case instance.Status == session.Paused:
 		p.setFallbackState(lipgloss.JoinVertical(lipgloss.Center,
 			"Session is paused. Press 'r' to resume.",
@@ -75,6 +134,10 @@ func (p *PreviewPane) UpdateContent(instance *session.Instance) error {
 		return nil
 	}
 
+	if p.scrollOffset > 0 {
+		return p.updateScrolled(instance, content)
+	}
+
 	p.previewState = previewState{
 		fallback: false,
 		text:     content,
@@ -82,6 +145,45 @@ func (p *PreviewPane) UpdateContent(instance *session.Instance) error {
 	return nil
 }
 
+// updateScrolled keeps the scrollback shown while scrolled up and notes whether new output arrived.
+func (p *PreviewPane) updateScrolled(instance *session.Instance, visible string) error {
+	if p.history {
+		if visible != p.visible {
+			p.newOutput = true
+		}
+		return nil
+	}
+
+	history, err := instance.PreviewHistory(previewHistoryLines)
+	if err != nil {
+		return err
+	}
+	p.history = true
+	p.visible = visible
+	p.previewState = previewState{fallback: false, text: strings.TrimRight(history, "\n")}
+	return nil
+}
+
+// scrolledLines returns the lines of the scrollback shown at the scroll offset, clamping the
+// offset to the top of the scrollback, followed by an indicator of what is below.
+func (p *PreviewPane) scrolledLines(height int) []string {
+	lines := strings.Split(p.previewState.text, "\n")
+	height = max(height-1, 1) // 1 for the indicator
+	p.scrollOffset = min(p.scrollOffset, max(len(lines)-height, 0))
+	if p.scrollOffset == 0 {
+		// Too little scrollback to scroll at all
+		p.resetScroll()
+	}
+
+	end := len(lines) - p.scrollOffset
+	start := max(end-height, 0)
+	indicator := fmt.Sprintf("▼ scrolled up %d lines, shift-↓ to follow", p.scrollOffset)
+	if p.newOutput {
+		indicator = "▼ new output below, shift-↓ to follow"
+	}
+	return append(lines[start:end:end], scrollIndicatorStyle.Render(indicator))
+}
+
 // Returns the preview pane content as a string.
 func (p *PreviewPane) String() string {
 	if p.width == 0 || p.height == 0 {
@@ -125,6 +227,9 @@ func (p *PreviewPane) String() string {
 	availableHeight := p.height - 1 //  1 for ellipsis
 
 	lines := strings.Split(p.previewState.text, "\n")
+	if p.history {
+		lines = p.scrolledLines(availableHeight)
+	}
 
 	// Truncate if we have more lines than available height
 	if availableHeight > 0 {
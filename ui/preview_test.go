@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func scrolledPreview(lines, offset int) *PreviewPane {
+	text := make([]string, lines)
+	for i := range text {
+		text[i] = fmt.Sprintf("line %d", i)
+	}
+	p := NewPreviewPane()
+	p.SetSize(40, 6)
+	p.previewState = previewState{text: strings.Join(text, "\n")}
+	p.history = true
+	p.scrollOffset = offset
+	return p
+}
+
+func TestPreviewScrolledLines(t *testing.T) {
+	p := scrolledPreview(20, 3)
+	lines := p.scrolledLines(5)
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want 5", len(lines))
+	}
+	if lines[0] != "line 13" || lines[3] != "line 16" {
+		t.Errorf("window = %v, want lines 13 to 16", lines[:4])
+	}
+	if !strings.Contains(lines[4], "scrolled up 3 lines") {
+		t.Errorf("indicator = %q, want the scroll offset", lines[4])
+	}
+
+	p.newOutput = true
+	if lines := p.scrolledLines(5); !strings.Contains(lines[4], "new output below") {
+		t.Errorf("indicator = %q, want new output", lines[4])
+	}
+}
+
+func TestPreviewScrollClamp(t *testing.T) {
+	p := scrolledPreview(20, 100)
+	lines := p.scrolledLines(5)
+	if lines[0] != "line 0" || p.scrollOffset != 16 {
+		t.Errorf("first line = %q at offset %d, want line 0 at 16", lines[0], p.scrollOffset)
+	}
+
+	// Scrolling back to the bottom follows the output again
+	for range 16 {
+		p.ScrollDown()
+	}
+	if p.history || p.scrollOffset != 0 {
+		t.Errorf("history = %v, offset = %d after scrolling to the bottom", p.history, p.scrollOffset)
+	}
+}
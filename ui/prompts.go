@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"claude-squad/session"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PromptsPane lists the prompts sent to an instance, newest first, so one can be sent again.
+type PromptsPane struct {
+	viewport viewport.Model
+	width    int
+	height   int
+
+	// title is the instance whose prompts are listed
+	title    string
+	prompts  []session.PromptRecord
+	selected int
+}
+
+// NewPromptsPane creates an empty prompts pane.
+func NewPromptsPane() *PromptsPane {
+	return &PromptsPane{viewport: viewport.New(0, 0)}
+}
+
+func (p *PromptsPane) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+	p.viewport.Width = width
+	p.viewport.Height = height
+	p.render()
+}
+
+// SetPrompts lists the prompt history of instance. The same prompt stays selected as new ones
+// are sent.
+func (p *PromptsPane) SetPrompts(instance *session.Instance) {
+	if instance == nil {
+		p.title = ""
+		p.prompts = nil
+		p.selected = 0
+		p.viewport.SetContent(lipgloss.Place(p.width, p.height, lipgloss.Center, lipgloss.Center, "No prompts"))
+		return
+	}
+	history := instance.PromptHistory()
+	prompts := make([]session.PromptRecord, len(history))
+	for i, record := range history {
+		prompts[len(history)-1-i] = record
+	}
+
+	if instance.Title != p.title {
+		p.title = instance.Title
+		p.selected = 0
+		p.viewport.GotoTop()
+	} else if p.selected < len(p.prompts) {
+		sentAt := p.prompts[p.selected].SentAt
+		p.selected = 0
+		for i, record := range prompts {
+			if record.SentAt.Equal(sentAt) {
+				p.selected = i
+			}
+		}
+	}
+	p.prompts = prompts
+	p.render()
+}
+
+// render shows the list of prompts, the selected one in full.
+func (p *PromptsPane) render() {
+	if p.title == "" {
+		return
+	}
+	if len(p.prompts) == 0 {
+		p.viewport.SetContent(lipgloss.Place(p.width, p.height, lipgloss.Center, lipgloss.Center,
+			"No prompts sent to this session yet"))
+		return
+	}
+
+	lines := []string{commitTimeStyle.Render(fmt.Sprintf("%d prompts sent, e edits and sends the selected one again", len(p.prompts))), ""}
+	selectedLine := 0
+	for i, record := range p.prompts {
+		when := record.SentAt.Local().Format("Jan 2 15:04")
+		if i == p.selected {
+			selectedLine = len(lines)
+			for j, line := range strings.Split(record.Prompt, "\n") {
+				if j == 0 {
+					line = when + " " + line
+				}
+				lines = append(lines, selectedCommitStyle.Render(truncateLine(line, max(p.width, 16))))
+			}
+			continue
+		}
+		prompt := strings.ReplaceAll(record.Prompt, "\n", " ")
+		lines = append(lines, commitTimeStyle.Render(when)+" "+truncateLine(prompt, max(p.width-len(when)-1, 16)))
+	}
+	p.viewport.SetContent(strings.Join(lines, "\n"))
+
+	// Keep the selected prompt in view
+	if selectedLine < p.viewport.YOffset {
+		p.viewport.SetYOffset(selectedLine)
+	} else if selectedLine >= p.viewport.YOffset+p.viewport.Height {
+		p.viewport.SetYOffset(selectedLine - p.viewport.Height + 1)
+	}
+}
+
+// SelectedPrompt returns the prompt selected in the list.
+func (p *PromptsPane) SelectedPrompt() (session.PromptRecord, bool) {
+	if p.selected >= len(p.prompts) {
+		return session.PromptRecord{}, false
+	}
+	return p.prompts[p.selected], true
+}
+
+// ScrollUp selects the next newer prompt.
+func (p *PromptsPane) ScrollUp() {
+	if p.selected > 0 {
+		p.selected--
+		p.render()
+	}
+}
+
+// ScrollDown selects the next older prompt.
+func (p *PromptsPane) ScrollDown() {
+	if p.selected < len(p.prompts)-1 {
+		p.selected++
+		p.render()
+	}
+}
+
+func (p *PromptsPane) String() string {
+	return p.viewport.View()
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"claude-squad/session"
+	"claude-squad/session/git"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -33,6 +34,9 @@ var (
 const (
 	PreviewTab = iota
 	DiffTab
+	ConversationTab
+	CommitsTab
+	PromptsTab
 )
 
 type Tab struct {
@@ -49,18 +53,30 @@ type TabbedWindow struct {
 	height    int
 	width     int
 
-	preview *PreviewPane
-	diff    *DiffPane
+	preview      *PreviewPane
+	diff         *DiffPane
+	conversation *ConversationPane
+	commits      *CommitsPane
+	prompts      *PromptsPane
+
+	// accent colors the border of the window, the accent color of the selected instance
+	accent lipgloss.TerminalColor
 }
 
-func NewTabbedWindow(preview *PreviewPane, diff *DiffPane) *TabbedWindow {
+func NewTabbedWindow(preview *PreviewPane, diff *DiffPane, conversation *ConversationPane, commits *CommitsPane, prompts *PromptsPane) *TabbedWindow {
 	return &TabbedWindow{
 		tabs: []string{
 			"Preview",
 			"Diff",
+			"Conversation",
+			"Commits",
+			"Prompts",
 		},
-		preview: preview,
-		diff:    diff,
+		preview:      preview,
+		diff:         diff,
+		conversation: conversation,
+		commits:      commits,
+		prompts:      prompts,
 	}
 }
 
@@ -69,6 +85,15 @@ func AdjustPreviewWidth(width int) int {
 	return int(float64(width) * 0.9)
 }
 
+// SetAccentColor colors the border with color, the accent color of the selected instance, or the
+// default highlight color if color is empty.
+func (w *TabbedWindow) SetAccentColor(color string) {
+	w.accent = highlightColor
+	if color != "" {
+		w.accent = lipgloss.Color(color)
+	}
+}
+
 func (w *TabbedWindow) SetSize(width, height int) {
 	w.width = AdjustPreviewWidth(width)
 	w.height = height
@@ -83,6 +108,9 @@ func (w *TabbedWindow) SetSize(width, height int) {
 
 	w.preview.SetSize(contentWidth, contentHeight)
 	w.diff.SetSize(contentWidth, contentHeight)
+	w.conversation.SetSize(contentWidth, contentHeight)
+	w.commits.SetSize(contentWidth, contentHeight)
+	w.prompts.SetSize(contentWidth, contentHeight)
 }
 
 func (w *TabbedWindow) GetPreviewSize() (width, height int) {
@@ -108,16 +136,89 @@ func (w *TabbedWindow) UpdateDiff(instance *session.Instance) {
 	w.diff.SetDiff(instance)
 }
 
+// UpdateConversation updates the conversation pane. instance may be nil.
+func (w *TabbedWindow) UpdateConversation(instance *session.Instance) {
+	if w.activeTab != ConversationTab {
+		return
+	}
+	w.conversation.SetConversation(instance)
+}
+
+// UpdateCommits updates the commits pane. instance may be nil.
+func (w *TabbedWindow) UpdateCommits(instance *session.Instance) {
+	if w.activeTab != CommitsTab {
+		return
+	}
+	w.commits.SetCommits(instance)
+}
+
+// UpdatePrompts updates the prompts pane. instance may be nil.
+func (w *TabbedWindow) UpdatePrompts(instance *session.Instance) {
+	if w.activeTab != PromptsTab {
+		return
+	}
+	w.prompts.SetPrompts(instance)
+}
+
+// SelectedPrompt returns the prompt selected in the prompts tab, if it is shown.
+func (w *TabbedWindow) SelectedPrompt() (session.PromptRecord, bool) {
+	if w.activeTab != PromptsTab {
+		return session.PromptRecord{}, false
+	}
+	return w.prompts.SelectedPrompt()
+}
+
+// ToggleCommitPatch shows the patch of the commit selected in the commits tab, or goes back to the
+// list of commits.
+func (w *TabbedWindow) ToggleCommitPatch() error {
+	if w.activeTab != CommitsTab {
+		return nil
+	}
+	return w.commits.TogglePatch()
+}
+
+// SelectedCommit returns the commit selected in the commits tab, if it is shown.
+func (w *TabbedWindow) SelectedCommit() (git.Commit, bool) {
+	if w.activeTab != CommitsTab {
+		return git.Commit{}, false
+	}
+	return w.commits.SelectedCommit()
+}
+
+// ReloadCommits lists the commits of instance again, as after its branch was rewritten.
+func (w *TabbedWindow) ReloadCommits(instance *session.Instance) {
+	w.commits.Reload()
+	w.UpdateCommits(instance)
+}
+
 // Add these new methods for handling scroll events
 func (w *TabbedWindow) ScrollUp() {
-	if w.activeTab == 1 { // Diff tab
+	switch w.activeTab {
+	case DiffTab:
 		w.diff.ScrollUp()
+	case ConversationTab:
+		w.conversation.ScrollUp()
+	case CommitsTab:
+		w.commits.ScrollUp()
+	case PromptsTab:
+		w.prompts.ScrollUp()
+	default:
+		w.preview.ScrollUp()
 	}
 }
 
 func (w *TabbedWindow) ScrollDown() {
-	if w.activeTab == 1 { // Diff tab
+	switch w.activeTab {
+	case DiffTab:
 		w.diff.ScrollDown()
+	case ConversationTab:
+		w.conversation.ScrollDown()
+	case CommitsTab:
+		w.commits.ScrollDown()
+	case PromptsTab:
+		w.prompts.ScrollDown()
+	default:
+		w.preview.ScrollDown()
 	}
 }
 
@@ -126,6 +227,23 @@ func (w *TabbedWindow) IsInDiffTab() bool {
 	return w.activeTab == 1
 }
 
+// IsInCommitsTab returns true if the commits tab is currently active
+func (w *TabbedWindow) IsInCommitsTab() bool {
+	return w.activeTab == CommitsTab
+}
+
+// IsInPromptsTab returns true if the prompts tab is currently active
+func (w *TabbedWindow) IsInPromptsTab() bool {
+	return w.activeTab == PromptsTab
+}
+
+func (w *TabbedWindow) accentColor() lipgloss.TerminalColor {
+	if w.accent == nil {
+		return highlightColor
+	}
+	return w.accent
+}
+
 func (w *TabbedWindow) String() string {
 	if w.width == 0 || w.height == 0 {
 		return ""
@@ -160,19 +278,26 @@ func (w *TabbedWindow) String() string {
 		} else if isLast && !isActive {
 			border.BottomRight = "┤"
 		}
-		style = style.Border(border)
+		style = style.Border(border).BorderForeground(w.accentColor())
 		style = style.Width(width - 1)
 		renderedTabs = append(renderedTabs, style.Render(t))
 	}
 
 	row := lipgloss.JoinHorizontal(lipgloss.Top, renderedTabs...)
 	var content string
-	if w.activeTab == 0 {
+	switch w.activeTab {
+	case PreviewTab:
 		content = w.preview.String()
-	} else {
+	case DiffTab:
 		content = w.diff.String()
+	case CommitsTab:
+		content = w.commits.String()
+	case PromptsTab:
+		content = w.prompts.String()
+	default:
+		content = w.conversation.String()
 	}
-	window := windowStyle.Render(
+	window := windowStyle.BorderForeground(w.accentColor()).Render(
 		lipgloss.Place(
 			w.width, w.height-2-windowStyle.GetVerticalFrameSize()-tabHeight,
 			lipgloss.Left, lipgloss.Top, content))
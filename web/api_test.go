@@ -10,7 +10,6 @@ import (
 	"os"
 	"sync"
 	"testing"
-	"time"
 )
 
 // TestAPIEndpoints tests the API endpoints directly.
@@ -52,10 +51,7 @@ func TestAPIEndpoints(t *testing.T) {
 	
 	// Create server
 	cfg := config.DefaultConfig()
-	server, err := NewServer(storage, cfg)
-	if err != nil {
-		t.Fatalf("Failed to create server: %v", err)
-	}
+	server := NewServer(storage, cfg)
 	
 	// Create test HTTP server
 	ts := httptest.NewServer(server.Handler())
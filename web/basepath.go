@@ -0,0 +1,36 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+)
+
+// basePathHandler serves next under base, such as /claude-squad, for a reverse proxy that
+// forwards that path unchanged. The prefix is stripped before routing, and base itself is
+// redirected to base/ so relative asset paths resolve under it. Requests without the prefix are
+// served as is, for proxies that strip it themselves. An empty base serves next at the root.
+func basePathHandler(base string, next http.Handler) http.Handler {
+	if base == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == base {
+			target := base + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, base+"/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = strings.TrimPrefix(r.URL.Path, base)
+		if r.URL.RawPath != "" {
+			r2.URL.RawPath = strings.TrimPrefix(r.URL.RawPath, base)
+		}
+		next.ServeHTTP(w, r2)
+	})
+}
@@ -0,0 +1,42 @@
+package web
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBasePath(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.WebServerBasePath = "claude-squad/"
+	server := NewServer(&testStorage{instances: make(map[string]*session.Instance)}, cfg)
+
+	tests := []struct {
+		path     string
+		code     int
+		location string
+	}{
+		{"/claude-squad/api/status", http.StatusOK, ""},
+		// Proxies that strip the prefix themselves
+		{"/api/status", http.StatusOK, ""},
+		{"/claude-squad", http.StatusMovedPermanently, "/claude-squad/"},
+		{"/claude-squad?x=1", http.StatusMovedPermanently, "/claude-squad/?x=1"},
+		{"/claude-squadx/api/status", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("GET %s = %d, want %d", tt.path, rec.Code, tt.code)
+		}
+		if got := rec.Header().Get("Location"); got != tt.location {
+			t.Errorf("GET %s redirected to %q, want %q", tt.path, got, tt.location)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package client calls the web server's HTTP and WebSocket API from local programs, such as
+// editor integrations and tray apps. It connects over the server's unix socket when one is
+// configured and over TCP otherwise, so callers don't need to know which one the server uses.
+package client
+
+import (
+	"claude-squad/config"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// Client calls the API of the web server described by a config.
+type Client struct {
+	http    *http.Client
+	dialer  *websocket.Dialer
+	baseURL string
+	token   string
+}
+
+// New creates a client for the web server configured in cfg.
+func New(cfg *config.Config) *Client {
+	c := &Client{dialer: &websocket.Dialer{}}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if socket := cfg.WebServerSocket; socket != "" {
+		// The host is ignored: every connection goes to the socket
+		dial := func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+		transport.DialContext = dial
+		c.dialer.NetDialContext = dial
+		c.baseURL = "http://unix" + cfg.WebBasePath()
+	} else {
+		host := cfg.WebServerHost
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "127.0.0.1"
+		}
+		scheme := "http"
+		if cfg.WebServerUseTLS {
+			scheme = "https"
+			if cfg.WebServerTLSCert == "" {
+				// The server generates a self-signed certificate when none is configured
+				transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+				c.dialer.TLSClientConfig = transport.TLSClientConfig
+			}
+		}
+		c.baseURL = fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(host, fmt.Sprint(cfg.WebServerPort)),
+			cfg.WebBasePath())
+		c.token = cfg.WebServerAuthToken
+	}
+
+	c.http = &http.Client{Transport: transport}
+	return c
+}
+
+// URL returns the URL of an absolute API path such as /api/instances.
+func (c *Client) URL(path string) string {
+	return c.baseURL + path
+}
+
+// Do sends a request for an absolute API path, such as /api/instances, with the auth token when
+// connecting over TCP.
+func (c *Client) Do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.URL(path), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.authorize(req.Header)
+	return c.http.Do(req)
+}
+
+// Get fetches an API path and decodes its JSON response into v. A response status other than
+// 200 is returned as an error with the response body.
+func (c *Client) Get(ctx context.Context, path string, v any) error {
+	resp, err := c.Do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("GET %s: %s: %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// DialWebSocket opens a WebSocket for an absolute path, such as /ws/events.
+func (c *Client) DialWebSocket(ctx context.Context, path string) (*websocket.Conn, error) {
+	url := "ws" + strings.TrimPrefix(c.URL(path), "http")
+	header := http.Header{}
+	c.authorize(header)
+	conn, resp, err := c.dialer.DialContext(ctx, url, header)
+	if resp != nil && resp.Body != nil {
+		resp.Body.Close()
+	}
+	return conn, err
+}
+
+func (c *Client) authorize(header http.Header) {
+	if c.token != "" {
+		header.Set("Authorization", "Bearer "+c.token)
+	}
+}
@@ -0,0 +1,69 @@
+package client_test
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/web"
+	"claude-squad/web/client"
+	"claude-squad/web/mock"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClientOverUnixSocket(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.WebServerSocket = filepath.Join(t.TempDir(), "cs.sock")
+	cfg.WebServerBasePath = "/cs"
+	server := web.NewServer(mock.NewEmptyMockStorage(), cfg)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer server.Stop()
+
+	info, err := os.Stat(cfg.WebServerSocket)
+	if err != nil {
+		t.Fatalf("socket not created: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("socket permissions = %o, want 600", perm)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := client.New(cfg)
+
+	var status struct {
+		Version string `json:"version"`
+	}
+	if err := c.Get(ctx, "/api/status", &status); err != nil {
+		t.Fatalf("GET /api/status: %v", err)
+	}
+	if status.Version == "" {
+		t.Errorf("status has no version")
+	}
+	if err := c.Get(ctx, "/api/instances/missing", &struct{}{}); err == nil {
+		t.Errorf("GET of a missing instance succeeded")
+	}
+
+	conn, err := c.DialWebSocket(ctx, "/ws/events")
+	if err != nil {
+		t.Fatalf("dial /ws/events: %v", err)
+	}
+	conn.Close()
+
+	// A second server can't take over the socket of a running one
+	if err := web.NewServer(mock.NewEmptyMockStorage(), cfg).Start(); err == nil {
+		t.Errorf("second server started on the same socket")
+	}
+
+	server.Stop()
+	if _, err := os.Stat(cfg.WebServerSocket); !os.IsNotExist(err) {
+		t.Errorf("socket not removed on shutdown: %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+// Package demo runs the web server against a set of fake-agent instances so the web UI can be
+// explored without configuring Claude.
+package demo
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/tmux"
+	"claude-squad/web"
+	"claude-squad/web/mock"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// activityInterval is how often each demo instance receives a new prompt.
+const activityInterval = 8 * time.Second
+
+// seed describes one demo instance.
+type seed struct {
+	title   string
+	prompts []string
+	// paused instances are started and then paused so the paused views have content
+	paused bool
+}
+
+var seeds = []seed{
+	{
+		title:   "demo-refactor",
+		prompts: []string{"Refactor the config loader", "Extract a helper for path handling", "Add doc comments"},
+	},
+	{
+		title:   "demo-tests",
+		prompts: []string{"Write table tests for the parser", "Cover the error paths", "Run the test suite"},
+	},
+	{
+		title:   "demo-docs",
+		prompts: []string{"Update the README usage section", "Document the web API"},
+	},
+	{
+		title:  "demo-paused",
+		paused: true,
+	},
+}
+
+// Demo holds the seeded instances and the web server serving them.
+type Demo struct {
+	dir       string
+	storage   *mock.MockStorage
+	server    *web.Server
+	instances []*session.Instance
+	mu        sync.Mutex
+}
+
+// Run seeds the demo instances, starts the web server and drives simulated activity until ctx
+// is cancelled. Everything it creates is cleaned up before it returns.
+func Run(ctx context.Context, cfg *config.Config) error {
+	d, err := start(cfg)
+	if err != nil {
+		return err
+	}
+	defer d.cleanup()
+
+	fmt.Printf("Demo web UI running at %s (Ctrl+C to stop)\n", cfg.WebServerAddress())
+	d.simulate(ctx)
+	return nil
+}
+
+func start(cfg *config.Config) (*Demo, error) {
+	program, err := fakeAgentProgram()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "claudesquad-demo-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create demo directory: %w", err)
+	}
+
+	d := &Demo{
+		dir:     dir,
+		storage: mock.NewEmptyMockStorage(),
+	}
+
+	repo, err := initRepo(dir)
+	if err != nil {
+		d.cleanup()
+		return nil, err
+	}
+
+	for _, s := range seeds {
+		instance, err := d.startInstance(s, repo, program)
+		if err != nil {
+			d.cleanup()
+			return nil, fmt.Errorf("failed to start demo instance %s: %w", s.title, err)
+		}
+		d.instances = append(d.instances, instance)
+		if err := d.storage.AddInstance(instance); err != nil {
+			d.cleanup()
+			return nil, err
+		}
+	}
+
+	d.server = web.NewServer(d.storage, cfg)
+	if err := d.server.Start(); err != nil {
+		d.cleanup()
+		return nil, fmt.Errorf("failed to start web server: %w", err)
+	}
+	return d, nil
+}
+
+// fakeAgentProgram returns the command that runs this binary's fake-agent subcommand.
+func fakeAgentProgram() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate claude-squad binary: %w", err)
+	}
+	return fmt.Sprintf("%q %s --delay=300ms --prompt-every=2", exe, tmux.ProgramFakeAgent), nil
+}
+
+// initRepo creates a scratch git repository for the demo worktrees.
+func initRepo(dir string) (string, error) {
+	repo := filepath.Join(dir, "repo")
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		return "", fmt.Errorf("failed to create demo repo: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("# Demo project\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write demo README: %w", err)
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=claude-squad", "-c", "user.email=demo@claude-squad.local", "commit", "-q", "-m", "Initial commit"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("git %s failed: %s (%w)", strings.Join(args, " "), out, err)
+		}
+	}
+	return repo, nil
+}
+
+func (d *Demo) startInstance(s seed, repo, program string) (*session.Instance, error) {
+	// Remove leftovers from a demo that didn't shut down cleanly
+	if tmux.DoesSessionExist(tmux.ToClaudeSquadTmuxName(s.title)) {
+		_ = tmux.NewTmuxSession(s.title, program).Close()
+	}
+
+	instance, err := session.NewInstance(session.InstanceOptions{
+		Title:   s.title,
+		Path:    repo,
+		Program: program,
+		AutoYes: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := instance.Start(true); err != nil {
+		return nil, err
+	}
+	if s.paused {
+		if err := instance.Pause(); err != nil {
+			log.FileOnlyWarningLog.Printf("demo: could not pause %s: %v", s.title, err)
+		}
+	}
+	return instance, nil
+}
+
+// simulate sends prompts to the running instances, accepts their permission prompts and writes
+// files into their worktrees so the diff views have content.
+func (d *Demo) simulate(ctx context.Context) {
+	ticker := time.NewTicker(activityInterval)
+	defer ticker.Stop()
+	promptTicker := time.NewTicker(500 * time.Millisecond)
+	defer promptTicker.Stop()
+
+	turn := 0
+	d.step(turn)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-promptTicker.C:
+			d.mu.Lock()
+			for _, instance := range d.instances {
+				if instance.Paused() {
+					continue
+				}
+				if _, hasPrompt := instance.HasUpdated(); hasPrompt {
+					instance.TapEnter()
+				}
+				if err := instance.UpdateDiffStats(); err != nil {
+					log.FileOnlyWarningLog.Printf("demo: diff stats for %s: %v", instance.Title, err)
+				}
+			}
+			d.mu.Unlock()
+		case <-ticker.C:
+			turn++
+			d.step(turn)
+		}
+	}
+}
+
+// step sends the next scripted prompt to every running instance.
+func (d *Demo) step(turn int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i, instance := range d.instances {
+		s := seeds[i]
+		if instance.Paused() || len(s.prompts) == 0 {
+			continue
+		}
+		prompt := s.prompts[turn%len(s.prompts)]
+		if err := instance.SendPrompt(prompt); err != nil {
+			log.FileOnlyWarningLog.Printf("demo: failed to send prompt to %s: %v", instance.Title, err)
+			continue
+		}
+
+		worktree, err := instance.GetGitWorktree()
+		if err != nil {
+			continue
+		}
+		notes := filepath.Join(worktree.GetWorktreePath(), "NOTES.md")
+		f, err := os.OpenFile(notes, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(f, "- %s\n", prompt)
+		f.Close()
+	}
+}
+
+func (d *Demo) cleanup() {
+	if d.server != nil {
+		if err := d.server.Stop(); err != nil {
+			log.FileOnlyErrorLog.Printf("demo: error stopping web server: %v", err)
+		}
+	}
+
+	d.mu.Lock()
+	for _, instance := range d.instances {
+		if err := instance.Kill(); err != nil {
+			log.FileOnlyErrorLog.Printf("demo: error killing %s: %v", instance.Title, err)
+		}
+	}
+	d.instances = nil
+	d.mu.Unlock()
+
+	if err := os.RemoveAll(d.dir); err != nil {
+		log.FileOnlyErrorLog.Printf("demo: error removing %s: %v", d.dir, err)
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ArchiveHandler streams a zip of the files of an instance's worktree, with its uncommitted
+// changes, so the work can be reviewed without access to the repository.
+func ArchiveHandler(storage session.InstanceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			http.Error(w, "Instance name required", http.StatusBadRequest)
+			return
+		}
+
+		instance, err := findInstanceByTitle(storage, name)
+		if err != nil {
+			http.Error(w, "Instance not found", http.StatusNotFound)
+			return
+		}
+		worktree, err := instance.GetGitWorktree()
+		if err != nil {
+			http.Error(w, "Instance is not running in a worktree", http.StatusBadRequest)
+			return
+		}
+
+		filename := strings.ReplaceAll(worktree.GetBranchName(), "/", "-") + ".zip"
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		// The status is sent with the first bytes, so later errors can only cut the zip short
+		if err := worktree.WriteArchive(r.Context(), w); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error archiving %s: %v", name, err)
+			return
+		}
+		log.FileOnlyInfoLog.Printf("API: '%s' archive downloaded from %s", name, r.RemoteAddr)
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/mock"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestArchiveHandler(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skipf("git not found in PATH: %v", err)
+	}
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	write("a.txt", "a\n")
+	write("deleted.txt", "d\n")
+	write(".gitignore", "*.log\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "base")
+	base := git("rev-parse", "HEAD")
+	write("a.txt", "changed\n")
+	write("new.txt", "n\n")
+	write("debug.log", "ignored\n")
+	if err := os.Remove(filepath.Join(repo, "deleted.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	storage := mock.NewEmptyMockStorage()
+	if err := storage.AddInstance(session.FromInstanceMetadata(session.InstanceData{
+		Title:    "task",
+		Worktree: session.GitWorktreeData{RepoPath: repo, WorktreePath: repo, BranchName: "user/task", BaseCommitSHA: base},
+	})); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/instances/task/archive", nil)
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("name", "task")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+	rec := httptest.NewRecorder()
+	ArchiveHandler(storage)(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); !strings.Contains(disposition, `"user-task.zip"`) {
+		t.Errorf("Content-Disposition = %q, want user-task.zip", disposition)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	var names []string
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(content)
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	want := "user-task/.gitignore,user-task/a.txt,user-task/new.txt"
+	if strings.Join(names, ",") != want {
+		t.Errorf("archived %v, want %s", names, want)
+	}
+	if files["user-task/a.txt"] != "changed\n" {
+		t.Errorf("a.txt = %q, want the uncommitted change", files["user-task/a.txt"])
+	}
+
+	// A client that went away stops the archive
+	ctx, cancel := context.WithCancel(req.Context())
+	cancel()
+	rec = httptest.NewRecorder()
+	ArchiveHandler(storage)(rec, req.WithContext(ctx))
+	if _, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len())); err == nil {
+		t.Error("the archive was written for a canceled request")
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"claude-squad/audit"
+	"claude-squad/log"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// defaultAuditLimit is the number of audit entries returned when no limit is given.
+	defaultAuditLimit = 100
+	// maxAuditLimit caps the number of audit entries returned.
+	maxAuditLimit = 1000
+)
+
+// AuditTrail is the response of the audit endpoint.
+type AuditTrail struct {
+	// Entries are the actions taken through the web server, newest first.
+	Entries []audit.Entry `json:"entries"`
+}
+
+// AuditHandler returns the latest entries of the audit trail, newest first. instance keeps the
+// entries of one instance and limit caps their number.
+func AuditHandler(trail *audit.Log) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultAuditLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxAuditLimit {
+				http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		entries, err := trail.Query(r.URL.Query().Get("instance"), limit)
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error reading the audit trail: %v", err)
+			http.Error(w, "Error reading the audit trail", http.StatusInternalServerError)
+			return
+		}
+		writeTemplateJSON(w, http.StatusOK, AuditTrail{Entries: entries})
+	}
+}
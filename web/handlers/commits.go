@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// A commits request returns defaultCommitLimit commits unless asked otherwise, and at most
+// maxCommitLimit.
+const (
+	defaultCommitLimit = 50
+	maxCommitLimit     = 500
+)
+
+// InstanceCommits is the response of the commits endpoint.
+type InstanceCommits struct {
+	Instance string `json:"instance"`
+	Branch   string `json:"branch"`
+	// Commits are the commits made on the branch since the worktree was created, newest first.
+	Commits []git.Commit `json:"commits"`
+}
+
+// CommitsHandler lists the commits made on the branch of an instance since its worktree was
+// created, with the lines each changed. limit caps how many are returned.
+func CommitsHandler(storage session.InstanceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			http.Error(w, "Instance name required", http.StatusBadRequest)
+			return
+		}
+
+		limit := defaultCommitLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxCommitLimit {
+				http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		instance, err := findInstanceByTitle(storage, name)
+		if err != nil {
+			http.Error(w, "Instance not found", http.StatusNotFound)
+			return
+		}
+		worktree, err := instance.GetGitWorktree()
+		if err != nil {
+			http.Error(w, "Instance is not running in a worktree", http.StatusBadRequest)
+			return
+		}
+
+		commits, err := worktree.Commits(limit)
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error listing commits of %s: %v", name, err)
+			http.Error(w, "Error listing commits", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(InstanceCommits{Instance: name, Branch: worktree.GetBranchName(), Commits: commits}); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error encoding commits: %v", err)
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
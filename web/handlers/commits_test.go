@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/mock"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCommitsHandler(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skipf("git not found in PATH: %v", err)
+	}
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "base")
+	base := git("rev-parse", "HEAD")
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("a\nb\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "Add a")
+
+	storage := mock.NewEmptyMockStorage()
+	// Restored from metadata, the instance counts as started in the worktree of its data
+	if err := storage.AddInstance(session.FromInstanceMetadata(session.InstanceData{
+		Title:    "task",
+		Worktree: session.GitWorktreeData{RepoPath: repo, WorktreePath: repo, BranchName: "task", BaseCommitSHA: base},
+	})); err != nil {
+		t.Fatal(err)
+	}
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/instances/task/commits"+query, nil)
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("name", "task")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+		rec := httptest.NewRecorder()
+		CommitsHandler(storage)(rec, req)
+		return rec
+	}
+
+	for _, query := range []string{"?limit=0", "?limit=501", "?limit=x"} {
+		if rec := get(query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rec.Code)
+		}
+	}
+	rec := get("")
+	var response InstanceCommits
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status %d, %v", rec.Code, err)
+	}
+	if len(response.Commits) != 1 || response.Commits[0].Subject != "Add a" || response.Commits[0].Insertions != 2 {
+		t.Errorf("commits = %+v, want Add a with 2 insertions", response.Commits)
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"encoding/json"
+	"net/http"
+)
+
+// InstanceComparison is the response of the compare endpoint.
+type InstanceComparison struct {
+	A string `json:"a"`
+	B string `json:"b"`
+	git.Comparison
+}
+
+// CompareHandler compares the changes of the instances named by the a and b query parameters:
+// files changed by both with their conflicting hunks, and files changed by only one of them.
+func CompareHandler(storage session.InstanceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		nameA, nameB := r.URL.Query().Get("a"), r.URL.Query().Get("b")
+		if nameA == "" || nameB == "" {
+			http.Error(w, "Query parameters a and b are required", http.StatusBadRequest)
+			return
+		}
+		if nameA == nameB {
+			http.Error(w, "Cannot compare an instance with itself", http.StatusBadRequest)
+			return
+		}
+
+		instanceA, err := findInstanceByTitle(storage, nameA)
+		if err != nil {
+			http.Error(w, "Instance not found: "+nameA, http.StatusNotFound)
+			return
+		}
+		instanceB, err := findInstanceByTitle(storage, nameB)
+		if err != nil {
+			http.Error(w, "Instance not found: "+nameB, http.StatusNotFound)
+			return
+		}
+		for _, instance := range []*session.Instance{instanceA, instanceB} {
+			if !instance.Started() || instance.Paused() || instance.InPlace {
+				http.Error(w, "Instance is not running in a worktree: "+instance.Title, http.StatusBadRequest)
+				return
+			}
+		}
+
+		comparison, err := session.CompareInstances(instanceA, instanceB)
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error comparing %s and %s: %v", nameA, nameB, err)
+			http.Error(w, "Error comparing instances", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(InstanceComparison{A: nameA, B: nameB, Comparison: *comparison}); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error encoding comparison: %v", err)
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"bufio"
+	"claude-squad/log"
+	"claude-squad/web/types"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnectionStats reports the traffic and latency of one open WebSocket connection.
+type ConnectionStats struct {
+	ID string `json:"id"`
+	// Kind is terminal, multiplex, layout or events.
+	Kind string `json:"kind"`
+	// Target is the instance of a terminal or event stream, or the instances streamed over a
+	// multiplexed or layout connection, separated by commas.
+	Target      string    `json:"target,omitempty"`
+	Remote      string    `json:"remote"`
+	ConnectedAt time.Time `json:"connected_at"`
+	// BytesSent counts the bytes written to the network, after compression.
+	BytesSent uint64 `json:"bytes_sent"`
+	// FramesSent counts the terminal updates sent, continuation chunks included.
+	FramesSent uint64 `json:"frames_sent"`
+	// FramesCoalesced counts the terminal updates skipped because a newer capture of the same pane
+	// was queued behind them, as happens when the client reads slower than output changes.
+	FramesCoalesced uint64 `json:"frames_coalesced"`
+	// PingRTT is the round trip of the last ping answered, and PingRTTAvg the average of all of
+	// them, in milliseconds. Both are 0 until a ping was answered.
+	PingRTT    float64 `json:"ping_rtt_ms"`
+	PingRTTAvg float64 `json:"ping_rtt_avg_ms"`
+	Pings      uint64  `json:"pings"`
+}
+
+// connStats tracks the statistics of an open connection. It is safe for concurrent use.
+type connStats struct {
+	id, kind, remote string
+	connectedAt      time.Time
+
+	bytesSent       atomic.Uint64
+	framesSent      atomic.Uint64
+	framesCoalesced atomic.Uint64
+
+	mu       sync.Mutex
+	target   string
+	pingSent time.Time
+	lastRTT  time.Duration
+	totalRTT time.Duration
+	pings    uint64
+}
+
+// openConnections holds the statistics of every open WebSocket connection.
+var openConnections = struct {
+	mu    sync.Mutex
+	next  uint64
+	conns map[string]*connStats
+}{conns: make(map[string]*connStats)}
+
+// trackConnection starts tracking a connection of kind to target from r. The returned stats must
+// be closed when the connection ends, and w is to be upgraded in place of the ResponseWriter so
+// the bytes written to the network are counted.
+func trackConnection(w http.ResponseWriter, r *http.Request, kind, target string) (http.ResponseWriter, *connStats) {
+	openConnections.mu.Lock()
+	defer openConnections.mu.Unlock()
+	openConnections.next++
+	s := &connStats{
+		id:          fmt.Sprintf("%s-%d", kind, openConnections.next),
+		kind:        kind,
+		remote:      r.RemoteAddr,
+		connectedAt: time.Now(),
+		target:      target,
+	}
+	openConnections.conns[s.id] = s
+	return countingResponseWriter{ResponseWriter: w, stats: s}, s
+}
+
+// OpenConnections returns the statistics of every open WebSocket connection, oldest first.
+func OpenConnections() []ConnectionStats {
+	openConnections.mu.Lock()
+	conns := make([]*connStats, 0, len(openConnections.conns))
+	for _, s := range openConnections.conns {
+		conns = append(conns, s)
+	}
+	openConnections.mu.Unlock()
+
+	stats := make([]ConnectionStats, 0, len(conns))
+	for _, s := range conns {
+		stats = append(stats, s.snapshot())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ConnectedAt.Before(stats[j].ConnectedAt) })
+	return stats
+}
+
+// setTarget changes what the connection streams, such as after a multiplexed client subscribed.
+func (s *connStats) setTarget(target string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.target = target
+}
+
+// sentFrames counts n messages sent.
+func (s *connStats) sentFrames(n int) {
+	s.framesSent.Add(uint64(n))
+}
+
+// coalesced counts n terminal updates skipped for a newer one.
+func (s *connStats) coalesced(n int) {
+	s.framesCoalesced.Add(uint64(n))
+}
+
+// pinged records that a ping was sent.
+func (s *connStats) pinged() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pingSent = time.Now()
+}
+
+// ponged records the answer to the last ping.
+func (s *connStats) ponged() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pingSent.IsZero() {
+		return
+	}
+	s.lastRTT = time.Since(s.pingSent)
+	s.totalRTT += s.lastRTT
+	s.pings++
+	s.pingSent = time.Time{}
+}
+
+func (s *connStats) snapshot() ConnectionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := ConnectionStats{
+		ID:              s.id,
+		Kind:            s.kind,
+		Target:          s.target,
+		Remote:          s.remote,
+		ConnectedAt:     s.connectedAt,
+		BytesSent:       s.bytesSent.Load(),
+		FramesSent:      s.framesSent.Load(),
+		FramesCoalesced: s.framesCoalesced.Load(),
+		PingRTT:         milliseconds(s.lastRTT),
+		Pings:           s.pings,
+	}
+	if s.pings > 0 {
+		stats.PingRTTAvg = milliseconds(s.totalRTT / time.Duration(s.pings))
+	}
+	return stats
+}
+
+// close stops tracking the connection and logs its statistics.
+func (s *connStats) close() {
+	stats := s.snapshot()
+	log.FileOnlyInfoLog.Printf("WebSocket: %s connection %s from %s closed after %s: %d bytes and %d frames sent, "+
+		"%d frames coalesced, ping RTT %.1fms (average %.1fms over %d pings)",
+		stats.Kind, stats.ID, stats.Remote, time.Since(stats.ConnectedAt).Round(time.Second), stats.BytesSent,
+		stats.FramesSent, stats.FramesCoalesced, stats.PingRTT, stats.PingRTTAvg, stats.Pings)
+
+	openConnections.mu.Lock()
+	defer openConnections.mu.Unlock()
+	delete(openConnections.conns, s.id)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// countingResponseWriter hands the WebSocket upgrader a network connection counting the bytes
+// written to it.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	stats *connStats
+}
+
+func (w countingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	return countingConn{Conn: conn, stats: w.stats}, brw, nil
+}
+
+type countingConn struct {
+	net.Conn
+	stats *connStats
+}
+
+func (c countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.stats.bytesSent.Add(uint64(n))
+	return n, err
+}
+
+// coalesce relays updates to the returned channel until ctx is done or updates is closed. While
+// the reader is busy, such as sending to a slow client, updates queue up here. Every update
+// carries the whole pane, so only the latest queued capture is kept and the others are counted as
+// coalesced in stats; notifications are always relayed, in order.
+func coalesce(ctx context.Context, updates <-chan types.TerminalUpdate, stats *connStats) <-chan types.TerminalUpdate {
+	out := make(chan types.TerminalUpdate)
+	go func() {
+		defer close(out)
+		var pending []types.TerminalUpdate
+		for {
+			// Only offer an update when there is one
+			var send chan types.TerminalUpdate
+			var next types.TerminalUpdate
+			if len(pending) > 0 {
+				send, next = out, pending[0]
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case update, ok := <-updates:
+				if !ok {
+					for _, update := range pending {
+						select {
+						case out <- update:
+						case <-ctx.Done():
+							return
+						}
+					}
+					return
+				}
+				if n := len(pending); n > 0 && pending[n-1].Type == "" && update.Type == "" && len(update.Content) > 0 {
+					pending[n-1] = update
+					stats.coalesced(1)
+					continue
+				}
+				pending = append(pending, update)
+			case send <- next:
+				pending = pending[1:]
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/web/types"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestCoalesce(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, stats := trackConnection(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "terminal", "test")
+	defer stats.close()
+
+	// Everything is queued before the reader starts, as with a slow client
+	updates := make(chan types.TerminalUpdate, 8)
+	updates <- types.TerminalUpdate{Content: "one"}
+	updates <- types.TerminalUpdate{Content: "two"}
+	updates <- types.TerminalUpdate{Type: types.EventInstanceRemoved, Message: "gone"}
+	updates <- types.TerminalUpdate{Content: "three"}
+	updates <- types.TerminalUpdate{Content: "four"}
+	close(updates)
+	out := coalesce(ctx, updates, stats)
+	// Give the relay time to queue everything
+	time.Sleep(50 * time.Millisecond)
+
+	var got []string
+	for update := range out {
+		if update.Type != "" {
+			got = append(got, update.Message)
+			continue
+		}
+		got = append(got, update.Content)
+	}
+	if strings.Join(got, ",") != "two,gone,four" {
+		t.Errorf("relayed %v, want [two gone four]", got)
+	}
+	if coalesced := stats.snapshot().FramesCoalesced; coalesced != 2 {
+		t.Errorf("coalesced %d frames, want 2", coalesced)
+	}
+}
+
+func TestTrackConnection(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		w, stats := trackConnection(w, r, "terminal", "test")
+		defer stats.close()
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetPongHandler(func(string) error {
+			stats.ponged()
+			return nil
+		})
+		if err := writeTerminalUpdate(conn, types.TerminalUpdate{Content: "hello"}, stats); err != nil {
+			return
+		}
+		stats.pinged()
+		conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reading answers the ping
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	var stats ConnectionStats
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		for _, s := range OpenConnections() {
+			if s.Target == "test" {
+				stats = s
+			}
+		}
+		if stats.Pings > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ping never answered: %+v", stats)
+		}
+	}
+	if stats.Kind != "terminal" || stats.FramesSent != 1 || stats.BytesSent == 0 {
+		t.Errorf("stats = %+v, want one terminal frame and the bytes sent", stats)
+	}
+
+	conn.Close()
+	<-done
+	for _, s := range OpenConnections() {
+		if s.ID == stats.ID {
+			t.Errorf("connection %s still listed after closing", s.ID)
+		}
+	}
+}
+
+// waitClosed waits until no connection of kind is open, so its handler logged its statistics
+// before the next test initializes the log again.
+func waitClosed(t *testing.T, kind string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		open := false
+		for _, s := range OpenConnections() {
+			open = open || s.Kind == kind
+		}
+		if !open {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s connection still open", kind)
+		}
+	}
+}
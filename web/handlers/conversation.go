@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"claude-squad/conversation"
+	"claude-squad/log"
+	"claude-squad/session"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// InstanceConversation is the response of the conversation endpoint.
+type InstanceConversation struct {
+	Instance string `json:"instance"`
+	conversation.Conversation
+	// TotalTurns is the number of turns before limit was applied.
+	TotalTurns int `json:"total_turns"`
+}
+
+// ConversationHandler returns the Claude Code conversation of an instance, parsed from the session
+// file Claude Code writes for the instance's directory. limit keeps the last turns.
+func ConversationHandler(storage session.InstanceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			http.Error(w, "Instance name required", http.StatusBadRequest)
+			return
+		}
+
+		limit := 0
+		if value := r.URL.Query().Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		instance, err := findInstanceByTitle(storage, name)
+		if err != nil {
+			http.Error(w, "Instance not found", http.StatusNotFound)
+			return
+		}
+		dir := instance.WorkDir()
+		if dir == "" {
+			http.Error(w, "Instance has not started", http.StatusBadRequest)
+			return
+		}
+
+		conv, err := conversation.Load(dir)
+		if err != nil {
+			if errors.Is(err, conversation.ErrNoConversation) {
+				http.Error(w, "No Claude Code conversation for this instance", http.StatusNotFound)
+				return
+			}
+			log.FileOnlyErrorLog.Printf("API: Error loading conversation of %s: %v", name, err)
+			http.Error(w, "Error loading conversation", http.StatusInternalServerError)
+			return
+		}
+
+		response := InstanceConversation{Instance: name, Conversation: *conv, TotalTurns: len(conv.Turns)}
+		if limit > 0 && len(response.Turns) > limit {
+			response.Turns = response.Turns[len(response.Turns)-limit:]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error encoding conversation: %v", err)
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
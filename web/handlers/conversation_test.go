@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/mock"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestConversationHandlerErrors(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	storage := mock.NewEmptyMockStorage()
+	instance, err := session.FromInstanceData(session.InstanceData{Title: "task", Status: session.Paused})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.AddInstance(instance); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name, instance, query string
+		want                  int
+	}{
+		{"unknown instance", "missing", "", http.StatusNotFound},
+		{"not started", "task", "", http.StatusBadRequest},
+		{"invalid limit", "task", "?limit=-1", http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/instances/"+tc.instance+"/conversation"+tc.query, nil)
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("name", tc.instance)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+		rec := httptest.NewRecorder()
+		ConversationHandler(storage)(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, rec.Code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/middleware"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// maxInstanceCreateBytes caps the size of an instance creation request body.
+const maxInstanceCreateBytes = 64 << 10
+
+// InstanceCreate is the body of a request creating an instance.
+type InstanceCreate struct {
+	Title string `json:"title"`
+	// Path is a directory of the repository to create the instance in. Defaults to the server's
+	// working directory.
+	Path string `json:"path,omitempty"`
+	// Program runs in the instance. Defaults to the configured program.
+	Program string `json:"program,omitempty"`
+	// Branch is an existing branch, of the repository or one of its remotes, to check out instead
+	// of creating a branch, such as the branch of a pull request. It is kept when the instance is
+	// killed.
+	Branch string `json:"branch,omitempty"`
+	// BaseBranch is the branch a new branch is created from. Defaults to HEAD.
+	BaseBranch string `json:"base_branch,omitempty"`
+	// VCS is the kind of workspace: "git" for a worktree, or "dir" for a copy of Path, which
+	// needn't be a git repository and has no branch. Defaults to the configured kind.
+	VCS string `json:"vcs,omitempty"`
+	// GitConfig holds git settings for the instance's worktree alone, such as user.email or
+	// http.proxy, on top of those of the config.
+	GitConfig map[string]string `json:"git_config,omitempty"`
+	// Template names the session template of the config the instance is launched with: its
+	// program, auto-yes setting, branch prefix, worktree directory and prompt.
+	Template string `json:"template,omitempty"`
+}
+
+// InstanceCreateHandler creates and starts an instance with factory, saves the instances and
+// responds with its InstanceDetail. A running TUI lists the instance once it has started.
+func InstanceCreateHandler(storage session.InstanceStore, factory *session.Factory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var create InstanceCreate
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxInstanceCreateBytes)).Decode(&create); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if create.Branch != "" && create.BaseBranch != "" {
+			http.Error(w, "branch and base_branch can't both be set", http.StatusBadRequest)
+			return
+		}
+		if create.Path == "" {
+			create.Path = "."
+		}
+
+		instances, err := storage.LoadInstances()
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error loading instances for create: %v", err)
+			http.Error(w, "Error loading instances", http.StatusInternalServerError)
+			return
+		}
+		for _, instance := range instances {
+			if instance.Title == create.Title {
+				http.Error(w, "Instance already exists", http.StatusConflict)
+				return
+			}
+		}
+		if err := session.ValidateTitle(create.Title, instances); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		instance, err := factory.New(session.InstanceOptions{
+			Title:      create.Title,
+			Path:       create.Path,
+			Program:    create.Program,
+			Branch:     create.Branch,
+			BaseBranch: create.BaseBranch,
+			VCS:        create.VCS,
+			GitConfig:  create.GitConfig,
+			Template:   create.Template,
+		})
+		if errors.Is(err, session.ErrRepositoryNotAllowed) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := instance.StartContext(r.Context(), true); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error starting '%s': %v", create.Title, err)
+			http.Error(w, "Error starting instance: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if prompt := instance.TemplatePrompt(); prompt != "" {
+			if err := instance.SendPrompt(prompt); err != nil {
+				log.FileOnlyWarningLog.Printf("API: Error sending the template prompt to '%s': %v", create.Title, err)
+			}
+		}
+		if err := storage.SaveInstances(append(instances, instance)); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error saving new instance '%s': %v", create.Title, err)
+			http.Error(w, "Error saving instance", http.StatusInternalServerError)
+			return
+		}
+		log.FileOnlyInfoLog.Printf("API: '%s' created from %s", create.Title, r.RemoteAddr)
+
+		writeTemplateJSON(w, http.StatusCreated, instanceToDetail(instance, middleware.Operator(r)))
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/mock"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestInstanceCreateHandlerRefuses(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skipf("git not found in PATH: %v", err)
+	}
+	repo, denied := t.TempDir(), t.TempDir()
+	for _, dir := range []string{repo, denied} {
+		if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+			t.Fatalf("git init: %v\n%s", err, out)
+		}
+	}
+
+	storage := mock.NewEmptyMockStorage()
+	if err := storage.AddInstance(session.FromInstanceMetadata(session.InstanceData{Title: "task"})); err != nil {
+		t.Fatal(err)
+	}
+	factory := &session.Factory{Program: "sh", Repositories: config.RepositoryPolicy{Deny: []string{denied}}}
+	create := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/instances", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		InstanceCreateHandler(storage, factory)(rec, req)
+		return rec
+	}
+
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"title": `, http.StatusBadRequest},
+		{`{"title": ""}`, http.StatusBadRequest},
+		{`{"title": "task"}`, http.StatusConflict},
+		{`{"title": "pr", "branch": "a", "base_branch": "b"}`, http.StatusBadRequest},
+		{`{"title": "pr", "path": "` + repo + `", "branch": "missing"}`, http.StatusBadRequest},
+		{`{"title": "pr", "path": "` + denied + `"}`, http.StatusForbidden},
+		{`{"title": "pr", "template": "missing"}`, http.StatusBadRequest},
+		{`{"title": "pr", "notes": "` + strings.Repeat("x", maxInstanceCreateBytes) + `"}`, http.StatusRequestEntityTooLarge},
+	} {
+		if rec := create(tc.body); rec.Code != tc.want {
+			t.Errorf("%.60s: status = %d (%s), want %d", tc.body, rec.Code, strings.TrimSpace(rec.Body.String()), tc.want)
+		}
+	}
+	if instances, _ := storage.LoadInstances(); len(instances) != 1 {
+		t.Errorf("%d instances saved, want only the existing one", len(instances))
+	}
+}
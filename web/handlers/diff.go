@@ -42,10 +42,12 @@ type WebDiffStats struct {
 	Added   int        `json:"added"`
 	Removed int        `json:"removed"`
 	Files   []FileDiff `json:"files"`
+	// Base describes the commit the changes are diffed against, set per instance in the TUI.
+	Base string `json:"base,omitempty"`
 }
 
 // DiffHandler handles getting git diff information for a specific instance.
-func DiffHandler(storage *session.Storage) http.HandlerFunc {
+func DiffHandler(storage session.InstanceStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		name := chi.URLParam(r, "name")
 		if name == "" {
@@ -69,7 +71,7 @@ func DiffHandler(storage *session.Storage) http.HandlerFunc {
 		// Get diff stats
 		diffStats := instance.GetDiffStats()
 		if diffStats == nil {
-			log.ErrorLog.Printf("Error getting diff stats: %v", err)
+			log.FileOnlyErrorLog.Printf("Error getting diff stats: %v", err)
 			http.Error(w, "Error getting diff stats", http.StatusInternalServerError)
 			return
 		}
@@ -85,7 +87,7 @@ func DiffHandler(storage *session.Storage) http.HandlerFunc {
 			return
 		}
 		
-		// Get format parameter (raw, parsed, stats)
+		// Get format parameter (raw, parsed, stats, patch)
 		format := r.URL.Query().Get("format")
 		if format == "" {
 			format = "parsed"
@@ -103,20 +105,40 @@ func DiffHandler(storage *session.Storage) http.HandlerFunc {
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"added":   diffStats.Added,
 				"removed": diffStats.Removed,
+				"base":    diffStats.Base,
 			})
 			
 		case "parsed":
 			// Parse and structure the diff
 			webDiff, err := parseDiffOutput(diffStats.Content, diffStats.Added, diffStats.Removed)
 			if err != nil {
-				log.ErrorLog.Printf("Error parsing diff: %v", err)
+				log.FileOnlyErrorLog.Printf("Error parsing diff: %v", err)
 				http.Error(w, "Error parsing diff", http.StatusInternalServerError)
 				return
 			}
+			webDiff.Base = diffStats.Base
 			
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(webDiff)
 			
+		case "patch":
+			// Return a patch to apply with git apply, computed afresh with new and binary files
+			worktree, err := instance.GetGitWorktree()
+			if err != nil {
+				http.Error(w, "Instance is not running in a worktree", http.StatusBadRequest)
+				return
+			}
+			patch, err := worktree.Patch()
+			if err != nil {
+				log.FileOnlyErrorLog.Printf("Error creating patch of %s: %v", name, err)
+				http.Error(w, "Error creating patch", http.StatusInternalServerError)
+				return
+			}
+			filename := strings.ReplaceAll(worktree.GetBranchName(), "/", "-") + ".patch"
+			w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+			w.Write([]byte(patch))
+			
 		default:
 			http.Error(w, "Invalid format parameter", http.StatusBadRequest)
 		}
@@ -124,7 +146,7 @@ func DiffHandler(storage *session.Storage) http.HandlerFunc {
 }
 
 // DiffHistoryHandler handles getting historical snapshots of diffs.
-func DiffHistoryHandler(storage *session.Storage) http.HandlerFunc {
+func DiffHistoryHandler(storage session.InstanceStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// TODO: Implement diff history tracking
 		http.Error(w, "Diff history not implemented", http.StatusNotImplemented)
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/types"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// eventStreamKeepAlive is how often a comment is sent on an idle event stream, so proxies don't
+// close it.
+const eventStreamKeepAlive = 30 * time.Second
+
+// StreamEvent is an instance lifecycle event sent on the event stream.
+type StreamEvent struct {
+	// Type is the event name: instance_removed for a killed instance, instance_paused for a paused
+	// one, like the WebSocket notifications, and the session event type with its dot replaced by
+	// an underscore for the others, e.g. instance_created.
+	Type           string    `json:"type"`
+	Instance       string    `json:"instance"`
+	Program        string    `json:"program"`
+	Branch         string    `json:"branch,omitempty"`
+	Ticket         string    `json:"ticket,omitempty"`
+	Tags           []string  `json:"tags,omitempty"`
+	Color          string    `json:"color"`
+	Status         string    `json:"status"`
+	PreviousStatus string    `json:"previous_status,omitempty"`
+	Notes          string    `json:"notes,omitempty"`
+	Command        string    `json:"command,omitempty"`
+	Attempt        int       `json:"attempt,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Time           time.Time `json:"time"`
+	// Notification is set on the event WebSocket for the types the client asked to be notified of.
+	Notification *Notification `json:"notification,omitempty"`
+}
+
+// newStreamEvent returns the stream event of e.
+func newStreamEvent(e session.Event) StreamEvent {
+	return StreamEvent{
+		Type:           streamEventType(e.Type),
+		Instance:       e.Instance,
+		Program:        e.Program,
+		Branch:         e.Branch,
+		Ticket:         e.Ticket,
+		Tags:           e.Tags,
+		Color:          e.Color,
+		Status:         e.Status,
+		PreviousStatus: e.PreviousStatus,
+		Notes:          e.Notes,
+		Command:        e.Command,
+		Attempt:        e.Attempt,
+		Error:          e.Error,
+		Time:           e.Time,
+	}
+}
+
+// streamEventType returns the name of events of type t on the event stream.
+func streamEventType(t session.EventType) string {
+	switch t {
+	case session.EventKilled:
+		return types.EventInstanceRemoved
+	case session.EventPaused:
+		return types.EventInstancePaused
+	}
+	return strings.ReplaceAll(string(t), ".", "_")
+}
+
+// EventsHandler streams instance lifecycle events as server-sent events, each named after its type
+// with a StreamEvent as data. The instance query parameter limits the stream to one instance.
+func EventsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		instance := r.URL.Query().Get("instance")
+		rc := http.NewResponseController(w)
+		// The stream outlives the server's write timeout
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			log.FileOnlyWarningLog.Printf("API: Could not clear write deadline of event stream: %v", err)
+		}
+
+		events := make(chan session.Event, 64)
+		unsubscribe := session.Subscribe(func(e session.Event) {
+			if instance != "" && e.Instance != instance {
+				return
+			}
+			select {
+			case events <- e:
+			default:
+				log.FileOnlyWarningLog.Printf("API: Event stream from %s is behind, dropped %s for %s",
+					r.RemoteAddr, e.Type, e.Instance)
+			}
+		})
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		if _, err := fmt.Fprint(w, ": connected\n\n"); err != nil {
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Event stream cannot be flushed: %v", err)
+			return
+		}
+
+		keepAlive := time.NewTicker(eventStreamKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			case e := <-events:
+				event := newStreamEvent(e)
+				data, err := json.Marshal(event)
+				if err != nil {
+					log.FileOnlyErrorLog.Printf("API: Error encoding %s event: %v", e.Type, err)
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+					return
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// EventsWebSocketHandler streams the same events as EventsHandler over a WebSocket, as JSON
+// StreamEvent messages. The recent events are sent first, so a client opening an activity feed
+// starts with what already happened; backlog=false skips them. The instance query parameter
+// limits the stream to one instance.
+//
+// Browsers set their preferences when connecting. notify takes the event types to attach a
+// Notification to, or all; events from the backlog never have one. attention=true also sends an
+// AttentionUpdate listing the instances waiting for input, loaded from storage on connecting and
+// then whenever it changes, so a backgrounded tab can keep a badge up to date.
+func EventsWebSocketHandler(storage session.InstanceStore) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 4096,
+		CheckOrigin: func(r *http.Request) bool {
+			return true // Allow all origins, like the terminal WebSocket
+		},
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		instance := r.URL.Query().Get("instance")
+		backlog := r.URL.Query().Get("backlog") != "false"
+		notify, err := parseNotify(r.URL.Query().Get("notify"))
+		if err != nil {
+			http.Error(w, "Invalid notify parameter: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var attention *attentionTracker
+		if r.URL.Query().Get("attention") == "true" {
+			instances, err := storage.LoadInstances()
+			if err != nil {
+				log.FileOnlyErrorLog.Printf("API: Error loading instances for the event WebSocket: %v", err)
+				http.Error(w, "Error loading instances", http.StatusInternalServerError)
+				return
+			}
+			var awaiting []string
+			for _, inst := range instances {
+				if inst.Status == session.Ready && (instance == "" || inst.Title == instance) {
+					awaiting = append(awaiting, inst.Title)
+				}
+			}
+			attention = newAttentionTracker(awaiting)
+		}
+
+		w, stats := trackConnection(w, r, "events", instance)
+		defer stats.close()
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Event WebSocket upgrade failed for %s: %v", r.RemoteAddr, err)
+			return
+		}
+		defer conn.Close()
+		conn.SetReadLimit(maxInboundMessageSize)
+		conn.SetPongHandler(func(string) error {
+			stats.ponged()
+			return nil
+		})
+
+		events := make(chan session.Event, 64)
+		recent, unsubscribe := session.SubscribeRecent(func(e session.Event) {
+			if instance != "" && e.Instance != instance {
+				return
+			}
+			select {
+			case events <- e:
+			default:
+				log.FileOnlyWarningLog.Printf("API: Event WebSocket of %s is behind, dropped %s for %s",
+					r.RemoteAddr, e.Type, e.Instance)
+			}
+		})
+		defer unsubscribe()
+
+		// Clients don't send anything; reading notices when they go away
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		write := func(v any) bool {
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteJSON(v); err != nil {
+				log.FileOnlyWarningLog.Printf("API: Event WebSocket of %s closed: %v", r.RemoteAddr, err)
+				return false
+			}
+			stats.sentFrames(1)
+			return true
+		}
+		send := func(e session.Event, live bool) bool {
+			event := newStreamEvent(e)
+			if live && notify[event.Type] {
+				event.Notification = notificationFor(event)
+			}
+			return write(event)
+		}
+		if backlog {
+			for _, e := range recent {
+				if (instance == "" || e.Instance == instance) && !send(e, false) {
+					return
+				}
+			}
+		}
+		if attention != nil && !write(attention.message()) {
+			return
+		}
+
+		keepAlive := time.NewTicker(eventStreamKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-closed:
+				return
+			case <-keepAlive.C:
+				stats.pinged()
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+					return
+				}
+			case e := <-events:
+				if !send(e, true) {
+					return
+				}
+				if attention != nil && attention.update(e) && !write(attention.message()) {
+					return
+				}
+			}
+		}
+	}
+}
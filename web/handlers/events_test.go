@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bufio"
+	"claude-squad/session"
+	"claude-squad/web/mock"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestEventsHandler(t *testing.T) {
+	server := httptest.NewServer(EventsHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?instance=watched")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	// The connected comment is flushed once the handler has subscribed
+	reader := bufio.NewReader(resp.Body)
+	if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, ":") {
+		t.Fatalf("first line = %q, %v, want a comment", line, err)
+	}
+
+	(&session.Instance{Title: "other"}).Emit(session.EventKilled)
+	(&session.Instance{Title: "watched"}).Emit(session.EventPaused)
+	(&session.Instance{Title: "watched"}).Emit(session.EventKilled)
+
+	var got []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var name string
+		for len(got) < 2 {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				var event StreamEvent
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+					t.Errorf("invalid event data %q: %v", line, err)
+				}
+				if event.Type != name {
+					t.Errorf("data type %q does not match event name %q", event.Type, name)
+				}
+				got = append(got, event.Instance+":"+name)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for events")
+	}
+
+	want := []string{"watched:instance_paused", "watched:instance_removed"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestEventsWebSocketHandler(t *testing.T) {
+	// Emitted before connecting, so it comes from the recent events
+	(&session.Instance{Title: "feed-earlier"}).EmitError(errors.New("push rejected"))
+
+	server := httptest.NewServer(EventsWebSocketHandler(mock.NewEmptyMockStorage()))
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		conn.Close()
+		waitClosed(t, "events")
+	}()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var got []string
+	for {
+		var event StreamEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatalf("reading events: %v, got %v", err, got)
+		}
+		if !strings.HasPrefix(event.Instance, "feed-") {
+			continue // From other tests
+		}
+		got = append(got, event.Instance+":"+event.Type+":"+event.Error)
+		if len(got) == 1 {
+			(&session.Instance{Title: "feed-later"}).Emit(session.EventPaused)
+		}
+		if len(got) == 2 {
+			break
+		}
+	}
+
+	want := []string{"feed-earlier:instance_error:push rejected", "feed-later:instance_paused:"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestEventsWebSocketNotifications(t *testing.T) {
+	storage := mock.NewEmptyMockStorage()
+	for _, data := range []session.InstanceData{
+		{Title: "notify-waiting", Status: session.Ready},
+		{Title: "notify-busy", Status: session.Running},
+	} {
+		instance, err := session.FromInstanceData(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := storage.AddInstance(instance); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := httptest.NewServer(EventsWebSocketHandler(storage))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url+"?notify=instance_bogus", nil); err == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown notify type: %v, want 400", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?backlog=false&attention=true&notify=instance_status_changed,instance_error", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		conn.Close()
+		waitClosed(t, "events")
+	}()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// read returns the next message about the instances of this test
+	read := func() map[string]any {
+		t.Helper()
+		for {
+			var msg map[string]any
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("reading events: %v", err)
+			}
+			if msg["type"] == EventAttention || strings.HasPrefix(msg["instance"].(string), "notify-") {
+				return msg
+			}
+		}
+	}
+	awaiting := func(msg map[string]any) string {
+		var titles []string
+		for _, title := range msg["awaiting_prompt"].([]any) {
+			if strings.HasPrefix(title.(string), "notify-") {
+				titles = append(titles, title.(string))
+			}
+		}
+		return strings.Join(titles, ",")
+	}
+
+	if msg := read(); msg["type"] != EventAttention || awaiting(msg) != "notify-waiting" {
+		t.Fatalf("first message = %v, want the instances awaiting input", msg)
+	}
+
+	busy := &session.Instance{Title: "notify-busy", Program: "claude", Status: session.Ready}
+	busy.Emit(session.EventStatusChanged)
+	msg := read()
+	notification, _ := msg["notification"].(map[string]any)
+	if msg["type"] != "instance_status_changed" || notification["title"] != "notify-busy is waiting for input" {
+		t.Errorf("status change = %v, want a notification", msg)
+	}
+	if msg := read(); msg["type"] != EventAttention || awaiting(msg) != "notify-busy,notify-waiting" {
+		t.Errorf("attention after the status change = %v", msg)
+	}
+
+	// Types not asked for have no notification, and the killed instance stops waiting
+	(&session.Instance{Title: "notify-waiting", Status: session.Ready}).Emit(session.EventKilled)
+	if msg := read(); msg["type"] != "instance_removed" || msg["notification"] != nil {
+		t.Errorf("removal = %v, want no notification", msg)
+	}
+	if msg := read(); msg["type"] != EventAttention || awaiting(msg) != "notify-busy" {
+		t.Errorf("attention after the removal = %v", msg)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// InstanceFiles is the response of the files endpoint.
+type InstanceFiles struct {
+	Instance string `json:"instance"`
+	git.FileReport
+}
+
+// FilesHandler lists the untracked and ignored files of an instance's worktree, which the diff
+// stats don't make obvious.
+func FilesHandler(storage session.InstanceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			http.Error(w, "Instance name required", http.StatusBadRequest)
+			return
+		}
+
+		instance, err := findInstanceByTitle(storage, name)
+		if err != nil {
+			http.Error(w, "Instance not found", http.StatusNotFound)
+			return
+		}
+		if !instance.Started() || instance.Paused() || instance.InPlace {
+			http.Error(w, "Instance is not running in a worktree", http.StatusBadRequest)
+			return
+		}
+
+		report, err := instance.FileReport()
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error listing files of %s: %v", name, err)
+			http.Error(w, "Error listing files", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(InstanceFiles{Instance: name, FileReport: *report}); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error encoding file report: %v", err)
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"claude-squad/web/types"
+	"compress/flate"
+	"fmt"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// maxFrameContentSize caps the terminal content carried by a single
+	// WebSocket message. Larger panes are split into continuation chunks.
+	maxFrameContentSize = 64 * 1024
+
+	// maxInboundMessageSize caps messages read from clients. Client messages
+	// are small JSON commands and keystrokes, so anything larger is rejected.
+	maxInboundMessageSize = 64 * 1024
+
+	// compressionLevel is the permessage-deflate level used for writes.
+	// Terminal output compresses well, so favour speed over ratio.
+	compressionLevel = flate.BestSpeed
+)
+
+// frameCounter generates unique frame IDs for chunked updates.
+var frameCounter uint64
+
+// splitContent splits content into chunks of at most size bytes without
+// breaking UTF-8 sequences. Content that fits in one chunk is returned as is.
+func splitContent(content string, size int) []string {
+	if len(content) <= size {
+		return []string{content}
+	}
+
+	var chunks []string
+	for len(content) > size {
+		end := size
+		// Back off to the start of a rune so multi-byte characters stay intact
+		for end > 0 && !utf8.RuneStart(content[end]) {
+			end--
+		}
+		if end == 0 {
+			end = size
+		}
+		chunks = append(chunks, content[:end])
+		content = content[end:]
+	}
+	if len(content) > 0 {
+		chunks = append(chunks, content)
+	}
+	return chunks
+}
+
+// chunkUpdate splits an update into continuation frames if its content
+// exceeds the frame size cap. Small updates are returned unchanged.
+func chunkUpdate(update types.TerminalUpdate, size int) []types.TerminalUpdate {
+	chunks := splitContent(update.Content, size)
+	if len(chunks) == 1 {
+		return []types.TerminalUpdate{update}
+	}
+
+	frameID := fmt.Sprintf("%d-%d", time.Now().UnixNano(), atomic.AddUint64(&frameCounter, 1))
+	frames := make([]types.TerminalUpdate, len(chunks))
+	for i, chunk := range chunks {
+		frame := update
+		frame.Content = chunk
+		frame.FrameID = frameID
+		frame.ChunkIndex = i
+		frame.ChunkCount = len(chunks)
+		frame.Continuation = i > 0
+		frames[i] = frame
+	}
+	return frames
+}
+
+// writeTerminalUpdate sends an update to the client, splitting it into
+// continuation frames when the content is larger than maxFrameContentSize.
+// The frames are counted in stats unless it is nil. The caller must hold the
+// connection's write lock.
+func writeTerminalUpdate(conn *websocket.Conn, update types.TerminalUpdate, stats *connStats) error {
+	for _, frame := range chunkUpdate(update, maxFrameContentSize) {
+		if err := conn.SetWriteDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			return err
+		}
+		if err := conn.WriteJSON(frame); err != nil {
+			return fmt.Errorf("failed to write frame %d/%d: %w", frame.ChunkIndex+1, max(frame.ChunkCount, 1), err)
+		}
+		if stats != nil {
+			stats.sentFrames(1)
+		}
+	}
+	return nil
+}
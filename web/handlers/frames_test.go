@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"claude-squad/web/types"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		size    int
+		want    int
+	}{
+		{name: "empty", content: "", size: 4, want: 1},
+		{name: "fits", content: "abcd", size: 4, want: 1},
+		{name: "exact multiple", content: "abcdefgh", size: 4, want: 2},
+		{name: "remainder", content: "abcdefghi", size: 4, want: 3},
+		{name: "multibyte", content: strings.Repeat("é", 5), size: 3, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := splitContent(tt.content, tt.size)
+			if len(chunks) != tt.want {
+				t.Fatalf("splitContent() returned %d chunks, want %d", len(chunks), tt.want)
+			}
+			if got := strings.Join(chunks, ""); got != tt.content {
+				t.Errorf("chunks reassemble to %q, want %q", got, tt.content)
+			}
+			for i, chunk := range chunks {
+				if len(chunk) > tt.size {
+					t.Errorf("chunk %d has %d bytes, cap is %d", i, len(chunk), tt.size)
+				}
+				if !utf8.ValidString(chunk) {
+					t.Errorf("chunk %d splits a UTF-8 sequence: %q", i, chunk)
+				}
+			}
+		})
+	}
+}
+
+func TestChunkUpdate(t *testing.T) {
+	update := types.TerminalUpdate{InstanceTitle: "test", Content: "small", Status: "running"}
+	if frames := chunkUpdate(update, 16); len(frames) != 1 || frames[0].FrameID != "" {
+		t.Fatalf("small update should be sent unchunked, got %+v", frames)
+	}
+
+	update.Content = strings.Repeat("x", 40)
+	frames := chunkUpdate(update, 16)
+	if len(frames) != 3 {
+		t.Fatalf("got %d frames, want 3", len(frames))
+	}
+	for i, frame := range frames {
+		if frame.FrameID == "" || frame.FrameID != frames[0].FrameID {
+			t.Errorf("frame %d has inconsistent frame ID %q", i, frame.FrameID)
+		}
+		if frame.ChunkIndex != i || frame.ChunkCount != 3 {
+			t.Errorf("frame %d has index %d/%d", i, frame.ChunkIndex, frame.ChunkCount)
+		}
+		if frame.Continuation != (i > 0) {
+			t.Errorf("frame %d continuation = %v", i, frame.Continuation)
+		}
+		if frame.InstanceTitle != "test" || frame.Status != "running" {
+			t.Errorf("frame %d lost update metadata: %+v", i, frame)
+		}
+	}
+}
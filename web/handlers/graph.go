@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"encoding/json"
+	"net/http"
+)
+
+// GraphHandler returns the lineage graph of all instances: which instances were forked from,
+// review or retry which others.
+func GraphHandler(storage session.InstanceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		instances, err := storage.LoadInstances()
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error loading instances for graph: %v", err)
+			http.Error(w, "Error loading instances", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(session.BuildGraph(instances)); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error encoding instance graph: %v", err)
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
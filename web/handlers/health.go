@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Health is the body of /healthz and /readyz.
+type Health struct {
+	// Status is "ok", or "unavailable" if a readiness check failed.
+	Status string `json:"status"`
+	Uptime string `json:"uptime"`
+	// Checks has the result of each readiness check by name. /healthz runs none.
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// HealthCheck is the result of a readiness check.
+type HealthCheck struct {
+	OK bool `json:"ok"`
+	// Detail describes what was found, such as the number of instances loaded.
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ReadinessCheck checks a dependency of the server, returning a description of what it found.
+type ReadinessCheck func() (string, error)
+
+// HealthzHandler reports that the process is alive and serving requests. It checks nothing
+// else, so a liveness probe doesn't restart the server over a dependency that will recover.
+func HealthzHandler(startTime time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, http.StatusOK, Health{Status: "ok", Uptime: time.Since(startTime).String()})
+	}
+}
+
+// ReadyzHandler runs checks and reports whether the server can do its work. It responds with
+// 503 Service Unavailable if any check fails, so load balancers stop sending it traffic.
+func ReadyzHandler(startTime time.Time, checks map[string]ReadinessCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		health := Health{
+			Status: "ok",
+			Uptime: time.Since(startTime).String(),
+			Checks: make(map[string]HealthCheck, len(checks)),
+		}
+		code := http.StatusOK
+		for name, check := range checks {
+			detail, err := check()
+			result := HealthCheck{OK: err == nil, Detail: detail}
+			if err != nil {
+				result.Error = err.Error()
+				health.Status = "unavailable"
+				code = http.StatusServiceUnavailable
+				log.FileOnlyWarningLog.Printf("API: Readiness check %s failed: %v", name, err)
+			}
+			health.Checks[name] = result
+		}
+		writeHealth(w, code, health)
+	}
+}
+
+func writeHealth(w http.ResponseWriter, code int, health Health) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(health); err != nil {
+		log.FileOnlyErrorLog.Printf("API: Error encoding health: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadyzHandler(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	ok := func() (string, error) { return "2 instances", nil }
+	failing := func() (string, error) { return "", errors.New("tmux did not answer within 2s") }
+
+	tests := []struct {
+		name   string
+		checks map[string]ReadinessCheck
+		code   int
+		status string
+	}{
+		{"all checks pass", map[string]ReadinessCheck{"storage": ok, "tmux": ok}, http.StatusOK, "ok"},
+		{"a check fails", map[string]ReadinessCheck{"storage": ok, "tmux": failing}, http.StatusServiceUnavailable, "unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ReadyzHandler(time.Now(), tt.checks)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tt.code {
+				t.Errorf("status code = %d, want %d", rec.Code, tt.code)
+			}
+			var health Health
+			if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil {
+				t.Fatalf("invalid body: %v", err)
+			}
+			if health.Status != tt.status {
+				t.Errorf("status = %q, want %q", health.Status, tt.status)
+			}
+			if len(health.Checks) != len(tt.checks) {
+				t.Errorf("got %d checks, want %d", len(health.Checks), len(tt.checks))
+			}
+			if storage := health.Checks["storage"]; !storage.OK || storage.Detail != "2 instances" {
+				t.Errorf("storage check = %+v", storage)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	HealthzHandler(time.Now())(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var health Health
+	if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil || rec.Code != http.StatusOK || health.Status != "ok" {
+		t.Errorf("GET /healthz = %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -3,6 +3,9 @@ package handlers
 import (
 	"claude-squad/log"
 	"claude-squad/session"
+	"claude-squad/session/tmux"
+	"claude-squad/web/middleware"
+	"claude-squad/web/types"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -22,6 +25,14 @@ type InstanceSummary struct {
 	UpdatedAt  time.Time `json:"updated_at"`
 	Program    string    `json:"program"`
 	InPlace    bool      `json:"in_place"`
+	// VCS is "dir" for an instance working in a copy of Path, empty for a git worktree.
+	VCS        string    `json:"vcs,omitempty"`
+	Parent     string    `json:"parent"`
+	Relation   string    `json:"relation"`
+	Ticket     string    `json:"ticket,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	// Color is the accent color of the instance, as #rrggbb.
+	Color      string    `json:"color"`
 	DiffStats  DiffStats `json:"diff_stats,omitempty"`
 }
 
@@ -30,6 +41,25 @@ type InstanceDetail struct {
 	InstanceSummary
 	HasPrompt     bool   `json:"has_prompt"`
 	TMuxSession   string `json:"tmux_session,omitempty"`
+	Notes         string `json:"notes"`
+	// TicketURL links to the ticket, if it is known.
+	TicketURL string `json:"ticket_url,omitempty"`
+	// Windows are the windows of the tmux session: the program's, then companion windows such
+	// as a shell.
+	Windows []tmux.Window `json:"windows,omitempty"`
+	// ExitCode is the exit status of the program of an exited instance, -1 if unknown.
+	ExitCode *int `json:"exit_code,omitempty"`
+	// Restarts is the number of times in a row a restart policy restarted the program.
+	Restarts int `json:"restarts,omitempty"`
+	// Cost is the cost of the Claude Code session last read from /cost.
+	Cost *session.CostReport `json:"cost,omitempty"`
+	// Branch is the branch of the instance's worktree, and ExistingBranch set if the instance
+	// checked out a branch it didn't create, which is kept when it is killed.
+	Branch         string `json:"branch,omitempty"`
+	ExistingBranch bool   `json:"existing_branch,omitempty"`
+	// WorktreePath is the directory the instance's program runs in, only shown to operators, not
+	// to tokens scoped to the instance.
+	WorktreePath string `json:"worktree_path,omitempty"`
 }
 
 // DiffStats represents git diff statistics.
@@ -44,10 +74,33 @@ type InstanceOutput struct {
 	Format     string    `json:"format"`
 	Timestamp  time.Time `json:"timestamp"`
 	HasPrompt  bool      `json:"has_prompt"`
+	// Seq is set on long polls: pass it as since to wait for newer output.
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// InstanceList is the response of the instance list endpoint. When fields is requested, each
+// instance only carries the selected keys.
+type InstanceList struct {
+	Instances []InstanceSummary `json:"instances"`
+	Total     int               `json:"total"`
+	Offset    int               `json:"offset"`
+	Limit     int               `json:"limit"`
+}
+
+// ServerStatus reports the server version and uptime.
+type ServerStatus struct {
+	Version string `json:"version"`
+	Uptime  string `json:"uptime"`
+	// OutputCache reports how often instance output was served from the format conversion cache.
+	OutputCache OutputCacheStats `json:"output_cache"`
+	// Monitor reports the memory held by the terminal monitor.
+	Monitor types.MonitorStats `json:"monitor"`
+	// WebSockets reports the traffic and ping latency of every open WebSocket connection.
+	WebSockets []ConnectionStats `json:"websockets"`
 }
 
 // InstancesHandler handles listing all instances.
-func InstancesHandler(storage *session.Storage) http.HandlerFunc {
+func InstancesHandler(storage session.InstanceStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.FileOnlyInfoLog.Printf("API: InstancesHandler called from %s", r.RemoteAddr)
 		
@@ -75,6 +128,12 @@ func InstancesHandler(storage *session.Storage) http.HandlerFunc {
 				i, instance.Title, instance.Status)
 		}
 		
+		query, err := parseInstanceQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		// Filter by status if requested
 		filter := r.URL.Query().Get("filter")
 		
@@ -92,11 +151,22 @@ func InstancesHandler(storage *session.Storage) http.HandlerFunc {
 			summary := instanceToSummary(instance)
 			summaries = append(summaries, summary)
 		}
+
+		page, total := query.apply(summaries)
+		result, err := query.project(page)
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error selecting instance fields: %v", err)
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
 		
 		// Return as JSON
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(map[string]interface{}{
-			"instances": summaries,
+			"instances": result,
+			"total":     total,
+			"offset":    query.offset,
+			"limit":     query.limit,
 		}); err != nil {
 			log.FileOnlyErrorLog.Printf("API: Error encoding instances: %v", err)
 			http.Error(w, "Error encoding response", http.StatusInternalServerError)
@@ -106,7 +176,7 @@ func InstancesHandler(storage *session.Storage) http.HandlerFunc {
 }
 
 // InstanceDetailHandler handles getting details for a specific instance.
-func InstanceDetailHandler(storage *session.Storage) http.HandlerFunc {
+func InstanceDetailHandler(storage session.InstanceStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		name := chi.URLParam(r, "name")
 		if name == "" {
@@ -121,21 +191,9 @@ func InstanceDetailHandler(storage *session.Storage) http.HandlerFunc {
 			return
 		}
 		
-		// Create detailed response
-		detail := InstanceDetail{
-			InstanceSummary: instanceToSummary(instance),
-			HasPrompt:       false, // Determine prompt status from output if needed
-		}
-		
-		// Include tmux session info if running
-		if instance.Started() && !instance.Paused() {
-			// Use instance title to derive tmux session name
-			detail.TMuxSession = "claudesquad_" + instance.Title
-		}
-		
 		// Return as JSON
 		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(detail); err != nil {
+		if err := json.NewEncoder(w).Encode(instanceToDetail(instance, middleware.Operator(r))); err != nil {
 			log.FileOnlyErrorLog.Printf("API: Error encoding instance detail: %v", err)
 			http.Error(w, "Error encoding response", http.StatusInternalServerError)
 			return
@@ -143,8 +201,10 @@ func InstanceDetailHandler(storage *session.Storage) http.HandlerFunc {
 	}
 }
 
-// InstanceOutputHandler handles getting terminal output for a specific instance.
-func InstanceOutputHandler(storage *session.Storage) http.HandlerFunc {
+// InstanceOutputHandler handles getting terminal output for a specific instance. With the since
+// query parameter it long polls the monitor instead, as described by pollOutput, for clients
+// that can't open the terminal WebSocket.
+func InstanceOutputHandler(storage session.InstanceStore, monitor types.TerminalMonitorInterface) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		name := chi.URLParam(r, "name")
 		if name == "" {
@@ -176,6 +236,11 @@ func InstanceOutputHandler(storage *session.Storage) http.HandlerFunc {
 			http.Error(w, "Instance is not running", http.StatusBadRequest)
 			return
 		}
+
+		if r.URL.Query().Has("since") {
+			pollOutput(w, r, monitor, name, format)
+			return
+		}
 		
 		// Get terminal output
 		content, err := instance.Preview()
@@ -185,12 +250,8 @@ func InstanceOutputHandler(storage *session.Storage) http.HandlerFunc {
 			return
 		}
 		
-		// Convert format if needed
-		if format == "html" {
-			content = convertAnsiToHtml(content)
-		} else if format == "text" {
-			content = stripAnsi(content)
-		}
+		// Convert format, reusing earlier conversions of the same capture
+		content = outputCache.Format(content, format)
 		
 		// Apply line limit if specified
 		limit := r.URL.Query().Get("limit")
@@ -213,7 +274,7 @@ func InstanceOutputHandler(storage *session.Storage) http.HandlerFunc {
 		// Return as JSON
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(output); err != nil {
-			log.ErrorLog.Printf("Error encoding output: %v", err)
+			log.FileOnlyErrorLog.Printf("Error encoding output: %v", err)
 			http.Error(w, "Error encoding response", http.StatusInternalServerError)
 			return
 		}
@@ -221,11 +282,14 @@ func InstanceOutputHandler(storage *session.Storage) http.HandlerFunc {
 }
 
 // ServerStatusHandler handles getting server status information.
-func ServerStatusHandler(version string, startTime time.Time) http.HandlerFunc {
+func ServerStatusHandler(version string, startTime time.Time, monitorStats func() types.MonitorStats) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		status := map[string]interface{}{
-			"version": version,
-			"uptime":  time.Since(startTime).String(),
+		status := ServerStatus{
+			Version:     version,
+			Uptime:      time.Since(startTime).String(),
+			OutputCache: outputCache.Stats(),
+			Monitor:     monitorStats(),
+			WebSockets:  OpenConnections(),
 		}
 		
 		w.Header().Set("Content-Type", "application/json")
@@ -240,7 +304,7 @@ func ServerStatusHandler(version string, startTime time.Time) http.HandlerFunc {
 // Helper functions
 
 // findInstanceByTitle finds an instance by its title.
-func findInstanceByTitle(storage *session.Storage, title string) (*session.Instance, error) {
+func findInstanceByTitle(storage session.InstanceStore, title string) (*session.Instance, error) {
 	instances, err := storage.LoadInstances()
 	if err != nil {
 		return nil, err
@@ -267,33 +331,57 @@ func instanceToSummary(instance *session.Instance) InstanceSummary {
 		}
 	}
 	
-	// Convert Status enum to proper string representation
-	var statusStr string
-	switch instance.Status {
-	case session.Running:
-		statusStr = "running"
-	case session.Ready:
-		statusStr = "ready"
-	case session.Loading:
-		statusStr = "loading"
-	case session.Paused:
-		statusStr = "paused"
-	default:
-		statusStr = "unknown"
-	}
-	
 	return InstanceSummary{
 		Title:     instance.Title,
-		Status:    statusStr, // Use proper string representation
+		Status:    instance.Status.String(),
 		Path:      instance.Path,
 		CreatedAt: instance.CreatedAt,
 		UpdatedAt: instance.UpdatedAt,
 		Program:   instance.Program,
 		InPlace:   instance.InPlace,
+		VCS:       instance.VCS,
+		Parent:    instance.Parent,
+		Relation:  string(instance.Relation),
+		Ticket:    instance.Ticket,
+		Tags:      instance.Tags,
+		Color:     instance.AccentColor(),
 		DiffStats: diffStats,
 	}
 }
 
+// instanceToDetail converts an Instance to an InstanceDetail, with its worktree path if operator
+// is set.
+func instanceToDetail(instance *session.Instance, operator bool) InstanceDetail {
+	detail := InstanceDetail{
+		InstanceSummary: instanceToSummary(instance),
+		HasPrompt:       false, // Determine prompt status from output if needed
+		Notes:           instance.Notes,
+		Restarts:        instance.Restarts,
+		Cost:            instance.Cost,
+		Branch:          instance.Branch,
+		ExistingBranch:  instance.ExistingBranch() != "",
+	}
+	if operator {
+		detail.WorktreePath = instance.WorkDir()
+	}
+	if ticket, ok := instance.ParsedTicket(); ok {
+		detail.TicketURL = ticket.URL
+	}
+	if instance.Status == session.Exited {
+		code := instance.ExitCode
+		detail.ExitCode = &code
+	}
+
+	// Include tmux session info if running
+	if instance.Started() && !instance.Paused() {
+		detail.TMuxSession = instance.GetTmuxSessionName()
+		if windows, err := instance.Windows(); err == nil {
+			detail.Windows = windows
+		}
+	}
+	return detail
+}
+
 // ANSI conversion function
 func convertAnsiToHtml(content string) string {
 	// Replace special HTML characters
@@ -312,8 +400,9 @@ func convertAnsiToHtml(content string) string {
 	return "<pre style=\"white-space: pre-wrap; font-family: monospace;\">" + content + "</pre>"
 }
 
+// ansiPattern matches ANSI escape codes
+var ansiPattern = regexp.MustCompile(`\x1B\[[0-9;]*[a-zA-Z]`)
+
 func stripAnsi(content string) string {
-	// ANSI escape code pattern
-	re := regexp.MustCompile(`\x1B\[[0-9;]*[a-zA-Z]`)
-	return re.ReplaceAllString(content, "")
+	return ansiPattern.ReplaceAllString(content, "")
 }
\ No newline at end of file
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/layouts"
+	"claude-squad/web/prefs"
+	"claude-squad/web/types"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxLayoutBytes caps the size of a layout request body.
+const maxLayoutBytes = 16 << 10
+
+// LayoutList is the response of the layout list endpoint.
+type LayoutList struct {
+	Layouts []layouts.Layout `json:"layouts"`
+}
+
+// LayoutsHandler lists the dashboard layouts on GET and creates one on POST. Creating a layout
+// whose name is taken fails with 409 Conflict.
+func LayoutsHandler(store *layouts.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			l, ok := decodeLayout(w, r)
+			if !ok {
+				return
+			}
+			if _, err := store.Get(l.Name); err == nil {
+				http.Error(w, "Layout already exists", http.StatusConflict)
+				return
+			}
+			saveLayout(w, store, l, http.StatusCreated)
+			return
+		}
+
+		list, err := store.List()
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error loading layouts: %v", err)
+			http.Error(w, "Error loading layouts", http.StatusInternalServerError)
+			return
+		}
+		writeTemplateJSON(w, http.StatusOK, LayoutList{Layouts: list})
+	}
+}
+
+// LayoutHandler gets a dashboard layout on GET, creates or replaces it on PUT and deletes it on
+// DELETE.
+func LayoutHandler(store *layouts.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "layout")
+		switch r.Method {
+		case http.MethodPut:
+			l, ok := decodeLayout(w, r)
+			if !ok {
+				return
+			}
+			// The path names the layout
+			l.Name = name
+			saveLayout(w, store, l, http.StatusOK)
+		case http.MethodDelete:
+			if err := store.Delete(name); err != nil {
+				if errors.Is(err, layouts.ErrNotFound) {
+					http.Error(w, "Layout not found", http.StatusNotFound)
+					return
+				}
+				log.FileOnlyErrorLog.Printf("API: Error deleting layout '%s': %v", name, err)
+				http.Error(w, "Error deleting layout", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			l, ok := getLayout(w, store, name)
+			if !ok {
+				return
+			}
+			writeTemplateJSON(w, http.StatusOK, l)
+		}
+	}
+}
+
+// LayoutWebSocketHandler streams the terminals of every instance of a dashboard layout over one
+// WebSocket, as described by serveMultiplexed. The config message also carries the layout.
+func LayoutWebSocketHandler(storage session.InstanceStore, monitor types.TerminalMonitorInterface, store *layouts.Store, preferences *prefs.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l, ok := getLayout(w, store, chi.URLParam(r, "layout"))
+		if !ok {
+			return
+		}
+		serveMultiplexed(w, r, storage, monitor, preferences, l.Instances, false, map[string]interface{}{"layout": l})
+	}
+}
+
+func getLayout(w http.ResponseWriter, store *layouts.Store, name string) (layouts.Layout, bool) {
+	l, err := store.Get(name)
+	if err != nil {
+		if errors.Is(err, layouts.ErrNotFound) {
+			http.Error(w, "Layout not found", http.StatusNotFound)
+			return l, false
+		}
+		log.FileOnlyErrorLog.Printf("API: Error loading layout '%s': %v", name, err)
+		http.Error(w, "Error loading layouts", http.StatusInternalServerError)
+		return l, false
+	}
+	return l, true
+}
+
+func decodeLayout(w http.ResponseWriter, r *http.Request) (layouts.Layout, bool) {
+	var l layouts.Layout
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLayoutBytes)).Decode(&l); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return l, false
+		}
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return l, false
+	}
+	return l, true
+}
+
+func saveLayout(w http.ResponseWriter, store *layouts.Store, l layouts.Layout, status int) {
+	if err := l.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	saved, err := store.Save(l)
+	if err != nil {
+		log.FileOnlyErrorLog.Printf("API: Error saving layout '%s': %v", l.Name, err)
+		http.Error(w, "Error saving layout", http.StatusInternalServerError)
+		return
+	}
+	writeTemplateJSON(w, status, saved)
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/layouts"
+	"claude-squad/web/mock"
+	"claude-squad/web/types"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+)
+
+// fakeMonitor hands out a channel per subscription and records the input sent to instances.
+type fakeMonitor struct {
+	mu     sync.Mutex
+	subs   map[string]chan types.TerminalUpdate
+	inputs []string
+	done   chan struct{}
+}
+
+func newFakeMonitor() *fakeMonitor {
+	return &fakeMonitor{subs: make(map[string]chan types.TerminalUpdate), done: make(chan struct{})}
+}
+
+func (f *fakeMonitor) Subscribe(instance string) chan types.TerminalUpdate {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan types.TerminalUpdate, 10)
+	f.subs[instance] = ch
+	return ch
+}
+
+func (f *fakeMonitor) Unsubscribe(instance string, ch chan types.TerminalUpdate) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subs[instance] == ch {
+		delete(f.subs, instance)
+	}
+}
+
+// publish sends content to the subscriber of instance, waiting for it to subscribe.
+func (f *fakeMonitor) publish(t *testing.T, instance, content string) {
+	t.Helper()
+	f.send(t, types.TerminalUpdate{InstanceTitle: instance, Content: content, Timestamp: time.Now()})
+}
+
+// send sends update to the subscriber of its instance, waiting for it to subscribe.
+func (f *fakeMonitor) send(t *testing.T, update types.TerminalUpdate) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		f.mu.Lock()
+		ch := f.subs[update.InstanceTitle]
+		f.mu.Unlock()
+		if ch != nil {
+			ch <- update
+			return
+		}
+	}
+	t.Fatalf("nobody subscribed to %s", update.InstanceTitle)
+}
+
+func (f *fakeMonitor) subscribed(instance string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.subs[instance] != nil
+}
+
+func (f *fakeMonitor) GetContent(string) (string, bool) { return "", false }
+
+func (f *fakeMonitor) SendInput(instance, input string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inputs = append(f.inputs, instance+":"+input)
+	return nil
+}
+
+func (f *fakeMonitor) GetTasks(string) ([]types.TaskItem, error) { return nil, nil }
+
+func (f *fakeMonitor) ResizeTerminal(string, int, int) error { return nil }
+
+func (f *fakeMonitor) Done() <-chan struct{} { return f.done }
+
+func layoutRequest(handler http.HandlerFunc, method, name, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/layouts/"+name, strings.NewReader(body))
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("layout", name)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestLayoutHandlers(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	store := layouts.NewStoreAt(filepath.Join(t.TempDir(), layouts.FileName))
+	list, one := LayoutsHandler(store), LayoutHandler(store)
+
+	rec := layoutRequest(list, http.MethodPost, "", `{"name": "backend", "instances": ["api", "db"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := layoutRequest(list, http.MethodPost, "", `{"name": "backend", "instances": ["api"]}`); rec.Code != http.StatusConflict {
+		t.Errorf("POST of a taken name status = %d, want 409", rec.Code)
+	}
+	if rec := layoutRequest(list, http.MethodPost, "", `{"name": "empty", "instances": []}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST without instances status = %d, want 400", rec.Code)
+	}
+
+	rec = layoutRequest(one, http.MethodPut, "backend", `{"name": "ignored", "instances": ["api", "db", "auth"], "columns": 3}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d: %s", rec.Code, rec.Body)
+	}
+	rec = layoutRequest(one, http.MethodGet, "backend", "")
+	var got layouts.Layout
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "backend" || len(got.Instances) != 3 || got.Columns != 3 {
+		t.Errorf("GET = %+v, want the replaced layout", got)
+	}
+
+	if rec := layoutRequest(one, http.MethodDelete, "backend", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE status = %d, want 204", rec.Code)
+	}
+	if rec := layoutRequest(one, http.MethodGet, "backend", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET of a deleted layout status = %d, want 404", rec.Code)
+	}
+}
+
+func TestLayoutWebSocketHandler(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	storage := mock.NewEmptyMockStorage()
+	for _, title := range []string{"api", "db"} {
+		instance, err := session.FromInstanceData(session.InstanceData{Title: title, Status: session.Running})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := storage.AddInstance(instance); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store := layouts.NewStoreAt(filepath.Join(t.TempDir(), layouts.FileName))
+	if _, err := store.Save(layouts.Layout{Name: "backend", Instances: []string{"api", "db", "gone"}}); err != nil {
+		t.Fatal(err)
+	}
+	monitor := newFakeMonitor()
+
+	router := chi.NewRouter()
+	router.Get("/ws/layouts/{layout}", LayoutWebSocketHandler(storage, monitor, store, nil))
+	server := httptest.NewServer(router)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/layouts/"
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url+"frontend", nil); err == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("dialing an unknown layout: %v, want 404", err)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url+"backend?privileges=read-write", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var config struct {
+		Type       string         `json:"type"`
+		Privileges string         `json:"privileges"`
+		Layout     layouts.Layout `json:"layout"`
+	}
+	if err := conn.ReadJSON(&config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Type != "config" || config.Privileges != "read-write" || config.Layout.Name != "backend" {
+		t.Errorf("config = %+v", config)
+	}
+	var missing types.TerminalUpdate
+	if err := conn.ReadJSON(&missing); err != nil {
+		t.Fatal(err)
+	}
+	if missing.InstanceTitle != "gone" || missing.Type != types.EventInstanceRemoved {
+		t.Errorf("update of a missing instance = %+v", missing)
+	}
+
+	monitor.publish(t, "db", "db output")
+	monitor.publish(t, "api", "api output")
+	got := map[string]string{}
+	for len(got) < 2 {
+		var update types.TerminalUpdate
+		if err := conn.ReadJSON(&update); err != nil {
+			t.Fatal(err)
+		}
+		got[update.InstanceTitle] = update.Content
+	}
+	if got["api"] != "api output" || got["db"] != "db output" {
+		t.Errorf("updates = %v", got)
+	}
+
+	for _, input := range []types.TerminalInput{
+		{InstanceTitle: "db", Content: "ls\n"},
+		{InstanceTitle: "gone", Content: "ls\n"},
+	} {
+		if err := conn.WriteJSON(input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var responses []bool
+	for len(responses) < 2 {
+		var response struct {
+			Success bool `json:"success"`
+		}
+		if err := conn.ReadJSON(&response); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, response.Success)
+	}
+	if !responses[0] || responses[1] {
+		t.Errorf("input responses = %v, want only the streamed instance to succeed", responses)
+	}
+	monitor.mu.Lock()
+	inputs := strings.Join(monitor.inputs, ",")
+	monitor.mu.Unlock()
+	if inputs != "db:ls\n" {
+		t.Errorf("inputs = %q, want db:ls", inputs)
+	}
+
+	conn.Close()
+	for deadline := time.Now().Add(5 * time.Second); monitor.subscribed("api") || monitor.subscribed("db"); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("instances still subscribed after the connection closed")
+		}
+	}
+	waitClosed(t, "layout")
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/middleware"
+	"claude-squad/web/tokens"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// lifecycleInstance returns the instances in storage and the one named in the URL of r, or writes
+// the error response and returns false.
+func lifecycleInstance(w http.ResponseWriter, r *http.Request, storage session.InstanceStore, action string) ([]*session.Instance, *session.Instance, bool) {
+	name := chi.URLParam(r, "name")
+	if name == "" {
+		http.Error(w, "Instance name required", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	instances, err := storage.LoadInstances()
+	if err != nil {
+		log.FileOnlyErrorLog.Printf("API: Error loading instances for %s: %v", action, err)
+		http.Error(w, "Error loading instances", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	for _, instance := range instances {
+		if instance.Title == name {
+			return instances, instance, true
+		}
+	}
+	http.Error(w, "Instance not found", http.StatusNotFound)
+	return nil, nil, false
+}
+
+// InstanceKillHandler kills an instance, removing its tmux session and its worktree or directory
+// copy, and deletes it from storage. Its branch is kept, as when it is killed from the TUI. Its
+// tokens in store, which may be nil, are revoked. Only operators may kill instances, not tokens
+// scoped to them.
+func InstanceKillHandler(storage session.InstanceStore, store *tokens.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !middleware.Operator(r) {
+			http.Error(w, "Scoped tokens can't kill their instance", http.StatusForbidden)
+			return
+		}
+		_, instance, ok := lifecycleInstance(w, r, storage, "kill")
+		if !ok {
+			return
+		}
+		if worktree, err := instance.GetGitWorktree(); err == nil && !instance.Paused() {
+			if checkedOut, err := worktree.IsBranchCheckedOut(); err == nil && checkedOut {
+				http.Error(w, "The branch of the instance is checked out in the repository", http.StatusConflict)
+				return
+			}
+		}
+
+		// Delete from storage first, so a failed cleanup doesn't leave a listed instance behind
+		if err := storage.DeleteInstance(instance.Title); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error deleting '%s': %v", instance.Title, err)
+			http.Error(w, "Error deleting instance", http.StatusInternalServerError)
+			return
+		}
+		if err := instance.Kill(); err != nil {
+			// The instance is gone from storage, what is left over is only logged
+			log.FileOnlyWarningLog.Printf("API: Error cleaning up killed '%s': %v", instance.Title, err)
+		}
+		if store != nil {
+			if err := store.RevokeInstance(instance.Title); err != nil {
+				log.FileOnlyWarningLog.Printf("API: Error revoking the tokens of killed '%s': %v", instance.Title, err)
+			}
+		}
+		log.FileOnlyInfoLog.Printf("API: '%s' killed from %s", instance.Title, r.RemoteAddr)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// InstancePauseHandler pauses an instance: its changes are committed, its tmux session closed and
+// its worktree removed, keeping the branch. It responds with the paused InstanceDetail.
+func InstancePauseHandler(storage session.InstanceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		instances, instance, ok := lifecycleInstance(w, r, storage, "pause")
+		if !ok {
+			return
+		}
+		switch {
+		case instance.Paused():
+			http.Error(w, "Instance is already paused", http.StatusConflict)
+			return
+		case !instance.Started():
+			http.Error(w, "Instance isn't running", http.StatusConflict)
+			return
+		case instance.InPlace:
+			http.Error(w, "Instances working in place can't be paused", http.StatusConflict)
+			return
+		}
+		if _, err := instance.GetGitWorktree(); err != nil {
+			http.Error(w, "Only instances with a worktree can be paused", http.StatusConflict)
+			return
+		}
+		if err := instance.Pause(); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error pausing '%s': %v", instance.Title, err)
+			http.Error(w, "Error pausing instance: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		saveLifecycleChange(w, r, storage, instances, instance, "paused")
+	}
+}
+
+// InstanceResumeHandler resumes a paused instance in a new worktree of its branch, or restarts
+// the program of an instance that exited. It responds with the resumed InstanceDetail.
+func InstanceResumeHandler(storage session.InstanceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		instances, instance, ok := lifecycleInstance(w, r, storage, "resume")
+		if !ok {
+			return
+		}
+		var err error
+		switch {
+		case instance.Status == session.Exited:
+			err = instance.Restart()
+		case instance.Paused():
+			err = instance.Resume()
+		default:
+			http.Error(w, "Instance isn't paused", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error resuming '%s': %v", instance.Title, err)
+			http.Error(w, "Error resuming instance: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		saveLifecycleChange(w, r, storage, instances, instance, "resumed")
+	}
+}
+
+// saveLifecycleChange saves instances after instance was paused or resumed and responds with its
+// InstanceDetail.
+func saveLifecycleChange(w http.ResponseWriter, r *http.Request, storage session.InstanceStore, instances []*session.Instance, instance *session.Instance, change string) {
+	if err := storage.SaveInstances(instances); err != nil {
+		log.FileOnlyErrorLog.Printf("API: Error saving %s '%s': %v", change, instance.Title, err)
+		http.Error(w, "Error saving instance", http.StatusInternalServerError)
+		return
+	}
+	log.FileOnlyInfoLog.Printf("API: '%s' %s from %s", instance.Title, change, r.RemoteAddr)
+	writeTemplateJSON(w, http.StatusOK, instanceToDetail(instance, middleware.Operator(r)))
+}
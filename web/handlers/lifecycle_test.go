@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/mock"
+	"claude-squad/web/tokens"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func lifecycleRequest(handler http.HandlerFunc, method, name string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/instances/"+name, nil)
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("name", name)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestLifecycleHandlers(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	storage := mock.NewEmptyMockStorage()
+	instance, err := session.FromInstanceData(session.InstanceData{Title: "task", Status: session.Paused})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.AddInstance(instance); err != nil {
+		t.Fatal(err)
+	}
+
+	kill := InstanceKillHandler(storage, nil)
+	pause := InstancePauseHandler(storage)
+	resume := InstanceResumeHandler(storage)
+	for name, handler := range map[string]http.HandlerFunc{"kill": kill, "pause": pause, "resume": resume} {
+		if rec := lifecycleRequest(handler, http.MethodPost, "missing"); rec.Code != http.StatusNotFound {
+			t.Errorf("%s of a missing instance = %d, want 404", name, rec.Code)
+		}
+	}
+	if rec := lifecycleRequest(pause, http.MethodPost, "task"); rec.Code != http.StatusConflict {
+		t.Errorf("pause of a paused instance = %d, want 409", rec.Code)
+	}
+}
+
+func TestKillRevokesTokens(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	storage := mock.NewEmptyMockStorage()
+	instance, err := session.NewInstance(session.InstanceOptions{Title: "task", Path: t.TempDir(), Program: "claude"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.AddInstance(instance); err != nil {
+		t.Fatal(err)
+	}
+	store := tokens.NewStoreAt(filepath.Join(t.TempDir(), tokens.FileName))
+	_, secret, err := store.Issue("task", tokens.ScopeControl, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Issue("other", tokens.ScopeView, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := lifecycleRequest(InstanceKillHandler(storage, store), http.MethodPost, "task"); rec.Code != http.StatusNoContent {
+		t.Fatalf("kill = %d, want 204", rec.Code)
+	}
+	if _, err := store.Lookup(secret); err == nil {
+		t.Error("the token of the killed instance still works")
+	}
+	if list, _ := store.List(""); len(list) != 1 {
+		t.Errorf("the tokens of other instances were revoked: %+v", list)
+	}
+}
@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/prefs"
+	"claude-squad/web/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// multiplexReadTimeout is how long a multiplexed connection may go without a message or pong.
+	multiplexReadTimeout = 70 * time.Second
+
+	// maxMultiplexedInstances caps the instances streamed over one connection.
+	maxMultiplexedInstances = 32
+)
+
+// multiplexer streams the terminals of several instances over one WebSocket. Every update carries
+// the title of its instance, and every instance is read by its own goroutine, all of them sharing
+// the connection and its ping loop.
+type multiplexer struct {
+	conn    *websocket.Conn
+	storage session.InstanceStore
+	monitor types.TerminalMonitorInterface
+	format  string
+	filters ContentFilters
+	// dynamic lets the client subscribe and unsubscribe
+	dynamic bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	streams map[string]*muxStream
+	stats   *connStats
+}
+
+// muxStream is an instance streamed over a multiplexed connection.
+type muxStream struct {
+	cancel context.CancelFunc // Stops streaming the instance
+}
+
+// write sends v as a JSON message.
+func (m *multiplexer) write(v interface{}) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	if err := m.conn.SetWriteDeadline(time.Now().Add(10 * time.Second)); err != nil {
+		return err
+	}
+	return m.conn.WriteJSON(v)
+}
+
+// subscribe starts streaming the terminal of instance. Subscribing to an instance already streamed
+// does nothing.
+func (m *multiplexer) subscribe(instance string) error {
+	if _, err := findInstanceByTitle(m.storage, instance); err != nil {
+		return fmt.Errorf("instance '%s' not found", instance)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.streams[instance]; ok {
+		return nil
+	}
+	if len(m.streams) >= maxMultiplexedInstances {
+		return fmt.Errorf("at most %d instances can be streamed over one connection", maxMultiplexedInstances)
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	stream := &muxStream{cancel: cancel}
+	m.streams[instance] = stream
+	updates := m.monitor.Subscribe(instance)
+	m.wg.Add(1)
+	go m.forward(ctx, instance, updates, stream)
+	m.stats.setTarget(m.targetLocked())
+	return nil
+}
+
+// unsubscribe stops streaming the terminal of instance. It returns false if it isn't streamed.
+func (m *multiplexer) unsubscribe(instance string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stream, ok := m.streams[instance]
+	if ok {
+		stream.cancel()
+		delete(m.streams, instance)
+		m.stats.setTarget(m.targetLocked())
+	}
+	return ok
+}
+
+// targetLocked returns the instances streamed, for the connection statistics. The caller must
+// hold m.mu.
+func (m *multiplexer) targetLocked() string {
+	instances := make([]string, 0, len(m.streams))
+	for instance := range m.streams {
+		instances = append(instances, instance)
+	}
+	sort.Strings(instances)
+	return strings.Join(instances, ",")
+}
+
+// streaming reports whether the terminal of instance is streamed.
+func (m *multiplexer) streaming(instance string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.streams[instance]
+	return ok
+}
+
+// forward sends the updates of instance until ctx is done, the connection fails or the instance
+// is removed.
+func (m *multiplexer) forward(ctx context.Context, instance string, updates chan types.TerminalUpdate, stream *muxStream) {
+	defer m.wg.Done()
+	defer m.monitor.Unsubscribe(instance, updates)
+	defer stream.cancel()
+	coalesced := coalesce(ctx, updates, m.stats)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-coalesced:
+			if !ok {
+				return
+			}
+			if update.Type == "" {
+				if len(update.Content) == 0 {
+					continue
+				}
+				update.Content = outputCache.Format(m.filters.Apply(update.Content), m.format)
+			}
+			m.writeMu.Lock()
+			err := writeTerminalUpdate(m.conn, update, m.stats)
+			m.writeMu.Unlock()
+			if err != nil {
+				log.FileOnlyWarningLog.Printf("WebSocket: Multiplexed connection closed sending '%s': %v", instance, err)
+				m.cancel()
+				return
+			}
+			if update.Type == types.EventInstanceRemoved {
+				m.mu.Lock()
+				if m.streams[instance] == stream {
+					delete(m.streams, instance)
+					m.stats.setTarget(m.targetLocked())
+				}
+				m.mu.Unlock()
+				return
+			}
+		}
+	}
+}
+
+// MultiplexWebSocketHandler streams the terminals of any number of instances over one WebSocket,
+// as described by serveMultiplexed. The instances query parameter lists those streamed from the
+// start, separated by commas; the client subscribes to others and unsubscribes with the subscribe
+// and unsubscribe commands.
+func MultiplexWebSocketHandler(storage session.InstanceStore, monitor types.TerminalMonitorInterface, preferences *prefs.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var instances []string
+		for _, instance := range strings.Split(r.URL.Query().Get("instances"), ",") {
+			if instance = strings.TrimSpace(instance); instance != "" {
+				instances = append(instances, instance)
+			}
+		}
+		if len(instances) > maxMultiplexedInstances {
+			http.Error(w, fmt.Sprintf("At most %d instances can be streamed over one connection", maxMultiplexedInstances),
+				http.StatusBadRequest)
+			return
+		}
+		serveMultiplexed(w, r, storage, monitor, preferences, instances, true, nil)
+	}
+}
+
+// serveMultiplexed upgrades r to a WebSocket streaming the terminals of instances. It sends a
+// config message with the caller's display preferences and the fields of extra, then the
+// TerminalUpdate messages of every instance, each with its instance_title. An instance that
+// doesn't exist gets an instance_removed notification instead.
+//
+// The client sends TerminalInput messages naming their instance in instance_title: terminal input
+// with read-write privileges, and the resize and get_tasks commands with any. When dynamic is set,
+// the subscribe and unsubscribe commands start and stop streaming the instance they name.
+func serveMultiplexed(w http.ResponseWriter, r *http.Request, storage session.InstanceStore, monitor types.TerminalMonitorInterface,
+	preferences *prefs.Store, instances []string, dynamic bool, extra map[string]interface{}) {
+	privileges := r.URL.Query().Get("privileges")
+	if privileges == "" {
+		privileges = "read-only"
+	}
+	if privileges != "read-only" && privileges != "read-write" {
+		http.Error(w, "Invalid privileges parameter", http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ansi"
+	}
+	if format != "ansi" && format != "html" && format != "text" {
+		http.Error(w, "Invalid format parameter", http.StatusBadRequest)
+		return
+	}
+	caps, capsDeclared := r.URL.Query()["caps"]
+
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:    4096,
+		WriteBufferSize:   4096,
+		EnableCompression: true,
+		CheckOrigin: func(r *http.Request) bool {
+			return true // Allow all origins, like the terminal WebSocket
+		},
+	}
+	kind := "layout"
+	if dynamic {
+		kind = "multiplex"
+	}
+	w, stats := trackConnection(w, r, kind, "")
+	defer stats.close()
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.FileOnlyErrorLog.Printf("WebSocket: Multiplexed upgrade failed for %s: %v", r.RemoteAddr, err)
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(maxInboundMessageSize)
+	conn.EnableWriteCompression(true)
+	if err := conn.SetCompressionLevel(compressionLevel); err != nil {
+		log.FileOnlyWarningLog.Printf("WebSocket: Could not set compression level: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	m := &multiplexer{
+		conn:    conn,
+		storage: storage,
+		monitor: monitor,
+		format:  format,
+		filters: filtersFromCapabilities(strings.Join(caps, ","), capsDeclared),
+		ctx:     ctx,
+		cancel:  cancel,
+		dynamic: dynamic,
+		streams: make(map[string]*muxStream),
+		stats:   stats,
+	}
+	defer m.wg.Wait()
+	defer cancel()
+	log.FileOnlyInfoLog.Printf("WebSocket: Multiplexed connection from %s for %v with privileges=%s",
+		r.RemoteAddr, instances, privileges)
+
+	display := displayPreferences(preferences, r)
+	config := map[string]interface{}{
+		"type":       "config",
+		"privileges": privileges,
+		"theme":      display.Theme,
+		"fontFamily": display.FontFamily,
+		"fontSize":   display.FontSize,
+		"filters":    m.filters,
+	}
+	for k, v := range extra {
+		config[k] = v
+	}
+	if err := m.write(config); err != nil {
+		return
+	}
+	for _, instance := range instances {
+		if err := m.subscribe(instance); err != nil {
+			err = m.write(types.TerminalUpdate{
+				InstanceTitle: instance,
+				Timestamp:     time.Now(),
+				Type:          types.EventInstanceRemoved,
+				Message:       err.Error(),
+			})
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(multiplexReadTimeout))
+	conn.SetPongHandler(func(string) error {
+		stats.ponged()
+		return conn.SetReadDeadline(time.Now().Add(multiplexReadTimeout))
+	})
+	go func() {
+		ticker := time.NewTicker(websocketPingInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-monitor.Done():
+				cancel()
+				return
+			case <-ticker.C:
+				m.writeMu.Lock()
+				stats.pinged()
+				err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second))
+				m.writeMu.Unlock()
+				if err != nil {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	// Reading blocks, so a cancelled connection is closed to stop it
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() == nil && websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.FileOnlyWarningLog.Printf("WebSocket: Multiplexed connection from %s failed: %v", r.RemoteAddr, err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(multiplexReadTimeout))
+		var input types.TerminalInput
+		if err := json.Unmarshal(message, &input); err != nil {
+			if m.write(map[string]string{"type": "error_response", "error": "Invalid message format"}) != nil {
+				return
+			}
+			continue
+		}
+		if m.write(m.handleInput(input, privileges)) != nil {
+			return
+		}
+	}
+}
+
+// handleInput applies input to its instance and returns the response to send.
+func (m *multiplexer) handleInput(input types.TerminalInput, privileges string) map[string]interface{} {
+	response := map[string]interface{}{
+		"type":           "input_response",
+		"instance_title": input.InstanceTitle,
+		"success":        false,
+	}
+	if input.IsCommand {
+		response["type"] = "command_response"
+		response["command"] = input.Content
+	}
+	if input.IsCommand && m.dynamic {
+		switch input.Content {
+		case "subscribe":
+			if err := m.subscribe(input.InstanceTitle); err != nil {
+				response["error"] = err.Error()
+				return response
+			}
+			response["success"] = true
+			return response
+		case "unsubscribe":
+			if !m.unsubscribe(input.InstanceTitle) {
+				response["error"] = fmt.Sprintf("Instance '%s' is not streamed on this connection", input.InstanceTitle)
+				return response
+			}
+			response["success"] = true
+			return response
+		}
+	}
+	if !m.streaming(input.InstanceTitle) {
+		response["error"] = fmt.Sprintf("Instance '%s' is not streamed on this connection", input.InstanceTitle)
+		return response
+	}
+
+	switch {
+	case input.IsCommand && input.Content == "get_tasks":
+		tasks, err := m.monitor.GetTasks(input.InstanceTitle)
+		if err != nil {
+			response["error"] = err.Error()
+			return response
+		}
+		response["tasks"] = tasks
+	case input.IsCommand && input.Content == "resize":
+		cols, colsOk := input.Cols.(float64)
+		rows, rowsOk := input.Rows.(float64)
+		if !colsOk || !rowsOk || cols <= 0 || rows <= 0 {
+			response["error"] = "Invalid dimensions"
+			return response
+		}
+		if err := m.monitor.ResizeTerminal(input.InstanceTitle, int(cols), int(rows)); err != nil {
+			response["error"] = fmt.Sprintf("Failed to resize terminal: %v", err)
+			return response
+		}
+	case input.IsCommand:
+		response["error"] = "Unknown command"
+		return response
+	case privileges != "read-write":
+		response["error"] = "Input needs read-write privileges"
+		return response
+	default:
+		if err := m.monitor.SendInput(input.InstanceTitle, input.Content); err != nil {
+			log.FileOnlyErrorLog.Printf("WebSocket: Error sending input to '%s': %v", input.InstanceTitle, err)
+			response["error"] = fmt.Sprintf("Failed to send input to '%s': %v", input.InstanceTitle, err)
+			return response
+		}
+	}
+	response["success"] = true
+	return response
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/mock"
+	"claude-squad/web/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// commandResponse is the reply to a command sent on a multiplexed connection.
+type commandResponse struct {
+	Type          string `json:"type"`
+	Command       string `json:"command"`
+	InstanceTitle string `json:"instance_title"`
+	Success       bool   `json:"success"`
+	Error         string `json:"error"`
+}
+
+func TestMultiplexWebSocketHandler(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	storage := mock.NewEmptyMockStorage()
+	for _, title := range []string{"api", "db"} {
+		instance, err := session.FromInstanceData(session.InstanceData{Title: title, Status: session.Running})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := storage.AddInstance(instance); err != nil {
+			t.Fatal(err)
+		}
+	}
+	monitor := newFakeMonitor()
+	server := httptest.NewServer(MultiplexWebSocketHandler(storage, monitor, nil))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	tooMany := strings.Repeat("x,", maxMultiplexedInstances) + "x"
+	if _, resp, err := websocket.DefaultDialer.Dial(url+"?instances="+tooMany, nil); err == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("dialing with too many instances: %v, want 400", err)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?instances=api", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var config map[string]interface{}
+	if err := conn.ReadJSON(&config); err != nil || config["type"] != "config" {
+		t.Fatalf("config = %v, %v", config, err)
+	}
+
+	command := func(name, instance string) commandResponse {
+		t.Helper()
+		if err := conn.WriteJSON(types.TerminalInput{InstanceTitle: instance, Content: name, IsCommand: true}); err != nil {
+			t.Fatal(err)
+		}
+		for {
+			var response commandResponse
+			if err := conn.ReadJSON(&response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Type == "command_response" {
+				return response
+			}
+		}
+	}
+
+	if response := command("subscribe", "db"); !response.Success || response.InstanceTitle != "db" {
+		t.Errorf("subscribe to db = %+v", response)
+	}
+	if response := command("subscribe", "gone"); response.Success {
+		t.Errorf("subscribe to a missing instance = %+v, want an error", response)
+	}
+
+	monitor.publish(t, "db", "db output")
+	monitor.publish(t, "api", "api output")
+	got := map[string]string{}
+	for len(got) < 2 {
+		var update types.TerminalUpdate
+		if err := conn.ReadJSON(&update); err != nil {
+			t.Fatal(err)
+		}
+		got[update.InstanceTitle] = update.Content
+	}
+	if got["api"] != "api output" || got["db"] != "db output" {
+		t.Errorf("updates = %v", got)
+	}
+
+	if response := command("unsubscribe", "api"); !response.Success {
+		t.Errorf("unsubscribe from api = %+v", response)
+	}
+	for deadline := time.Now().Add(5 * time.Second); monitor.subscribed("api"); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("api still subscribed after unsubscribing")
+		}
+	}
+	if response := command("unsubscribe", "api"); response.Success {
+		t.Errorf("second unsubscribe from api = %+v, want an error", response)
+	}
+	if response := command("resize", "api"); response.Success {
+		t.Errorf("resize of an unsubscribed instance = %+v, want an error", response)
+	}
+	if !monitor.subscribed("db") {
+		t.Error("db unsubscribed along with api")
+	}
+
+	conn.Close()
+	for deadline := time.Now().Add(5 * time.Second); monitor.subscribed("db"); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("db still subscribed after the connection closed")
+		}
+	}
+	waitClosed(t, "multiplex")
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"claude-squad/session"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EventAttention is the type of the AttentionUpdate messages of the event WebSocket.
+const EventAttention = "attention"
+
+// Notification is what a browser shows for an event, as a Web Notification.
+type Notification struct {
+	Title string `json:"title"`
+	Body  string `json:"body,omitempty"`
+	// Tag groups the notifications of an instance and event type, so a new one replaces the last.
+	Tag string `json:"tag"`
+}
+
+// AttentionUpdate lists the instances waiting for input, for a badge or the page title. It is sent
+// when the event WebSocket opens and whenever the list changes.
+type AttentionUpdate struct {
+	// Type is always attention.
+	Type           string    `json:"type"`
+	AwaitingPrompt []string  `json:"awaiting_prompt"`
+	Time           time.Time `json:"time"`
+}
+
+// parseNotify parses the notify parameter of the event WebSocket: comma separated stream event
+// types, such as instance_prompt_detected, or all. It returns the types to notify of, nil for
+// none.
+func parseNotify(value string) (map[string]bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	known := make(map[string]bool, len(session.EventTypes))
+	for _, t := range session.EventTypes {
+		known[streamEventType(t)] = true
+	}
+	if value == "all" {
+		return known, nil
+	}
+	types := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if !known[name] {
+			return nil, fmt.Errorf("unknown event type %q", name)
+		}
+		types[name] = true
+	}
+	return types, nil
+}
+
+// notificationFor returns the notification of e, or nil if it isn't worth interrupting for. Of
+// the status changes, only those to ready are: the instance is waiting for input.
+func notificationFor(e StreamEvent) *Notification {
+	n := &Notification{Tag: e.Instance + ":" + e.Type}
+	switch e.Type {
+	case streamEventType(session.EventPromptDetected):
+		n.Title = e.Instance + " needs input"
+		n.Body = e.Program + " is waiting on a prompt"
+	case streamEventType(session.EventStatusChanged):
+		if e.Status != session.Ready.String() {
+			return nil
+		}
+		n.Title = e.Instance + " is waiting for input"
+		n.Body = e.Program + " has finished working"
+	case streamEventType(session.EventError):
+		n.Title = e.Instance + " failed"
+		n.Body = e.Error
+	case streamEventType(session.EventCommandFinished):
+		n.Title = e.Instance + ": " + e.Command + " finished"
+		if e.Error != "" {
+			n.Title = e.Instance + ": " + e.Command + " failed"
+			n.Body = e.Error
+		}
+	default:
+		n.Title = e.Instance + " " + strings.ReplaceAll(strings.TrimPrefix(e.Type, "instance_"), "_", " ")
+	}
+	return n
+}
+
+// attentionTracker follows which instances are waiting for input from their events.
+type attentionTracker struct {
+	awaiting map[string]bool
+}
+
+func newAttentionTracker(awaiting []string) *attentionTracker {
+	a := &attentionTracker{awaiting: make(map[string]bool, len(awaiting))}
+	for _, title := range awaiting {
+		a.awaiting[title] = true
+	}
+	return a
+}
+
+// update records the status carried by e and reports whether the instances waiting changed.
+func (a *attentionTracker) update(e session.Event) bool {
+	waiting := e.Type != session.EventKilled && e.Status == session.Ready.String()
+	if a.awaiting[e.Instance] == waiting {
+		return false
+	}
+	if waiting {
+		a.awaiting[e.Instance] = true
+	} else {
+		delete(a.awaiting, e.Instance)
+	}
+	return true
+}
+
+// message returns the AttentionUpdate listing the instances waiting, by title.
+func (a *attentionTracker) message() AttentionUpdate {
+	titles := make([]string, 0, len(a.awaiting))
+	for title := range a.awaiting {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+	return AttentionUpdate{Type: EventAttention, AwaitingPrompt: titles, Time: time.Now()}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+// Output cache limits. Captures are up to a few hundred KB, so the byte limit is what usually
+// applies.
+const (
+	outputCacheEntries  = 256
+	outputCacheMaxBytes = 32 << 20
+)
+
+// outputConverters convert raw pane content to each output format.
+var outputConverters = map[string]func(string) string{
+	"ansi": sanitizeAnsiContent,
+	"html": convertAnsiToHtml,
+	"text": stripAnsi,
+}
+
+// outputKey identifies a conversion by the hash of the raw content and the format.
+type outputKey struct {
+	sum    [sha256.Size]byte
+	format string
+}
+
+type outputEntry struct {
+	key     outputKey
+	content string
+}
+
+// OutputCacheStats reports the effectiveness of the output cache.
+type OutputCacheStats struct {
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	Evictions uint64  `json:"evictions"`
+	Entries   int     `json:"entries"`
+	Bytes     int     `json:"bytes"`
+	HitRate   float64 `json:"hit_rate"`
+}
+
+// OutputCache is an LRU cache of instance output converted to a format, so clients polling the
+// same capture in different formats convert it once.
+type OutputCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	order      *list.List
+	entries    map[outputKey]*list.Element
+	stats      OutputCacheStats
+}
+
+// NewOutputCache returns a cache holding up to maxEntries conversions and maxBytes of converted
+// content.
+func NewOutputCache(maxEntries, maxBytes int) *OutputCache {
+	return &OutputCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		entries:    make(map[outputKey]*list.Element),
+	}
+}
+
+// outputCache is shared by the REST and WebSocket output handlers.
+var outputCache = NewOutputCache(outputCacheEntries, outputCacheMaxBytes)
+
+// Format returns content converted to format: "html", "text" or "ansi", the raw content without
+// a trailing incomplete escape sequence. Unknown formats are treated as "ansi".
+func (c *OutputCache) Format(content, format string) string {
+	if content == "" {
+		return content
+	}
+	convert, ok := outputConverters[format]
+	if !ok {
+		format, convert = "ansi", outputConverters["ansi"]
+	}
+	key := outputKey{sum: sha256.Sum256([]byte(content)), format: format}
+
+	c.mu.Lock()
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		c.stats.Hits++
+		c.mu.Unlock()
+		return element.Value.(*outputEntry).content
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// Convert without the lock; two requests racing on the same content both convert it
+	converted := convert(content)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok || len(converted) > c.maxBytes {
+		return converted
+	}
+	c.entries[key] = c.order.PushFront(&outputEntry{key: key, content: converted})
+	c.bytes += len(converted)
+	for c.order.Len() > c.maxEntries || c.bytes > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*outputEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.bytes -= len(entry.content)
+		c.stats.Evictions++
+	}
+	return converted
+}
+
+// Stats returns the hit counters and size of the cache.
+func (c *OutputCache) Stats() OutputCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	stats.Bytes = c.bytes
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
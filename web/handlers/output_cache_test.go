@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"strings"
+	"testing"
+)
+
+func TestOutputCacheFormat(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	cache := NewOutputCache(8, 1<<20)
+	frame := "\x1b[32mok\x1b[0m <done>\n\x1b[3"
+
+	if got := cache.Format(frame, "text"); got != "ok <done>\n\x1b[3" {
+		t.Errorf("text = %q", got)
+	}
+	if got := cache.Format(frame, "html"); !strings.Contains(got, "&lt;done&gt;") {
+		t.Errorf("html = %q, want escaped markup", got)
+	}
+	if got := cache.Format(frame, "ansi"); got != "\x1b[32mok\x1b[0m <done>\n" {
+		t.Errorf("ansi = %q, want the incomplete sequence dropped", got)
+	}
+	cache.Format(frame, "text")
+	cache.Format(frame, "unknown")
+
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 3 || stats.Entries != 3 {
+		t.Errorf("stats = %+v, want 2 hits, 3 misses and 3 entries", stats)
+	}
+	if stats.HitRate != 0.4 {
+		t.Errorf("hit rate = %v, want 0.4", stats.HitRate)
+	}
+}
+
+func TestOutputCacheEviction(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int
+	}{
+		{name: "entries", maxEntries: 2, maxBytes: 1 << 20},
+		{name: "bytes", maxEntries: 8, maxBytes: 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewOutputCache(tt.maxEntries, tt.maxBytes)
+			a, b, c := strings.Repeat("a", 10), strings.Repeat("b", 10), strings.Repeat("c", 10)
+			cache.Format(a, "text")
+			cache.Format(b, "text")
+			cache.Format(a, "text") // a is now the most recently used
+			cache.Format(c, "text") // evicts b
+
+			stats := cache.Stats()
+			if stats.Evictions != 1 || stats.Entries != 2 || stats.Bytes != 20 {
+				t.Fatalf("stats = %+v, want 1 eviction leaving 2 entries of 20 bytes", stats)
+			}
+			cache.Format(a, "text")
+			cache.Format(b, "text")
+			if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 4 {
+				t.Errorf("stats = %+v, want a kept and b evicted", stats)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"claude-squad/web/types"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// A long poll of instance output waits defaultOutputWait unless asked otherwise, and at most
+// maxOutputWait so it is answered within the server's 30 second write timeout.
+const (
+	defaultOutputWait = 20 * time.Second
+	maxOutputWait     = 25 * time.Second
+)
+
+// pollOutput answers a long poll for the output of instance newer than the since query
+// parameter, a sequence returned by an earlier poll or 0 for the current output. It waits for
+// new output on the monitor, like the terminal WebSocket, for the wait query parameter in
+// seconds and answers 204 No Content if there was none.
+func pollOutput(w http.ResponseWriter, r *http.Request, monitor types.TerminalMonitorInterface, instance, format string) {
+	since, err := strconv.ParseUint(r.URL.Query().Get("since"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid since parameter", http.StatusBadRequest)
+		return
+	}
+	wait := defaultOutputWait
+	if v := r.URL.Query().Get("wait"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 || time.Duration(seconds)*time.Second > maxOutputWait {
+			http.Error(w, "Invalid wait parameter", http.StatusBadRequest)
+			return
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+
+	// Capture output the monitor dropped or didn't poll yet, so subscribing sends it
+	monitor.GetContent(instance)
+	updates := monitor.Subscribe(instance)
+	defer monitor.Unsubscribe(instance, updates)
+	expired := time.After(wait)
+	for {
+		var update types.TerminalUpdate
+		var ok bool
+		// Queued output wins over an expired wait, so wait=0 returns the current output
+		select {
+		case update, ok = <-updates:
+		default:
+			select {
+			case update, ok = <-updates:
+			case <-expired:
+				w.WriteHeader(http.StatusNoContent)
+				return
+			case <-r.Context().Done():
+				return
+			case <-monitor.Done():
+				http.Error(w, "Terminal monitor stopped", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		if !ok {
+			http.Error(w, "Terminal monitor stopped", http.StatusServiceUnavailable)
+			return
+		}
+		if update.Type == types.EventInstanceRemoved {
+			http.Error(w, "Instance not found", http.StatusNotFound)
+			return
+		}
+		if update.Type != "" || update.Seq <= since {
+			continue
+		}
+		writeTemplateJSON(w, http.StatusOK, InstanceOutput{
+			Content:   outputCache.Format(update.Content, format),
+			Format:    format,
+			Timestamp: update.Timestamp,
+			HasPrompt: update.HasPrompt,
+			Seq:       update.Seq,
+		})
+		return
+	}
+}
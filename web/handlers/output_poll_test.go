@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/mock"
+	"claude-squad/web/types"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestInstanceOutputLongPoll(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	storage := mock.NewEmptyMockStorage()
+	// Restored from metadata, the instance counts as started without a tmux session
+	if err := storage.AddInstance(session.FromInstanceMetadata(session.InstanceData{Title: "task"})); err != nil {
+		t.Fatal(err)
+	}
+	monitor := newFakeMonitor()
+	poll := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/instances/task/output"+query, nil)
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("name", "task")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+		rec := httptest.NewRecorder()
+		InstanceOutputHandler(storage, monitor)(rec, req)
+		return rec
+	}
+
+	for _, query := range []string{"?since=x", "?since=0&wait=-1", "?since=0&wait=60"} {
+		if rec := poll(query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rec.Code)
+		}
+	}
+	if rec := poll("?since=0&wait=0"); rec.Code != http.StatusNoContent {
+		t.Errorf("poll without output: status = %d, want 204", rec.Code)
+	}
+
+	// Output no newer than since keeps the poll waiting
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- poll("?since=5&wait=5") }()
+	monitor.send(t, types.TerminalUpdate{InstanceTitle: "task", Content: "old", Seq: 5})
+	monitor.send(t, types.TerminalUpdate{InstanceTitle: "task", Content: "new", Seq: 7, Timestamp: time.Now()})
+	rec := <-done
+	var output InstanceOutput
+	if err := json.NewDecoder(rec.Body).Decode(&output); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("long poll: status %d, %v", rec.Code, err)
+	}
+	if output.Content != "new" || output.Seq != 7 {
+		t.Errorf("long poll = %+v, want new at 7", output)
+	}
+
+	go func() { done <- poll("?since=7&wait=5") }()
+	monitor.send(t, types.TerminalUpdate{InstanceTitle: "task", Type: types.EventInstanceRemoved})
+	if rec := <-done; rec.Code != http.StatusNotFound {
+		t.Errorf("poll of a removed instance: status = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// pingIntervals holds how often connections are pinged to keep them open.
+var pingIntervals = struct {
+	mu        sync.RWMutex
+	websocket time.Duration
+	terminal  time.Duration
+}{websocket: 30 * time.Second, terminal: 15 * time.Second}
+
+// SetPingIntervals sets how often output WebSocket streams and terminal connections opened from
+// now on are pinged.
+func SetPingIntervals(websocket, terminal time.Duration) {
+	pingIntervals.mu.Lock()
+	defer pingIntervals.mu.Unlock()
+	pingIntervals.websocket = websocket
+	pingIntervals.terminal = terminal
+}
+
+func websocketPingInterval() time.Duration {
+	pingIntervals.mu.RLock()
+	defer pingIntervals.mu.RUnlock()
+	return pingIntervals.websocket
+}
+
+func terminalPingInterval() time.Duration {
+	pingIntervals.mu.RLock()
+	defer pingIntervals.mu.RUnlock()
+	return pingIntervals.terminal
+}
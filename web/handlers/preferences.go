@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/web/middleware"
+	"claude-squad/web/prefs"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// maxPreferencesBytes caps the size of a preferences update body.
+const maxPreferencesBytes = 4 << 10
+
+// ownerPreferences is the user of the requests not made with a token scoped to an instance.
+const ownerPreferences = "owner"
+
+// DisplayUpdate is the body of a display preferences update. Fields left out are not changed.
+type DisplayUpdate struct {
+	// Theme is one of dark, light, high-contrast, solarized-dark and solarized-light.
+	Theme      *string `json:"theme,omitempty"`
+	FontFamily *string `json:"font_family,omitempty"`
+	// FontSize is in pixels, from 6 to 48.
+	FontSize *int `json:"font_size,omitempty"`
+}
+
+// preferencesUser returns whose preferences r reads and changes: the token's for a request made
+// with a token scoped to an instance, and otherwise the owner's, from any browser or device.
+func preferencesUser(r *http.Request) string {
+	if t, ok := middleware.ScopedToken(r); ok {
+		return "token:" + t.ID
+	}
+	return ownerPreferences
+}
+
+// displayPreferences returns the display preferences of the user of r, or the defaults if store
+// is nil or can't be read.
+func displayPreferences(store *prefs.Store, r *http.Request) prefs.Display {
+	if store == nil {
+		return prefs.DefaultDisplay()
+	}
+	display, err := store.Get(preferencesUser(r))
+	if err != nil {
+		log.FileOnlyErrorLog.Printf("API: Error loading display preferences: %v", err)
+	}
+	return display
+}
+
+// PreferencesHandler returns the display preferences of the caller on GET and applies a
+// DisplayUpdate to them on PATCH, responding with the result.
+func PreferencesHandler(store *prefs.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := preferencesUser(r)
+		display, err := store.Get(user)
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error loading display preferences: %v", err)
+			http.Error(w, "Error loading preferences", http.StatusInternalServerError)
+			return
+		}
+		if r.Method != http.MethodPatch {
+			writeTemplateJSON(w, http.StatusOK, display)
+			return
+		}
+
+		var update DisplayUpdate
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPreferencesBytes)).Decode(&update); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if update.Theme != nil {
+			display.Theme = *update.Theme
+		}
+		if update.FontFamily != nil {
+			display.FontFamily = *update.FontFamily
+		}
+		if update.FontSize != nil {
+			display.FontSize = *update.FontSize
+		}
+		if err := display.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := store.Save(user, display); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error saving display preferences: %v", err)
+			http.Error(w, "Error saving preferences", http.StatusInternalServerError)
+			return
+		}
+		writeTemplateJSON(w, http.StatusOK, display)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/web/prefs"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreferencesHandler(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	store := prefs.NewStoreAt(filepath.Join(t.TempDir(), prefs.FileName))
+	handler := PreferencesHandler(store)
+	request := func(method, body string) (*httptest.ResponseRecorder, prefs.Display) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(method, "/api/preferences", strings.NewReader(body)))
+		var d prefs.Display
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &d); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec, d
+	}
+
+	if rec, d := request(http.MethodGet, ""); rec.Code != http.StatusOK || d != prefs.DefaultDisplay() {
+		t.Fatalf("GET = %d %+v, want the defaults", rec.Code, d)
+	}
+
+	rec, d := request(http.MethodPatch, `{"theme": "solarized-light", "font_size": 18}`)
+	want := prefs.DefaultDisplay()
+	want.Theme, want.FontSize = "solarized-light", 18
+	if rec.Code != http.StatusOK || d != want {
+		t.Fatalf("PATCH = %d %+v, want %+v", rec.Code, d, want)
+	}
+	if _, d := request(http.MethodGet, ""); d != want {
+		t.Errorf("GET after PATCH = %+v, want %+v", d, want)
+	}
+
+	for _, body := range []string{`{"theme": "neon"}`, `{"font_size": 2}`, `{"font_family": "<script>"}`, `{`} {
+		if rec, _ := request(http.MethodPatch, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("PATCH %s = %d, want 400", body, rec.Code)
+		}
+	}
+	if _, d := request(http.MethodGet, ""); d != want {
+		t.Errorf("invalid updates changed the preferences to %+v", d)
+	}
+}
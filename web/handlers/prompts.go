@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// InstancePrompts is the response of the prompts endpoints.
+type InstancePrompts struct {
+	Instance string `json:"instance"`
+	// Prompts are the prompts sent to the instance, oldest first. The position of a prompt is the
+	// index that replays it.
+	Prompts []session.PromptRecord `json:"prompts"`
+}
+
+// PromptsHandler returns the prompt history of an instance: the prompts sent to it from the TUI,
+// the web UI or the API, with the time each was sent.
+func PromptsHandler(storage session.InstanceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			http.Error(w, "Instance name required", http.StatusBadRequest)
+			return
+		}
+		instance, err := findInstanceByTitle(storage, name)
+		if err != nil {
+			http.Error(w, "Instance not found", http.StatusNotFound)
+			return
+		}
+		writeTemplateJSON(w, http.StatusOK, instancePrompts(instance))
+	}
+}
+
+// PromptReplayHandler sends the prompt at index in the history of an instance again, and responds
+// with the history it was added to.
+func PromptReplayHandler(storage session.InstanceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			http.Error(w, "Instance name required", http.StatusBadRequest)
+			return
+		}
+		index, err := strconv.Atoi(chi.URLParam(r, "index"))
+		if err != nil || index < 0 {
+			http.Error(w, "Invalid prompt index", http.StatusBadRequest)
+			return
+		}
+		instance, err := findInstanceByTitle(storage, name)
+		if err != nil {
+			http.Error(w, "Instance not found", http.StatusNotFound)
+			return
+		}
+		if index >= len(instance.PromptHistory()) {
+			http.Error(w, "Prompt not found", http.StatusNotFound)
+			return
+		}
+		if !instance.Started() || instance.Paused() {
+			http.Error(w, "Instance isn't running", http.StatusConflict)
+			return
+		}
+		if _, err := instance.ReplayPrompt(index); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error replaying prompt %d of '%s': %v", index, name, err)
+			http.Error(w, "Error sending prompt: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		log.FileOnlyInfoLog.Printf("API: prompt %d of '%s' replayed from %s", index, name, r.RemoteAddr)
+		writeTemplateJSON(w, http.StatusOK, instancePrompts(instance))
+	}
+}
+
+func instancePrompts(instance *session.Instance) InstancePrompts {
+	prompts := instance.PromptHistory()
+	if prompts == nil {
+		prompts = []session.PromptRecord{}
+	}
+	return InstancePrompts{Instance: instance.Title, Prompts: prompts}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/mock"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func promptsRequest(handler http.HandlerFunc, method, name, index string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/instances/"+name+"/prompts", nil)
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("name", name)
+	if index != "" {
+		routeCtx.URLParams.Add("index", index)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestPromptsHandlers(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	storage := mock.NewEmptyMockStorage()
+	sentAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	instance, err := session.FromInstanceData(session.InstanceData{
+		Title:   "task",
+		Status:  session.Paused,
+		Prompts: []session.PromptRecord{{Prompt: "add tests", SentAt: sentAt}, {Prompt: "fix the lint", SentAt: sentAt.Add(time.Minute)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.AddInstance(instance); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := promptsRequest(PromptsHandler(storage), http.MethodGet, "task", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var prompts InstancePrompts
+	if err := json.Unmarshal(rec.Body.Bytes(), &prompts); err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts.Prompts) != 2 || prompts.Prompts[0].Prompt != "add tests" || !prompts.Prompts[1].SentAt.Equal(sentAt.Add(time.Minute)) {
+		t.Errorf("prompts = %+v, want the history oldest first", prompts.Prompts)
+	}
+	if rec := promptsRequest(PromptsHandler(storage), http.MethodGet, "missing", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("prompts of a missing instance = %d, want 404", rec.Code)
+	}
+
+	replay := PromptReplayHandler(storage)
+	for index, want := range map[string]int{"x": http.StatusBadRequest, "-1": http.StatusBadRequest, "2": http.StatusNotFound, "0": http.StatusConflict} {
+		if rec := promptsRequest(replay, http.MethodPost, "task", index); rec.Code != want {
+			t.Errorf("replay of prompt %s of a paused instance = %d, want %d", index, rec.Code, want)
+		}
+	}
+}
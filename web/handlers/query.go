@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxInstanceLimit caps the page size of the instance list.
+const maxInstanceLimit = 500
+
+// instanceSortFields maps the values accepted by the sort parameter to a less function.
+var instanceSortFields = map[string]func(a, b InstanceSummary) bool{
+	"title":      func(a, b InstanceSummary) bool { return a.Title < b.Title },
+	"status":     func(a, b InstanceSummary) bool { return a.Status < b.Status },
+	"created_at": func(a, b InstanceSummary) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	"updated_at": func(a, b InstanceSummary) bool { return a.UpdatedAt.Before(b.UpdatedAt) },
+}
+
+// instanceSummaryFields is the set of JSON keys of InstanceSummary accepted by the fields
+// parameter. They are read from the struct tags, as marshaling leaves out empty omitempty fields.
+var instanceSummaryFields = func() map[string]bool {
+	t := reflect.TypeOf(InstanceSummary{})
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// instanceQuery holds the list options parsed from the query string of GET /api/instances.
+type instanceQuery struct {
+	// statuses keeps only instances with one of these statuses. Empty keeps all.
+	statuses map[string]bool
+	// program keeps only instances whose program command contains this string.
+	program string
+	// tags keeps only instances with one of these tags. Empty keeps all.
+	tags []string
+	// sortBy is a key of instanceSortFields, empty for storage order.
+	sortBy string
+	desc   bool
+	// limit is the maximum number of instances returned, 0 for all.
+	limit  int
+	offset int
+	// fields restricts each returned instance to these JSON keys. Empty returns all of them.
+	fields []string
+}
+
+// parseInstanceQuery parses limit, offset, sort, status, program, tag and fields. sort takes a
+// field name, prefixed with "-" for descending order. status, tag and fields take comma separated
+// lists.
+func parseInstanceQuery(values url.Values) (instanceQuery, error) {
+	var q instanceQuery
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return q, fmt.Errorf("invalid limit %q", v)
+		}
+		q.limit = min(limit, maxInstanceLimit)
+	}
+	if v := values.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return q, fmt.Errorf("invalid offset %q", v)
+		}
+		q.offset = offset
+	}
+
+	if v := values.Get("sort"); v != "" {
+		q.sortBy = strings.TrimPrefix(v, "-")
+		q.desc = strings.HasPrefix(v, "-")
+		if _, ok := instanceSortFields[q.sortBy]; !ok {
+			return q, fmt.Errorf("invalid sort field %q", q.sortBy)
+		}
+	}
+
+	for _, status := range splitList(values.Get("status")) {
+		if q.statuses == nil {
+			q.statuses = make(map[string]bool)
+		}
+		q.statuses[status] = true
+	}
+	q.program = values.Get("program")
+	q.tags = splitList(values.Get("tag"))
+
+	for _, field := range splitList(values.Get("fields")) {
+		if !instanceSummaryFields[field] {
+			return q, fmt.Errorf("invalid field %q", field)
+		}
+		q.fields = append(q.fields, field)
+	}
+
+	return q, nil
+}
+
+// splitList splits a comma separated list, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// match returns true if summary passes the status, program and tag filters.
+func (q instanceQuery) match(summary InstanceSummary) bool {
+	if len(q.statuses) > 0 && !q.statuses[summary.Status] {
+		return false
+	}
+	if len(q.tags) > 0 && !slices.ContainsFunc(q.tags, func(tag string) bool {
+		return slices.Contains(summary.Tags, tag)
+	}) {
+		return false
+	}
+	return q.program == "" || strings.Contains(summary.Program, q.program)
+}
+
+// apply filters, sorts and pages summaries. It returns the page and the number of instances that
+// matched before paging.
+func (q instanceQuery) apply(summaries []InstanceSummary) ([]InstanceSummary, int) {
+	matched := make([]InstanceSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		if q.match(summary) {
+			matched = append(matched, summary)
+		}
+	}
+
+	if less, ok := instanceSortFields[q.sortBy]; ok {
+		sort.SliceStable(matched, func(i, j int) bool {
+			if q.desc {
+				return less(matched[j], matched[i])
+			}
+			return less(matched[i], matched[j])
+		})
+	}
+
+	total := len(matched)
+	if q.offset >= total {
+		return []InstanceSummary{}, total
+	}
+	matched = matched[q.offset:]
+	if q.limit > 0 && q.limit < len(matched) {
+		matched = matched[:q.limit]
+	}
+	return matched, total
+}
+
+// project returns the summaries to encode, restricted to the requested fields.
+func (q instanceQuery) project(summaries []InstanceSummary) (interface{}, error) {
+	if len(q.fields) == 0 {
+		return summaries, nil
+	}
+
+	projected := make([]map[string]json.RawMessage, 0, len(summaries))
+	for _, summary := range summaries {
+		data, err := json.Marshal(summary)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+		selected := make(map[string]json.RawMessage, len(q.fields))
+		for _, field := range q.fields {
+			selected[field] = all[field]
+		}
+		projected = append(projected, selected)
+	}
+	return projected, nil
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseInstanceQueryErrors(t *testing.T) {
+	tests := []string{
+		"limit=-1",
+		"limit=ten",
+		"offset=-5",
+		"sort=name",
+		"fields=title,secret",
+	}
+
+	for _, raw := range tests {
+		t.Run(raw, func(t *testing.T) {
+			values, _ := url.ParseQuery(raw)
+			if _, err := parseInstanceQuery(values); err == nil {
+				t.Errorf("parseInstanceQuery(%q) succeeded, want error", raw)
+			}
+		})
+	}
+}
+
+func TestInstanceQueryApply(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	summaries := []InstanceSummary{
+		{Title: "a", Status: "running", Program: "claude", UpdatedAt: base.Add(2 * time.Hour), Tags: []string{"backend"}},
+		{Title: "b", Status: "paused", Program: "aider", UpdatedAt: base.Add(1 * time.Hour), Tags: []string{"frontend", "ci"}},
+		{Title: "c", Status: "ready", Program: "claude --model opus", UpdatedAt: base.Add(3 * time.Hour), Tags: []string{"ci"}},
+		{Title: "d", Status: "running", Program: "aider", UpdatedAt: base},
+	}
+
+	tests := []struct {
+		query     string
+		wantTitle []string
+		wantTotal int
+	}{
+		{query: "", wantTitle: []string{"a", "b", "c", "d"}, wantTotal: 4},
+		{query: "sort=updated_at", wantTitle: []string{"d", "b", "a", "c"}, wantTotal: 4},
+		{query: "sort=-updated_at&limit=2", wantTitle: []string{"c", "a"}, wantTotal: 4},
+		{query: "sort=title&offset=3", wantTitle: []string{"d"}, wantTotal: 4},
+		{query: "offset=10", wantTitle: []string{}, wantTotal: 4},
+		{query: "status=running,ready", wantTitle: []string{"a", "c", "d"}, wantTotal: 3},
+		{query: "program=claude&sort=-title", wantTitle: []string{"c", "a"}, wantTotal: 2},
+		{query: "status=running&limit=1&offset=1", wantTitle: []string{"d"}, wantTotal: 2},
+		{query: "tag=ci", wantTitle: []string{"b", "c"}, wantTotal: 2},
+		{query: "tag=backend,frontend&status=running", wantTitle: []string{"a"}, wantTotal: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			q, err := parseInstanceQuery(values)
+			if err != nil {
+				t.Fatalf("parseInstanceQuery(%q) error: %v", tt.query, err)
+			}
+			page, total := q.apply(summaries)
+			titles := make([]string, 0, len(page))
+			for _, s := range page {
+				titles = append(titles, s.Title)
+			}
+			if !reflect.DeepEqual(titles, tt.wantTitle) || total != tt.wantTotal {
+				t.Errorf("apply(%q) = %v (total %d), want %v (total %d)", tt.query, titles, total, tt.wantTitle, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestInstanceQueryOmittedFields(t *testing.T) {
+	values, _ := url.ParseQuery("fields=title,tags,ticket,vcs")
+	q, err := parseInstanceQuery(values)
+	if err != nil {
+		t.Fatalf("parseInstanceQuery() error: %v", err)
+	}
+	result, err := q.project([]InstanceSummary{{Title: "a", Tags: []string{"ci"}}})
+	if err != nil {
+		t.Fatalf("project() error: %v", err)
+	}
+	projected := result.([]map[string]json.RawMessage)
+	if string(projected[0]["tags"]) != `["ci"]` || projected[0]["ticket"] != nil {
+		t.Errorf("project() = %v", projected[0])
+	}
+}
+
+func TestInstanceQueryProject(t *testing.T) {
+	values, _ := url.ParseQuery("fields=title,status")
+	q, err := parseInstanceQuery(values)
+	if err != nil {
+		t.Fatalf("parseInstanceQuery() error: %v", err)
+	}
+
+	result, err := q.project([]InstanceSummary{{Title: "a", Status: "running", Program: "claude"}})
+	if err != nil {
+		t.Fatalf("project() error: %v", err)
+	}
+	projected := result.([]map[string]json.RawMessage)
+	if len(projected) != 1 || len(projected[0]) != 2 {
+		t.Fatalf("project() = %v, want one instance with two fields", projected)
+	}
+	if string(projected[0]["title"]) != `"a"` || string(projected[0]["status"]) != `"running"` {
+		t.Errorf("project() = %v", projected[0])
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Client capability flags accepted in the "caps" query parameter of the
+// WebSocket handshake. A client that sends the parameter declares the full
+// set of sequences it can handle; anything it omits is filtered server side.
+// Clients that omit the parameter receive unfiltered content.
+const (
+	capOSCTitle  = "osc-title"  // Window/icon title OSC sequences
+	capAltScreen = "alt-screen" // Alternate screen buffer switches
+	capCR        = "cr"         // Bare carriage returns used to redraw lines
+)
+
+var (
+	// oscTitleRegex matches OSC 0/1/2 title sequences terminated by BEL or ST
+	oscTitleRegex = regexp.MustCompile(`\x1b\][012];[^\x07\x1b]*(?:\x07|\x1b\\)`)
+	// altScreenRegex matches DEC private modes that switch to or from the
+	// alternate screen buffer
+	altScreenRegex = regexp.MustCompile(`\x1b\[\?(?:1049|1047|47)[hl]`)
+)
+
+// ContentFilters describes the sanitization applied to terminal content for
+// a single connection.
+type ContentFilters struct {
+	StripOSCTitles bool `json:"strip_osc_titles"`
+	StripAltScreen bool `json:"strip_alt_screen"`
+	NormalizeCR    bool `json:"normalize_cr"`
+}
+
+// filtersFromCapabilities builds the filters for a connection from the
+// comma separated capability list sent by the client.
+func filtersFromCapabilities(caps string, declared bool) ContentFilters {
+	if !declared {
+		return ContentFilters{}
+	}
+
+	supported := make(map[string]bool)
+	for _, c := range strings.Split(caps, ",") {
+		if c = strings.TrimSpace(strings.ToLower(c)); c != "" {
+			supported[c] = true
+		}
+	}
+
+	return ContentFilters{
+		StripOSCTitles: !supported[capOSCTitle],
+		StripAltScreen: !supported[capAltScreen],
+		NormalizeCR:    !supported[capCR],
+	}
+}
+
+// Enabled reports whether any filter is active.
+func (f ContentFilters) Enabled() bool {
+	return f.StripOSCTitles || f.StripAltScreen || f.NormalizeCR
+}
+
+// Apply runs the enabled filters over content.
+func (f ContentFilters) Apply(content string) string {
+	if f.StripOSCTitles {
+		content = oscTitleRegex.ReplaceAllString(content, "")
+	}
+	if f.StripAltScreen {
+		content = altScreenRegex.ReplaceAllString(content, "")
+	}
+	if f.NormalizeCR {
+		// Collapse CRLF first so it doesn't become a blank line, then treat
+		// any remaining bare CR as a line break
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+		content = strings.ReplaceAll(content, "\r", "\n")
+	}
+	return content
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import "testing"
+
+func TestFiltersFromCapabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		caps     string
+		declared bool
+		want     ContentFilters
+	}{
+		{name: "not declared", caps: "", declared: false, want: ContentFilters{}},
+		{name: "declared empty", caps: "", declared: true, want: ContentFilters{true, true, true}},
+		{name: "full support", caps: "osc-title,alt-screen,cr", declared: true, want: ContentFilters{}},
+		{name: "partial", caps: " CR , osc-title", declared: true, want: ContentFilters{StripAltScreen: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filtersFromCapabilities(tt.caps, tt.declared); got != tt.want {
+				t.Errorf("filtersFromCapabilities(%q, %v) = %+v, want %+v", tt.caps, tt.declared, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentFiltersApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters ContentFilters
+		input   string
+		want    string
+	}{
+		{
+			name:    "no filters",
+			filters: ContentFilters{},
+			input:   "\x1b]0;title\x07\x1b[?1049hhi\r\n",
+			want:    "\x1b]0;title\x07\x1b[?1049hhi\r\n",
+		},
+		{
+			name:    "osc title with BEL and ST",
+			filters: ContentFilters{StripOSCTitles: true},
+			input:   "\x1b]0;one\x07a\x1b]2;two\x1b\\b",
+			want:    "ab",
+		},
+		{
+			name:    "alternate screen",
+			filters: ContentFilters{StripAltScreen: true},
+			input:   "\x1b[?1049hbody\x1b[?47l\x1b[31mred",
+			want:    "body\x1b[31mred",
+		},
+		{
+			name:    "carriage returns",
+			filters: ContentFilters{NormalizeCR: true},
+			input:   "a\r\nb\rc",
+			want:    "a\nb\nc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filters.Apply(tt.input); got != tt.want {
+				t.Errorf("Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
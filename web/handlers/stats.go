@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// A stats request lists defaultStatsFiles files unless asked otherwise, and at most
+// maxStatsFiles.
+const (
+	defaultStatsFiles = 20
+	maxStatsFiles     = 500
+)
+
+// InstanceStats is the response of the stats endpoint.
+type InstanceStats struct {
+	Instance string `json:"instance"`
+	Branch   string `json:"branch"`
+	git.ChangeStats
+}
+
+// StatsHandler describes how the changes of an instance grew since its worktree was created:
+// the lines each commit, snapshot and the uncommitted changes changed, the files changed most
+// often, and how many of them are tests. top caps the files listed.
+func StatsHandler(storage session.InstanceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			http.Error(w, "Instance name required", http.StatusBadRequest)
+			return
+		}
+
+		top := defaultStatsFiles
+		if value := r.URL.Query().Get("top"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxStatsFiles {
+				http.Error(w, "Invalid top parameter", http.StatusBadRequest)
+				return
+			}
+			top = n
+		}
+
+		instance, err := findInstanceByTitle(storage, name)
+		if err != nil {
+			http.Error(w, "Instance not found", http.StatusNotFound)
+			return
+		}
+		if instance.Paused() {
+			http.Error(w, "Instance is not running", http.StatusBadRequest)
+			return
+		}
+		worktree, err := instance.GetGitWorktree()
+		if err != nil {
+			http.Error(w, "Instance is not running in a worktree", http.StatusBadRequest)
+			return
+		}
+
+		stats, err := worktree.ChangeStats()
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error computing stats of %s: %v", name, err)
+			http.Error(w, "Error computing stats", http.StatusInternalServerError)
+			return
+		}
+		stats.Files = stats.Files[:min(len(stats.Files), top)]
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(InstanceStats{Instance: name, Branch: worktree.GetBranchName(), ChangeStats: *stats}); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error encoding stats: %v", err)
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"claude-squad/daemon"
+	"claude-squad/log"
+	"claude-squad/session"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// DaemonStatus reports the auto-yes daemon.
+type DaemonStatus struct {
+	Running bool `json:"running"`
+	PID     int  `json:"pid,omitempty"`
+	// Stats are what the daemon reports while it runs: its poll interval, the tags it is scoped
+	// to, the instances it watches and the prompts it accepted in each.
+	Stats *daemon.Stats `json:"stats,omitempty"`
+}
+
+// Summary is an overview of the whole squad, small enough to poll from a status bar.
+type Summary struct {
+	session.Summary
+	Daemon DaemonStatus `json:"daemon"`
+	// UptimeSeconds is how long the web server has been running.
+	UptimeSeconds int64 `json:"uptime_seconds"`
+}
+
+// SummaryHandler returns the squad summary: instance counts by status, total diff stats, the
+// instances awaiting a prompt, the daemon status from daemonStatus and the server uptime since
+// startTime. Diff stats are the last ones recorded, so no git command is run.
+func SummaryHandler(storage session.InstanceStore, startTime time.Time, daemonStatus func() DaemonStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		instances, err := storage.LoadInstances()
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error loading instances for summary: %v", err)
+			http.Error(w, "Error loading instances", http.StatusInternalServerError)
+			return
+		}
+
+		data := make([]session.InstanceData, 0, len(instances))
+		for _, instance := range instances {
+			data = append(data, instance.ToInstanceData())
+		}
+		summary := Summary{
+			Summary:       session.Summarize(data),
+			Daemon:        daemonStatus(),
+			UptimeSeconds: int64(time.Since(startTime).Seconds()),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(summary); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error encoding summary: %v", err)
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
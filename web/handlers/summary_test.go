@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"claude-squad/daemon"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/mock"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSummaryHandler(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	storage := mock.NewEmptyMockStorage()
+	for _, data := range []session.InstanceData{
+		{Title: "busy", Status: session.Running, DiffStats: session.DiffStatsData{Added: 10, Removed: 2}},
+		{Title: "waiting", Status: session.Ready, DiffStats: session.DiffStatsData{Added: 5, Removed: 1}},
+		{Title: "parked", Status: session.Paused},
+	} {
+		instance, err := session.FromInstanceData(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := storage.AddInstance(instance); err != nil {
+			t.Fatal(err)
+		}
+	}
+	daemonStatus := func() DaemonStatus {
+		return DaemonStatus{Running: true, PID: 42, Stats: &daemon.Stats{
+			PollIntervalMs: 1000, Tags: []string{"nightly"}, Watched: []string{"busy"}, Accepted: map[string]int{"busy": 3},
+		}}
+	}
+
+	rec := httptest.NewRecorder()
+	SummaryHandler(storage, time.Now().Add(-time.Minute), daemonStatus)(rec, httptest.NewRequest(http.MethodGet, "/api/summary", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var summary Summary
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatal(err)
+	}
+
+	wantByStatus := map[string]int{"running": 1, "ready": 1, "loading": 0, "paused": 1, "exited": 0}
+	if summary.Instances != 3 || !reflect.DeepEqual(summary.ByStatus, wantByStatus) {
+		t.Errorf("instances = %d, by status = %v, want 3 and %v", summary.Instances, summary.ByStatus, wantByStatus)
+	}
+	if summary.Added != 15 || summary.Removed != 3 {
+		t.Errorf("diff = +%d -%d, want +15 -3", summary.Added, summary.Removed)
+	}
+	if !reflect.DeepEqual(summary.AwaitingPrompt, []string{"waiting"}) {
+		t.Errorf("awaiting prompt = %v, want [waiting]", summary.AwaitingPrompt)
+	}
+	if !summary.Daemon.Running || summary.Daemon.PID != 42 || summary.UptimeSeconds < 60 {
+		t.Errorf("daemon = %+v, uptime = %ds", summary.Daemon, summary.UptimeSeconds)
+	}
+	if stats := summary.Daemon.Stats; stats == nil || stats.Accepted["busy"] != 3 || !reflect.DeepEqual(stats.Tags, []string{"nightly"}) {
+		t.Errorf("daemon stats = %+v, want the prompts accepted and the tags", stats)
+	}
+}
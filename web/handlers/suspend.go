@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"encoding/json"
+	"net/http"
+)
+
+// SuspendState reports whether every agent has been suspended.
+type SuspendState struct {
+	Suspended bool   `json:"suspended"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SuspendHandler reports the global suspend state on GET. On POST it suspends every running
+// instance, or resumes them if they are already suspended.
+func SuspendHandler(storage session.InstanceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state := SuspendState{Suspended: session.Suspended()}
+
+		if r.Method == http.MethodPost {
+			instances, err := storage.LoadInstances()
+			if err != nil {
+				log.FileOnlyErrorLog.Printf("API: Error loading instances for suspend: %v", err)
+				http.Error(w, "Error loading instances", http.StatusInternalServerError)
+				return
+			}
+
+			suspended, err := session.ToggleSuspendAll(instances)
+			state.Suspended = suspended
+			if err != nil {
+				// The toggle still applies to the instances that could be signalled
+				log.FileOnlyWarningLog.Printf("API: Errors toggling suspend: %v", err)
+				state.Error = err.Error()
+			}
+			log.FileOnlyInfoLog.Printf("API: Suspend toggled from %s, suspended=%v", r.RemoteAddr, suspended)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(state); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error encoding suspend state: %v", err)
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
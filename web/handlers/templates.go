@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/prompt"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxTemplateBytes caps the size of a template request body.
+const maxTemplateBytes = 256 << 10
+
+// TemplateList is the response of the template list endpoint.
+type TemplateList struct {
+	Templates []TemplateInfo `json:"templates"`
+}
+
+// TemplateInfo is a prompt template with the variables its body uses.
+type TemplateInfo struct {
+	prompt.Template
+	Variables []string `json:"variables"`
+}
+
+func templateInfo(t prompt.Template) TemplateInfo {
+	variables := t.Variables()
+	if variables == nil {
+		variables = []string{}
+	}
+	return TemplateInfo{Template: t, Variables: variables}
+}
+
+// TemplatesHandler lists the prompt templates on GET and creates one on POST. Creating a template
+// whose name is taken fails with 409 Conflict.
+func TemplatesHandler(store *prompt.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			t, ok := decodeTemplate(w, r)
+			if !ok {
+				return
+			}
+			if _, err := store.Get(t.Name); err == nil {
+				http.Error(w, "Template already exists", http.StatusConflict)
+				return
+			}
+			saveTemplate(w, store, t, http.StatusCreated)
+			return
+		}
+
+		templates, err := store.List()
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error loading templates: %v", err)
+			http.Error(w, "Error loading templates", http.StatusInternalServerError)
+			return
+		}
+		list := TemplateList{Templates: make([]TemplateInfo, 0, len(templates))}
+		for _, t := range templates {
+			list.Templates = append(list.Templates, templateInfo(t))
+		}
+		writeTemplateJSON(w, http.StatusOK, list)
+	}
+}
+
+// TemplateHandler gets a prompt template on GET, creates or replaces it on PUT and deletes it on
+// DELETE.
+func TemplateHandler(store *prompt.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "template")
+		switch r.Method {
+		case http.MethodPut:
+			t, ok := decodeTemplate(w, r)
+			if !ok {
+				return
+			}
+			// The path names the template
+			t.Name = name
+			saveTemplate(w, store, t, http.StatusOK)
+		case http.MethodDelete:
+			if err := store.Delete(name); err != nil {
+				if errors.Is(err, prompt.ErrTemplateNotFound) {
+					http.Error(w, "Template not found", http.StatusNotFound)
+					return
+				}
+				log.FileOnlyErrorLog.Printf("API: Error deleting template '%s': %v", name, err)
+				http.Error(w, "Error deleting template", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t, err := store.Get(name)
+			if err != nil {
+				if errors.Is(err, prompt.ErrTemplateNotFound) {
+					http.Error(w, "Template not found", http.StatusNotFound)
+					return
+				}
+				log.FileOnlyErrorLog.Printf("API: Error loading template '%s': %v", name, err)
+				http.Error(w, "Error loading templates", http.StatusInternalServerError)
+				return
+			}
+			writeTemplateJSON(w, http.StatusOK, templateInfo(t))
+		}
+	}
+}
+
+func decodeTemplate(w http.ResponseWriter, r *http.Request) (prompt.Template, bool) {
+	var t prompt.Template
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTemplateBytes)).Decode(&t); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return t, false
+		}
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return t, false
+	}
+	return t, true
+}
+
+func saveTemplate(w http.ResponseWriter, store *prompt.Store, t prompt.Template, status int) {
+	if err := t.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := store.Save(t); err != nil {
+		log.FileOnlyErrorLog.Printf("API: Error saving template '%s': %v", t.Name, err)
+		http.Error(w, "Error saving template", http.StatusInternalServerError)
+		return
+	}
+	writeTemplateJSON(w, status, templateInfo(t))
+}
+
+func writeTemplateJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.FileOnlyErrorLog.Printf("API: Error encoding template response: %v", err)
+	}
+}
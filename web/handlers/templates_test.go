@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/prompt"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func templateRequest(store *prompt.Store, method, name, body string) *httptest.ResponseRecorder {
+	path := "/api/templates"
+	handler := TemplatesHandler(store)
+	if name != "" {
+		path += "/" + name
+		handler = TemplateHandler(store)
+	}
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("template", name)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestTemplateHandlers(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	store := prompt.NewStoreAt(filepath.Join(t.TempDir(), prompt.TemplatesFileName))
+
+	rec := templateRequest(store, http.MethodPost, "", `{"name": "review", "body": "Review {file} for {ticket}"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d: %s", rec.Code, rec.Body)
+	}
+	var info TemplateInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "review" || strings.Join(info.Variables, ",") != "file,ticket" {
+		t.Errorf("created template = %+v", info)
+	}
+	if rec := templateRequest(store, http.MethodPost, "", `{"name": "review", "body": "x"}`); rec.Code != http.StatusConflict {
+		t.Errorf("POST of an existing name status = %d, want 409", rec.Code)
+	}
+
+	// PUT takes the name from the path
+	rec = templateRequest(store, http.MethodPut, "review", `{"name": "other", "body": "Review {diff}"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d: %s", rec.Code, rec.Body)
+	}
+	rec = templateRequest(store, http.MethodGet, "", "")
+	var list TemplateList
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Templates) != 1 || list.Templates[0].Name != "review" || list.Templates[0].Body != "Review {diff}" {
+		t.Errorf("templates after PUT = %+v", list.Templates)
+	}
+
+	if rec := templateRequest(store, http.MethodDelete, "review", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE status = %d, want 204", rec.Code)
+	}
+
+	for _, tc := range []struct {
+		name, method, template, body string
+		want                         int
+	}{
+		{"get deleted", http.MethodGet, "review", "", http.StatusNotFound},
+		{"delete deleted", http.MethodDelete, "review", "", http.StatusNotFound},
+		{"empty body", http.MethodPut, "review", `{"body": ""}`, http.StatusBadRequest},
+		{"invalid name", http.MethodPost, "", `{"name": "../x", "body": "x"}`, http.StatusBadRequest},
+		{"invalid json", http.MethodPost, "", `review`, http.StatusBadRequest},
+		{"too large", http.MethodPut, "big", `{"body": "` + strings.Repeat("x", maxTemplateBytes) + `"}`, http.StatusRequestEntityTooLarge},
+	} {
+		if rec := templateRequest(store, tc.method, tc.template, tc.body); rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, rec.Code, tc.want)
+		}
+	}
+}
@@ -41,7 +41,7 @@ type ContentHash struct {
 
 // TerminalHandler handles websocket connections for terminals
 type TerminalHandler struct {
-	instances        *session.Storage
+	instances        session.InstanceStore
 	upgrader         websocket.Upgrader
 	activeInstances  map[string]*activeInstance
 	mutex            sync.Mutex
@@ -59,7 +59,7 @@ type activeInstance struct {
 }
 
 // NewTerminalHandler creates a new terminal handler
-func NewTerminalHandler(instances *session.Storage) *TerminalHandler {
+func NewTerminalHandler(instances session.InstanceStore) *TerminalHandler {
 	handler := &TerminalHandler{
 		instances: instances,
 		upgrader: websocket.Upgrader{
@@ -185,7 +185,7 @@ func (h *TerminalHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request
 	// Load all instances
 	instances, err := h.instances.LoadInstances()
 	if err != nil {
-		log.ErrorLog.Printf("Failed to load instances: %v", err)
+		log.FileOnlyErrorLog.Printf("Failed to load instances: %v", err)
 		http.Error(w, "Failed to load instances", http.StatusInternalServerError)
 		return
 	}
@@ -213,7 +213,7 @@ func (h *TerminalHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request
 	// Upgrade connection to websocket
 	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.ErrorLog.Printf("Failed to upgrade connection: %v", err)
+		log.FileOnlyErrorLog.Printf("Failed to upgrade connection: %v", err)
 		return
 	}
 	defer conn.Close()
@@ -223,7 +223,7 @@ func (h *TerminalHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request
 	// Get or create active instance tracking
 	activeInst, err := h.getOrCreateActiveInstance(instanceName, targetInstance)
 	if err != nil {
-		log.ErrorLog.Printf("Failed to activate instance: %v", err)
+		log.FileOnlyErrorLog.Printf("Failed to activate instance: %v", err)
 		conn.WriteMessage(websocket.TextMessage, []byte("Failed to connect to terminal: "+err.Error()))
 		return
 	}
@@ -443,7 +443,7 @@ func (h *TerminalHandler) handleConnection(conn *websocket.Conn, instance *sessi
 	connectionActive := true
 
 	// Set up ping ticker to keep connection alive
-	pingTicker := time.NewTicker(15 * time.Second)
+	pingTicker := time.NewTicker(terminalPingInterval())
 	defer pingTicker.Stop()
 
 	// Send initial content immediately
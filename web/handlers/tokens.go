@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/tokens"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxTokenRequestBytes caps the size of a token request body.
+const maxTokenRequestBytes = 4 << 10
+
+// TokenRequest is the body of a request issuing a token scoped to an instance.
+type TokenRequest struct {
+	// Scope is view to watch the instance or control to also type in its terminal and change it.
+	Scope tokens.Scope `json:"scope"`
+	// ExpiresIn is how long the token is valid, as a duration such as 8h. It defaults to 24h and
+	// is at most 720h.
+	ExpiresIn string `json:"expires_in,omitempty"`
+	// Label describes who the token is for.
+	Label string `json:"label,omitempty"`
+}
+
+// IssuedToken is the response issuing a token. The token is only ever returned here.
+type IssuedToken struct {
+	tokens.Token
+	Secret string `json:"token"`
+	// Link opens the instance's terminal in the web UI with the token, relative to the server.
+	Link string `json:"link"`
+}
+
+// TokenList is the response of the token list endpoint. It never includes the tokens themselves.
+type TokenList struct {
+	Tokens []tokens.Token `json:"tokens"`
+}
+
+// InstanceTokensHandler lists the unexpired tokens scoped to an instance on GET and issues one on
+// POST. basePath prefixes the link of an issued token.
+func InstanceTokensHandler(storage session.InstanceStore, store *tokens.Store, basePath string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		if _, err := findInstanceByTitle(storage, name); err != nil {
+			http.Error(w, "Instance not found", http.StatusNotFound)
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			list, err := store.List(name)
+			if err != nil {
+				log.FileOnlyErrorLog.Printf("API: Error loading tokens: %v", err)
+				http.Error(w, "Error loading tokens", http.StatusInternalServerError)
+				return
+			}
+			writeTemplateJSON(w, http.StatusOK, TokenList{Tokens: list})
+			return
+		}
+
+		var req TokenRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTokenRequestBytes)).Decode(&req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		var ttl time.Duration
+		if req.ExpiresIn != "" {
+			var err error
+			if ttl, err = time.ParseDuration(req.ExpiresIn); err != nil || ttl <= 0 || ttl > tokens.MaxTTL {
+				http.Error(w, "expires_in must be a positive duration of at most 720h, such as 8h", http.StatusBadRequest)
+				return
+			}
+		}
+		if !req.Scope.Valid() {
+			http.Error(w, "scope must be view or control", http.StatusBadRequest)
+			return
+		}
+
+		t, secret, err := store.Issue(name, req.Scope, ttl, req.Label)
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error issuing token for '%s': %v", name, err)
+			http.Error(w, "Error issuing token", http.StatusInternalServerError)
+			return
+		}
+		log.FileOnlyInfoLog.Printf("API: Issued %s token %s for '%s' expiring %s", t.Scope, t.ID, name,
+			t.ExpiresAt.Format(time.RFC3339))
+		writeTemplateJSON(w, http.StatusCreated, IssuedToken{
+			Token:  t,
+			Secret: secret,
+			Link:   basePath + "/terminal/" + url.PathEscape(name) + "?token=" + secret,
+		})
+	}
+}
+
+// InstanceTokenHandler revokes a token scoped to an instance on DELETE.
+func InstanceTokenHandler(store *tokens.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, id := chi.URLParam(r, "name"), chi.URLParam(r, "id")
+		if err := store.Revoke(name, id); err != nil {
+			if errors.Is(err, tokens.ErrNotFound) {
+				http.Error(w, "Token not found", http.StatusNotFound)
+				return
+			}
+			log.FileOnlyErrorLog.Printf("API: Error revoking token %s: %v", id, err)
+			http.Error(w, "Error revoking token", http.StatusInternalServerError)
+			return
+		}
+		log.FileOnlyInfoLog.Printf("API: Revoked token %s for '%s'", id, name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/mock"
+	"claude-squad/web/tokens"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func tokenRequest(handler http.HandlerFunc, method, name, id, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/instances/"+url.PathEscape(name)+"/tokens", strings.NewReader(body))
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("name", name)
+	routeCtx.URLParams.Add("id", id)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestInstanceTokenHandlers(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	storage := mock.NewEmptyMockStorage()
+	instance, err := session.FromInstanceData(session.InstanceData{Title: "my task", Status: session.Paused})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.AddInstance(instance); err != nil {
+		t.Fatal(err)
+	}
+	store := tokens.NewStoreAt(filepath.Join(t.TempDir(), tokens.FileName))
+	list := InstanceTokensHandler(storage, store, "/squad")
+
+	rec := tokenRequest(list, http.MethodPost, "my task", "", `{"scope": "view", "expires_in": "8h", "label": "contractor"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d: %s", rec.Code, rec.Body)
+	}
+	var issued IssuedToken
+	if err := json.Unmarshal(rec.Body.Bytes(), &issued); err != nil {
+		t.Fatal(err)
+	}
+	if issued.Scope != tokens.ScopeView || issued.Label != "contractor" || issued.ExpiresAt.Sub(issued.CreatedAt).Hours() != 8 {
+		t.Errorf("issued token = %+v", issued)
+	}
+	if want := "/squad/terminal/my%20task?token=" + issued.Secret; issued.Link != want {
+		t.Errorf("link = %q, want %q", issued.Link, want)
+	}
+	if got, err := store.Lookup(issued.Secret); err != nil || got.ID != issued.ID {
+		t.Errorf("issued token doesn't authorize: %+v, %v", got, err)
+	}
+
+	rec = tokenRequest(list, http.MethodGet, "my task", "", "")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), issued.Secret) {
+		t.Fatalf("GET = %d: %s", rec.Code, rec.Body)
+	}
+	var tokenList TokenList
+	if err := json.Unmarshal(rec.Body.Bytes(), &tokenList); err != nil {
+		t.Fatal(err)
+	}
+	if len(tokenList.Tokens) != 1 || tokenList.Tokens[0].ID != issued.ID {
+		t.Errorf("tokens = %+v", tokenList.Tokens)
+	}
+
+	for _, tc := range []struct {
+		instance, body string
+		want           int
+	}{
+		{"missing", `{"scope": "view"}`, http.StatusNotFound},
+		{"my task", `{"scope": "admin"}`, http.StatusBadRequest},
+		{"my task", `{"scope": "view", "expires_in": "forever"}`, http.StatusBadRequest},
+		{"my task", `{"scope": "view", "expires_in": "9000h"}`, http.StatusBadRequest},
+		{"my task", `{"scope": `, http.StatusBadRequest},
+	} {
+		if rec := tokenRequest(list, http.MethodPost, tc.instance, "", tc.body); rec.Code != tc.want {
+			t.Errorf("POST %s %s = %d, want %d", tc.instance, tc.body, rec.Code, tc.want)
+		}
+	}
+
+	revoke := InstanceTokenHandler(store)
+	if rec := tokenRequest(revoke, http.MethodDelete, "my task", issued.ID, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d: %s", rec.Code, rec.Body)
+	}
+	if rec := tokenRequest(revoke, http.MethodDelete, "my task", issued.ID, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("second DELETE status = %d, want 404", rec.Code)
+	}
+	if _, err := store.Lookup(issued.Secret); err == nil {
+		t.Error("revoked token still authorizes")
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/middleware"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxInstanceUpdateBytes caps the size of an instance update request body.
+const maxInstanceUpdateBytes = 64 << 10
+
+// InstanceUpdate is the body of an instance update. Fields left out are not changed.
+type InstanceUpdate struct {
+	Notes *string `json:"notes,omitempty"`
+	// Ticket is a ticket reference such as a GitHub issue URL or a Jira key. Empty clears it.
+	Ticket *string `json:"ticket,omitempty"`
+	// Color is an accent color as #rrggbb. Empty goes back to the default color of the instance.
+	Color *string `json:"color,omitempty"`
+	// Tags replace the tags of the instance, which can scope the daemon. Empty removes them.
+	Tags *[]string `json:"tags,omitempty"`
+}
+
+// InstanceUpdateHandler applies an InstanceUpdate to an instance, saves the instances and responds
+// with the updated InstanceDetail.
+func InstanceUpdateHandler(storage session.InstanceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			http.Error(w, "Instance name required", http.StatusBadRequest)
+			return
+		}
+
+		var update InstanceUpdate
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxInstanceUpdateBytes)).Decode(&update); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if update.Notes == nil && update.Ticket == nil && update.Color == nil && update.Tags == nil {
+			http.Error(w, "No field to update", http.StatusBadRequest)
+			return
+		}
+
+		instances, err := storage.LoadInstances()
+		if err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error loading instances for update: %v", err)
+			http.Error(w, "Error loading instances", http.StatusInternalServerError)
+			return
+		}
+		var instance *session.Instance
+		for _, candidate := range instances {
+			if candidate.Title == name {
+				instance = candidate
+				break
+			}
+		}
+		if instance == nil {
+			http.Error(w, "Instance not found", http.StatusNotFound)
+			return
+		}
+
+		// A running TUI picks the changes up from their events before it next saves
+		if update.Ticket != nil {
+			if err := instance.SetTicket(*update.Ticket); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if update.Color != nil {
+			if err := instance.SetColor(*update.Color); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if update.Tags != nil {
+			if err := instance.SetTags(*update.Tags); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if update.Notes != nil {
+			instance.SetNotes(*update.Notes)
+		}
+		if err := storage.SaveInstances(instances); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error saving update of '%s': %v", name, err)
+			http.Error(w, "Error saving instance", http.StatusInternalServerError)
+			return
+		}
+		log.FileOnlyInfoLog.Printf("API: '%s' updated from %s", name, r.RemoteAddr)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(instanceToDetail(instance, middleware.Operator(r))); err != nil {
+			log.FileOnlyErrorLog.Printf("API: Error encoding instance detail: %v", err)
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+	}
+}
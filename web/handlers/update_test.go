@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/mock"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func patchInstance(storage session.InstanceStore, name, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, "/api/instances/"+name, strings.NewReader(body))
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("name", name)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+	rec := httptest.NewRecorder()
+	InstanceUpdateHandler(storage)(rec, req)
+	return rec
+}
+
+func TestInstanceUpdateHandler(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	storage := mock.NewEmptyMockStorage()
+	instance, err := session.FromInstanceData(session.InstanceData{Title: "task", Status: session.Paused})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.AddInstance(instance); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []session.Event
+	unsubscribe := session.Subscribe(func(e session.Event) { events = append(events, e) })
+	defer unsubscribe()
+
+	rec := patchInstance(storage, "task", `{"notes": "Fixes #12, review the migration"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var detail InstanceDetail
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatal(err)
+	}
+	if detail.Notes != "Fixes #12, review the migration" {
+		t.Errorf("notes in response = %q", detail.Notes)
+	}
+	saved, err := storage.GetInstanceByTitle("task")
+	if err != nil || saved.Notes != detail.Notes {
+		t.Errorf("saved notes = %q (%v), want %q", saved.Notes, err, detail.Notes)
+	}
+	if len(events) != 1 || events[0].Type != session.EventNotesChanged || events[0].Notes != detail.Notes {
+		t.Errorf("events = %+v, want one notes change", events)
+	}
+
+	rec = patchInstance(storage, "task", `{"ticket": "https://github.com/acme/app/issues/12"}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || detail.Ticket != "https://github.com/acme/app/issues/12" || detail.TicketURL == "" {
+		t.Errorf("status = %d, ticket = %q, url = %q after setting the ticket", rec.Code, detail.Ticket, detail.TicketURL)
+	}
+
+	rec = patchInstance(storage, "task", `{"color": "#FF8800"}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || detail.Color != "#ff8800" {
+		t.Errorf("status = %d, color = %q after setting the color", rec.Code, detail.Color)
+	}
+	rec = patchInstance(storage, "task", `{"color": ""}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatal(err)
+	}
+	if detail.Color != session.DefaultAccentColor("task") {
+		t.Errorf("color = %q after clearing it, want the default %q", detail.Color, session.DefaultAccentColor("task"))
+	}
+
+	rec = patchInstance(storage, "task", `{"tags": ["Nightly", "backend", "nightly"]}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || strings.Join(detail.Tags, ",") != "nightly,backend" {
+		t.Errorf("status = %d, tags = %q after setting the tags", rec.Code, detail.Tags)
+	}
+
+	for _, tc := range []struct {
+		name, instance, body string
+		want                 int
+	}{
+		{"unknown instance", "missing", `{"notes": "x"}`, http.StatusNotFound},
+		{"no field", "task", `{}`, http.StatusBadRequest},
+		{"invalid ticket", "task", `{"ticket": "two words"}`, http.StatusBadRequest},
+		{"invalid color", "task", `{"color": "red"}`, http.StatusBadRequest},
+		{"invalid tag", "task", `{"tags": ["two words"]}`, http.StatusBadRequest},
+		{"invalid body", "task", `notes`, http.StatusBadRequest},
+		{"too large", "task", `{"notes": "` + strings.Repeat("x", maxInstanceUpdateBytes) + `"}`, http.StatusRequestEntityTooLarge},
+	} {
+		if rec := patchInstance(storage, tc.instance, tc.body); rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, rec.Code, tc.want)
+		}
+	}
+}
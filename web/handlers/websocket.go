@@ -3,6 +3,7 @@ package handlers
 import (
 	"claude-squad/log"
 	"claude-squad/session"
+	"claude-squad/web/prefs"
 	"claude-squad/web/types"
 	"context"
 	"encoding/json"
@@ -55,10 +56,14 @@ func sanitizeAnsiContent(content string) string {
 }
 
 // WebSocketHandler handles terminal output streaming via WebSocket with bidirectional communication.
-func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInterface) http.HandlerFunc {
+// The terminal is configured with the caller's display preferences from preferences, or the
+// defaults if it is nil.
+func WebSocketHandler(storage session.InstanceStore, monitor types.TerminalMonitorInterface, preferences *prefs.Store) http.HandlerFunc {
 	upgrader := websocket.Upgrader{
 		ReadBufferSize:  4096,  // Increased for better performance
 		WriteBufferSize: 4096,  // Increased for better performance
+		// Negotiate permessage-deflate; large pane captures compress well
+		EnableCompression: true,
 		CheckOrigin: func(r *http.Request) bool {
 			// Always allow all origins for development
 			return true
@@ -93,7 +98,7 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 			return
 		}
 		log.FileOnlyInfoLog.Printf("WebSocket: Found instance '%s' with status=%s, started=%v",
-			instanceTitle, string(instance.Status), instance.Started())
+			instanceTitle, instance.Status.String(), instance.Started())
 
 		// Get privileges parameter (read-only vs read-write)
 		privileges := r.URL.Query().Get("privileges")
@@ -111,9 +116,11 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 
 		// Upgrade HTTP connection to WebSocket with detailed diagnostics
 		log.FileOnlyInfoLog.Printf("WebSocket: Upgrading connection for instance '%s', headers: %v", instanceTitle, r.Header)
+		w, stats := trackConnection(w, r, "terminal", instanceTitle)
+		defer stats.close()
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
-			log.FileOnlyErrorLog.Printf("WebSocket upgrade failed for '%s': %v (Remote: %s, URL: %s)", 
+			log.FileOnlyErrorLog.Printf("WebSocket upgrade failed for '%s'': %v (Remote: %s, URL: %s)", 
 				instanceTitle, err, r.RemoteAddr, r.URL.String())
 			// Log the request headers to help diagnose issues
 			log.FileOnlyErrorLog.Printf("WebSocket upgrade failed headers: %v", r.Header)
@@ -125,11 +132,20 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 		log.FileOnlyInfoLog.Printf("WebSocket: Connection successfully upgraded for '%s' from %s", 
 			instanceTitle, r.RemoteAddr)
 		defer conn.Close()
+
+		// Cap inbound messages and compress outbound frames when the client
+		// negotiated permessage-deflate
+		conn.SetReadLimit(maxInboundMessageSize)
+		conn.EnableWriteCompression(true)
+		if err := conn.SetCompressionLevel(compressionLevel); err != nil {
+			log.FileOnlyWarningLog.Printf("WebSocket: Could not set compression level for '%s': %v", instanceTitle, err)
+		}
 		
 		// Set ping handler to keep connection alive using standard WebSocket protocol
 		conn.SetPongHandler(func(appData string) error {
 			log.FileOnlyInfoLog.Printf("WebSocket: Received standard pong from client for '%s', appData: %s", 
 				instanceTitle, appData)
+			stats.ponged()
 			// Extend read deadline on successful pong
 			err := conn.SetReadDeadline(time.Now().Add(70 * time.Second))
 			if err != nil {
@@ -177,6 +193,13 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 			return
 		}
 		log.FileOnlyInfoLog.Printf("WebSocket: Using format=%s for instance '%s'", format, instanceTitle)
+
+		// Build per-connection content filters from the client's capability flags
+		caps, capsDeclared := r.URL.Query()["caps"]
+		filters := filtersFromCapabilities(strings.Join(caps, ","), capsDeclared)
+		if filters.Enabled() {
+			log.FileOnlyInfoLog.Printf("WebSocket: Using content filters %+v for instance '%s'", filters, instanceTitle)
+		}
 		
 		// --- CRITICAL CHANGE FOR ANSI RENDERING ---
 		// If the client intends to render ANSI, ensure we don't pre-process it on the server.
@@ -188,6 +211,8 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 		log.FileOnlyInfoLog.Printf("WebSocket: Subscribing to updates for instance '%s'", instanceTitle)
 		updates := monitor.Subscribe(instanceTitle)
 		defer monitor.Unsubscribe(instanceTitle, updates)
+		// Skip captures superseded while the client was still receiving an earlier one
+		coalesced := coalesce(ctx, updates, stats)
 
 		// Set up instance validity checking
 		instanceValidityTicker := time.NewTicker(5 * time.Second)
@@ -253,21 +278,11 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 			log.FileOnlyInfoLog.Printf("WebSocket: Initial content available for '%s' (len: %d)",
 				instanceTitle, len(initialContent))
 			
-			// Apply format conversion if needed for non-ANSI clients
-			formattedContent := initialContent
-			// Only convert/strip if explicitly requested for non-ANSI clients.
-			// If client is an ANSI terminal, it wants raw ANSI.
-			if format == "html" { // Client explicitly wants HTML
-				formattedContent = convertAnsiToHtml(initialContent)
-				log.FileOnlyInfoLog.Printf("WebSocket: Converted initial content to HTML format for '%s'", instanceTitle)
-			} else if format == "text" { // Client explicitly wants plain text
-				formattedContent = stripAnsi(initialContent)
-				log.FileOnlyInfoLog.Printf("WebSocket: Converted initial content to plain text format for '%s'", instanceTitle)
-			} else { // Default is "ansi", send raw
-				// For raw ANSI mode, sanitize the content to ensure complete sequences
-				formattedContent = sanitizeAnsiContent(initialContent)
-				log.FileOnlyInfoLog.Printf("WebSocket: Sending sanitized raw ANSI initial content for '%s'", instanceTitle)
-			}
+			// Apply format conversion if needed for non-ANSI clients.
+			// If client is an ANSI terminal, it wants raw ANSI with complete sequences only.
+			formattedContent := filters.Apply(initialContent)
+			formattedContent = outputCache.Format(formattedContent, format)
+			log.FileOnlyInfoLog.Printf("WebSocket: Formatted initial content as %s for '%s'", format, instanceTitle)
 
 			// Make sure we actually have content to send
 			if len(formattedContent) == 0 {
@@ -286,12 +301,12 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 				InstanceTitle: instanceTitle,
 				Content:       formattedContent,
 				Timestamp:     time.Now(),
-				Status:        string(instance.Status),
+				Status:        instance.Status.String(),
 				HasPrompt:     hasPrompt,
 			}
 
 			log.FileOnlyInfoLog.Printf("WebSocket: Sending initial update for '%s', content length: %d, status: %s",
-				instanceTitle, len(formattedContent), string(instance.Status))
+				instanceTitle, len(formattedContent), instance.Status.String())
 			
 			// Update write deadline before sending
 			if err := conn.SetWriteDeadline(time.Now().Add(10 * time.Second)); err != nil {
@@ -315,7 +330,9 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 					}
 				}()
 				
-				writeErrorChan <- conn.WriteJSON(initialUpdate)
+				writeMu.Lock()
+				defer writeMu.Unlock()
+				writeErrorChan <- writeTerminalUpdate(conn, initialUpdate, stats)
 			}()
 			
 			select {
@@ -337,7 +354,7 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 				InstanceTitle: instanceTitle,
 				Content:       "[No terminal content available yet. Please wait...]",
 				Timestamp:     time.Now(),
-				Status:        string(instance.Status),
+				Status:        instance.Status.String(),
 				HasPrompt:     false,
 			}
 			
@@ -357,12 +374,14 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 		}
 
 		// Send terminal configuration
+		display := displayPreferences(preferences, r)
 		config := map[string]interface{}{
 			"type":       "config",
 			"privileges": privileges,
-			"theme":      "dark", // Default theme
-			"fontFamily": "Menlo, Monaco, 'Courier New', monospace",
-			"fontSize":   14,
+			"theme":      display.Theme,
+			"fontFamily": display.FontFamily,
+			"fontSize":   display.FontSize,
+			"filters":    filters,
 		}
 		
 		// Update write deadline before sending
@@ -478,7 +497,7 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 						// Parse message
 						var input types.TerminalInput
 						if err := json.Unmarshal(message, &input); err != nil {
-							log.ErrorLog.Printf("Error parsing WebSocket input: %v", err)
+							log.FileOnlyErrorLog.Printf("Error parsing WebSocket input: %v", err)
 							
 							writeMu.Lock()
 							// Update write deadline before sending
@@ -526,7 +545,7 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 								log.FileOnlyInfoLog.Printf("WebSocket: Processing get_tasks command for '%s'", instanceTitle)
 								tasks, err := monitor.GetTasks(instanceTitle)
 								if err != nil {
-									log.ErrorLog.Printf("Error getting tasks: %v", err)
+									log.FileOnlyErrorLog.Printf("Error getting tasks: %v", err)
 									response = map[string]interface{}{
 										"type":  "command_response",
 										"command": "get_tasks",
@@ -690,7 +709,7 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 				}
 			}()
 			
-			ticker := time.NewTicker(30 * time.Second)
+			ticker := time.NewTicker(websocketPingInterval())
 			defer ticker.Stop()
 
 			for {
@@ -710,6 +729,7 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 					log.FileOnlyInfoLog.Printf("WebSocket: Sending ping to '%s'", instanceTitle)
 					// Update write deadline before sending
 					conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+					stats.pinged()
 					if err := conn.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
 						log.FileOnlyErrorLog.Printf("WebSocket: Ping failed for '%s': %v", instanceTitle, err)
 						writeMu.Unlock()
@@ -730,11 +750,12 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 		// Listen for updates and send to client
 		log.FileOnlyInfoLog.Printf("WebSocket: Starting update listener for '%s'", instanceTitle)
 		updateCounter := 0
+		removalSent := false
 		
 		updateLoop:
 		for {
 				select {
-				case update, ok := <-updates:
+				case update, ok := <-coalesced:
 					if !ok {
 						log.FileOnlyInfoLog.Printf("WebSocket: Updates channel closed for '%s'", instanceTitle)
 						break updateLoop
@@ -760,6 +781,29 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 						continue
 					}
 					
+					// Notifications go out as they are. Once the instance is removed there is
+					// nothing left to stream; a paused instance may resume, so its stream stays open.
+					if update.Type != "" {
+						writeMu.Lock()
+						_ = conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+						err := conn.WriteJSON(update)
+						writeMu.Unlock()
+						if err != nil {
+							log.FileOnlyErrorLog.Printf("WebSocket: Error sending %s for '%s': %v", update.Type, instanceTitle, err)
+							cancel()
+							break updateLoop
+						}
+						if update.Type == types.EventInstanceRemoved {
+							removalSent = true
+							instanceValidMu.Lock()
+							instanceValid = false
+							instanceValidMu.Unlock()
+							cancel()
+							break updateLoop
+						}
+						continue
+					}
+
 					// Skip empty updates
 					if len(update.Content) == 0 {
 						log.FileOnlyWarningLog.Printf("WebSocket: Skipping empty update #%d for '%s'",
@@ -767,19 +811,13 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 					continue
 				}
 				
+				// Strip sequences the client declared it can't handle
+				update.Content = filters.Apply(update.Content)
+
 				// Apply format conversion if needed for non-ANSI clients
 				// If client is an ANSI terminal (format="ansi" or default), send raw.
-				if format == "html" {
-					update.Content = convertAnsiToHtml(update.Content)
-					log.FileOnlyInfoLog.Printf("WebSocket: Converted update to HTML format for '%s'", instanceTitle)
-				} else if format == "text" {
-					update.Content = stripAnsi(update.Content)
-					log.FileOnlyInfoLog.Printf("WebSocket: Converted update to plain text format for '%s'", instanceTitle)
-				} else {
-					// For raw ANSI mode, sanitize the content to ensure complete sequences
-					update.Content = sanitizeAnsiContent(update.Content)
-					log.FileOnlyInfoLog.Printf("WebSocket: Sending sanitized raw ANSI content for '%s'", instanceTitle)
-				}
+				update.Content = outputCache.Format(update.Content, format)
+				log.FileOnlyInfoLog.Printf("WebSocket: Formatted update as %s for '%s'", format, instanceTitle)
 				
 				// Make sure we still have content after conversion
 				if len(update.Content) == 0 {
@@ -792,9 +830,8 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 				log.FileOnlyInfoLog.Printf("WebSocket: Sending update #%d to client for '%s', content length: %d",
 					updateCounter, instanceTitle, len(update.Content))
 
-				// Update write deadline before sending
-				conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
-				if err := conn.WriteJSON(update); err != nil {
+				// Large updates are split into continuation frames
+				if err := writeTerminalUpdate(conn, update, stats); err != nil {
 					log.FileOnlyErrorLog.Printf("WebSocket: Error sending update for '%s': %v", instanceTitle, err)
 					writeMu.Unlock()
 					cancel() // Signal all goroutines to terminate
@@ -816,7 +853,7 @@ func WebSocketHandler(storage *session.Storage, monitor types.TerminalMonitorInt
 		isValid := instanceValid 
 		instanceValidMu.RUnlock()
 		
-		if !isValid {
+		if !isValid && !removalSent {
 			// Try to send a termination message
 			writeMu.Lock()
 			log.FileOnlyInfoLog.Printf("WebSocket: Sending termination notification for '%s'", instanceTitle)
@@ -5,7 +5,6 @@ import (
 	"claude-squad/log"
 	"claude-squad/session"
 	"claude-squad/web"
-	"claude-squad/web/mock"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -116,10 +115,7 @@ func TestWebServer(t *testing.T) {
 	cfg.WebServerAllowLocalhost = true
 	
 	// Create and start server
-	server, err := web.NewServer(storage, cfg)
-	if err != nil {
-		t.Fatalf("Failed to create server: %v", err)
-	}
+	server := web.NewServer(storage, cfg)
 	
 	// Create test HTTP server
 	ts := httptest.NewServer(server.Handler())
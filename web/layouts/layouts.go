@@ -0,0 +1,191 @@
+// Package layouts keeps the dashboard layouts of the web UI: named sets of instances whose
+// terminals are shown side by side and streamed over one WebSocket.
+package layouts
+
+import (
+	"claude-squad/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// FileName is the file in the config directory holding the layouts.
+const FileName = "layouts.json"
+
+const (
+	// MaxNameLength is the maximum length of a layout name.
+	MaxNameLength = 64
+	// MaxInstances is the most instances a layout shows, a 3x3 grid.
+	MaxInstances = 9
+	// MaxColumns is the widest grid a layout may ask for.
+	MaxColumns = 4
+	// DefaultColumns is the number of columns of a layout that doesn't set it, giving 2x2 grids.
+	DefaultColumns = 2
+)
+
+// ErrNotFound is returned for a layout that doesn't exist.
+var ErrNotFound = errors.New("layout not found")
+
+var layoutName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 ._-]*$`)
+
+// Layout is a named set of instances shown in a grid, in order, row by row.
+type Layout struct {
+	Name      string   `json:"name"`
+	Instances []string `json:"instances"`
+	// Columns is the number of terminals in a row, DefaultColumns if 0.
+	Columns int `json:"columns,omitempty"`
+}
+
+// Validate returns an error if the layout has an invalid name, no instances, too many or the same
+// one twice, or a column count out of bounds.
+func (l Layout) Validate() error {
+	if len(l.Name) > MaxNameLength {
+		return fmt.Errorf("layout name is longer than %d characters", MaxNameLength)
+	}
+	if !layoutName.MatchString(l.Name) {
+		return fmt.Errorf("layout name %q must start with a letter or digit and contain only letters, digits, spaces, '.', '_' and '-'", l.Name)
+	}
+	if len(l.Instances) == 0 || len(l.Instances) > MaxInstances {
+		return fmt.Errorf("layout %s must have between 1 and %d instances", l.Name, MaxInstances)
+	}
+	seen := make(map[string]bool)
+	for _, instance := range l.Instances {
+		if instance == "" {
+			return fmt.Errorf("layout %s has an empty instance title", l.Name)
+		}
+		if seen[instance] {
+			return fmt.Errorf("layout %s has instance %s twice", l.Name, instance)
+		}
+		seen[instance] = true
+	}
+	if l.Columns < 0 || l.Columns > MaxColumns {
+		return fmt.Errorf("layout %s must have between 1 and %d columns", l.Name, MaxColumns)
+	}
+	return nil
+}
+
+// Store keeps layouts in a JSON file. Every call reads the file, so processes sharing it see each
+// other's changes.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns the store in the config directory.
+func NewStore() (*Store, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreAt(filepath.Join(dir, FileName)), nil
+}
+
+// NewStoreAt returns a store kept in the file at path.
+func NewStoreAt(path string) *Store {
+	return &Store{path: path}
+}
+
+// List returns the layouts sorted by name.
+func (s *Store) List() ([]Layout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Get returns the layout called name, or ErrNotFound.
+func (s *Store) Get(name string) (Layout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	layouts, err := s.load()
+	if err != nil {
+		return Layout{}, err
+	}
+	for _, l := range layouts {
+		if l.Name == name {
+			return l, nil
+		}
+	}
+	return Layout{}, ErrNotFound
+}
+
+// Save validates l and adds it, replacing the layout of the same name. A layout without columns
+// is saved with DefaultColumns.
+func (s *Store) Save(l Layout) (Layout, error) {
+	if err := l.Validate(); err != nil {
+		return l, err
+	}
+	if l.Columns == 0 {
+		l.Columns = DefaultColumns
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	layouts, err := s.load()
+	if err != nil {
+		return l, err
+	}
+	replaced := false
+	for i := range layouts {
+		if layouts[i].Name == l.Name {
+			layouts[i] = l
+			replaced = true
+		}
+	}
+	if !replaced {
+		layouts = append(layouts, l)
+	}
+	return l, s.write(layouts)
+}
+
+// Delete removes the layout called name, or returns ErrNotFound.
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	layouts, err := s.load()
+	if err != nil {
+		return err
+	}
+	for i, l := range layouts {
+		if l.Name == name {
+			return s.write(append(layouts[:i], layouts[i+1:]...))
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *Store) load() ([]Layout, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Layout{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read layouts: %w", err)
+	}
+	var layouts []Layout
+	if err := json.Unmarshal(data, &layouts); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	sort.Slice(layouts, func(i, j int) bool { return layouts[i].Name < layouts[j].Name })
+	return layouts, nil
+}
+
+func (s *Store) write(layouts []Layout) error {
+	sort.Slice(layouts, func(i, j int) bool { return layouts[i].Name < layouts[j].Name })
+	data, err := json.MarshalIndent(layouts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal layouts: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// Write and rename so a concurrent reader never sees a partial file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write layouts: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
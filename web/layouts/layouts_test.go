@@ -0,0 +1,59 @@
+package layouts
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStoreAt(filepath.Join(t.TempDir(), FileName))
+	if layouts, err := store.List(); err != nil || len(layouts) != 0 {
+		t.Fatalf("List() of a new store = %v, %v", layouts, err)
+	}
+
+	saved, err := store.Save(Layout{Name: "backend", Instances: []string{"api", "db", "auth", "jobs"}})
+	if err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if saved.Columns != DefaultColumns {
+		t.Errorf("Save() columns = %d, want %d", saved.Columns, DefaultColumns)
+	}
+	if _, err := store.Save(Layout{Name: "alpha", Instances: []string{"api"}, Columns: 1}); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if _, err := store.Save(Layout{Name: "backend", Instances: []string{"api", "db"}, Columns: 2}); err != nil {
+		t.Fatalf("Save() of a replacement error: %v", err)
+	}
+
+	layouts, err := store.List()
+	if err != nil || len(layouts) != 2 || layouts[0].Name != "alpha" || len(layouts[1].Instances) != 2 {
+		t.Fatalf("List() = %+v, %v, want alpha and the replaced backend", layouts, err)
+	}
+
+	if err := store.Delete("alpha"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if _, err := store.Get("alpha"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of a deleted layout error = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete("alpha"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() of a deleted layout error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, l := range []Layout{
+		{Name: "", Instances: []string{"a"}},
+		{Name: "../x", Instances: []string{"a"}},
+		{Name: "empty"},
+		{Name: "twice", Instances: []string{"a", "a"}},
+		{Name: "blank", Instances: []string{""}},
+		{Name: "many", Instances: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}},
+		{Name: "wide", Instances: []string{"a"}, Columns: MaxColumns + 1},
+	} {
+		if err := l.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", l)
+		}
+	}
+}
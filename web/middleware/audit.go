@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"claude-squad/audit"
+	"claude-squad/log"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// maxAuditBody caps how much of a request body is read to summarize it.
+const maxAuditBody = 16 << 10
+
+// maxAuditValue caps the length of each value in a request summary.
+const maxAuditValue = 60
+
+type actorKey struct{}
+
+func withActor(r *http.Request, actor string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), actorKey{}, actor))
+}
+
+// Actor returns who made r as found by AuthMiddleware: "token <id>", "auth token", "localhost"
+// or "unix socket". Without AuthMiddleware it is "unix socket" or "anonymous".
+func Actor(r *http.Request) string {
+	if actor, ok := r.Context().Value(actorKey{}).(string); ok {
+		return actor
+	}
+	if isUnixSocket(r) {
+		return "unix socket"
+	}
+	return "anonymous"
+}
+
+func isUnixSocket(r *http.Request) bool {
+	_, ok := r.Context().Value(http.LocalAddrContextKey).(*net.UnixAddr)
+	return ok
+}
+
+// AuditMiddleware records the requests that change state in trail: every request other than GET,
+// HEAD and OPTIONS, and terminal WebSockets opened read-write, including those streaming several
+// instances, which can type in an agent's terminal. WebSockets are recorded when they open, others
+// when they have been answered. trail may be nil to record nothing.
+func AuditMiddleware(trail *audit.Log) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if trail == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			instance, terminal, _ := requestInstance(r)
+			terminal = terminal || multiplexed(r)
+			entry := audit.Entry{
+				Actor:    Actor(r),
+				Remote:   r.RemoteAddr,
+				Method:   r.Method,
+				Path:     r.URL.Path,
+				Instance: instance,
+			}
+
+			switch {
+			case terminal:
+				if r.URL.Query().Get("privileges") == "read-write" {
+					entry.Summary = "privileges=read-write"
+					record(trail, entry)
+				}
+				next.ServeHTTP(w, r)
+			case r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions:
+				next.ServeHTTP(w, r)
+			default:
+				entry.Summary = summarizeBody(r)
+				ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+				next.ServeHTTP(ww, r)
+				entry.Status = ww.Status()
+				if entry.Status == 0 {
+					entry.Status = http.StatusOK
+				}
+				record(trail, entry)
+			}
+		})
+	}
+}
+
+func record(trail *audit.Log, entry audit.Entry) {
+	if err := trail.Record(entry); err != nil {
+		log.FileOnlyErrorLog.Printf("Failed to record %s %s in the audit trail: %v", entry.Method, entry.Path, err)
+	}
+}
+
+// summarizeBody describes the body of r and leaves it to be read again. A JSON object is
+// described by its fields, such as notes="Fix the login flow", with long values cut short and
+// secrets left out; other bodies by their size.
+func summarizeBody(r *http.Request) string {
+	if r.Body == nil || r.Body == http.NoBody {
+		return ""
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+	if err != nil || len(data) == 0 {
+		return ""
+	}
+	if len(data) > maxAuditBody {
+		return fmt.Sprintf("body over %d bytes", maxAuditBody)
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return fmt.Sprintf("body of %d bytes", len(data))
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+summarizeValue(key, fields[key]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func summarizeValue(key string, raw json.RawMessage) string {
+	lower := strings.ToLower(key)
+	for _, secret := range []string{"token", "secret", "password", "key"} {
+		if strings.Contains(lower, secret) {
+			return "[redacted]"
+		}
+	}
+	value := string(raw)
+	var compact bytes.Buffer
+	if json.Compact(&compact, raw) == nil {
+		value = compact.String()
+	}
+	var s string
+	isString := json.Unmarshal(raw, &s) == nil
+	if isString {
+		value = s
+	}
+	if utf8.RuneCountInString(value) > maxAuditValue {
+		value = string([]rune(value)[:maxAuditValue]) + "…"
+	}
+	if isString {
+		return fmt.Sprintf("%q", value)
+	}
+	return value
+}
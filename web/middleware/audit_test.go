@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"claude-squad/audit"
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/web/tokens"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.WebServerAuthToken = "server-token"
+	dir := t.TempDir()
+	store := tokens.NewStoreAt(filepath.Join(dir, tokens.FileName))
+	scoped, secret, err := store.Issue("alpha", tokens.ScopeControl, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trail := audit.NewLogAt(filepath.Join(dir, audit.FileName))
+
+	var body string
+	handler := AuthMiddleware(cfg, store)(AuditMiddleware(trail)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})))
+
+	send := func(method, target, remote, header, reqBody string) {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(reqBody))
+		req.RemoteAddr = remote
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code >= 300 {
+			t.Fatalf("%s %s = %d", method, target, rec.Code)
+		}
+	}
+
+	patch := `{"notes": "` + strings.Repeat("n", 100) + `", "color": "#ff8800", "token": "hunter2", "pinned": true}`
+	send("PATCH", "/api/instances/alpha", "192.0.2.1:1234", "Bearer "+secret, patch)
+	if body != patch {
+		t.Errorf("handler read body %q, want it unchanged", body)
+	}
+	send("GET", "/api/instances/alpha", "127.0.0.1:1234", "", "")
+	send("GET", "/ws/alpha?privileges=read-write", "127.0.0.1:1234", "", "")
+	send("GET", "/ws/alpha?privileges=read-only", "127.0.0.1:1234", "", "")
+	send("DELETE", "/api/instances/beta", "192.0.2.1:1234", "Bearer server-token", "")
+
+	entries, err := trail.Query("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("recorded %d entries, want 3: %+v", len(entries), entries)
+	}
+
+	del, ws, update := entries[0], entries[1], entries[2]
+	if del.Actor != "auth token" || del.Method != "DELETE" || del.Instance != "beta" || del.Status != http.StatusNoContent {
+		t.Errorf("DELETE entry = %+v", del)
+	}
+	if ws.Actor != "localhost" || ws.Instance != "alpha" || ws.Summary != "privileges=read-write" {
+		t.Errorf("WebSocket entry = %+v", ws)
+	}
+	if update.Actor != "token "+scoped.ID || update.Remote != "192.0.2.1:1234" || update.Status != http.StatusOK {
+		t.Errorf("PATCH entry = %+v", update)
+	}
+	want := `color="#ff8800" notes="` + strings.Repeat("n", maxAuditValue) + `…" pinned=true token=[redacted]`
+	if update.Summary != want {
+		t.Errorf("PATCH summary = %s, want %s", update.Summary, want)
+	}
+}
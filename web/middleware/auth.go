@@ -3,6 +3,7 @@ package middleware
 import (
 	"claude-squad/config"
 	"claude-squad/log"
+	"claude-squad/web/tokens"
 	"fmt"
 	"net"
 	"net/http"
@@ -11,50 +12,87 @@ import (
 	"time"
 )
 
-// AuthMiddleware creates middleware for API authentication.
-func AuthMiddleware(config *config.Config) func(http.Handler) http.Handler {
+// AuthMiddleware creates middleware for API authentication. A request presents the server's auth
+// token, or a token from store scoped to one instance, in the Authorization header or in the token
+// query parameter, as browsers can't send headers with a WebSocket. A scoped token limits the
+// request to its instance even when the connection needs no auth. store may be nil to only accept
+// the server's token.
+func AuthMiddleware(config *config.Config, store *tokens.Store) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := requestToken(r)
+			if !ok {
+				http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
+				log.FileOnlyWarningLog.Printf("Auth attempt with invalid format from %s", r.RemoteAddr)
+				return
+			}
+
+			if store != nil && strings.HasPrefix(token, tokens.Prefix) {
+				scoped, err := store.Lookup(token)
+				if err != nil {
+					http.Error(w, "Invalid authorization token", http.StatusUnauthorized)
+					log.FileOnlyWarningLog.Printf("Auth attempt with invalid scoped token from %s: %v", r.RemoteAddr, err)
+					return
+				}
+				authorized, ok := authorizeScoped(r, scoped)
+				if !ok {
+					http.Error(w, "Token not valid for this request", http.StatusForbidden)
+					log.FileOnlyWarningLog.Printf("Scoped token %s for '%s' refused %s %s from %s",
+						scoped.ID, scoped.Instance, r.Method, r.URL.Path, r.RemoteAddr)
+					return
+				}
+				next.ServeHTTP(w, withActor(authorized, "token "+scoped.ID))
+				return
+			}
+
+			// Connections over the unix socket are limited by its file permissions
+			if isUnixSocket(r) {
+				next.ServeHTTP(w, withActor(r, "unix socket"))
+				return
+			}
+			
 			// Skip auth for localhost when configured
 			if config.WebServerAllowLocalhost {
 				host, _, err := net.SplitHostPort(r.RemoteAddr)
 				if err == nil && (host == "127.0.0.1" || host == "::1" || host == "localhost") {
-					next.ServeHTTP(w, r)
+					next.ServeHTTP(w, withActor(r, "localhost"))
 					return
 				}
 			}
 			
-			// Get auth token from header
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
+			if token == "" {
 				http.Error(w, "Authorization required", http.StatusUnauthorized)
-				log.WarningLog.Printf("Auth attempt with no token from %s", r.RemoteAddr)
-				return
-			}
-			
-			// Validate token format
-			parts := strings.Split(authHeader, " ")
-			if len(parts) != 2 || parts[0] != "Bearer" {
-				http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
-				log.WarningLog.Printf("Auth attempt with invalid format from %s", r.RemoteAddr)
+				log.FileOnlyWarningLog.Printf("Auth attempt with no token from %s", r.RemoteAddr)
 				return
 			}
 			
-			token := parts[1]
-			
 			// Validate token
 			if token != config.WebServerAuthToken {
 				http.Error(w, "Invalid authorization token", http.StatusUnauthorized)
-				log.WarningLog.Printf("Auth attempt with invalid token from %s", r.RemoteAddr)
+				log.FileOnlyWarningLog.Printf("Auth attempt with invalid token from %s", r.RemoteAddr)
 				return
 			}
 			
 			// Token valid, continue
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, withActor(r, "auth token"))
 		})
 	}
 }
 
+// requestToken returns the token r presents, or "" if it presents none. ok is false if the
+// Authorization header isn't a bearer token.
+func requestToken(r *http.Request) (token string, ok bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return r.URL.Query().Get("token"), true
+	}
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", false
+	}
+	return parts[1], true
+}
+
 // RateLimitMiddleware creates middleware for rate limiting.
 func RateLimitMiddleware(requests int, duration time.Duration, exemptWebSockets ...bool) func(http.Handler) http.Handler {
 	// Different rate limits for different endpoints
@@ -146,7 +184,7 @@ func RateLimitMiddleware(requests int, duration time.Duration, exemptWebSockets
 				// Set retry-after header (in seconds)
 				w.Header().Set("Retry-After", fmt.Sprintf("%d", int(duration.Seconds())))
 				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
-				log.WarningLog.Printf("Rate limit exceeded for %s (API: %v)", ip, isApi)
+				log.FileOnlyWarningLog.Printf("Rate limit exceeded for %s (API: %v)", ip, isApi)
 				return
 			}
 			
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/web/tokens"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuthMiddlewareScopedTokens(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.WebServerAuthToken = "server-token"
+	store := tokens.NewStoreAt(filepath.Join(t.TempDir(), tokens.FileName))
+	_, view, err := store.Issue("alpha", tokens.ScopeView, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, control, err := store.Issue("alpha", tokens.ScopeControl, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var privileges string
+	handler := AuthMiddleware(cfg, store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		privileges = r.URL.Query().Get("privileges")
+	}))
+
+	tests := []struct {
+		name, method, target, remote, header string
+		want                                 int
+	}{
+		{"no token", "GET", "/api/instances", "192.0.2.1:1234", "", http.StatusUnauthorized},
+		{"server token", "GET", "/api/instances", "192.0.2.1:1234", "Bearer server-token", http.StatusOK},
+		{"wrong token", "GET", "/api/instances", "192.0.2.1:1234", "Bearer nope", http.StatusUnauthorized},
+		{"localhost", "GET", "/api/instances", "127.0.0.1:1234", "", http.StatusOK},
+		{"server token in query", "GET", "/ws/alpha?token=server-token", "192.0.2.1:1234", "", http.StatusOK},
+		{"view own instance", "GET", "/api/instances/alpha/diff?token=" + view, "192.0.2.1:1234", "", http.StatusOK},
+		{"view header", "GET", "/api/instances/alpha", "192.0.2.1:1234", "Bearer " + view, http.StatusOK},
+		{"view other instance", "GET", "/api/instances/beta?token=" + view, "192.0.2.1:1234", "", http.StatusForbidden},
+		{"view instance list", "GET", "/api/instances?token=" + view, "192.0.2.1:1234", "", http.StatusForbidden},
+		{"view from localhost", "GET", "/api/instances?token=" + view, "127.0.0.1:1234", "", http.StatusForbidden},
+		{"view change", "PATCH", "/api/instances/alpha?token=" + view, "192.0.2.1:1234", "", http.StatusForbidden},
+		{"view events", "GET", "/api/events?instance=alpha&token=" + view, "192.0.2.1:1234", "", http.StatusOK},
+		{"view all events", "GET", "/ws/events?token=" + view, "192.0.2.1:1234", "", http.StatusForbidden},
+		{"view web UI", "GET", "/terminal/alpha?token=" + view, "192.0.2.1:1234", "", http.StatusOK},
+		{"view tokens", "GET", "/api/instances/alpha/tokens?token=" + view, "192.0.2.1:1234", "", http.StatusForbidden},
+		{"view preferences", "PATCH", "/api/preferences?token=" + view, "192.0.2.1:1234", "", http.StatusOK},
+		{"view layouts", "GET", "/api/layouts?token=" + view, "192.0.2.1:1234", "", http.StatusForbidden},
+		{"control multiplexed stream", "GET", "/ws/multiplex?instances=alpha&token=" + control, "192.0.2.1:1234", "", http.StatusForbidden},
+		{"control layout stream", "GET", "/ws/layouts/backend?token=" + control, "192.0.2.1:1234", "", http.StatusForbidden},
+		{"control change", "PATCH", "/api/instances/alpha?token=" + control, "192.0.2.1:1234", "", http.StatusOK},
+		{"control tokens", "POST", "/api/instances/alpha/tokens?token=" + control, "192.0.2.1:1234", "", http.StatusForbidden},
+		{"unknown scoped token", "GET", "/api/instances/alpha?token=" + tokens.Prefix + "x", "127.0.0.1:1234", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.RemoteAddr = tt.remote
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.target, rec.Code, tt.want)
+			}
+		})
+	}
+
+	// Terminals are read-only with a view token and as asked with a control token
+	for token, want := range map[string]string{view: "read-only", control: "read-write"} {
+		for _, target := range []string{"/ws/alpha", "/ws/terminal/alpha", "/ws/attach/alpha", "/ws?instance=alpha"} {
+			req := httptest.NewRequest("GET", target, nil)
+			req.URL.RawQuery += "&privileges=read-write&token=" + token
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK || privileges != want {
+				t.Errorf("%s = %d with privileges %q, want %q", target, rec.Code, privileges, want)
+			}
+		}
+	}
+}
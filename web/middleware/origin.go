@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"claude-squad/log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// OriginAllowed reports whether r comes from a page allowed to use the server: one it served
+// itself, one of the allowed origins, or no page at all, as with curl and other programs, which
+// send no Origin header.
+func OriginAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(origin, a) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// OriginMiddleware refuses requests that change something when they come from a page of an origin
+// OriginAllowed refuses. CORS doesn't stop them: browsers send a POST without a body, or with a
+// form or plain text body, without asking the server first, and only hide its response.
+func OriginMiddleware(allowed []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+			default:
+				if !OriginAllowed(r, allowed) {
+					http.Error(w, "Origin not allowed", http.StatusForbidden)
+					log.FileOnlyWarningLog.Printf("Refused %s %s from origin %s", r.Method, r.URL.Path, r.Header.Get("Origin"))
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
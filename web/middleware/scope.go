@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"claude-squad/web/tokens"
+	"context"
+	"net/http"
+	"strings"
+)
+
+type scopedTokenKey struct{}
+
+// ScopedToken returns the instance-scoped token r was authorized with, if any.
+func ScopedToken(r *http.Request) (tokens.Token, bool) {
+	t, ok := r.Context().Value(scopedTokenKey{}).(tokens.Token)
+	return t, ok
+}
+
+// Operator reports whether r was made with access to the whole squad, with the auth token, from
+// localhost or over the unix socket, rather than with a token scoped to one instance.
+func Operator(r *http.Request) bool {
+	_, scoped := ScopedToken(r)
+	return !scoped
+}
+
+// authorizeScoped returns r authorized with the scoped token t, or false if t doesn't allow it.
+// A token only reaches its own instance's API, terminal and events, its display preferences and
+// the pages of the web UI. A view token may only read its instance, and its terminal WebSockets
+// are made read-only.
+func authorizeScoped(r *http.Request, t tokens.Token) (*http.Request, bool) {
+	if managesTokens(r) {
+		return r, false
+	}
+	if strings.TrimSuffix(r.URL.Path, "/") == "/api/preferences" {
+		// Every token has its own display preferences
+		return r.WithContext(context.WithValue(r.Context(), scopedTokenKey{}, t)), true
+	}
+	instance, terminal, ok := requestInstance(r)
+	if !ok {
+		// The web UI's pages and assets, and the health checks, expose no instance
+		return r, !strings.HasPrefix(r.URL.Path, "/api/") && !strings.HasPrefix(r.URL.Path, "/ws")
+	}
+	if instance != t.Instance {
+		return r, false
+	}
+	if t.Scope == tokens.ScopeView {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			return r, false
+		}
+		if terminal {
+			r = r.Clone(r.Context())
+			query := r.URL.Query()
+			query.Set("privileges", "read-only")
+			r.URL.RawQuery = query.Encode()
+		}
+	}
+	return r.WithContext(context.WithValue(r.Context(), scopedTokenKey{}, t)), true
+}
+
+// requestInstance returns the instance an API or WebSocket request is limited to, and whether it
+// streams the instance's terminal. ok is false for requests that aren't limited to one instance.
+func requestInstance(r *http.Request) (instance string, terminal bool, ok bool) {
+	p := strings.TrimSuffix(r.URL.Path, "/")
+	query := r.URL.Query()
+	switch {
+	case p == "/api/events" || p == "/ws/events":
+		instance = query.Get("instance")
+		return instance, false, instance != ""
+	case p == "/ws":
+		instance = query.Get("instance")
+		return instance, true, instance != ""
+	case strings.HasPrefix(p, "/ws/attach/"):
+		instance = strings.TrimPrefix(p, "/ws/attach/")
+		return instance, true, instance != "" && !strings.Contains(instance, "/")
+	case strings.HasPrefix(p, "/ws/terminal/"):
+		instance = strings.TrimPrefix(p, "/ws/terminal/")
+		return instance, true, instance != "" && !strings.Contains(instance, "/")
+	case multiplexed(r):
+		return "", false, false
+	case strings.HasPrefix(p, "/ws/"):
+		instance = strings.TrimPrefix(p, "/ws/")
+		return instance, true, !strings.Contains(instance, "/")
+	case strings.HasPrefix(p, "/api/instances/"):
+		instance, _, _ = strings.Cut(strings.TrimPrefix(p, "/api/instances/"), "/")
+		return instance, false, instance != "" && instance != "graph"
+	}
+	return "", false, false
+}
+
+// multiplexed reports whether r is for a WebSocket streaming the terminals of several instances: a
+// dashboard layout or the multiplexed terminal stream.
+func multiplexed(r *http.Request) bool {
+	p := strings.TrimSuffix(r.URL.Path, "/")
+	return p == "/ws/multiplex" || strings.HasPrefix(p, "/ws/layouts/")
+}
+
+// managesTokens reports whether r is for the endpoints managing an instance's tokens.
+func managesTokens(r *http.Request) bool {
+	rest, ok := strings.CutPrefix(r.URL.Path, "/api/instances/")
+	_, sub, _ := strings.Cut(rest, "/")
+	return ok && (strings.TrimSuffix(sub, "/") == "tokens" || strings.HasPrefix(sub, "tokens/"))
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"claude-squad/tracing"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// TracingMiddleware records a span for every request, named after the matched chi route so
+// requests for different instances are grouped together. Incoming trace context headers are
+// honoured so the span joins the caller's trace.
+func TracingMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracing.Start(ctx, "HTTP "+r.Method,
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			)
+			defer span.End()
+
+			// The wrapper keeps http.Hijacker working for WebSocket upgrades
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				span.SetName(fmt.Sprintf("HTTP %s %s", r.Method, rctx.RoutePattern()))
+				span.SetAttributes(attribute.String("http.route", rctx.RoutePattern()))
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	router := chi.NewRouter()
+	router.Use(TracingMiddleware())
+	router.Get("/api/instances/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/instances/foo", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if want := "HTTP GET /api/instances/{name}"; span.Name() != want {
+		t.Errorf("span name = %q, want %q", span.Name(), want)
+	}
+
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs["http.response.status_code"].AsInt64(); got != http.StatusNotFound {
+		t.Errorf("status code attribute = %d, want %d", got, http.StatusNotFound)
+	}
+	if got := attrs["url.path"].AsString(); got != "/api/instances/foo" {
+		t.Errorf("url.path attribute = %q, want /api/instances/foo", got)
+	}
+}
@@ -25,6 +25,13 @@ func NewMockStorage() *MockStorage {
 	return storage
 }
 
+// NewEmptyMockStorage creates a mock storage without sample instances.
+func NewEmptyMockStorage() *MockStorage {
+	return &MockStorage{
+		instances: make(map[string]*session.Instance),
+	}
+}
+
 // CreateSampleInstances creates sample instances for testing.
 func (s *MockStorage) CreateSampleInstances() {
 	// Create instances with different statuses
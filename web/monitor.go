@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,9 +19,16 @@ var _ types.TerminalMonitorInterface = (*TerminalMonitor)(nil)
 
 // TerminalMonitor watches for changes in terminal output.
 type TerminalMonitor struct {
-	storage            *session.Storage
+	storage            session.InstanceStore
 	contentMap         map[string]string
 	hashMap            map[string][]byte
+	contentUpdated     map[string]time.Time // When each entry of contentMap was last set
+	contentSeq         map[string]uint64    // Sequence of the last capture of each instance
+	seq                uint64               // Sequence of the last capture of any instance
+	contentBytes       int                  // Total size of contentMap
+	evictions          uint64
+	truncations        uint64
+	unsubscribeEvents  func()
 	monitoredInstances []*session.Instance // Cached list of instances
 	subscribers        map[string][]chan types.TerminalUpdate
 	taskCache          map[string][]types.TaskItem
@@ -28,6 +36,11 @@ type TerminalMonitor struct {
 	mutex              sync.RWMutex
 	ticker             *time.Ticker
 	done               chan struct{}
+	lastPoll           atomic.Int64 // When output was last polled, in Unix nanoseconds
+
+	// pollInterval is how often output is checked, refreshInterval how often instances are reloaded
+	pollInterval    time.Duration
+	refreshInterval time.Duration
 	
 	// Rate-limited loggers to prevent excessive logging
 	inactiveLogger     *log.Every  // Logger for "no active instances" messages
@@ -38,6 +51,15 @@ type TerminalMonitor struct {
 // Set this to true to enable detailed debug logging
 const debugLogging = false
 
+const (
+	// maxMonitorContentBytes caps the output kept per instance. Only the newest lines are kept.
+	maxMonitorContentBytes = 256 * 1024
+	// maxMonitorTotalBytes caps the output kept for all instances. Past it, the output of the
+	// instances nobody is subscribed to is dropped, least recently changed first, to be captured
+	// again when asked for.
+	maxMonitorTotalBytes = 16 * 1024 * 1024
+)
+
 // Patterns to extract task items from Claude's output
 // Primary pattern for explicitly marked tasks like "1. [TODO] Task description"
 var taskRegexp = regexp.MustCompile(`(?m)^(\d+)\.\s+\[([\w\s]+)\]\s+(.+)$`)
@@ -48,32 +70,56 @@ var doneRegexp = regexp.MustCompile(`(?m)^(\d+)\.\s+(?:DONE|Completed|✓):\s+(.
 var progressRegexp = regexp.MustCompile(`(?m)^(\d+)\.\s+(?:IN PROGRESS|WIP|Doing):\s+(.+)$`) // For "1. IN PROGRESS: Task description"
 
 // NewTerminalMonitor creates a new terminal monitor.
-func NewTerminalMonitor(storage *session.Storage) *TerminalMonitor {
+func NewTerminalMonitor(storage session.InstanceStore) *TerminalMonitor {
 	return &TerminalMonitor{
 		storage:            storage,
 		contentMap:         make(map[string]string),
 		hashMap:            make(map[string][]byte),
+		contentUpdated:     make(map[string]time.Time),
+		contentSeq:         make(map[string]uint64),
 		subscribers:        make(map[string][]chan types.TerminalUpdate),
 		taskCache:          make(map[string][]types.TaskItem),
 		taskCacheTimestamp: make(map[string]time.Time),
 		done:               make(chan struct{}),
+		pollInterval:       500 * time.Millisecond,
+		refreshInterval:    10 * time.Second,
 	}
 }
 
+// SetIntervals sets how often output is polled and the instance list refreshed. It must be
+// called before Start.
+func (tm *TerminalMonitor) SetIntervals(poll, refresh time.Duration) {
+	tm.pollInterval = poll
+	tm.refreshInterval = refresh
+}
+
 // Start begins monitoring terminal output.
 func (tm *TerminalMonitor) Start() {
-	tm.ticker = time.NewTicker(500 * time.Millisecond) // Polling for UI updates
+	// Free the output of killed instances without waiting for the next refresh, and tell their
+	// subscribers the terminal is gone rather than leaving them waiting for output
+	tm.unsubscribeEvents = session.Subscribe(func(e session.Event) {
+		switch e.Type {
+		case session.EventKilled:
+			tm.Forget(e.Instance)
+			tm.notify(e, types.EventInstanceRemoved, "Instance was killed")
+		case session.EventPaused:
+			tm.notify(e, types.EventInstancePaused, "Instance was paused")
+		}
+	})
+	tm.ticker = time.NewTicker(tm.pollInterval) // Polling for UI updates
+	tm.lastPoll.Store(time.Now().UnixNano())
 	go func() {
 		tm.refreshMonitoredInstances() // Initial load
 		
 		// Create ticker for refreshing instance list (much less frequent)
-		instanceRefreshTicker := time.NewTicker(10 * time.Second)
+		instanceRefreshTicker := time.NewTicker(tm.refreshInterval)
 		defer instanceRefreshTicker.Stop()
 		
 		for {
 			select {
 			case <-tm.ticker.C:
 				tm.checkForUpdates()
+				tm.lastPoll.Store(time.Now().UnixNano())
 			case <-instanceRefreshTicker.C:
 				tm.refreshMonitoredInstances() // Refresh list occasionally
 			case <-tm.done:
@@ -93,17 +139,164 @@ func (tm *TerminalMonitor) refreshMonitoredInstances() {
 		log.FileOnlyErrorLog.Printf("MONITOR: Error loading instances for monitoring: %v", err)
 		return
 	}
+	live := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		live[instance.Title] = true
+	}
+
 	tm.mutex.Lock()
 	tm.monitoredInstances = instances
+	// Drop everything kept for instances that are gone
+	for title := range tm.hashMap {
+		if !live[title] {
+			tm.forgetLocked(title)
+		}
+	}
+	for title := range tm.contentMap {
+		if !live[title] {
+			tm.forgetLocked(title)
+		}
+	}
+	for title := range tm.taskCacheTimestamp {
+		if !live[title] {
+			tm.forgetLocked(title)
+		}
+	}
 	tm.mutex.Unlock()
 	LogWebDebug("MONITOR: Refreshed, now monitoring %d instances", len(instances))
 }
 
+// notify sends a notification of type typ about the instance of e to its subscribers. A
+// subscriber that is behind loses its oldest queued update to make room, since the notification
+// matters more than output it will never render.
+func (tm *TerminalMonitor) notify(e session.Event, typ, message string) {
+	update := types.TerminalUpdate{
+		InstanceTitle: e.Instance,
+		Timestamp:     e.Time,
+		Status:        e.Status,
+		Type:          typ,
+		Message:       message,
+	}
+
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+	for _, ch := range tm.subscribers[e.Instance] {
+		select {
+		case ch <- update:
+			continue
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- update:
+		default:
+			log.FileOnlyWarningLog.Printf("MONITOR: Channel full, dropped %s notification for %s", typ, e.Instance)
+		}
+	}
+}
+
+// Forget drops the output and tasks kept for an instance, such as one that was killed.
+func (tm *TerminalMonitor) Forget(instanceTitle string) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.forgetLocked(instanceTitle)
+}
+
+// forgetLocked is Forget with tm.mutex held.
+func (tm *TerminalMonitor) forgetLocked(title string) {
+	if content, ok := tm.contentMap[title]; ok {
+		tm.contentBytes -= len(content)
+		tm.evictions++
+	}
+	delete(tm.contentMap, title)
+	delete(tm.contentUpdated, title)
+	delete(tm.contentSeq, title)
+	delete(tm.hashMap, title)
+	delete(tm.taskCache, title)
+	delete(tm.taskCacheTimestamp, title)
+}
+
+// setContentLocked keeps content as the output of an instance, with tm.mutex held. Content over
+// maxMonitorContentBytes is cut to its newest lines, and older output of other instances is
+// dropped if the total goes over maxMonitorTotalBytes.
+func (tm *TerminalMonitor) setContentLocked(title, content string) {
+	if len(content) > maxMonitorContentBytes {
+		content = content[len(content)-maxMonitorContentBytes:]
+		if i := strings.IndexByte(content, '\n'); i >= 0 {
+			content = content[i+1:]
+		}
+		tm.truncations++
+	}
+	tm.contentBytes += len(content) - len(tm.contentMap[title])
+	tm.contentMap[title] = content
+	tm.contentUpdated[title] = time.Now()
+	tm.seq++
+	tm.contentSeq[title] = tm.seq
+
+	for tm.contentBytes > maxMonitorTotalBytes {
+		oldest := ""
+		for other, updated := range tm.contentUpdated {
+			if other == title || len(tm.subscribers[other]) > 0 {
+				continue
+			}
+			if oldest == "" || updated.Before(tm.contentUpdated[oldest]) {
+				oldest = other
+			}
+		}
+		if oldest == "" {
+			return
+		}
+		// Keep the hash so unchanged output isn't stored again on the next poll
+		tm.contentBytes -= len(tm.contentMap[oldest])
+		delete(tm.contentMap, oldest)
+		delete(tm.contentUpdated, oldest)
+		tm.evictions++
+	}
+}
+
+// Stats returns the memory held by the monitor.
+func (tm *TerminalMonitor) Stats() types.MonitorStats {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+	stats := types.MonitorStats{
+		Instances:    len(tm.contentMap),
+		ContentBytes: tm.contentBytes,
+		Evictions:    tm.evictions,
+		Truncations:  tm.truncations,
+	}
+	for _, subscribers := range tm.subscribers {
+		stats.Subscribers += len(subscribers)
+	}
+	return stats
+}
+
+// Polling reports whether the monitor runs and polled output recently, and when it last did. A
+// poll that doesn't end, such as on a hung tmux command, stops it from polling.
+func (tm *TerminalMonitor) Polling() (bool, time.Time) {
+	last := tm.lastPoll.Load()
+	if last == 0 {
+		return false, time.Time{}
+	}
+	lastPoll := time.Unix(0, last)
+	select {
+	case <-tm.done:
+		return false, lastPoll
+	default:
+	}
+	return time.Since(lastPoll) < max(10*tm.pollInterval, 10*time.Second), lastPoll
+}
+
 // Stop ends the monitoring.
 func (tm *TerminalMonitor) Stop() {
 	if tm.ticker != nil {
 		tm.ticker.Stop()
 	}
+	if tm.unsubscribeEvents != nil {
+		tm.unsubscribeEvents()
+	}
 	close(tm.done)
 	
 	// Close all subscriber channels
@@ -138,7 +331,7 @@ func (tm *TerminalMonitor) Subscribe(instanceTitle string) chan types.TerminalUp
 		if err == nil {
 			for _, instance := range instances {
 				if instance.Title == instanceTitle {
-					status = string(instance.Status)
+					status = instance.Status.String()
 					_, hasPrompt = instance.HasUpdated()
 					break
 				}
@@ -152,6 +345,7 @@ func (tm *TerminalMonitor) Subscribe(instanceTitle string) chan types.TerminalUp
 			Timestamp:     time.Now(),
 			Status:        status,
 			HasPrompt:     hasPrompt,
+			Seq:           tm.contentSeq[instanceTitle],
 		}:
 		default:
 		}
@@ -218,7 +412,7 @@ func (tm *TerminalMonitor) GetContent(instanceTitle string) (string, bool) {
 		// Load all instances
 		instances, err := tm.storage.LoadInstances()
 		if err != nil {
-			log.ErrorLog.Printf("Error loading instances: %v", err)
+			log.FileOnlyErrorLog.Printf("Error loading instances: %v", err)
 			return "", false
 		}
 		
@@ -242,14 +436,14 @@ func (tm *TerminalMonitor) GetContent(instanceTitle string) (string, bool) {
 					}
 					// Only log retries for actual errors, not empty preview (which is common)
 					if previewErr != nil {
-						log.WarningLog.Printf("Retry %d: Error getting preview for %s: %v", 
+						log.FileOnlyWarningLog.Printf("Retry %d: Error getting preview for %s: %v", 
 							retries, instanceTitle, previewErr)
 					}
 					time.Sleep(100 * time.Millisecond)
 				}
 				
 				if previewErr != nil {
-					log.ErrorLog.Printf("All retries failed: Error getting preview for %s: %v", 
+					log.FileOnlyErrorLog.Printf("All retries failed: Error getting preview for %s: %v", 
 						instanceTitle, previewErr)
 					return "", false
 				}
@@ -257,14 +451,14 @@ func (tm *TerminalMonitor) GetContent(instanceTitle string) (string, bool) {
 				if preview == "" {
 					// This is a common case, only log at warning level in debug mode
 					if debugLogging {
-						log.WarningLog.Printf("Got empty preview for instance %s despite successful call", 
+						log.FileOnlyWarningLog.Printf("Got empty preview for instance %s despite successful call", 
 							instanceTitle)
 					}
 					// Return empty but valid to allow placeholder to be shown
 					
 					// Update empty cache anyway
 					tm.mutex.Lock()
-					tm.contentMap[instanceTitle] = preview
+					tm.setContentLocked(instanceTitle, preview)
 					tm.mutex.Unlock()
 					
 					return "", true
@@ -276,16 +470,17 @@ func (tm *TerminalMonitor) GetContent(instanceTitle string) (string, bool) {
 				
 				// Update our cache
 				tm.mutex.Lock()
-				tm.contentMap[instanceTitle] = preview
+				tm.setContentLocked(instanceTitle, preview)
+				content = tm.contentMap[instanceTitle]
 				tm.mutex.Unlock()
 				
-				return preview, true
+				return content, true
 			}
 		}
 		
 		// This is a legitimate warning, keep it
 		if !instanceFound {
-			log.WarningLog.Printf("Instance %s not found in storage", instanceTitle)
+			log.FileOnlyWarningLog.Printf("Instance %s not found in storage", instanceTitle)
 		}
 		
 		return "", false
@@ -585,14 +780,14 @@ func (tm *TerminalMonitor) checkForUpdates() {
 		// Get updated content
 		content, err := currentInstance.Preview()
 		if err != nil {
-			log.ErrorLog.Printf("Error capturing content for %s: %v", currentInstance.Title, err)
+			log.FileOnlyErrorLog.Printf("Error capturing content for %s: %v", currentInstance.Title, err)
 			continue
 		}
 		
 		// Skip empty content - only log in debug mode to avoid console spam
 		if content == "" {
 			if debugLogging {
-				log.WarningLog.Printf("Empty content received for active instance %s", currentInstance.Title)
+				log.FileOnlyWarningLog.Printf("Empty content received for active instance %s", currentInstance.Title)
 			}
 			continue
 		}
@@ -629,7 +824,7 @@ func (tm *TerminalMonitor) checkForUpdates() {
 			}
 			
 			// Update our content map and hash
-			tm.contentMap[currentInstance.Title] = content
+			tm.setContentLocked(currentInstance.Title, content)
 			tm.hashMap[currentInstance.Title] = newHash
 			
 			// Get prompt status
@@ -647,8 +842,9 @@ func (tm *TerminalMonitor) checkForUpdates() {
 				InstanceTitle: currentInstance.Title,
 				Content:       content,
 				Timestamp:     time.Now(),
-				Status:        string(currentInstance.Status),
+				Status:        currentInstance.Status.String(),
 				HasPrompt:     hasPrompt,
+				Seq:           tm.contentSeq[currentInstance.Title],
 			}
 			
 			// Get subscribers
@@ -671,7 +867,7 @@ func (tm *TerminalMonitor) checkForUpdates() {
 					sentCount++
 				default:
 					// This is a genuine warning - keep it
-					log.WarningLog.Printf("Channel full, skipped update for a subscriber of %s", 
+					log.FileOnlyWarningLog.Printf("Channel full, skipped update for a subscriber of %s", 
 						currentInstance.Title)
 				}
 			}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"claude-squad/session"
+	"claude-squad/web/mock"
+	"claude-squad/web/types"
+	"strings"
+	"testing"
+)
+
+func TestMonitorContentCaps(t *testing.T) {
+	tm := NewTerminalMonitor(mock.NewEmptyMockStorage())
+
+	// Output over the per instance cap keeps its newest whole lines
+	line := strings.Repeat("x", 1023) + "\n"
+	tm.setContentLocked("big", strings.Repeat(line, maxMonitorContentBytes/len(line)+10))
+	if got := tm.contentMap["big"]; len(got) > maxMonitorContentBytes || !strings.HasPrefix(got, "x") {
+		t.Errorf("kept %d bytes starting %q, want at most %d of whole lines", len(got), got[:1], maxMonitorContentBytes)
+	}
+	if stats := tm.Stats(); stats.Truncations != 1 || stats.ContentBytes != len(tm.contentMap["big"]) {
+		t.Errorf("stats = %+v after one truncation", stats)
+	}
+
+	// Past the total cap, the output nobody is subscribed to goes first
+	tm.subscribers["big"] = []chan types.TerminalUpdate{make(chan types.TerminalUpdate)}
+	chunk := strings.Repeat(line, maxMonitorContentBytes/len(line))
+	n := maxMonitorTotalBytes/len(chunk) + 2
+	for i := 0; i < n; i++ {
+		tm.setContentLocked(string(rune('a'+i%26))+strings.Repeat("-", i/26), chunk)
+	}
+	if tm.contentBytes > maxMonitorTotalBytes {
+		t.Errorf("content is %d bytes, over the %d cap", tm.contentBytes, maxMonitorTotalBytes)
+	}
+	if _, ok := tm.contentMap["big"]; !ok {
+		t.Errorf("output of a subscribed instance was evicted")
+	}
+	if _, ok := tm.contentMap["a"]; ok {
+		t.Errorf("oldest unsubscribed output was kept")
+	}
+}
+
+func TestMonitorForgetsRemovedInstances(t *testing.T) {
+	storage := mock.NewEmptyMockStorage()
+	kept := &session.Instance{Title: "kept"}
+	if err := storage.AddInstance(kept); err != nil {
+		t.Fatal(err)
+	}
+	tm := NewTerminalMonitor(storage)
+	for _, title := range []string{"kept", "removed"} {
+		tm.setContentLocked(title, "output of "+title)
+		tm.hashMap[title] = []byte(title)
+		tm.taskCache[title] = []types.TaskItem{{ID: "1"}}
+	}
+
+	tm.refreshMonitoredInstances()
+	if _, ok := tm.contentMap["removed"]; ok {
+		t.Errorf("output of a removed instance was kept")
+	}
+	if _, ok := tm.taskCache["removed"]; ok {
+		t.Errorf("tasks of a removed instance were kept")
+	}
+	if tm.contentMap["kept"] != "output of kept" {
+		t.Errorf("output of a live instance was dropped")
+	}
+
+	// Killing an instance frees its output straight away
+	tm.Start()
+	defer tm.Stop()
+	kept.Emit(session.EventKilled)
+	if stats := tm.Stats(); stats.Instances != 0 || stats.ContentBytes != 0 || stats.Evictions != 2 {
+		t.Errorf("stats = %+v after forgetting every instance", stats)
+	}
+}
+
+func TestMonitorNotifiesSubscribers(t *testing.T) {
+	tm := NewTerminalMonitor(mock.NewEmptyMockStorage())
+	tm.Start()
+	defer tm.Stop()
+
+	instance := &session.Instance{Title: "watched"}
+	updates := tm.Subscribe(instance.Title)
+	// A subscriber that is behind still gets the notification
+	for i := 0; i < cap(updates); i++ {
+		updates <- types.TerminalUpdate{InstanceTitle: instance.Title, Content: "output"}
+	}
+
+	instance.Emit(session.EventPaused)
+	instance.Emit(session.EventKilled)
+
+	var got []string
+	for len(updates) > 0 {
+		if update := <-updates; update.Type != "" {
+			got = append(got, update.Type)
+		}
+	}
+	want := []string{types.EventInstancePaused, types.EventInstanceRemoved}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("notifications = %v, want %v", got, want)
+	}
+}
+
+func TestMonitorSequencesContent(t *testing.T) {
+	storage := mock.NewEmptyMockStorage()
+	if err := storage.AddInstance(&session.Instance{Title: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	tm := NewTerminalMonitor(storage)
+	tm.setContentLocked("a", "first")
+	tm.setContentLocked("b", "other")
+	tm.setContentLocked("a", "second")
+
+	updates := tm.Subscribe("a")
+	defer tm.Unsubscribe("a", updates)
+	if update := <-updates; update.Content != "second" || update.Seq != 3 {
+		t.Errorf("initial update = %q at %d, want second at 3", update.Content, update.Seq)
+	}
+
+	// Forgetting an instance doesn't reuse its sequences
+	tm.Forget("a")
+	tm.setContentLocked("a", "again")
+	if seq := tm.contentSeq["a"]; seq != 4 {
+		t.Errorf("sequence after forgetting = %d, want 4", seq)
+	}
+}
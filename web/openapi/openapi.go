@@ -0,0 +1,283 @@
+// Package openapi builds an OpenAPI 3 document from route definitions. The web server registers
+// its handlers from the same definitions, so the document stays in sync with the routes.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI version of the generated document.
+const Version = "3.0.3"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components holds the named schemas referenced from operations.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// PathItem maps lower case HTTP methods to operations.
+type PathItem map[string]*Operation
+
+// Operation describes one endpoint.
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+	// WebSocket documents the messages exchanged after a WebSocket upgrade, which OpenAPI has no
+	// native way to express.
+	WebSocket *WebSocket `json:"x-websocket,omitempty"`
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the JSON body an operation accepts.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response for one status code.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a request or response body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// WebSocket describes the message schemas of a WebSocket endpoint.
+type WebSocket struct {
+	ClientMessage *Schema `json:"clientMessage,omitempty"`
+	ServerMessage *Schema `json:"serverMessage,omitempty"`
+}
+
+// Schema is a JSON schema, as far as OpenAPI 3.0 needs it.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+// Route defines one endpoint. Request, Response and the WebSocket messages are example values
+// whose Go types are turned into schemas.
+type Route struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Description string
+	Tag         string
+	Params      []Parameter
+	// Request is the required JSON request body, if any.
+	Request interface{}
+	// Status is the success status code, http.StatusOK if zero.
+	Status   int
+	Response interface{}
+	// ContentType is the media type of Response, application/json if empty.
+	ContentType string
+	// Errors maps error status codes to their descriptions.
+	Errors map[int]string
+	// ClientMessage and ServerMessage mark the route as a WebSocket endpoint.
+	ClientMessage interface{}
+	ServerMessage interface{}
+}
+
+// PathParam returns a required string path parameter.
+func PathParam(name, description string) Parameter {
+	return Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &Schema{Type: "string"}}
+}
+
+// QueryParam returns an optional query parameter of the given schema type.
+func QueryParam(name, typ, description string, enum ...string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ, Enum: enum}}
+}
+
+// RequiredQueryParam returns a required query parameter of the given schema type.
+func RequiredQueryParam(name, typ, description string) Parameter {
+	p := QueryParam(name, typ, description)
+	p.Required = true
+	return p
+}
+
+// Build generates the document for routes.
+func Build(info Info, routes []Route) *Document {
+	g := &generator{schemas: make(map[string]*Schema)}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+	}
+
+	for _, route := range routes {
+		op := &Operation{
+			OperationID: route.OperationID,
+			Summary:     route.Summary,
+			Description: route.Description,
+			Parameters:  route.Params,
+			Responses:   make(map[string]Response),
+		}
+		if route.Tag != "" {
+			op.Tags = []string{route.Tag}
+		}
+		if route.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: g.schemaFor(reflect.TypeOf(route.Request))},
+				},
+			}
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := Response{Description: http.StatusText(status)}
+		if route.Response != nil {
+			contentType := route.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			success.Content = map[string]MediaType{
+				contentType: {Schema: g.schemaFor(reflect.TypeOf(route.Response))},
+			}
+		}
+		op.Responses[strconv.Itoa(status)] = success
+		for code, description := range route.Errors {
+			op.Responses[strconv.Itoa(code)] = Response{Description: description}
+		}
+
+		if route.ClientMessage != nil || route.ServerMessage != nil {
+			op.WebSocket = &WebSocket{}
+			if route.ClientMessage != nil {
+				op.WebSocket.ClientMessage = g.schemaFor(reflect.TypeOf(route.ClientMessage))
+			}
+			if route.ServerMessage != nil {
+				op.WebSocket.ServerMessage = g.schemaFor(reflect.TypeOf(route.ServerMessage))
+			}
+		}
+
+		item, ok := doc.Paths[route.Path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = op
+	}
+
+	doc.Components.Schemas = g.schemas
+	return doc
+}
+
+// generator turns Go types into schemas. Named struct types become components referenced by $ref.
+type generator struct {
+	schemas map[string]*Schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Ptr:
+		s := g.schemaFor(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			// Reserve the name first so recursive types terminate
+			g.schemas[t.Name()] = &Schema{}
+			g.schemas[t.Name()] = g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	default:
+		// interface{} and anything else accepts any value
+		return &Schema{}
+	}
+}
+
+// structSchema builds an object schema from the exported fields of t, following encoding/json's
+// tag rules and flattening embedded structs.
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for key, prop := range g.structSchema(field.Type).Properties {
+				s.Properties[key] = prop
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = g.schemaFor(field.Type)
+	}
+	return s
+}
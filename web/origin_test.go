@@ -0,0 +1,47 @@
+package web
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCrossOriginRequests(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.WebServerCorsOrigin = "http://localhost:3000, https://dash.example/"
+	server := NewServer(&testStorage{instances: make(map[string]*session.Instance)}, cfg)
+
+	tests := []struct {
+		name, method, path, origin string
+		code                       int
+		allowOrigin                string
+	}{
+		{"no origin", "POST", "/api/instances/nope/pause", "", http.StatusNotFound, ""},
+		{"web UI", "POST", "/api/instances/nope/pause", "http://example.com", http.StatusNotFound, "http://example.com"},
+		{"allowed origin", "POST", "/api/instances/nope/pause", "https://dash.example", http.StatusNotFound, "https://dash.example"},
+		{"other origin", "POST", "/api/instances/nope/pause", "http://evil.example", http.StatusForbidden, ""},
+		{"other origin suspend", "POST", "/api/suspend", "http://evil.example", http.StatusForbidden, ""},
+		{"other origin read", "GET", "/api/status", "http://evil.example", http.StatusOK, ""},
+		{"allowed origin read", "GET", "/api/status", "http://localhost:3000", http.StatusOK, "http://localhost:3000"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("%s: %s %s = %d, want %d", tt.name, tt.method, tt.path, rec.Code, tt.code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", tt.name, got, tt.allowOrigin)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package prefs keeps the display preferences of web clients, so the web terminal looks the same
+// in every browser and on every device of a user.
+package prefs
+
+import (
+	"claude-squad/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// FileName is the file in the config directory holding the preferences.
+const FileName = "preferences.json"
+
+const (
+	// MinFontSize and MaxFontSize bound the terminal font size, in pixels.
+	MinFontSize = 6
+	MaxFontSize = 48
+	// maxFontFamilyLength caps the length of a font family list.
+	maxFontFamilyLength = 200
+)
+
+// Themes are the names of the terminal color themes the web UI knows.
+var Themes = []string{"dark", "light", "high-contrast", "solarized-dark", "solarized-light"}
+
+// fontFamily accepts CSS font family lists, such as "JetBrains Mono", Menlo, monospace.
+var fontFamily = regexp.MustCompile(`^[A-Za-z0-9 ,'"_-]+$`)
+
+// Display is how the web terminal is shown.
+type Display struct {
+	Theme      string `json:"theme"`
+	FontFamily string `json:"font_family"`
+	FontSize   int    `json:"font_size"`
+}
+
+// DefaultDisplay returns the preferences of a user who has set none.
+func DefaultDisplay() Display {
+	return Display{
+		Theme:      "dark",
+		FontFamily: "Menlo, Monaco, 'Courier New', monospace",
+		FontSize:   14,
+	}
+}
+
+// Validate returns an error if d has an unknown theme, an invalid font family or a font size out
+// of bounds.
+func (d Display) Validate() error {
+	known := false
+	for _, theme := range Themes {
+		known = known || d.Theme == theme
+	}
+	if !known {
+		return fmt.Errorf("unknown theme %q, use one of %v", d.Theme, Themes)
+	}
+	if len(d.FontFamily) > maxFontFamilyLength || !fontFamily.MatchString(d.FontFamily) {
+		return fmt.Errorf("font family must be a list of font names of at most %d characters", maxFontFamilyLength)
+	}
+	if d.FontSize < MinFontSize || d.FontSize > MaxFontSize {
+		return fmt.Errorf("font size must be between %d and %d", MinFontSize, MaxFontSize)
+	}
+	return nil
+}
+
+// Store keeps the preferences of each user in a JSON file. Every call reads the file, so a change
+// is seen by the next terminal opened with any server.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns the store in the config directory.
+func NewStore() (*Store, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreAt(filepath.Join(dir, FileName)), nil
+}
+
+// NewStoreAt returns a store kept in the file at path.
+func NewStoreAt(path string) *Store {
+	return &Store{path: path}
+}
+
+// Get returns the preferences of user, or DefaultDisplay if they have set none.
+func (s *Store) Get(user string) (Display, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users, err := s.load()
+	if err != nil {
+		return DefaultDisplay(), err
+	}
+	if d, ok := users[user]; ok {
+		return d, nil
+	}
+	return DefaultDisplay(), nil
+}
+
+// Save validates d and stores it as the preferences of user.
+func (s *Store) Save(user string, d Display) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users, err := s.load()
+	if err != nil {
+		return err
+	}
+	users[user] = d
+	return s.write(users)
+}
+
+func (s *Store) load() (map[string]Display, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]Display{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read preferences: %w", err)
+	}
+	users := map[string]Display{}
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	return users, nil
+}
+
+func (s *Store) write(users map[string]Display) error {
+	data, err := json.MarshalIndent(users, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal preferences: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// Write and rename so a concurrent reader never sees a partial file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write preferences: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
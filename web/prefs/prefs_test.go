@@ -0,0 +1,39 @@
+package prefs
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStoreAt(filepath.Join(t.TempDir(), FileName))
+
+	if d, err := store.Get("owner"); err != nil || d != DefaultDisplay() {
+		t.Fatalf("Get before saving = %+v, %v, want the defaults", d, err)
+	}
+
+	light := Display{Theme: "light", FontFamily: `"JetBrains Mono", monospace`, FontSize: 16}
+	if err := store.Save("owner", light); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := store.Get("owner"); d != light {
+		t.Errorf("Get = %+v, want %+v", d, light)
+	}
+	if d, _ := store.Get("token:abc"); d != DefaultDisplay() {
+		t.Errorf("another user's preferences = %+v, want the defaults", d)
+	}
+
+	for _, invalid := range []Display{
+		{Theme: "neon", FontFamily: "Menlo", FontSize: 14},
+		{Theme: "dark", FontFamily: "Menlo; } body { display: none", FontSize: 14},
+		{Theme: "dark", FontFamily: "", FontSize: 14},
+		{Theme: "dark", FontFamily: "Menlo", FontSize: 100},
+	} {
+		if err := store.Save("owner", invalid); err == nil {
+			t.Errorf("Save(%+v) succeeded", invalid)
+		}
+	}
+	if d, _ := store.Get("owner"); d != light {
+		t.Errorf("invalid preferences were saved: %+v", d)
+	}
+}
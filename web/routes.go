@@ -0,0 +1,988 @@
+package web
+
+import (
+	"claude-squad/prompt"
+	"claude-squad/session"
+	"claude-squad/web/handlers"
+	"claude-squad/web/layouts"
+	"claude-squad/web/openapi"
+	"claude-squad/web/prefs"
+	"claude-squad/web/terminal"
+	"claude-squad/web/types"
+	"encoding/json"
+	"net/http"
+)
+
+// apiVersion is reported in the OpenAPI document.
+const apiVersion = "1.0.0"
+
+// route pairs an endpoint's OpenAPI definition with its handler. The router and the document at
+// /api/openapi.json are both built from Server.routes, so a route can't be served without being
+// documented.
+type route struct {
+	spec    openapi.Route
+	handler http.HandlerFunc
+}
+
+var (
+	instanceNameParam = openapi.PathParam("name", "Instance title")
+	templateNameParam = openapi.PathParam("template", "Template name")
+	tokenIDParam      = openapi.PathParam("id", "Token ID")
+	promptIndexParam  = openapi.PathParam("index", "Position of the prompt in the history, from 0")
+	layoutNameParam   = openapi.PathParam("layout", "Dashboard layout name")
+)
+
+// routes returns every REST and WebSocket endpoint served by s. Paths are absolute.
+func (s *Server) routes() []route {
+	notFound := map[int]string{http.StatusNotFound: "Instance not found"}
+	notRunning := map[int]string{
+		http.StatusBadRequest: "Instance is not running or a parameter is invalid",
+		http.StatusNotFound:   "Instance not found",
+	}
+
+	return []route{
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/instances",
+				OperationID: "listInstances",
+				Summary:     "List instances",
+				Tag:         "instances",
+				Params: []openapi.Parameter{
+					openapi.QueryParam("limit", "integer", "Maximum number of instances to return, capped at 500"),
+					openapi.QueryParam("offset", "integer", "Number of matching instances to skip"),
+					openapi.QueryParam("sort", "string", "Sort field, prefixed with - for descending order",
+						"title", "-title", "status", "-status", "created_at", "-created_at", "updated_at", "-updated_at"),
+					openapi.QueryParam("status", "string", "Comma separated statuses to keep"),
+					openapi.QueryParam("program", "string", "Keep instances whose program contains this string"),
+					openapi.QueryParam("tag", "string", "Comma separated tags, keeping instances with any of them"),
+					openapi.QueryParam("fields", "string", "Comma separated instance fields to return"),
+					openapi.QueryParam("filter", "string", "Legacy status filter", "all", "running", "paused"),
+				},
+				Response: handlers.InstanceList{},
+				Errors:   map[int]string{http.StatusBadRequest: "Invalid query parameter"},
+			},
+			handler: s.handleInstances,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodPost,
+				Path:        "/api/instances",
+				OperationID: "createInstance",
+				Summary:     "Create and start an instance",
+				Description: "The instance gets a new branch, created from base_branch or HEAD, unless " +
+					"branch names an existing branch to check out, such as a pull request's, found " +
+					"locally or on a remote. That branch is kept when the instance is killed. With " +
+					"vcs set to dir, the instance works in a copy of path instead, without a branch. " +
+					"git_config sets git settings in the instance's worktree alone. template names a " +
+					"session template of the config filling in the program, auto-yes, branch prefix and " +
+					"worktree directory, whose prompt is sent once the instance has started. The response " +
+					"is sent once the instance has started.",
+				Tag:      "instances",
+				Request:  handlers.InstanceCreate{},
+				Status:   http.StatusCreated,
+				Response: handlers.InstanceDetail{},
+				Errors: map[int]string{
+					http.StatusBadRequest:            "Invalid body, title, path, branch, git setting or template",
+					http.StatusForbidden:             "Repository not allowed by the repository policy",
+					http.StatusConflict:              "An instance of this title exists",
+					http.StatusRequestEntityTooLarge: "Body too large",
+				},
+			},
+			handler: s.handleInstanceCreate,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/instances/graph",
+				OperationID: "getInstanceGraph",
+				Summary:     "Get the instance lineage graph",
+				Description: "Edges point from an instance to the instances forked from, reviewing or " +
+					"retrying it. /graph renders this graph.",
+				Tag:      "instances",
+				Response: session.Graph{},
+			},
+			handler: s.handleInstanceGraph,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/instances/{name}",
+				OperationID: "getInstance",
+				Summary:     "Get instance details",
+				Tag:         "instances",
+				Params:      []openapi.Parameter{instanceNameParam},
+				Response:    handlers.InstanceDetail{},
+				Errors:      notFound,
+			},
+			handler: s.handleInstanceDetail,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodPatch,
+				Path:        "/api/instances/{name}",
+				OperationID: "updateInstance",
+				Summary:     "Update instance notes, ticket, color and tags",
+				Description: "Fields left out of the body are not changed.",
+				Tag:         "instances",
+				Params:      []openapi.Parameter{instanceNameParam},
+				Request:     handlers.InstanceUpdate{},
+				Response:    handlers.InstanceDetail{},
+				Errors: map[int]string{
+					http.StatusBadRequest:            "Invalid body, ticket reference, color, tags or no field to update",
+					http.StatusNotFound:              "Instance not found",
+					http.StatusRequestEntityTooLarge: "Body too large",
+				},
+			},
+			handler: s.handleInstanceUpdate,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodDelete,
+				Path:        "/api/instances/{name}",
+				OperationID: "killInstance",
+				Summary:     "Kill an instance",
+				Description: "Closes the instance's tmux session, removes its worktree or directory copy " +
+					"and deletes it from storage. The branch is kept. Tokens scoped to the instance " +
+					"can't kill it.",
+				Tag:    "instances",
+				Params: []openapi.Parameter{instanceNameParam},
+				Status: http.StatusNoContent,
+				Errors: map[int]string{
+					http.StatusForbidden: "Request made with a scoped token",
+					http.StatusNotFound:  "Instance not found",
+					http.StatusConflict:  "The instance's branch is checked out in the repository",
+				},
+			},
+			handler: s.handleInstanceKill,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodPost,
+				Path:        "/api/instances/{name}/pause",
+				OperationID: "pauseInstance",
+				Summary:     "Pause an instance",
+				Description: "Commits the instance's changes, closes its tmux session and removes its " +
+					"worktree, keeping the branch.",
+				Tag:      "instances",
+				Params:   []openapi.Parameter{instanceNameParam},
+				Response: handlers.InstanceDetail{},
+				Errors: map[int]string{
+					http.StatusNotFound: "Instance not found",
+					http.StatusConflict: "Instance already paused, not running, or without a worktree",
+				},
+			},
+			handler: s.handleInstancePause,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodPost,
+				Path:        "/api/instances/{name}/resume",
+				OperationID: "resumeInstance",
+				Summary:     "Resume an instance",
+				Description: "Checks the branch of a paused instance out in a new worktree and starts its " +
+					"program, or restarts the program of an instance whose program exited.",
+				Tag:      "instances",
+				Params:   []openapi.Parameter{instanceNameParam},
+				Response: handlers.InstanceDetail{},
+				Errors: map[int]string{
+					http.StatusNotFound: "Instance not found",
+					http.StatusConflict: "Instance neither paused nor exited",
+				},
+			},
+			handler: s.handleInstanceResume,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/instances/{name}/output",
+				OperationID: "getInstanceOutput",
+				Summary:     "Get terminal output",
+				Description: "With since, long polls for output newer than that sequence, for clients that " +
+					"can't open the terminal WebSocket: the response carries the seq to pass next, or is " +
+					"204 No Content when nothing changed within wait seconds.",
+				Tag: "instances",
+				Params: []openapi.Parameter{
+					instanceNameParam,
+					openapi.QueryParam("format", "string", "Output format", "ansi", "html", "text"),
+					openapi.QueryParam("since", "integer", "Sequence of the output last received, 0 for the current output"),
+					openapi.QueryParam("wait", "integer", "Seconds to wait for newer output, at most 25 (default 20)"),
+				},
+				Response: handlers.InstanceOutput{},
+				Errors:   notRunning,
+			},
+			handler: s.handleInstanceOutput,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/instances/{name}/diff",
+				OperationID: "getInstanceDiff",
+				Summary:     "Get git diff",
+				Description: "format=parsed returns the structured diff below, format=stats only added and " +
+					"removed, format=raw the plain text diff, and format=patch a .patch download that git apply " +
+					"applies to the diff base, with new and binary files.",
+				Tag: "instances",
+				Params: []openapi.Parameter{
+					instanceNameParam,
+					openapi.QueryParam("format", "string", "Diff format", "parsed", "stats", "raw", "patch"),
+				},
+				Response: handlers.WebDiffStats{},
+				Errors:   notRunning,
+			},
+			handler: s.handleInstanceDiff,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/instances/{name}/files",
+				OperationID: "getInstanceFiles",
+				Summary:     "List untracked and ignored files",
+				Description: "untracked lists new files never staged, ignored the files matched by " +
+					".gitignore, which are never committed, and ignored_modified the tracked files " +
+					"matching .gitignore that changed since the worktree was created. submodules " +
+					"lists the submodules not checked out, moved to another commit or modified, and " +
+					"not_in_lfs the changed files tracked with Git LFS but stored in git.",
+				Tag:      "instances",
+				Params:   []openapi.Parameter{instanceNameParam},
+				Response: handlers.InstanceFiles{},
+				Errors:   notRunning,
+			},
+			handler: s.handleInstanceFiles,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/instances/{name}/commits",
+				OperationID: "listInstanceCommits",
+				Summary:     "List the commits of the instance branch",
+				Description: "Lists the commits made on the branch since the worktree was created, newest " +
+					"first, with their hash, author, time, message and the lines each inserted and deleted.",
+				Tag: "instances",
+				Params: []openapi.Parameter{
+					instanceNameParam,
+					openapi.QueryParam("limit", "integer", "Maximum number of commits to return, 50 by default and at most 500"),
+				},
+				Response: handlers.InstanceCommits{},
+				Errors:   notRunning,
+			},
+			handler: s.handleInstanceCommits,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/instances/{name}/stats",
+				OperationID: "getInstanceStats",
+				Summary:     "Get statistics of the instance changes",
+				Description: "Describes how the changes grew since the worktree was created: a timeline of the " +
+					"lines changed by each commit, snapshot and the uncommitted changes, the files changed most " +
+					"often, and the share of them that are tests.",
+				Tag: "instances",
+				Params: []openapi.Parameter{
+					instanceNameParam,
+					openapi.QueryParam("top", "integer", "Maximum number of files to list, 20 by default and at most 500"),
+				},
+				Response: handlers.InstanceStats{},
+				Errors:   notRunning,
+			},
+			handler: s.handleInstanceStats,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/instances/{name}/archive",
+				OperationID: "downloadInstanceArchive",
+				Summary:     "Download the worktree as a zip",
+				Description: "Streams a zip of the files of the worktree as they are on disk, with uncommitted " +
+					"changes and new files that aren't ignored, under a directory named after the branch. A paused " +
+					"instance is archived from the last commit of its branch.",
+				Tag:         "instances",
+				Params:      []openapi.Parameter{instanceNameParam},
+				Response:    "",
+				ContentType: "application/zip",
+				Errors:      notRunning,
+			},
+			handler: s.handleInstanceArchive,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/instances/{name}/conversation",
+				OperationID: "getInstanceConversation",
+				Summary:     "Get the Claude Code conversation",
+				Description: "Parsed from the latest session file Claude Code wrote under ~/.claude/projects " +
+					"for the instance's directory: its turns, tool calls with their results, model and " +
+					"token usage. Tool inputs and results are truncated.",
+				Tag: "instances",
+				Params: []openapi.Parameter{
+					instanceNameParam,
+					openapi.QueryParam("limit", "integer", "Only return the last turns"),
+				},
+				Response: handlers.InstanceConversation{},
+				Errors: map[int]string{
+					http.StatusBadRequest: "Instance has not started or limit is invalid",
+					http.StatusNotFound:   "Instance or conversation not found, or the integration is disabled",
+				},
+			},
+			handler: s.handleConversation,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/instances/{name}/prompts",
+				OperationID: "getInstancePrompts",
+				Summary:     "Get the prompt history",
+				Description: "The last prompts sent to the instance from the TUI, the web UI or the API, " +
+					"oldest first, with the time each was sent.",
+				Tag:      "instances",
+				Params:   []openapi.Parameter{instanceNameParam},
+				Response: handlers.InstancePrompts{},
+				Errors:   notFound,
+			},
+			handler: s.handlePrompts,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodPost,
+				Path:        "/api/instances/{name}/prompts/{index}/replay",
+				OperationID: "replayInstancePrompt",
+				Summary:     "Send a past prompt again",
+				Description: "Sends the prompt at index in the history again. It is added to the end of the " +
+					"history, which is returned.",
+				Tag:      "instances",
+				Params:   []openapi.Parameter{instanceNameParam, promptIndexParam},
+				Response: handlers.InstancePrompts{},
+				Errors: map[int]string{
+					http.StatusBadRequest: "Invalid prompt index",
+					http.StatusNotFound:   "Instance or prompt not found",
+					http.StatusConflict:   "Instance isn't running",
+				},
+			},
+			handler: s.handlePromptReplay,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/instances/{name}/tokens",
+				OperationID: "listInstanceTokens",
+				Summary:     "List the tokens scoped to an instance",
+				Description: "Lists the unexpired tokens, without the tokens themselves.",
+				Tag:         "tokens",
+				Params:      []openapi.Parameter{instanceNameParam},
+				Response:    handlers.TokenList{},
+				Errors:      notFound,
+			},
+			handler: s.handleInstanceTokens,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodPost,
+				Path:        "/api/instances/{name}/tokens",
+				OperationID: "issueInstanceToken",
+				Summary:     "Issue a token scoped to an instance",
+				Description: "The token only reaches this instance's API, terminal and events. A view token " +
+					"can only read and watch the terminal; a control token can also type in it. The " +
+					"token is only returned in this response, with a link opening the terminal. " +
+					"Needs web_server_require_auth.",
+				Tag:      "tokens",
+				Params:   []openapi.Parameter{instanceNameParam},
+				Request:  handlers.TokenRequest{},
+				Status:   http.StatusCreated,
+				Response: handlers.IssuedToken{},
+				Errors: map[int]string{
+					http.StatusBadRequest:            "Invalid body, scope or expiry",
+					http.StatusNotFound:              "Instance not found",
+					http.StatusConflict:              "Authentication is disabled",
+					http.StatusRequestEntityTooLarge: "Body too large",
+				},
+			},
+			handler: s.handleInstanceTokens,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodDelete,
+				Path:        "/api/instances/{name}/tokens/{id}",
+				OperationID: "revokeInstanceToken",
+				Summary:     "Revoke a token scoped to an instance",
+				Tag:         "tokens",
+				Params:      []openapi.Parameter{instanceNameParam, tokenIDParam},
+				Status:      http.StatusNoContent,
+				Errors:      map[int]string{http.StatusNotFound: "Token not found"},
+			},
+			handler: s.handleInstanceToken,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/compare",
+				OperationID: "compareInstances",
+				Summary:     "Compare the changes of two instances",
+				Description: "Each instance's changes are taken relative to the commit its worktree was " +
+					"created from. Hunks of a file changed by both that touch the same base lines and " +
+					"differ are reported as conflicts.",
+				Tag: "instances",
+				Params: []openapi.Parameter{
+					openapi.RequiredQueryParam("a", "string", "Title of the first instance"),
+					openapi.RequiredQueryParam("b", "string", "Title of the second instance"),
+				},
+				Response: handlers.InstanceComparison{},
+				Errors: map[int]string{
+					http.StatusBadRequest: "a or b is missing, or an instance is not running in a worktree",
+					http.StatusNotFound:   "Instance not found",
+				},
+			},
+			handler: s.handleCompare,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/status",
+				OperationID: "getServerStatus",
+				Summary:     "Get server status",
+				Tag:         "server",
+				Response:    handlers.ServerStatus{},
+			},
+			handler: s.handleServerStatus,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/summary",
+				OperationID: "getSummary",
+				Summary:     "Get a summary of all instances",
+				Description: "Instance counts by status, total diff stats, instances awaiting a prompt, " +
+					"the daemon status and the server uptime in one call, for status bars.",
+				Tag:      "server",
+				Response: handlers.Summary{},
+			},
+			handler: s.handleSummary,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/preferences",
+				OperationID: "getPreferences",
+				Summary:     "Get the caller's display preferences",
+				Description: "The terminal theme and font, sent to the web terminal in its config message. " +
+					"Each token scoped to an instance has its own; other requests share the owner's, " +
+					"from any browser or device.",
+				Tag:      "server",
+				Response: prefs.Display{},
+			},
+			handler: s.handlePreferences,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodPatch,
+				Path:        "/api/preferences",
+				OperationID: "updatePreferences",
+				Summary:     "Update the caller's display preferences",
+				Description: "Fields left out are not changed. Terminals opened afterwards use the new preferences.",
+				Tag:         "server",
+				Request:     handlers.DisplayUpdate{},
+				Response:    prefs.Display{},
+				Errors: map[int]string{
+					http.StatusBadRequest:            "Invalid body, unknown theme, or font out of bounds",
+					http.StatusRequestEntityTooLarge: "Body too large",
+				},
+			},
+			handler: s.handlePreferences,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/audit",
+				OperationID: "getAuditTrail",
+				Summary:     "Get the audit trail of web actions",
+				Description: "Every request changing state, and every terminal WebSocket opened read-write, " +
+					"is recorded with who made it, the endpoint, the instance, the response status and a " +
+					"summary of the body with secrets left out.",
+				Tag: "server",
+				Params: []openapi.Parameter{
+					openapi.QueryParam("instance", "string", "Only return the actions on this instance"),
+					openapi.QueryParam("limit", "integer", "Maximum number of entries, newest first (default 100, at most 1000)"),
+				},
+				Response: handlers.AuditTrail{},
+				Errors:   map[int]string{http.StatusBadRequest: "Invalid limit"},
+			},
+			handler: s.handleAudit,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/healthz",
+				OperationID: "getHealth",
+				Summary:     "Check that the server is alive",
+				Description: "Checks nothing but that the process serves requests, for liveness probes.",
+				Tag:         "server",
+				Response:    handlers.Health{},
+			},
+			handler: s.handleHealthz,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/readyz",
+				OperationID: "getReadiness",
+				Summary:     "Check that the server can do its work",
+				Description: "Checks that the instances load from storage, tmux answers and the " +
+					"terminal monitor polls output. checks has the result of each, by the names " +
+					"storage, tmux and monitor.",
+				Tag:      "server",
+				Response: handlers.Health{},
+				Errors:   map[int]string{http.StatusServiceUnavailable: "A check failed"},
+			},
+			handler: s.handleReadyz,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/events",
+				OperationID: "streamEvents",
+				Summary:     "Stream instance lifecycle events",
+				Description: "Server-sent events, each named after its type: instance_created, " +
+					"instance_status_changed, instance_prompt_detected, instance_branch_pushed, " +
+					"instance_paused, instance_resumed, instance_removed, instance_notes_changed, instance_ticket_changed, " +
+					"instance_tags_changed, instance_color_changed, instance_error, instance_command_finished, instance_restarted, " +
+					"instance_auto_yes_expired and instance_prompt_sent.",
+				Tag: "server",
+				Params: []openapi.Parameter{
+					openapi.QueryParam("instance", "string", "Only stream the events of this instance"),
+				},
+				Response:    handlers.StreamEvent{},
+				ContentType: "text/event-stream",
+			},
+			handler: s.handleEvents,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/suspend",
+				OperationID: "getSuspend",
+				Summary:     "Report whether all agents are suspended",
+				Tag:         "server",
+				Response:    handlers.SuspendState{},
+			},
+			handler: s.handleSuspend,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodPost,
+				Path:        "/api/suspend",
+				OperationID: "toggleSuspend",
+				Summary:     "Interrupt all running agents, or resume them if suspended",
+				Tag:         "server",
+				Response:    handlers.SuspendState{},
+			},
+			handler: s.handleSuspend,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/templates",
+				OperationID: "listTemplates",
+				Summary:     "List prompt templates",
+				Tag:         "templates",
+				Response:    handlers.TemplateList{},
+			},
+			handler: s.handleTemplates,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodPost,
+				Path:        "/api/templates",
+				OperationID: "createTemplate",
+				Summary:     "Create a prompt template",
+				Description: "Variables in the body, such as {file}, {ticket} or {diff}, are filled in " +
+					"when the template is used to send a prompt.",
+				Tag:      "templates",
+				Request:  prompt.Template{},
+				Status:   http.StatusCreated,
+				Response: handlers.TemplateInfo{},
+				Errors: map[int]string{
+					http.StatusBadRequest:            "Invalid body, name or empty template",
+					http.StatusConflict:              "A template of this name exists",
+					http.StatusRequestEntityTooLarge: "Body too large",
+				},
+			},
+			handler: s.handleTemplates,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/templates/{template}",
+				OperationID: "getTemplate",
+				Summary:     "Get a prompt template",
+				Tag:         "templates",
+				Params:      []openapi.Parameter{templateNameParam},
+				Response:    handlers.TemplateInfo{},
+				Errors:      map[int]string{http.StatusNotFound: "Template not found"},
+			},
+			handler: s.handleTemplate,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodPut,
+				Path:        "/api/templates/{template}",
+				OperationID: "putTemplate",
+				Summary:     "Create or replace a prompt template",
+				Description: "The name in the path is used over the one in the body.",
+				Tag:         "templates",
+				Params:      []openapi.Parameter{templateNameParam},
+				Request:     prompt.Template{},
+				Response:    handlers.TemplateInfo{},
+				Errors: map[int]string{
+					http.StatusBadRequest:            "Invalid body, name or empty template",
+					http.StatusRequestEntityTooLarge: "Body too large",
+				},
+			},
+			handler: s.handleTemplate,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodDelete,
+				Path:        "/api/templates/{template}",
+				OperationID: "deleteTemplate",
+				Summary:     "Delete a prompt template",
+				Tag:         "templates",
+				Params:      []openapi.Parameter{templateNameParam},
+				Status:      http.StatusNoContent,
+				Errors:      map[int]string{http.StatusNotFound: "Template not found"},
+			},
+			handler: s.handleTemplate,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/layouts",
+				OperationID: "listLayouts",
+				Summary:     "List dashboard layouts",
+				Description: "Named sets of instances the web UI shows as a grid of terminals.",
+				Tag:         "layouts",
+				Response:    handlers.LayoutList{},
+			},
+			handler: s.handleLayouts,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodPost,
+				Path:        "/api/layouts",
+				OperationID: "createLayout",
+				Summary:     "Create a dashboard layout",
+				Description: "Instances are shown in order, row by row, in columns terminals per row " +
+					"(default 2). A layout has at most 9 instances.",
+				Tag:      "layouts",
+				Request:  layouts.Layout{},
+				Status:   http.StatusCreated,
+				Response: layouts.Layout{},
+				Errors: map[int]string{
+					http.StatusBadRequest:            "Invalid body, name, instances or columns",
+					http.StatusConflict:              "A layout of this name exists",
+					http.StatusRequestEntityTooLarge: "Body too large",
+				},
+			},
+			handler: s.handleLayouts,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/api/layouts/{layout}",
+				OperationID: "getLayout",
+				Summary:     "Get a dashboard layout",
+				Tag:         "layouts",
+				Params:      []openapi.Parameter{layoutNameParam},
+				Response:    layouts.Layout{},
+				Errors:      map[int]string{http.StatusNotFound: "Layout not found"},
+			},
+			handler: s.handleLayout,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodPut,
+				Path:        "/api/layouts/{layout}",
+				OperationID: "putLayout",
+				Summary:     "Create or replace a dashboard layout",
+				Description: "The name in the path is used over the one in the body.",
+				Tag:         "layouts",
+				Params:      []openapi.Parameter{layoutNameParam},
+				Request:     layouts.Layout{},
+				Response:    layouts.Layout{},
+				Errors: map[int]string{
+					http.StatusBadRequest:            "Invalid body, name, instances or columns",
+					http.StatusRequestEntityTooLarge: "Body too large",
+				},
+			},
+			handler: s.handleLayout,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodDelete,
+				Path:        "/api/layouts/{layout}",
+				OperationID: "deleteLayout",
+				Summary:     "Delete a dashboard layout",
+				Tag:         "layouts",
+				Params:      []openapi.Parameter{layoutNameParam},
+				Status:      http.StatusNoContent,
+				Errors:      map[int]string{http.StatusNotFound: "Layout not found"},
+			},
+			handler: s.handleLayout,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/ws/{name}",
+				OperationID: "terminalWebSocket",
+				Summary:     "Stream a terminal over a WebSocket",
+				Description: "Upgrades to a WebSocket. The server sends terminal updates; with read-write " +
+					"privileges the client sends terminal input. /ws/terminal/{name} and " +
+					"/ws?instance={name} are aliases.",
+				Tag: "terminal",
+				Params: []openapi.Parameter{
+					instanceNameParam,
+					openapi.QueryParam("format", "string", "Output format", "ansi", "html", "text"),
+					openapi.QueryParam("privileges", "string", "Access level", "read-only", "read-write"),
+					openapi.QueryParam("caps", "string", "Comma separated control sequences the client renders: osc-title, alt-screen, cr"),
+				},
+				Status:        http.StatusSwitchingProtocols,
+				Errors:        notRunning,
+				ClientMessage: types.TerminalInput{},
+				ServerMessage: types.TerminalUpdate{},
+			},
+			handler: s.handleTerminalWebSocket,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/ws/attach/{name}",
+				OperationID: "attachWebSocket",
+				Summary:     "Attach to a terminal over a WebSocket",
+				Description: "Upgrades to a WebSocket streaming the PTY of a tmux client attached to the " +
+					"instance, as a local attach would. The server sends an attached message, then the " +
+					"output as binary messages, and a detached message once the session ends. The client " +
+					"sends its keys as binary messages, ignored unless read-write, and resize and ack " +
+					"messages. Output pauses while more than the window of attached is unacknowledged.",
+				Tag: "terminal",
+				Params: []openapi.Parameter{
+					instanceNameParam,
+					openapi.QueryParam("privileges", "string", "Access level", "read-only", "read-write"),
+					openapi.QueryParam("cols", "integer", "Columns of the terminal (default 80)"),
+					openapi.QueryParam("rows", "integer", "Rows of the terminal (default 24)"),
+				},
+				Status:        http.StatusSwitchingProtocols,
+				Errors:        notRunning,
+				ClientMessage: terminal.AttachControl{},
+				ServerMessage: terminal.AttachControl{},
+			},
+			handler: s.handleAttachWebSocket,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/ws/multiplex",
+				OperationID: "multiplexWebSocket",
+				Summary:     "Stream the terminals of several instances over one WebSocket",
+				Description: "Upgrades to a WebSocket sending the terminal updates of every instance " +
+					"subscribed to, each naming its instance in instance_title. The client subscribes " +
+					"and unsubscribes with the subscribe and unsubscribe commands naming the instance " +
+					"in instance_title, and names the instance of each input the same way. An instance " +
+					"named multiplex is reached through /ws/terminal/multiplex.",
+				Tag: "terminal",
+				Params: []openapi.Parameter{
+					openapi.QueryParam("instances", "string", "Comma separated instances to stream from the start"),
+					openapi.QueryParam("format", "string", "Output format", "ansi", "html", "text"),
+					openapi.QueryParam("privileges", "string", "Access level", "read-only", "read-write"),
+					openapi.QueryParam("caps", "string", "Comma separated control sequences the client renders: osc-title, alt-screen, cr"),
+				},
+				Status:        http.StatusSwitchingProtocols,
+				Errors:        map[int]string{http.StatusBadRequest: "Invalid format or privileges, or more than 32 instances"},
+				ClientMessage: types.TerminalInput{},
+				ServerMessage: types.TerminalUpdate{},
+			},
+			handler: s.handleMultiplexWebSocket,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/ws/layouts/{layout}",
+				OperationID: "layoutWebSocket",
+				Summary:     "Stream the terminals of a dashboard layout over one WebSocket",
+				Description: "Upgrades to a WebSocket sending a config message with the layout, then the " +
+					"terminal updates of all its instances, each naming its instance in instance_title. " +
+					"An instance that doesn't exist gets an instance_removed message. The client names " +
+					"the instance of each input in instance_title; typing needs read-write privileges.",
+				Tag: "terminal",
+				Params: []openapi.Parameter{
+					layoutNameParam,
+					openapi.QueryParam("format", "string", "Output format", "ansi", "html", "text"),
+					openapi.QueryParam("privileges", "string", "Access level", "read-only", "read-write"),
+					openapi.QueryParam("caps", "string", "Comma separated control sequences the client renders: osc-title, alt-screen, cr"),
+				},
+				Status: http.StatusSwitchingProtocols,
+				Errors: map[int]string{
+					http.StatusBadRequest: "Invalid format or privileges",
+					http.StatusNotFound:   "Layout not found",
+				},
+				ClientMessage: types.TerminalInput{},
+				ServerMessage: types.TerminalUpdate{},
+			},
+			handler: s.handleLayoutWebSocket,
+		},
+		{
+			spec: openapi.Route{
+				Method:      http.MethodGet,
+				Path:        "/ws/events",
+				OperationID: "eventsWebSocket",
+				Summary:     "Stream the activity feed over a WebSocket",
+				Description: "Upgrades to a WebSocket sending the events of /api/events as JSON messages, " +
+					"starting with the recent events of every instance. With attention=true, messages of " +
+					"type attention list the instances waiting for input, on connecting and whenever " +
+					"the list changes. An instance named events is reached through /ws/terminal/events.",
+				Tag: "server",
+				Params: []openapi.Parameter{
+					openapi.QueryParam("instance", "string", "Only stream the events of this instance"),
+					openapi.QueryParam("backlog", "boolean", "Send the recent events first (default true)"),
+					openapi.QueryParam("notify", "string", "Comma separated event types to attach a browser notification to, or all"),
+					openapi.QueryParam("attention", "boolean", "Also send the instances waiting for input"),
+				},
+				Status:        http.StatusSwitchingProtocols,
+				ServerMessage: handlers.StreamEvent{},
+			},
+			handler: s.handleEventsWebSocket,
+		},
+	}
+}
+
+// openAPIDocument builds the OpenAPI document for routes.
+func openAPIDocument(routes []route) *openapi.Document {
+	specs := make([]openapi.Route, 0, len(routes))
+	for _, r := range routes {
+		specs = append(specs, r.spec)
+	}
+	return openapi.Build(openapi.Info{
+		Title:       "Claude Squad",
+		Version:     apiVersion,
+		Description: "Monitor and control Claude Squad instances.",
+	}, specs)
+}
+
+// openAPIHandler serves the OpenAPI document as JSON.
+func openAPIHandler(doc *openapi.Document) http.HandlerFunc {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, "Error encoding OpenAPI document", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// swaggerUIPage renders the OpenAPI document with Swagger UI loaded from a CDN. The document is
+// fetched relative to the page, so it works under a base path.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Claude Squad API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
+
+// swaggerUIHandler serves the Swagger UI page.
+func swaggerUIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
+
+// graphPage draws the instance lineage graph from /api/instances/graph, one column per generation.
+// The graph is fetched relative to the page, so it works under a base path.
+const graphPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Claude Squad Lineage</title>
+  <style>
+    body { font-family: sans-serif; background: #1a1a1a; color: #dddddd; margin: 2em; }
+    .node rect { fill: #2a2a3a; stroke: #7d56f4; rx: 6; }
+    .node text { fill: #dddddd; font-size: 13px; }
+    .node .status { fill: #888888; font-size: 11px; }
+    .edge { stroke: #36cfc9; fill: none; }
+    .relation { fill: #ffcc00; font-size: 11px; }
+  </style>
+</head>
+<body>
+  <h1>Instance lineage</h1>
+  <svg id="graph"></svg>
+  <script>
+    const nodeW = 180, nodeH = 44, colGap = 90, rowGap = 16;
+    const svg = document.getElementById("graph");
+    const ns = "http://www.w3.org/2000/svg";
+    const el = (name, attrs, text) => {
+      const e = document.createElementNS(ns, name);
+      for (const [k, v] of Object.entries(attrs)) e.setAttribute(k, v);
+      if (text !== undefined) e.textContent = text;
+      return e;
+    };
+
+    fetch("api/instances/graph").then(r => r.json()).then(graph => {
+      const parent = {}, children = {};
+      for (const e of graph.edges) {
+        parent[e.child] = e;
+        (children[e.parent] = children[e.parent] || []).push(e.child);
+      }
+      // Depth first, so every instance sits in the row after its previous sibling's subtree
+      const pos = {};
+      let row = 0;
+      const place = (title, depth) => {
+        if (pos[title]) return;
+        pos[title] = { x: depth * (nodeW + colGap), y: row++ * (nodeH + rowGap) };
+        for (const child of children[title] || []) place(child, depth + 1);
+      };
+      for (const n of graph.nodes) if (!parent[n.title]) place(n.title, 0);
+      for (const n of graph.nodes) place(n.title, 0);
+
+      let width = 0, height = 0;
+      for (const p of Object.values(pos)) {
+        width = Math.max(width, p.x + nodeW + 1);
+        height = Math.max(height, p.y + nodeH + 1);
+      }
+      svg.setAttribute("width", width);
+      svg.setAttribute("height", height);
+
+      for (const e of graph.edges) {
+        const from = pos[e.parent], to = pos[e.child];
+        const x1 = from.x + nodeW, y1 = from.y + nodeH / 2, x2 = to.x, y2 = to.y + nodeH / 2;
+        const mid = (x1 + x2) / 2;
+        svg.appendChild(el("path", { class: "edge", d: "M" + x1 + "," + y1 + " C" + mid + "," + y1 + " " + mid + "," + y2 + " " + x2 + "," + y2 }));
+        svg.appendChild(el("text", { class: "relation", x: mid - 30, y: y2 - 4 }, e.relation));
+      }
+      for (const n of graph.nodes) {
+        const p = pos[n.title];
+        const g = el("g", { class: "node", transform: "translate(" + p.x + "," + p.y + ")" });
+        g.appendChild(el("rect", { width: nodeW, height: nodeH }));
+        g.appendChild(el("text", { x: 10, y: 18 }, n.title));
+        g.appendChild(el("text", { class: "status", x: 10, y: 35 }, n.status + " · " + n.program));
+        svg.appendChild(g);
+      }
+    });
+  </script>
+</body>
+</html>
+`
+
+// graphPageHandler serves the lineage graph page.
+func graphPageHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(graphPage))
+}
@@ -0,0 +1,91 @@
+package web
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/web/openapi"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIDocumentCoversRoutes(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	server := NewServer(&testStorage{instances: make(map[string]*session.Instance)}, config.DefaultConfig())
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/openapi.json = %d, want 200", rec.Code)
+	}
+
+	var doc openapi.Document
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid OpenAPI document: %v", err)
+	}
+	if doc.OpenAPI != openapi.Version {
+		t.Errorf("openapi = %q, want %q", doc.OpenAPI, openapi.Version)
+	}
+
+	for _, rt := range server.routes() {
+		item, ok := doc.Paths[rt.spec.Path]
+		if !ok || item[strings.ToLower(rt.spec.Method)] == nil {
+			t.Errorf("%s %s is routed but not documented", rt.spec.Method, rt.spec.Path)
+		}
+	}
+
+	summary, ok := doc.Components.Schemas["InstanceSummary"]
+	if !ok {
+		t.Fatalf("InstanceSummary schema missing")
+	}
+	for _, field := range []string{"title", "status", "updated_at", "diff_stats"} {
+		if summary.Properties[field] == nil {
+			t.Errorf("InstanceSummary schema missing %q", field)
+		}
+	}
+	if got := summary.Properties["updated_at"].Format; got != "date-time" {
+		t.Errorf("updated_at format = %q, want date-time", got)
+	}
+
+	// InstanceDetail embeds InstanceSummary, so its fields are flattened like encoding/json does
+	if detail := doc.Components.Schemas["InstanceDetail"]; detail == nil || detail.Properties["title"] == nil {
+		t.Errorf("InstanceDetail schema does not include embedded InstanceSummary fields")
+	}
+
+	ws := doc.Paths["/ws/{name}"]["get"]
+	if ws == nil || ws.WebSocket == nil || ws.WebSocket.ServerMessage == nil {
+		t.Errorf("WebSocket handshake not documented with its message schemas")
+	}
+}
+
+func TestInstanceGraphRoute(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	storage := &testStorage{instances: make(map[string]*session.Instance)}
+	storage.AddInstance(&session.Instance{Title: "api", Program: "claude"})
+	storage.AddInstance(&session.Instance{Title: "api-fork", Program: "claude", Parent: "api", Relation: session.RelationFork})
+	server := NewServer(storage, config.DefaultConfig())
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/instances/graph", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/instances/graph = %d, want 200", rec.Code)
+	}
+
+	var graph session.Graph
+	if err := json.Unmarshal(rec.Body.Bytes(), &graph); err != nil {
+		t.Fatalf("invalid graph: %v", err)
+	}
+	if len(graph.Nodes) != 2 {
+		t.Errorf("got %d nodes, want 2", len(graph.Nodes))
+	}
+	want := session.GraphEdge{Parent: "api", Child: "api-fork", Relation: session.RelationFork}
+	if len(graph.Edges) != 1 || graph.Edges[0] != want {
+		t.Errorf("edges = %+v, want [%+v]", graph.Edges, want)
+	}
+}
@@ -14,40 +14,62 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 
+	"claude-squad/audit"
 	"claude-squad/config"
+	"claude-squad/daemon"
 	"claude-squad/log"
+	"claude-squad/prompt"
 	"claude-squad/session"
+	"claude-squad/session/tmux"
 	"claude-squad/web/handlers"
+	"claude-squad/web/layouts"
+	"claude-squad/web/prefs"
 	webmiddleware "claude-squad/web/middleware" // Our custom middleware
 	"claude-squad/web/static" // Static file handler
+	"claude-squad/web/terminal"
+	"claude-squad/web/tokens"
 )
 
+// tmuxPingTimeout is how long /readyz waits for tmux to answer.
+const tmuxPingTimeout = 2 * time.Second
+
 // Server manages the HTTP server for monitoring Claude Squad.
 type Server struct {
-	storage         *session.Storage
+	storage         session.InstanceStore
 	config          *config.Config
 	router          chi.Router
 	srv             *http.Server
 	terminalMonitor *TerminalMonitor
+	attach          *terminal.Manager
+	templates       *prompt.Store
+	tokens          *tokens.Store
+	auditTrail      *audit.Log
+	preferences     *prefs.Store
+	layouts         *layouts.Store
 	done            chan struct{}
+	stopOnce        sync.Once
 	startTime       time.Time
 }
 
-// Handler returns the http.Handler for testing.
+// Handler returns the http.Handler for testing. It serves the router under the configured base
+// path.
 func (s *Server) Handler() http.Handler {
-	return s.router
+	return basePathHandler(s.config.WebBasePath(), s.router)
 }
 
 // NewServer creates a new monitoring server.
-func NewServer(storage *session.Storage, config *config.Config) *Server {
-	// Initialize special empty lists
-	storage.PreloadSimpleMode()
+func NewServer(storage session.InstanceStore, config *config.Config) *Server {
+	// Initialize special empty lists when backed by the real storage
+	if preloader, ok := storage.(interface{ PreloadSimpleMode() }); ok {
+		preloader.PreloadSimpleMode()
+	}
 
 	server := &Server{
 		storage:   storage,
@@ -56,8 +78,41 @@ func NewServer(storage *session.Storage, config *config.Config) *Server {
 		startTime: time.Now(),
 	}
 
+	templates, err := prompt.NewStore()
+	if err != nil {
+		log.FileOnlyErrorLog.Printf("Prompt templates unavailable: %v", err)
+	}
+	server.templates = templates
+
+	tokenStore, err := tokens.NewStore()
+	if err != nil {
+		log.FileOnlyErrorLog.Printf("Scoped tokens unavailable: %v", err)
+	}
+	server.tokens = tokenStore
+
+	auditTrail, err := audit.NewLog()
+	if err != nil {
+		log.FileOnlyErrorLog.Printf("Audit trail unavailable: %v", err)
+	}
+	server.auditTrail = auditTrail
+
+	preferences, err := prefs.NewStore()
+	if err != nil {
+		log.FileOnlyErrorLog.Printf("Display preferences unavailable: %v", err)
+	}
+	server.preferences = preferences
+
+	layoutStore, err := layouts.NewStore()
+	if err != nil {
+		log.FileOnlyErrorLog.Printf("Dashboard layouts unavailable: %v", err)
+	}
+	server.layouts = layoutStore
+
 	// Create terminal monitor
 	server.terminalMonitor = NewTerminalMonitor(storage)
+	server.terminalMonitor.SetIntervals(config.Intervals.MonitorPoll(), config.Intervals.InstanceRefresh())
+	handlers.SetPingIntervals(config.Intervals.WebSocketPing(), config.Intervals.TerminalPing())
+	server.attach = terminal.NewManager(storage, config.Intervals.TerminalPing(), config.WebAllowedOrigins())
 
 	// Create router with middleware
 	router := chi.NewRouter()
@@ -68,55 +123,39 @@ func NewServer(storage *session.Storage, config *config.Config) *Server {
 	// router.Use(chimiddleware.Logger)
 	router.Use(chimiddleware.Recoverer)
 	router.Use(chimiddleware.StripSlashes)
+	router.Use(webmiddleware.TracingMiddleware())
 	
 	// Authentication Middleware
-	// Forcing auth to be disabled for all connections by using 'true ||'
-	// This is a development convenience that bypasses auth completely
-	if true || config.WebServerAllowLocalhost {
-		log.FileOnlyInfoLog.Printf("Authentication completely disabled for all connections")
+	if config.WebServerRequireAuth {
+		router.Use(webmiddleware.AuthMiddleware(config, server.tokens))
 	} else {
-		// This branch is currently unreachable due to the 'true ||' above
-		// It's kept for future use if auth is needed
-		router.Use(webmiddleware.AuthMiddleware(config))
+		log.FileOnlyInfoLog.Printf("Authentication disabled for all connections")
 	}
+	router.Use(webmiddleware.AuditMiddleware(server.auditTrail))
 	
 	// Add rate limiting - exempt WebSocket connections from rate limiting
 	// Increase to 500/minute to handle SPA route changes and asset requests
 	router.Use(webmiddleware.RateLimitMiddleware(500, time.Minute, true)) // 500 requests per minute, WebSockets exempt
 	
-	// Set up CORS - allow all origins for testing
-	router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"}, // Allow all origins for testing
-		AllowedMethods:   []string{"GET", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: false,
-		MaxAge:           300, // Maximum value not ignored by any of major browsers
-	}))
+	// Only the web UI and the configured origins may call the API from a browser
+	origins := config.WebAllowedOrigins()
+	router.Use(corsMiddleware(origins))
+	router.Use(webmiddleware.OriginMiddleware(origins))
 	
 	// Set up minimal logging for server - only log important events to avoid UI corruption
 	// Info logs about every request would be too noisy and risk terminal UI issues
 	
-	// API routes
-	router.Route("/api", func(r chi.Router) {
-		r.Get("/instances", server.handleInstances)
-		r.Route("/instances/{name}", func(r chi.Router) {
-			r.Get("/", server.handleInstanceDetail)
-			r.Get("/output", server.handleInstanceOutput)
-			r.Get("/diff", server.handleInstanceDiff)
-		})
-		r.Get("/status", server.handleServerStatus)
-	})
-	
-	// WebSocket route for terminal streaming.
-	// Use the TerminalMonitor-based handler for all WebSocket connections
-	webSocketHandler := handlers.WebSocketHandler(server.storage, server.terminalMonitor)
-	
-	// Primary route pattern for new clients
-	router.Get("/ws/{name}", webSocketHandler)
+	// API and WebSocket routes, registered from the same definitions as the OpenAPI document
+	routes := server.routes()
+	for _, rt := range routes {
+		router.Method(rt.spec.Method, rt.spec.Path, rt.handler)
+	}
+	router.Get("/api/openapi.json", openAPIHandler(openAPIDocument(routes)))
+	router.Get("/api/docs", swaggerUIHandler)
+	router.Get("/graph", graphPageHandler)
 	
 	// Backward compatibility route for existing clients that use /ws/terminal/{name}
-	router.Get("/ws/terminal/{name}", webSocketHandler)
+	router.Get("/ws/terminal/{name}", server.handleTerminalWebSocket)
 	
 	// Compatibility route for clients that use query params: /ws?instance=...
 	router.Get("/ws", func(w http.ResponseWriter, r *http.Request) {
@@ -125,7 +164,7 @@ func NewServer(storage *session.Storage, config *config.Config) *Server {
 			chiCtx := chi.NewRouteContext()
 			chiCtx.URLParams.Add("name", instanceName)
 			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chiCtx))
-			webSocketHandler(w, r)
+			server.handleTerminalWebSocket(w, r)
 			return
 		}
 		
@@ -144,14 +183,14 @@ func NewServer(storage *session.Storage, config *config.Config) *Server {
 	}
 	
 	// Static files for web UI
-	router.Handle("/*", static.FileServer())
+	router.Handle("/*", static.FileServer(config.WebBasePath()))
 	
 	server.router = router
 	
 	// Configure HTTP server with timeouts
 	server.srv = &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", config.WebServerHost, config.WebServerPort),
-		Handler:      router,
+		Handler:      server.Handler(),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  120 * time.Second,
@@ -171,6 +210,12 @@ func (s *Server) Start() error {
 	InitDebugLog()
 	LogWebDebug("==== STARTING WEB SERVER ====")
 	
+	// Listen first, so a port or socket in use is reported to the caller
+	listener, err := s.listen()
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.config.WebServerAddress(), err)
+	}
+
 	// Log instances at startup
 	instances, err := s.storage.LoadInstances()
 	if err != nil {
@@ -188,26 +233,70 @@ func (s *Server) Start() error {
 	// Start HTTP server
 	go func() {
 		var err error
-		if s.config.WebServerUseTLS {
+		switch {
+		case s.config.WebServerSocket != "":
+			log.FileOnlyInfoLog.Printf("Starting HTTP server on unix socket %s", s.config.WebServerSocket)
+			err = s.srv.Serve(listener)
+		case s.config.WebServerUseTLS:
 			log.FileOnlyInfoLog.Printf("Starting HTTPS server on %s:%d",
 				s.config.WebServerHost, s.config.WebServerPort)
-			err = s.srv.ListenAndServeTLS("", "")  // Uses TLSConfig
-		} else {
+			err = s.srv.ServeTLS(listener, "", "")  // Uses TLSConfig
+		default:
 			log.FileOnlyInfoLog.Printf("Starting HTTP server on %s:%d",
 				s.config.WebServerHost, s.config.WebServerPort)
-			err = s.srv.ListenAndServe()
+			err = s.srv.Serve(listener)
 		}
 		
 		if err != nil && err != http.ErrServerClosed {
-			log.ErrorLog.Printf("HTTP server error: %v", err)
+			log.FileOnlyErrorLog.Printf("HTTP server error: %v", err)
 		}
 	}()
 	
 	return nil
 }
 
-// Stop gracefully shuts down the server.
+// listen opens the unix socket or the TCP address the server is configured for. A socket file
+// left by a server that didn't shut down is replaced, and the socket is only accessible by the
+// user. The socket file is removed when the server shuts down.
+func (s *Server) listen() (net.Listener, error) {
+	path := s.config.WebServerSocket
+	if path == "" {
+		return net.Listen("tcp", s.srv.Addr)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another server is listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// Stop gracefully shuts down the server. It is safe to call more than once; the signal
+// handler and the owner of the server may both stop it.
 func (s *Server) Stop() error {
+	var err error
+	s.stopOnce.Do(func() {
+		err = s.stop()
+	})
+	return err
+}
+
+func (s *Server) stop() error {
 	LogWebDebug("==== STOPPING WEB SERVER ====")
 	close(s.done)
 	
@@ -263,7 +352,7 @@ func configureTLS(config *config.Config) *tls.Config {
 		// Use provided certificates
 		cert, err = tls.LoadX509KeyPair(config.WebServerTLSCert, config.WebServerTLSKey)
 		if err != nil {
-			log.ErrorLog.Printf("Error loading TLS certificates: %v", err)
+			log.FileOnlyErrorLog.Printf("Error loading TLS certificates: %v", err)
 			// Fall back to self-signed
 		}
 	}
@@ -272,7 +361,7 @@ func configureTLS(config *config.Config) *tls.Config {
 	if cert.Certificate == nil {
 		cert, err = generateSelfSignedCert()
 		if err != nil {
-			log.ErrorLog.Printf("Error generating self-signed cert: %v", err)
+			log.FileOnlyErrorLog.Printf("Error generating self-signed cert: %v", err)
 		}
 	}
 	
@@ -336,28 +425,249 @@ func generateSelfSignedCert() (tls.Certificate, error) {
 	return tls.X509KeyPair(certPEM, keyPEM)
 }
 
+// corsMiddleware lets pages of the allowed origins read the API's responses. The web UI, served
+// by the server itself, needs no CORS.
+func corsMiddleware(origins []string) func(http.Handler) http.Handler {
+	return cors.Handler(cors.Options{
+		AllowOriginFunc: func(r *http.Request, origin string) bool {
+			return webmiddleware.OriginAllowed(r, origins)
+		},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"Link"},
+		AllowCredentials: false,
+		MaxAge:           300, // Maximum value not ignored by any of major browsers
+	})
+}
+
 // Handler methods - these delegate to the appropriate implementation
 func (s *Server) handleInstances(w http.ResponseWriter, r *http.Request) {
 	handlers.InstancesHandler(s.storage)(w, r)
 }
 
+// handleInstanceCreate creates instances with the program and auto-yes setting of the config.
+func (s *Server) handleInstanceCreate(w http.ResponseWriter, r *http.Request) {
+	handlers.InstanceCreateHandler(s.storage, session.NewFactory(s.config, "", false))(w, r)
+}
+
 func (s *Server) handleInstanceDetail(w http.ResponseWriter, r *http.Request) {
 	handlers.InstanceDetailHandler(s.storage)(w, r)
 }
 
+func (s *Server) handleInstanceUpdate(w http.ResponseWriter, r *http.Request) {
+	handlers.InstanceUpdateHandler(s.storage)(w, r)
+}
+
+func (s *Server) handleInstanceKill(w http.ResponseWriter, r *http.Request) {
+	handlers.InstanceKillHandler(s.storage, s.tokens)(w, r)
+}
+
+func (s *Server) handleInstancePause(w http.ResponseWriter, r *http.Request) {
+	handlers.InstancePauseHandler(s.storage)(w, r)
+}
+
+func (s *Server) handleInstanceResume(w http.ResponseWriter, r *http.Request) {
+	handlers.InstanceResumeHandler(s.storage)(w, r)
+}
+
+func (s *Server) handlePrompts(w http.ResponseWriter, r *http.Request) {
+	handlers.PromptsHandler(s.storage)(w, r)
+}
+
+func (s *Server) handlePromptReplay(w http.ResponseWriter, r *http.Request) {
+	handlers.PromptReplayHandler(s.storage)(w, r)
+}
+
+func (s *Server) handleConversation(w http.ResponseWriter, r *http.Request) {
+	if s.config.DisableConversations {
+		http.Error(w, "Conversation integration disabled", http.StatusNotFound)
+		return
+	}
+	handlers.ConversationHandler(s.storage)(w, r)
+}
+
+func (s *Server) handleTemplates(w http.ResponseWriter, r *http.Request) {
+	if s.templates == nil {
+		http.Error(w, "Prompt templates unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	handlers.TemplatesHandler(s.templates)(w, r)
+}
+
+func (s *Server) handleTemplate(w http.ResponseWriter, r *http.Request) {
+	if s.templates == nil {
+		http.Error(w, "Prompt templates unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	handlers.TemplateHandler(s.templates)(w, r)
+}
+
+func (s *Server) handleInstanceTokens(w http.ResponseWriter, r *http.Request) {
+	if s.tokens == nil {
+		http.Error(w, "Scoped tokens unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	// Without auth anyone reaching the server has every instance, so a scoped token would
+	// restrict nothing
+	if r.Method == http.MethodPost && !s.config.WebServerRequireAuth {
+		http.Error(w, "Scoped tokens need web_server_require_auth to be enabled", http.StatusConflict)
+		return
+	}
+	handlers.InstanceTokensHandler(s.storage, s.tokens, s.config.WebBasePath())(w, r)
+}
+
+func (s *Server) handleInstanceToken(w http.ResponseWriter, r *http.Request) {
+	if s.tokens == nil {
+		http.Error(w, "Scoped tokens unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	handlers.InstanceTokenHandler(s.tokens)(w, r)
+}
+
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if s.auditTrail == nil {
+		http.Error(w, "Audit trail unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	handlers.AuditHandler(s.auditTrail)(w, r)
+}
+
+func (s *Server) handlePreferences(w http.ResponseWriter, r *http.Request) {
+	if s.preferences == nil {
+		http.Error(w, "Preferences unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	handlers.PreferencesHandler(s.preferences)(w, r)
+}
+
+func (s *Server) handleLayouts(w http.ResponseWriter, r *http.Request) {
+	if s.layouts == nil {
+		http.Error(w, "Dashboard layouts unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	handlers.LayoutsHandler(s.layouts)(w, r)
+}
+
+func (s *Server) handleLayout(w http.ResponseWriter, r *http.Request) {
+	if s.layouts == nil {
+		http.Error(w, "Dashboard layouts unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	handlers.LayoutHandler(s.layouts)(w, r)
+}
+
+func (s *Server) handleAttachWebSocket(w http.ResponseWriter, r *http.Request) {
+	s.attach.HandleWebSocket(w, r)
+}
+
+func (s *Server) handleMultiplexWebSocket(w http.ResponseWriter, r *http.Request) {
+	handlers.MultiplexWebSocketHandler(s.storage, s.terminalMonitor, s.preferences)(w, r)
+}
+
+func (s *Server) handleLayoutWebSocket(w http.ResponseWriter, r *http.Request) {
+	if s.layouts == nil {
+		http.Error(w, "Dashboard layouts unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	handlers.LayoutWebSocketHandler(s.storage, s.terminalMonitor, s.layouts, s.preferences)(w, r)
+}
+
 func (s *Server) handleInstanceOutput(w http.ResponseWriter, r *http.Request) {
-	handlers.InstanceOutputHandler(s.storage)(w, r)
+	handlers.InstanceOutputHandler(s.storage, s.terminalMonitor)(w, r)
 }
 
 func (s *Server) handleInstanceDiff(w http.ResponseWriter, r *http.Request) {
 	handlers.DiffHandler(s.storage)(w, r)
 }
 
+func (s *Server) handleInstanceFiles(w http.ResponseWriter, r *http.Request) {
+	handlers.FilesHandler(s.storage)(w, r)
+}
+
+func (s *Server) handleInstanceCommits(w http.ResponseWriter, r *http.Request) {
+	handlers.CommitsHandler(s.storage)(w, r)
+}
+
+func (s *Server) handleInstanceStats(w http.ResponseWriter, r *http.Request) {
+	handlers.StatsHandler(s.storage)(w, r)
+}
+
+func (s *Server) handleInstanceArchive(w http.ResponseWriter, r *http.Request) {
+	handlers.ArchiveHandler(s.storage)(w, r)
+}
+
+func (s *Server) handleInstanceGraph(w http.ResponseWriter, r *http.Request) {
+	handlers.GraphHandler(s.storage)(w, r)
+}
+
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	handlers.CompareHandler(s.storage)(w, r)
+}
+
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	handlers.SummaryHandler(s.storage, s.startTime, daemonStatus)(w, r)
+}
+
+// daemonStatus reports the auto-yes daemon from its PID file, with the stats it writes while it
+// runs.
+func daemonStatus() handlers.DaemonStatus {
+	pid, running := daemon.Status()
+	status := handlers.DaemonStatus{Running: running, PID: pid}
+	if running {
+		if stats, err := daemon.ReadStats(); err == nil {
+			status.Stats = stats
+		}
+	}
+	return status
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	handlers.HealthzHandler(s.startTime)(w, r)
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	handlers.ReadyzHandler(s.startTime, map[string]handlers.ReadinessCheck{
+		"storage": func() (string, error) {
+			instances, err := s.storage.LoadInstances()
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d instances", len(instances)), nil
+		},
+		"tmux": func() (string, error) {
+			return "", tmux.Ping(tmuxPingTimeout)
+		},
+		"monitor": func() (string, error) {
+			polling, lastPoll := s.terminalMonitor.Polling()
+			if lastPoll.IsZero() {
+				return "", fmt.Errorf("terminal monitor not started")
+			}
+			detail := fmt.Sprintf("last polled %s ago", time.Since(lastPoll).Round(time.Millisecond))
+			if !polling {
+				return detail, fmt.Errorf("terminal monitor is not polling")
+			}
+			return detail, nil
+		},
+	})(w, r)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	handlers.EventsHandler()(w, r)
+}
+
+func (s *Server) handleEventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	handlers.EventsWebSocketHandler(s.storage)(w, r)
+}
+
+func (s *Server) handleSuspend(w http.ResponseWriter, r *http.Request) {
+	handlers.SuspendHandler(s.storage)(w, r)
+}
+
 func (s *Server) handleServerStatus(w http.ResponseWriter, r *http.Request) {
 	version := "1.0.0" // TODO: Get from app
-	handlers.ServerStatusHandler(version, s.startTime)(w, r)
+	handlers.ServerStatusHandler(version, s.startTime, s.terminalMonitor.Stats)(w, r)
 }
 
 func (s *Server) handleTerminalWebSocket(w http.ResponseWriter, r *http.Request) {
-	// This function is effectively replaced by the logic in router.Get("/ws", ...)
-}
\ No newline at end of file
+	handlers.WebSocketHandler(s.storage, s.terminalMonitor, s.preferences)(w, r)
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
 
 	"claude-squad/log"
 	"claude-squad/web/handlers"
@@ -25,23 +24,22 @@ func (s *Server) setupReactServer() {
 	router.Use(chimiddleware.Recoverer)
 	router.Use(chimiddleware.StripSlashes)
 	
-	// Authentication Middleware - disabled for local connections
-	// For development and local usage, skip authentication entirely
-	log.FileOnlyInfoLog.Printf("Authentication disabled for all connections in React mode")
+	// Authentication Middleware
+	if s.config.WebServerRequireAuth {
+		router.Use(webmiddleware.AuthMiddleware(s.config, s.tokens))
+	} else {
+		log.FileOnlyInfoLog.Printf("Authentication disabled for all connections in React mode")
+	}
+	router.Use(webmiddleware.AuditMiddleware(s.auditTrail))
 	
 	// Add rate limiting - exempt WebSocket connections from rate limiting
 	// Increase to 500/minute to handle SPA route changes and asset requests
 	router.Use(webmiddleware.RateLimitMiddleware(500, time.Minute, true)) // 500 requests per minute, WebSockets exempt
 	
-	// Set up CORS - allow all origins for testing
-	router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"}, // Allow all origins for testing
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: false,
-		MaxAge:           300, // Maximum value not ignored by any of major browsers
-	}))
+	// Only the web UI and the configured origins may call the API from a browser
+	origins := s.config.WebAllowedOrigins()
+	router.Use(corsMiddleware(origins))
+	router.Use(webmiddleware.OriginMiddleware(origins))
 	
 	// API routes
 	router.Route("/api", func(r chi.Router) {
@@ -50,15 +48,32 @@ func (s *Server) setupReactServer() {
 			r.Get("/", s.handleInstanceDetail)
 			r.Get("/output", s.handleInstanceOutput)
 			r.Get("/diff", s.handleInstanceDiff)
+			r.Get("/tokens", s.handleInstanceTokens)
+			r.Post("/tokens", s.handleInstanceTokens)
+			r.Delete("/tokens/{id}", s.handleInstanceToken)
 		})
 		r.Get("/status", s.handleServerStatus)
+		r.Get("/audit", s.handleAudit)
+		r.Get("/preferences", s.handlePreferences)
+		r.Patch("/preferences", s.handlePreferences)
+		r.Get("/layouts", s.handleLayouts)
+		r.Post("/layouts", s.handleLayouts)
+		r.Get("/layouts/{layout}", s.handleLayout)
+		r.Put("/layouts/{layout}", s.handleLayout)
+		r.Delete("/layouts/{layout}", s.handleLayout)
+		r.Get("/events", s.handleEvents)
 	})
+	router.Get("/healthz", s.handleHealthz)
+	router.Get("/readyz", s.handleReadyz)
 	
 	// WebSocket route for terminal streaming
-	webSocketHandler := handlers.WebSocketHandler(s.storage, s.terminalMonitor)
+	webSocketHandler := handlers.WebSocketHandler(s.storage, s.terminalMonitor, s.preferences)
 	
 	// Primary route pattern for new clients
 	router.Get("/ws/{name}", webSocketHandler)
+	router.Get("/ws/events", s.handleEventsWebSocket)
+	router.Get("/ws/layouts/{layout}", s.handleLayoutWebSocket)
+	router.Get("/ws/multiplex", s.handleMultiplexWebSocket)
 	
 	// Backward compatibility route for existing clients that use /ws/terminal/{name}
 	router.Get("/ws/terminal/{name}", webSocketHandler)
@@ -81,15 +96,15 @@ func (s *Server) setupReactServer() {
 
 	// For backward compatibility, maintain these explicitly defined routes
 	router.Get("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		static.ReactFileServer().ServeHTTP(w, r)
+		static.ReactFileServer(s.config.WebBasePath()).ServeHTTP(w, r)
 	}))
 	
 	router.Get("/index.html", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		static.ReactFileServer().ServeHTTP(w, r)
+		static.ReactFileServer(s.config.WebBasePath()).ServeHTTP(w, r)
 	}))
 	
 	// Serve static files and SPA routes
-	router.Handle("/*", static.ReactFileServer())
+	router.Handle("/*", static.ReactFileServer(s.config.WebBasePath()))
 	
 	s.router = router
 }
@@ -100,5 +115,5 @@ func (s *Server) UseReactServer() {
 	s.setupReactServer()
 	
 	// Update HTTP server handler
-	s.srv.Handler = s.router
+	s.srv.Handler = s.Handler()
 }
\ No newline at end of file
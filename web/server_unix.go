@@ -16,16 +16,16 @@ func (s *Server) setupPlatformSignals() {
 	
 	go func() {
 		for sig := range signalChan {
-			log.InfoLog.Printf("Received signal: %v", sig)
+			log.FileOnlyInfoLog.Printf("Received signal: %v", sig)
 			
 			switch sig {
 			case syscall.SIGINT, syscall.SIGTERM:
 				// Graceful shutdown
-				log.InfoLog.Printf("Shutting down web server due to signal: %v", sig)
+				log.FileOnlyInfoLog.Printf("Shutting down web server due to signal: %v", sig)
 				s.Stop()
 			case syscall.SIGHUP:
 				// Reload configuration (not implemented yet)
-				log.InfoLog.Printf("Reload configuration (not implemented)")
+				log.FileOnlyInfoLog.Printf("Reload configuration (not implemented)")
 			}
 		}
 	}()
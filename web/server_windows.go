@@ -17,12 +17,12 @@ func (s *Server) setupPlatformSignals() {
 	
 	go func() {
 		for sig := range signalChan {
-			log.InfoLog.Printf("Received signal: %v", sig)
+			log.FileOnlyInfoLog.Printf("Received signal: %v", sig)
 			
 			switch sig {
 			case syscall.SIGINT, syscall.SIGTERM:
 				// Graceful shutdown
-				log.InfoLog.Printf("Shutting down web server due to signal: %v", sig)
+				log.FileOnlyInfoLog.Printf("Shutting down web server due to signal: %v", sig)
 				s.Stop()
 			}
 		}
@@ -0,0 +1,104 @@
+package static
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// encodings are the precompressed copies of an asset looked for next to it, in order of
+// preference.
+var encodings = []struct {
+	name string
+	ext  string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// etags caches the ETag of each asset by its name, size and modification time, so files are
+// hashed once.
+var etags sync.Map
+
+// serveAsset serves the file name of fsys with an ETag and a Cache-Control header. When the client
+// accepts it, a precompressed copy such as name.br or name.gz is served instead if one exists.
+// Conditional and range requests are handled by http.ServeContent.
+func serveAsset(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) {
+	served, encoding := name, ""
+	for _, enc := range encodings {
+		if !acceptsEncoding(r, enc.name) {
+			continue
+		}
+		if info, err := fs.Stat(fsys, name+enc.ext); err == nil && !info.IsDir() {
+			served, encoding = name+enc.ext, enc.name
+			break
+		}
+	}
+
+	info, err := fs.Stat(fsys, served)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := fs.ReadFile(fsys, served)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Accept-Encoding")
+	header.Set("Cache-Control", cacheControl(name))
+	header.Set("ETag", etag(served, info, data))
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+	}
+	// Set from the uncompressed name, as sniffing the compressed content would fail
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		header.Set("Content-Type", ctype)
+	}
+	http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(data))
+}
+
+// cacheControl returns the Cache-Control header of an asset. Vite names the files under assets/
+// after a hash of their content, so they never change and are cached for a year; other files
+// are revalidated with their ETag on every use.
+func cacheControl(name string) string {
+	if strings.HasPrefix(name, "assets/") {
+		return "public, max-age=31536000, immutable"
+	}
+	return "no-cache"
+}
+
+// etag returns the strong ETag of an asset, hashing its content the first time it is served.
+func etag(name string, info fs.FileInfo, data []byte) string {
+	key := fmt.Sprintf("%s|%d|%d", name, info.Size(), info.ModTime().UnixNano())
+	if tag, ok := etags.Load(key); ok {
+		return tag.(string)
+	}
+	sum := sha256.Sum256(data)
+	tag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	etags.Store(key, tag)
+	return tag
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header of r accepts encoding.
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+		// q=0 refuses the encoding
+		q := strings.ReplaceAll(strings.TrimSpace(params), " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSPAFileServer(t *testing.T) {
+	server := &spaFileServer{
+		fs: fstest.MapFS{
+			"index.html":              {Data: []byte("<html><head><title>cs</title></head></html>")},
+			"favicon.svg":             {Data: []byte("<svg/>")},
+			"assets/index-1234.js":    {Data: []byte("console.log('app')")},
+			"assets/index-1234.js.gz": {Data: []byte("gzipped")},
+			"assets/index-1234.js.br": {Data: []byte("brotli")},
+		},
+		basePath: "/cs",
+	}
+	get := func(target string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name           string
+		target         string
+		acceptEncoding string
+		body           string
+		encoding       string
+		cacheControl   string
+	}{
+		{"plain asset", "/assets/index-1234.js", "", "console.log('app')", "", "public, max-age=31536000, immutable"},
+		{"brotli preferred", "/assets/index-1234.js", "gzip, br", "brotli", "br", "public, max-age=31536000, immutable"},
+		{"gzip", "/assets/index-1234.js", "gzip, deflate", "gzipped", "gzip", "public, max-age=31536000, immutable"},
+		{"refused encoding", "/assets/index-1234.js", "br;q=0, gzip", "gzipped", "gzip", "public, max-age=31536000, immutable"},
+		{"asset under a nested route", "/terminal/assets/index-1234.js", "", "console.log('app')", "", "public, max-age=31536000, immutable"},
+		{"unhashed file", "/favicon.svg", "gzip", "<svg/>", "", "no-cache"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(tt.target, map[string]string{"Accept-Encoding": tt.acceptEncoding})
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			if got := rec.Body.String(); got != tt.body {
+				t.Errorf("body = %q, want %q", got, tt.body)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.cacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.cacheControl)
+			}
+		})
+	}
+
+	rec := get("/assets/index-1234.js", nil)
+	if ct := rec.Header().Get("Content-Type"); !strings.Contains(ct, "javascript") {
+		t.Errorf("Content-Type = %q, want JavaScript", ct)
+	}
+	tag := rec.Header().Get("ETag")
+	if tag == "" {
+		t.Fatalf("no ETag")
+	}
+	if rec := get("/assets/index-1234.js", map[string]string{"If-None-Match": tag}); rec.Code != http.StatusNotModified {
+		t.Errorf("revalidation status = %d, want 304", rec.Code)
+	}
+
+	rec = get("/terminal/foo", nil)
+	if body := rec.Body.String(); !strings.Contains(body, `<base href="/cs/">`) {
+		t.Errorf("SPA route served %q, want the index with its base path", body)
+	}
+	if rec := get("/missing.js", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("missing file status = %d, want 404", rec.Code)
+	}
+}
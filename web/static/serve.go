@@ -1,12 +1,13 @@
 package static
 
 import (
+	"bytes"
 	"embed"
+	"html"
 	"io/fs"
 	"net/http"
 	"os"
 	"path"
-	"path/filepath"
 	"strings"
 )
 
@@ -36,42 +37,40 @@ func (f *redirectingFileServer) ServeHTTP(w http.ResponseWriter, r *http.Request
 	http.StripPrefix("", http.FileServer(f.fs)).ServeHTTP(w, r)
 }
 
-// reactFileSystemServer is a special file server for serving React assets
-type reactFileSystemServer struct {
-	root http.Dir
+// serveIndex serves the index.html of the React app in fsys, with a base element and a meta tag
+// naming basePath added to its head. The base element resolves the app's relative asset paths
+// from its root on nested SPA routes, and the app prefixes its API and WebSocket URLs with the
+// path in the meta tag.
+func serveIndex(w http.ResponseWriter, fsys fs.FS, basePath string) {
+	data, err := fs.ReadFile(fsys, "index.html")
+	if err != nil {
+		http.Error(w, "index.html unavailable", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(injectBasePath(data, basePath))
 }
 
-func (f *reactFileSystemServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Add trailing slash to make relative paths work correctly
-	path := strings.TrimPrefix(r.URL.Path, "/")
-	
-	// If the path is empty or requests index page, serve index.html
-	if path == "" || path == "index.html" {
-		path = "index.html"
-	}
-	
-	// Check if file exists
-	filePath := filepath.Join(string(f.root), path)
-	_, err := os.Stat(filePath)
-	if os.IsNotExist(err) {
-		// For SPA routes, serve index.html
-		if !strings.Contains(path, ".") && !strings.HasPrefix(path, "api/") && !strings.HasPrefix(path, "ws/") {
-			http.ServeFile(w, r, filepath.Join(string(f.root), "index.html"))
-			return
-		}
-		http.NotFound(w, r)
-		return
-	} else if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+// injectBasePath adds the base element and base path meta tag to the head of an HTML page.
+func injectBasePath(page []byte, basePath string) []byte {
+	escaped := html.EscapeString(basePath)
+	tags := []byte(`<base href="` + escaped + `/">` +
+		`<meta name="claude-squad-base-path" content="` + escaped + `">`)
+	idx := bytes.Index(bytes.ToLower(page), []byte("<head>"))
+	if idx < 0 {
+		return append(tags, page...)
 	}
-	
-	// Serve the file
-	http.ServeFile(w, r, filePath)
+	idx += len("<head>")
+	out := make([]byte, 0, len(page)+len(tags))
+	out = append(out, page[:idx]...)
+	out = append(out, tags...)
+	return append(out, page[idx:]...)
 }
 
 // FileServer returns a handler that serves HTTP requests with the contents of the embedded static files.
-func FileServer() http.Handler {
+// basePath is the path the server is reached under behind a reverse proxy, or "".
+func FileServer(basePath string) http.Handler {
 	// Static file handler that prioritizes the React app
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Setup basic security headers
@@ -86,7 +85,7 @@ func FileServer() http.Handler {
 
 		// If React is available, use the special file server
 		if reactDirExists {
-			fs := &reactFileSystemServer{root: http.Dir("web/static/dist")}
+			fs := &spaFileServer{fs: os.DirFS("web/static/dist"), basePath: basePath}
 			fs.ServeHTTP(w, r)
 			return
 		}
@@ -100,12 +99,16 @@ func FileServer() http.Handler {
 		
 		// For root or index when React is not available, redirect to easy-terminal.html
 		if r.URL.Path == "/" || r.URL.Path == "/index.html" {
-			http.Redirect(w, r, "/easy-terminal.html", http.StatusFound)
+			http.Redirect(w, r, basePath+"/easy-terminal.html", http.StatusFound)
 			return
 		}
 		
-		// Use the standard file server for embedded assets
-		fileServer := http.FileServer(http.FS(content))
-		fileServer.ServeHTTP(w, r)
+		// Serve embedded assets with caching headers
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if info, err := fs.Stat(content, name); err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+		serveAsset(w, r, content, name)
 	})
 }
\ No newline at end of file
@@ -0,0 +1,58 @@
+package web
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// TestRequestsDoNotWriteToTerminal checks that handling requests writes nothing to stdout or
+// stderr, which would scribble over the TUI when it runs with --web.
+func TestRequestsDoNotWriteToTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = w, w
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	// Loggers writing to the terminal are created with the redirected stdout and stderr
+	log.Initialize(false)
+	func() {
+		defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+		storage := &testStorage{instances: make(map[string]*session.Instance)}
+		storage.AddInstance(&session.Instance{Title: "api", Program: "claude"})
+
+		legacy := NewServer(storage, config.DefaultConfig())
+		react := NewServer(storage, config.DefaultConfig())
+		react.UseReactServer()
+
+		paths := []string{
+			"/", "/index.html", "/terminal/api", "/assets/missing.js", "/favicon.svg",
+			"/api/instances", "/api/instances/api", "/api/instances/missing",
+			"/api/instances/api/output", "/api/instances/api/diff", "/api/instances/missing/diff",
+			"/api/status", "/api/summary", "/api/openapi.json", "/ws/missing", "/ws",
+		}
+		for _, server := range []*Server{legacy, react} {
+			for _, path := range paths {
+				server.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+			}
+		}
+	}()
+	log.Close()
+	w.Close()
+
+	if got := <-output; got != "" {
+		t.Errorf("request handling wrote to the terminal:\n%s", got)
+	}
+}
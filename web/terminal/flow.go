@@ -0,0 +1,58 @@
+package terminal
+
+import "sync"
+
+// flowWindow is how many bytes of output are sent ahead of the client's acks. Past it the PTY is
+// no longer read, so tmux holds the output back as it would for a slow local terminal, instead of
+// the socket buffering it without bound.
+const flowWindow = 1 << 20
+
+// flow counts the output bytes sent but not yet acknowledged by the client. It is safe for
+// concurrent use.
+type flow struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	window  int
+	unacked int
+	closed  bool
+}
+
+func newFlow(window int) *flow {
+	f := &flow{window: window}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// wait blocks while window bytes or more are unacknowledged. It returns false once the flow is
+// closed.
+func (f *flow) wait() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for !f.closed && f.unacked >= f.window {
+		f.cond.Wait()
+	}
+	return !f.closed
+}
+
+// sent counts n bytes sent.
+func (f *flow) sent(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.unacked += n
+}
+
+// ack counts n bytes acknowledged by the client. A client can't acknowledge more than was sent.
+func (f *flow) ack(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.unacked = max(f.unacked-max(n, 0), 0)
+	f.cond.Broadcast()
+}
+
+// close releases wait for good.
+func (f *flow) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	f.cond.Broadcast()
+}
@@ -0,0 +1,65 @@
+package terminal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlowWaitsForAcks(t *testing.T) {
+	f := newFlow(10)
+	if !f.wait() {
+		t.Fatal("wait() = false on an open flow")
+	}
+	f.sent(12)
+
+	released := make(chan bool)
+	go func() { released <- f.wait() }()
+	select {
+	case <-released:
+		t.Fatal("wait() returned with more than the window unacknowledged")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	f.ack(5)
+	select {
+	case ok := <-released:
+		if !ok {
+			t.Error("wait() = false after an ack")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wait() still blocked once the client acknowledged output")
+	}
+
+	// Acknowledging more than was sent doesn't open the window any wider
+	f.ack(100)
+	f.sent(10)
+	go func() { released <- f.wait() }()
+	f.close()
+	select {
+	case ok := <-released:
+		if ok {
+			t.Error("wait() = true on a closed flow")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wait() still blocked once the flow was closed")
+	}
+}
+
+func TestSizeParam(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		want  int
+		ok    bool
+	}{
+		{"", defaultColumns, true},
+		{"120", 120, true},
+		{"5000", maxColumns, true},
+		{"0", 0, false},
+		{"wide", 0, false},
+	} {
+		got, err := sizeParam(tc.value, defaultColumns, maxColumns)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("sizeParam(%q) = %d, %v, want %d", tc.value, got, err, tc.want)
+		}
+	}
+}
@@ -2,228 +2,147 @@ package terminal
 
 import (
 	"claude-squad/log"
+	"claude-squad/session/tmux"
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"sync"
-	"syscall"
 	"time"
-	"unsafe"
-	
+
 	"github.com/creack/pty"
 )
 
-// TmuxAttachment represents a connection to an existing tmux session
+// closeTimeout bounds how long Close waits for the tmux client to detach before killing it.
+const closeTimeout = time.Second
+
+// TmuxAttachment is a tmux client attached to an instance's tmux session in a PTY of its own, so
+// whoever reads it gets the same bytes as a local attach: cursor movement, the alternate screen of
+// full-screen programs, colors and the status line.
 type TmuxAttachment struct {
-	sessionName string    // Name of the tmux session to attach to
-	cmd         *exec.Cmd // Command for the tmux attach process
-	pty         *os.File  // PTY file for the tmux process
-	ptyClosed   chan struct{}
-	mutex       sync.Mutex
-	windowSize  struct {
-		rows    int
-		columns int
-	}
+	sessionName string // Name of the tmux session to attach to
+	readOnly    bool   // Whether the client is attached with -r, ignoring keys
+	cmd         *exec.Cmd
+	pty         *os.File
+	// done is closed once the tmux client has exited
+	done  chan struct{}
+	mutex sync.Mutex
 }
 
-// NewTmuxAttachment creates a new attachment to an existing tmux session
-func NewTmuxAttachment(sessionName string) (*TmuxAttachment, error) {
-	log.FileOnlyInfoLog.Printf("Creating new tmux attachment for session: %s", sessionName)
-	
-	// Verify the session exists
-	if !doesSessionExist(sessionName) {
+// NewTmuxAttachment creates an attachment to an existing tmux session, connected by Connect. A
+// read-only attachment can't type in the session.
+func NewTmuxAttachment(sessionName string, readOnly bool) (*TmuxAttachment, error) {
+	if !tmux.DoesSessionExist(sessionName) {
 		return nil, fmt.Errorf("tmux session '%s' does not exist", sessionName)
 	}
-
-	// Create and return the attachment (don't connect yet)
-	attachment := &TmuxAttachment{
+	return &TmuxAttachment{
 		sessionName: sessionName,
-		ptyClosed:   make(chan struct{}),
-	}
-	
-	return attachment, nil
+		readOnly:    readOnly,
+		done:        make(chan struct{}),
+	}, nil
 }
 
-// Connect establishes the connection to the tmux session
-func (t *TmuxAttachment) Connect() error {
+// Connect starts the tmux client in a PTY of columns by rows.
+func (t *TmuxAttachment) Connect(columns, rows int) error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
-	
-	// If already connected, do nothing
+
 	if t.pty != nil {
 		return nil
 	}
-	
-	log.FileOnlyInfoLog.Printf("Connecting to tmux session: %s", t.sessionName)
-	
-	// Create the command for attaching to tmux session
-	cmd := exec.Command("tmux", "attach-session", "-t", t.sessionName)
-	
-	// Start the command with a PTY
-	pty, err := pty.Start(cmd)
+
+	cmd := tmux.AttachCommand(t.sessionName, t.readOnly)
+	cmd.Env = attachEnv(os.Environ())
+	f, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(columns), Rows: uint16(rows)})
 	if err != nil {
 		return fmt.Errorf("failed to start tmux attach command: %w", err)
 	}
-	
-	// Store the command and PTY
 	t.cmd = cmd
-	t.pty = pty
-	
-	// Start a goroutine to wait for the command to exit
+	t.pty = f
+	log.FileOnlyInfoLog.Printf("Attached to tmux session %s (%dx%d, read-only %v)", t.sessionName, columns, rows, t.readOnly)
+
 	go func() {
-		defer func() {
-			t.mutex.Lock()
-			t.pty.Close()
-			t.pty = nil
-			t.cmd = nil
-			close(t.ptyClosed)
-			t.ptyClosed = make(chan struct{})
-			t.mutex.Unlock()
-			
-			log.FileOnlyInfoLog.Printf("Tmux attachment closed for session: %s", t.sessionName)
-		}()
-		
-		// Wait for the command to exit
-		t.cmd.Wait()
+		_ = cmd.Wait()
+		close(t.done)
+		log.FileOnlyInfoLog.Printf("Tmux attachment closed for session: %s", t.sessionName)
 	}()
-	
-	// Apply window size if set
-	if t.windowSize.rows > 0 && t.windowSize.columns > 0 {
-		t.ResizeTerminal(t.windowSize.columns, t.windowSize.rows)
-	}
-	
 	return nil
 }
 
-// Read reads from the tmux session
+// attachEnv returns env for the tmux client: TMUX is dropped so the client attaches even when the
+// web server runs inside tmux, and TERM describes xterm.js.
+func attachEnv(env []string) []string {
+	out := make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "TMUX=") || strings.HasPrefix(kv, "TMUX_PANE=") || strings.HasPrefix(kv, "TERM=") {
+			continue
+		}
+		out = append(out, kv)
+	}
+	return append(out, "TERM=xterm-256color")
+}
+
+// Read reads what the tmux client draws. It fails once the client has exited.
 func (t *TmuxAttachment) Read(p []byte) (n int, err error) {
 	t.mutex.Lock()
-	pty := t.pty
+	f := t.pty
 	t.mutex.Unlock()
-	
-	if pty == nil {
+
+	if f == nil {
 		return 0, fmt.Errorf("not connected to tmux session")
 	}
-	
-	return pty.Read(p)
+	return f.Read(p)
 }
 
-// Write writes to the tmux session
+// Write types p in the tmux client.
 func (t *TmuxAttachment) Write(p []byte) (n int, err error) {
 	t.mutex.Lock()
-	pty := t.pty
+	f := t.pty
 	t.mutex.Unlock()
-	
-	if pty == nil {
+
+	if f == nil {
 		return 0, fmt.Errorf("not connected to tmux session")
 	}
-	
-	return pty.Write(p)
+	return f.Write(p)
 }
 
-// Close closes the tmux attachment
+// Done is closed once the tmux client has exited, detached or because its session ended.
+func (t *TmuxAttachment) Done() <-chan struct{} {
+	return t.done
+}
+
+// Close detaches the tmux client, leaving the session running. Closing the PTY hangs the client
+// up, which detaches it; it is killed if it hasn't exited within closeTimeout.
 func (t *TmuxAttachment) Close() error {
 	t.mutex.Lock()
-	cmd := t.cmd
-	pty := t.pty
-	ptyClosed := t.ptyClosed
+	cmd, f := t.cmd, t.pty
+	t.pty = nil
 	t.mutex.Unlock()
-	
-	if cmd == nil || pty == nil {
+
+	if f == nil {
 		return nil
 	}
-	
-	log.FileOnlyInfoLog.Printf("Closing tmux attachment for session: %s", t.sessionName)
-	
-	// Try to detach from tmux session cleanly
-	_, err := pty.Write([]byte{0x01, 'd'}) // Ctrl+A d to detach
-	if err != nil {
-		log.FileOnlyWarningLog.Printf("Error sending detach command: %v, will force close", err)
-	}
-	
-	// Wait for the process to exit with timeout
+	err := f.Close()
 	select {
-	case <-ptyClosed:
-		return nil
-	case <-time.After(500 * time.Millisecond):
-		// Force kill if it doesn't exit cleanly
+	case <-t.done:
+	case <-time.After(closeTimeout):
+		log.FileOnlyWarningLog.Printf("Tmux client of %s didn't detach, killing it", t.sessionName)
 		if cmd.Process != nil {
-			cmd.Process.Signal(syscall.SIGTERM)
-		}
-		
-		// Wait again with timeout
-		select {
-		case <-ptyClosed:
-			return nil
-		case <-time.After(500 * time.Millisecond):
-			// Force kill
-			if cmd.Process != nil {
-				log.FileOnlyWarningLog.Printf("Force killing attachment process")
-				cmd.Process.Kill()
-			}
+			_ = cmd.Process.Kill()
 		}
 	}
-	
-	return nil
+	return err
 }
 
-// ResizeTerminal resizes the terminal window
+// ResizeTerminal resizes the PTY, which tmux applies to the session's window like a local
+// terminal being resized.
 func (t *TmuxAttachment) ResizeTerminal(columns int, rows int) error {
-	// Save the window size for reconnection
-	t.windowSize.rows = rows
-	t.windowSize.columns = columns
-	
 	t.mutex.Lock()
-	pty := t.pty
+	f := t.pty
 	t.mutex.Unlock()
-	
-	if pty == nil {
-		// Size will be applied when connected
-		return nil
-	}
-	
-	// Set the terminal window size
-	window := struct {
-		row uint16
-		col uint16
-		x   uint16
-		y   uint16
-	}{
-		uint16(rows),
-		uint16(columns),
-		0,
-		0,
-	}
-	
-	_, _, errno := syscall.Syscall(
-		syscall.SYS_IOCTL,
-		pty.Fd(),
-		syscall.TIOCSWINSZ,
-		uintptr(unsafe.Pointer(&window)),
-	)
-	
-	if errno != 0 {
-		return errno
-	}
-	
-	return nil
-}
 
-// CaptureOutput captures the current content of the tmux pane
-func (t *TmuxAttachment) CaptureOutput() (string, error) {
-	// Use tmux capture-pane to get the current content
-	cmd := exec.Command("tmux", "capture-pane", "-p", "-t", t.sessionName)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("failed to capture tmux pane content: %w", err)
+	if f == nil {
+		return fmt.Errorf("not connected to tmux session")
 	}
-	
-	return string(output), nil
+	return pty.Setsize(f, &pty.Winsize{Cols: uint16(columns), Rows: uint16(rows)})
 }
-
-// doesSessionExist checks if a tmux session exists
-func doesSessionExist(sessionName string) bool {
-	cmd := exec.Command("tmux", "has-session", "-t", sessionName)
-	err := cmd.Run()
-	return err == nil
-}
\ No newline at end of file
@@ -1,335 +1,326 @@
+// Package terminal attaches web clients to instances' tmux sessions, streaming the bytes of a
+// dedicated tmux client over a WebSocket, as a terminal emulator attached locally would get them.
 package terminal
 
 import (
 	"claude-squad/log"
 	"claude-squad/session"
+	"claude-squad/web/middleware"
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
 )
 
-// Message types
 const (
-	OutputMessage  = 'o' // Output from terminal to client
-	InputMessage   = 'i' // Input from client to terminal
-	ResizeMessage  = 'r' // Resize terminal window
-	PingMessage    = 'p' // Ping from client
-	PongMessage    = 'P' // Pong response
-	CloseMessage   = 'c' // Close connection
+	// defaultColumns and defaultRows size the PTY of a client that didn't give its size.
+	defaultColumns = 80
+	defaultRows    = 24
+	// maxColumns and maxRows bound the size a client may ask for.
+	maxColumns = 1000
+	maxRows    = 500
+	// maxInputBytes bounds a message from the client, such as a large paste.
+	maxInputBytes = 1 << 20
+	// writeTimeout is how long a message may take to reach a client before it is dropped.
+	writeTimeout = 10 * time.Second
+	// readBufferBytes is how much PTY output is sent in one message at most.
+	readBufferBytes = 32 << 10
 )
 
-// ResizeMessage represents a terminal resize request
-type ResizeData struct {
-	Columns int `json:"cols"`
-	Rows    int `json:"rows"`
+// AttachControl is a JSON text message of the attach protocol. The PTY bytes travel as binary
+// messages: the server's are output for the terminal, the client's are keys typed in it.
+type AttachControl struct {
+	// Type is resize or ack from the client, and attached, detached or error from the server.
+	Type string `json:"type"`
+	// Cols and Rows are the size of the terminal, in resize and attached.
+	Cols int `json:"cols,omitempty"`
+	Rows int `json:"rows,omitempty"`
+	// Bytes is how many output bytes the client has written to its terminal since its last ack.
+	Bytes int `json:"bytes,omitempty"`
+	// Window is how many output bytes the server sends ahead of the acks, in attached.
+	Window int `json:"window,omitempty"`
+	// ReadOnly is set in attached when the keys typed are ignored.
+	ReadOnly bool `json:"read_only,omitempty"`
+	// Message says why the terminal was detached, in detached and error.
+	Message string `json:"message,omitempty"`
 }
 
-// Manager handles terminal websocket connections
+// Manager serves the attach WebSockets, each with a tmux client of its own.
 type Manager struct {
-	instances        *session.Storage
-	upgrader         websocket.Upgrader
-	activeAttachments map[string]*TmuxAttachment
-	mutex            sync.Mutex
+	instances    session.InstanceStore
+	upgrader     websocket.Upgrader
+	pingInterval time.Duration
 }
 
-// NewManager creates a new terminal websocket manager
-func NewManager(instances *session.Storage) *Manager {
+// NewManager creates a manager attaching to the instances of instances, pinging its clients every
+// pingInterval, or never if it is 0. Browsers only connect from the web UI or allowedOrigins:
+// browsers don't ask before opening a WebSocket to another origin, so any page could otherwise
+// type in the terminals of a server reachable from the browser.
+func NewManager(instances session.InstanceStore, pingInterval time.Duration, allowedOrigins []string) *Manager {
 	return &Manager{
-		instances:        instances,
+		instances: instances,
 		upgrader: websocket.Upgrader{
-			ReadBufferSize:  1024,
-			WriteBufferSize: 1024,
+			ReadBufferSize:    4096,
+			WriteBufferSize:   readBufferBytes,
+			EnableCompression: true,
 			CheckOrigin: func(r *http.Request) bool {
-				return true // Allow all origins for now
+				return middleware.OriginAllowed(r, allowedOrigins)
 			},
 		},
-		activeAttachments: make(map[string]*TmuxAttachment),
+		pingInterval: pingInterval,
 	}
 }
 
-// HandleWebSocket handles a websocket connection for a terminal
+// HandleWebSocket attaches the client to the instance named in the path. The tmux client starts at
+// the cols and rows of the query, and ignores the keys typed unless privileges is read-write.
 func (m *Manager) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
-	// Get instance name from URL path or query parameter
-	instanceName := r.URL.Query().Get("instance")
-	if instanceName == "" {
+	if !m.upgrader.CheckOrigin(r) {
+		log.FileOnlyWarningLog.Printf("Attach: refused a WebSocket from origin %s", r.Header.Get("Origin"))
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+	name := chi.URLParam(r, "name")
+	if name == "" {
 		http.Error(w, "Instance name required", http.StatusBadRequest)
 		return
 	}
+	query := r.URL.Query()
+	privileges := query.Get("privileges")
+	if privileges == "" {
+		privileges = "read-only"
+	}
+	if privileges != "read-only" && privileges != "read-write" {
+		http.Error(w, "Invalid privileges parameter", http.StatusBadRequest)
+		return
+	}
+	readOnly := privileges == "read-only"
+	columns, err := sizeParam(query.Get("cols"), defaultColumns, maxColumns)
+	if err != nil {
+		http.Error(w, "Invalid cols parameter", http.StatusBadRequest)
+		return
+	}
+	rows, err := sizeParam(query.Get("rows"), defaultRows, maxRows)
+	if err != nil {
+		http.Error(w, "Invalid rows parameter", http.StatusBadRequest)
+		return
+	}
 
-	// Load instances
-	instances, err := m.instances.LoadInstances()
+	instance, err := m.findInstance(name)
 	if err != nil {
-		log.ErrorLog.Printf("Failed to load instances: %v", err)
+		log.FileOnlyErrorLog.Printf("Attach: failed to load instances: %v", err)
 		http.Error(w, "Failed to load instances", http.StatusInternalServerError)
 		return
 	}
-
-	// Find the requested instance
-	var targetInstance *session.Instance
-	for _, instance := range instances {
-		if instance.Title == instanceName {
-			targetInstance = instance
-			break
-		}
+	if instance == nil {
+		http.Error(w, "Instance not found", http.StatusNotFound)
+		return
 	}
-
-	if targetInstance == nil {
-		http.Error(w, fmt.Sprintf("Instance '%s' not found", instanceName), http.StatusNotFound)
+	if !instance.Started() || instance.Paused() || instance.GetTmuxSessionName() == "" {
+		http.Error(w, "Instance is not running", http.StatusBadRequest)
 		return
 	}
-
-	// Check if instance has a tmux session
-	if !targetInstance.Started() || targetInstance.Paused() {
-		http.Error(w, "Instance has no active tmux session", http.StatusBadRequest)
+	attachment, err := NewTmuxAttachment(instance.GetTmuxSessionName(), readOnly)
+	if err != nil {
+		http.Error(w, "Instance is not running", http.StatusBadRequest)
 		return
 	}
 
-	log.FileOnlyInfoLog.Printf("New terminal websocket connection for instance: %s", instanceName)
-
-	// Upgrade HTTP connection to WebSocket
 	conn, err := m.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.ErrorLog.Printf("Failed to upgrade websocket: %v", err)
+		log.FileOnlyErrorLog.Printf("Attach: WebSocket upgrade failed for '%s': %v", name, err)
 		return
 	}
 	defer conn.Close()
+	conn.SetReadLimit(maxInputBytes)
 
-	// Get or create tmux attachment
-	attachment, err := m.getOrCreateAttachment(instanceName, targetInstance)
-	if err != nil {
-		log.ErrorLog.Printf("Failed to create tmux attachment: %v", err)
-		conn.WriteMessage(websocket.TextMessage, []byte("Failed to connect to terminal: "+err.Error()))
+	s := &attachSession{conn: conn, attachment: attachment, flow: newFlow(flowWindow), readOnly: readOnly}
+	if err := attachment.Connect(columns, rows); err != nil {
+		log.FileOnlyErrorLog.Printf("Attach: %v", err)
+		_ = s.writeControl(AttachControl{Type: "error", Message: "failed to attach to the tmux session"})
 		return
 	}
-	defer m.closeAttachment(instanceName)
-
-	// Handle the terminal session
-	m.handleTerminalSession(conn, attachment)
-}
-
-// getOrCreateAttachment gets an existing attachment or creates a new one
-func (m *Manager) getOrCreateAttachment(instanceName string, instance *session.Instance) (*TmuxAttachment, error) {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-
-	// Check if we already have an attachment
-	attachment, exists := m.activeAttachments[instanceName]
-	if exists {
-		return attachment, nil
+	defer attachment.Close()
+	log.FileOnlyInfoLog.Printf("Attach: %s attached to '%s' (%s)", r.RemoteAddr, name, privileges)
+	if err := s.writeControl(AttachControl{
+		Type:     "attached",
+		Cols:     columns,
+		Rows:     rows,
+		Window:   flowWindow,
+		ReadOnly: readOnly,
+	}); err != nil {
+		return
 	}
+	s.run(r.Context(), m.pingInterval)
+	log.FileOnlyInfoLog.Printf("Attach: %s detached from '%s'", r.RemoteAddr, name)
+}
 
-	// Create a new attachment
-	attachment, err := NewTmuxAttachment(instance.GetTmuxSessionName())
+// findInstance returns the instance titled name, or nil if there is none.
+func (m *Manager) findInstance(name string) (*session.Instance, error) {
+	instances, err := m.instances.LoadInstances()
 	if err != nil {
 		return nil, err
 	}
-
-	// Connect to the tmux session
-	err = attachment.Connect()
-	if err != nil {
-		return nil, err
+	for _, instance := range instances {
+		if instance.Title == name {
+			return instance, nil
+		}
 	}
-
-	// Store the attachment
-	m.activeAttachments[instanceName] = attachment
-	return attachment, nil
+	return nil, nil
 }
 
-// closeAttachment closes and removes an attachment
-func (m *Manager) closeAttachment(instanceName string) {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-
-	attachment, exists := m.activeAttachments[instanceName]
-	if !exists {
-		return
+// sizeParam parses the terminal dimension value, def when empty, capped at limit.
+func sizeParam(value string, def, limit int) (int, error) {
+	if value == "" {
+		return def, nil
 	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, errors.New("invalid terminal size")
+	}
+	return min(n, limit), nil
+}
 
-	// Close the attachment
-	attachment.Close()
-	delete(m.activeAttachments, instanceName)
+// attachSession is a WebSocket attached to a tmux client.
+type attachSession struct {
+	conn       *websocket.Conn
+	attachment *TmuxAttachment
+	flow       *flow
+	readOnly   bool
+	// writeMu serializes the writes to conn
+	writeMu sync.Mutex
 }
 
-// handleTerminalSession handles the terminal websocket session
-func (m *Manager) handleTerminalSession(conn *websocket.Conn, attachment *TmuxAttachment) {
-	// Set up channels for communication
-	doneCh := make(chan struct{})
-	defer close(doneCh)
+// run streams until the client goes away or the tmux client exits, as it does when the session is
+// killed. The client is told when the tmux client exited.
+func (s *attachSession) run(ctx context.Context, pingInterval time.Duration) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	// Start reading from websocket (client to terminal)
 	go func() {
-		defer func() {
-			doneCh <- struct{}{}
-		}()
-
-		for {
-			// Read message from websocket
-			_, message, err := conn.ReadMessage()
-			if err != nil {
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway) {
-					log.FileOnlyErrorLog.Printf("Websocket error: %v", err)
-				}
-				return
-			}
-
-			// Process the message
-			if len(message) > 0 {
-				switch message[0] {
-				case InputMessage:
-					// Write input to terminal
-					if len(message) > 1 {
-						_, err := attachment.Write(message[1:])
-						if err != nil {
-							log.FileOnlyErrorLog.Printf("Failed to write to terminal: %v", err)
-							return
-						}
-					}
-
-				case ResizeMessage:
-					// Resize terminal
-					if len(message) > 1 {
-						var resizeData ResizeData
-						err := json.Unmarshal(message[1:], &resizeData)
-						if err != nil {
-							log.FileOnlyErrorLog.Printf("Failed to parse resize message: %v", err)
-							continue
-						}
-
-						err = attachment.ResizeTerminal(resizeData.Columns, resizeData.Rows)
-						if err != nil {
-							log.FileOnlyErrorLog.Printf("Failed to resize terminal: %v", err)
-						}
-					}
+		defer cancel()
+		s.readInput()
+	}()
+	if pingInterval > 0 {
+		s.conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+		s.conn.SetPongHandler(func(string) error {
+			return s.conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+		})
+		go s.ping(ctx, pingInterval)
+	}
+	outputDone := make(chan struct{})
+	go func() {
+		defer close(outputDone)
+		s.writeOutput()
+	}()
 
-				case PingMessage:
-					// Respond with pong
-					err := conn.WriteMessage(websocket.TextMessage, []byte{PongMessage})
-					if err != nil {
-						log.FileOnlyErrorLog.Printf("Failed to send pong: %v", err)
-						return
-					}
+	select {
+	case <-outputDone:
+		_ = s.writeControl(AttachControl{Type: "detached", Message: "the tmux session ended"})
+		s.writeMu.Lock()
+		_ = s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		_ = s.conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "detached"))
+		s.writeMu.Unlock()
+	case <-ctx.Done():
+	}
+	// Unblock writeOutput, waiting for acks or output
+	s.flow.close()
+	_ = s.attachment.Close()
+	<-outputDone
+}
 
-				case CloseMessage:
-					// Client requested close
-					return
-				}
+// writeOutput sends the PTY output as binary messages, holding back once the client is a window
+// behind, until the tmux client exits or the flow is closed.
+func (s *attachSession) writeOutput() {
+	buf := make([]byte, readBufferBytes)
+	for s.flow.wait() {
+		n, err := s.attachment.Read(buf)
+		if n > 0 {
+			s.writeMu.Lock()
+			_ = s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			werr := s.conn.WriteMessage(websocket.BinaryMessage, buf[:n])
+			s.writeMu.Unlock()
+			if werr != nil {
+				return
 			}
+			s.flow.sent(n)
 		}
-	}()
-
-	// Helper function to ensure ANSI escape sequences are not split
-	// Returns the safe content to send and any remaining incomplete sequence
-	ensureCompleteAnsiSequences := func(data []byte) ([]byte, []byte) {
-		// If no data or very small, return as is (might be incomplete)
-		if len(data) < 3 {
-			return data, nil
-		}
-		
-		// Check if the buffer ends with an incomplete ANSI escape sequence
-		// Look backwards from the end to find the last ESC character
-		for i := len(data) - 1; i >= 2; i-- {
-			// Found ESC character
-			if data[i] == 0x1b && data[i+1] == '[' {
-				// We found an ESC[ near the end, check if it's a complete sequence
-				// ANSI sequences end with a letter (ASCII range 64-126)
-				isComplete := false
-				for j := i + 2; j < len(data); j++ {
-					// If we find a letter, the sequence is complete
-					if data[j] >= 64 && data[j] <= 126 {
-						isComplete = true
-						break
-					}
-				}
-				
-				if !isComplete {
-					// This is an incomplete sequence at the end
-					return data[:i], data[i:]
-				}
-				
-				// If we found a complete sequence, no need to check earlier positions
-				break
-			}
+		if err != nil {
+			return
 		}
-		
-		return data, nil
 	}
-	
-	// Set up larger buffer for reading from terminal to avoid splitting ANSI sequences
-	// and a buffer to hold incomplete ANSI sequences between reads
-	buffer := make([]byte, 32768)  // Increased size to 32KB
-	var ansiBuffer []byte
-	
-	// Read from terminal and send to websocket (terminal to client)
-	go func() {
-		defer func() {
-			doneCh <- struct{}{}
-		}()
+}
 
-		for {
-			n, err := attachment.Read(buffer)
-			if err != nil {
-				log.FileOnlyErrorLog.Printf("Failed to read from terminal: %v", err)
+// readInput types the client's binary messages in the tmux client and handles its controls, until
+// the client goes away.
+func (s *attachSession) readInput() {
+	for {
+		kind, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		switch kind {
+		case websocket.BinaryMessage:
+			if s.readOnly {
+				continue
+			}
+			if _, err := s.attachment.Write(data); err != nil {
 				return
 			}
-
-			if n > 0 {
-				// Process the buffer to handle partial ANSI sequences
-				data := buffer[:n]
-				
-				// If we have incomplete ANSI sequence from last read, prepend it
-				if len(ansiBuffer) > 0 {
-					data = append(ansiBuffer, data...)
-					ansiBuffer = nil // Clear the buffer
-				}
-				
-				// Check for incomplete ANSI sequence at the end
-				content, remaining := ensureCompleteAnsiSequences(data)
-				
-				// Store any incomplete sequence for next read
-				if len(remaining) > 0 {
-					ansiBuffer = remaining
-					log.FileOnlyInfoLog.Printf("Saved incomplete ANSI sequence (%d bytes) for next read", len(remaining))
+		case websocket.TextMessage:
+			var control AttachControl
+			if err := json.Unmarshal(data, &control); err != nil {
+				log.FileOnlyWarningLog.Printf("Attach: invalid control message: %v", err)
+				continue
+			}
+			switch control.Type {
+			case "ack":
+				s.flow.ack(control.Bytes)
+			case "resize":
+				if control.Cols < 1 || control.Rows < 1 {
+					continue
 				}
-				
-				// Create message with output type prefix
-				message := make([]byte, len(content)+1)
-				message[0] = OutputMessage
-				copy(message[1:], content)
-
-				// Send to websocket
-				err = conn.WriteMessage(websocket.TextMessage, message)
-				if err != nil {
-					log.FileOnlyErrorLog.Printf("Failed to write to websocket: %v", err)
-					return
+				if err := s.attachment.ResizeTerminal(min(control.Cols, maxColumns), min(control.Rows, maxRows)); err != nil {
+					log.FileOnlyWarningLog.Printf("Attach: resize failed: %v", err)
 				}
 			}
 		}
-	}()
+	}
+}
 
-	// Start pinging to keep connection alive
-	ticker := time.NewTicker(30 * time.Second)
+// ping pings the client every interval until ctx is done.
+func (s *attachSession) ping(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
-
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				// Send ping
-				err := conn.WriteMessage(websocket.TextMessage, []byte{PingMessage})
-				if err != nil {
-					log.FileOnlyErrorLog.Printf("Failed to send ping: %v", err)
-					return
-				}
-			case <-doneCh:
+	for {
+		select {
+		case <-ticker.C:
+			s.writeMu.Lock()
+			err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+			s.writeMu.Unlock()
+			if err != nil {
 				return
 			}
+		case <-ctx.Done():
+			return
 		}
-	}()
+	}
+}
 
-	// Wait for either goroutine to finish
-	<-doneCh
-}
\ No newline at end of file
+// writeControl sends control as a text message.
+func (s *attachSession) writeControl(control AttachControl) error {
+	data, err := json.Marshal(control)
+	if err != nil {
+		return err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return s.conn.WriteMessage(websocket.TextMessage, data)
+}
@@ -0,0 +1,44 @@
+package terminal
+
+import (
+	"claude-squad/log"
+	"claude-squad/web/mock"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestHandleWebSocketOrigins(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	m := NewManager(mock.NewEmptyMockStorage(), 0, []string{"http://localhost:5173"})
+	tests := []struct {
+		origin string
+		want   int
+	}{
+		// Past the origin check, the missing instance is reported
+		{"", http.StatusNotFound},
+		{"http://example.com", http.StatusNotFound},
+		{"http://localhost:5173", http.StatusNotFound},
+		{"http://evil.example", http.StatusForbidden},
+		{"null", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/ws/attach/alpha?privileges=read-write", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("name", "alpha")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+		rec := httptest.NewRecorder()
+		m.HandleWebSocket(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("origin %q: status %d, want %d", tt.origin, rec.Code, tt.want)
+		}
+	}
+}
@@ -4,10 +4,8 @@ import (
 	"claude-squad/config"
 	"claude-squad/web"
 	"claude-squad/web/types"
-	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -33,10 +31,7 @@ func TestWebSocketTerminalStreaming(t *testing.T) {
 	cfg.WebServerAllowLocalhost = true // Allow localhost without auth
 
 	// Create server with mock storage
-	server, err := web.NewServer(storage, cfg)
-	if err != nil {
-		t.Fatalf("Failed to create server: %v", err)
-	}
+	server := web.NewServer(storage, cfg)
 
 	// Start server for testing
 	if err := server.Start(); err != nil {
@@ -59,20 +54,17 @@ func TestWebSocketTerminalStreaming(t *testing.T) {
 
 	// Run WebSocket tests
 	t.Run("TerminalWebSocketStreaming", func(t *testing.T) {
-		testTerminalWebSocketStreaming(t, ts.URL, testInstance.Instance.Title)
+		testTerminalWebSocketStreaming(t, ts.URL, testInstance.Title)
 	})
 
 	t.Run("TerminalWebSocketBidirectional", func(t *testing.T) {
-		testTerminalWebSocketBidirectional(t, ts.URL, testInstance.Instance.Title)
+		testTerminalWebSocketBidirectional(t, ts.URL, testInstance.Title)
 	})
 
 	// Allow time for all tests to complete
 	time.Sleep(500 * time.Millisecond)
 
 	// Shut down the server
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
 	server.Stop()
 }
 
@@ -0,0 +1,272 @@
+// Package tokens keeps access tokens scoped to a single instance, so one agent's terminal can be
+// shared without exposing the rest of the squad.
+package tokens
+
+import (
+	"claude-squad/config"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName is the file in the config directory holding the tokens.
+const FileName = "tokens.json"
+
+// Prefix starts every scoped token, telling them apart from the server's auth token.
+const Prefix = "cst_"
+
+const (
+	// DefaultTTL is how long a token is valid when no expiry is asked for.
+	DefaultTTL = 24 * time.Hour
+	// MaxTTL is the longest a token can be valid.
+	MaxTTL = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrNotFound is returned for a token that doesn't exist.
+	ErrNotFound = errors.New("token not found")
+	// ErrInvalid is returned for a secret that matches no valid token, including expired ones.
+	ErrInvalid = errors.New("invalid or expired token")
+)
+
+// Scope is what a token allows on its instance.
+type Scope string
+
+const (
+	// ScopeView allows reading the instance and watching its terminal.
+	ScopeView Scope = "view"
+	// ScopeControl also allows typing in its terminal and changing it.
+	ScopeControl Scope = "control"
+)
+
+// Valid reports whether s is a known scope.
+func (s Scope) Valid() bool {
+	return s == ScopeView || s == ScopeControl
+}
+
+// Token is a token scoped to one instance. Only a hash of its secret is kept; the secret is
+// returned once, when the token is issued.
+type Token struct {
+	// ID names the token for listing and revoking it.
+	ID        string    `json:"id"`
+	Instance  string    `json:"instance"`
+	Scope     Scope     `json:"scope"`
+	Label     string    `json:"label,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Hash      string    `json:"-"`
+}
+
+// Expired reports whether the token has expired at now.
+func (t Token) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// storedToken is a token as written to the file, with its hash.
+type storedToken struct {
+	Token
+	Hash string `json:"hash"`
+}
+
+// Store keeps tokens in a JSON file. Every call reads the file, so the TUI and the web server
+// see each other's tokens. Expired tokens are dropped when the file is written.
+type Store struct {
+	path string
+	mu   sync.Mutex
+	now  func() time.Time
+}
+
+// NewStore returns the store in the config directory.
+func NewStore() (*Store, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreAt(filepath.Join(dir, FileName)), nil
+}
+
+// NewStoreAt returns a store kept in the file at path.
+func NewStoreAt(path string) *Store {
+	return &Store{path: path, now: time.Now}
+}
+
+// Issue creates a token for instance, valid for ttl: DefaultTTL if zero, at most MaxTTL. It
+// returns the token and its secret.
+func (s *Store) Issue(instance string, scope Scope, ttl time.Duration, label string) (Token, string, error) {
+	if instance == "" {
+		return Token{}, "", fmt.Errorf("no instance to scope the token to")
+	}
+	if !scope.Valid() {
+		return Token{}, "", fmt.Errorf("invalid scope %q, use %s or %s", scope, ScopeView, ScopeControl)
+	}
+	switch {
+	case ttl < 0:
+		return Token{}, "", fmt.Errorf("expiry must be positive")
+	case ttl == 0:
+		ttl = DefaultTTL
+	case ttl > MaxTTL:
+		return Token{}, "", fmt.Errorf("expiry must be at most %s", MaxTTL)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return Token{}, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	secret := Prefix + base64.RawURLEncoding.EncodeToString(raw)
+	now := s.now()
+	t := Token{
+		ID:        hash(secret)[:12],
+		Instance:  instance,
+		Scope:     scope,
+		Label:     label,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+		Hash:      hash(secret),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens, err := s.load()
+	if err != nil {
+		return Token{}, "", err
+	}
+	if err := s.write(append(tokens, t)); err != nil {
+		return Token{}, "", err
+	}
+	return t, secret, nil
+}
+
+// Lookup returns the valid token whose secret is secret, or ErrInvalid.
+func (s *Store) Lookup(secret string) (Token, error) {
+	if !strings.HasPrefix(secret, Prefix) {
+		return Token{}, ErrInvalid
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens, err := s.load()
+	if err != nil {
+		return Token{}, err
+	}
+	h := hash(secret)
+	for _, t := range tokens {
+		if t.Hash == h && !t.Expired(s.now()) {
+			return t, nil
+		}
+	}
+	return Token{}, ErrInvalid
+}
+
+// List returns the unexpired tokens of instance, or of every instance if instance is empty,
+// oldest first.
+func (s *Store) List(instance string) ([]Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	list := []Token{}
+	for _, t := range tokens {
+		if (instance == "" || t.Instance == instance) && !t.Expired(s.now()) {
+			list = append(list, t)
+		}
+	}
+	return list, nil
+}
+
+// Revoke deletes the token of instance with the given ID, or returns ErrNotFound.
+func (s *Store) Revoke(instance, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens, err := s.load()
+	if err != nil {
+		return err
+	}
+	for i, t := range tokens {
+		if t.ID == id && t.Instance == instance {
+			return s.write(append(tokens[:i], tokens[i+1:]...))
+		}
+	}
+	return ErrNotFound
+}
+
+// RevokeInstance deletes the tokens of instance, or of every instance if instance is empty. It is
+// called when the instance is killed, so its tokens don't carry over to a new instance given the
+// same title.
+func (s *Store) RevokeInstance(instance string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens, err := s.load()
+	if err != nil {
+		return err
+	}
+	kept := tokens[:0]
+	for _, t := range tokens {
+		if instance != "" && t.Instance != instance {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == len(tokens) {
+		return nil
+	}
+	return s.write(kept)
+}
+
+func (s *Store) load() ([]Token, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Token{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens: %w", err)
+	}
+	var stored []storedToken
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	tokens := make([]Token, 0, len(stored))
+	for _, st := range stored {
+		st.Token.Hash = st.Hash
+		tokens = append(tokens, st.Token)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.Before(tokens[j].CreatedAt) })
+	return tokens, nil
+}
+
+func (s *Store) write(tokens []Token) error {
+	stored := make([]storedToken, 0, len(tokens))
+	for _, t := range tokens {
+		if !t.Expired(s.now()) {
+			stored = append(stored, storedToken{Token: t, Hash: t.Hash})
+		}
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal tokens: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// Write and rename so a concurrent reader never sees a partial file. Only the user may read
+	// the hashes.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write tokens: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
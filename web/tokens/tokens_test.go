@@ -0,0 +1,95 @@
+package tokens
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStoreAt(filepath.Join(t.TempDir(), FileName))
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	view, secret, err := store.Issue("alpha", ScopeView, 0, "contractor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, Prefix) || strings.Contains(secret, view.Hash) {
+		t.Errorf("secret = %q", secret)
+	}
+	if !view.ExpiresAt.Equal(now.Add(DefaultTTL)) {
+		t.Errorf("ExpiresAt = %s, want the default expiry", view.ExpiresAt)
+	}
+
+	got, err := store.Lookup(secret)
+	if err != nil || got.ID != view.ID || got.Instance != "alpha" || got.Scope != ScopeView || got.Label != "contractor" {
+		t.Errorf("Lookup = %+v, %v", got, err)
+	}
+	if _, err := store.Lookup(Prefix + "unknown"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Lookup of an unknown token = %v, want ErrInvalid", err)
+	}
+
+	if _, _, err := store.Issue("alpha", "admin", 0, ""); err == nil {
+		t.Error("Issue with an invalid scope succeeded")
+	}
+	if _, _, err := store.Issue("alpha", ScopeView, MaxTTL+time.Hour, ""); err == nil {
+		t.Error("Issue beyond the longest expiry succeeded")
+	}
+
+	short, shortSecret, err := store.Issue("alpha", ScopeControl, time.Hour, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Issue("beta", ScopeControl, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := store.List("alpha"); len(list) != 2 || list[0].ID != view.ID || list[1].ID != short.ID {
+		t.Errorf("List(alpha) = %+v", list)
+	}
+	if list, _ := store.List(""); len(list) != 3 {
+		t.Errorf("List() returned %d tokens, want 3", len(list))
+	}
+
+	// Expired tokens stop working and are left out of lists
+	now = now.Add(2 * time.Hour)
+	if _, err := store.Lookup(shortSecret); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Lookup of an expired token = %v, want ErrInvalid", err)
+	}
+	if list, _ := store.List("alpha"); len(list) != 1 {
+		t.Errorf("List(alpha) after expiry returned %d tokens, want 1", len(list))
+	}
+
+	// A token is revoked through its own instance only
+	if err := store.Revoke("beta", view.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Revoke through another instance = %v, want ErrNotFound", err)
+	}
+	if err := store.Revoke("alpha", view.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Lookup(secret); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Lookup of a revoked token = %v, want ErrInvalid", err)
+	}
+	if err := store.Revoke("alpha", view.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Revoke = %v, want ErrNotFound", err)
+	}
+
+	// Killing an instance revokes its tokens only
+	if _, _, err := store.Issue("alpha", ScopeView, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RevokeInstance("alpha"); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := store.List(""); len(list) != 1 || list[0].Instance != "beta" {
+		t.Errorf("List() after RevokeInstance(alpha) = %+v", list)
+	}
+	if err := store.RevokeInstance(""); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := store.List(""); len(list) != 0 {
+		t.Errorf("List() after RevokeInstance() = %+v", list)
+	}
+}
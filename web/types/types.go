@@ -12,6 +12,43 @@ type TerminalUpdate struct {
 	Timestamp     time.Time `json:"timestamp"`
 	Status        string    `json:"status"`
 	HasPrompt     bool      `json:"has_prompt"`
+	// Seq numbers the captures of the monitor across all instances, so a larger one is newer
+	// output. It is 0 on notifications.
+	Seq uint64 `json:"seq,omitempty"`
+
+	// Chunking fields are only set when the content exceeded the frame size
+	// cap and was split across several messages. Clients concatenate the
+	// content of every chunk sharing a FrameID in ChunkIndex order.
+	FrameID      string `json:"frame_id,omitempty"`
+	ChunkIndex   int    `json:"chunk_index,omitempty"`
+	ChunkCount   int    `json:"chunk_count,omitempty"`
+	Continuation bool   `json:"continuation,omitempty"` // True for every chunk after the first
+
+	// Type is only set on notifications, which carry no content: EventInstanceRemoved once the
+	// instance was killed and EventInstancePaused once it was paused.
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Notification types sent to terminal subscribers and on the event stream.
+const (
+	EventInstanceRemoved = "instance_removed"
+	EventInstancePaused  = "instance_paused"
+)
+
+// MonitorStats reports the memory held by the terminal monitor.
+type MonitorStats struct {
+	// Instances is the number of instances with output kept.
+	Instances int `json:"instances"`
+	// ContentBytes is the size of the output kept for all instances.
+	ContentBytes int `json:"content_bytes"`
+	// Evictions counts the outputs dropped because their instance was removed or the size cap
+	// was reached.
+	Evictions uint64 `json:"evictions"`
+	// Truncations counts the outputs cut to the per instance size cap.
+	Truncations uint64 `json:"truncations"`
+	// Subscribers is the number of channels receiving updates.
+	Subscribers int `json:"subscribers"`
 }
 
 // TerminalInput represents input sent to a terminal from a client.
@@ -32,7 +32,7 @@ func InitDebugLog() {
 	logPath := filepath.Join(os.TempDir(), DebugLogFile)
 	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
-		log.ErrorLog.Printf("Failed to create web debug log: %v", err)
+		log.FileOnlyErrorLog.Printf("Failed to create web debug log: %v", err)
 		return
 	}
 
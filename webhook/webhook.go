@@ -0,0 +1,213 @@
+// Package webhook delivers instance lifecycle events to the webhooks in the config. Payloads are
+// signed with HMAC-SHA256 so receivers can verify they came from claude-squad.
+package webhook
+
+import (
+	"bytes"
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// EventHeader carries the event type of a delivery.
+	EventHeader = "X-Claude-Squad-Event"
+	// DeliveryHeader carries a unique id for each delivery, shared by its retries.
+	DeliveryHeader = "X-Claude-Squad-Delivery"
+	// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the body.
+	SignatureHeader = "X-Claude-Squad-Signature"
+)
+
+const (
+	queueSize      = 256
+	maxAttempts    = 3
+	retryBackoff   = time.Second
+	requestTimeout = 10 * time.Second
+)
+
+// delivery is one event to send to one webhook.
+type delivery struct {
+	hook  config.WebhookConfig
+	id    string
+	event session.Event
+}
+
+// Dispatcher sends events to webhooks from a background goroutine so the code emitting events
+// never waits on the network.
+type Dispatcher struct {
+	hooks  []config.WebhookConfig
+	client *http.Client
+	queue  chan delivery
+	done   chan struct{}
+	wg     sync.WaitGroup
+	// backoff is the delay before the first retry. It doubles for each further attempt.
+	backoff time.Duration
+}
+
+// Start delivers events to hooks until the returned function is called. It does nothing if no
+// webhooks are configured.
+func Start(hooks []config.WebhookConfig) func() {
+	if len(hooks) == 0 {
+		return func() {}
+	}
+	return NewDispatcher(hooks).Start()
+}
+
+// NewDispatcher creates a dispatcher for hooks. Call Start to begin delivering.
+func NewDispatcher(hooks []config.WebhookConfig) *Dispatcher {
+	return &Dispatcher{
+		hooks:   hooks,
+		client:  &http.Client{Timeout: requestTimeout},
+		queue:   make(chan delivery, queueSize),
+		done:    make(chan struct{}),
+		backoff: retryBackoff,
+	}
+}
+
+// Start subscribes to instance events and starts delivering them. It returns a function that
+// unsubscribes, delivers what is already queued and stops the dispatcher.
+func (d *Dispatcher) Start() func() {
+	d.wg.Add(1)
+	go d.run()
+	unsubscribe := session.Subscribe(d.Handle)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			unsubscribe()
+			close(d.done)
+			d.wg.Wait()
+		})
+	}
+}
+
+// Handle queues event for every webhook subscribed to its type. Events are dropped if the queue
+// is full.
+func (d *Dispatcher) Handle(event session.Event) {
+	id := newDeliveryID()
+	for _, hook := range d.hooks {
+		if !wants(hook, event.Type) {
+			continue
+		}
+		select {
+		case d.queue <- delivery{hook: hook, id: id, event: event}:
+		default:
+			log.FileOnlyWarningLog.Printf("webhook: queue full, dropping %s for %s", event.Type, hook.URL)
+		}
+	}
+}
+
+func wants(hook config.WebhookConfig, t session.EventType) bool {
+	if len(hook.Events) == 0 {
+		return true
+	}
+	for _, e := range hook.Events {
+		if e == string(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) run() {
+	defer d.wg.Done()
+	for {
+		select {
+		case dl := <-d.queue:
+			d.deliver(dl)
+		case <-d.done:
+			// Flush what is already queued, without retries, so shutdown stays quick
+			for {
+				select {
+				case dl := <-d.queue:
+					if err := d.send(dl); err != nil {
+						log.FileOnlyWarningLog.Printf("webhook: %v", err)
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends dl, retrying with exponential backoff.
+func (d *Dispatcher) deliver(dl delivery) {
+	backoff := d.backoff
+	for attempt := 1; ; attempt++ {
+		err := d.send(dl)
+		if err == nil {
+			return
+		}
+		if attempt == maxAttempts {
+			log.FileOnlyErrorLog.Printf("webhook: giving up after %d attempts: %v", attempt, err)
+			return
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-d.done:
+			log.FileOnlyWarningLog.Printf("webhook: dropping retry on shutdown: %v", err)
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) send(dl delivery) error {
+	body, err := json.Marshal(dl.event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", dl.event.Type, err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, dl.hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook url %s: %w", dl.hook.URL, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "claude-squad-webhook")
+	req.Header.Set(EventHeader, string(dl.event.Type))
+	req.Header.Set(DeliveryHeader, dl.id)
+	if dl.hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(dl.hook.Secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver %s to %s: %w", dl.event.Type, dl.hook.URL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s for %s", dl.hook.URL, resp.Status, dl.event.Type)
+	}
+	return nil
+}
+
+// Sign returns the signature header value for body: "sha256=" and the hex HMAC-SHA256 of body
+// keyed with secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid signature of body for secret.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+func newDeliveryID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,128 @@
+package webhook
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver records the deliveries a test webhook server receives.
+type receiver struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	// failures is the number of requests to answer with a 500 before succeeding
+	failures int
+	received chan struct{}
+}
+
+func newReceiver(t *testing.T, failures int) (*receiver, *httptest.Server) {
+	r := &receiver{failures: failures, received: make(chan struct{}, 16)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+		fail := r.failures > 0
+		r.failures--
+		r.mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		r.received <- struct{}{}
+	}))
+	t.Cleanup(srv.Close)
+	return r, srv
+}
+
+func (r *receiver) wait(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-r.received:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for delivery %d of %d", i+1, n)
+		}
+	}
+}
+
+func TestDispatcherSignsAndFilters(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	all, allSrv := newReceiver(t, 0)
+	created, createdSrv := newReceiver(t, 0)
+	d := NewDispatcher([]config.WebhookConfig{
+		{URL: allSrv.URL, Secret: "s3cret"},
+		{URL: createdSrv.URL, Events: []string{string(session.EventCreated)}},
+	})
+	stop := d.Start()
+
+	d.Handle(session.Event{Type: session.EventKilled, Instance: "a"})
+	d.Handle(session.Event{Type: session.EventCreated, Instance: "b"})
+	all.wait(t, 2)
+	created.wait(t, 1)
+	stop()
+
+	if len(created.requests) != 1 || created.requests[0].Header.Get(EventHeader) != string(session.EventCreated) {
+		t.Errorf("filtered webhook received %d deliveries, want only the created event", len(created.requests))
+	}
+	if got := created.requests[0].Header.Get(SignatureHeader); got != "" {
+		t.Errorf("webhook without secret got signature %q", got)
+	}
+
+	for i, req := range all.requests {
+		if !Verify("s3cret", all.bodies[i], req.Header.Get(SignatureHeader)) {
+			t.Errorf("delivery %d has invalid signature %q", i, req.Header.Get(SignatureHeader))
+		}
+		var event session.Event
+		if err := json.Unmarshal(all.bodies[i], &event); err != nil {
+			t.Fatalf("delivery %d is not an event: %v", i, err)
+		}
+		if string(event.Type) != req.Header.Get(EventHeader) {
+			t.Errorf("delivery %d header %s does not match body type %s", i, req.Header.Get(EventHeader), event.Type)
+		}
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	r, srv := newReceiver(t, 2)
+	d := NewDispatcher([]config.WebhookConfig{{URL: srv.URL}})
+	d.backoff = time.Millisecond
+	stop := d.Start()
+	defer stop()
+
+	d.Handle(session.Event{Type: session.EventPromptDetected, Instance: "a"})
+	r.wait(t, maxAttempts)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := map[string]bool{}
+	for _, req := range r.requests {
+		ids[req.Header.Get(DeliveryHeader)] = true
+	}
+	if len(ids) != 1 {
+		t.Errorf("retries used %d delivery ids, want 1", len(ids))
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"type":"instance.created"}`)
+	sig := Sign("key", body)
+	if !Verify("key", body, sig) {
+		t.Errorf("Verify rejected its own signature")
+	}
+	if Verify("other", body, sig) || Verify("key", []byte("tampered"), sig) {
+		t.Errorf("Verify accepted a signature for a different secret or body")
+	}
+}